@@ -3,21 +3,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	"github.com/segmentio/kafka-go"
 
 	"github.com/emadnahed/FastGoLink/internal/analytics"
 	"github.com/emadnahed/FastGoLink/internal/cache"
 	"github.com/emadnahed/FastGoLink/internal/config"
 	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/geo"
 	"github.com/emadnahed/FastGoLink/internal/handlers"
 	"github.com/emadnahed/FastGoLink/internal/idgen"
 	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/security"
 	"github.com/emadnahed/FastGoLink/internal/server"
 	"github.com/emadnahed/FastGoLink/internal/services"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+	"github.com/emadnahed/FastGoLink/migrations"
 	"github.com/emadnahed/FastGoLink/pkg/logger"
 )
 
@@ -34,6 +42,9 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
 
 	// Create logger
 	log := logger.New(os.Stdout, cfg.App.LogLevel)
@@ -44,6 +55,25 @@ func run() error {
 		"port", cfg.Server.Port,
 	)
 
+	// Set up tracing before any instrumented component starts
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to flush traces", "error", err.Error())
+		}
+	}()
+	if cfg.Tracing.Enabled {
+		log.Info("tracing enabled",
+			"endpoint", cfg.Tracing.Endpoint,
+			"sample_ratio", cfg.Tracing.SampleRatio,
+		)
+	}
+
 	// Create server
 	srv := server.New(cfg, log)
 
@@ -67,12 +97,32 @@ func run() error {
 		} else {
 			log.Info("database connected successfully")
 
+			if metricsHandler := srv.MetricsHandler(); metricsHandler != nil {
+				if err := metricsHandler.RegisterPoolStats(dbRouter); err != nil {
+					log.Warn("failed to register database pool metrics", "error", err.Error())
+				}
+			}
+
 			// Add database health check
-			srv.HealthHandler().AddCheck("database", func() bool {
-				ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ReadTimeout)
-				defer cancel()
-				return dbRouter.HealthCheck(ctx) == nil
-			})
+			srv.HealthHandler().AddCheck("database", dbRouter.HealthCheck)
+
+			// Stay unready until every shard's schema has been migrated
+			if cfg.Database.CheckSchemaVersion {
+				var versions []func(context.Context) (int, error)
+				expected := cfg.Database.SchemaVersion
+				for _, shard := range dbRouter.GetAllShards() {
+					migrator, err := database.NewMigrator(shard, migrations.FS, ".")
+					if err != nil {
+						return fmt.Errorf("failed to load migrations: %w", err)
+					}
+					if expected == 0 {
+						expected = migrator.LatestVersion()
+					}
+					versions = append(versions, migrator.CurrentVersion)
+				}
+				srv.HealthHandler().AddCheck("migrations", database.SchemaVersionCheck(expected, versions...))
+				log.Info("schema version check enabled", "expected_version", expected)
+			}
 
 			defer dbRouter.Close()
 		}
@@ -99,11 +149,21 @@ func run() error {
 		} else {
 			log.Info("Redis connected successfully")
 
-			// Add Redis health check
-			srv.HealthHandler().AddCheck("redis", func() bool {
-				ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ReadTimeout)
-				defer cancel()
-				return redisCache.Ping(ctx) == nil
+			// Watch Redis so lookups bypass the cache while it is down
+			redisCache.StartHealthMonitor(cfg.Redis.HealthCheckInterval, func(healthy bool, err error) {
+				if healthy {
+					log.Info("Redis recovered, resuming caching")
+				} else {
+					log.Warn("Redis unreachable, serving lookups from database", "error", err.Error())
+				}
+			})
+
+			// Report Redis health without failing readiness, since lookups fall back to the database
+			srv.HealthHandler().AddOptionalCheck("redis", func(ctx context.Context) error {
+				if !redisCache.Healthy() {
+					return errors.New("redis unreachable, cache bypassed")
+				}
+				return redisCache.Ping(ctx)
 			})
 
 			defer func() {
@@ -120,68 +180,358 @@ func run() error {
 	if dbRouter != nil {
 		// Get the database pool (using shard 0 for single-shard setup)
 		dbPool := dbRouter.GetShard("")
-		baseRepo := repository.NewPostgresURLRepository(dbPool)
+
+		var baseRepo repository.URLRepository = repository.NewPostgresURLRepository(dbPool)
+		replicaConfigs, err := cfg.Database.ReplicaConfigs()
+		if err != nil {
+			return fmt.Errorf("invalid DB_REPLICA_HOSTS: %w", err)
+		}
+		var replicaPools []*database.Pool
+		for i := range replicaConfigs {
+			replicaCfg := &replicaConfigs[i]
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ReadTimeout)
+			replicaPool, err := database.NewPool(ctx, replicaCfg)
+			cancel()
+			if err != nil {
+				log.Warn("read replica connection failed, skipping",
+					"host", replicaCfg.Host,
+					"port", replicaCfg.Port,
+					"error", err.Error(),
+				)
+				continue
+			}
+			defer replicaPool.Close()
+			replicaPools = append(replicaPools, replicaPool)
+		}
+		if len(replicaPools) > 0 {
+			baseRepo = repository.NewReadWriteURLRepository(dbPool, replicaPools...)
+			log.Info("read replicas configured", "replicas", len(replicaPools))
+		}
+
+		// Retry reads and idempotent writes that hit transient database errors
+		baseRepo = repository.NewRetryingURLRepository(baseRepo, database.DefaultRetryPolicy())
+
+		codec, err := cache.CodecByName(cfg.Cache.Codec)
+		if err != nil {
+			return fmt.Errorf("invalid CACHE_CODEC: %w", err)
+		}
 
 		var urlRepo repository.URLRepository
+		var urlCache *cache.URLCache
+		var idempotencyCache cache.Cache
 		if redisCache != nil {
 			// Create cached repository with Redis
 			log.Info("enabling repository caching",
+				"backend", "redis",
 				"key_prefix", cfg.Redis.KeyPrefix,
 				"cache_ttl", cfg.Redis.CacheTTL.String(),
+				"codec", cfg.Cache.Codec,
 			)
-			urlCache := cache.NewURLCache(redisCache, cfg.Redis.KeyPrefix, cfg.Redis.CacheTTL)
+			urlCache = cache.NewURLCacheWithCodec(redisCache, cfg.Redis.KeyPrefix, cfg.Redis.CacheTTL, codec)
 			urlRepo = repository.NewCachedURLRepository(baseRepo, urlCache, cfg.Redis.CacheTTL)
+			idempotencyCache = redisCache
 		} else {
-			// Use base repository without caching
-			urlRepo = baseRepo
+			// Fall back to a local LRU cache when Redis is unavailable
+			memoryCache := cache.NewMemoryCache(cfg.Cache.MemoryMaxEntries, cfg.Cache.MemorySweepInterval)
+			defer memoryCache.Close()
+
+			log.Info("enabling repository caching",
+				"backend", "memory",
+				"max_entries", cfg.Cache.MemoryMaxEntries,
+				"cache_ttl", cfg.Redis.CacheTTL.String(),
+				"codec", cfg.Cache.Codec,
+			)
+			urlCache = cache.NewURLCacheWithCodec(memoryCache, cfg.Redis.KeyPrefix, cfg.Redis.CacheTTL, codec)
+			urlRepo = repository.NewCachedURLRepository(baseRepo, urlCache, cfg.Redis.CacheTTL)
+
+			// Idempotency keys get their own LRU, so a burst of URL lookups
+			// can't evict a response a client is about to retry for
+			if cfg.Idempotency.Enabled {
+				idempotencyMemory := cache.NewMemoryCache(cfg.Cache.MemoryMaxEntries, cfg.Cache.MemorySweepInterval)
+				defer idempotencyMemory.Close()
+				idempotencyCache = idempotencyMemory
+			}
 		}
 
 		srv.SetURLRepository(urlRepo)
 		log.Info("URL repository configured")
 
+		if metricsHandler := srv.MetricsHandler(); metricsHandler != nil {
+			if cachedRepo, ok := urlRepo.(*repository.CachedURLRepository); ok {
+				if err := metricsHandler.RegisterCacheStats(cachedRepo); err != nil {
+					log.Warn("failed to register cache metrics", "error", err.Error())
+				}
+			}
+		}
+
 		// Create ID generator with collision detection
-		baseGen := idgen.NewRandomGenerator(cfg.URL.ShortCodeLen)
-		collisionGen := idgen.NewCollisionAwareGenerator(baseGen, urlRepo, cfg.URL.IDGenMaxRetries)
+		genCfg := idgen.GeneratorConfig{
+			CodeLength: cfg.URL.ShortCodeLen,
+			NodeID:     int64(cfg.URL.SnowflakeMachineID),
+			Epoch:      cfg.URL.SnowflakeEpoch,
+			Logger:     log,
+		}
+		if cfg.URL.IDGenStrategy == idgen.StrategySequential {
+			genCfg.Counter = repository.NewPostgresCounterSource(dbPool, repository.ShortCodeSequence)
+		}
+		baseGen, err := idgen.NewGenerator(cfg.URL.IDGenStrategy, genCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create ID generator: %w", err)
+		}
+		if cfg.URL.IDGenStrategy == idgen.StrategySnowflake {
+			// Creates start failing once codes outgrow the short_code column
+			until := idgen.SnowflakeCodesFitUntil(cfg.URL.SnowflakeEpoch, cfg.URL.MaxShortCodeLen)
+			if time.Until(until) < 365*24*time.Hour {
+				log.Warn("snowflake codes will soon outgrow URL_MAX_SHORT_CODE_LEN",
+					"until", until.Format(time.RFC3339),
+					"max_short_code_len", cfg.URL.MaxShortCodeLen,
+				)
+			}
+		}
+		collisionGen := idgen.NewCollisionAwareGeneratorWithOptions(baseGen, urlRepo, idgen.CollisionOptions{
+			MaxRetries:      cfg.URL.IDGenMaxRetries,
+			Backoff:         cfg.URL.IDGenRetryBackoff,
+			MaxBackoff:      cfg.URL.IDGenMaxBackoff,
+			LengthBumpAfter: cfg.URL.IDGenLengthBumpAfter,
+			MaxLength:       cfg.URL.MaxShortCodeLen,
+			Reserved:        cfg.URL.ReservedCodesList(),
+		})
+		if metricsHandler := srv.MetricsHandler(); metricsHandler != nil {
+			if err := metricsHandler.RegisterGeneratorStats(collisionGen); err != nil {
+				log.Warn("failed to register ID generator metrics", "error", err.Error())
+			}
+		}
 
 		// Create URL sanitizer with security config
 		sanitizer := security.NewSanitizer(security.Config{
 			MaxURLLength:    cfg.Security.MaxURLLength,
 			AllowPrivateIPs: cfg.Security.AllowPrivateIPs,
 			BlockedHosts:    cfg.Security.BlockedHostsList(),
+			AllowedHosts:    cfg.Security.AllowedHostsList(),
+			AllowedSchemes:  cfg.Security.AllowedSchemesList(),
+			ResolveHosts:    cfg.Security.ResolveHosts,
+			ResolveTimeout:  cfg.Security.ResolveTimeout,
 		})
 
+		// Send link events to the webhook endpoint, if any; queued events
+		// get until the shutdown timeout to be delivered
+		var webhookNotifier *services.WebhookNotifier
+		var clickMilestones *services.ClickMilestoneNotifier
+		if cfg.Webhook.Enabled() {
+			webhookNotifier = services.NewWebhookNotifier(services.WebhookConfig{
+				URL:        cfg.Webhook.URL,
+				Secret:     cfg.Webhook.Secret,
+				Timeout:    cfg.Webhook.Timeout,
+				MaxRetries: cfg.Webhook.MaxRetries,
+			}, log)
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+				defer cancel()
+				if err := webhookNotifier.Close(ctx); err != nil {
+					log.Warn("undelivered webhook events dropped", "error", err.Error())
+				}
+			}()
+			milestones, _ := cfg.Webhook.ClickMilestoneList() // Checked by Validate
+			clickMilestones = services.NewClickMilestoneNotifier(urlRepo, webhookNotifier, milestones)
+			log.Info("link event webhooks enabled",
+				"signed", cfg.Webhook.Secret != "",
+				"click_milestones", cfg.Webhook.ClickMilestones,
+			)
+		}
+
 		// Create URL service and handler
-		urlService := services.NewURLServiceWithSanitizer(urlRepo, collisionGen, sanitizer, cfg.URL.BaseURL)
+		urlService := services.NewURLServiceWithConfig(urlRepo, collisionGen, sanitizer, cfg.PublicBaseURL(), services.URLServiceConfig{
+			AliasMinLength: cfg.URL.AliasMinLength,
+			AliasMaxLength: cfg.URL.AliasMaxLength,
+			ReservedCodes:  cfg.URL.ReservedCodesList(),
+			Normalize: security.NormalizeOptions{
+				StripTrailingSlash: cfg.URL.NormalizeStripTrailingSlash,
+				StripFragment:      cfg.URL.NormalizeStripFragment,
+			},
+			Dedupe:           cfg.URL.Dedupe,
+			DefaultExpiry:    cfg.URL.DefaultExpiry,
+			MaxExpiry:        cfg.URL.MaxExpiry,
+			CreateMaxRetries: cfg.URL.CreateMaxRetries,
+			MaxShortCodeLen:  cfg.URL.MaxShortCodeLen,
+		})
+		if webhookNotifier != nil {
+			urlService.SetNotifier(webhookNotifier)
+		}
 		urlHandler := handlers.NewURLHandler(urlService)
+		if cfg.Idempotency.Enabled {
+			// With the memory cache, keys only hold for this instance
+			store := handlers.NewIdempotencyStore(idempotencyCache, cfg.Redis.KeyPrefix, cfg.Idempotency.TTL)
+			urlHandler = handlers.NewURLHandlerWithIdempotency(urlService, store)
+			log.Info("idempotency keys enabled", "ttl", cfg.Idempotency.TTL.String())
+		}
+		if domains := cfg.URL.ShortDomainsList(); len(domains) > 0 {
+			urlHandler.SetShortDomains(cfg.PublicBaseURL(), domains)
+			log.Info("short domains configured", "domains", cfg.URL.ShortDomains)
+		}
 		srv.SetURLHandler(urlHandler)
+		srv.SetQRHandler(handlers.NewQRHandler(urlService, cfg.PublicBaseURL()))
 		log.Info("URL shortening API configured",
-			"base_url", cfg.URL.BaseURL,
+			"base_url", cfg.PublicBaseURL(),
 			"code_length", cfg.URL.ShortCodeLen,
+			"idgen_strategy", cfg.URL.IDGenStrategy,
+			"dedupe_urls", cfg.URL.Dedupe,
 			"max_url_length", cfg.Security.MaxURLLength,
 			"allow_private_ips", cfg.Security.AllowPrivateIPs,
+			"resolve_hosts", cfg.Security.ResolveHosts,
 		)
 
 		// Create click analytics counter with async batch processing
-		clickFlusher := analytics.NewRepositoryFlusher(urlRepo, log)
+		clickBucketRepo := repository.NewPostgresClickBucketRepository(dbPool)
+		clickSourceRepo := repository.NewPostgresClickSourceRepository(dbPool)
+		clickCountryRepo := repository.NewPostgresClickCountryRepository(dbPool)
+		var clickRepo analytics.ClickRepository = urlRepo
+		if clickMilestones != nil {
+			clickRepo = clickMilestones
+		}
+		clickFlusher := analytics.NewRepositoryFlusherWithGeo(clickRepo, clickBucketRepo, clickSourceRepo, clickCountryRepo, log)
+		if cfg.Analytics.ArchiveInterval > 0 {
+			clickFlusher.SetArchive(repository.NewPostgresClickArchiveRepository(dbPool), analytics.ArchiveConfig{
+				Interval:  cfg.Analytics.ArchiveInterval,
+				Threshold: int64(cfg.Analytics.ArchiveThreshold),
+			})
+			log.Info("click archiving enabled",
+				"interval", cfg.Analytics.ArchiveInterval.String(),
+				"threshold", cfg.Analytics.ArchiveThreshold,
+			)
+		}
+		// External sinks receive the same flushed counts as the database
+		var flusher analytics.Flusher = clickFlusher
+		if sinks := cfg.Analytics.SinksList(); len(sinks) > 0 {
+			flushers := []analytics.Flusher{clickFlusher}
+			for _, sink := range sinks {
+				switch sink {
+				case "http":
+					client := &http.Client{Timeout: cfg.Analytics.HTTPSinkTimeout}
+					flushers = append(flushers, analytics.NewHTTPFlusher(cfg.Analytics.HTTPSinkURL, client, log))
+				case "kafka":
+					writer := &kafka.Writer{
+						Addr:     kafka.TCP(cfg.Analytics.KafkaBrokersList()...),
+						Topic:    cfg.Analytics.KafkaTopic,
+						Balancer: &kafka.Hash{},
+					}
+					// Deferred before the counter's Stop, so it closes after the final flush
+					defer writer.Close()
+					flushers = append(flushers, analytics.NewKafkaFlusher(writer, log))
+				}
+			}
+			flusher = analytics.NewMultiFlusher(flushers...)
+			log.Info("analytics sinks enabled", "sinks", cfg.Analytics.Sinks)
+		}
 		clickCounterConfig := analytics.DefaultConfig()
-		clickCounter := analytics.NewClickCounter(clickCounterConfig, clickFlusher)
+		clickCounterConfig.JournalInterval = cfg.Analytics.JournalInterval
+		clickCounterConfig.BlockOnFull = cfg.Analytics.BlockOnFull
+		var clickCounter *analytics.ClickCounter
+		if cfg.Analytics.JournalPath != "" {
+			journal := analytics.NewFileJournal(cfg.Analytics.JournalPath)
+			clickCounter, err = analytics.NewClickCounterWithJournal(clickCounterConfig, flusher, journal)
+			if err != nil {
+				return fmt.Errorf("failed to load click journal: %w", err)
+			}
+			log.Info("click journal enabled",
+				"path", cfg.Analytics.JournalPath,
+				"interval", cfg.Analytics.JournalInterval.String(),
+			)
+		} else {
+			clickCounter = analytics.NewClickCounter(clickCounterConfig, flusher)
+		}
+		// Shutdown flushes the counter before the database closes; the
+		// deferred Stop covers early returns
 		defer clickCounter.Stop()
+		srv.SetClickCounter(clickCounter)
+		// Dropped clicks degrade the service without making it unready
+		srv.HealthHandler().AddOptionalCheck("click_counter", clickCounter.HealthCheck)
+		if metricsHandler := srv.MetricsHandler(); metricsHandler != nil {
+			if err := metricsHandler.RegisterPendingClicks(clickCounter); err != nil {
+				log.Warn("failed to register click metrics", "error", err.Error())
+			}
+			if err := metricsHandler.RegisterClickStats(clickCounter); err != nil {
+				log.Warn("failed to register click buffer metrics", "error", err.Error())
+			}
+		}
 		log.Info("click analytics configured",
 			"flush_interval", clickCounterConfig.FlushInterval.String(),
 			"batch_size", clickCounterConfig.BatchSize,
+			"block_on_full", clickCounterConfig.BlockOnFull,
 		)
 
+		// Resolve click countries when a GeoIP database is configured
+		var geoResolver geo.Resolver
+		if cfg.GeoIP.DBPath != "" {
+			resolver, err := geo.OpenMaxMind(cfg.GeoIP.DBPath)
+			if err != nil {
+				return fmt.Errorf("failed to load GeoIP database: %w", err)
+			}
+			geoResolver = resolver
+			log.Info("GeoIP country analytics enabled", "db_path", cfg.GeoIP.DBPath)
+		}
+
 		// Create redirect service with analytics
-		redirectService := services.NewRedirectServiceWithAnalytics(urlRepo, clickCounter)
-		redirectHandler := handlers.NewRedirectHandler(redirectService)
+		redirectService := services.NewRedirectServiceWithGeo(urlRepo, clickCounter, geoResolver)
+		redirectService.SetClickCountMode(services.ClickCountMode(cfg.Analytics.ClickCountMode))
+		if cfg.Analytics.ClickCountMode != string(services.ClickCountBatched) {
+			log.Info("click count mode configured", "mode", cfg.Analytics.ClickCountMode)
+		}
+		if clickMilestones != nil {
+			redirectService.SetClickMilestones(clickMilestones)
+		}
+		redirectConfig := handlers.RedirectConfig{
+			RootURL:     cfg.URL.RootRedirectURL,
+			NotFoundURL: cfg.URL.NotFoundURL,
+			ExpiredURL:  cfg.URL.ExpiredURL,
+		}
+		if cfg.URL.NotFoundPage != "" {
+			redirectConfig.NotFoundPage, err = os.ReadFile(cfg.URL.NotFoundPage)
+			if err != nil {
+				return fmt.Errorf("failed to read not-found page: %w", err)
+			}
+		}
+		redirectHandler := handlers.NewRedirectHandlerWithConfig(redirectService, redirectConfig)
 		srv.SetRedirectHandler(redirectHandler)
 		log.Info("URL redirect handler configured")
 
 		// Create analytics service and handler
-		analyticsService := services.NewAnalyticsServiceWithPendingStats(urlRepo, clickCounter)
+		analyticsService := services.NewAnalyticsServiceWithGeo(urlRepo, clickCounter, clickBucketRepo, clickSourceRepo, clickCountryRepo)
 		analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 		srv.SetAnalyticsHandler(analyticsHandler)
 		log.Info("analytics API configured")
+
+		// Periodically delete expired URLs; cancelled on shutdown
+		if cfg.Reaper.Enabled {
+			reaperCtx, stopReaper := context.WithCancel(context.Background())
+			defer stopReaper()
+			reaper := services.NewReaper(urlRepo, cfg.Reaper.Interval, log)
+			if webhookNotifier != nil {
+				reaper.SetNotifier(webhookNotifier)
+			}
+			go reaper.Run(reaperCtx)
+			log.Info("expired URL reaper enabled", "interval", cfg.Reaper.Interval.String())
+		}
+
+		// Periodically probe destinations for dead links; cancelled on shutdown
+		if cfg.LinkCheck.Enabled {
+			linkCheckerCtx, stopLinkChecker := context.WithCancel(context.Background())
+			defer stopLinkChecker()
+			linkChecker := services.NewLinkChecker(repository.NewCachedLinkStatusRepository(repository.NewPostgresLinkStatusRepository(dbPool), urlCache), services.LinkCheckerConfig{
+				Interval:     cfg.LinkCheck.Interval,
+				RecheckAfter: cfg.LinkCheck.RecheckAfter,
+				BatchSize:    cfg.LinkCheck.BatchSize,
+				Concurrency:  cfg.LinkCheck.Concurrency,
+				RateLimit:    cfg.LinkCheck.RateLimit,
+				Timeout:      cfg.LinkCheck.Timeout,
+
+				AllowPrivateIPs: cfg.Security.AllowPrivateIPs,
+			}, log)
+			go linkChecker.Run(linkCheckerCtx)
+			log.Info("link checker enabled",
+				"interval", cfg.LinkCheck.Interval.String(),
+				"concurrency", cfg.LinkCheck.Concurrency,
+			)
+		}
 	}
 
 	// Handle graceful shutdown
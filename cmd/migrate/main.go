@@ -0,0 +1,119 @@
+// Package main is the entry point for the FastGoLink migration tool.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/emadnahed/FastGoLink/internal/config"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/migrations"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up            Apply all pending migrations
+  down [N]      Roll back the last N migrations (default 1)
+  to VERSION    Apply or roll back migrations to reach VERSION (0 rolls back everything)
+  status        Show the current version and pending migrations
+
+The database is configured with the same DB_* environment variables as the API server.
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("missing command")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if !cfg.DatabaseEnabled() {
+		return errors.New("database not configured: set DB_HOST and DB_PASSWORD")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := database.NewPool(ctx, &cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	migrator, err := database.NewMigrator(pool, migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			steps, err = strconv.Atoi(rest[0])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count %q: must be a positive integer", rest[0])
+			}
+		}
+		rolledBack, err := migrator.DownN(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d migration(s)\n", rolledBack)
+
+	case "to":
+		if len(rest) == 0 {
+			return errors.New("to requires a target version")
+		}
+		target, err := strconv.Atoi(rest[0])
+		if err != nil || target < 0 {
+			return fmt.Errorf("invalid version %q: must be a non-negative integer", rest[0])
+		}
+		changed, err := migrator.MigrateTo(ctx, target)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("migrated to version %d (%d migration(s) changed)\n", target, changed)
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current version: %d\n", status.CurrentVersion)
+		if len(status.Pending) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("pending: %03d %s\n", m.Version, m.Name)
+		}
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package analytics
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,20 +15,81 @@ type Flusher interface {
 	FlushClicks(ctx context.Context, counts map[string]int64) error
 }
 
+// BucketWidth is the time window clicks are grouped into for time-series
+// analytics. Coarser intervals such as days are summed from these buckets.
+const BucketWidth = time.Hour
+
+// BucketKey identifies the clicks of one short code within one time window.
+type BucketKey struct {
+	ShortCode string
+	Start     time.Time // Start of the window in UTC, aligned to BucketWidth
+}
+
+// BucketFlusher is implemented by Flushers that also persist time-bucketed
+// click counts. ClickCounter only tracks buckets when its flusher implements it.
+type BucketFlusher interface {
+	FlushClickBuckets(ctx context.Context, buckets map[BucketKey]int64) error
+}
+
+// bucketStart returns the start of the window containing t.
+func bucketStart(t time.Time) time.Time {
+	return t.UTC().Truncate(BucketWidth)
+}
+
+// click is a single recorded redirect.
+type click struct {
+	shortCode string
+	at        time.Time
+	source    ClickSource
+}
+
+// flushRequest asks the run loop for a synchronous flush.
+type flushRequest struct {
+	ctx  context.Context
+	done chan error
+}
+
 // Config holds configuration for the ClickCounter.
 type Config struct {
-	FlushInterval time.Duration // How often to flush accumulated counts
-	BatchSize     int           // Flush when this many clicks accumulated
-	ChannelBuffer int           // Size of the click channel buffer
+	FlushInterval   time.Duration // How often to flush accumulated counts
+	BatchSize       int           // Flush when this many clicks accumulated
+	ChannelBuffer   int           // Size of the click channel buffer
+	JournalInterval time.Duration // How often to save pending counts to the journal, if any
+
+	// BlockOnFull makes RecordClick wait for room when the channel buffer is
+	// full instead of dropping the click, slowing redirects down to the rate
+	// clicks can be aggregated. A click whose context ends while waiting is
+	// dropped.
+	BlockOnFull bool
+
+	// DropWindow is how long HealthCheck keeps reporting a dropped click.
+	DropWindow time.Duration
 }
 
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	return Config{
-		FlushInterval: 10 * time.Second,
-		BatchSize:     100,
-		ChannelBuffer: 10000,
+		FlushInterval:   10 * time.Second,
+		BatchSize:       100,
+		ChannelBuffer:   10000,
+		JournalInterval: time.Second,
+		DropWindow:      time.Minute,
+	}
+}
+
+// Stats describes the click channel's backpressure.
+type Stats struct {
+	Dropped  int64 // Clicks dropped because the channel buffer was full
+	Buffered int   // Clicks waiting in the channel buffer
+	Capacity int   // Size of the channel buffer
+}
+
+// Utilization returns the fraction of the channel buffer in use, from 0 to 1.
+func (s Stats) Utilization() float64 {
+	if s.Capacity == 0 {
+		return 0
 	}
+	return float64(s.Buffered) / float64(s.Capacity)
 }
 
 // ClickCounter provides non-blocking, batched click counting.
@@ -34,50 +97,160 @@ type ClickCounter struct {
 	flusher Flusher
 	cfg     Config
 
-	clickChan    chan string
+	clickChan    chan click
 	counts       map[string]int64
+	buckets      map[BucketKey]int64   // nil unless flusher is a BucketFlusher
+	referrers    map[ReferrerKey]int64 // nil unless flusher is a SourceFlusher
+	agents       map[AgentKey]int64    // nil unless flusher is a SourceFlusher
+	countries    map[CountryKey]int64  // nil unless flusher is a GeoFlusher
 	countsMu     sync.Mutex
 	pendingCount int64 // total pending clicks (for batch size check)
+	now          func() time.Time
+
+	journal      Journal // nil unless created with NewClickCounterWithJournal
+	journalDirty bool    // counts changed since the last journal save; run loop only
+
+	flushChan chan flushRequest
 
 	stopOnce sync.Once
 	stopChan chan struct{}
 	doneChan chan struct{}
 	stopped  atomic.Bool
+
+	dropped    atomic.Int64
+	lastDropAt atomic.Int64 // Unix nanoseconds of the latest drop, 0 if none
 }
 
 // NewClickCounter creates a new ClickCounter instance.
 func NewClickCounter(cfg Config, flusher Flusher) *ClickCounter {
+	c := newClickCounter(cfg, flusher)
+	go c.run()
+	return c
+}
+
+// NewClickCounterWithJournal creates a ClickCounter that saves its pending
+// counts to journal every JournalInterval. Counts left in the journal by a
+// previous process, such as one that crashed, are loaded and flushed again.
+//
+// A crash between a flush and the next journal save replays the flushed
+// clicks, so journaled clicks are counted at least once rather than at most once.
+func NewClickCounterWithJournal(cfg Config, flusher Flusher, journal Journal) (*ClickCounter, error) {
+	recovered, err := journal.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	c := newClickCounter(cfg, flusher)
+	c.journal = journal
+	for code, count := range recovered {
+		c.counts[code] += count
+		c.pendingCount += count
+	}
+
+	go c.run()
+	return c, nil
+}
+
+func newClickCounter(cfg Config, flusher Flusher) *ClickCounter {
 	if cfg.ChannelBuffer <= 0 {
 		cfg.ChannelBuffer = DefaultConfig().ChannelBuffer
 	}
+	if cfg.JournalInterval <= 0 {
+		cfg.JournalInterval = DefaultConfig().JournalInterval
+	}
+	if cfg.DropWindow <= 0 {
+		cfg.DropWindow = DefaultConfig().DropWindow
+	}
 
 	c := &ClickCounter{
 		flusher:   flusher,
 		cfg:       cfg,
-		clickChan: make(chan string, cfg.ChannelBuffer),
+		clickChan: make(chan click, cfg.ChannelBuffer),
 		counts:    make(map[string]int64),
+		now:       time.Now,
+		flushChan: make(chan flushRequest),
 		stopChan:  make(chan struct{}),
 		doneChan:  make(chan struct{}),
 	}
-
-	go c.run()
+	if _, ok := flusher.(BucketFlusher); ok {
+		c.buckets = make(map[BucketKey]int64)
+	}
+	if _, ok := flusher.(SourceFlusher); ok {
+		c.referrers = make(map[ReferrerKey]int64)
+		c.agents = make(map[AgentKey]int64)
+	}
+	if _, ok := flusher.(GeoFlusher); ok {
+		c.countries = make(map[CountryKey]int64)
+	}
 	return c
 }
 
-// RecordClick records a click for a short code (non-blocking).
-func (c *ClickCounter) RecordClick(shortCode string) {
+// RecordClick records a click for a short code (non-blocking, unless
+// BlockOnFull is set).
+func (c *ClickCounter) RecordClick(ctx context.Context, shortCode string) {
+	c.RecordClickFrom(ctx, shortCode, ClickSource{})
+}
+
+// RecordClickFrom records a click along with where it came from
+// (non-blocking, unless BlockOnFull is set). The headers are parsed off the
+// request path, when the click is aggregated. Clicks without a country are
+// not counted per country.
+func (c *ClickCounter) RecordClickFrom(ctx context.Context, shortCode string, src ClickSource) {
 	if c.stopped.Load() {
 		return
 	}
 
+	clk := click{shortCode: shortCode, at: c.now(), source: src}
+
+	if c.cfg.BlockOnFull {
+		select {
+		case c.clickChan <- clk:
+		case <-ctx.Done():
+			c.drop()
+		case <-c.stopChan:
+		}
+		return
+	}
+
 	// Non-blocking send - drop if buffer is full
 	select {
-	case c.clickChan <- shortCode:
+	case c.clickChan <- clk:
 	default:
 		// Channel full, click dropped (acceptable for analytics)
+		c.drop()
 	}
 }
 
+// drop counts a click that could not be recorded.
+func (c *ClickCounter) drop() {
+	c.dropped.Add(1)
+	c.lastDropAt.Store(c.now().UnixNano())
+}
+
+// GetStats returns the click channel's dropped count and utilization.
+func (c *ClickCounter) GetStats() Stats {
+	return Stats{
+		Dropped:  c.dropped.Load(),
+		Buffered: len(c.clickChan),
+		Capacity: cap(c.clickChan),
+	}
+}
+
+// HealthCheck reports an error when a click was dropped within the last
+// DropWindow, meaning the channel buffer is too small for the current load.
+// It only reads state, so any number of probes see the same result.
+func (c *ClickCounter) HealthCheck(ctx context.Context) error {
+	last := c.lastDropAt.Load()
+	if last == 0 {
+		return nil
+	}
+	if since := c.now().Sub(time.Unix(0, last)); since < c.cfg.DropWindow {
+		return fmt.Errorf("click dropped %s ago, %d in total: click buffer full",
+			since.Round(time.Second), c.dropped.Load())
+	}
+	return nil
+}
+
 // Stop stops the click counter and flushes remaining counts.
 func (c *ClickCounter) Stop() {
 	c.stopOnce.Do(func() {
@@ -87,6 +260,26 @@ func (c *ClickCounter) Stop() {
 	})
 }
 
+// Flush synchronously flushes all clicks recorded so far and returns the
+// flusher's errors. After Stop it returns nil, as Stop already flushed.
+func (c *ClickCounter) Flush(ctx context.Context) error {
+	req := flushRequest{ctx: ctx, done: make(chan error, 1)}
+	select {
+	case c.flushChan <- req:
+	case <-c.doneChan:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GetPendingStats returns a snapshot of pending (unflushed) click counts.
 func (c *ClickCounter) GetPendingStats() map[string]int64 {
 	c.countsMu.Lock()
@@ -106,27 +299,43 @@ func (c *ClickCounter) run() {
 	ticker := time.NewTicker(c.cfg.FlushInterval)
 	defer ticker.Stop()
 
+	// A nil channel never fires, disabling journal saves without a journal
+	var journalTick <-chan time.Time
+	if c.journal != nil {
+		journalTicker := time.NewTicker(c.cfg.JournalInterval)
+		defer journalTicker.Stop()
+		journalTick = journalTicker.C
+		c.journalDirty = c.pendingCount > 0
+	}
+
 	for {
 		select {
-		case shortCode := <-c.clickChan:
+		case clk := <-c.clickChan:
 			c.countsMu.Lock()
-			c.counts[shortCode]++
-			c.pendingCount++
+			c.add(clk)
 			shouldFlush := int(c.pendingCount) >= c.cfg.BatchSize
 			c.countsMu.Unlock()
+			c.journalDirty = true
 
 			if shouldFlush {
-				c.flush()
+				c.flushWithTimeout()
 			}
 
 		case <-ticker.C:
-			c.flush()
+			c.flushWithTimeout()
+
+		case <-journalTick:
+			c.saveJournal()
+
+		case req := <-c.flushChan:
+			c.drainChannel()
+			req.done <- c.flush(req.ctx)
 
 		case <-c.stopChan:
 			// Drain remaining clicks from channel
 			c.drainChannel()
 			// Final flush
-			c.flush()
+			c.flushWithTimeout()
 			return
 		}
 	}
@@ -136,34 +345,99 @@ func (c *ClickCounter) run() {
 func (c *ClickCounter) drainChannel() {
 	for {
 		select {
-		case shortCode := <-c.clickChan:
+		case clk := <-c.clickChan:
 			c.countsMu.Lock()
-			c.counts[shortCode]++
-			c.pendingCount++
+			c.add(clk)
 			c.countsMu.Unlock()
+			c.journalDirty = true
 		default:
 			return
 		}
 	}
 }
 
-// flush sends accumulated counts to the flusher and resets.
-func (c *ClickCounter) flush() {
+// add accumulates a click. The caller must hold countsMu.
+func (c *ClickCounter) add(clk click) {
+	c.counts[clk.shortCode]++
+	if c.buckets != nil {
+		c.buckets[BucketKey{ShortCode: clk.shortCode, Start: bucketStart(clk.at)}]++
+	}
+	if c.referrers != nil {
+		c.referrers[ReferrerKey{ShortCode: clk.shortCode, Referrer: ReferrerHost(clk.source.Referrer)}]++
+		browser, os := ParseUserAgent(clk.source.UserAgent)
+		c.agents[AgentKey{ShortCode: clk.shortCode, Browser: browser, OS: os}]++
+	}
+	if c.countries != nil && clk.source.Country != "" {
+		c.countries[CountryKey{ShortCode: clk.shortCode, Country: clk.source.Country}]++
+	}
+	c.pendingCount++
+}
+
+// flushWithTimeout flushes in the background loop, where flusher errors
+// have already been logged by the flusher.
+func (c *ClickCounter) flushWithTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = c.flush(ctx)
+}
+
+// flush sends accumulated counts to the flusher and resets. It must only be
+// called from the run loop.
+func (c *ClickCounter) flush(ctx context.Context) error {
 	c.countsMu.Lock()
 	if len(c.counts) == 0 {
 		c.countsMu.Unlock()
-		return
+		return nil
 	}
 
 	// Swap maps for minimal lock time
 	toFlush := c.counts
 	c.counts = make(map[string]int64)
+	bucketsToFlush := c.buckets
+	if c.buckets != nil {
+		c.buckets = make(map[BucketKey]int64)
+	}
+	referrersToFlush, agentsToFlush := c.referrers, c.agents
+	if c.referrers != nil {
+		c.referrers = make(map[ReferrerKey]int64)
+		c.agents = make(map[AgentKey]int64)
+	}
+	countriesToFlush := c.countries
+	if c.countries != nil {
+		c.countries = make(map[CountryKey]int64)
+	}
 	c.pendingCount = 0
 	c.countsMu.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Failed flushes are not retried; clicks are dropped like a full buffer
+	errs := []error{c.flusher.FlushClicks(ctx, toFlush)}
+	if bf, ok := c.flusher.(BucketFlusher); ok {
+		errs = append(errs, bf.FlushClickBuckets(ctx, bucketsToFlush))
+	}
+	if sf, ok := c.flusher.(SourceFlusher); ok {
+		errs = append(errs, sf.FlushClickSources(ctx, referrersToFlush, agentsToFlush))
+	}
+	if gf, ok := c.flusher.(GeoFlusher); ok {
+		errs = append(errs, gf.FlushClickCountries(ctx, countriesToFlush))
+	}
+
+	// The flushed clicks no longer need to be replayed after a crash
+	c.journalDirty = true
+	c.saveJournal()
 
-	// Fire and forget - errors are logged but don't block
-	_ = c.flusher.FlushClicks(ctx, toFlush)
+	return errors.Join(errs...)
+}
+
+// saveJournal saves the pending counts to the journal if they changed since
+// the last save. It must only be called from the run loop.
+func (c *ClickCounter) saveJournal() {
+	if c.journal == nil || !c.journalDirty {
+		return
+	}
+
+	// On failure the journal stays dirty, so the next tick retries
+	if err := c.journal.Save(c.GetPendingStats()); err == nil {
+		c.journalDirty = false
+	}
 }
@@ -2,12 +2,16 @@ package analytics
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockFlusher is a mock implementation of the Flusher interface.
@@ -58,9 +62,9 @@ func TestClickCounter_RecordClick(t *testing.T) {
 		}, flusher)
 		defer counter.Stop()
 
-		counter.RecordClick("abc123")
-		counter.RecordClick("abc123")
-		counter.RecordClick("xyz789")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "xyz789")
 
 		// Wait for flush
 		time.Sleep(100 * time.Millisecond)
@@ -80,7 +84,7 @@ func TestClickCounter_RecordClick(t *testing.T) {
 
 		// Record many clicks
 		for i := 0; i < 100; i++ {
-			counter.RecordClick("abc123")
+			counter.RecordClick(context.Background(), "abc123")
 		}
 
 		// Wait for flush
@@ -100,7 +104,7 @@ func TestClickCounter_RecordClick(t *testing.T) {
 
 		// Record enough clicks to trigger batch flush
 		for i := 0; i < 15; i++ {
-			counter.RecordClick("abc123")
+			counter.RecordClick(context.Background(), "abc123")
 		}
 
 		// Give time for batch flush
@@ -119,9 +123,9 @@ func TestClickCounter_Stop(t *testing.T) {
 			BatchSize:     1000,             // Large batch
 		}, flusher)
 
-		counter.RecordClick("abc123")
-		counter.RecordClick("abc123")
-		counter.RecordClick("xyz789")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "xyz789")
 
 		// Stop should flush remaining
 		counter.Stop()
@@ -138,7 +142,7 @@ func TestClickCounter_Stop(t *testing.T) {
 			BatchSize:     100,
 		}, flusher)
 
-		counter.RecordClick("abc123")
+		counter.RecordClick(context.Background(), "abc123")
 
 		// Should not panic
 		counter.Stop()
@@ -153,12 +157,12 @@ func TestClickCounter_Stop(t *testing.T) {
 			BatchSize:     1000,
 		}, flusher)
 
-		counter.RecordClick("before-stop")
+		counter.RecordClick(context.Background(), "before-stop")
 		counter.Stop()
 
 		// These should be ignored
-		counter.RecordClick("after-stop")
-		counter.RecordClick("after-stop")
+		counter.RecordClick(context.Background(), "after-stop")
+		counter.RecordClick(context.Background(), "after-stop")
 
 		counts := flusher.getCounts()
 		assert.Equal(t, int64(1), counts["before-stop"])
@@ -166,6 +170,147 @@ func TestClickCounter_Stop(t *testing.T) {
 	})
 }
 
+func TestClickCounter_Flush(t *testing.T) {
+	t.Run("flushes pending clicks synchronously", func(t *testing.T) {
+		flusher := newMockFlusher()
+		counter := NewClickCounter(Config{
+			FlushInterval: time.Hour,
+			BatchSize:     1000,
+		}, flusher)
+		defer counter.Stop()
+
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "abc123")
+
+		require.NoError(t, counter.Flush(context.Background()))
+
+		assert.Equal(t, int64(2), flusher.getCounts()["abc123"])
+		assert.Empty(t, counter.GetPendingStats())
+	})
+
+	t.Run("returns flusher errors", func(t *testing.T) {
+		counter := NewClickCounter(Config{
+			FlushInterval: time.Hour,
+			BatchSize:     1000,
+		}, failingFlusher{})
+		defer counter.Stop()
+
+		counter.RecordClick(context.Background(), "abc123")
+
+		assert.Error(t, counter.Flush(context.Background()))
+	})
+
+	t.Run("is a no-op after stop", func(t *testing.T) {
+		flusher := newMockFlusher()
+		counter := NewClickCounter(Config{
+			FlushInterval: time.Hour,
+			BatchSize:     1000,
+		}, flusher)
+
+		counter.RecordClick(context.Background(), "abc123")
+		counter.Stop()
+
+		assert.NoError(t, counter.Flush(context.Background()))
+		assert.Equal(t, int64(1), flusher.getCounts()["abc123"])
+	})
+
+	t.Run("honors context cancellation", func(t *testing.T) {
+		flusher := &blockingFlusher{release: make(chan struct{})}
+		counter := NewClickCounter(Config{
+			FlushInterval: time.Hour,
+			BatchSize:     1000,
+		}, flusher)
+		defer counter.Stop()
+		defer close(flusher.release)
+
+		counter.RecordClick(context.Background(), "abc123")
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, counter.Flush(ctx), context.DeadlineExceeded)
+	})
+}
+
+// failingFlusher fails every flush.
+type failingFlusher struct{}
+
+func (failingFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
+	return errors.New("database unavailable")
+}
+
+// blockingFlusher blocks every flush until release is closed.
+type blockingFlusher struct {
+	release chan struct{}
+}
+
+func (b *blockingFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
+	<-b.release
+	return nil
+}
+
+func TestClickCounter_Journal(t *testing.T) {
+	cfg := Config{
+		FlushInterval:   time.Hour,
+		BatchSize:       1000,
+		JournalInterval: 10 * time.Millisecond,
+	}
+
+	t.Run("recovers unflushed clicks after a crash", func(t *testing.T) {
+		journal := NewFileJournal(filepath.Join(t.TempDir(), "clicks.journal"))
+
+		// The first process records clicks and dies before flushing them
+		crashed := newMockFlusher()
+		counter, err := NewClickCounterWithJournal(cfg, crashed, journal)
+		require.NoError(t, err)
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "xyz789")
+		assert.Eventually(t, func() bool {
+			counts, err := journal.Load()
+			return err == nil && counts["abc123"] == 2 && counts["xyz789"] == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Empty(t, crashed.getCounts())
+
+		// The next process flushes the journaled clicks
+		flusher := newMockFlusher()
+		recovered, err := NewClickCounterWithJournal(cfg, flusher, journal)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"abc123": 2, "xyz789": 1}, recovered.GetPendingStats())
+		recovered.Stop()
+
+		assert.Equal(t, map[string]int64{"abc123": 2, "xyz789": 1}, flusher.getCounts())
+		counts, err := journal.Load()
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+
+		// Release the goroutine of the first process
+		counter.Stop()
+	})
+
+	t.Run("flushing empties the journal", func(t *testing.T) {
+		journal := NewFileJournal(filepath.Join(t.TempDir(), "clicks.journal"))
+		counter, err := NewClickCounterWithJournal(cfg, newMockFlusher(), journal)
+		require.NoError(t, err)
+		defer counter.Stop()
+
+		counter.RecordClick(context.Background(), "abc123")
+		require.NoError(t, counter.Flush(context.Background()))
+
+		counts, err := journal.Load()
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	t.Run("fails on an unreadable journal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "clicks.journal")
+		require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+		_, err := NewClickCounterWithJournal(cfg, newMockFlusher(), NewFileJournal(path))
+
+		assert.Error(t, err)
+	})
+}
+
 func TestClickCounter_Concurrency(t *testing.T) {
 	t.Run("handles concurrent clicks safely", func(t *testing.T) {
 		flusher := newMockFlusher()
@@ -184,7 +329,7 @@ func TestClickCounter_Concurrency(t *testing.T) {
 			go func() {
 				defer wg.Done()
 				for j := 0; j < clicksPerGoroutine; j++ {
-					counter.RecordClick("concurrent-code")
+					counter.RecordClick(context.Background(), "concurrent-code")
 				}
 			}()
 		}
@@ -212,7 +357,7 @@ func TestClickCounter_NonBlocking(t *testing.T) {
 		// Should complete very quickly
 		start := time.Now()
 		for i := 0; i < 1000; i++ {
-			counter.RecordClick("fast-code")
+			counter.RecordClick(context.Background(), "fast-code")
 		}
 		elapsed := time.Since(start)
 
@@ -230,9 +375,9 @@ func TestClickCounter_GetStats(t *testing.T) {
 		}, flusher)
 		defer counter.Stop()
 
-		counter.RecordClick("abc123")
-		counter.RecordClick("abc123")
-		counter.RecordClick("xyz789")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "xyz789")
 
 		// Allow time for async processing
 		time.Sleep(10 * time.Millisecond)
@@ -243,12 +388,276 @@ func TestClickCounter_GetStats(t *testing.T) {
 	})
 }
 
+func TestClickCounter_Backpressure(t *testing.T) {
+	t.Run("full buffer drops and counts clicks without blocking", func(t *testing.T) {
+		// No run loop, so nothing drains the channel
+		counter := newClickCounter(Config{ChannelBuffer: 2, BatchSize: 100}, newMockFlusher())
+
+		done := make(chan struct{})
+		go func() {
+			for range 5 {
+				counter.RecordClick(context.Background(), "busy")
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("RecordClick blocked on a full buffer")
+		}
+
+		stats := counter.GetStats()
+		assert.Equal(t, int64(3), stats.Dropped)
+		assert.Equal(t, 2, stats.Buffered)
+		assert.Equal(t, 2, stats.Capacity)
+		assert.Equal(t, 1.0, stats.Utilization())
+	})
+
+	t.Run("health check reports drops within the window", func(t *testing.T) {
+		counter := newClickCounter(Config{ChannelBuffer: 1, DropWindow: time.Minute}, newMockFlusher())
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		counter.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		counter.RecordClick(ctx, "busy")
+		assert.NoError(t, counter.HealthCheck(ctx))
+
+		counter.RecordClick(ctx, "busy")
+		now = now.Add(10 * time.Second)
+		// Concurrent probes don't consume each other's signal
+		assert.ErrorContains(t, counter.HealthCheck(ctx), "click dropped 10s ago, 1 in total")
+		assert.Error(t, counter.HealthCheck(ctx))
+
+		now = now.Add(time.Minute)
+		assert.NoError(t, counter.HealthCheck(ctx))
+	})
+
+	t.Run("blocking mode waits for room", func(t *testing.T) {
+		counter := newClickCounter(Config{ChannelBuffer: 1, BlockOnFull: true}, newMockFlusher())
+		counter.RecordClick(context.Background(), "busy")
+
+		done := make(chan struct{})
+		go func() {
+			counter.RecordClick(context.Background(), "busy")
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatal("RecordClick returned with a full buffer")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		<-counter.clickChan
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("RecordClick still blocked after room was made")
+		}
+		assert.Zero(t, counter.GetStats().Dropped)
+	})
+
+	t.Run("blocking mode drops the click when its context ends", func(t *testing.T) {
+		counter := newClickCounter(Config{ChannelBuffer: 1, BlockOnFull: true}, newMockFlusher())
+		counter.RecordClick(context.Background(), "busy")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			counter.RecordClick(ctx, "busy")
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("RecordClick ignored the cancelled context")
+		}
+		assert.Equal(t, int64(1), counter.GetStats().Dropped)
+		assert.Error(t, counter.HealthCheck(context.Background()))
+	})
+}
+
+// mockBucketFlusher also records time-bucketed counts.
+type mockBucketFlusher struct {
+	*mockFlusher
+	buckets map[BucketKey]int64
+}
+
+func newMockBucketFlusher() *mockBucketFlusher {
+	return &mockBucketFlusher{
+		mockFlusher: newMockFlusher(),
+		buckets:     make(map[BucketKey]int64),
+	}
+}
+
+func (m *mockBucketFlusher) FlushClickBuckets(ctx context.Context, buckets map[BucketKey]int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, count := range buckets {
+		m.buckets[key] += count
+	}
+	return nil
+}
+
+func TestClickCounter_Buckets(t *testing.T) {
+	t.Run("groups clicks by hour window", func(t *testing.T) {
+		flusher := newMockBucketFlusher()
+		counter := NewClickCounter(Config{
+			FlushInterval: 10 * time.Second,
+			BatchSize:     1000,
+		}, flusher)
+
+		hour := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
+		clicks := []time.Time{
+			hour.Add(-time.Nanosecond), // last instant of 09:00
+			hour,                       // first instant of 10:00
+			hour.Add(59*time.Minute + 59*time.Second), // still 10:00
+			hour.Add(time.Hour),                       // first instant of 11:00
+		}
+		for _, at := range clicks {
+			counter.now = func() time.Time { return at }
+			counter.RecordClick(context.Background(), "abc123")
+		}
+		counter.Stop()
+
+		assert.Equal(t, map[BucketKey]int64{
+			{ShortCode: "abc123", Start: hour.Add(-time.Hour)}: 1,
+			{ShortCode: "abc123", Start: hour}:                 2,
+			{ShortCode: "abc123", Start: hour.Add(time.Hour)}:  1,
+		}, flusher.buckets)
+		assert.Equal(t, int64(4), flusher.getCounts()["abc123"])
+	})
+
+	t.Run("aligns windows to UTC", func(t *testing.T) {
+		zone := time.FixedZone("UTC+5:30", 5*3600+1800)
+		at := time.Date(2024, 3, 10, 15, 45, 0, 0, zone) // 10:15 UTC
+
+		assert.Equal(t, time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC), bucketStart(at))
+	})
+
+	t.Run("skips buckets for plain flushers", func(t *testing.T) {
+		counter := NewClickCounter(Config{FlushInterval: time.Minute, BatchSize: 10}, newMockFlusher())
+		defer counter.Stop()
+
+		assert.Nil(t, counter.buckets)
+	})
+}
+
+// mockSourceFlusher also records click sources.
+type mockSourceFlusher struct {
+	*mockFlusher
+	referrers map[ReferrerKey]int64
+	agents    map[AgentKey]int64
+}
+
+func newMockSourceFlusher() *mockSourceFlusher {
+	return &mockSourceFlusher{
+		mockFlusher: newMockFlusher(),
+		referrers:   make(map[ReferrerKey]int64),
+		agents:      make(map[AgentKey]int64),
+	}
+}
+
+func (m *mockSourceFlusher) FlushClickSources(ctx context.Context, referrers map[ReferrerKey]int64, agents map[AgentKey]int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, count := range referrers {
+		m.referrers[key] += count
+	}
+	for key, count := range agents {
+		m.agents[key] += count
+	}
+	return nil
+}
+
+func TestClickCounter_Sources(t *testing.T) {
+	t.Run("aggregates referrers and agents", func(t *testing.T) {
+		flusher := newMockSourceFlusher()
+		counter := NewClickCounter(Config{
+			FlushInterval: 10 * time.Second,
+			BatchSize:     1000,
+		}, flusher)
+
+		firefox := "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+		counter.RecordClickFrom(context.Background(), "abc123", ClickSource{Referrer: "https://www.google.com/search?q=a", UserAgent: firefox})
+		counter.RecordClickFrom(context.Background(), "abc123", ClickSource{Referrer: "https://google.com/search?q=b", UserAgent: firefox})
+		counter.RecordClickFrom(context.Background(), "abc123", ClickSource{UserAgent: "curl/8.4.0"})
+		counter.RecordClick(context.Background(), "xyz789")
+		counter.Stop()
+
+		assert.Equal(t, map[ReferrerKey]int64{
+			{ShortCode: "abc123", Referrer: "google.com"}:   2,
+			{ShortCode: "abc123", Referrer: ReferrerDirect}: 1,
+			{ShortCode: "xyz789", Referrer: ReferrerDirect}: 1,
+		}, flusher.referrers)
+		assert.Equal(t, map[AgentKey]int64{
+			{ShortCode: "abc123", Browser: "Firefox", OS: "Linux"}:         2,
+			{ShortCode: "abc123", Browser: "curl", OS: UnknownAgent}:       1,
+			{ShortCode: "xyz789", Browser: UnknownAgent, OS: UnknownAgent}: 1,
+		}, flusher.agents)
+		assert.Equal(t, int64(3), flusher.getCounts()["abc123"])
+	})
+
+	t.Run("skips sources for plain flushers", func(t *testing.T) {
+		counter := NewClickCounter(Config{FlushInterval: time.Minute, BatchSize: 10}, newMockFlusher())
+		defer counter.Stop()
+
+		assert.Nil(t, counter.referrers)
+		assert.Nil(t, counter.agents)
+	})
+}
+
+// mockGeoFlusher also records click countries.
+type mockGeoFlusher struct {
+	*mockFlusher
+	countries map[CountryKey]int64
+}
+
+func (m *mockGeoFlusher) FlushClickCountries(ctx context.Context, countries map[CountryKey]int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, count := range countries {
+		m.countries[key] += count
+	}
+	return nil
+}
+
+func TestClickCounter_Countries(t *testing.T) {
+	t.Run("aggregates countries", func(t *testing.T) {
+		flusher := &mockGeoFlusher{mockFlusher: newMockFlusher(), countries: make(map[CountryKey]int64)}
+		counter := NewClickCounter(Config{
+			FlushInterval: 10 * time.Second,
+			BatchSize:     1000,
+		}, flusher)
+
+		counter.RecordClickFrom(context.Background(), "abc123", ClickSource{Country: "DE"})
+		counter.RecordClickFrom(context.Background(), "abc123", ClickSource{Country: "DE"})
+		counter.RecordClickFrom(context.Background(), "abc123", ClickSource{Country: "JP"})
+		counter.RecordClick(context.Background(), "abc123") // No country resolved
+		counter.Stop()
+
+		assert.Equal(t, map[CountryKey]int64{
+			{ShortCode: "abc123", Country: "DE"}: 2,
+			{ShortCode: "abc123", Country: "JP"}: 1,
+		}, flusher.countries)
+		assert.Equal(t, int64(4), flusher.getCounts()["abc123"])
+	})
+
+	t.Run("skips countries for plain flushers", func(t *testing.T) {
+		counter := NewClickCounter(Config{FlushInterval: time.Minute, BatchSize: 10}, newMockFlusher())
+		defer counter.Stop()
+
+		assert.Nil(t, counter.countries)
+	})
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 
 	assert.Equal(t, 10*time.Second, cfg.FlushInterval)
 	assert.Equal(t, 100, cfg.BatchSize)
 	assert.Equal(t, 10000, cfg.ChannelBuffer)
+	assert.Equal(t, time.Second, cfg.JournalInterval)
 }
 
 // benchmarkCounter benchmarks click recording
@@ -263,7 +672,7 @@ func BenchmarkClickCounter_RecordClick(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		counter.RecordClick("bench-code")
+		counter.RecordClick(context.Background(), "bench-code")
 	}
 }
 
@@ -282,7 +691,7 @@ func BenchmarkClickCounter_RecordClick_Parallel(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			n := atomic.AddInt64(&counter64, 1)
-			counter.RecordClick("bench-code-" + string(rune(n%26+'a')))
+			counter.RecordClick(context.Background(), "bench-code-"+string(rune(n%26+'a')))
 		}
 	})
 }
@@ -2,19 +2,58 @@ package analytics
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/emadnahed/FastGoLink/pkg/logger"
 )
 
 // ClickRepository defines the interface for persisting click counts.
+// BatchIncrementClickCounts returns the new total of every URL it updated.
 type ClickRepository interface {
-	BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error
+	BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error)
+}
+
+// ClickBucketRepository defines the interface for persisting time-bucketed click counts.
+type ClickBucketRepository interface {
+	IncrementClickBuckets(ctx context.Context, buckets map[BucketKey]int64) error
+}
+
+// ClickSourceRepository defines the interface for persisting click counts
+// per referrer and per browser/OS.
+type ClickSourceRepository interface {
+	IncrementClickSources(ctx context.Context, referrers map[ReferrerKey]int64, agents map[AgentKey]int64) error
+}
+
+// ClickCountryRepository defines the interface for persisting click counts per country.
+type ClickCountryRepository interface {
+	IncrementClickCountries(ctx context.Context, countries map[CountryKey]int64) error
+}
+
+// ClickArchiveRepository defines the interface for moving large click counts
+// out of the live counters.
+type ClickArchiveRepository interface {
+	ArchiveClickCounts(ctx context.Context, threshold int64) (int64, error)
+}
+
+// ArchiveConfig controls how often live click counts are archived.
+type ArchiveConfig struct {
+	Interval  time.Duration // Minimum time between archive runs
+	Threshold int64         // Live count at which a URL is archived
 }
 
 // RepositoryFlusher implements Flusher using a repository.
 type RepositoryFlusher struct {
-	repo ClickRepository
-	log  *logger.Logger
+	repo      ClickRepository
+	buckets   ClickBucketRepository
+	sources   ClickSourceRepository
+	countries ClickCountryRepository
+	log       *logger.Logger
+
+	archiveMu   sync.Mutex
+	archive     ClickArchiveRepository
+	archiveCfg  ArchiveConfig
+	lastArchive time.Time
 }
 
 // NewRepositoryFlusher creates a new RepositoryFlusher.
@@ -25,13 +64,59 @@ func NewRepositoryFlusher(repo ClickRepository, log *logger.Logger) *RepositoryF
 	}
 }
 
-// FlushClicks persists click counts to the repository.
+// NewRepositoryFlusherWithBuckets creates a RepositoryFlusher that also
+// persists time-bucketed click counts.
+func NewRepositoryFlusherWithBuckets(repo ClickRepository, buckets ClickBucketRepository, log *logger.Logger) *RepositoryFlusher {
+	return &RepositoryFlusher{
+		repo:    repo,
+		buckets: buckets,
+		log:     log,
+	}
+}
+
+// NewRepositoryFlusherWithSources creates a RepositoryFlusher that also
+// persists time-bucketed click counts and click sources.
+func NewRepositoryFlusherWithSources(repo ClickRepository, buckets ClickBucketRepository, sources ClickSourceRepository, log *logger.Logger) *RepositoryFlusher {
+	return &RepositoryFlusher{
+		repo:    repo,
+		buckets: buckets,
+		sources: sources,
+		log:     log,
+	}
+}
+
+// NewRepositoryFlusherWithGeo creates a RepositoryFlusher that also persists
+// time-bucketed click counts, click sources and click counts per country.
+func NewRepositoryFlusherWithGeo(repo ClickRepository, buckets ClickBucketRepository, sources ClickSourceRepository, countries ClickCountryRepository, log *logger.Logger) *RepositoryFlusher {
+	return &RepositoryFlusher{
+		repo:      repo,
+		buckets:   buckets,
+		sources:   sources,
+		countries: countries,
+		log:       log,
+	}
+}
+
+// SetArchive makes FlushClicks archive live click counts of at least
+// cfg.Threshold, at most once per cfg.Interval, so the counters of popular
+// URLs are reset regularly. The first run happens one interval after the
+// call.
+func (f *RepositoryFlusher) SetArchive(archive ClickArchiveRepository, cfg ArchiveConfig) {
+	f.archiveMu.Lock()
+	defer f.archiveMu.Unlock()
+	f.archive = archive
+	f.archiveCfg = cfg
+	f.lastArchive = time.Now()
+}
+
+// FlushClicks persists click counts to the repository, then archives hot
+// counters when an archive run is due.
 func (f *RepositoryFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
 	if len(counts) == 0 {
 		return nil
 	}
 
-	err := f.repo.BatchIncrementClickCounts(ctx, counts)
+	_, err := f.repo.BatchIncrementClickCounts(ctx, counts)
 	if err != nil {
 		if f.log != nil {
 			f.log.Error("failed to flush click counts", "error", err.Error(), "count", len(counts))
@@ -47,5 +132,96 @@ func (f *RepositoryFlusher) FlushClicks(ctx context.Context, counts map[string]i
 		f.log.Debug("flushed click counts", "urls", len(counts), "total_clicks", total)
 	}
 
+	f.archiveIfDue(ctx)
+	return nil
+}
+
+// archiveIfDue runs an archive when one is configured and the interval has
+// passed. Failures are only logged: the clicks are already persisted, so the
+// flush itself succeeded, and the next due run archives whatever was missed.
+func (f *RepositoryFlusher) archiveIfDue(ctx context.Context) {
+	f.archiveMu.Lock()
+	defer f.archiveMu.Unlock()
+	if f.archive == nil || time.Since(f.lastArchive) < f.archiveCfg.Interval {
+		return
+	}
+	f.lastArchive = time.Now()
+
+	archived, err := f.archive.ArchiveClickCounts(ctx, f.archiveCfg.Threshold)
+	if f.log == nil {
+		return
+	}
+	if err != nil {
+		f.log.Error("failed to archive click counts", "error", err.Error())
+		return
+	}
+	if archived > 0 {
+		f.log.Info("archived click counts", "urls", archived, "threshold", f.archiveCfg.Threshold)
+	}
+}
+
+// FlushClickBuckets persists time-bucketed click counts to the bucket
+// repository. It is a no-op when no bucket repository is configured.
+func (f *RepositoryFlusher) FlushClickBuckets(ctx context.Context, buckets map[BucketKey]int64) error {
+	if len(buckets) == 0 || f.buckets == nil {
+		return nil
+	}
+
+	err := f.buckets.IncrementClickBuckets(ctx, buckets)
+	if err != nil {
+		if f.log != nil {
+			f.log.Error("failed to flush click buckets", "error", err.Error(), "count", len(buckets))
+		}
+		return err
+	}
+
+	if f.log != nil {
+		f.log.Debug("flushed click buckets", "buckets", len(buckets))
+	}
+
+	return nil
+}
+
+// FlushClickSources persists click counts per referrer and per browser/OS to
+// the source repository. It is a no-op when no source repository is configured.
+func (f *RepositoryFlusher) FlushClickSources(ctx context.Context, referrers map[ReferrerKey]int64, agents map[AgentKey]int64) error {
+	if (len(referrers) == 0 && len(agents) == 0) || f.sources == nil {
+		return nil
+	}
+
+	err := f.sources.IncrementClickSources(ctx, referrers, agents)
+	if err != nil {
+		if f.log != nil {
+			f.log.Error("failed to flush click sources", "error", err.Error(), "referrers", len(referrers), "agents", len(agents))
+		}
+		return err
+	}
+
+	if f.log != nil {
+		f.log.Debug("flushed click sources", "referrers", len(referrers), "agents", len(agents))
+	}
+
+	return nil
+}
+
+// FlushClickCountries persists click counts per country to the country
+// repository. It is a no-op when no country repository is configured.
+func (f *RepositoryFlusher) FlushClickCountries(ctx context.Context, countries map[CountryKey]int64) error {
+	if len(countries) == 0 || f.countries == nil {
+		return nil
+	}
+
+	err := f.countries.IncrementClickCountries(ctx, countries)
+	if err != nil {
+		if f.log != nil {
+			f.log.Error("failed to flush click countries", "error", err.Error(), "count", len(countries))
+		}
+		return err
+	}
+
+	if f.log != nil {
+		f.log.Debug("flushed click countries", "countries", len(countries))
+	}
+
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,10 +20,10 @@ type mockClickRepository struct {
 	batchErr             error
 }
 
-func (m *mockClickRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error {
+func (m *mockClickRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
 	m.batchIncrementCalled = true
 	m.batchCounts = counts
-	return m.batchErr
+	return nil, m.batchErr
 }
 
 func TestNewRepositoryFlusher(t *testing.T) {
@@ -111,3 +112,214 @@ func TestRepositoryFlusher_FlushClicks(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+// mockClickBucketRepository implements ClickBucketRepository for testing.
+type mockClickBucketRepository struct {
+	buckets map[BucketKey]int64
+	err     error
+}
+
+func (m *mockClickBucketRepository) IncrementClickBuckets(ctx context.Context, buckets map[BucketKey]int64) error {
+	m.buckets = buckets
+	return m.err
+}
+
+func TestRepositoryFlusher_FlushClickBuckets(t *testing.T) {
+	buckets := map[BucketKey]int64{
+		{ShortCode: "abc123", Start: time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)}: 4,
+	}
+
+	t.Run("flushes buckets to repository", func(t *testing.T) {
+		bucketRepo := &mockClickBucketRepository{}
+		flusher := NewRepositoryFlusherWithBuckets(&mockClickRepository{}, bucketRepo, logger.New(os.Stdout, "debug"))
+
+		require.NoError(t, flusher.FlushClickBuckets(context.Background(), buckets))
+		assert.Equal(t, buckets, bucketRepo.buckets)
+	})
+
+	t.Run("returns repository error", func(t *testing.T) {
+		bucketRepo := &mockClickBucketRepository{err: errors.New("database error")}
+		flusher := NewRepositoryFlusherWithBuckets(&mockClickRepository{}, bucketRepo, logger.New(os.Stdout, "debug"))
+
+		assert.Error(t, flusher.FlushClickBuckets(context.Background(), buckets))
+	})
+
+	t.Run("no-op without bucket repository", func(t *testing.T) {
+		flusher := NewRepositoryFlusher(&mockClickRepository{}, nil)
+
+		assert.NoError(t, flusher.FlushClickBuckets(context.Background(), buckets))
+	})
+}
+
+// mockClickSourceRepository implements ClickSourceRepository for testing.
+type mockClickSourceRepository struct {
+	referrers map[ReferrerKey]int64
+	agents    map[AgentKey]int64
+	err       error
+}
+
+func (m *mockClickSourceRepository) IncrementClickSources(ctx context.Context, referrers map[ReferrerKey]int64, agents map[AgentKey]int64) error {
+	m.referrers, m.agents = referrers, agents
+	return m.err
+}
+
+func TestRepositoryFlusher_FlushClickSources(t *testing.T) {
+	referrers := map[ReferrerKey]int64{{ShortCode: "abc123", Referrer: "google.com"}: 2}
+	agents := map[AgentKey]int64{{ShortCode: "abc123", Browser: "Firefox", OS: "Linux"}: 2}
+
+	t.Run("flushes sources to repository", func(t *testing.T) {
+		sourceRepo := &mockClickSourceRepository{}
+		flusher := NewRepositoryFlusherWithSources(&mockClickRepository{}, nil, sourceRepo, logger.New(os.Stdout, "debug"))
+
+		require.NoError(t, flusher.FlushClickSources(context.Background(), referrers, agents))
+		assert.Equal(t, referrers, sourceRepo.referrers)
+		assert.Equal(t, agents, sourceRepo.agents)
+	})
+
+	t.Run("returns repository error", func(t *testing.T) {
+		sourceRepo := &mockClickSourceRepository{err: errors.New("database error")}
+		flusher := NewRepositoryFlusherWithSources(&mockClickRepository{}, nil, sourceRepo, logger.New(os.Stdout, "debug"))
+
+		assert.Error(t, flusher.FlushClickSources(context.Background(), referrers, agents))
+	})
+
+	t.Run("no-op without source repository", func(t *testing.T) {
+		flusher := NewRepositoryFlusher(&mockClickRepository{}, nil)
+
+		assert.NoError(t, flusher.FlushClickSources(context.Background(), referrers, agents))
+	})
+}
+
+// mockClickCountryRepository implements ClickCountryRepository for testing.
+type mockClickCountryRepository struct {
+	countries map[CountryKey]int64
+	err       error
+}
+
+func (m *mockClickCountryRepository) IncrementClickCountries(ctx context.Context, countries map[CountryKey]int64) error {
+	m.countries = countries
+	return m.err
+}
+
+func TestRepositoryFlusher_FlushClickCountries(t *testing.T) {
+	countries := map[CountryKey]int64{{ShortCode: "abc123", Country: "DE"}: 2}
+
+	t.Run("flushes countries to repository", func(t *testing.T) {
+		countryRepo := &mockClickCountryRepository{}
+		flusher := NewRepositoryFlusherWithGeo(&mockClickRepository{}, nil, nil, countryRepo, logger.New(os.Stdout, "debug"))
+
+		require.NoError(t, flusher.FlushClickCountries(context.Background(), countries))
+		assert.Equal(t, countries, countryRepo.countries)
+	})
+
+	t.Run("returns repository error", func(t *testing.T) {
+		countryRepo := &mockClickCountryRepository{err: errors.New("database error")}
+		flusher := NewRepositoryFlusherWithGeo(&mockClickRepository{}, nil, nil, countryRepo, logger.New(os.Stdout, "debug"))
+
+		assert.Error(t, flusher.FlushClickCountries(context.Background(), countries))
+	})
+
+	t.Run("no-op without country repository", func(t *testing.T) {
+		flusher := NewRepositoryFlusherWithSources(&mockClickRepository{}, nil, nil, nil)
+
+		assert.NoError(t, flusher.FlushClickCountries(context.Background(), countries))
+	})
+}
+
+// archivingClickStore keeps live and archived click counts like the urls and
+// url_click_archive tables, for testing.
+type archivingClickStore struct {
+	live       map[string]int64
+	archived   map[string]int64
+	archives   int
+	archiveErr error
+}
+
+func newArchivingClickStore() *archivingClickStore {
+	return &archivingClickStore{live: map[string]int64{}, archived: map[string]int64{}}
+}
+
+func (s *archivingClickStore) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
+	totals := make(map[string]int64, len(counts))
+	for code, n := range counts {
+		s.live[code] += n
+		totals[code] = s.live[code] + s.archived[code]
+	}
+	return totals, nil
+}
+
+func (s *archivingClickStore) ArchiveClickCounts(ctx context.Context, threshold int64) (int64, error) {
+	s.archives++
+	if s.archiveErr != nil {
+		return 0, s.archiveErr
+	}
+	var n int64
+	for code, count := range s.live {
+		if count >= threshold {
+			s.archived[code] += count
+			s.live[code] = 0
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *archivingClickStore) total(code string) int64 {
+	return s.live[code] + s.archived[code]
+}
+
+func TestRepositoryFlusher_Archive(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("totals survive an archive cycle", func(t *testing.T) {
+		store := newArchivingClickStore()
+		flusher := NewRepositoryFlusher(store, logger.New(os.Stdout, "debug"))
+		flusher.SetArchive(store, ArchiveConfig{Threshold: 10})
+
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"hot": 25, "cold": 3}))
+		assert.Zero(t, store.live["hot"], "hot counter is reset")
+		assert.Equal(t, int64(25), store.archived["hot"])
+		assert.Equal(t, int64(3), store.live["cold"], "cold counter stays live")
+
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"hot": 12, "cold": 4}))
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"hot": 1}))
+
+		assert.Equal(t, int64(38), store.total("hot"))
+		assert.Equal(t, int64(1), store.live["hot"])
+		assert.Equal(t, int64(7), store.total("cold"))
+		assert.Equal(t, 3, store.archives)
+	})
+
+	t.Run("waits for the interval between runs", func(t *testing.T) {
+		store := newArchivingClickStore()
+		flusher := NewRepositoryFlusher(store, nil)
+		flusher.SetArchive(store, ArchiveConfig{Interval: time.Hour, Threshold: 1})
+
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"abc123": 5}))
+		assert.Zero(t, store.archives)
+
+		flusher.lastArchive = time.Now().Add(-time.Hour)
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"abc123": 5}))
+		assert.Equal(t, 1, store.archives)
+		assert.Equal(t, int64(10), store.total("abc123"))
+	})
+
+	t.Run("archive failure does not fail the flush", func(t *testing.T) {
+		store := newArchivingClickStore()
+		store.archiveErr = errors.New("database error")
+		flusher := NewRepositoryFlusher(store, logger.New(os.Stdout, "debug"))
+		flusher.SetArchive(store, ArchiveConfig{Threshold: 1})
+
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"abc123": 5}))
+		assert.Equal(t, 1, store.archives)
+		assert.Equal(t, int64(5), store.total("abc123"))
+	})
+
+	t.Run("disabled without an archive", func(t *testing.T) {
+		store := newArchivingClickStore()
+		flusher := NewRepositoryFlusher(store, nil)
+
+		require.NoError(t, flusher.FlushClicks(ctx, map[string]int64{"abc123": 5}))
+		assert.Zero(t, store.archives)
+	})
+}
@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Journal durably stores the click counts a ClickCounter has not flushed yet,
+// so that a crash loses at most one journal interval of clicks.
+//
+// Only per-URL totals are journaled. Clicks recovered from a journal are
+// added to click counts but not to time-series, source or country analytics.
+type Journal interface {
+	// Save replaces the journal contents with counts.
+	Save(counts map[string]int64) error
+	// Load returns the counts of the last Save, or an empty map.
+	Load() (map[string]int64, error)
+}
+
+// FileJournal implements Journal as a JSON file that is replaced atomically
+// on every Save.
+type FileJournal struct {
+	path string
+}
+
+// NewFileJournal creates a FileJournal stored at path.
+func NewFileJournal(path string) *FileJournal {
+	return &FileJournal{path: path}
+}
+
+// Save writes counts to a temporary file, syncs it and renames it over the
+// journal, so a crash leaves either the old or the new contents.
+func (j *FileJournal) Save(counts map[string]int64) error {
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return fmt.Errorf("failed to encode click journal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create click journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write click journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync click journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write click journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("failed to replace click journal: %w", err)
+	}
+	return nil
+}
+
+// Load reads the journal. A missing journal file holds no clicks.
+func (j *FileJournal) Load() (map[string]int64, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]int64{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read click journal: %w", err)
+	}
+
+	counts := map[string]int64{}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode click journal: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileJournal(t *testing.T) {
+	t.Run("missing file holds no clicks", func(t *testing.T) {
+		j := NewFileJournal(filepath.Join(t.TempDir(), "clicks.journal"))
+
+		counts, err := j.Load()
+
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	t.Run("load returns the last save", func(t *testing.T) {
+		dir := t.TempDir()
+		j := NewFileJournal(filepath.Join(dir, "clicks.journal"))
+
+		require.NoError(t, j.Save(map[string]int64{"abc123": 1}))
+		require.NoError(t, j.Save(map[string]int64{"abc123": 3, "xyz789": 2}))
+		counts, err := j.Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"abc123": 3, "xyz789": 2}, counts)
+
+		// Temporary files are cleaned up
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("save fails in a missing directory", func(t *testing.T) {
+		j := NewFileJournal(filepath.Join(t.TempDir(), "missing", "clicks.journal"))
+
+		assert.Error(t, j.Save(map[string]int64{"abc123": 1}))
+	})
+
+	t.Run("load fails on corrupt file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "clicks.journal")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+		_, err := NewFileJournal(path).Load()
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,218 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// ClickEvent is the click count of one short code in a flush, as sent to
+// external analytics sinks.
+type ClickEvent struct {
+	ShortCode string    `json:"short_code"`
+	Clicks    int64     `json:"clicks"`
+	FlushedAt time.Time `json:"flushed_at"`
+}
+
+// newClickEvents converts flushed counts to events, ordered by short code.
+func newClickEvents(counts map[string]int64, flushedAt time.Time) []ClickEvent {
+	events := make([]ClickEvent, 0, len(counts))
+	for shortCode, clicks := range counts {
+		events = append(events, ClickEvent{ShortCode: shortCode, Clicks: clicks, FlushedAt: flushedAt.UTC()})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ShortCode < events[j].ShortCode
+	})
+	return events
+}
+
+// MultiFlusher fans flushes out to several flushers, such as the repository
+// and an external analytics sink. Every flusher is called even when an earlier
+// one fails, and their errors are joined. Time buckets, sources and countries
+// only go to the flushers that implement BucketFlusher, SourceFlusher and
+// GeoFlusher.
+type MultiFlusher struct {
+	flushers []Flusher
+}
+
+// NewMultiFlusher creates a MultiFlusher that flushes to each of flushers in order.
+func NewMultiFlusher(flushers ...Flusher) *MultiFlusher {
+	return &MultiFlusher{flushers: flushers}
+}
+
+// FlushClicks flushes click counts to every flusher.
+func (m *MultiFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
+	var errs []error
+	for _, f := range m.flushers {
+		errs = append(errs, f.FlushClicks(ctx, counts))
+	}
+	return errors.Join(errs...)
+}
+
+// FlushClickBuckets flushes time-bucketed click counts to every BucketFlusher.
+func (m *MultiFlusher) FlushClickBuckets(ctx context.Context, buckets map[BucketKey]int64) error {
+	var errs []error
+	for _, f := range m.flushers {
+		if bf, ok := f.(BucketFlusher); ok {
+			errs = append(errs, bf.FlushClickBuckets(ctx, buckets))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FlushClickSources flushes click sources to every SourceFlusher.
+func (m *MultiFlusher) FlushClickSources(ctx context.Context, referrers map[ReferrerKey]int64, agents map[AgentKey]int64) error {
+	var errs []error
+	for _, f := range m.flushers {
+		if sf, ok := f.(SourceFlusher); ok {
+			errs = append(errs, sf.FlushClickSources(ctx, referrers, agents))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FlushClickCountries flushes click counts per country to every GeoFlusher.
+func (m *MultiFlusher) FlushClickCountries(ctx context.Context, countries map[CountryKey]int64) error {
+	var errs []error
+	for _, f := range m.flushers {
+		if gf, ok := f.(GeoFlusher); ok {
+			errs = append(errs, gf.FlushClickCountries(ctx, countries))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPClickBatch is the body HTTPFlusher posts for each flush.
+type HTTPClickBatch struct {
+	Events []ClickEvent `json:"events"`
+}
+
+// HTTPFlusher implements Flusher by posting each flush's click counts as a
+// JSON HTTPClickBatch to an external analytics endpoint. Any status other
+// than 2xx fails the flush.
+type HTTPFlusher struct {
+	endpoint string
+	client   *http.Client
+	log      *logger.Logger
+}
+
+// NewHTTPFlusher creates an HTTPFlusher posting to endpoint. A nil client
+// uses http.DefaultClient; pass one with a timeout so a slow endpoint can't
+// hold up flushes.
+func NewHTTPFlusher(endpoint string, client *http.Client, log *logger.Logger) *HTTPFlusher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPFlusher{
+		endpoint: endpoint,
+		client:   client,
+		log:      log,
+	}
+}
+
+// FlushClicks posts click counts to the endpoint.
+func (f *HTTPFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	err := f.post(ctx, HTTPClickBatch{Events: newClickEvents(counts, time.Now())})
+	if err != nil {
+		if f.log != nil {
+			f.log.Error("failed to send click counts", "sink", "http", "error", err.Error(), "count", len(counts))
+		}
+		return err
+	}
+
+	if f.log != nil {
+		f.log.Debug("sent click counts", "sink", "http", "urls", len(counts))
+	}
+	return nil
+}
+
+// post sends batch to the endpoint and checks the response status.
+func (f *HTTPFlusher) post(ctx context.Context, batch HTTPClickBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("analytics endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// KafkaWriter writes messages to a Kafka topic. *kafka.Writer satisfies it.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaFlusher implements Flusher by writing each short code's click count
+// as a JSON ClickEvent message, keyed by short code so the events of a code
+// stay in order on one partition.
+type KafkaFlusher struct {
+	writer KafkaWriter
+	log    *logger.Logger
+}
+
+// NewKafkaFlusher creates a KafkaFlusher writing to writer, whose topic and
+// brokers are configured by the caller.
+func NewKafkaFlusher(writer KafkaWriter, log *logger.Logger) *KafkaFlusher {
+	return &KafkaFlusher{
+		writer: writer,
+		log:    log,
+	}
+}
+
+// FlushClicks writes one message per short code in a single batch.
+func (f *KafkaFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	events := newClickEvents(counts, time.Now())
+	msgs := make([]kafka.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msgs[i] = kafka.Message{Key: []byte(event.ShortCode), Value: value}
+	}
+
+	if err := f.writer.WriteMessages(ctx, msgs...); err != nil {
+		if f.log != nil {
+			f.log.Error("failed to send click counts", "sink", "kafka", "error", err.Error(), "count", len(counts))
+		}
+		return err
+	}
+
+	if f.log != nil {
+		f.log.Debug("sent click counts", "sink", "kafka", "urls", len(counts))
+	}
+	return nil
+}
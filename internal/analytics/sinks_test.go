@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiFlusher(t *testing.T) {
+	t.Run("flushes clicks to every sink", func(t *testing.T) {
+		first, second := newMockFlusher(), newMockFlusher()
+		flusher := NewMultiFlusher(first, second)
+
+		require.NoError(t, flusher.FlushClicks(context.Background(), map[string]int64{"abc123": 3}))
+
+		assert.Equal(t, map[string]int64{"abc123": 3}, first.getCounts())
+		assert.Equal(t, map[string]int64{"abc123": 3}, second.getCounts())
+	})
+
+	t.Run("sends optional data only to sinks that take it", func(t *testing.T) {
+		plain := newMockFlusher()
+		buckets := newMockBucketFlusher()
+		sources := newMockSourceFlusher()
+		countries := &mockGeoFlusher{mockFlusher: newMockFlusher(), countries: make(map[CountryKey]int64)}
+		flusher := NewMultiFlusher(plain, buckets, sources, countries)
+		ctx := context.Background()
+
+		bucket := BucketKey{ShortCode: "abc123", Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		referrer := ReferrerKey{ShortCode: "abc123", Referrer: "google.com"}
+		agent := AgentKey{ShortCode: "abc123", Browser: "Firefox", OS: "Linux"}
+		country := CountryKey{ShortCode: "abc123", Country: "DE"}
+		require.NoError(t, flusher.FlushClickBuckets(ctx, map[BucketKey]int64{bucket: 2}))
+		require.NoError(t, flusher.FlushClickSources(ctx, map[ReferrerKey]int64{referrer: 2}, map[AgentKey]int64{agent: 2}))
+		require.NoError(t, flusher.FlushClickCountries(ctx, map[CountryKey]int64{country: 2}))
+
+		assert.Equal(t, map[BucketKey]int64{bucket: 2}, buckets.buckets)
+		assert.Equal(t, map[ReferrerKey]int64{referrer: 2}, sources.referrers)
+		assert.Equal(t, map[AgentKey]int64{agent: 2}, sources.agents)
+		assert.Equal(t, map[CountryKey]int64{country: 2}, countries.countries)
+		assert.Zero(t, plain.getCallCount())
+	})
+
+	t.Run("keeps flushing after a sink fails", func(t *testing.T) {
+		after := newMockFlusher()
+		flusher := NewMultiFlusher(failingFlusher{}, after)
+
+		err := flusher.FlushClicks(context.Background(), map[string]int64{"abc123": 1})
+
+		assert.ErrorContains(t, err, "database unavailable")
+		assert.Equal(t, map[string]int64{"abc123": 1}, after.getCounts())
+	})
+
+	t.Run("feeds a click counter", func(t *testing.T) {
+		db, sink := newMockBucketFlusher(), newMockFlusher()
+		counter := NewClickCounter(Config{FlushInterval: time.Hour, BatchSize: 1000}, NewMultiFlusher(db, sink))
+
+		counter.RecordClick(context.Background(), "abc123")
+		counter.RecordClick(context.Background(), "abc123")
+		counter.Stop()
+
+		assert.Equal(t, map[string]int64{"abc123": 2}, db.getCounts())
+		assert.Equal(t, map[string]int64{"abc123": 2}, sink.getCounts())
+		assert.NotEmpty(t, db.buckets)
+	})
+}
+
+func TestHTTPFlusher(t *testing.T) {
+	t.Run("posts click events", func(t *testing.T) {
+		var batch HTTPClickBatch
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		flusher := NewHTTPFlusher(server.URL, server.Client(), nil)
+		require.NoError(t, flusher.FlushClicks(context.Background(), map[string]int64{"xyz789": 1, "abc123": 4}))
+
+		require.Len(t, batch.Events, 2)
+		assert.Equal(t, "abc123", batch.Events[0].ShortCode)
+		assert.Equal(t, int64(4), batch.Events[0].Clicks)
+		assert.Equal(t, "xyz789", batch.Events[1].ShortCode)
+		assert.False(t, batch.Events[0].FlushedAt.IsZero())
+	})
+
+	t.Run("fails on an error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		flusher := NewHTTPFlusher(server.URL, server.Client(), nil)
+		err := flusher.FlushClicks(context.Background(), map[string]int64{"abc123": 1})
+
+		assert.ErrorContains(t, err, "503")
+	})
+
+	t.Run("skips empty flushes", func(t *testing.T) {
+		flusher := NewHTTPFlusher("http://127.0.0.1:0", nil, nil)
+		assert.NoError(t, flusher.FlushClicks(context.Background(), nil))
+	})
+}
+
+// mockKafkaWriter records the messages written to it.
+type mockKafkaWriter struct {
+	msgs []kafka.Message
+	err  error
+}
+
+func (m *mockKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	m.msgs = append(m.msgs, msgs...)
+	return m.err
+}
+
+func TestKafkaFlusher(t *testing.T) {
+	t.Run("writes a message per short code", func(t *testing.T) {
+		writer := &mockKafkaWriter{}
+		flusher := NewKafkaFlusher(writer, nil)
+
+		require.NoError(t, flusher.FlushClicks(context.Background(), map[string]int64{"xyz789": 1, "abc123": 4}))
+
+		require.Len(t, writer.msgs, 2)
+		assert.Equal(t, "abc123", string(writer.msgs[0].Key))
+		var event ClickEvent
+		require.NoError(t, json.Unmarshal(writer.msgs[0].Value, &event))
+		assert.Equal(t, "abc123", event.ShortCode)
+		assert.Equal(t, int64(4), event.Clicks)
+		assert.Equal(t, "xyz789", string(writer.msgs[1].Key))
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockKafkaWriter{err: errors.New("broker unavailable")}
+		flusher := NewKafkaFlusher(writer, nil)
+
+		err := flusher.FlushClicks(context.Background(), map[string]int64{"abc123": 1})
+
+		assert.ErrorContains(t, err, "broker unavailable")
+	})
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Referrer values for clicks without a usable Referer header.
+const (
+	ReferrerDirect  = "direct"  // No Referer header
+	ReferrerUnknown = "unknown" // Referer header without a host
+)
+
+// Browser and OS value for user agents that are not recognized.
+const UnknownAgent = "Other"
+
+// maxReferrerLength caps stored referrer hosts; DNS names are at most 253 bytes.
+const maxReferrerLength = 253
+
+// ClickSource describes where a click came from.
+type ClickSource struct {
+	Referrer  string // Raw Referer header
+	UserAgent string // Raw User-Agent header
+	Country   string // ISO country code of the client; empty when not resolved
+}
+
+// ReferrerKey identifies the clicks of one short code from one referring host.
+type ReferrerKey struct {
+	ShortCode string
+	Referrer  string // Lowercase host, ReferrerDirect or ReferrerUnknown
+}
+
+// AgentKey identifies the clicks of one short code from one browser and OS.
+type AgentKey struct {
+	ShortCode string
+	Browser   string
+	OS        string
+}
+
+// CountryKey identifies the clicks of one short code from one country.
+type CountryKey struct {
+	ShortCode string
+	Country   string
+}
+
+// SourceFlusher is implemented by Flushers that also persist where clicks
+// came from. ClickCounter only tracks sources when its flusher implements it.
+type SourceFlusher interface {
+	FlushClickSources(ctx context.Context, referrers map[ReferrerKey]int64, agents map[AgentKey]int64) error
+}
+
+// GeoFlusher is implemented by Flushers that also persist click counts per
+// country. ClickCounter only tracks countries when its flusher implements it.
+type GeoFlusher interface {
+	FlushClickCountries(ctx context.Context, countries map[CountryKey]int64) error
+}
+
+// ReferrerHost reduces a Referer header to its lowercase host, without port
+// or leading "www.".
+func ReferrerHost(referer string) string {
+	referer = strings.TrimSpace(referer)
+	if referer == "" {
+		return ReferrerDirect
+	}
+
+	u, err := url.Parse(referer)
+	if err != nil || u.Host == "" {
+		return ReferrerUnknown
+	}
+
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" || len(host) > maxReferrerLength {
+		return ReferrerUnknown
+	}
+	return host
+}
+
+// uaMatcher maps a User-Agent substring to a name. Order matters: many
+// user agents mention several products, e.g. Edge also claims Chrome and Safari.
+type uaMatcher struct {
+	token string
+	name  string
+}
+
+var browserMatchers = []uaMatcher{
+	{"bot", "Bot"},
+	{"spider", "Bot"},
+	{"crawl", "Bot"},
+	{"curl/", "curl"},
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"edga/", "Edge"},
+	{"edgios/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"chrome/", "Chrome"},
+	{"crios/", "Chrome"},
+	{"safari/", "Safari"},
+	{"msie ", "Internet Explorer"},
+	{"trident/", "Internet Explorer"},
+}
+
+var osMatchers = []uaMatcher{
+	{"windows", "Windows"},
+	{"android", "Android"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// ParseUserAgent returns the browser and operating system named in a
+// User-Agent header, or UnknownAgent for parts it does not recognize.
+func ParseUserAgent(userAgent string) (browser, os string) {
+	ua := strings.ToLower(userAgent)
+	return matchUserAgent(ua, browserMatchers), matchUserAgent(ua, osMatchers)
+}
+
+func matchUserAgent(ua string, matchers []uaMatcher) string {
+	for _, m := range matchers {
+		if strings.Contains(ua, m.token) {
+			return m.name
+		}
+	}
+	return UnknownAgent
+}
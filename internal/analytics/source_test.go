@@ -0,0 +1,97 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferrerHost(t *testing.T) {
+	tests := []struct {
+		referer string
+		want    string
+	}{
+		{"", ReferrerDirect},
+		{"   ", ReferrerDirect},
+		{"https://www.Google.com/search?q=go", "google.com"},
+		{"https://news.ycombinator.com/item?id=1", "news.ycombinator.com"},
+		{"http://localhost:3000/page", "localhost"},
+		{"https://[::1]:8443/", "::1"},
+		{"android-app://com.slack", "com.slack"},
+		{"/relative/path", ReferrerUnknown},
+		{"not a url", ReferrerUnknown},
+		{"https://" + strings.Repeat("a", 300) + ".com/", ReferrerUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.referer, func(t *testing.T) {
+			assert.Equal(t, tt.want, ReferrerHost(tt.referer))
+		})
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name        string
+		userAgent   string
+		wantBrowser string
+		wantOS      string
+	}{
+		{
+			"Chrome on Windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			"Chrome", "Windows",
+		},
+		{
+			"Edge on Windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			"Edge", "Windows",
+		},
+		{
+			"Safari on macOS",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			"Safari", "macOS",
+		},
+		{
+			"Safari on iPhone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			"Safari", "iOS",
+		},
+		{
+			"Chrome on iPad",
+			"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			"Chrome", "iOS",
+		},
+		{
+			"Firefox on Linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			"Firefox", "Linux",
+		},
+		{
+			"Samsung Internet on Android",
+			"Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			"Samsung Internet", "Android",
+		},
+		{
+			"Opera on ChromeOS",
+			"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
+			"Opera", "ChromeOS",
+		},
+		{
+			"Googlebot",
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			"Bot", UnknownAgent,
+		},
+		{"curl", "curl/8.4.0", "curl", UnknownAgent},
+		{"empty", "", UnknownAgent, UnknownAgent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			browser, os := ParseUserAgent(tt.userAgent)
+			assert.Equal(t, tt.wantBrowser, browser)
+			assert.Equal(t, tt.wantOS, os)
+		})
+	}
+}
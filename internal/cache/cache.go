@@ -1,18 +1,25 @@
-// Package cache handles Redis caching operations.
+// Package cache handles Redis and in-memory caching operations.
 package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/emadnahed/FastGoLink/internal/config"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/emadnahed/FastGoLink/internal/cache")
+
 // Common errors
 var (
 	ErrCacheMiss    = errors.New("cache miss")
@@ -27,6 +34,10 @@ type Cache interface {
 	// Set stores a value in the cache with a TTL.
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 
+	// SetNX stores a value with a TTL only if the key does not exist yet,
+	// reporting whether it was stored. It is atomic, so it can act as a lock.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
 	// Delete removes a value from the cache.
 	Delete(ctx context.Context, key string) error
 
@@ -40,26 +51,90 @@ type Cache interface {
 	Close() error
 }
 
+// HealthReporter is implemented by caches that track their own availability.
+type HealthReporter interface {
+	// Healthy reports whether the cache was reachable at the last check.
+	Healthy() bool
+}
+
+// Ensure RedisCache implements Cache and HealthReporter
+var (
+	_ Cache          = (*RedisCache)(nil)
+	_ HealthReporter = (*RedisCache)(nil)
+)
+
 // RedisCache implements Cache using Redis.
 type RedisCache struct {
-	client *redis.Client
+	client  redis.UniversalClient
+	healthy atomic.Bool
+
+	// For the health monitor
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
 }
 
-// NewRedisCache creates a new Redis cache client.
+// NewRedisClient creates a client for the deployment mode in cfg: a single
+// node, a Redis Cluster, or a Sentinel-managed failover group. It does not
+// connect. maxRetries follows go-redis: 0 uses the default, -1 disables retries.
+func NewRedisClient(cfg *config.RedisConfig, maxRetries int) (redis.UniversalClient, error) {
+	addrs := cfg.AddrsList()
+	opts := &redis.UniversalOptions{
+		Addrs:      addrs,
+		Password:   cfg.Password,
+		DB:         cfg.DB,
+		PoolSize:   cfg.PoolSize,
+		MaxRetries: maxRetries,
+	}
+
+	switch cfg.Mode {
+	case config.RedisModeStandalone, "":
+		if cfg.MasterName != "" {
+			return nil, errors.New("redis master name is only used in sentinel mode")
+		}
+		opts.Addrs = []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+		return redis.NewClient(opts.Simple()), nil
+	case config.RedisModeCluster:
+		if len(addrs) == 0 {
+			return nil, errors.New("redis cluster mode requires at least one address")
+		}
+		if cfg.DB != 0 {
+			return nil, fmt.Errorf("redis cluster mode only supports DB 0, got %d", cfg.DB)
+		}
+		return redis.NewClusterClient(opts.Cluster()), nil
+	case config.RedisModeSentinel:
+		if len(addrs) == 0 {
+			return nil, errors.New("redis sentinel mode requires at least one sentinel address")
+		}
+		if cfg.MasterName == "" {
+			return nil, errors.New("redis sentinel mode requires a master name")
+		}
+		opts.MasterName = cfg.MasterName
+		return redis.NewFailoverClient(opts.Failover()), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", cfg.Mode)
+	}
+}
+
+// NewRedisCache creates a new Redis cache client for the mode in cfg.
 func NewRedisCache(ctx context.Context, cfg *config.RedisConfig) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password: cfg.Password,
-		DB:       cfg.DB,
-		PoolSize: cfg.PoolSize,
-	})
+	client, err := NewRedisClient(cfg, 0)
+	if err != nil {
+		return nil, err
+	}
 
 	// Verify connectivity
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisCache{client: client}, nil
+	c := &RedisCache{
+		client: client,
+		done:   make(chan struct{}),
+	}
+	c.healthy.Store(true)
+	return c, nil
 }
 
 // Get retrieves a value from the cache.
@@ -83,6 +158,15 @@ func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time
 	return nil
 }
 
+// SetNX stores a value with a TTL unless the key already exists.
+func (c *RedisCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	stored, err := c.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("cache setnx failed: %w", err)
+	}
+	return stored, nil
+}
+
 // Delete removes a value from the cache.
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
 	err := c.client.Del(ctx, key).Err()
@@ -106,13 +190,57 @@ func (c *RedisCache) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
 
-// Close closes the cache connection.
+// Healthy reports whether Redis answered the last health monitor ping.
+// It is always true if the monitor was never started.
+func (c *RedisCache) Healthy() bool {
+	return c.healthy.Load()
+}
+
+// StartHealthMonitor pings Redis every interval in the background and
+// tracks whether it is reachable. onChange, if non-nil, is called on every
+// transition with the new state and the ping error that caused it (nil on
+// recovery). The client redials on its own, so recovery needs no extra work.
+// It must be called at most once; Close stops the monitor.
+func (c *RedisCache) StartHealthMonitor(interval time.Duration, onChange func(healthy bool, err error)) {
+	c.wg.Add(1)
+	go c.monitorLoop(interval, onChange)
+}
+
+// monitorLoop periodically pings Redis and records transitions.
+func (c *RedisCache) monitorLoop(interval time.Duration, onChange func(healthy bool, err error)) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := c.Ping(ctx)
+			cancel()
+
+			healthy := err == nil
+			if c.healthy.Swap(healthy) != healthy && onChange != nil {
+				onChange(healthy, err)
+			}
+		}
+	}
+}
+
+// Close stops the health monitor and closes the cache connection.
 func (c *RedisCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
 	return c.client.Close()
 }
 
 // Client returns the underlying Redis client for advanced operations.
-func (c *RedisCache) Client() *redis.Client {
+func (c *RedisCache) Client() redis.UniversalClient {
 	return c.client
 }
 
@@ -125,6 +253,28 @@ type URLCacher interface {
 	Delete(ctx context.Context, shortCode string) error
 	Exists(ctx context.Context, shortCode string) (bool, error)
 	Ping(ctx context.Context) error
+	Healthy() bool
+	Stats() Stats
+}
+
+// Stats is a snapshot of URL cache lookup counters.
+type Stats struct {
+	Hits    uint64 `json:"hits"`    // Lookups served from cache
+	Misses  uint64 `json:"misses"`  // Lookups not served from cache, including expired entries
+	Expired uint64 `json:"expired"` // Entries found expired and evicted on lookup
+
+	// Coalesced counts misses that shared a concurrent lookup's database
+	// read instead of making their own. Set by the cached repository.
+	Coalesced uint64 `json:"coalesced"`
+}
+
+// HitRatio returns the fraction of lookups served from cache, or 0 if there were none.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
 }
 
 // Ensure URLCache implements URLCacher
@@ -135,10 +285,24 @@ type URLCache struct {
 	cache      Cache
 	keyPrefix  string
 	defaultTTL time.Duration
+	codec      Codec
+
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	expired atomic.Uint64
 }
 
-// NewURLCache creates a new URL-specific cache.
+// NewURLCache creates a new URL-specific cache that stores entries as JSON.
 func NewURLCache(cache Cache, keyPrefix string, defaultTTL time.Duration) *URLCache {
+	return NewURLCacheWithCodec(cache, keyPrefix, defaultTTL, JSONCodec{})
+}
+
+// NewURLCacheWithCodec creates a new URL-specific cache that stores entries
+// with the given codec. Entries written by a different codec read as misses.
+func NewURLCacheWithCodec(cache Cache, keyPrefix string, defaultTTL time.Duration, codec Codec) *URLCache {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
 	if keyPrefix == "" {
 		keyPrefix = "url:"
 	}
@@ -149,30 +313,60 @@ func NewURLCache(cache Cache, keyPrefix string, defaultTTL time.Duration) *URLCa
 		cache:      cache,
 		keyPrefix:  keyPrefix,
 		defaultTTL: defaultTTL,
+		codec:      codec,
 	}
 }
 
 // CachedURL represents a URL stored in cache.
 // Contains all fields from models.URL for complete data on cache hit.
 type CachedURL struct {
-	ID          int64      `json:"id"`
-	ShortCode   string     `json:"short_code"`
-	OriginalURL string     `json:"original_url"`
-	CreatedAt   time.Time  `json:"created_at"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
-	ClickCount  int64      `json:"click_count"`
+	ID           int64      `json:"id"`
+	ShortCode    string     `json:"short_code"`
+	OriginalURL  string     `json:"original_url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	ClickCount   int64      `json:"click_count"`
+	Permanent    bool       `json:"permanent,omitempty"`
+	PasswordHash string     `json:"password_hash,omitempty"`
+	MaxClicks    *int64     `json:"max_clicks,omitempty"`
+	ShowPreview  bool       `json:"show_preview,omitempty"`
+
+	AppendParams    map[string]string `json:"append_params,omitempty"`
+	PlatformTargets map[string]string `json:"platform_targets,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Disabled        bool              `json:"disabled,omitempty"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	LinkStatus      *int              `json:"link_status,omitempty"`
+	LinkCheckedAt   *time.Time        `json:"link_checked_at,omitempty"`
 }
 
 // Get retrieves a URL from cache by short code.
 func (c *URLCache) Get(ctx context.Context, shortCode string) (*CachedURL, error) {
+	ctx, span := tracer.Start(ctx, "URLCache.Get", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	url, err := c.get(ctx, shortCode)
+	span.SetAttributes(tracing.CacheHitKey.Bool(err == nil))
+	if err != nil && !errors.Is(err, ErrCacheMiss) && !errors.Is(err, ErrCacheExpired) {
+		// Misses are expected; only backend failures mark the span as failed
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return url, err
+}
+
+// get performs the lookup and updates the hit, miss and expiry counters.
+func (c *URLCache) get(ctx context.Context, shortCode string) (*CachedURL, error) {
 	key := c.key(shortCode)
 	data, err := c.cache.Get(ctx, key)
 	if err != nil {
+		c.misses.Add(1)
 		return nil, err
 	}
 
 	var url CachedURL
-	if err := json.Unmarshal(data, &url); err != nil {
+	if err := decode(c.codec, data, &url); err != nil {
+		c.misses.Add(1)
 		return nil, fmt.Errorf("failed to unmarshal cached URL: %w", err)
 	}
 
@@ -180,22 +374,37 @@ func (c *URLCache) Get(ctx context.Context, shortCode string) (*CachedURL, error
 	if url.ExpiresAt != nil && time.Now().After(*url.ExpiresAt) {
 		// Delete expired entry
 		_ = c.cache.Delete(ctx, key)
+		c.expired.Add(1)
+		c.misses.Add(1)
 		return nil, ErrCacheExpired
 	}
 
+	c.hits.Add(1)
 	return &url, nil
 }
 
+// Stats returns a snapshot of the lookup counters.
+func (c *URLCache) Stats() Stats {
+	return Stats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Expired: c.expired.Load(),
+	}
+}
+
 // Set stores a URL in cache.
 func (c *URLCache) Set(ctx context.Context, url *CachedURL) error {
 	return c.SetWithTTL(ctx, url, c.defaultTTL)
 }
 
 // SetWithTTL stores a URL in cache with a specific TTL.
-func (c *URLCache) SetWithTTL(ctx context.Context, url *CachedURL, ttl time.Duration) error {
+func (c *URLCache) SetWithTTL(ctx context.Context, url *CachedURL, ttl time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "URLCache.Set", trace.WithAttributes(tracing.ShortCodeKey.String(url.ShortCode)))
+	defer func() { tracing.End(span, err) }()
+
 	key := c.key(url.ShortCode)
 
-	data, err := json.Marshal(url)
+	data, err := encode(c.codec, url)
 	if err != nil {
 		return fmt.Errorf("failed to marshal URL: %w", err)
 	}
@@ -216,7 +425,10 @@ func (c *URLCache) SetWithTTL(ctx context.Context, url *CachedURL, ttl time.Dura
 }
 
 // Delete removes a URL from cache.
-func (c *URLCache) Delete(ctx context.Context, shortCode string) error {
+func (c *URLCache) Delete(ctx context.Context, shortCode string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLCache.Delete", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
 	return c.cache.Delete(ctx, c.key(shortCode))
 }
 
@@ -234,3 +446,12 @@ func (c *URLCache) key(shortCode string) string {
 func (c *URLCache) Ping(ctx context.Context) error {
 	return c.cache.Ping(ctx)
 }
+
+// Healthy reports whether the backing cache is available. Caches that do
+// not track their own health are always considered healthy.
+func (c *URLCache) Healthy() bool {
+	if reporter, ok := c.cache.(HealthReporter); ok {
+		return reporter.Healthy()
+	}
+	return true
+}
@@ -2,11 +2,11 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -91,6 +91,140 @@ func TestNewRedisCache_InvalidHost(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to connect to Redis")
 }
 
+func TestNewRedisClient_Modes(t *testing.T) {
+	t.Run("standalone", func(t *testing.T) {
+		client, err := NewRedisClient(&config.RedisConfig{Host: "localhost", Port: 6379}, 0)
+		require.NoError(t, err)
+		defer client.Close()
+		assert.IsType(t, &redis.Client{}, client)
+	})
+
+	t.Run("cluster", func(t *testing.T) {
+		client, err := NewRedisClient(&config.RedisConfig{
+			Mode:  config.RedisModeCluster,
+			Addrs: "redis-a:7000,redis-b:7001",
+		}, 0)
+		require.NoError(t, err)
+		defer client.Close()
+		assert.IsType(t, &redis.ClusterClient{}, client)
+	})
+
+	t.Run("sentinel", func(t *testing.T) {
+		client, err := NewRedisClient(&config.RedisConfig{
+			Mode:       config.RedisModeSentinel,
+			Addrs:      "sentinel-a:26379",
+			MasterName: "mymaster",
+		}, 0)
+		require.NoError(t, err)
+		defer client.Close()
+		assert.IsType(t, &redis.Client{}, client)
+	})
+}
+
+func TestNewRedisClient_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.RedisConfig
+		wantErr string
+	}{
+		{
+			name:    "unknown mode",
+			cfg:     config.RedisConfig{Mode: "replicated"},
+			wantErr: `unknown redis mode "replicated"`,
+		},
+		{
+			name:    "master name outside sentinel mode",
+			cfg:     config.RedisConfig{Host: "localhost", MasterName: "mymaster"},
+			wantErr: "only used in sentinel mode",
+		},
+		{
+			name:    "cluster without addresses",
+			cfg:     config.RedisConfig{Mode: config.RedisModeCluster},
+			wantErr: "requires at least one address",
+		},
+		{
+			name:    "cluster with non-zero DB",
+			cfg:     config.RedisConfig{Mode: config.RedisModeCluster, Addrs: "redis-a:7000", DB: 1},
+			wantErr: "only supports DB 0",
+		},
+		{
+			name:    "sentinel without master name",
+			cfg:     config.RedisConfig{Mode: config.RedisModeSentinel, Addrs: "sentinel-a:26379"},
+			wantErr: "requires a master name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRedisClient(&tt.cfg, 0)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestRedisCache_ClusterAndSentinel runs the Cache operations against a
+// Redis Cluster (TEST_REDIS_CLUSTER_ADDRS) and a Sentinel group
+// (TEST_REDIS_SENTINEL_ADDRS, TEST_REDIS_MASTER_NAME) when configured.
+func TestRedisCache_ClusterAndSentinel(t *testing.T) {
+	modes := []struct {
+		name string
+		cfg  *config.RedisConfig
+	}{
+		{
+			name: config.RedisModeCluster,
+			cfg: &config.RedisConfig{
+				Mode:     config.RedisModeCluster,
+				Addrs:    os.Getenv("TEST_REDIS_CLUSTER_ADDRS"),
+				Password: os.Getenv("REDIS_PASSWORD"),
+				PoolSize: 10,
+			},
+		},
+		{
+			name: config.RedisModeSentinel,
+			cfg: &config.RedisConfig{
+				Mode:       config.RedisModeSentinel,
+				Addrs:      os.Getenv("TEST_REDIS_SENTINEL_ADDRS"),
+				MasterName: getEnvOrDefault("TEST_REDIS_MASTER_NAME", "mymaster"),
+				Password:   os.Getenv("REDIS_PASSWORD"),
+				PoolSize:   10,
+			},
+		},
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			if mode.cfg.Addrs == "" {
+				t.Skipf("Skipping: no %s addresses configured", mode.name)
+			}
+
+			ctx := context.Background()
+			cache, err := NewRedisCache(ctx, mode.cfg)
+			require.NoError(t, err)
+			defer cache.Close()
+
+			// Spread keys across hash slots so cluster routing is exercised
+			for _, key := range []string{"test:mode:a", "test:mode:b", "test:mode:c"} {
+				require.NoError(t, cache.Set(ctx, key, []byte(key), time.Minute))
+
+				got, err := cache.Get(ctx, key)
+				require.NoError(t, err)
+				assert.Equal(t, []byte(key), got)
+
+				exists, err := cache.Exists(ctx, key)
+				require.NoError(t, err)
+				assert.True(t, exists)
+
+				require.NoError(t, cache.Delete(ctx, key))
+				_, err = cache.Get(ctx, key)
+				assert.ErrorIs(t, err, ErrCacheMiss)
+			}
+
+			assert.NoError(t, cache.Ping(ctx))
+		})
+	}
+}
+
 func TestRedisCache_SetAndGet(t *testing.T) {
 	cache, cleanup := setupTestRedis(t)
 	defer cleanup()
@@ -183,6 +317,28 @@ func TestRedisCache_Exists(t *testing.T) {
 	})
 }
 
+func TestRedisCache_SetNX(t *testing.T) {
+	cache, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	key := "test:setnx"
+	_ = cache.Delete(ctx, key)
+	defer func() { _ = cache.Delete(ctx, key) }()
+
+	stored, err := cache.SetNX(ctx, key, []byte("first"), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = cache.SetNX(ctx, key, []byte("second"), time.Minute)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	val, err := cache.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), val)
+}
+
 func TestRedisCache_Ping(t *testing.T) {
 	cache, cleanup := setupTestRedis(t)
 	defer cleanup()
@@ -193,6 +349,32 @@ func TestRedisCache_Ping(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRedisCache_HealthMonitor(t *testing.T) {
+	// Nothing listens on port 1, so every ping fails
+	cache := &RedisCache{
+		client: redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}),
+		done:   make(chan struct{}),
+	}
+	cache.healthy.Store(true)
+
+	changes := make(chan bool, 1)
+	cache.StartHealthMonitor(10*time.Millisecond, func(healthy bool, err error) {
+		assert.Error(t, err)
+		changes <- healthy
+	})
+
+	select {
+	case healthy := <-changes:
+		assert.False(t, healthy)
+	case <-time.After(2 * time.Second):
+		t.Fatal("health monitor did not report Redis as down")
+	}
+	assert.False(t, cache.Healthy())
+
+	// Close stops the monitor before closing the client
+	require.NoError(t, cache.Close())
+}
+
 // URLCache tests
 
 func TestNewURLCache(t *testing.T) {
@@ -267,7 +449,7 @@ func TestURLCache_SetAndGet(t *testing.T) {
 		}
 
 		// Force set it directly (bypassing the expiry check in SetWithTTL)
-		data, _ := json.Marshal(url)
+		data, _ := encode(JSONCodec{}, url)
 		err := cache.Set(ctx, "test:url:pastexp", data, time.Minute)
 		require.NoError(t, err)
 
@@ -394,6 +576,31 @@ func TestURLCache_Exists(t *testing.T) {
 	})
 }
 
+func TestURLCache_Healthy(t *testing.T) {
+	t.Run("cache without health tracking is healthy", func(t *testing.T) {
+		assert.True(t, NewURLCache(&MockCache{}, "", 0).Healthy())
+	})
+
+	t.Run("reports backing cache health", func(t *testing.T) {
+		backing := &healthReportingCache{}
+		urlCache := NewURLCache(backing, "", 0)
+		assert.False(t, urlCache.Healthy())
+
+		backing.healthy = true
+		assert.True(t, urlCache.Healthy())
+	})
+}
+
+// healthReportingCache is a MockCache with a settable health state.
+type healthReportingCache struct {
+	MockCache
+	healthy bool
+}
+
+func (c *healthReportingCache) Healthy() bool {
+	return c.healthy
+}
+
 func TestURLCache_Ping(t *testing.T) {
 	cache, cleanup := setupTestRedis(t)
 	defer cleanup()
@@ -430,6 +637,13 @@ func (m *MockCache) Set(_ context.Context, key string, value []byte, _ time.Dura
 	return nil
 }
 
+func (m *MockCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if _, ok := m.data[key]; ok {
+		return false, nil
+	}
+	return true, m.Set(ctx, key, value, ttl)
+}
+
 func (m *MockCache) Delete(_ context.Context, key string) error {
 	delete(m.data, key)
 	return nil
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec names accepted by CodecByName.
+const (
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+)
+
+// ErrCodecMismatch is returned when a cached value was written by a
+// different codec than the one reading it. Callers treat it like a miss.
+var ErrCodecMismatch = errors.New("cached value written by a different codec")
+
+// Codec serializes values stored by URLCache.
+type Codec interface {
+	// ID is written as the first byte of every encoded value so values
+	// written by another codec are rejected instead of misread.
+	ID() byte
+
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+// Codec IDs. Zero is never used, and neither is '{', so values written
+// before the header byte existed are rejected too.
+const (
+	jsonCodecID    byte = 1
+	msgpackCodecID byte = 2
+)
+
+// JSONCodec encodes values as JSON. It is the default.
+type JSONCodec struct{}
+
+// ID returns the JSON codec header byte.
+func (JSONCodec) ID() byte { return jsonCodecID }
+
+// Marshal encodes v as JSON.
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// MsgpackCodec encodes values as MessagePack, which is smaller and cheaper
+// to decode than JSON. Field names and omitempty follow the json tags.
+type MsgpackCodec struct{}
+
+// ID returns the msgpack codec header byte.
+func (MsgpackCodec) ID() byte { return msgpackCodecID }
+
+// Marshal encodes v as MessagePack.
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes MessagePack data into v.
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// CodecByName returns the codec for a configured name.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case CodecJSON, "":
+		return JSONCodec{}, nil
+	case CodecMsgpack:
+		return MsgpackCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache codec %q", name)
+	}
+}
+
+// encode marshals v with codec and prefixes the codec's header byte.
+func encode(codec Codec, v any) ([]byte, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{codec.ID()}, data...), nil
+}
+
+// decode checks the header byte and unmarshals the rest with codec.
+func decode(codec Codec, data []byte, v any) error {
+	if len(data) == 0 || data[0] != codec.ID() {
+		return ErrCodecMismatch
+	}
+	return codec.Unmarshal(data[1:], v)
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCachedURL() *CachedURL {
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+	maxClicks := int64(100)
+	return &CachedURL{
+		ID:              42,
+		ShortCode:       "abc1234",
+		OriginalURL:     "https://example.com/some/long/path?with=query",
+		CreatedAt:       time.Now().UTC().Truncate(time.Millisecond),
+		ExpiresAt:       &expiry,
+		ClickCount:      1234,
+		MaxClicks:       &maxClicks,
+		ShowPreview:     true,
+		AppendParams:    map[string]string{"utm_source": "newsletter"},
+		PlatformTargets: map[string]string{"ios": "https://apps.apple.com/app"},
+		Tags:            []string{"campaign", "spring"},
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec{}, MsgpackCodec{}} {
+		want := testCachedURL()
+
+		data, err := encode(codec, want)
+		require.NoError(t, err)
+		assert.Equal(t, codec.ID(), data[0])
+
+		var got CachedURL
+		require.NoError(t, decode(codec, data, &got))
+		assert.Equal(t, want.ShortCode, got.ShortCode)
+		assert.Equal(t, want.OriginalURL, got.OriginalURL)
+		assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+		require.NotNil(t, got.ExpiresAt)
+		assert.True(t, want.ExpiresAt.Equal(*got.ExpiresAt))
+		assert.Equal(t, want.MaxClicks, got.MaxClicks)
+		assert.Equal(t, want.AppendParams, got.AppendParams)
+		assert.Equal(t, want.PlatformTargets, got.PlatformTargets)
+		assert.Equal(t, want.Tags, got.Tags)
+	}
+}
+
+func TestCodecs_MismatchFailsCleanly(t *testing.T) {
+	jsonData, err := encode(JSONCodec{}, testCachedURL())
+	require.NoError(t, err)
+	msgpackData, err := encode(MsgpackCodec{}, testCachedURL())
+	require.NoError(t, err)
+
+	var url CachedURL
+	assert.ErrorIs(t, decode(MsgpackCodec{}, jsonData, &url), ErrCodecMismatch)
+	assert.ErrorIs(t, decode(JSONCodec{}, msgpackData, &url), ErrCodecMismatch)
+
+	// Values written before the header byte existed are rejected too
+	assert.ErrorIs(t, decode(JSONCodec{}, []byte(`{"short_code":"abc"}`), &url), ErrCodecMismatch)
+	assert.ErrorIs(t, decode(JSONCodec{}, nil, &url), ErrCodecMismatch)
+}
+
+func TestURLCache_CodecMismatchIsMiss(t *testing.T) {
+	backing := &MockCache{}
+	ctx := context.Background()
+
+	writer := NewURLCacheWithCodec(backing, "url:", time.Hour, JSONCodec{})
+	require.NoError(t, writer.Set(ctx, testCachedURL()))
+
+	reader := NewURLCacheWithCodec(backing, "url:", time.Hour, MsgpackCodec{})
+	_, err := reader.Get(ctx, "abc1234")
+	assert.ErrorIs(t, err, ErrCodecMismatch)
+	assert.Equal(t, uint64(1), reader.Stats().Misses)
+
+	// Rewriting with the reader's codec makes the entry readable again
+	require.NoError(t, reader.Set(ctx, testCachedURL()))
+	got, err := reader.Get(ctx, "abc1234")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/some/long/path?with=query", got.OriginalURL)
+}
+
+func TestCodecByName(t *testing.T) {
+	codec, err := CodecByName("")
+	require.NoError(t, err)
+	assert.IsType(t, JSONCodec{}, codec)
+
+	codec, err = CodecByName(CodecMsgpack)
+	require.NoError(t, err)
+	assert.IsType(t, MsgpackCodec{}, codec)
+
+	_, err = CodecByName("gob")
+	assert.Error(t, err)
+}
+
+func BenchmarkCodec(b *testing.B) {
+	for _, bc := range []struct {
+		name  string
+		codec Codec
+	}{
+		{CodecJSON, JSONCodec{}},
+		{CodecMsgpack, MsgpackCodec{}},
+	} {
+		url := testCachedURL()
+		data, err := encode(bc.codec, url)
+		require.NoError(b, err)
+
+		b.Run(bc.name+"/marshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = encode(bc.codec, url)
+			}
+			b.ReportMetric(float64(len(data)), "bytes/value")
+		})
+
+		b.Run(bc.name+"/unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var decoded CachedURL
+				_ = decode(bc.codec, data, &decoded)
+			}
+		})
+	}
+}
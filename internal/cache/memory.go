@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Ensure MemoryCache implements Cache
+var _ Cache = (*MemoryCache)(nil)
+
+// MemoryCache implements Cache in process memory with LRU eviction.
+// Entries are evicted once maxEntries is reached, and expired entries are
+// removed lazily on access and periodically by a background sweeper.
+type MemoryCache struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	order      *list.List // Front is most recently used
+	maxEntries int
+
+	// For the sweeper
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// memoryEntry is a single cached value.
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // Zero means no expiry
+}
+
+// expired reports whether the entry has expired at the given time.
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// NewMemoryCache creates a new in-memory LRU cache.
+// A non-positive maxEntries means unbounded; a non-positive sweepInterval disables the sweeper.
+func NewMemoryCache(maxEntries int, sweepInterval time.Duration) *MemoryCache {
+	c := &MemoryCache{
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		done:       make(chan struct{}),
+	}
+
+	if sweepInterval > 0 {
+		c.wg.Add(1)
+		go c.sweepLoop(sweepInterval)
+	}
+
+	return c
+}
+
+// Get retrieves a value from the cache.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if entry.expired(time.Now()) {
+		c.removeElement(elem)
+		return nil, ErrCacheMiss
+	}
+
+	c.order.MoveToFront(elem)
+
+	value := make([]byte, len(entry.value))
+	copy(value, entry.value)
+	return value, nil
+}
+
+// Set stores a value in the cache with a TTL. A non-positive TTL means no expiry.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, ttl)
+	return nil
+}
+
+// SetNX stores a value with a TTL unless an unexpired entry already exists
+// for key. A non-positive TTL means no expiry.
+func (c *MemoryCache) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok && !elem.Value.(*memoryEntry).expired(time.Now()) {
+		return false, nil
+	}
+	c.set(key, value, ttl)
+	return true, nil
+}
+
+// Delete removes a value from the cache.
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	return nil
+}
+
+// Exists checks if a key exists in the cache.
+func (c *MemoryCache) Exists(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return false, nil
+	}
+	if elem.Value.(*memoryEntry).expired(time.Now()) {
+		c.removeElement(elem)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Ping always succeeds for the in-memory cache.
+func (c *MemoryCache) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close stops the background sweeper.
+func (c *MemoryCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+	return nil
+}
+
+// Len returns the number of entries currently held, including expired
+// entries that have not been swept yet.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// set stores a copy of value under key, evicting over capacity. The caller
+// must hold c.mu.
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	stored := make([]byte, len(value))
+	copy(stored, value)
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = stored
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&memoryEntry{
+		key:       key,
+		value:     stored,
+		expiresAt: expiresAt,
+	})
+
+	// Evict least recently used entries over capacity
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// removeElement removes an element from the list and index.
+// Must be called with c.mu held.
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*memoryEntry).key)
+}
+
+// sweepLoop periodically removes expired entries.
+func (c *MemoryCache) sweepLoop(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.sweep()
+		}
+	}
+}
+
+// sweep removes all expired entries.
+func (c *MemoryCache) sweep() {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*memoryEntry).expired(now) {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
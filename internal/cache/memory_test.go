@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_SetGet(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+}
+
+func TestMemoryCache_Miss(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	_, err := c.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestMemoryCache_ValueIsCopied(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	value := []byte("value")
+	require.NoError(t, c.Set(ctx, "key", value, time.Minute))
+	value[0] = 'X'
+
+	got, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	got[1] = 'Y'
+
+	again, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), again)
+}
+
+func TestMemoryCache_TTLExpiry(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "short", []byte("v"), 20*time.Millisecond))
+	require.NoError(t, c.Set(ctx, "forever", []byte("v"), 0))
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, err := c.Get(ctx, "short")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	exists, err := c.Exists(ctx, "short")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = c.Get(ctx, "forever")
+	assert.NoError(t, err)
+}
+
+func TestMemoryCache_LRUEviction(t *testing.T) {
+	c := NewMemoryCache(2, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// Touch "a" so "b" becomes least recently used
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	assert.Equal(t, 2, c.Len())
+	_, err = c.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	_, err = c.Get(ctx, "a")
+	assert.NoError(t, err)
+	_, err = c.Get(ctx, "c")
+	assert.NoError(t, err)
+}
+
+func TestMemoryCache_OverwriteDoesNotEvict(t *testing.T) {
+	c := NewMemoryCache(2, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+	require.NoError(t, c.Set(ctx, "a", []byte("updated"), time.Minute))
+
+	assert.Equal(t, 2, c.Len())
+	val, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("updated"), val)
+}
+
+func TestMemoryCache_DeleteAndExists(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("v"), time.Minute))
+
+	exists, err := c.Exists(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, c.Delete(ctx, "key"))
+	require.NoError(t, c.Delete(ctx, "missing"))
+
+	exists, err = c.Exists(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMemoryCache_SetNX(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	ctx := context.Background()
+	stored, err := c.SetNX(ctx, "key", []byte("first"), 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = c.SetNX(ctx, "key", []byte("second"), time.Minute)
+	require.NoError(t, err)
+	assert.False(t, stored)
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), val)
+
+	// An expired entry no longer holds the key
+	time.Sleep(60 * time.Millisecond)
+	stored, err = c.SetNX(ctx, "key", []byte("third"), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, stored)
+}
+
+func TestMemoryCache_SetNXConcurrent(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stored, err := c.SetNX(context.Background(), "lock", []byte("x"), time.Minute)
+			assert.NoError(t, err)
+			if stored {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, winners)
+}
+
+func TestMemoryCache_Sweeper(t *testing.T) {
+	c := NewMemoryCache(10, 10*time.Millisecond)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("v"), 5*time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return c.Len() == 0
+	}, time.Second, 10*time.Millisecond, "sweeper should remove expired entries")
+}
+
+func TestMemoryCache_PingAndClose(t *testing.T) {
+	c := NewMemoryCache(10, time.Minute)
+
+	assert.NoError(t, c.Ping(context.Background()))
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close(), "close should be idempotent")
+}
+
+func TestMemoryCache_Concurrency(t *testing.T) {
+	c := NewMemoryCache(50, time.Millisecond)
+	defer c.Close()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("key-%d", (i*100+j)%80)
+				_ = c.Set(ctx, key, []byte("v"), time.Millisecond*time.Duration(j%5))
+				_, _ = c.Get(ctx, key)
+				_, _ = c.Exists(ctx, key)
+				if j%10 == 0 {
+					_ = c.Delete(ctx, key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Len(), 50)
+}
+
+func TestMemoryCache_WithURLCache(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	urlCache := NewURLCache(c, "test:", time.Minute)
+	ctx := context.Background()
+
+	require.NoError(t, urlCache.Set(ctx, &CachedURL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com",
+		CreatedAt:   time.Now(),
+	}))
+
+	got, err := urlCache.Get(ctx, "abc1234")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got.OriginalURL)
+
+	_, err = urlCache.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestMemoryCache_URLCacheTTLCappedToExpiry(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	urlCache := NewURLCache(c, "test:", time.Hour)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+	require.NoError(t, urlCache.Set(ctx, &CachedURL{
+		ShortCode:   "cap1234",
+		OriginalURL: "https://example.com",
+		ExpiresAt:   &expiresAt,
+	}))
+
+	time.Sleep(80 * time.Millisecond)
+
+	// The entry itself is gone, not just reported as expired by URLCache
+	_, err := c.Get(ctx, "test:cap1234")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestURLCache_Stats(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	defer c.Close()
+
+	urlCache := NewURLCache(c, "test:", time.Minute)
+	ctx := context.Background()
+
+	assert.Equal(t, Stats{}, urlCache.Stats())
+	assert.Equal(t, float64(0), urlCache.Stats().HitRatio())
+
+	require.NoError(t, urlCache.Set(ctx, &CachedURL{ShortCode: "hit", OriginalURL: "https://example.com"}))
+	_, err := urlCache.Get(ctx, "hit")
+	require.NoError(t, err)
+	_, err = urlCache.Get(ctx, "hit")
+	require.NoError(t, err)
+
+	_, err = urlCache.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	// Store an already-expired entry directly so URLCache sees it on lookup
+	past := time.Now().Add(-time.Minute)
+	data, err := encode(JSONCodec{}, &CachedURL{ShortCode: "old", OriginalURL: "https://example.com", ExpiresAt: &past})
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "test:old", data, time.Minute))
+	_, err = urlCache.Get(ctx, "old")
+	assert.ErrorIs(t, err, ErrCacheExpired)
+
+	stats := urlCache.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Expired)
+	assert.Equal(t, 0.5, stats.HitRatio())
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -11,19 +12,30 @@ import (
 
 // Config holds all configuration for the application.
 type Config struct {
-	App      AppConfig
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	URL      URLConfig
-	Rate     RateLimitConfig
-	Security SecurityConfig
+	App         AppConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	Cache       CacheConfig
+	URL         URLConfig
+	Rate        RateLimitConfig
+	Security    SecurityConfig
+	Metrics     MetricsConfig
+	Debug       DebugConfig
+	Tracing     TracingConfig
+	GeoIP       GeoIPConfig
+	Analytics   AnalyticsConfig
+	Reaper      ReaperConfig
+	LinkCheck   LinkCheckConfig
+	Webhook     WebhookConfig
+	Idempotency IdempotencyConfig
 }
 
 // AppConfig holds application-level configuration.
 type AppConfig struct {
-	Env      string
-	LogLevel string
+	Env       string
+	LogLevel  string
+	AccessLog bool // Log one line per HTTP request
 }
 
 // IsDevelopment returns true if the app is running in development mode.
@@ -43,6 +55,18 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+
+	ReadHeaderTimeout time.Duration // Time allowed to read request headers; drops slow-header clients
+	IdleTimeout       time.Duration // How long keep-alive connections wait for the next request
+	MaxHeaderBytes    int           // Maximum size of request headers
+	H2CEnabled        bool          // Accept cleartext HTTP/2 (h2c) alongside HTTP/1.1
+
+	UnixSocket     string      // Listen on this Unix domain socket path instead of TCP
+	UnixSocketMode os.FileMode // Permissions applied to the socket file
+
+	RequestTimeout time.Duration // Deadline for handling a request, after which it fails with 503; 0 disables
+	ErrorFormat    string        // Error response body: "json" (default) or "problem" for RFC 7807 problem+json
+	BasePath       string        // Path prefix all API, docs and redirect routes are served under, e.g. "/shortener"; empty for the root
 }
 
 // Address returns the server address in host:port format.
@@ -61,8 +85,44 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	ReplicaHosts    string // Comma-separated read replica hosts (host or host:port); empty disables replicas
+
+	// CheckSchemaVersion makes /ready fail until the schema has been
+	// migrated to SchemaVersion, or to the newest embedded migration when
+	// SchemaVersion is 0.
+	CheckSchemaVersion bool
+	SchemaVersion      int
+}
+
+// ReplicaConfigs returns a connection config for each read replica. Replicas
+// share every setting with the primary except the host and port; a host
+// without a port uses the primary's port.
+func (d DatabaseConfig) ReplicaConfigs() ([]DatabaseConfig, error) {
+	hosts := splitList(d.ReplicaHosts)
+	configs := make([]DatabaseConfig, 0, len(hosts))
+	for _, entry := range hosts {
+		replica := d
+		replica.ReplicaHosts = ""
+		replica.Host = entry
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			p, err := strconv.Atoi(port)
+			if err != nil || !validPort(p) {
+				return nil, fmt.Errorf("has an invalid port in %q", entry)
+			}
+			replica.Host, replica.Port = host, p
+		}
+		configs = append(configs, replica)
+	}
+	return configs, nil
 }
 
+// Redis deployment modes.
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeCluster    = "cluster"
+	RedisModeSentinel   = "sentinel"
+)
+
 // RedisConfig holds Redis connection configuration.
 type RedisConfig struct {
 	Host      string
@@ -72,44 +132,277 @@ type RedisConfig struct {
 	PoolSize  int
 	KeyPrefix string
 	CacheTTL  time.Duration
+
+	HealthCheckInterval time.Duration // How often to ping Redis to detect outages and recovery
+
+	Mode       string // "standalone" (Host and Port), "cluster" or "sentinel" (Addrs)
+	Addrs      string // Comma-separated host:port seed nodes (cluster) or sentinels (sentinel)
+	MasterName string // Sentinel master name; sentinel mode only
+}
+
+// AddrsList returns the cluster or sentinel addresses as a slice.
+func (r RedisConfig) AddrsList() []string {
+	return splitList(r.Addrs)
+}
+
+// CacheConfig holds configuration for the in-memory cache used when Redis is unavailable.
+type CacheConfig struct {
+	MemoryMaxEntries    int           // Maximum cached URLs before LRU eviction
+	MemorySweepInterval time.Duration // How often expired entries are removed
+	Codec               string        // Serialization for cached URLs: "json" or "msgpack"
 }
 
 // URLConfig holds URL shortener specific configuration.
 type URLConfig struct {
 	BaseURL         string
+	ShortDomains    string // Comma-separated extra hosts short URLs may be built on, chosen per request
 	ShortCodeLen    int
-	DefaultExpiry   time.Duration
-	IDGenStrategy   string
+	MaxShortCodeLen int           // Longest short code accepted; must fit the short_code column
+	DefaultExpiry   time.Duration // Expiry for links created without one; 0 never expires
+	MaxExpiry       time.Duration // Longest expiry a link may have; 0 means no cap
+	IDGenStrategy   string        // "random", "sequential" or "snowflake"
 	IDGenMaxRetries int
+	AliasMinLength  int
+	AliasMaxLength  int
+
+	IDGenRetryBackoff    time.Duration // Wait before the first collision retry, doubled per retry; 0 retries at once
+	IDGenMaxBackoff      time.Duration // Upper bound on the wait between collision retries
+	IDGenLengthBumpAfter int           // Consecutive collisions before retrying with one more character; 0 disables
+	CreateMaxRetries     int           // Retries with a new code when a generated code is taken on insert (default: 3)
+
+	SnowflakeMachineID int       // Unique per instance when IDGenStrategy is "snowflake"; 0-1023
+	SnowflakeEpoch     time.Time // Time snowflake IDs count from; shared by all instances
+
+	NormalizeStripTrailingSlash bool // Remove trailing slashes from destination paths before storing
+	NormalizeStripFragment      bool // Drop #fragments from destination URLs before storing
+
+	Dedupe bool // Return the existing short code when a destination is shortened again
+
+	ReservedCodes string // Comma-separated codes never minted or accepted as aliases
+
+	RootRedirectURL string // Where GET / redirects to; empty responds 404
+	NotFoundURL     string // Where unknown short codes and paths redirect to
+	NotFoundPage    string // HTML file served with 404 for unknown short codes and paths
+	ExpiredURL      string // Where expired links redirect to; empty responds 410
 }
 
 // RateLimitConfig holds rate limiting configuration.
 type RateLimitConfig struct {
-	Enabled      bool          // Whether rate limiting is enabled
-	Requests     int           // Max requests per window
-	Window       time.Duration // Time window
-	TrustProxy   bool          // Trust X-Forwarded-For header
-	APIKeyHeader string        // Header name for API key (e.g., "X-API-Key")
+	Enabled        bool          // Whether rate limiting is enabled
+	Requests       int           // Max requests per window
+	Window         time.Duration // Time window
+	TrustProxy     bool          // Trust X-Forwarded-For header
+	TrustedProxies string        // Comma-separated proxy IPs and CIDR ranges allowed to set X-Forwarded-For; empty trusts all
+	AllowlistCIDRs string        // Comma-separated client IPs and CIDR ranges that bypass rate limiting
+	APIKeyHeader   string        // Header name for API key (e.g., "X-API-Key")
+	Backend        string        // Limiter backend: "memory" (per instance) or "redis" (shared)
+	Algorithm      string        // Memory limiter algorithm: "sliding_window" or "token_bucket"
+	BurstSize      int           // Token bucket capacity (0 = Requests)
+	RefillRate     float64       // Token bucket refill rate per second (0 = Requests/Window)
+	Routes         []RouteRateLimit
+}
+
+// RouteRateLimit overrides the rate limit for requests under a path prefix.
+type RouteRateLimit struct {
+	Prefix   string        // Path prefix, e.g. "/api/v1/shorten"
+	Requests int           // Max requests per window
+	Window   time.Duration // Time window
+}
+
+// MetricsConfig holds Prometheus metrics configuration.
+type MetricsConfig struct {
+	Enabled bool // Record request metrics and serve GET /metrics
+}
+
+// DebugConfig holds configuration for debugging endpoints.
+type DebugConfig struct {
+	// PprofEnabled serves net/http/pprof under /debug/pprof/. Profiles expose
+	// memory contents, command-line flags and goroutine stacks, so it is off
+	// by default and requires an API key when SECURITY_API_KEYS is set.
+	PprofEnabled bool
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	Enabled     bool    // Export spans to an OTLP collector
+	Endpoint    string  // OTLP/HTTP collector endpoint (host:port)
+	Insecure    bool    // Use plain HTTP instead of TLS for the exporter
+	ServiceName string  // service.name resource attribute
+	SampleRatio float64 // Fraction of new traces to sample (0.0 - 1.0)
+}
+
+// GeoIPConfig holds GeoIP lookup configuration.
+type GeoIPConfig struct {
+	DBPath string // Path to a MaxMind country database; empty disables country analytics
+}
+
+// AnalyticsConfig holds click analytics configuration.
+type AnalyticsConfig struct {
+	JournalPath     string        // File pending click counts are saved to; empty disables the journal
+	JournalInterval time.Duration // How often pending click counts are saved (default: 1s)
+	BlockOnFull     bool          // Make redirects wait for room instead of dropping clicks when the click buffer is full
+	ClickCountMode  string        // How redirects count clicks: "batched", "sync" or "off" (default: batched)
+
+	ArchiveInterval  time.Duration // How often hot click counters are archived; 0 disables archiving
+	ArchiveThreshold int           // Live click count at which a URL's counter is archived (default: 1000000)
+
+	// Sinks lists external systems that also receive flushed click counts,
+	// comma-separated: "http" and "kafka". The database always does.
+	Sinks           string
+	HTTPSinkURL     string        // Endpoint the http sink posts click batches to
+	HTTPSinkTimeout time.Duration // Timeout of a single http sink request (default: 5s)
+	KafkaBrokers    string        // Comma-separated host:port list of Kafka brokers for the kafka sink
+	KafkaTopic      string        // Topic the kafka sink writes click events to
+}
+
+// SinksList returns the external analytics sinks as a slice.
+func (a AnalyticsConfig) SinksList() []string {
+	return splitList(a.Sinks)
+}
+
+// KafkaBrokersList returns the Kafka broker addresses as a slice.
+func (a AnalyticsConfig) KafkaBrokersList() []string {
+	return splitList(a.KafkaBrokers)
+}
+
+// ReaperConfig holds expired URL cleanup configuration.
+type ReaperConfig struct {
+	Enabled  bool          // Periodically delete expired URLs (default: true)
+	Interval time.Duration // How often expired URLs are deleted (default: 1h)
+}
+
+// LinkCheckConfig holds destination link checking configuration.
+type LinkCheckConfig struct {
+	Enabled      bool          // Periodically check that destinations respond (default: false)
+	Interval     time.Duration // How often a batch of links is checked (default: 10m)
+	RecheckAfter time.Duration // Minimum time between checks of the same link (default: 24h)
+	BatchSize    int           // Links checked per pass (default: 100)
+	Concurrency  int           // Maximum checks in flight (default: 4)
+	RateLimit    int           // Maximum checks started per second; 0 for no limit (default: 5)
+	Timeout      time.Duration // Timeout for a single check (default: 10s)
+}
+
+// WebhookConfig holds link event webhook configuration.
+type WebhookConfig struct {
+	URL             string        // Endpoint link events are POSTed to; empty disables webhooks
+	Secret          string        // Shared secret for HMAC-SHA256 payload signatures
+	Timeout         time.Duration // Timeout for a single delivery attempt (default: 5s)
+	MaxRetries      int           // Retries after a failed delivery (default: 3)
+	ClickMilestones string        // Comma-separated click counts that trigger an event (default: 100,1000,10000)
+}
+
+// Enabled returns true if a webhook endpoint is configured.
+func (w WebhookConfig) Enabled() bool {
+	return w.URL != ""
+}
+
+// ClickMilestoneList returns the click milestones as numbers.
+func (w WebhookConfig) ClickMilestoneList() ([]int64, error) {
+	var milestones []int64
+	for _, item := range splitList(w.ClickMilestones) {
+		n, err := strconv.ParseInt(item, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid click milestone %q", item)
+		}
+		milestones = append(milestones, n)
+	}
+	return milestones, nil
+}
+
+// IdempotencyConfig holds configuration for Idempotency-Key handling on
+// shorten requests.
+type IdempotencyConfig struct {
+	Enabled bool          // Replay responses for repeated Idempotency-Key headers (default: true)
+	TTL     time.Duration // How long responses are remembered (default: 24h)
 }
 
 // SecurityConfig holds security configuration.
 type SecurityConfig struct {
-	MaxURLLength    int    // Maximum allowed URL length (default: 2048)
-	AllowPrivateIPs bool   // Allow private IPs as redirect targets (default: false)
-	BlockedHosts    string // Comma-separated list of blocked hostnames
+	MaxURLLength    int           // Maximum allowed URL length (default: 2048)
+	AllowPrivateIPs bool          // Allow private IPs as redirect targets (default: false)
+	BlockedHosts    string        // Comma-separated list of blocked hostnames
+	AllowedHosts    string        // Comma-separated list of allowed hostnames; empty allows any host
+	AllowedSchemes  string        // Comma-separated list of accepted URL schemes (default: http,https)
+	ResolveHosts    bool          // Resolve hostnames and reject those pointing at private IPs (default: false)
+	ResolveTimeout  time.Duration // Timeout for each host lookup (default: 2s)
+	MaxBodyBytes    int64         // Maximum request body size for write endpoints (default: 1 MiB)
+	APIKeys         string        // Comma-separated API keys required on write endpoints; empty disables auth
+	InfoRequiresKey bool          // Also require an API key on GET /info (default: false)
+
+	Headers                   bool   // Set browser security headers on responses (default: true)
+	FrameOptions              string // X-Frame-Options value (default: DENY)
+	ReferrerPolicy            string // Referrer-Policy value (default: no-referrer)
+	ContentSecurityPolicy     string // CSP for API responses
+	DocsContentSecurityPolicy string // CSP for /docs pages, which load their UI from CDNs
 }
 
+const (
+	// DefaultContentSecurityPolicy forbids loading any content, which suits JSON and redirect responses.
+	DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+	// DefaultDocsContentSecurityPolicy allows the CDN scripts, styles and fonts used by
+	// the Scalar, Swagger UI and Redoc pages, plus their inline bootstrap code.
+	DefaultDocsContentSecurityPolicy = "default-src 'self'; " +
+		"script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net https://unpkg.com https://cdn.redoc.ly; " +
+		"style-src 'self' 'unsafe-inline' https://unpkg.com https://fonts.googleapis.com; " +
+		"font-src 'self' data: https://fonts.gstatic.com https://cdn.jsdelivr.net; " +
+		"img-src 'self' data: https:; " +
+		"connect-src 'self'; " +
+		"worker-src 'self' blob:; " +
+		"frame-ancestors 'none'"
+)
+
 // BlockedHostsList returns the blocked hosts as a slice.
 func (s SecurityConfig) BlockedHostsList() []string {
-	if s.BlockedHosts == "" {
+	return splitList(s.BlockedHosts)
+}
+
+// AllowedHostsList returns the allowed hosts as a slice.
+func (s SecurityConfig) AllowedHostsList() []string {
+	return splitList(s.AllowedHosts)
+}
+
+// AllowedSchemesList returns the allowed URL schemes as a slice.
+func (s SecurityConfig) AllowedSchemesList() []string {
+	return splitList(s.AllowedSchemes)
+}
+
+// ShortDomainsList returns the extra short URL hosts as a slice.
+func (u URLConfig) ShortDomainsList() []string {
+	return splitList(u.ShortDomains)
+}
+
+// ReservedCodesList returns the reserved short codes as a slice.
+func (u URLConfig) ReservedCodesList() []string {
+	return splitList(u.ReservedCodes)
+}
+
+// TrustedProxiesList returns the trusted proxy IPs and CIDR ranges as a slice.
+func (r RateLimitConfig) TrustedProxiesList() []string {
+	return splitList(r.TrustedProxies)
+}
+
+// AllowlistCIDRsList returns the rate limit allowlist IPs and CIDR ranges as a slice.
+func (r RateLimitConfig) AllowlistCIDRsList() []string {
+	return splitList(r.AllowlistCIDRs)
+}
+
+// APIKeysList returns the API keys as a slice.
+func (s SecurityConfig) APIKeysList() []string {
+	return splitList(s.APIKeys)
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping empty entries.
+func splitList(value string) []string {
+	if value == "" {
 		return nil
 	}
-	hosts := strings.Split(s.BlockedHosts, ",")
-	result := make([]string, 0, len(hosts))
-	for _, h := range hosts {
-		h = strings.TrimSpace(h)
-		if h != "" {
-			result = append(result, h)
+	items := strings.Split(value, ",")
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
 		}
 	}
 	return result
@@ -122,6 +415,7 @@ func Load() (*Config, error) {
 	// App config
 	cfg.App.Env = getEnvOrDefault("APP_ENV", "development")
 	cfg.App.LogLevel = getEnvOrDefault("LOG_LEVEL", "info")
+	cfg.App.AccessLog = getEnvOrDefault("ACCESS_LOG_ENABLED", "true") == "true"
 
 	// Server config
 	cfg.Server.Host = getEnvOrDefault("SERVER_HOST", "0.0.0.0")
@@ -149,6 +443,35 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid SERVER_SHUTDOWN_TIMEOUT: %w", err)
 	}
 	cfg.Server.ShutdownTimeout = shutdownTimeout
+	readHeaderTimeout, err := getEnvAsDuration("SERVER_READ_HEADER_TIMEOUT", 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_READ_HEADER_TIMEOUT: %w", err)
+	}
+	cfg.Server.ReadHeaderTimeout = readHeaderTimeout
+	idleTimeout, err := getEnvAsDuration("SERVER_IDLE_TIMEOUT", 120*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_IDLE_TIMEOUT: %w", err)
+	}
+	cfg.Server.IdleTimeout = idleTimeout
+	maxHeaderBytes, err := getEnvAsInt("SERVER_MAX_HEADER_BYTES", 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_MAX_HEADER_BYTES: %w", err)
+	}
+	cfg.Server.MaxHeaderBytes = maxHeaderBytes
+	cfg.Server.H2CEnabled = getEnvOrDefault("SERVER_H2C_ENABLED", "false") == "true"
+	cfg.Server.UnixSocket = getEnvOrDefault("SERVER_UNIX_SOCKET", "")
+	socketMode, err := strconv.ParseUint(getEnvOrDefault("SERVER_UNIX_SOCKET_MODE", "0660"), 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_UNIX_SOCKET_MODE: %w", err)
+	}
+	cfg.Server.UnixSocketMode = os.FileMode(socketMode)
+	requestTimeout, err := getEnvAsDuration("SERVER_REQUEST_TIMEOUT", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_REQUEST_TIMEOUT: %w", err)
+	}
+	cfg.Server.RequestTimeout = requestTimeout
+	cfg.Server.ErrorFormat = getEnvOrDefault("SERVER_ERROR_FORMAT", "json")
+	cfg.Server.BasePath = strings.TrimSuffix(getEnvOrDefault("SERVER_BASE_PATH", ""), "/")
 
 	// Database config
 	cfg.Database.Host = getEnvOrDefault("DB_HOST", "localhost")
@@ -179,6 +502,13 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_CONN_MAX_LIFETIME: %w", err)
 	}
 	cfg.Database.ConnMaxLifetime = connMaxLifetime
+	cfg.Database.ReplicaHosts = getEnvOrDefault("DB_REPLICA_HOSTS", "")
+	cfg.Database.CheckSchemaVersion = getEnvOrDefault("DB_CHECK_SCHEMA_VERSION", "false") == "true"
+	schemaVersion, err := getEnvAsInt("DB_SCHEMA_VERSION", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_SCHEMA_VERSION: %w", err)
+	}
+	cfg.Database.SchemaVersion = schemaVersion
 
 	// Redis config
 	cfg.Redis.Host = getEnvOrDefault("REDIS_HOST", "localhost")
@@ -204,20 +534,105 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid REDIS_CACHE_TTL: %w", err)
 	}
 	cfg.Redis.CacheTTL = redisCacheTTL
+	redisHealthCheckInterval, err := getEnvAsDuration("REDIS_HEALTH_CHECK_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_HEALTH_CHECK_INTERVAL: %w", err)
+	}
+	cfg.Redis.HealthCheckInterval = redisHealthCheckInterval
+	cfg.Redis.Mode = getEnvOrDefault("REDIS_MODE", RedisModeStandalone)
+	cfg.Redis.Addrs = getEnvOrDefault("REDIS_ADDRS", "")
+	cfg.Redis.MasterName = getEnvOrDefault("REDIS_MASTER_NAME", "")
+
+	// In-memory cache config
+	memoryMaxEntries, err := getEnvAsInt("CACHE_MEMORY_MAX_ENTRIES", 10000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MEMORY_MAX_ENTRIES: %w", err)
+	}
+	cfg.Cache.MemoryMaxEntries = memoryMaxEntries
+	memorySweepInterval, err := getEnvAsDuration("CACHE_MEMORY_SWEEP_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MEMORY_SWEEP_INTERVAL: %w", err)
+	}
+	cfg.Cache.MemorySweepInterval = memorySweepInterval
+	cfg.Cache.Codec = getEnvOrDefault("CACHE_CODEC", "json")
 
 	// URL config
 	cfg.URL.BaseURL = getEnvOrDefault("URL_BASE_URL", "http://localhost:8080")
+	cfg.URL.ShortDomains = getEnvOrDefault("URL_SHORT_DOMAINS", "")
 	shortCodeLen, err := getEnvAsInt("URL_SHORT_CODE_LEN", 7)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL_SHORT_CODE_LEN: %w", err)
 	}
 	cfg.URL.ShortCodeLen = shortCodeLen
+	maxShortCodeLen, err := getEnvAsInt("URL_MAX_SHORT_CODE_LEN", MaxShortCodeLen)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_MAX_SHORT_CODE_LEN: %w", err)
+	}
+	cfg.URL.MaxShortCodeLen = maxShortCodeLen
 	cfg.URL.IDGenStrategy = getEnvOrDefault("URL_IDGEN_STRATEGY", "random")
+	snowflakeMachineID, err := getEnvAsInt("SNOWFLAKE_MACHINE_ID", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SNOWFLAKE_MACHINE_ID: %w", err)
+	}
+	cfg.URL.SnowflakeMachineID = snowflakeMachineID
+	snowflakeEpoch, err := time.Parse(time.RFC3339, getEnvOrDefault("SNOWFLAKE_EPOCH", "2024-01-01T00:00:00Z"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SNOWFLAKE_EPOCH: %w", err)
+	}
+	cfg.URL.SnowflakeEpoch = snowflakeEpoch
 	idGenMaxRetries, err := getEnvAsInt("URL_IDGEN_MAX_RETRIES", 3)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL_IDGEN_MAX_RETRIES: %w", err)
 	}
 	cfg.URL.IDGenMaxRetries = idGenMaxRetries
+	idGenRetryBackoff, err := getEnvAsDuration("URL_IDGEN_RETRY_BACKOFF", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_IDGEN_RETRY_BACKOFF: %w", err)
+	}
+	cfg.URL.IDGenRetryBackoff = idGenRetryBackoff
+	idGenMaxBackoff, err := getEnvAsDuration("URL_IDGEN_MAX_BACKOFF", 100*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_IDGEN_MAX_BACKOFF: %w", err)
+	}
+	cfg.URL.IDGenMaxBackoff = idGenMaxBackoff
+	idGenLengthBumpAfter, err := getEnvAsInt("URL_IDGEN_LENGTH_BUMP_AFTER", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_IDGEN_LENGTH_BUMP_AFTER: %w", err)
+	}
+	cfg.URL.IDGenLengthBumpAfter = idGenLengthBumpAfter
+	createMaxRetries, err := getEnvAsInt("URL_CREATE_MAX_RETRIES", 3)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_CREATE_MAX_RETRIES: %w", err)
+	}
+	cfg.URL.CreateMaxRetries = createMaxRetries
+	aliasMinLength, err := getEnvAsInt("URL_ALIAS_MIN_LENGTH", 3)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_ALIAS_MIN_LENGTH: %w", err)
+	}
+	cfg.URL.AliasMinLength = aliasMinLength
+	aliasMaxLength, err := getEnvAsInt("URL_ALIAS_MAX_LENGTH", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_ALIAS_MAX_LENGTH: %w", err)
+	}
+	cfg.URL.AliasMaxLength = aliasMaxLength
+	defaultExpiry, err := getEnvAsDuration("URL_DEFAULT_EXPIRY", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_DEFAULT_EXPIRY: %w", err)
+	}
+	cfg.URL.DefaultExpiry = defaultExpiry
+	maxExpiry, err := getEnvAsDuration("URL_MAX_EXPIRY", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_MAX_EXPIRY: %w", err)
+	}
+	cfg.URL.MaxExpiry = maxExpiry
+	cfg.URL.NormalizeStripTrailingSlash = getEnvOrDefault("URL_NORMALIZE_STRIP_TRAILING_SLASH", "false") == "true"
+	cfg.URL.NormalizeStripFragment = getEnvOrDefault("URL_NORMALIZE_STRIP_FRAGMENT", "false") == "true"
+	cfg.URL.Dedupe = getEnvOrDefault("DEDUPE_URLS", "false") == "true"
+	cfg.URL.ReservedCodes = getEnvOrDefault("URL_RESERVED_CODES", "api,debug,docs,health,info,metrics,ready")
+	cfg.URL.RootRedirectURL = getEnvOrDefault("URL_ROOT_REDIRECT", "")
+	cfg.URL.NotFoundURL = getEnvOrDefault("URL_NOT_FOUND_REDIRECT", "")
+	cfg.URL.NotFoundPage = getEnvOrDefault("URL_NOT_FOUND_PAGE", "")
+	cfg.URL.ExpiredURL = getEnvOrDefault("EXPIRED_REDIRECT_URL", "")
 
 	// Rate limit config
 	cfg.Rate.Enabled = getEnvOrDefault("RATE_LIMIT_ENABLED", "true") == "true"
@@ -232,7 +647,26 @@ func Load() (*Config, error) {
 	}
 	cfg.Rate.Window = rateLimitWindow
 	cfg.Rate.TrustProxy = getEnvOrDefault("RATE_LIMIT_TRUST_PROXY", "false") == "true"
+	cfg.Rate.TrustedProxies = getEnvOrDefault("RATE_LIMIT_TRUSTED_PROXIES", "")
+	cfg.Rate.AllowlistCIDRs = getEnvOrDefault("RATE_LIMIT_ALLOWLIST", "")
 	cfg.Rate.APIKeyHeader = getEnvOrDefault("RATE_LIMIT_API_KEY_HEADER", "X-API-Key")
+	cfg.Rate.Backend = getEnvOrDefault("RATE_LIMIT_BACKEND", "memory")
+	cfg.Rate.Algorithm = getEnvOrDefault("RATE_LIMIT_ALGORITHM", "sliding_window")
+	burstSize, err := getEnvAsInt("RATE_LIMIT_BURST_SIZE", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BURST_SIZE: %w", err)
+	}
+	cfg.Rate.BurstSize = burstSize
+	refillRate, err := getEnvAsFloat("RATE_LIMIT_REFILL_RATE", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_REFILL_RATE: %w", err)
+	}
+	cfg.Rate.RefillRate = refillRate
+	routeLimits, err := parseRouteRateLimits(os.Getenv("RATE_LIMIT_ROUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+	}
+	cfg.Rate.Routes = routeLimits
 
 	// Security config
 	maxURLLength, err := getEnvAsInt("SECURITY_MAX_URL_LENGTH", 2048)
@@ -242,6 +676,146 @@ func Load() (*Config, error) {
 	cfg.Security.MaxURLLength = maxURLLength
 	cfg.Security.AllowPrivateIPs = getEnvOrDefault("SECURITY_ALLOW_PRIVATE_IPS", "false") == "true"
 	cfg.Security.BlockedHosts = getEnvOrDefault("SECURITY_BLOCKED_HOSTS", "")
+	cfg.Security.AllowedHosts = getEnvOrDefault("SECURITY_ALLOWED_HOSTS", "")
+	cfg.Security.AllowedSchemes = getEnvOrDefault("SECURITY_ALLOWED_SCHEMES", "http,https")
+	cfg.Security.ResolveHosts = getEnvOrDefault("SECURITY_RESOLVE_HOSTS", "false") == "true"
+	resolveTimeout, err := getEnvAsDuration("SECURITY_RESOLVE_TIMEOUT", 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECURITY_RESOLVE_TIMEOUT: %w", err)
+	}
+	cfg.Security.ResolveTimeout = resolveTimeout
+	maxBodyBytes, err := getEnvAsInt("SECURITY_MAX_BODY_BYTES", 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECURITY_MAX_BODY_BYTES: %w", err)
+	}
+	cfg.Security.MaxBodyBytes = int64(maxBodyBytes)
+	cfg.Security.APIKeys = getEnvOrDefault("SECURITY_API_KEYS", "")
+	cfg.Security.InfoRequiresKey = getEnvOrDefault("SECURITY_INFO_REQUIRE_API_KEY", "false") == "true"
+	cfg.Security.Headers = getEnvOrDefault("SECURITY_HEADERS_ENABLED", "true") == "true"
+	cfg.Security.FrameOptions = getEnvOrDefault("SECURITY_FRAME_OPTIONS", "DENY")
+	cfg.Security.ReferrerPolicy = getEnvOrDefault("SECURITY_REFERRER_POLICY", "no-referrer")
+	cfg.Security.ContentSecurityPolicy = getEnvOrDefault("SECURITY_CSP", DefaultContentSecurityPolicy)
+	cfg.Security.DocsContentSecurityPolicy = getEnvOrDefault("SECURITY_DOCS_CSP", DefaultDocsContentSecurityPolicy)
+
+	// Metrics config
+	cfg.Metrics.Enabled = getEnvOrDefault("METRICS_ENABLED", "true") == "true"
+
+	// Debug config
+	cfg.Debug.PprofEnabled = getEnvOrDefault("DEBUG_PPROF_ENABLED", "false") == "true"
+
+	// Tracing config
+	cfg.Tracing.Enabled = getEnvOrDefault("TRACING_ENABLED", "false") == "true"
+	cfg.Tracing.Endpoint = getEnvOrDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	cfg.Tracing.Insecure = getEnvOrDefault("TRACING_OTLP_INSECURE", "true") == "true"
+	cfg.Tracing.ServiceName = getEnvOrDefault("TRACING_SERVICE_NAME", "fastgolink")
+	sampleRatio, err := getEnvAsFloat("TRACING_SAMPLE_RATIO", 1.0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO: %w", err)
+	}
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO: must be between 0 and 1")
+	}
+	cfg.Tracing.SampleRatio = sampleRatio
+
+	// GeoIP config
+	cfg.GeoIP.DBPath = getEnvOrDefault("GEOIP_DB_PATH", "")
+
+	// Analytics config
+	cfg.Analytics.JournalPath = getEnvOrDefault("ANALYTICS_JOURNAL_PATH", "")
+	journalInterval, err := getEnvAsDuration("ANALYTICS_JOURNAL_INTERVAL", time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ANALYTICS_JOURNAL_INTERVAL: %w", err)
+	}
+	cfg.Analytics.JournalInterval = journalInterval
+	cfg.Analytics.BlockOnFull = getEnvOrDefault("ANALYTICS_BLOCK_ON_FULL", "false") == "true"
+	cfg.Analytics.ClickCountMode = getEnvOrDefault("CLICK_COUNT_MODE", "batched")
+	archiveInterval, err := getEnvAsDuration("ANALYTICS_ARCHIVE_INTERVAL", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ANALYTICS_ARCHIVE_INTERVAL: %w", err)
+	}
+	cfg.Analytics.ArchiveInterval = archiveInterval
+	archiveThreshold, err := getEnvAsInt("ANALYTICS_ARCHIVE_THRESHOLD", 1000000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ANALYTICS_ARCHIVE_THRESHOLD: %w", err)
+	}
+	cfg.Analytics.ArchiveThreshold = archiveThreshold
+	cfg.Analytics.Sinks = getEnvOrDefault("ANALYTICS_SINKS", "")
+	cfg.Analytics.HTTPSinkURL = getEnvOrDefault("ANALYTICS_HTTP_SINK_URL", "")
+	httpSinkTimeout, err := getEnvAsDuration("ANALYTICS_HTTP_SINK_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ANALYTICS_HTTP_SINK_TIMEOUT: %w", err)
+	}
+	cfg.Analytics.HTTPSinkTimeout = httpSinkTimeout
+	cfg.Analytics.KafkaBrokers = getEnvOrDefault("ANALYTICS_KAFKA_BROKERS", "")
+	cfg.Analytics.KafkaTopic = getEnvOrDefault("ANALYTICS_KAFKA_TOPIC", "")
+
+	// Reaper config
+	cfg.Reaper.Enabled = getEnvOrDefault("REAPER_ENABLED", "false") == "true"
+	reaperInterval, err := getEnvAsDuration("REAPER_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REAPER_INTERVAL: %w", err)
+	}
+	cfg.Reaper.Interval = reaperInterval
+
+	// Link check config
+	cfg.LinkCheck.Enabled = getEnvOrDefault("LINK_CHECK_ENABLED", "false") == "true"
+	linkCheckInterval, err := getEnvAsDuration("LINK_CHECK_INTERVAL", 10*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_CHECK_INTERVAL: %w", err)
+	}
+	cfg.LinkCheck.Interval = linkCheckInterval
+	linkRecheckAfter, err := getEnvAsDuration("LINK_CHECK_RECHECK_AFTER", 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_CHECK_RECHECK_AFTER: %w", err)
+	}
+	cfg.LinkCheck.RecheckAfter = linkRecheckAfter
+	linkCheckBatchSize, err := getEnvAsInt("LINK_CHECK_BATCH_SIZE", 100)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_CHECK_BATCH_SIZE: %w", err)
+	}
+	cfg.LinkCheck.BatchSize = linkCheckBatchSize
+	linkCheckConcurrency, err := getEnvAsInt("LINK_CHECK_CONCURRENCY", 4)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_CHECK_CONCURRENCY: %w", err)
+	}
+	cfg.LinkCheck.Concurrency = linkCheckConcurrency
+	linkCheckRateLimit, err := getEnvAsInt("LINK_CHECK_RATE_LIMIT", 5)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_CHECK_RATE_LIMIT: %w", err)
+	}
+	cfg.LinkCheck.RateLimit = linkCheckRateLimit
+	linkCheckTimeout, err := getEnvAsDuration("LINK_CHECK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LINK_CHECK_TIMEOUT: %w", err)
+	}
+	cfg.LinkCheck.Timeout = linkCheckTimeout
+
+	// Webhook config
+	cfg.Webhook.URL = getEnvOrDefault("WEBHOOK_URL", "")
+	cfg.Webhook.Secret = getEnvOrDefault("WEBHOOK_SECRET", "")
+	webhookTimeout, err := getEnvAsDuration("WEBHOOK_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %w", err)
+	}
+	cfg.Webhook.Timeout = webhookTimeout
+	webhookMaxRetries, err := getEnvAsInt("WEBHOOK_MAX_RETRIES", 3)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_RETRIES: %w", err)
+	}
+	cfg.Webhook.MaxRetries = webhookMaxRetries
+	cfg.Webhook.ClickMilestones = getEnvOrDefault("WEBHOOK_CLICK_MILESTONES", "100,1000,10000")
+
+	// Idempotency config
+	cfg.Idempotency.Enabled = getEnvOrDefault("IDEMPOTENCY_ENABLED", "true") == "true"
+	idempotencyTTL, err := getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL: %w", err)
+	}
+	cfg.Idempotency.TTL = idempotencyTTL
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
 
 	return cfg, nil
 }
@@ -251,9 +825,56 @@ func (c *Config) DatabaseEnabled() bool {
 	return c.Database.Host != "" && c.Database.Password != ""
 }
 
+// PublicBaseURL returns the URL short codes are appended to: the base URL
+// followed by the server's base path.
+func (c *Config) PublicBaseURL() string {
+	return strings.TrimSuffix(c.URL.BaseURL, "/") + c.Server.BasePath
+}
+
 // RedisEnabled returns true if Redis configuration is provided.
 func (c *Config) RedisEnabled() bool {
-	return c.Redis.Host != ""
+	return c.Redis.Host != "" || c.Redis.Addrs != ""
+}
+
+// parseRouteRateLimits parses a comma-separated list of "prefix=requests/window"
+// entries, e.g. "/api/v1/shorten=10/1m,/api/v1/analytics=60/1m".
+func parseRouteRateLimits(value string) ([]RouteRateLimit, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var routes []RouteRateLimit
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, limit, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("entry %q must be in the form /prefix=requests/window", entry)
+		}
+		requestsStr, windowStr, ok := strings.Cut(limit, "/")
+		if !ok {
+			return nil, fmt.Errorf("entry %q must be in the form /prefix=requests/window", entry)
+		}
+		requests, err := strconv.Atoi(requestsStr)
+		if err != nil || requests <= 0 {
+			return nil, fmt.Errorf("entry %q has invalid request count", entry)
+		}
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("entry %q has invalid window", entry)
+		}
+
+		routes = append(routes, RouteRateLimit{
+			Prefix:   prefix,
+			Requests: requests,
+			Window:   window,
+		})
+	}
+
+	return routes, nil
 }
 
 // getEnvOrDefault returns the environment variable value or a default.
@@ -277,6 +898,19 @@ func getEnvAsInt(key string, defaultValue int) (int, error) {
 	return value, nil
 }
 
+// getEnvAsFloat returns the environment variable as a float.
+func getEnvAsFloat(key string, defaultValue float64) (float64, error) {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
 // getEnvAsDuration returns the environment variable as a duration.
 func getEnvAsDuration(key string, defaultValue time.Duration) (time.Duration, error) {
 	valueStr := os.Getenv(key)
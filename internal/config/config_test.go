@@ -40,7 +40,10 @@ func TestLoad_Defaults(t *testing.T) {
 	envVars := []string{
 		"SERVER_HOST", "SERVER_PORT", "SERVER_READ_TIMEOUT",
 		"SERVER_WRITE_TIMEOUT", "SERVER_SHUTDOWN_TIMEOUT",
-		"APP_ENV", "LOG_LEVEL",
+		"SERVER_READ_HEADER_TIMEOUT", "SERVER_IDLE_TIMEOUT",
+		"SERVER_MAX_HEADER_BYTES", "SERVER_H2C_ENABLED",
+		"SERVER_UNIX_SOCKET", "SERVER_UNIX_SOCKET_MODE", "SERVER_REQUEST_TIMEOUT", "SERVER_ERROR_FORMAT", "SERVER_BASE_PATH",
+		"APP_ENV", "LOG_LEVEL", "ACCESS_LOG_ENABLED",
 	}
 	for _, v := range envVars {
 		clearEnv(t, v)
@@ -55,10 +58,20 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
 	assert.Equal(t, 10*time.Second, cfg.Server.WriteTimeout)
 	assert.Equal(t, 30*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 2*time.Second, cfg.Server.ReadHeaderTimeout)
+	assert.Equal(t, 120*time.Second, cfg.Server.IdleTimeout)
+	assert.Equal(t, 1<<20, cfg.Server.MaxHeaderBytes)
+	assert.False(t, cfg.Server.H2CEnabled)
+	assert.Empty(t, cfg.Server.UnixSocket)
+	assert.Equal(t, os.FileMode(0o660), cfg.Server.UnixSocketMode)
+	assert.Zero(t, cfg.Server.RequestTimeout)
+	assert.Equal(t, "json", cfg.Server.ErrorFormat)
+	assert.Empty(t, cfg.Server.BasePath)
 
 	// App defaults
 	assert.Equal(t, "development", cfg.App.Env)
 	assert.Equal(t, "info", cfg.App.LogLevel)
+	assert.True(t, cfg.App.AccessLog)
 }
 
 func TestLoad_ServerConfig(t *testing.T) {
@@ -67,6 +80,15 @@ func TestLoad_ServerConfig(t *testing.T) {
 	setEnv(t, "SERVER_READ_TIMEOUT", "10s")
 	setEnv(t, "SERVER_WRITE_TIMEOUT", "20s")
 	setEnv(t, "SERVER_SHUTDOWN_TIMEOUT", "60s")
+	setEnv(t, "SERVER_READ_HEADER_TIMEOUT", "3s")
+	setEnv(t, "SERVER_IDLE_TIMEOUT", "90s")
+	setEnv(t, "SERVER_MAX_HEADER_BYTES", "65536")
+	setEnv(t, "SERVER_H2C_ENABLED", "true")
+	setEnv(t, "SERVER_UNIX_SOCKET", "/run/fastgolink.sock")
+	setEnv(t, "SERVER_UNIX_SOCKET_MODE", "0600")
+	setEnv(t, "SERVER_REQUEST_TIMEOUT", "5s")
+	setEnv(t, "SERVER_ERROR_FORMAT", "problem")
+	setEnv(t, "SERVER_BASE_PATH", "/shortener/")
 
 	cfg, err := Load()
 	require.NoError(t, err)
@@ -76,17 +98,28 @@ func TestLoad_ServerConfig(t *testing.T) {
 	assert.Equal(t, 10*time.Second, cfg.Server.ReadTimeout)
 	assert.Equal(t, 20*time.Second, cfg.Server.WriteTimeout)
 	assert.Equal(t, 60*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 3*time.Second, cfg.Server.ReadHeaderTimeout)
+	assert.Equal(t, 90*time.Second, cfg.Server.IdleTimeout)
+	assert.Equal(t, 65536, cfg.Server.MaxHeaderBytes)
+	assert.True(t, cfg.Server.H2CEnabled)
+	assert.Equal(t, "/run/fastgolink.sock", cfg.Server.UnixSocket)
+	assert.Equal(t, os.FileMode(0o600), cfg.Server.UnixSocketMode)
+	assert.Equal(t, 5*time.Second, cfg.Server.RequestTimeout)
+	assert.Equal(t, "problem", cfg.Server.ErrorFormat)
+	assert.Equal(t, "/shortener", cfg.Server.BasePath, "trailing slash is trimmed")
 }
 
 func TestLoad_AppConfig(t *testing.T) {
 	setEnv(t, "APP_ENV", "production")
 	setEnv(t, "LOG_LEVEL", "error")
+	setEnv(t, "ACCESS_LOG_ENABLED", "false")
 
 	cfg, err := Load()
 	require.NoError(t, err)
 
 	assert.Equal(t, "production", cfg.App.Env)
 	assert.Equal(t, "error", cfg.App.LogLevel)
+	assert.False(t, cfg.App.AccessLog)
 }
 
 func TestLoad_InvalidPort(t *testing.T) {
@@ -244,10 +277,19 @@ func TestConfig_DatabaseEnabled(t *testing.T) {
 	}
 }
 
+func TestConfig_PublicBaseURL(t *testing.T) {
+	cfg := &Config{URL: URLConfig{BaseURL: "https://sho.rt/"}}
+	assert.Equal(t, "https://sho.rt", cfg.PublicBaseURL())
+
+	cfg.Server.BasePath = "/shortener"
+	assert.Equal(t, "https://sho.rt/shortener", cfg.PublicBaseURL())
+}
+
 func TestConfig_RedisEnabled(t *testing.T) {
 	tests := []struct {
 		name     string
 		host     string
+		addrs    string
 		expected bool
 	}{
 		{
@@ -260,12 +302,17 @@ func TestConfig_RedisEnabled(t *testing.T) {
 			host:     "",
 			expected: false,
 		},
+		{
+			name:     "cluster addresses set",
+			addrs:    "redis-a:6379",
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := &Config{
-				Redis: RedisConfig{Host: tt.host},
+				Redis: RedisConfig{Host: tt.host, Addrs: tt.addrs},
 			}
 			assert.Equal(t, tt.expected, cfg.RedisEnabled())
 		})
@@ -300,6 +347,71 @@ func TestLoad_DatabaseConfig(t *testing.T) {
 	assert.True(t, cfg.DatabaseEnabled())
 }
 
+func TestDatabaseConfig_ReplicaConfigs(t *testing.T) {
+	primary := DatabaseConfig{
+		Host:         "primary.example.com",
+		Port:         5432,
+		User:         "fastgolink",
+		Password:     "secret",
+		DBName:       "fastgolink",
+		MaxOpenConns: 25,
+		ReplicaHosts: "replica-a.example.com, replica-b.example.com:5433,[::1]:6432",
+	}
+
+	replicas, err := primary.ReplicaConfigs()
+	require.NoError(t, err)
+	require.Len(t, replicas, 3)
+
+	assert.Equal(t, "replica-a.example.com", replicas[0].Host)
+	assert.Equal(t, 5432, replicas[0].Port)
+	assert.Equal(t, "replica-b.example.com", replicas[1].Host)
+	assert.Equal(t, 5433, replicas[1].Port)
+	assert.Equal(t, "::1", replicas[2].Host)
+	assert.Equal(t, 6432, replicas[2].Port)
+
+	// Everything but the address is shared with the primary
+	assert.Equal(t, "secret", replicas[0].Password)
+	assert.Equal(t, 25, replicas[1].MaxOpenConns)
+	assert.Empty(t, replicas[0].ReplicaHosts)
+
+	primary.ReplicaHosts = ""
+	replicas, err = primary.ReplicaConfigs()
+	require.NoError(t, err)
+	assert.Empty(t, replicas)
+
+	primary.ReplicaHosts = "replica.example.com:notaport"
+	_, err = primary.ReplicaConfigs()
+	assert.Error(t, err)
+}
+
+func TestLoad_DatabaseReplicaHosts(t *testing.T) {
+	setEnv(t, "DB_PASSWORD", "testpass")
+	setEnv(t, "DB_REPLICA_HOSTS", "replica-a,replica-b:5433")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "replica-a,replica-b:5433", cfg.Database.ReplicaHosts)
+
+	setEnv(t, "DB_REPLICA_HOSTS", "replica-a:70000")
+	_, err = Load()
+	assert.ErrorContains(t, err, "DB_REPLICA_HOSTS")
+}
+
+func TestLoad_DatabaseSchemaVersion(t *testing.T) {
+	setEnv(t, "DB_PASSWORD", "testpass")
+	setEnv(t, "DB_CHECK_SCHEMA_VERSION", "true")
+	setEnv(t, "DB_SCHEMA_VERSION", "12")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Database.CheckSchemaVersion)
+	assert.Equal(t, 12, cfg.Database.SchemaVersion)
+
+	setEnv(t, "DB_SCHEMA_VERSION", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "DB_SCHEMA_VERSION must not be negative")
+}
+
 func TestLoad_RedisConfig(t *testing.T) {
 	clearEnv(t, "REDIS_HOST")
 	clearEnv(t, "REDIS_PORT")
@@ -315,13 +427,39 @@ func TestLoad_RedisConfig(t *testing.T) {
 	assert.Equal(t, "redis.example.com", cfg.Redis.Host)
 	assert.Equal(t, 6380, cfg.Redis.Port)
 	assert.Equal(t, "redispass", cfg.Redis.Password)
+	assert.Equal(t, RedisModeStandalone, cfg.Redis.Mode)
 	assert.True(t, cfg.RedisEnabled())
 }
 
+func TestLoad_RedisSentinelConfig(t *testing.T) {
+	setEnv(t, "REDIS_MODE", "sentinel")
+	setEnv(t, "REDIS_ADDRS", "sentinel-a:26379, sentinel-b:26379")
+	setEnv(t, "REDIS_MASTER_NAME", "mymaster")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, RedisModeSentinel, cfg.Redis.Mode)
+	assert.Equal(t, []string{"sentinel-a:26379", "sentinel-b:26379"}, cfg.Redis.AddrsList())
+	assert.Equal(t, "mymaster", cfg.Redis.MasterName)
+}
+
+func TestSecurityConfig_APIKeysList(t *testing.T) {
+	assert.Nil(t, SecurityConfig{}.APIKeysList())
+	assert.Equal(t, []string{"key-one", "key-two"}, SecurityConfig{APIKeys: " key-one,,key-two "}.APIKeysList())
+}
+
 func TestLoad_SecurityConfig(t *testing.T) {
 	setEnv(t, "SECURITY_MAX_URL_LENGTH", "4096")
 	setEnv(t, "SECURITY_ALLOW_PRIVATE_IPS", "true")
 	setEnv(t, "SECURITY_BLOCKED_HOSTS", "evil.com,bad.com")
+	setEnv(t, "SECURITY_ALLOWED_HOSTS", "example.com, tools.internal.io")
+	setEnv(t, "SECURITY_ALLOWED_SCHEMES", "https,ftp")
+	setEnv(t, "SECURITY_RESOLVE_HOSTS", "true")
+	setEnv(t, "SECURITY_RESOLVE_TIMEOUT", "500ms")
+	setEnv(t, "SECURITY_MAX_BODY_BYTES", "4096")
+	setEnv(t, "SECURITY_API_KEYS", "key-one, key-two")
+	setEnv(t, "SECURITY_INFO_REQUIRE_API_KEY", "true")
 
 	cfg, err := Load()
 	require.NoError(t, err)
@@ -330,6 +468,49 @@ func TestLoad_SecurityConfig(t *testing.T) {
 	assert.True(t, cfg.Security.AllowPrivateIPs)
 	assert.Equal(t, "evil.com,bad.com", cfg.Security.BlockedHosts)
 	assert.Equal(t, []string{"evil.com", "bad.com"}, cfg.Security.BlockedHostsList())
+	assert.Equal(t, []string{"example.com", "tools.internal.io"}, cfg.Security.AllowedHostsList())
+	assert.Equal(t, []string{"https", "ftp"}, cfg.Security.AllowedSchemesList())
+	assert.True(t, cfg.Security.ResolveHosts)
+	assert.Equal(t, 500*time.Millisecond, cfg.Security.ResolveTimeout)
+	assert.Equal(t, int64(4096), cfg.Security.MaxBodyBytes)
+	assert.Equal(t, []string{"key-one", "key-two"}, cfg.Security.APIKeysList())
+	assert.True(t, cfg.Security.InfoRequiresKey)
+}
+
+func TestLoad_SecurityHeadersConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t, "SECURITY_HEADERS_ENABLED")
+		clearEnv(t, "SECURITY_FRAME_OPTIONS")
+		clearEnv(t, "SECURITY_REFERRER_POLICY")
+		clearEnv(t, "SECURITY_CSP")
+		clearEnv(t, "SECURITY_DOCS_CSP")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.True(t, cfg.Security.Headers)
+		assert.Equal(t, "DENY", cfg.Security.FrameOptions)
+		assert.Equal(t, "no-referrer", cfg.Security.ReferrerPolicy)
+		assert.Equal(t, DefaultContentSecurityPolicy, cfg.Security.ContentSecurityPolicy)
+		assert.Equal(t, DefaultDocsContentSecurityPolicy, cfg.Security.DocsContentSecurityPolicy)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		setEnv(t, "SECURITY_HEADERS_ENABLED", "false")
+		setEnv(t, "SECURITY_FRAME_OPTIONS", "SAMEORIGIN")
+		setEnv(t, "SECURITY_REFERRER_POLICY", "same-origin")
+		setEnv(t, "SECURITY_CSP", "default-src 'self'")
+		setEnv(t, "SECURITY_DOCS_CSP", "default-src *")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.False(t, cfg.Security.Headers)
+		assert.Equal(t, "SAMEORIGIN", cfg.Security.FrameOptions)
+		assert.Equal(t, "same-origin", cfg.Security.ReferrerPolicy)
+		assert.Equal(t, "default-src 'self'", cfg.Security.ContentSecurityPolicy)
+		assert.Equal(t, "default-src *", cfg.Security.DocsContentSecurityPolicy)
+	})
 }
 
 func TestLoad_RateLimitConfig(t *testing.T) {
@@ -342,6 +523,104 @@ func TestLoad_RateLimitConfig(t *testing.T) {
 
 	assert.True(t, cfg.Rate.Enabled)
 	assert.Equal(t, 50, cfg.Rate.Requests)
+	assert.Equal(t, 30*time.Second, cfg.Rate.Window)
+	assert.Equal(t, "memory", cfg.Rate.Backend)
+}
+
+func TestLoad_RateLimitDisabled(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_ENABLED", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Rate.Enabled)
+}
+
+func TestLoad_SecurityAndRateLimitDefaults(t *testing.T) {
+	envVars := []string{
+		"SECURITY_MAX_URL_LENGTH", "SECURITY_ALLOW_PRIVATE_IPS", "SECURITY_BLOCKED_HOSTS",
+		"SECURITY_ALLOWED_SCHEMES", "SECURITY_RESOLVE_HOSTS", "SECURITY_RESOLVE_TIMEOUT",
+		"RATE_LIMIT_ENABLED", "RATE_LIMIT_REQUESTS", "RATE_LIMIT_WINDOW",
+		"RATE_LIMIT_TRUST_PROXY", "RATE_LIMIT_TRUSTED_PROXIES", "RATE_LIMIT_ALLOWLIST", "RATE_LIMIT_API_KEY_HEADER",
+	}
+	for _, v := range envVars {
+		clearEnv(t, v)
+	}
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	// Security defaults
+	assert.Equal(t, 2048, cfg.Security.MaxURLLength)
+	assert.False(t, cfg.Security.AllowPrivateIPs)
+	assert.Nil(t, cfg.Security.BlockedHostsList())
+	assert.Equal(t, []string{"http", "https"}, cfg.Security.AllowedSchemesList())
+	assert.False(t, cfg.Security.ResolveHosts)
+	assert.Equal(t, 2*time.Second, cfg.Security.ResolveTimeout)
+
+	// Rate limit defaults
+	assert.True(t, cfg.Rate.Enabled)
+	assert.Equal(t, 100, cfg.Rate.Requests)
+	assert.Equal(t, time.Minute, cfg.Rate.Window)
+	assert.False(t, cfg.Rate.TrustProxy)
+	assert.Nil(t, cfg.Rate.TrustedProxiesList())
+	assert.Nil(t, cfg.Rate.AllowlistCIDRsList())
+	assert.Equal(t, "X-API-Key", cfg.Rate.APIKeyHeader)
+}
+
+func TestLoad_RateLimitTrustedProxies(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5,2001:db8::/32")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.5", "2001:db8::/32"}, cfg.Rate.TrustedProxiesList())
+
+	setEnv(t, "RATE_LIMIT_TRUSTED_PROXIES", "10.0.0.0/33")
+	_, err = Load()
+	assert.ErrorContains(t, err, `RATE_LIMIT_TRUSTED_PROXIES entries must be IPs or CIDR ranges, got "10.0.0.0/33"`)
+}
+
+func TestLoad_RateLimitAllowlist(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_ENABLED", "true")
+	setEnv(t, "RATE_LIMIT_ALLOWLIST", "10.0.0.0/8, 127.0.0.1")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "127.0.0.1"}, cfg.Rate.AllowlistCIDRsList())
+
+	setEnv(t, "RATE_LIMIT_ALLOWLIST", "health-checker")
+	_, err = Load()
+	assert.ErrorContains(t, err, `RATE_LIMIT_ALLOWLIST entries must be IPs or CIDR ranges, got "health-checker"`)
+}
+
+func TestLoad_RateLimitBackend(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_BACKEND", "redis")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "redis", cfg.Rate.Backend)
+}
+
+func TestLoad_RateLimitTokenBucket(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_ALGORITHM", "token_bucket")
+	setEnv(t, "RATE_LIMIT_BURST_SIZE", "20")
+	setEnv(t, "RATE_LIMIT_REFILL_RATE", "2.5")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "token_bucket", cfg.Rate.Algorithm)
+	assert.Equal(t, 20, cfg.Rate.BurstSize)
+	assert.Equal(t, 2.5, cfg.Rate.RefillRate)
+}
+
+func TestLoad_InvalidRateLimitRefillRate(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_REFILL_RATE", "fast")
+
+	_, err := Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_REFILL_RATE")
 }
 
 func TestLoad_InvalidDatabasePort(t *testing.T) {
@@ -416,6 +695,14 @@ func TestLoad_InvalidRedisDB(t *testing.T) {
 	assert.Contains(t, err.Error(), "REDIS_DB")
 }
 
+func TestLoad_InvalidResolveTimeout(t *testing.T) {
+	setEnv(t, "SECURITY_RESOLVE_TIMEOUT", "invalid")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SECURITY_RESOLVE_TIMEOUT")
+}
+
 func TestLoad_InvalidMaxURLLength(t *testing.T) {
 	setEnv(t, "SECURITY_MAX_URL_LENGTH", "invalid")
 
@@ -448,6 +735,14 @@ func TestLoad_InvalidRedisCacheTTL(t *testing.T) {
 	assert.Contains(t, err.Error(), "REDIS_CACHE_TTL")
 }
 
+func TestLoad_InvalidRedisHealthCheckInterval(t *testing.T) {
+	setEnv(t, "REDIS_HEALTH_CHECK_INTERVAL", "invalid")
+
+	_, err := Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "REDIS_HEALTH_CHECK_INTERVAL")
+}
+
 func TestLoad_InvalidURLShortCodeLen(t *testing.T) {
 	setEnv(t, "URL_SHORT_CODE_LEN", "invalid")
 
@@ -463,3 +758,546 @@ func TestLoad_InvalidURLIDGenMaxRetries(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "URL_IDGEN_MAX_RETRIES")
 }
+
+func TestLoad_URLIDGenRetryConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t, "URL_IDGEN_RETRY_BACKOFF")
+		clearEnv(t, "URL_IDGEN_MAX_BACKOFF")
+		clearEnv(t, "URL_IDGEN_LENGTH_BUMP_AFTER")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Zero(t, cfg.URL.IDGenRetryBackoff)
+		assert.Equal(t, 100*time.Millisecond, cfg.URL.IDGenMaxBackoff)
+		assert.Zero(t, cfg.URL.IDGenLengthBumpAfter)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		setEnv(t, "URL_IDGEN_RETRY_BACKOFF", "5ms")
+		setEnv(t, "URL_IDGEN_MAX_BACKOFF", "50ms")
+		setEnv(t, "URL_IDGEN_LENGTH_BUMP_AFTER", "2")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, 5*time.Millisecond, cfg.URL.IDGenRetryBackoff)
+		assert.Equal(t, 50*time.Millisecond, cfg.URL.IDGenMaxBackoff)
+		assert.Equal(t, 2, cfg.URL.IDGenLengthBumpAfter)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		setEnv(t, "URL_IDGEN_RETRY_BACKOFF", "soon")
+
+		_, err := Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "URL_IDGEN_RETRY_BACKOFF")
+	})
+}
+
+func TestLoad_URLCreateMaxRetries(t *testing.T) {
+	clearEnv(t, "URL_CREATE_MAX_RETRIES")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 3, cfg.URL.CreateMaxRetries)
+
+	setEnv(t, "URL_CREATE_MAX_RETRIES", "0")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.URL.CreateMaxRetries)
+
+	setEnv(t, "URL_CREATE_MAX_RETRIES", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "URL_CREATE_MAX_RETRIES must not be negative")
+}
+
+func TestLoad_SnowflakeConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t, "SNOWFLAKE_MACHINE_ID")
+		clearEnv(t, "SNOWFLAKE_EPOCH")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Zero(t, cfg.URL.SnowflakeMachineID)
+		assert.True(t, cfg.URL.SnowflakeEpoch.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		setEnv(t, "URL_IDGEN_STRATEGY", "snowflake")
+		setEnv(t, "SNOWFLAKE_MACHINE_ID", "42")
+		setEnv(t, "SNOWFLAKE_EPOCH", "2026-01-01T00:00:00Z")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, "snowflake", cfg.URL.IDGenStrategy)
+		assert.Equal(t, 42, cfg.URL.SnowflakeMachineID)
+		assert.True(t, cfg.URL.SnowflakeEpoch.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("invalid machine ID", func(t *testing.T) {
+		setEnv(t, "SNOWFLAKE_MACHINE_ID", "node-1")
+
+		_, err := Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SNOWFLAKE_MACHINE_ID")
+	})
+
+	t.Run("invalid epoch", func(t *testing.T) {
+		setEnv(t, "SNOWFLAKE_EPOCH", "2024-01-01")
+
+		_, err := Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SNOWFLAKE_EPOCH")
+	})
+}
+
+func TestLoad_URLExpiryConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t, "URL_DEFAULT_EXPIRY")
+		clearEnv(t, "URL_MAX_EXPIRY")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Zero(t, cfg.URL.DefaultExpiry)
+		assert.Zero(t, cfg.URL.MaxExpiry)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		setEnv(t, "URL_DEFAULT_EXPIRY", "2160h")
+		setEnv(t, "URL_MAX_EXPIRY", "8760h")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, 2160*time.Hour, cfg.URL.DefaultExpiry)
+		assert.Equal(t, 8760*time.Hour, cfg.URL.MaxExpiry)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		setEnv(t, "URL_MAX_EXPIRY", "90d")
+
+		_, err := Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "URL_MAX_EXPIRY")
+	})
+}
+
+func TestLoad_URLAliasConfig(t *testing.T) {
+	setEnv(t, "URL_ALIAS_MIN_LENGTH", "4")
+	setEnv(t, "URL_ALIAS_MAX_LENGTH", "8")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, cfg.URL.AliasMinLength)
+	assert.Equal(t, 8, cfg.URL.AliasMaxLength)
+}
+
+func TestLoad_URLNormalizeConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t, "URL_NORMALIZE_STRIP_TRAILING_SLASH")
+		clearEnv(t, "URL_NORMALIZE_STRIP_FRAGMENT")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.False(t, cfg.URL.NormalizeStripTrailingSlash)
+		assert.False(t, cfg.URL.NormalizeStripFragment)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		setEnv(t, "URL_NORMALIZE_STRIP_TRAILING_SLASH", "true")
+		setEnv(t, "URL_NORMALIZE_STRIP_FRAGMENT", "true")
+
+		cfg, err := Load()
+		require.NoError(t, err)
+
+		assert.True(t, cfg.URL.NormalizeStripTrailingSlash)
+		assert.True(t, cfg.URL.NormalizeStripFragment)
+	})
+}
+
+func TestLoad_DedupeURLs(t *testing.T) {
+	clearEnv(t, "DEDUPE_URLS")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.URL.Dedupe)
+
+	setEnv(t, "DEDUPE_URLS", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.URL.Dedupe)
+}
+
+func TestLoad_URLShortDomains(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.URL.ShortDomainsList())
+
+	setEnv(t, "URL_SHORT_DOMAINS", "go.example.com, links.example.org:8443")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go.example.com", "links.example.org:8443"}, cfg.URL.ShortDomainsList())
+
+	for _, domain := range []string{"https://go.example.com", "go.example.com/path", ":8080"} {
+		setEnv(t, "URL_SHORT_DOMAINS", domain)
+		_, err = Load()
+		assert.ErrorContains(t, err, "URL_SHORT_DOMAINS entries must be hosts", domain)
+	}
+}
+
+func TestLoad_ReservedCodes(t *testing.T) {
+	clearEnv(t, "URL_RESERVED_CODES")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "debug", "docs", "health", "info", "metrics", "ready"}, cfg.URL.ReservedCodesList())
+
+	setEnv(t, "URL_RESERVED_CODES", "admin, login")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "login"}, cfg.URL.ReservedCodesList())
+}
+
+func TestLoad_NotFoundConfig(t *testing.T) {
+	clearEnv(t, "URL_ROOT_REDIRECT")
+	clearEnv(t, "URL_NOT_FOUND_REDIRECT")
+	clearEnv(t, "URL_NOT_FOUND_PAGE")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.URL.RootRedirectURL)
+	assert.Empty(t, cfg.URL.NotFoundURL)
+	assert.Empty(t, cfg.URL.NotFoundPage)
+
+	setEnv(t, "URL_ROOT_REDIRECT", "https://example.com/")
+	setEnv(t, "URL_NOT_FOUND_PAGE", "/etc/fastgolink/404.html")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", cfg.URL.RootRedirectURL)
+	assert.Equal(t, "/etc/fastgolink/404.html", cfg.URL.NotFoundPage)
+}
+
+func TestLoad_ExpiredRedirect(t *testing.T) {
+	clearEnv(t, "EXPIRED_REDIRECT_URL")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.URL.ExpiredURL)
+
+	setEnv(t, "EXPIRED_REDIRECT_URL", "https://example.com/expired")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/expired", cfg.URL.ExpiredURL)
+
+	setEnv(t, "EXPIRED_REDIRECT_URL", "/expired")
+	_, err = Load()
+	assert.ErrorContains(t, err, "EXPIRED_REDIRECT_URL")
+}
+
+func TestLoad_InvalidURLAliasMinLength(t *testing.T) {
+	setEnv(t, "URL_ALIAS_MIN_LENGTH", "invalid")
+
+	_, err := Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "URL_ALIAS_MIN_LENGTH")
+}
+
+func TestLoad_RateLimitRoutes(t *testing.T) {
+	setEnv(t, "RATE_LIMIT_ROUTES", "/api/v1/shorten=10/1m, /api/v1/analytics=60/30s")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []RouteRateLimit{
+		{Prefix: "/api/v1/shorten", Requests: 10, Window: time.Minute},
+		{Prefix: "/api/v1/analytics", Requests: 60, Window: 30 * time.Second},
+	}, cfg.Rate.Routes)
+}
+
+func TestLoad_InvalidRateLimitRoutes(t *testing.T) {
+	tests := []string{
+		"api/v1/shorten=10/1m",
+		"/api/v1/shorten",
+		"/api/v1/shorten=10",
+		"/api/v1/shorten=ten/1m",
+		"/api/v1/shorten=10/soon",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			setEnv(t, "RATE_LIMIT_ROUTES", value)
+
+			_, err := Load()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "RATE_LIMIT_ROUTES")
+		})
+	}
+}
+
+func TestLoad_MemoryCacheConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10000, cfg.Cache.MemoryMaxEntries)
+	assert.Equal(t, time.Minute, cfg.Cache.MemorySweepInterval)
+	assert.Equal(t, "json", cfg.Cache.Codec)
+
+	setEnv(t, "CACHE_MEMORY_MAX_ENTRIES", "500")
+	setEnv(t, "CACHE_MEMORY_SWEEP_INTERVAL", "30s")
+	setEnv(t, "CACHE_CODEC", "msgpack")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 500, cfg.Cache.MemoryMaxEntries)
+	assert.Equal(t, 30*time.Second, cfg.Cache.MemorySweepInterval)
+	assert.Equal(t, "msgpack", cfg.Cache.Codec)
+}
+
+func TestLoad_InvalidMemoryCacheMaxEntries(t *testing.T) {
+	setEnv(t, "CACHE_MEMORY_MAX_ENTRIES", "lots")
+
+	_, err := Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "CACHE_MEMORY_MAX_ENTRIES")
+}
+
+func TestLoad_MetricsConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Metrics.Enabled)
+
+	setEnv(t, "METRICS_ENABLED", "false")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Metrics.Enabled)
+}
+
+func TestLoad_DebugConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Debug.PprofEnabled)
+
+	setEnv(t, "DEBUG_PPROF_ENABLED", "true")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Debug.PprofEnabled)
+}
+
+func TestLoad_TracingConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Tracing.Enabled)
+	assert.Equal(t, "localhost:4318", cfg.Tracing.Endpoint)
+	assert.True(t, cfg.Tracing.Insecure)
+	assert.Equal(t, "fastgolink", cfg.Tracing.ServiceName)
+	assert.Equal(t, 1.0, cfg.Tracing.SampleRatio)
+
+	setEnv(t, "TRACING_ENABLED", "true")
+	setEnv(t, "TRACING_OTLP_ENDPOINT", "collector:4318")
+	setEnv(t, "TRACING_OTLP_INSECURE", "false")
+	setEnv(t, "TRACING_SERVICE_NAME", "shortener")
+	setEnv(t, "TRACING_SAMPLE_RATIO", "0.25")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Tracing.Enabled)
+	assert.Equal(t, "collector:4318", cfg.Tracing.Endpoint)
+	assert.False(t, cfg.Tracing.Insecure)
+	assert.Equal(t, "shortener", cfg.Tracing.ServiceName)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+}
+
+func TestLoad_GeoIPConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.GeoIP.DBPath)
+
+	setEnv(t, "GEOIP_DB_PATH", "/data/GeoLite2-Country.mmdb")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/data/GeoLite2-Country.mmdb", cfg.GeoIP.DBPath)
+}
+
+func TestLoad_AnalyticsConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Analytics.JournalPath)
+	assert.Equal(t, time.Second, cfg.Analytics.JournalInterval)
+	assert.False(t, cfg.Analytics.BlockOnFull)
+	assert.Equal(t, "batched", cfg.Analytics.ClickCountMode)
+	assert.Zero(t, cfg.Analytics.ArchiveInterval)
+	assert.Equal(t, 1000000, cfg.Analytics.ArchiveThreshold)
+
+	setEnv(t, "ANALYTICS_JOURNAL_PATH", "/var/lib/fastgolink/clicks.journal")
+	setEnv(t, "ANALYTICS_JOURNAL_INTERVAL", "5s")
+	setEnv(t, "ANALYTICS_BLOCK_ON_FULL", "true")
+	setEnv(t, "CLICK_COUNT_MODE", "sync")
+	setEnv(t, "ANALYTICS_ARCHIVE_INTERVAL", "1h")
+	setEnv(t, "ANALYTICS_ARCHIVE_THRESHOLD", "50000")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/fastgolink/clicks.journal", cfg.Analytics.JournalPath)
+	assert.Equal(t, 5*time.Second, cfg.Analytics.JournalInterval)
+	assert.True(t, cfg.Analytics.BlockOnFull)
+	assert.Equal(t, "sync", cfg.Analytics.ClickCountMode)
+	assert.Equal(t, time.Hour, cfg.Analytics.ArchiveInterval)
+	assert.Equal(t, 50000, cfg.Analytics.ArchiveThreshold)
+
+	setEnv(t, "ANALYTICS_JOURNAL_INTERVAL", "0s")
+	_, err = Load()
+	assert.ErrorContains(t, err, "ANALYTICS_JOURNAL_INTERVAL must be positive")
+
+	setEnv(t, "ANALYTICS_JOURNAL_INTERVAL", "5s")
+	setEnv(t, "ANALYTICS_ARCHIVE_THRESHOLD", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "ANALYTICS_ARCHIVE_THRESHOLD must be positive")
+
+	setEnv(t, "ANALYTICS_ARCHIVE_THRESHOLD", "50000")
+	setEnv(t, "CLICK_COUNT_MODE", "async")
+	_, err = Load()
+	assert.ErrorContains(t, err, "CLICK_COUNT_MODE must be")
+}
+
+func TestLoad_AnalyticsSinks(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Analytics.SinksList())
+	assert.Equal(t, 5*time.Second, cfg.Analytics.HTTPSinkTimeout)
+
+	setEnv(t, "ANALYTICS_SINKS", "http, kafka")
+	setEnv(t, "ANALYTICS_HTTP_SINK_URL", "https://collector.example.com/clicks")
+	setEnv(t, "ANALYTICS_HTTP_SINK_TIMEOUT", "2s")
+	setEnv(t, "ANALYTICS_KAFKA_BROKERS", "kafka-1:9092,kafka-2:9092")
+	setEnv(t, "ANALYTICS_KAFKA_TOPIC", "clicks")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http", "kafka"}, cfg.Analytics.SinksList())
+	assert.Equal(t, "https://collector.example.com/clicks", cfg.Analytics.HTTPSinkURL)
+	assert.Equal(t, 2*time.Second, cfg.Analytics.HTTPSinkTimeout)
+	assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, cfg.Analytics.KafkaBrokersList())
+	assert.Equal(t, "clicks", cfg.Analytics.KafkaTopic)
+
+	setEnv(t, "ANALYTICS_HTTP_SINK_URL", "")
+	_, err = Load()
+	assert.ErrorContains(t, err, "ANALYTICS_HTTP_SINK_URL")
+
+	setEnv(t, "ANALYTICS_HTTP_SINK_URL", "https://collector.example.com/clicks")
+	setEnv(t, "ANALYTICS_KAFKA_TOPIC", "")
+	_, err = Load()
+	assert.ErrorContains(t, err, "ANALYTICS_KAFKA_TOPIC must not be empty")
+
+	setEnv(t, "ANALYTICS_SINKS", "webhook")
+	_, err = Load()
+	assert.ErrorContains(t, err, `ANALYTICS_SINKS entries must be "http" or "kafka"`)
+}
+
+func TestLoad_ReaperConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Reaper.Enabled)
+	assert.Equal(t, time.Hour, cfg.Reaper.Interval)
+
+	setEnv(t, "REAPER_ENABLED", "true")
+	setEnv(t, "REAPER_INTERVAL", "15m")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Reaper.Enabled)
+	assert.Equal(t, 15*time.Minute, cfg.Reaper.Interval)
+
+	setEnv(t, "REAPER_INTERVAL", "0s")
+	_, err = Load()
+	assert.ErrorContains(t, err, "REAPER_INTERVAL must be positive")
+}
+
+func TestLoad_LinkCheckConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.LinkCheck.Enabled)
+	assert.Equal(t, 10*time.Minute, cfg.LinkCheck.Interval)
+	assert.Equal(t, 24*time.Hour, cfg.LinkCheck.RecheckAfter)
+	assert.Equal(t, 100, cfg.LinkCheck.BatchSize)
+	assert.Equal(t, 4, cfg.LinkCheck.Concurrency)
+	assert.Equal(t, 5, cfg.LinkCheck.RateLimit)
+	assert.Equal(t, 10*time.Second, cfg.LinkCheck.Timeout)
+
+	setEnv(t, "LINK_CHECK_ENABLED", "true")
+	setEnv(t, "LINK_CHECK_INTERVAL", "1m")
+	setEnv(t, "LINK_CHECK_RATE_LIMIT", "0")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.LinkCheck.Enabled)
+	assert.Equal(t, time.Minute, cfg.LinkCheck.Interval)
+	assert.Zero(t, cfg.LinkCheck.RateLimit)
+
+	setEnv(t, "LINK_CHECK_CONCURRENCY", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "LINK_CHECK_CONCURRENCY must be positive")
+}
+
+func TestLoad_WebhookConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Webhook.Enabled())
+	assert.Equal(t, 5*time.Second, cfg.Webhook.Timeout)
+	assert.Equal(t, 3, cfg.Webhook.MaxRetries)
+	milestones, err := cfg.Webhook.ClickMilestoneList()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{100, 1000, 10000}, milestones)
+
+	setEnv(t, "WEBHOOK_URL", "https://hooks.example.com/fastgolink")
+	setEnv(t, "WEBHOOK_SECRET", "s3cret")
+	setEnv(t, "WEBHOOK_CLICK_MILESTONES", "50, 500")
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Webhook.Enabled())
+	assert.Equal(t, "s3cret", cfg.Webhook.Secret)
+	milestones, err = cfg.Webhook.ClickMilestoneList()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{50, 500}, milestones)
+
+	setEnv(t, "WEBHOOK_CLICK_MILESTONES", "50,lots")
+	_, err = Load()
+	assert.ErrorContains(t, err, "WEBHOOK_CLICK_MILESTONES")
+
+	setEnv(t, "WEBHOOK_CLICK_MILESTONES", "50")
+	setEnv(t, "WEBHOOK_URL", "ftp://hooks.example.com")
+	_, err = Load()
+	assert.ErrorContains(t, err, "WEBHOOK_URL must use http or https")
+}
+
+func TestLoad_InvalidTracingSampleRatio(t *testing.T) {
+	setEnv(t, "TRACING_SAMPLE_RATIO", "1.5")
+
+	_, err := Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TRACING_SAMPLE_RATIO")
+}
+
+func TestLoad_IdempotencyConfig(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Idempotency.Enabled)
+	assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+
+	setEnv(t, "IDEMPOTENCY_TTL", "1h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, cfg.Idempotency.TTL)
+
+	setEnv(t, "IDEMPOTENCY_TTL", "0s")
+	_, err = Load()
+	assert.ErrorContains(t, err, "IDEMPOTENCY_TTL must be positive")
+
+	setEnv(t, "IDEMPOTENCY_ENABLED", "false")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Idempotency.Enabled)
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/idgen"
+)
+
+// Short code length bounds. Codes are stored in a VARCHAR(10) column, and
+// fewer than 4 Base62 characters leaves too small a keyspace. MaxShortCodeLen
+// is the default for URL_MAX_SHORT_CODE_LEN, which may be raised only after
+// widening the column.
+const (
+	MinShortCodeLen = 4
+	MaxShortCodeLen = 10
+)
+
+// MaxSnowflakeMachineID is the largest machine ID that fits the 10 bits a
+// snowflake ID reserves for it.
+const MaxSnowflakeMachineID = 1023
+
+// basePathPattern matches a base path of one or more plain segments.
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// Validate checks configuration invariants that parsing alone cannot catch.
+// It reports every problem found, joined into a single error.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	// Server
+	check(validPort(c.Server.Port), "SERVER_PORT must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "SERVER_READ_TIMEOUT must be positive, got %s", c.Server.ReadTimeout)
+	check(c.Server.WriteTimeout > 0, "SERVER_WRITE_TIMEOUT must be positive, got %s", c.Server.WriteTimeout)
+	check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT must be positive, got %s", c.Server.ShutdownTimeout)
+	check(c.Server.ReadHeaderTimeout > 0, "SERVER_READ_HEADER_TIMEOUT must be positive, got %s", c.Server.ReadHeaderTimeout)
+	check(c.Server.IdleTimeout > 0, "SERVER_IDLE_TIMEOUT must be positive, got %s", c.Server.IdleTimeout)
+	check(c.Server.MaxHeaderBytes > 0, "SERVER_MAX_HEADER_BYTES must be positive, got %d", c.Server.MaxHeaderBytes)
+	check(c.Server.RequestTimeout >= 0 && c.Server.RequestTimeout < c.Server.WriteTimeout,
+		"SERVER_REQUEST_TIMEOUT (%s) must not be negative and must be less than SERVER_WRITE_TIMEOUT (%s)", c.Server.RequestTimeout, c.Server.WriteTimeout)
+	check(c.Server.ErrorFormat == "json" || c.Server.ErrorFormat == "problem",
+		"SERVER_ERROR_FORMAT must be \"json\" or \"problem\", got %q", c.Server.ErrorFormat)
+	check(c.Server.BasePath == "" || basePathPattern.MatchString(c.Server.BasePath),
+		"SERVER_BASE_PATH must be empty or a path like /shortener, got %q", c.Server.BasePath)
+	check(c.Server.UnixSocketMode&^os.ModePerm == 0,
+		"SERVER_UNIX_SOCKET_MODE must be a permission mode between 0000 and 0777, got %#o", uint32(c.Server.UnixSocketMode))
+
+	// URL
+	if err := validateBaseURL(c.URL.BaseURL); err != nil {
+		errs = append(errs, fmt.Errorf("URL_BASE_URL %w", err))
+	}
+	for _, domain := range c.URL.ShortDomainsList() {
+		u, err := url.Parse("//" + domain)
+		check(err == nil && u.Host == domain && u.Hostname() != "",
+			"URL_SHORT_DOMAINS entries must be hosts such as \"go.example.com\", got %q", domain)
+	}
+	check(c.URL.MaxShortCodeLen >= MinShortCodeLen,
+		"URL_MAX_SHORT_CODE_LEN must be at least %d, got %d", MinShortCodeLen, c.URL.MaxShortCodeLen)
+	check(c.URL.ShortCodeLen >= MinShortCodeLen && c.URL.ShortCodeLen <= c.URL.MaxShortCodeLen,
+		"URL_SHORT_CODE_LEN must be between %d and %d, got %d", MinShortCodeLen, c.URL.MaxShortCodeLen, c.URL.ShortCodeLen)
+	// An unknown URL_IDGEN_STRATEGY is not an error: idgen.NewGenerator falls
+	// back to random codes with a warning.
+	if c.URL.IDGenStrategy == idgen.StrategySnowflake {
+		check(c.URL.SnowflakeMachineID >= 0 && c.URL.SnowflakeMachineID <= MaxSnowflakeMachineID,
+			"SNOWFLAKE_MACHINE_ID must be between 0 and %d, got %d", MaxSnowflakeMachineID, c.URL.SnowflakeMachineID)
+		check(!c.URL.SnowflakeEpoch.After(time.Now()),
+			"SNOWFLAKE_EPOCH must not be in the future, got %s", c.URL.SnowflakeEpoch.Format(time.RFC3339))
+		until := idgen.SnowflakeCodesFitUntil(c.URL.SnowflakeEpoch, c.URL.MaxShortCodeLen)
+		check(time.Now().Before(until),
+			"snowflake codes counted from SNOWFLAKE_EPOCH %s outgrew URL_MAX_SHORT_CODE_LEN (%d) at %s; use a later epoch or widen the short_code column",
+			c.URL.SnowflakeEpoch.Format(time.RFC3339), c.URL.MaxShortCodeLen, until.Format(time.RFC3339))
+	}
+	check(c.URL.IDGenMaxRetries >= 0, "URL_IDGEN_MAX_RETRIES must not be negative, got %d", c.URL.IDGenMaxRetries)
+	check(c.URL.CreateMaxRetries >= 0, "URL_CREATE_MAX_RETRIES must not be negative, got %d", c.URL.CreateMaxRetries)
+	check(c.URL.IDGenRetryBackoff >= 0, "URL_IDGEN_RETRY_BACKOFF must not be negative, got %s", c.URL.IDGenRetryBackoff)
+	check(c.URL.IDGenMaxBackoff >= c.URL.IDGenRetryBackoff,
+		"URL_IDGEN_MAX_BACKOFF (%s) must not be less than URL_IDGEN_RETRY_BACKOFF (%s)", c.URL.IDGenMaxBackoff, c.URL.IDGenRetryBackoff)
+	check(c.URL.IDGenLengthBumpAfter >= 0, "URL_IDGEN_LENGTH_BUMP_AFTER must not be negative, got %d", c.URL.IDGenLengthBumpAfter)
+	check(c.URL.AliasMinLength > 0, "URL_ALIAS_MIN_LENGTH must be positive, got %d", c.URL.AliasMinLength)
+	check(c.URL.AliasMaxLength >= c.URL.AliasMinLength,
+		"URL_ALIAS_MAX_LENGTH (%d) must not be less than URL_ALIAS_MIN_LENGTH (%d)", c.URL.AliasMaxLength, c.URL.AliasMinLength)
+	check(c.URL.AliasMaxLength <= c.URL.MaxShortCodeLen,
+		"URL_ALIAS_MAX_LENGTH (%d) must not exceed URL_MAX_SHORT_CODE_LEN (%d)", c.URL.AliasMaxLength, c.URL.MaxShortCodeLen)
+	check(c.URL.DefaultExpiry >= 0, "URL_DEFAULT_EXPIRY must not be negative, got %s", c.URL.DefaultExpiry)
+	check(c.URL.MaxExpiry >= 0, "URL_MAX_EXPIRY must not be negative, got %s", c.URL.MaxExpiry)
+	check(c.URL.MaxExpiry == 0 || c.URL.DefaultExpiry <= c.URL.MaxExpiry,
+		"URL_DEFAULT_EXPIRY (%s) must not exceed URL_MAX_EXPIRY (%s)", c.URL.DefaultExpiry, c.URL.MaxExpiry)
+	if c.URL.RootRedirectURL != "" {
+		if err := validateBaseURL(c.URL.RootRedirectURL); err != nil {
+			errs = append(errs, fmt.Errorf("URL_ROOT_REDIRECT %w", err))
+		}
+	}
+	if c.URL.NotFoundURL != "" {
+		if err := validateBaseURL(c.URL.NotFoundURL); err != nil {
+			errs = append(errs, fmt.Errorf("URL_NOT_FOUND_REDIRECT %w", err))
+		}
+	}
+	check(c.URL.NotFoundURL == "" || c.URL.NotFoundPage == "",
+		"URL_NOT_FOUND_REDIRECT and URL_NOT_FOUND_PAGE are mutually exclusive")
+	if c.URL.ExpiredURL != "" {
+		if err := validateBaseURL(c.URL.ExpiredURL); err != nil {
+			errs = append(errs, fmt.Errorf("EXPIRED_REDIRECT_URL %w", err))
+		}
+	}
+
+	// Rate limiting
+	if c.Rate.Enabled {
+		check(c.Rate.Requests > 0, "RATE_LIMIT_REQUESTS must be positive, got %d", c.Rate.Requests)
+		check(c.Rate.Window > 0, "RATE_LIMIT_WINDOW must be positive, got %s", c.Rate.Window)
+		check(c.Rate.Backend == "memory" || c.Rate.Backend == "redis",
+			"RATE_LIMIT_BACKEND must be \"memory\" or \"redis\", got %q", c.Rate.Backend)
+		check(c.Rate.Algorithm == "sliding_window" || c.Rate.Algorithm == "token_bucket",
+			"RATE_LIMIT_ALGORITHM must be \"sliding_window\" or \"token_bucket\", got %q", c.Rate.Algorithm)
+		check(c.Rate.Backend != "redis" || c.RedisEnabled(), "RATE_LIMIT_BACKEND=redis requires REDIS_HOST")
+		for _, entry := range c.Rate.AllowlistCIDRsList() {
+			_, _, cidrErr := net.ParseCIDR(entry)
+			check(cidrErr == nil || net.ParseIP(entry) != nil,
+				"RATE_LIMIT_ALLOWLIST entries must be IPs or CIDR ranges, got %q", entry)
+		}
+	}
+	// Trusted proxies also apply to client IPs used by analytics, so they are
+	// checked even when rate limiting is disabled
+	for _, proxy := range c.Rate.TrustedProxiesList() {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil,
+			"RATE_LIMIT_TRUSTED_PROXIES entries must be IPs or CIDR ranges, got %q", proxy)
+	}
+
+	// Database
+	if c.DatabaseEnabled() {
+		check(validPort(c.Database.Port), "DB_PORT must be between 1 and 65535, got %d", c.Database.Port)
+		check(c.Database.DBName != "", "DB_NAME must not be empty")
+		check(c.Database.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS must be positive, got %d", c.Database.MaxOpenConns)
+		check(c.Database.SchemaVersion >= 0, "DB_SCHEMA_VERSION must not be negative, got %d", c.Database.SchemaVersion)
+		check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+			"DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+		if _, err := c.Database.ReplicaConfigs(); err != nil {
+			errs = append(errs, fmt.Errorf("DB_REPLICA_HOSTS %w", err))
+		}
+	}
+
+	// Redis
+	if c.RedisEnabled() {
+		check(validPort(c.Redis.Port), "REDIS_PORT must be between 1 and 65535, got %d", c.Redis.Port)
+		check(c.Redis.DB >= 0, "REDIS_DB must not be negative, got %d", c.Redis.DB)
+		check(c.Redis.PoolSize > 0, "REDIS_POOL_SIZE must be positive, got %d", c.Redis.PoolSize)
+		check(c.Redis.CacheTTL > 0, "REDIS_CACHE_TTL must be positive, got %s", c.Redis.CacheTTL)
+		check(c.Redis.HealthCheckInterval > 0, "REDIS_HEALTH_CHECK_INTERVAL must be positive, got %s", c.Redis.HealthCheckInterval)
+		errs = append(errs, validateRedisMode(&c.Redis)...)
+	}
+
+	// Cache
+	check(c.Cache.Codec == "json" || c.Cache.Codec == "msgpack",
+		"CACHE_CODEC must be \"json\" or \"msgpack\", got %q", c.Cache.Codec)
+
+	// Analytics
+	check(c.Analytics.ClickCountMode == "batched" || c.Analytics.ClickCountMode == "sync" || c.Analytics.ClickCountMode == "off",
+		"CLICK_COUNT_MODE must be \"batched\", \"sync\" or \"off\", got %q", c.Analytics.ClickCountMode)
+	if c.Analytics.JournalPath != "" {
+		check(c.Analytics.JournalInterval > 0, "ANALYTICS_JOURNAL_INTERVAL must be positive, got %s", c.Analytics.JournalInterval)
+	}
+	check(c.Analytics.ArchiveInterval >= 0, "ANALYTICS_ARCHIVE_INTERVAL must not be negative, got %s", c.Analytics.ArchiveInterval)
+	if c.Analytics.ArchiveInterval > 0 {
+		check(c.Analytics.ArchiveThreshold > 0, "ANALYTICS_ARCHIVE_THRESHOLD must be positive, got %d", c.Analytics.ArchiveThreshold)
+	}
+	for _, sink := range c.Analytics.SinksList() {
+		switch sink {
+		case "http":
+			if err := validateBaseURL(c.Analytics.HTTPSinkURL); err != nil {
+				errs = append(errs, fmt.Errorf("ANALYTICS_HTTP_SINK_URL %w", err))
+			}
+			check(c.Analytics.HTTPSinkTimeout > 0, "ANALYTICS_HTTP_SINK_TIMEOUT must be positive, got %s", c.Analytics.HTTPSinkTimeout)
+		case "kafka":
+			check(len(c.Analytics.KafkaBrokersList()) > 0, "ANALYTICS_KAFKA_BROKERS must not be empty when the kafka sink is enabled")
+			check(c.Analytics.KafkaTopic != "", "ANALYTICS_KAFKA_TOPIC must not be empty when the kafka sink is enabled")
+		default:
+			check(false, "ANALYTICS_SINKS entries must be \"http\" or \"kafka\", got %q", sink)
+		}
+	}
+
+	// Reaper
+	if c.Reaper.Enabled {
+		check(c.Reaper.Interval > 0, "REAPER_INTERVAL must be positive, got %s", c.Reaper.Interval)
+	}
+
+	// Link check
+	if c.LinkCheck.Enabled {
+		check(c.LinkCheck.Interval > 0, "LINK_CHECK_INTERVAL must be positive, got %s", c.LinkCheck.Interval)
+		check(c.LinkCheck.RecheckAfter > 0, "LINK_CHECK_RECHECK_AFTER must be positive, got %s", c.LinkCheck.RecheckAfter)
+		check(c.LinkCheck.BatchSize > 0, "LINK_CHECK_BATCH_SIZE must be positive, got %d", c.LinkCheck.BatchSize)
+		check(c.LinkCheck.Concurrency > 0, "LINK_CHECK_CONCURRENCY must be positive, got %d", c.LinkCheck.Concurrency)
+		check(c.LinkCheck.RateLimit >= 0, "LINK_CHECK_RATE_LIMIT must not be negative, got %d", c.LinkCheck.RateLimit)
+		check(c.LinkCheck.Timeout > 0, "LINK_CHECK_TIMEOUT must be positive, got %s", c.LinkCheck.Timeout)
+	}
+
+	// Webhook
+	if c.Webhook.Enabled() {
+		if err := validateBaseURL(c.Webhook.URL); err != nil {
+			errs = append(errs, fmt.Errorf("WEBHOOK_URL %w", err))
+		}
+		check(c.Webhook.Timeout > 0, "WEBHOOK_TIMEOUT must be positive, got %s", c.Webhook.Timeout)
+		check(c.Webhook.MaxRetries >= 0, "WEBHOOK_MAX_RETRIES must not be negative, got %d", c.Webhook.MaxRetries)
+		if _, err := c.Webhook.ClickMilestoneList(); err != nil {
+			errs = append(errs, fmt.Errorf("WEBHOOK_CLICK_MILESTONES: %w", err))
+		}
+	}
+
+	// Idempotency
+	if c.Idempotency.Enabled {
+		check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL must be positive, got %s", c.Idempotency.TTL)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateRedisMode checks that the fields set match the Redis mode.
+func validateRedisMode(r *RedisConfig) []error {
+	var errs []error
+	switch r.Mode {
+	case RedisModeStandalone:
+		if r.Addrs != "" {
+			errs = append(errs, errors.New("REDIS_ADDRS is only used with REDIS_MODE=cluster or sentinel; set REDIS_HOST and REDIS_PORT instead"))
+		}
+		if r.MasterName != "" {
+			errs = append(errs, errors.New("REDIS_MASTER_NAME is only used with REDIS_MODE=sentinel"))
+		}
+	case RedisModeCluster:
+		if len(r.AddrsList()) == 0 {
+			errs = append(errs, errors.New("REDIS_MODE=cluster requires REDIS_ADDRS"))
+		}
+		if r.MasterName != "" {
+			errs = append(errs, errors.New("REDIS_MASTER_NAME is only used with REDIS_MODE=sentinel"))
+		}
+		if r.DB != 0 {
+			errs = append(errs, fmt.Errorf("REDIS_DB must be 0 with REDIS_MODE=cluster, got %d", r.DB))
+		}
+	case RedisModeSentinel:
+		if len(r.AddrsList()) == 0 {
+			errs = append(errs, errors.New("REDIS_MODE=sentinel requires REDIS_ADDRS"))
+		}
+		if r.MasterName == "" {
+			errs = append(errs, errors.New("REDIS_MODE=sentinel requires REDIS_MASTER_NAME"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("REDIS_MODE must be \"standalone\", \"cluster\" or \"sentinel\", got %q", r.Mode))
+	}
+	for _, addr := range r.AddrsList() {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Errorf("REDIS_ADDRS entry %q must be host:port", addr))
+		}
+	}
+	return errs
+}
+
+// validPort reports whether port is a usable TCP port number.
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// validateBaseURL checks that base is an absolute http(s) URL.
+func validateBaseURL(base string) error {
+	if base == "" {
+		return errors.New("must not be empty")
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must use http or https, got %q", base)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("must include a host, got %q", base)
+	}
+	return nil
+}
@@ -0,0 +1,451 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/idgen"
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a configuration that passes Validate.
+func validConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              8080,
+			ReadTimeout:       5 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			ReadHeaderTimeout: 2 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			UnixSocketMode:    0o660,
+			ErrorFormat:       "json",
+		},
+		URL: URLConfig{
+			BaseURL:         "http://localhost:8080",
+			ShortCodeLen:    7,
+			MaxShortCodeLen: 10,
+			IDGenStrategy:   "random",
+			IDGenMaxRetries: 3,
+			AliasMinLength:  3,
+			AliasMaxLength:  10,
+		},
+		Rate: RateLimitConfig{
+			Enabled:   true,
+			Requests:  100,
+			Window:    time.Minute,
+			Backend:   "memory",
+			Algorithm: "sliding_window",
+		},
+		Database: DatabaseConfig{
+			Host:         "localhost",
+			Port:         5432,
+			Password:     "secret",
+			DBName:       "fastgolink",
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
+		},
+		Redis: RedisConfig{
+			Host:     "localhost",
+			Port:     6379,
+			PoolSize: 10,
+			CacheTTL: time.Hour,
+
+			HealthCheckInterval: 5 * time.Second,
+			Mode:                RedisModeStandalone,
+		},
+		Cache: CacheConfig{
+			Codec: "json",
+		},
+		Analytics: AnalyticsConfig{
+			ClickCountMode: "batched",
+		},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		assert.NoError(t, validConfig().Validate())
+	})
+
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{
+			name:    "zero port",
+			modify:  func(c *Config) { c.Server.Port = 0 },
+			wantErr: "SERVER_PORT must be between 1 and 65535, got 0",
+		},
+		{
+			name:    "port out of range",
+			modify:  func(c *Config) { c.Server.Port = 70000 },
+			wantErr: "SERVER_PORT",
+		},
+		{
+			name:    "zero write timeout",
+			modify:  func(c *Config) { c.Server.WriteTimeout = 0 },
+			wantErr: "SERVER_WRITE_TIMEOUT must be positive",
+		},
+		{
+			name:    "zero read header timeout",
+			modify:  func(c *Config) { c.Server.ReadHeaderTimeout = 0 },
+			wantErr: "SERVER_READ_HEADER_TIMEOUT must be positive",
+		},
+		{
+			name:    "zero idle timeout",
+			modify:  func(c *Config) { c.Server.IdleTimeout = 0 },
+			wantErr: "SERVER_IDLE_TIMEOUT must be positive",
+		},
+		{
+			name:    "zero max header bytes",
+			modify:  func(c *Config) { c.Server.MaxHeaderBytes = 0 },
+			wantErr: "SERVER_MAX_HEADER_BYTES must be positive",
+		},
+		{
+			name:    "unix socket mode with non-permission bits",
+			modify:  func(c *Config) { c.Server.UnixSocketMode = os.ModeSetuid | 0o755 },
+			wantErr: "SERVER_UNIX_SOCKET_MODE",
+		},
+		{
+			name:    "base path without leading slash",
+			modify:  func(c *Config) { c.Server.BasePath = "shortener" },
+			wantErr: "SERVER_BASE_PATH",
+		},
+		{
+			name:    "base path with query",
+			modify:  func(c *Config) { c.Server.BasePath = "/shortener?x=1" },
+			wantErr: "SERVER_BASE_PATH",
+		},
+		{
+			name:    "base path with empty segment",
+			modify:  func(c *Config) { c.Server.BasePath = "/a//b" },
+			wantErr: "SERVER_BASE_PATH",
+		},
+		{
+			name:    "unknown error format",
+			modify:  func(c *Config) { c.Server.ErrorFormat = "xml" },
+			wantErr: "SERVER_ERROR_FORMAT",
+		},
+		{
+			name:    "negative request timeout",
+			modify:  func(c *Config) { c.Server.RequestTimeout = -time.Second },
+			wantErr: "SERVER_REQUEST_TIMEOUT",
+		},
+		{
+			name:    "request timeout not below write timeout",
+			modify:  func(c *Config) { c.Server.RequestTimeout = c.Server.WriteTimeout },
+			wantErr: "SERVER_REQUEST_TIMEOUT",
+		},
+		{
+			name:    "empty base URL",
+			modify:  func(c *Config) { c.URL.BaseURL = "" },
+			wantErr: "URL_BASE_URL must not be empty",
+		},
+		{
+			name:    "base URL without scheme",
+			modify:  func(c *Config) { c.URL.BaseURL = "localhost:8080" },
+			wantErr: "URL_BASE_URL must use http or https",
+		},
+		{
+			name:    "base URL without host",
+			modify:  func(c *Config) { c.URL.BaseURL = "https://" },
+			wantErr: "URL_BASE_URL must include a host",
+		},
+		{
+			name:    "zero short code length",
+			modify:  func(c *Config) { c.URL.ShortCodeLen = 0 },
+			wantErr: "URL_SHORT_CODE_LEN must be between 4 and 10, got 0",
+		},
+		{
+			name:    "short code longer than column",
+			modify:  func(c *Config) { c.URL.ShortCodeLen = 11 },
+			wantErr: "URL_SHORT_CODE_LEN",
+		},
+		{
+			name:    "max short code length below minimum",
+			modify:  func(c *Config) { c.URL.MaxShortCodeLen = 3 },
+			wantErr: "URL_MAX_SHORT_CODE_LEN must be at least 4, got 3",
+		},
+		{
+			name:    "alias longer than max short code",
+			modify:  func(c *Config) { c.URL.AliasMaxLength = 12 },
+			wantErr: "URL_ALIAS_MAX_LENGTH (12) must not exceed URL_MAX_SHORT_CODE_LEN (10)",
+		},
+		{
+			name: "snowflake machine ID too large",
+			modify: func(c *Config) {
+				c.URL.IDGenStrategy, c.URL.SnowflakeEpoch = "snowflake", time.Now().Add(-time.Hour)
+				c.URL.SnowflakeMachineID = 1024
+			},
+			wantErr: "SNOWFLAKE_MACHINE_ID must be between 0 and 1023, got 1024",
+		},
+		{
+			name: "negative snowflake machine ID",
+			modify: func(c *Config) {
+				c.URL.IDGenStrategy, c.URL.SnowflakeEpoch = "snowflake", time.Now().Add(-time.Hour)
+				c.URL.SnowflakeMachineID = -1
+			},
+			wantErr: "SNOWFLAKE_MACHINE_ID must be between 0 and 1023, got -1",
+		},
+		{
+			name: "snowflake epoch in the future",
+			modify: func(c *Config) {
+				c.URL.IDGenStrategy = "snowflake"
+				c.URL.SnowflakeEpoch = time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			wantErr: "SNOWFLAKE_EPOCH must not be in the future, got 2999-01-01T00:00:00Z",
+		},
+		{
+			name: "snowflake codes too long for the epoch",
+			modify: func(c *Config) {
+				c.URL.IDGenStrategy = "snowflake"
+				c.URL.SnowflakeEpoch = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			wantErr: "snowflake codes counted from SNOWFLAKE_EPOCH 2015-01-01T00:00:00Z outgrew URL_MAX_SHORT_CODE_LEN (10)",
+		},
+		{
+			name: "ID generation max backoff below backoff",
+			modify: func(c *Config) {
+				c.URL.IDGenRetryBackoff, c.URL.IDGenMaxBackoff = 50*time.Millisecond, 10*time.Millisecond
+			},
+			wantErr: "URL_IDGEN_MAX_BACKOFF (10ms) must not be less than URL_IDGEN_RETRY_BACKOFF (50ms)",
+		},
+		{
+			name:    "negative length bump threshold",
+			modify:  func(c *Config) { c.URL.IDGenLengthBumpAfter = -1 },
+			wantErr: "URL_IDGEN_LENGTH_BUMP_AFTER must not be negative, got -1",
+		},
+		{
+			name:    "negative default expiry",
+			modify:  func(c *Config) { c.URL.DefaultExpiry = -time.Hour },
+			wantErr: "URL_DEFAULT_EXPIRY must not be negative, got -1h0m0s",
+		},
+		{
+			name:    "default expiry above max",
+			modify:  func(c *Config) { c.URL.DefaultExpiry, c.URL.MaxExpiry = 48*time.Hour, 24*time.Hour },
+			wantErr: "URL_DEFAULT_EXPIRY (48h0m0s) must not exceed URL_MAX_EXPIRY (24h0m0s)",
+		},
+		{
+			name:    "alias max below min",
+			modify:  func(c *Config) { c.URL.AliasMinLength, c.URL.AliasMaxLength = 8, 4 },
+			wantErr: "URL_ALIAS_MAX_LENGTH (4) must not be less than URL_ALIAS_MIN_LENGTH (8)",
+		},
+		{
+			name:    "relative root redirect",
+			modify:  func(c *Config) { c.URL.RootRedirectURL = "/landing" },
+			wantErr: "URL_ROOT_REDIRECT must use http or https",
+		},
+		{
+			name:    "relative not-found redirect",
+			modify:  func(c *Config) { c.URL.NotFoundURL = "missing" },
+			wantErr: "URL_NOT_FOUND_REDIRECT must use http or https",
+		},
+		{
+			name: "not-found redirect and page",
+			modify: func(c *Config) {
+				c.URL.NotFoundURL = "https://example.com/404"
+				c.URL.NotFoundPage = "404.html"
+			},
+			wantErr: "URL_NOT_FOUND_REDIRECT and URL_NOT_FOUND_PAGE are mutually exclusive",
+		},
+		{
+			name:    "zero rate limit window",
+			modify:  func(c *Config) { c.Rate.Window = 0 },
+			wantErr: "RATE_LIMIT_WINDOW must be positive",
+		},
+		{
+			name:    "unknown rate limit backend",
+			modify:  func(c *Config) { c.Rate.Backend = "memcached" },
+			wantErr: `RATE_LIMIT_BACKEND must be "memory" or "redis", got "memcached"`,
+		},
+		{
+			name:    "unknown rate limit algorithm",
+			modify:  func(c *Config) { c.Rate.Algorithm = "leaky_bucket" },
+			wantErr: "RATE_LIMIT_ALGORITHM",
+		},
+		{
+			name: "redis backend without redis",
+			modify: func(c *Config) {
+				c.Rate.Backend = "redis"
+				c.Redis.Host = ""
+			},
+			wantErr: "RATE_LIMIT_BACKEND=redis requires REDIS_HOST",
+		},
+		{
+			name:    "unknown redis mode",
+			modify:  func(c *Config) { c.Redis.Mode = "replicated" },
+			wantErr: `REDIS_MODE must be "standalone", "cluster" or "sentinel", got "replicated"`,
+		},
+		{
+			name:    "standalone with addresses",
+			modify:  func(c *Config) { c.Redis.Addrs = "redis-a:6379" },
+			wantErr: "REDIS_ADDRS is only used with REDIS_MODE=cluster or sentinel",
+		},
+		{
+			name:    "standalone with master name",
+			modify:  func(c *Config) { c.Redis.MasterName = "mymaster" },
+			wantErr: "REDIS_MASTER_NAME is only used with REDIS_MODE=sentinel",
+		},
+		{
+			name:    "cluster without addresses",
+			modify:  func(c *Config) { c.Redis.Mode = RedisModeCluster },
+			wantErr: "REDIS_MODE=cluster requires REDIS_ADDRS",
+		},
+		{
+			name: "cluster with non-zero DB",
+			modify: func(c *Config) {
+				c.Redis.Mode = RedisModeCluster
+				c.Redis.Addrs = "redis-a:6379,redis-b:6379"
+				c.Redis.DB = 2
+			},
+			wantErr: "REDIS_DB must be 0 with REDIS_MODE=cluster, got 2",
+		},
+		{
+			name: "sentinel without master name",
+			modify: func(c *Config) {
+				c.Redis.Mode = RedisModeSentinel
+				c.Redis.Addrs = "sentinel-a:26379"
+			},
+			wantErr: "REDIS_MODE=sentinel requires REDIS_MASTER_NAME",
+		},
+		{
+			name: "address without port",
+			modify: func(c *Config) {
+				c.Redis.Mode = RedisModeCluster
+				c.Redis.Addrs = "redis-a"
+			},
+			wantErr: `REDIS_ADDRS entry "redis-a" must be host:port`,
+		},
+		{
+			name:    "unknown cache codec",
+			modify:  func(c *Config) { c.Cache.Codec = "gob" },
+			wantErr: `CACHE_CODEC must be "json" or "msgpack", got "gob"`,
+		},
+		{
+			name:    "unknown click count mode",
+			modify:  func(c *Config) { c.Analytics.ClickCountMode = "async" },
+			wantErr: `CLICK_COUNT_MODE must be "batched", "sync" or "off", got "async"`,
+		},
+		{
+			name:    "idle conns above open conns",
+			modify:  func(c *Config) { c.Database.MaxIdleConns = 50 },
+			wantErr: "DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (25), got 50",
+		},
+		{
+			name:    "empty database name",
+			modify:  func(c *Config) { c.Database.DBName = "" },
+			wantErr: "DB_NAME must not be empty",
+		},
+		{
+			name:    "zero redis pool size",
+			modify:  func(c *Config) { c.Redis.PoolSize = 0 },
+			wantErr: "REDIS_POOL_SIZE must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("skips disabled sections", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Rate.Enabled = false
+		cfg.Rate.Window = 0
+		cfg.Database.Password = ""
+		cfg.Database.MaxOpenConns = 0
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("skips snowflake settings for other strategies", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.URL.SnowflakeMachineID = 1024
+		cfg.URL.SnowflakeEpoch = time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("valid cluster and sentinel modes", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Redis.Mode = RedisModeCluster
+		cfg.Redis.Addrs = "redis-a:6379, redis-b:6379"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Redis.Mode = RedisModeSentinel
+		cfg.Redis.Addrs = "sentinel-a:26379"
+		cfg.Redis.MasterName = "mymaster"
+		cfg.Redis.DB = 1
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Server.Port = 0
+		cfg.URL.BaseURL = ""
+		cfg.URL.ShortCodeLen = 0
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_PORT")
+		assert.Contains(t, err.Error(), "URL_BASE_URL")
+		assert.Contains(t, err.Error(), "URL_SHORT_CODE_LEN")
+	})
+}
+
+func TestLoad_ValidatesConfig(t *testing.T) {
+	setEnv(t, "URL_SHORT_CODE_LEN", "0")
+	setEnv(t, "URL_BASE_URL", "ftp://example.com")
+
+	cfg, err := Load()
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+	assert.Contains(t, err.Error(), "URL_SHORT_CODE_LEN")
+	assert.Contains(t, err.Error(), "URL_BASE_URL")
+}
+
+func TestLoad_UnknownIDGenStrategyFallsBackToRandom(t *testing.T) {
+	setEnv(t, "URL_IDGEN_STRATEGY", "uuid")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	// Wire the generator the way the server does
+	var buf bytes.Buffer
+	gen, err := idgen.NewGenerator(cfg.URL.IDGenStrategy, idgen.GeneratorConfig{
+		CodeLength: cfg.URL.ShortCodeLen,
+		NodeID:     int64(cfg.URL.SnowflakeMachineID),
+		Epoch:      cfg.URL.SnowflakeEpoch,
+		Logger:     logger.New(&buf, "warn"),
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &idgen.RandomGenerator{}, gen)
+	assert.Contains(t, buf.String(), "unknown ID generation strategy")
+
+	code, err := gen.Generate()
+	require.NoError(t, err)
+	assert.Len(t, code, cfg.URL.ShortCodeLen)
+}
+
+func TestLoad_DefaultsAreValid(t *testing.T) {
+	_, err := Load()
+	require.NoError(t, err)
+
+	setEnv(t, "DB_PASSWORD", "testpass")
+	_, err = Load()
+	require.NoError(t, err)
+}
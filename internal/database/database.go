@@ -4,6 +4,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -17,13 +18,13 @@ type Pool struct {
 
 // Stats represents pool statistics.
 type Stats struct {
-	MaxConns          int32
-	TotalConns        int32
-	IdleConns         int32
-	AcquiredConns     int32
-	AcquireCount      int64
-	AcquireDuration   int64
-	EmptyAcquireCount int64
+	MaxConns          int32         // Maximum size of the pool
+	TotalConns        int32         // Connections currently open, idle or in use
+	IdleConns         int32         // Open connections not in use
+	AcquiredConns     int32         // Connections currently checked out
+	AcquireCount      int64         // Cumulative successful acquires
+	AcquireDuration   time.Duration // Cumulative time spent waiting to acquire
+	EmptyAcquireCount int64         // Cumulative acquires that had to wait for a connection
 }
 
 // NewPool creates a new database connection pool.
@@ -82,7 +83,7 @@ func (p *Pool) Stats() *Stats {
 		IdleConns:         s.IdleConns(),
 		AcquiredConns:     s.AcquiredConns(),
 		AcquireCount:      s.AcquireCount(),
-		AcquireDuration:   int64(s.AcquireDuration()),
+		AcquireDuration:   s.AcquireDuration(),
 		EmptyAcquireCount: s.EmptyAcquireCount(),
 	}
 }
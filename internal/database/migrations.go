@@ -5,6 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -33,6 +34,12 @@ type MigrationRecord struct {
 	AppliedAt time.Time
 }
 
+// MigrationStatus reports the applied version and what remains to apply.
+type MigrationStatus struct {
+	CurrentVersion int
+	Pending        []Migration
+}
+
 // NewMigrator creates a new Migrator with embedded migrations.
 func NewMigrator(pool *Pool, migrationsFS embed.FS, dir string) (*Migrator, error) {
 	migrations, err := loadMigrations(migrationsFS, dir)
@@ -84,7 +91,7 @@ func loadMigrations(migrationsFS embed.FS, dir string) ([]Migration, error) {
 			continue
 		}
 
-		content, err := fs.ReadFile(migrationsFS, dir+"/"+name)
+		content, err := fs.ReadFile(migrationsFS, path.Join(dir, name))
 		if err != nil {
 			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
 		}
@@ -196,32 +203,121 @@ func (m *Migrator) Up(ctx context.Context) (int, error) {
 
 // Down rolls back the last migration.
 func (m *Migrator) Down(ctx context.Context) error {
+	_, err := m.DownN(ctx, 1)
+	return err
+}
+
+// DownN rolls back the last steps migrations, newest first, and returns how
+// many were rolled back. It stops early when no applied migrations remain.
+func (m *Migrator) DownN(ctx context.Context, steps int) (int, error) {
+	if steps < 0 {
+		return 0, fmt.Errorf("steps must not be negative, got %d", steps)
+	}
+
 	applied, err := m.AppliedMigrations(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if len(applied) == 0 {
-		return nil // Nothing to rollback
+	rolledBack := 0
+	for i := len(applied) - 1; i >= 0 && rolledBack < steps; i-- {
+		migration, err := m.findMigration(applied[i].Version)
+		if err != nil {
+			return rolledBack, err
+		}
+		if err := m.rollbackMigration(ctx, migration); err != nil {
+			return rolledBack, fmt.Errorf("failed to roll back migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		rolledBack++
+	}
+
+	return rolledBack, nil
+}
+
+// MigrateTo applies or rolls back migrations until targetVersion is the
+// newest applied migration. A target of 0 rolls back every migration.
+// It returns how many migrations were applied or rolled back.
+func (m *Migrator) MigrateTo(ctx context.Context, targetVersion int) (int, error) {
+	if targetVersion != 0 {
+		if _, err := m.findMigration(targetVersion); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := m.EnsureMigrationsTable(ctx); err != nil {
+		return 0, fmt.Errorf("failed to ensure migrations table: %w", err)
 	}
 
-	// Find the last applied migration
-	lastApplied := applied[len(applied)-1]
+	applied, err := m.AppliedMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+
+	// Roll back anything newer than the target, newest first
+	for i := len(applied) - 1; i >= 0; i-- {
+		if applied[i].Version <= targetVersion {
+			continue
+		}
+		migration, err := m.findMigration(applied[i].Version)
+		if err != nil {
+			return changed, err
+		}
+		if err := m.rollbackMigration(ctx, migration); err != nil {
+			return changed, fmt.Errorf("failed to roll back migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		changed++
+	}
 
-	// Find the migration with the matching version
-	var migration *Migration
-	for i := range m.migrations {
-		if m.migrations[i].Version == lastApplied.Version {
-			migration = &m.migrations[i]
-			break
+	// Apply pending migrations up to and including the target, oldest first
+	appliedSet := make(map[int]bool, len(applied))
+	for _, r := range applied {
+		appliedSet[r.Version] = true
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > targetVersion || appliedSet[migration.Version] {
+			continue
 		}
+		if err := m.applyMigration(ctx, migration); err != nil {
+			return changed, fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		changed++
 	}
 
-	if migration == nil {
-		return fmt.Errorf("migration %d not found", lastApplied.Version)
+	return changed, nil
+}
+
+// Status returns the current version and the migrations not yet applied.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	if err := m.EnsureMigrationsTable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	version, err := m.CurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := m.PendingMigrations(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return m.rollbackMigration(ctx, *migration)
+	return &MigrationStatus{
+		CurrentVersion: version,
+		Pending:        pending,
+	}, nil
+}
+
+// findMigration returns the known migration with the given version.
+func (m *Migrator) findMigration(version int) (Migration, error) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("migration %d not found", version)
 }
 
 // applyMigration applies a single migration.
@@ -287,3 +383,31 @@ func (m *Migrator) CurrentVersion(ctx context.Context) (int, error) {
 
 	return applied[len(applied)-1].Version, nil
 }
+
+// LatestVersion returns the version of the newest known migration, or 0 when
+// there are none.
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// SchemaVersionCheck returns a readiness check that fails while the schema
+// reported by any of versions, typically Migrator.CurrentVersion for each
+// shard, is behind expected. A newer schema passes, so instances running the
+// previous release stay ready while the next one migrates.
+func SchemaVersionCheck(expected int, versions ...func(ctx context.Context) (int, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for i, version := range versions {
+			current, err := version(ctx)
+			if err != nil {
+				return fmt.Errorf("shard %d: failed to read schema version: %w", i, err)
+			}
+			if current < expected {
+				return fmt.Errorf("shard %d: schema is at version %d, expected %d", i, current, expected)
+			}
+		}
+		return nil
+	}
+}
@@ -2,11 +2,14 @@ package database
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/migrations"
 )
 
 func TestMigrator_Up(t *testing.T) {
@@ -343,3 +346,174 @@ func TestMigration_TimestampRecording(t *testing.T) {
 	// Clean up
 	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS schema_migrations")
 }
+
+// threeStepMigrations builds a test_table in three versions.
+func threeStepMigrations() []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "create_test_table",
+			UpSQL:   "CREATE TABLE test_table (id SERIAL PRIMARY KEY)",
+			DownSQL: "DROP TABLE test_table",
+		},
+		{
+			Version: 2,
+			Name:    "add_name_column",
+			UpSQL:   "ALTER TABLE test_table ADD COLUMN name VARCHAR(255)",
+			DownSQL: "ALTER TABLE test_table DROP COLUMN name",
+		},
+		{
+			Version: 3,
+			Name:    "add_email_column",
+			UpSQL:   "ALTER TABLE test_table ADD COLUMN email VARCHAR(255)",
+			DownSQL: "ALTER TABLE test_table DROP COLUMN email",
+		},
+	}
+}
+
+func TestMigrator_DownN(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	ctx := context.Background()
+	cfg := testDBConfig()
+
+	pool, err := NewPool(ctx, cfg)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// Clean up
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS schema_migrations")
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS test_table")
+
+	migrator := NewMigratorWithMigrations(pool, threeStepMigrations())
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	// Roll back two steps
+	rolledBack, err := migrator.DownN(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rolledBack)
+
+	version, err := migrator.CurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	// Asking for more steps than remain stops at version 0
+	rolledBack, err = migrator.DownN(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rolledBack)
+
+	version, err = migrator.CurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	_, err = migrator.DownN(ctx, -1)
+	assert.Error(t, err)
+
+	// Clean up
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS test_table")
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS schema_migrations")
+}
+
+func TestMigrator_MigrateTo(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	ctx := context.Background()
+	cfg := testDBConfig()
+
+	pool, err := NewPool(ctx, cfg)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// Clean up
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS schema_migrations")
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS test_table")
+
+	migrator := NewMigratorWithMigrations(pool, threeStepMigrations())
+
+	// Forward to a version short of the latest
+	changed, err := migrator.MigrateTo(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.CurrentVersion)
+	require.Len(t, status.Pending, 1)
+	assert.Equal(t, 3, status.Pending[0].Version)
+
+	// Forward to the latest
+	changed, err = migrator.MigrateTo(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+
+	// Already there
+	changed, err = migrator.MigrateTo(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	// Back to the first version
+	changed, err = migrator.MigrateTo(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	version, err := migrator.CurrentVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	// Back to an empty schema
+	changed, err = migrator.MigrateTo(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.CurrentVersion)
+	assert.Len(t, status.Pending, 3)
+
+	// Clean up
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS test_table")
+	_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS schema_migrations")
+}
+
+func TestMigrator_MigrateToUnknownVersion(t *testing.T) {
+	migrator := NewMigratorWithMigrations(nil, threeStepMigrations())
+
+	_, err := migrator.MigrateTo(context.Background(), 7)
+	assert.ErrorContains(t, err, "migration 7 not found")
+}
+
+func TestNewMigrator_EmbeddedMigrations(t *testing.T) {
+	migrator, err := NewMigrator(nil, migrations.FS, ".")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrator.migrations)
+
+	for i, m := range migrator.migrations {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, m.UpSQL, "migration %d has no up SQL", m.Version)
+		assert.NotEmpty(t, m.DownSQL, "migration %d has no down SQL", m.Version)
+	}
+}
+
+func TestMigrator_LatestVersion(t *testing.T) {
+	assert.Equal(t, 3, NewMigratorWithMigrations(nil, threeStepMigrations()).LatestVersion())
+	assert.Zero(t, NewMigratorWithMigrations(nil, nil).LatestVersion())
+}
+
+func TestSchemaVersionCheck(t *testing.T) {
+	at := func(version int, err error) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) { return version, err }
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, SchemaVersionCheck(3, at(3, nil))(ctx))
+	assert.NoError(t, SchemaVersionCheck(3, at(4, nil))(ctx), "a newer schema is ready")
+
+	err := SchemaVersionCheck(3, at(3, nil), at(2, nil))(ctx)
+	assert.EqualError(t, err, "shard 1: schema is at version 2, expected 3")
+
+	err = SchemaVersionCheck(3, at(0, errors.New("relation \"schema_migrations\" does not exist")))(ctx)
+	assert.ErrorContains(t, err, "shard 0: failed to read schema version")
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy controls how WithRetry retries an operation.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 1 disables retries
+	InitialBackoff time.Duration // Wait before the second attempt
+	MaxBackoff     time.Duration // Upper bound on the wait between attempts
+}
+
+// DefaultRetryPolicy returns a policy suited to short transient failures:
+// three attempts, waiting about 50ms and then 100ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+}
+
+// WithRetry calls fn until it succeeds, fails with an error that IsRetryable
+// rejects, or the policy runs out of attempts. The wait between attempts
+// doubles each time, with jitter. It gives up early when ctx is done or its
+// deadline would pass during the wait, returning the last error from fn.
+//
+// Only wrap operations that are safe to run more than once: fn may have
+// taken effect even though it returned an error.
+func WithRetry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		// Sleep between half and all of the backoff so concurrent callers
+		// do not retry in lockstep
+		wait := backoff/2 + rand.N(backoff/2+1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, policy.MaxBackoff)
+	}
+}
+
+// IsRetryable reports whether err is a transient failure that may succeed
+// on another attempt: a lost or refused connection, a serialization failure
+// or a deadlock. Context cancellation and expiry are never retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"57P01": // admin_shutdown
+			return true
+		}
+		// Class 08 covers connection exceptions
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
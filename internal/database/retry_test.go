@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fastRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    attempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     4 * time.Millisecond,
+	}
+}
+
+// failingOp fails with err for the first failures calls, then succeeds.
+type failingOp struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *failingOp) run(context.Context) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func TestWithRetry_SucceedsAfterTransientFailures(t *testing.T) {
+	op := &failingOp{failures: 2, err: &pgconn.PgError{Code: "40001"}}
+
+	err := WithRetry(context.Background(), fastRetryPolicy(3), op.run)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, op.calls)
+}
+
+func TestWithRetry_StopsAtMaxAttempts(t *testing.T) {
+	transient := fmt.Errorf("failed to get URL: %w", io.ErrUnexpectedEOF)
+	op := &failingOp{failures: 10, err: transient}
+
+	err := WithRetry(context.Background(), fastRetryPolicy(3), op.run)
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 3, op.calls)
+}
+
+func TestWithRetry_DoesNotRetryPermanentErrors(t *testing.T) {
+	op := &failingOp{failures: 10, err: &pgconn.PgError{Code: "23505"}}
+
+	err := WithRetry(context.Background(), fastRetryPolicy(3), op.run)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, op.calls)
+}
+
+func TestWithRetry_SingleAttempt(t *testing.T) {
+	op := &failingOp{failures: 1, err: io.EOF}
+
+	err := WithRetry(context.Background(), fastRetryPolicy(1), op.run)
+
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 1, op.calls)
+}
+
+func TestWithRetry_RespectsContext(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	t.Run("deadline shorter than backoff", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		op := &failingOp{failures: 10, err: io.EOF}
+
+		start := time.Now()
+		err := WithRetry(ctx, policy, op.run)
+
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 1, op.calls)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		op := &failingOp{failures: 10, err: io.EOF}
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		err := WithRetry(ctx, policy, op.run)
+
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 1, op.calls)
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", syscall.ECONNREFUSED, true},
+		{"unexpected EOF", fmt.Errorf("failed to get URL: %w", io.ErrUnexpectedEOF), true},
+		{"context canceled", context.Canceled, false},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
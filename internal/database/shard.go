@@ -16,27 +16,77 @@ type ShardConfig struct {
 	Config *config.DatabaseConfig
 }
 
-// ShardRouter routes requests to appropriate database shards.
+// HashVersion selects the hash ShardRouter places keys and shards with.
+// Every version puts most keys on a different shard than the others, so a
+// deployment must keep the version its rows were written with, or move the
+// rows to their new shards when it switches.
+type HashVersion int
+
+const (
+	// HashFNV hashes with plain FNV-1a. It spreads the ring's virtual nodes
+	// unevenly, leaving some shards with several times the keys of others,
+	// but it is the placement existing multi-shard deployments were written
+	// with, so it stays the default.
+	HashFNV HashVersion = iota
+
+	// HashMixed runs FNV-1a through the murmur3 finalizer, which spreads
+	// keys evenly across shards. New deployments should use it.
+	HashMixed
+)
+
+// hash hashes key with the version's hash function.
+func (v HashVersion) hash(key string) uint32 {
+	if v == HashMixed {
+		return hashKey(key)
+	}
+	return fnvHash(key)
+}
+
+// ShardRouterConfig holds tunable settings for ShardRouter.
+type ShardRouterConfig struct {
+	HashVersion HashVersion
+}
+
+// DefaultShardRouterConfig returns the default shard router configuration,
+// which keeps the HashFNV placement.
+func DefaultShardRouterConfig() ShardRouterConfig {
+	return ShardRouterConfig{HashVersion: HashFNV}
+}
+
+// ShardRouter routes requests to appropriate database shards. Keys are
+// placed on a consistent hash ring with virtual nodes for every shard, so
+// adding a shard only moves the keys it takes over, about 1/N of them,
+// rather than remapping most keys as hash % shardCount would.
 type ShardRouter struct {
 	shards       []*Pool
 	shardCount   int
 	ring         []uint32
 	ringToShard  map[uint32]int
 	virtualNodes int
+	hashVersion  HashVersion
 	mu           sync.RWMutex
 }
 
 // NewShardRouter creates a new shard router with the given shard configurations.
 func NewShardRouter(ctx context.Context, configs []ShardConfig) (*ShardRouter, error) {
+	return NewShardRouterWithConfig(ctx, configs, DefaultShardRouterConfig())
+}
+
+// NewShardRouterWithConfig creates a shard router with custom settings.
+func NewShardRouterWithConfig(ctx context.Context, configs []ShardConfig, cfg ShardRouterConfig) (*ShardRouter, error) {
 	if len(configs) == 0 {
 		return nil, fmt.Errorf("at least one shard configuration is required")
 	}
+	if cfg.HashVersion != HashFNV && cfg.HashVersion != HashMixed {
+		return nil, fmt.Errorf("unknown shard hash version %d", cfg.HashVersion)
+	}
 
 	router := &ShardRouter{
 		shards:       make([]*Pool, len(configs)),
 		shardCount:   len(configs),
 		ringToShard:  make(map[uint32]int),
 		virtualNodes: 150, // Virtual nodes per shard for better distribution
+		hashVersion:  cfg.HashVersion,
 	}
 
 	// Create pools for each shard
@@ -65,7 +115,7 @@ func (r *ShardRouter) buildRing() {
 	for shardIdx := 0; shardIdx < r.shardCount; shardIdx++ {
 		for vn := 0; vn < r.virtualNodes; vn++ {
 			key := fmt.Sprintf("shard-%d-vn-%d", shardIdx, vn)
-			hash := hashKey(key)
+			hash := r.hashVersion.hash(key)
 			r.ring = append(r.ring, hash)
 			r.ringToShard[hash] = shardIdx
 		}
@@ -85,7 +135,7 @@ func (r *ShardRouter) GetShard(key string) *Pool {
 		return r.shards[0]
 	}
 
-	hash := hashKey(key)
+	hash := r.hashVersion.hash(key)
 	idx := r.findShardIndex(hash)
 	shardIdx := r.ringToShard[r.ring[idx]]
 
@@ -101,7 +151,7 @@ func (r *ShardRouter) GetShardIndex(key string) int {
 		return 0
 	}
 
-	hash := hashKey(key)
+	hash := r.hashVersion.hash(key)
 	idx := r.findShardIndex(hash)
 	return r.ringToShard[r.ring[idx]]
 }
@@ -130,6 +180,18 @@ func (r *ShardRouter) GetAllShards() []*Pool {
 	return shards
 }
 
+// Stats returns pool statistics for every shard, indexed by shard.
+func (r *ShardRouter) Stats() []*Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make([]*Stats, len(r.shards))
+	for i, shard := range r.shards {
+		stats[i] = shard.Stats()
+	}
+	return stats
+}
+
 // ShardCount returns the number of shards.
 func (r *ShardRouter) ShardCount() int {
 	return r.shardCount
@@ -158,13 +220,31 @@ func (r *ShardRouter) Close() {
 	}
 }
 
-// hashKey generates a hash for the given key.
+// hashKey generates the HashMixed hash of key: FNV-1a run through the
+// murmur3 finalizer, since FNV-1a alone spreads keys that differ in a few
+// characters, like the ring's virtual node names, unevenly.
 func hashKey(key string) uint32 {
+	return mix32(fnvHash(key))
+}
+
+// fnvHash generates the HashFNV hash of key.
+func fnvHash(key string) uint32 {
 	h := fnv.New32a()
 	h.Write([]byte(key))
 	return h.Sum32()
 }
 
+// mix32 is the murmur3 32-bit finalizer, which makes every input bit affect
+// every output bit.
+func mix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
 // SingleShardRouter creates a router with a single shard (no sharding).
 func SingleShardRouter(ctx context.Context, cfg *config.DatabaseConfig) (*ShardRouter, error) {
 	return NewShardRouter(ctx, []ShardConfig{
@@ -146,6 +146,21 @@ func TestShardRouter_GetAllShards(t *testing.T) {
 	assert.Len(t, shards, 1)
 }
 
+func TestShardRouter_Stats(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	ctx := context.Background()
+	cfg := testDBConfig()
+
+	router, err := SingleShardRouter(ctx, cfg)
+	require.NoError(t, err)
+	defer router.Close()
+
+	stats := router.Stats()
+	require.Len(t, stats, 1)
+	assert.GreaterOrEqual(t, stats[0].MaxConns, int32(1))
+}
+
 func TestShardRouter_Close(t *testing.T) {
 	skipIfNoPostgres(t)
 
@@ -261,3 +276,84 @@ func TestShardRouter_ConsistentHashing(t *testing.T) {
 		assert.Equal(t, keyToShard[key], idx, "key %s should consistently route to same shard", key)
 	}
 }
+
+// newRingRouter creates a router over shardCount shards without connecting
+// to them, for checking how keys are routed.
+func newRingRouter(shardCount int, version HashVersion) *ShardRouter {
+	router := &ShardRouter{
+		shardCount:   shardCount,
+		ringToShard:  make(map[uint32]int),
+		virtualNodes: 150,
+		hashVersion:  version,
+	}
+	router.buildRing()
+	return router
+}
+
+func TestShardRouter_HashVersions(t *testing.T) {
+	// HashFNV keeps the placement rows were written with before HashMixed
+	// existed; HashMixed moves most of them
+	fnvRouter, mixedRouter := newRingRouter(4, HashFNV), newRingRouter(4, HashMixed)
+	placement := map[string][2]int{
+		"aZ3kP9q": {3, 1},
+		"hello":   {1, 3},
+		"7Yt2mQw": {0, 2},
+		"000000":  {3, 3},
+		"short1":  {1, 0},
+	}
+	for key, want := range placement {
+		assert.Equal(t, want[0], fnvRouter.GetShardIndex(key), "HashFNV shard of %s", key)
+		assert.Equal(t, want[1], mixedRouter.GetShardIndex(key), "HashMixed shard of %s", key)
+	}
+}
+
+func TestNewShardRouterWithConfig_UnknownHashVersion(t *testing.T) {
+	_, err := NewShardRouterWithConfig(context.Background(), []ShardConfig{{ID: 0}}, ShardRouterConfig{HashVersion: 7})
+	assert.ErrorContains(t, err, "unknown shard hash version 7")
+}
+
+func TestShardRouter_AddShardMovesFewKeys(t *testing.T) {
+	const keys = 20000
+
+	for _, n := range []int{2, 4, 8} {
+		t.Run(fmt.Sprintf("%d to %d shards", n, n+1), func(t *testing.T) {
+			before, after := newRingRouter(n, HashMixed), newRingRouter(n+1, HashMixed)
+
+			ringMoved, moduloMoved := 0, 0
+			for i := 0; i < keys; i++ {
+				key := fmt.Sprintf("code-%d", i)
+				if idx := after.GetShardIndex(key); idx != before.GetShardIndex(key) {
+					ringMoved++
+					assert.Equal(t, n, idx, "key %s moved between existing shards", key)
+				}
+				if hash := hashKey(key); hash%uint32(n) != hash%uint32(n+1) {
+					moduloMoved++
+				}
+			}
+
+			// Ideally 1/(n+1) of the keys move to the new shard, where modulo
+			// routing moves n/(n+1) of them
+			assert.Less(t, float64(ringMoved)/keys, 1.5/float64(n+1))
+			assert.Less(t, ringMoved, moduloMoved/2)
+		})
+	}
+}
+
+func TestShardRouter_Balance(t *testing.T) {
+	const keys = 20000
+
+	for _, n := range []int{2, 3, 5, 8} {
+		t.Run(fmt.Sprintf("%d shards", n), func(t *testing.T) {
+			router := newRingRouter(n, HashMixed)
+			counts := make([]int, n)
+			for i := 0; i < keys; i++ {
+				counts[router.GetShardIndex(fmt.Sprintf("code-%d", i))]++
+			}
+
+			even := keys / n
+			for idx, count := range counts {
+				assert.InDelta(t, even, count, float64(even)*0.25, "shard %d holds %d keys", idx, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// Package geo resolves client IP addresses to countries.
+package geo
+
+import "net"
+
+// UnknownCountry is the country code recorded for clients whose country
+// cannot be resolved, such as private addresses.
+const UnknownCountry = "XX"
+
+// Resolver maps IP addresses to ISO 3166-1 alpha-2 country codes.
+type Resolver interface {
+	// Country returns the country code of ip, or "" when it is not known.
+	Country(ip net.IP) (string, error)
+}
+
+// CountryOf resolves a textual client IP with r, returning UnknownCountry when
+// the IP cannot be parsed or resolved.
+func CountryOf(r Resolver, clientIP string) string {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return UnknownCountry
+	}
+	country, err := r.Country(ip)
+	if err != nil || country == "" {
+		return UnknownCountry
+	}
+	return country
+}
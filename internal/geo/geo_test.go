@@ -0,0 +1,40 @@
+package geo
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubResolver resolves every IP to the same country.
+type stubResolver struct {
+	country string
+	err     error
+}
+
+func (s stubResolver) Country(ip net.IP) (string, error) {
+	return s.country, s.err
+}
+
+func TestCountryOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver Resolver
+		ip       string
+		want     string
+	}{
+		{"resolved", stubResolver{country: "NZ"}, "203.0.113.7", "NZ"},
+		{"not in database", stubResolver{}, "203.0.113.7", UnknownCountry},
+		{"lookup error", stubResolver{err: errors.New("corrupt")}, "203.0.113.7", UnknownCountry},
+		{"unparseable IP", stubResolver{country: "NZ"}, "not-an-ip", UnknownCountry},
+		{"empty IP", stubResolver{country: "NZ"}, "", UnknownCountry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CountryOf(tt.resolver, tt.ip))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ErrInvalidDatabase is returned for files that are not valid MaxMind databases.
+var ErrInvalidDatabase = errors.New("invalid MaxMind database")
+
+// MaxMindResolver implements Resolver using a MaxMind DB file such as
+// GeoLite2-Country or GeoIP2-City. The whole database is held in memory.
+type MaxMindResolver struct {
+	reader *maxminddb.Reader
+
+	countries sync.Map // Data section offset -> country code
+}
+
+// maxMindRecord holds the fields of a MaxMind record that Country reads.
+type maxMindRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// OpenMaxMind reads a MaxMind DB file into memory.
+func OpenMaxMind(path string) (*MaxMindResolver, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+	}
+	return NewMaxMindResolver(buf)
+}
+
+// NewMaxMindResolver creates a MaxMindResolver from the contents of a MaxMind DB file.
+func NewMaxMindResolver(buf []byte) (*MaxMindResolver, error) {
+	reader, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	if v := reader.Metadata.IPVersion; v != 4 && v != 6 {
+		return nil, fmt.Errorf("%w: unsupported IP version %d", ErrInvalidDatabase, v)
+	}
+	if reader.Metadata.NodeCount == 0 {
+		return nil, fmt.Errorf("%w: empty search tree", ErrInvalidDatabase)
+	}
+	return &MaxMindResolver{reader: reader}, nil
+}
+
+// Country returns the ISO country code of ip, falling back to the country
+// the network is registered in. It returns "" for addresses not in the database.
+func (r *MaxMindResolver) Country(ip net.IP) (string, error) {
+	if ip.To4() == nil && (ip.To16() == nil || r.reader.Metadata.IPVersion == 4) {
+		return "", nil
+	}
+
+	offset, err := r.reader.LookupOffset(ip)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	if offset == maxminddb.NotFound {
+		return "", nil
+	}
+	if country, ok := r.countries.Load(offset); ok {
+		return country.(string), nil
+	}
+
+	var record maxMindRecord
+	if err := r.reader.Decode(offset, &record); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	country := record.Country.ISOCode
+	if country == "" {
+		country = record.RegisteredCountry.ISOCode
+	}
+	r.countries.Store(offset, country)
+	return country, nil
+}
@@ -0,0 +1,292 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Constants of the MaxMind DB format needed to build test databases.
+// See https://maxmind.github.io/MaxMind-DB/.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+const dataSectionSeparator = 16
+
+const (
+	typeExtended = 0
+	typeString   = 2
+	typeUint16   = 5
+	typeUint32   = 6
+	typeMap      = 7
+	typeUint64   = 9
+	typeArray    = 11
+)
+
+// testNetwork maps a CIDR to a data record in a test database.
+type testNetwork struct {
+	cidr   string
+	record map[string]interface{}
+}
+
+func countryRecord(key, code string) map[string]interface{} {
+	return map[string]interface{}{key: map[string]interface{}{"iso_code": code}}
+}
+
+var testNetworks = []testNetwork{
+	{"1.2.0.0/16", countryRecord("country", "AU")},
+	{"81.0.0.0/8", countryRecord("country", "FR")},
+	{"100.64.0.0/10", countryRecord("registered_country", "US")},
+	{"2001:db8::/32", countryRecord("country", "DE")},
+}
+
+// buildMMDB writes a MaxMind database holding networks. IPv6 networks are
+// skipped for IPv4 databases, and IPv4 networks are stored under ::/96 in IPv6
+// databases.
+func buildMMDB(t *testing.T, ipVersion, recordSize int, networks []testNetwork) []byte {
+	t.Helper()
+
+	// Search tree nodes; a child is 0 when empty, n > 0 for node n and
+	// -(k+1) for data record k.
+	nodes := [][2]int{{0, 0}}
+	var data []byte
+	var dataOffsets []int
+	for _, n := range networks {
+		_, ipNet, err := net.ParseCIDR(n.cidr)
+		require.NoError(t, err)
+		ones, _ := ipNet.Mask.Size()
+		addr := ipNet.IP
+		if ip4 := addr.To4(); ip4 != nil {
+			addr = ip4
+			if ipVersion == 6 {
+				addr = append(make([]byte, 12), ip4...)
+				ones += 96
+			}
+		} else if ipVersion == 4 {
+			continue
+		}
+
+		dataOffsets = append(dataOffsets, len(data))
+		data = append(data, mmdbEncode(t, n.record)...)
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := addr[i/8] >> (7 - uint(i%8)) & 1
+			if i == ones-1 {
+				nodes[node][bit] = -len(dataOffsets)
+				break
+			}
+			if nodes[node][bit] <= 0 {
+				nodes = append(nodes, [2]int{0, 0})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	nodeCount := len(nodes)
+	var buf []byte
+	for _, n := range nodes {
+		var records [2]uint32
+		for bit, child := range n {
+			switch {
+			case child == 0:
+				records[bit] = uint32(nodeCount)
+			case child > 0:
+				records[bit] = uint32(child)
+			default:
+				records[bit] = uint32(nodeCount + dataSectionSeparator + dataOffsets[-child-1])
+			}
+		}
+		left, right := records[0], records[1]
+		switch recordSize {
+		case 24:
+			buf = append(buf, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			buf = append(buf, byte(left>>16), byte(left>>8), byte(left), byte(left>>24)<<4|byte(right>>24), byte(right>>16), byte(right>>8), byte(right))
+		default:
+			buf = binary.BigEndian.AppendUint32(buf, left)
+			buf = binary.BigEndian.AppendUint32(buf, right)
+		}
+	}
+
+	buf = append(buf, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, data...)
+	buf = append(buf, metadataMarker...)
+	buf = append(buf, mmdbEncode(t, map[string]interface{}{
+		"node_count":    uint32(nodeCount),
+		"record_size":   uint16(recordSize),
+		"ip_version":    uint16(ipVersion),
+		"database_type": "Test-Country",
+		"build_epoch":   uint64(1700000000),
+		"languages":     []interface{}{"en"},
+	})...)
+	return buf
+}
+
+// mmdbEncode encodes a data section field.
+func mmdbEncode(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	uintField := func(typ int, u uint64) []byte {
+		var b []byte
+		for ; u > 0; u >>= 8 {
+			b = append([]byte{byte(u)}, b...)
+		}
+		return append(mmdbControl(typ, len(b)), b...)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return append(mmdbControl(typeString, len(v)), v...)
+	case uint16:
+		return uintField(typeUint16, uint64(v))
+	case uint32:
+		return uintField(typeUint32, uint64(v))
+	case uint64:
+		return uintField(typeUint64, v)
+	case []interface{}:
+		out := mmdbControl(typeArray, len(v))
+		for _, item := range v {
+			out = append(out, mmdbEncode(t, item)...)
+		}
+		return out
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := mmdbControl(typeMap, len(v))
+		for _, k := range keys {
+			out = append(out, mmdbEncode(t, k)...)
+			out = append(out, mmdbEncode(t, v[k])...)
+		}
+		return out
+	default:
+		t.Fatalf("cannot encode %T", v)
+		return nil
+	}
+}
+
+// mmdbControl encodes the control byte of a field with a payload size below 285.
+func mmdbControl(typ, size int) []byte {
+	var ext []byte
+	if typ > 7 {
+		ext = []byte{byte(typ - 7)}
+		typ = typeExtended
+	}
+	if size < 29 {
+		return append([]byte{byte(typ<<5 | size)}, ext...)
+	}
+	return append(append([]byte{byte(typ<<5 | 29)}, ext...), byte(size-29))
+}
+
+func TestMaxMindResolver_Country(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"1.2.3.4", "AU"},
+		{"81.200.1.1", "FR"},
+		{"100.100.0.1", "US"}, // registered_country fallback
+		{"8.8.8.8", ""},
+		{"1.3.0.1", ""},
+	}
+
+	for _, ipVersion := range []int{4, 6} {
+		for _, recordSize := range []int{24, 28, 32} {
+			r, err := NewMaxMindResolver(buildMMDB(t, ipVersion, recordSize, testNetworks))
+			require.NoError(t, err)
+
+			for _, tt := range tests {
+				got, err := r.Country(net.ParseIP(tt.ip))
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got, "IPv%d/%d-bit lookup of %s", ipVersion, recordSize, tt.ip)
+			}
+
+			got, err := r.Country(net.ParseIP("2001:db8::1"))
+			require.NoError(t, err)
+			if ipVersion == 6 {
+				assert.Equal(t, "DE", got)
+			} else {
+				assert.Empty(t, got, "IPv6 lookups miss in IPv4 databases")
+			}
+		}
+	}
+}
+
+func TestMaxMindResolver_CachesRecords(t *testing.T) {
+	r, err := NewMaxMindResolver(buildMMDB(t, 6, 24, testNetworks))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		got, err := r.Country(net.ParseIP("1.2.3.4"))
+		require.NoError(t, err)
+		assert.Equal(t, "AU", got)
+	}
+
+	cached := 0
+	r.countries.Range(func(_, _ any) bool {
+		cached++
+		return true
+	})
+	assert.Equal(t, 1, cached)
+}
+
+func TestNewMaxMindResolver_Invalid(t *testing.T) {
+	t.Run("no metadata", func(t *testing.T) {
+		_, err := NewMaxMindResolver([]byte("not a database"))
+		assert.ErrorIs(t, err, ErrInvalidDatabase)
+	})
+
+	t.Run("truncated tree", func(t *testing.T) {
+		db := buildMMDB(t, 4, 24, testNetworks)
+		_, err := NewMaxMindResolver(db[bytes.LastIndex(db, metadataMarker)-20:])
+		assert.ErrorIs(t, err, ErrInvalidDatabase)
+	})
+
+	t.Run("unsupported IP version", func(t *testing.T) {
+		db := append([]byte{}, make([]byte, 64)...)
+		db = append(db, metadataMarker...)
+		db = append(db, mmdbEncode(t, map[string]interface{}{
+			"node_count":  uint32(1),
+			"record_size": uint16(24),
+			"ip_version":  uint16(5),
+		})...)
+		_, err := NewMaxMindResolver(db)
+		assert.ErrorIs(t, err, ErrInvalidDatabase)
+	})
+
+	t.Run("unsupported record size", func(t *testing.T) {
+		db := append([]byte{}, make([]byte, 64)...)
+		db = append(db, metadataMarker...)
+		db = append(db, mmdbEncode(t, map[string]interface{}{
+			"node_count":  uint32(1),
+			"record_size": uint16(20),
+			"ip_version":  uint16(4),
+		})...)
+		_, err := NewMaxMindResolver(db)
+		assert.ErrorIs(t, err, ErrInvalidDatabase)
+	})
+}
+
+func TestOpenMaxMind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	require.NoError(t, os.WriteFile(path, buildMMDB(t, 6, 28, testNetworks), 0o600))
+
+	r, err := OpenMaxMind(path)
+	require.NoError(t, err)
+	got, err := r.Country(net.ParseIP("81.1.1.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "FR", got)
+
+	_, err = OpenMaxMind(filepath.Join(t.TempDir(), "missing.mmdb"))
+	assert.Error(t, err)
+}
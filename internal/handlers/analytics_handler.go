@@ -1,11 +1,33 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/emadnahed/FastGoLink/internal/models"
 	"github.com/emadnahed/FastGoLink/internal/services"
 )
 
+// BatchStatsItem reports the click statistics of a single short code in a
+// batch analytics request.
+type BatchStatsItem struct {
+	ShortCode string             `json:"short_code"`
+	Status    int                `json:"status"`
+	Stats     *services.URLStats `json:"stats,omitempty"`
+	Error     *ErrorResponse     `json:"error,omitempty"`
+}
+
+// BatchStatsResponse represents the response for a batch analytics request.
+type BatchStatsResponse struct {
+	Results   []BatchStatsItem `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
 // AnalyticsHandler handles analytics-related HTTP requests.
 type AnalyticsHandler struct {
 	service services.AnalyticsService
@@ -19,7 +41,7 @@ func NewAnalyticsHandler(svc services.AnalyticsService) *AnalyticsHandler {
 // GetStats handles GET /api/v1/analytics/:code requests.
 func (h *AnalyticsHandler) GetStats(w http.ResponseWriter, r *http.Request, shortCode string) {
 	if shortCode == "" {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
 			Error: "short code is required",
 			Code:  "INVALID_SHORT_CODE",
 		})
@@ -28,7 +50,7 @@ func (h *AnalyticsHandler) GetStats(w http.ResponseWriter, r *http.Request, shor
 
 	stats, err := h.service.GetURLStats(r.Context(), shortCode)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, ErrorResponse{
+		writeError(w, r, http.StatusNotFound, ErrorResponse{
 			Error: "URL not found",
 			Code:  "NOT_FOUND",
 		})
@@ -37,3 +59,217 @@ func (h *AnalyticsHandler) GetStats(w http.ResponseWriter, r *http.Request, shor
 
 	writeJSON(w, http.StatusOK, stats)
 }
+
+// GetStatsBatch handles POST /api/v1/analytics/batch requests. The body is
+// a JSON array of short codes; results are index-aligned with it, and codes
+// that do not exist are reported with status 404. The response is 207 when
+// any code was not found.
+func (h *AnalyticsHandler) GetStatsBatch(w http.ResponseWriter, r *http.Request) {
+	var shortCodes []string
+	if err := json.NewDecoder(r.Body).Decode(&shortCodes); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if len(shortCodes) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "batch must contain at least one short code",
+			Code:  "EMPTY_BATCH",
+		})
+		return
+	}
+	if len(shortCodes) > MaxBatchSize {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("batch exceeds maximum size of %d", MaxBatchSize),
+			Code:  "BATCH_TOO_LARGE",
+		})
+		return
+	}
+
+	// Only distinct, non-empty codes are looked up
+	seen := make(map[string]bool, len(shortCodes))
+	codes := make([]string, 0, len(shortCodes))
+	for _, shortCode := range shortCodes {
+		if shortCode != "" && !seen[shortCode] {
+			seen[shortCode] = true
+			codes = append(codes, shortCode)
+		}
+	}
+
+	var stats map[string]*services.URLStats
+	if len(codes) > 0 {
+		var err error
+		stats, err = h.service.GetURLStatsBatch(r.Context(), codes)
+		if err != nil {
+			status, errResp := mapErrorToResponse(err)
+			writeError(w, r, status, errResp)
+			return
+		}
+	}
+
+	batchResp := BatchStatsResponse{Results: make([]BatchStatsItem, len(shortCodes))}
+	for i, shortCode := range shortCodes {
+		item := BatchStatsItem{ShortCode: shortCode, Status: http.StatusOK}
+		switch urlStats, ok := stats[shortCode]; {
+		case shortCode == "":
+			item.Status = http.StatusBadRequest
+			item.Error = &ErrorResponse{Error: "short code is required", Code: "INVALID_SHORT_CODE"}
+		case !ok:
+			item.Status = http.StatusNotFound
+			item.Error = &ErrorResponse{Error: "URL not found", Code: "NOT_FOUND"}
+		default:
+			item.Stats = urlStats
+		}
+
+		if item.Error != nil {
+			batchResp.Failed++
+		} else {
+			batchResp.Succeeded++
+		}
+		batchResp.Results[i] = item
+	}
+
+	status := http.StatusOK
+	if batchResp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	writeJSON(w, status, batchResp)
+}
+
+// GetTimeSeries handles GET /api/v1/analytics/:code/timeseries requests.
+// Supports ?from= and ?to= (RFC 3339) and ?interval= (hour, day; default day).
+func (h *AnalyticsHandler) GetTimeSeries(w http.ResponseWriter, r *http.Request, shortCode string) {
+	query := r.URL.Query()
+
+	interval := models.IntervalDay
+	if v := query.Get("interval"); v != "" {
+		interval = models.Interval(strings.ToLower(v))
+	}
+
+	var from, to time.Time
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"from", &from},
+		{"to", &to},
+	} {
+		v := query.Get(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: param.name + " must be an RFC 3339 timestamp",
+				Code:  "INVALID_TIME_RANGE",
+			})
+			return
+		}
+		*param.dst = t
+	}
+
+	points, err := h.service.GetTimeSeries(r.Context(), shortCode, from, to, interval)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, points)
+}
+
+// GetReferrers handles GET /api/v1/analytics/:code/referrers requests.
+// Supports ?limit= (default 20, max 100).
+func (h *AnalyticsHandler) GetReferrers(w http.ResponseWriter, r *http.Request, shortCode string) {
+	limit, ok := parseLimit(w, r, services.DefaultListLimit)
+	if !ok {
+		return
+	}
+
+	referrers, err := h.service.GetReferrers(r.Context(), shortCode, limit)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, referrers)
+}
+
+// GetAgents handles GET /api/v1/analytics/:code/agents requests.
+// Supports ?limit= (default 20, max 100).
+func (h *AnalyticsHandler) GetAgents(w http.ResponseWriter, r *http.Request, shortCode string) {
+	limit, ok := parseLimit(w, r, services.DefaultListLimit)
+	if !ok {
+		return
+	}
+
+	agents, err := h.service.GetAgents(r.Context(), shortCode, limit)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, agents)
+}
+
+// parseLimit reads the ?limit= parameter, defaulting to def. It writes a 400
+// response and returns false when the parameter is not a number.
+func parseLimit(w http.ResponseWriter, r *http.Request, def int) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: services.ErrInvalidPagination.Error(),
+			Code:  "INVALID_PAGINATION",
+		})
+		return 0, false
+	}
+	return n, true
+}
+
+// GetCountries handles GET /api/v1/analytics/:code/geo requests.
+// Supports ?limit= (default 20, max 100).
+func (h *AnalyticsHandler) GetCountries(w http.ResponseWriter, r *http.Request, shortCode string) {
+	limit, ok := parseLimit(w, r, services.DefaultListLimit)
+	if !ok {
+		return
+	}
+
+	countries, err := h.service.GetCountries(r.Context(), shortCode, limit)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, countries)
+}
+
+// GetTopURLs handles GET /api/v1/analytics/top requests.
+// Supports ?limit= (default 10, max 100) and ?by=clicks|created (default clicks).
+func (h *AnalyticsHandler) GetTopURLs(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r, services.DefaultTopLimit)
+	if !ok {
+		return
+	}
+	by := services.TopOrder(r.URL.Query().Get("by"))
+	if by == "" {
+		by = services.TopOrderClicks
+	}
+
+	top, err := h.service.GetTopURLs(r.Context(), limit, by)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, top)
+}
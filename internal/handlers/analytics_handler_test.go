@@ -6,18 +6,40 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/emadnahed/FastGoLink/internal/models"
 	"github.com/emadnahed/FastGoLink/internal/services"
 )
 
 // mockAnalyticsService implements services.AnalyticsService for testing.
 type mockAnalyticsService struct {
-	stats *services.URLStats
-	err   error
+	stats     *services.URLStats
+	batch     map[string]*services.URLStats
+	points    []models.ClickBucket
+	referrers []models.ReferrerCount
+	agents    []models.AgentCount
+	countries []models.CountryCount
+	top       []services.TopURL
+	err       error
+
+	// Arguments of the last GetTimeSeries call
+	from, to time.Time
+	interval models.Interval
+
+	// Limit of the last GetReferrers, GetAgents, GetCountries or GetTopURLs call
+	limit int
+
+	// Order of the last GetTopURLs call
+	by services.TopOrder
+
+	// Codes of the last GetURLStatsBatch call
+	codes []string
 }
 
 func (m *mockAnalyticsService) GetURLStats(ctx context.Context, shortCode string) (*services.URLStats, error) {
@@ -27,6 +49,54 @@ func (m *mockAnalyticsService) GetURLStats(ctx context.Context, shortCode string
 	return m.stats, nil
 }
 
+func (m *mockAnalyticsService) GetURLStatsBatch(ctx context.Context, shortCodes []string) (map[string]*services.URLStats, error) {
+	m.codes = shortCodes
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.batch, nil
+}
+
+func (m *mockAnalyticsService) GetTimeSeries(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) ([]models.ClickBucket, error) {
+	m.from, m.to, m.interval = from, to, interval
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.points, nil
+}
+
+func (m *mockAnalyticsService) GetReferrers(ctx context.Context, shortCode string, limit int) ([]models.ReferrerCount, error) {
+	m.limit = limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.referrers, nil
+}
+
+func (m *mockAnalyticsService) GetAgents(ctx context.Context, shortCode string, limit int) ([]models.AgentCount, error) {
+	m.limit = limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.agents, nil
+}
+
+func (m *mockAnalyticsService) GetCountries(ctx context.Context, shortCode string, limit int) ([]models.CountryCount, error) {
+	m.limit = limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.countries, nil
+}
+
+func (m *mockAnalyticsService) GetTopURLs(ctx context.Context, limit int, by services.TopOrder) ([]services.TopURL, error) {
+	m.limit, m.by = limit, by
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.top, nil
+}
+
 func TestNewAnalyticsHandler(t *testing.T) {
 	svc := &mockAnalyticsService{}
 	handler := NewAnalyticsHandler(svc)
@@ -97,3 +167,321 @@ func TestAnalyticsHandler_GetStats(t *testing.T) {
 		assert.Equal(t, "NOT_FOUND", errResp.Code)
 	})
 }
+
+func TestAnalyticsHandler_GetStatsBatch(t *testing.T) {
+	post := func(handler *AnalyticsHandler, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/analytics/batch", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.GetStatsBatch(rec, req)
+		return rec
+	}
+
+	t.Run("mixes existing and missing codes", func(t *testing.T) {
+		svc := &mockAnalyticsService{
+			batch: map[string]*services.URLStats{
+				"abc123": {ShortCode: "abc123", ClickCount: 42, PendingCount: 3},
+				"xyz789": {ShortCode: "xyz789", ClickCount: 7},
+			},
+		}
+
+		rec := post(NewAnalyticsHandler(svc), `["abc123", "missing", "xyz789", "", "abc123"]`)
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		assert.Equal(t, []string{"abc123", "missing", "xyz789"}, svc.codes, "only distinct, non-empty codes are looked up")
+
+		var resp BatchStatsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, 3, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+		require.Len(t, resp.Results, 5)
+
+		assert.Equal(t, http.StatusOK, resp.Results[0].Status)
+		require.NotNil(t, resp.Results[0].Stats)
+		assert.Equal(t, int64(42), resp.Results[0].Stats.ClickCount)
+		assert.Equal(t, int64(3), resp.Results[0].Stats.PendingCount)
+
+		assert.Equal(t, "missing", resp.Results[1].ShortCode)
+		assert.Equal(t, http.StatusNotFound, resp.Results[1].Status)
+		assert.Nil(t, resp.Results[1].Stats)
+		require.NotNil(t, resp.Results[1].Error)
+		assert.Equal(t, "NOT_FOUND", resp.Results[1].Error.Code)
+
+		assert.Equal(t, int64(7), resp.Results[2].Stats.ClickCount)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Results[3].Status)
+		assert.Equal(t, "INVALID_SHORT_CODE", resp.Results[3].Error.Code)
+
+		assert.Equal(t, int64(42), resp.Results[4].Stats.ClickCount)
+	})
+
+	t.Run("returns 200 when every code exists", func(t *testing.T) {
+		svc := &mockAnalyticsService{
+			batch: map[string]*services.URLStats{"abc123": {ShortCode: "abc123", ClickCount: 1}},
+		}
+
+		rec := post(NewAnalyticsHandler(svc), `["abc123"]`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("rejects invalid batches", func(t *testing.T) {
+		for body, code := range map[string]string{
+			`[]`:       "EMPTY_BATCH",
+			`{"a": 1}`: "INVALID_REQUEST",
+			`[` + strings.Repeat(`"abc",`, MaxBatchSize) + `"abc"]`: "BATCH_TOO_LARGE",
+		} {
+			rec := post(NewAnalyticsHandler(&mockAnalyticsService{}), body)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var errResp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+			assert.Equal(t, code, errResp.Code)
+		}
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		rec := post(NewAnalyticsHandler(&mockAnalyticsService{err: errors.New("db down")}), `["abc123"]`)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestAnalyticsHandler_GetTimeSeries(t *testing.T) {
+	midnight := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns array of points", func(t *testing.T) {
+		svc := &mockAnalyticsService{
+			points: []models.ClickBucket{
+				{Timestamp: midnight, Count: 3},
+				{Timestamp: midnight.Add(time.Hour), Count: 0},
+			},
+		}
+		handler := NewAnalyticsHandler(svc)
+
+		req := httptest.NewRequest(http.MethodGet,
+			"/api/v1/analytics/abc123/timeseries?from=2024-03-10T00:00:00Z&to=2024-03-10T02:00:00Z&interval=HOUR", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetTimeSeries(rec, req, "abc123")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[
+			{"timestamp":"2024-03-10T00:00:00Z","count":3},
+			{"timestamp":"2024-03-10T01:00:00Z","count":0}
+		]`, rec.Body.String())
+		assert.Equal(t, midnight, svc.from)
+		assert.Equal(t, midnight.Add(2*time.Hour), svc.to)
+		assert.Equal(t, models.IntervalHour, svc.interval)
+	})
+
+	t.Run("defaults to daily interval and open range", func(t *testing.T) {
+		svc := &mockAnalyticsService{points: []models.ClickBucket{}}
+		handler := NewAnalyticsHandler(svc)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/timeseries", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetTimeSeries(rec, req, "abc123")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+		assert.Equal(t, models.IntervalDay, svc.interval)
+		assert.True(t, svc.from.IsZero())
+		assert.True(t, svc.to.IsZero())
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"malformed from", "?from=yesterday", nil, http.StatusBadRequest, "INVALID_TIME_RANGE"},
+		{"malformed to", "?to=2024-03-10", nil, http.StatusBadRequest, "INVALID_TIME_RANGE"},
+		{"invalid interval", "?interval=week", services.ErrInvalidInterval, http.StatusBadRequest, "INVALID_INTERVAL"},
+		{"invalid range", "", services.ErrInvalidTimeRange, http.StatusBadRequest, "INVALID_TIME_RANGE"},
+		{"unknown URL", "", models.ErrURLNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"not configured", "", services.ErrTimeSeriesUnavailable, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAnalyticsHandler(&mockAnalyticsService{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/timeseries"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.GetTimeSeries(rec, req, "abc123")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			var errResp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+			assert.Equal(t, tt.wantCode, errResp.Code)
+		})
+	}
+}
+
+func TestAnalyticsHandler_GetReferrers(t *testing.T) {
+	t.Run("returns array of referrers", func(t *testing.T) {
+		svc := &mockAnalyticsService{
+			referrers: []models.ReferrerCount{{Referrer: "twitter.com", Count: 7}, {Referrer: "direct", Count: 2}},
+		}
+		handler := NewAnalyticsHandler(svc)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/referrers?limit=5", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetReferrers(rec, req, "abc123")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[{"referrer":"twitter.com","count":7},{"referrer":"direct","count":2}]`, rec.Body.String())
+		assert.Equal(t, 5, svc.limit)
+	})
+
+	t.Run("defaults limit", func(t *testing.T) {
+		svc := &mockAnalyticsService{referrers: []models.ReferrerCount{}}
+		handler := NewAnalyticsHandler(svc)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/referrers", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetReferrers(rec, req, "abc123")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, services.DefaultListLimit, svc.limit)
+	})
+}
+
+func TestAnalyticsHandler_GetAgents(t *testing.T) {
+	svc := &mockAnalyticsService{
+		agents: []models.AgentCount{{Browser: "Chrome", OS: "Windows", Count: 4}},
+	}
+	handler := NewAnalyticsHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/agents", nil)
+	rec := httptest.NewRecorder()
+
+	handler.GetAgents(rec, req, "abc123")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"browser":"Chrome","os":"Windows","count":4}]`, rec.Body.String())
+}
+
+func TestAnalyticsHandler_GetCountries(t *testing.T) {
+	svc := &mockAnalyticsService{
+		countries: []models.CountryCount{{Country: "DE", Count: 5}, {Country: "XX", Count: 1}},
+	}
+	handler := NewAnalyticsHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/geo?limit=2", nil)
+	rec := httptest.NewRecorder()
+
+	handler.GetCountries(rec, req, "abc123")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"country":"DE","count":5},{"country":"XX","count":1}]`, rec.Body.String())
+	assert.Equal(t, 2, svc.limit)
+}
+
+func TestAnalyticsHandler_ClickSourceErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"malformed limit", "?limit=ten", nil, http.StatusBadRequest, "INVALID_PAGINATION"},
+		{"limit out of range", "?limit=0", services.ErrInvalidPagination, http.StatusBadRequest, "INVALID_PAGINATION"},
+		{"unknown URL", "", models.ErrURLNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"not configured", "", services.ErrSourcesUnavailable, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		for _, endpoint := range []string{"referrers", "agents", "geo"} {
+			t.Run(endpoint+"/"+tt.name, func(t *testing.T) {
+				handler := NewAnalyticsHandler(&mockAnalyticsService{err: tt.err})
+
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/abc123/"+endpoint+tt.query, nil)
+				rec := httptest.NewRecorder()
+
+				switch endpoint {
+				case "referrers":
+					handler.GetReferrers(rec, req, "abc123")
+				case "agents":
+					handler.GetAgents(rec, req, "abc123")
+				default:
+					handler.GetCountries(rec, req, "abc123")
+				}
+
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				var errResp ErrorResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+				assert.Equal(t, tt.wantCode, errResp.Code)
+			})
+		}
+	}
+}
+
+func TestAnalyticsHandler_GetTopURLs(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("defaults to top 10 by clicks", func(t *testing.T) {
+		svc := &mockAnalyticsService{top: []services.TopURL{
+			{ShortCode: "abc123", OriginalURL: "https://example.com", ClickCount: 7, PendingCount: 2, CreatedAt: created},
+		}}
+		handler := NewAnalyticsHandler(svc)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/top", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetTopURLs(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[{"short_code":"abc123","original_url":"https://example.com","click_count":7,"pending_count":2,"created_at":"2024-01-01T00:00:00Z"}]`, rec.Body.String())
+		assert.Equal(t, services.DefaultTopLimit, svc.limit)
+		assert.Equal(t, services.TopOrderClicks, svc.by)
+	})
+
+	t.Run("passes limit and order", func(t *testing.T) {
+		svc := &mockAnalyticsService{top: []services.TopURL{}}
+		handler := NewAnalyticsHandler(svc)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/top?limit=3&by=created", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetTopURLs(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+		assert.Equal(t, 3, svc.limit)
+		assert.Equal(t, services.TopOrderCreated, svc.by)
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		wantCode string
+	}{
+		{"non-numeric limit", "?limit=ten", nil, "INVALID_PAGINATION"},
+		{"out-of-range limit", "?limit=500", services.ErrInvalidPagination, "INVALID_PAGINATION"},
+		{"unknown order", "?by=views", services.ErrInvalidTopOrder, "INVALID_SORT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAnalyticsHandler(&mockAnalyticsService{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/top"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.GetTopURLs(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var errResp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+			assert.Equal(t, tt.wantCode, errResp.Code)
+		})
+	}
+}
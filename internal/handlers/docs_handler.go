@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"bytes"
 	"embed"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/emadnahed/FastGoLink/pkg/logger"
 )
@@ -55,7 +58,7 @@ func (h *DocsHandler) ScalarUI(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(html)
+	w.Write(h.prefixSpecURL(html))
 }
 
 // OpenAPISpec serves the OpenAPI specification YAML file.
@@ -67,7 +70,7 @@ func (h *DocsHandler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
 	// If we have embedded content, use it
 	if len(h.specContent) > 0 {
 		w.WriteHeader(http.StatusOK)
-		w.Write(h.specContent)
+		w.Write(h.rewriteServers(h.specContent))
 		return
 	}
 
@@ -101,7 +104,7 @@ func (h *DocsHandler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(http.StatusOK)
-	w.Write(content)
+	w.Write(h.rewriteServers(content))
 }
 
 // Redoc serves the ReDoc API documentation UI as an alternative.
@@ -117,7 +120,7 @@ func (h *DocsHandler) Redoc(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(html)
+	w.Write(h.prefixSpecURL(html))
 }
 
 // SwaggerUI serves the Swagger UI as another alternative.
@@ -133,5 +136,52 @@ func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(html)
+	w.Write(h.prefixSpecURL(html))
+}
+
+// basePath returns the path component of the base URL, which is where the
+// routes are mounted when the server runs under a base path.
+func (h *DocsHandler) basePath() string {
+	u, err := url.Parse(h.baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// prefixSpecURL points a UI template at the spec under the base path.
+func (h *DocsHandler) prefixSpecURL(html []byte) []byte {
+	prefix := h.basePath()
+	if prefix == "" {
+		return html
+	}
+	return bytes.ReplaceAll(html, []byte("/docs/openapi.yaml"), []byte(prefix+"/docs/openapi.yaml"))
+}
+
+// rewriteServers replaces the spec's top-level servers list with the base
+// URL when running under a base path, so "try it" requests from the docs UIs
+// include the prefix. Without a base path the spec is served unchanged.
+func (h *DocsHandler) rewriteServers(spec []byte) []byte {
+	if h.basePath() == "" {
+		return spec
+	}
+
+	lines := strings.Split(string(spec), "\n")
+	out := make([]string, 0, len(lines))
+	inServers := false
+	for _, line := range lines {
+		if inServers {
+			if line == "" || line[0] == ' ' || line[0] == '-' {
+				continue
+			}
+			inServers = false
+		}
+		if strings.TrimRight(line, " ") == "servers:" {
+			inServers = true
+			out = append(out, line, "  - url: "+h.baseURL, "")
+			continue
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
 }
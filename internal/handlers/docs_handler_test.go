@@ -128,6 +128,44 @@ func TestDocsHandler_OpenAPISpec_FileNotFound(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), "OpenAPI specification not found")
 }
 
+func TestDocsHandler_BasePath(t *testing.T) {
+	spec := []byte(`openapi: "3.0.0"
+servers:
+  - url: http://localhost:8080
+    description: Local development server
+
+paths: {}`)
+	handler := NewDocsHandlerWithSpec("https://example.com/shortener", spec, nil)
+
+	t.Run("UIs load the prefixed spec", func(t *testing.T) {
+		for _, serve := range []http.HandlerFunc{handler.ScalarUI, handler.Redoc, handler.SwaggerUI} {
+			rr := httptest.NewRecorder()
+			serve(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Body.String(), "/shortener/docs/openapi.yaml")
+		}
+	})
+
+	t.Run("spec servers point at the base URL", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.OpenAPISpec(rr, httptest.NewRequest(http.MethodGet, "/docs/openapi.yaml", nil))
+
+		body := rr.Body.String()
+		assert.Contains(t, body, "servers:\n  - url: https://example.com/shortener\n")
+		assert.NotContains(t, body, "localhost:8080")
+		assert.Contains(t, body, "paths: {}")
+	})
+
+	t.Run("no base path leaves spec untouched", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		NewDocsHandlerWithSpec("https://example.com", spec, nil).
+			OpenAPISpec(rr, httptest.NewRequest(http.MethodGet, "/docs/openapi.yaml", nil))
+
+		assert.Equal(t, string(spec), rr.Body.String())
+	})
+}
+
 func TestNewDocsHandler_DefaultPath(t *testing.T) {
 	handler := NewDocsHandler("http://localhost:8080", "", nil)
 	assert.Equal(t, "docs/openapi.yaml", handler.specPath)
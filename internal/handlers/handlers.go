@@ -1,2 +1,6 @@
 // Package handlers contains HTTP request handlers.
 package handlers
+
+import "go.opentelemetry.io/otel"
+
+var tracer = otel.Tracer("github.com/emadnahed/FastGoLink/internal/handlers")
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/middleware"
 )
 
 // HealthResponse represents the response for the health endpoint.
@@ -15,31 +19,48 @@ type HealthResponse struct {
 
 // ReadyResponse represents the response for the ready endpoint.
 type ReadyResponse struct {
-	Status    string            `json:"status"`
-	Timestamp string            `json:"timestamp"`
-	Checks    map[string]string `json:"checks,omitempty"`
+	Status    string                 `json:"status"`
+	Timestamp string                 `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult reports the outcome of a single dependency check.
+type CheckResult struct {
+	Status    string  `json:"status"`          // "ok", "fail", or "degraded" for a failed optional check
+	LatencyMS float64 `json:"latency_ms"`      // How long the check took, capped at the timeout
+	Error     string  `json:"error,omitempty"` // Why the check failed
 }
 
-// CheckFunc is a function that checks if a dependency is ready.
-type CheckFunc func() bool
+// CheckFunc checks whether a dependency is ready. It should give up once
+// ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// DefaultCheckTimeout bounds how long a single readiness check may run.
+const DefaultCheckTimeout = 2 * time.Second
 
 // HealthHandler handles health check endpoints.
 type HealthHandler struct {
-	ready  bool
-	checks map[string]CheckFunc
-	mu     sync.RWMutex
+	ready        bool
+	checks       map[string]CheckFunc
+	optional     map[string]bool // Checks whose failure does not make the service unready
+	checkTimeout time.Duration
+	mu           sync.RWMutex
 }
 
 // NewHealthHandler creates a new HealthHandler.
 func NewHealthHandler() *HealthHandler {
 	return &HealthHandler{
-		ready:  true,
-		checks: make(map[string]CheckFunc),
+		ready:        true,
+		checks:       make(map[string]CheckFunc),
+		optional:     make(map[string]bool),
+		checkTimeout: DefaultCheckTimeout,
 	}
 }
 
 // Health handles the /health endpoint.
-// This endpoint indicates if the service is running.
+// This endpoint indicates if the service is running. It never runs the
+// dependency checks, so it stays cheap and a slow dependency cannot make
+// the process look dead.
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 	response := HealthResponse{
 		Status:    "healthy",
@@ -51,21 +72,47 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 
 // Ready handles the /ready endpoint.
 // This endpoint indicates if the service is ready to accept traffic.
+// Checks run concurrently, each bounded by the check timeout, so one hung
+// dependency is reported as failed without delaying the others.
 func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	checks := make(map[string]string)
 	allReady := h.ready
-
-	// Run all registered checks
+	timeout := h.checkTimeout
+	checks := make(map[string]CheckFunc, len(h.checks))
 	for name, check := range h.checks {
-		if check() {
-			checks[name] = "ok"
-		} else {
-			checks[name] = "fail"
-			allReady = false
+		checks[name] = check
+	}
+	optional := make(map[string]bool, len(h.optional))
+	for name := range h.optional {
+		optional[name] = true
+	}
+	h.mu.RUnlock()
+
+	results := make(map[string]CheckResult, len(checks))
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runCheck(r.Context(), check, timeout)
+			resultsMu.Lock()
+			results[name] = result
+			resultsMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for name, result := range results {
+		if result.Status == "ok" {
+			continue
+		}
+		if optional[name] {
+			result.Status = "degraded"
+			results[name] = result
+			continue
 		}
+		allReady = false
 	}
 
 	status := "ready"
@@ -81,13 +128,41 @@ func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 
-	if len(checks) > 0 {
-		response.Checks = checks
+	if len(results) > 0 {
+		response.Checks = results
 	}
 
 	writeJSON(w, statusCode, response)
 }
 
+// runCheck runs check with a timeout. A check that ignores its context is
+// abandoned when the timeout passes; it finishes in the background.
+func runCheck(ctx context.Context, check CheckFunc, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	result := CheckResult{
+		Status:    "ok",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
 // SetReady sets the ready state.
 func (h *HealthHandler) SetReady(ready bool) {
 	h.mu.Lock()
@@ -102,11 +177,29 @@ func (h *HealthHandler) IsReady() bool {
 	return h.ready
 }
 
+// SetCheckTimeout sets how long each readiness check may run.
+func (h *HealthHandler) SetCheckTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkTimeout = timeout
+}
+
 // AddCheck adds a dependency check.
 func (h *HealthHandler) AddCheck(name string, check CheckFunc) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.checks[name] = check
+	delete(h.optional, name)
+}
+
+// AddOptionalCheck adds a check for a dependency the service can run
+// without. Its result is reported, as "degraded" when it fails, but it
+// never makes the service unready.
+func (h *HealthHandler) AddOptionalCheck(name string, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+	h.optional[name] = true
 }
 
 // writeJSON writes a JSON response.
@@ -115,3 +208,9 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(data)
 }
+
+// writeError writes errResp with the given status in the error format
+// selected for the request.
+func writeError(w http.ResponseWriter, r *http.Request, status int, errResp ErrorResponse) {
+	middleware.WriteError(w, r, status, errResp.Error, errResp.Code)
+}
@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -100,7 +105,7 @@ func TestReadyResponse_WithChecks(t *testing.T) {
 	handler := NewHealthHandler()
 
 	// Add a dependency check
-	handler.AddCheck("database", func() bool { return true })
+	handler.AddCheck("database", func(context.Context) error { return nil })
 
 	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
 	rec := httptest.NewRecorder()
@@ -114,15 +119,16 @@ func TestReadyResponse_WithChecks(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, "ready", response.Status)
-	assert.Contains(t, response.Checks, "database")
-	assert.Equal(t, "ok", response.Checks["database"])
+	require.Contains(t, response.Checks, "database")
+	assert.Equal(t, "ok", response.Checks["database"].Status)
+	assert.Empty(t, response.Checks["database"].Error)
 }
 
 func TestReadyResponse_WithFailingCheck(t *testing.T) {
 	handler := NewHealthHandler()
 
 	// Add a failing dependency check
-	handler.AddCheck("database", func() bool { return false })
+	handler.AddCheck("database", func(context.Context) error { return errors.New("connection refused") })
 
 	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
 	rec := httptest.NewRecorder()
@@ -136,6 +142,129 @@ func TestReadyResponse_WithFailingCheck(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, "not ready", response.Status)
-	assert.Contains(t, response.Checks, "database")
-	assert.Equal(t, "fail", response.Checks["database"])
+	require.Contains(t, response.Checks, "database")
+	assert.Equal(t, "fail", response.Checks["database"].Status)
+	assert.Equal(t, "connection refused", response.Checks["database"].Error)
+}
+
+func TestReadyResponse_OutdatedSchema(t *testing.T) {
+	handler := NewHealthHandler()
+	version := 17
+	handler.AddCheck("migrations", database.SchemaVersionCheck(18, func(context.Context) (int, error) {
+		return version, nil
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.Ready(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var response ReadyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "fail", response.Checks["migrations"].Status)
+	assert.Equal(t, "shard 0: schema is at version 17, expected 18", response.Checks["migrations"].Error)
+
+	// Ready once the migration has been applied
+	version = 18
+	rec = httptest.NewRecorder()
+	handler.Ready(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestReadyResponse_SlowCheckTimesOut(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.SetCheckTimeout(50 * time.Millisecond)
+
+	// A check that ignores its context and hangs
+	release := make(chan struct{})
+	defer close(release)
+	handler.AddCheck("hung", func(context.Context) error {
+		<-release
+		return nil
+	})
+	handler.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	handler.AddCheck("database", func(context.Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.Ready(rec, req)
+	elapsed := time.Since(start)
+
+	// Checks run concurrently, so the endpoint waits about one timeout
+	assert.Less(t, elapsed, time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var response ReadyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	assert.Equal(t, "not ready", response.Status)
+	assert.Equal(t, "fail", response.Checks["hung"].Status)
+	assert.Equal(t, "timed out after 50ms", response.Checks["hung"].Error)
+	assert.Equal(t, "fail", response.Checks["slow"].Status)
+	assert.GreaterOrEqual(t, response.Checks["hung"].LatencyMS, float64(50))
+	assert.Equal(t, "ok", response.Checks["database"].Status)
+	assert.Less(t, response.Checks["database"].LatencyMS, float64(50))
+}
+
+func TestReadyResponse_ChecksRunConcurrently(t *testing.T) {
+	handler := NewHealthHandler()
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		handler.AddCheck(name, func(context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.Ready(rec, req)
+
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHealthHandler_DoesNotRunChecks(t *testing.T) {
+	handler := NewHealthHandler()
+	called := false
+	handler.AddCheck("database", func(context.Context) error {
+		called = true
+		return errors.New("down")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+
+	handler.Health(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, called)
+}
+
+func TestReadyResponse_OptionalCheckFailing(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.AddCheck("database", func(context.Context) error { return nil })
+	handler.AddOptionalCheck("redis", func(context.Context) error { return errors.New("redis unreachable") })
+
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+	rec := httptest.NewRecorder()
+
+	handler.Ready(rec, req)
+
+	// A failing optional dependency is reported without failing readiness
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response ReadyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	assert.Equal(t, "ready", response.Status)
+	assert.Equal(t, "ok", response.Checks["database"].Status)
+	assert.Equal(t, "degraded", response.Checks["redis"].Status)
+	assert.Equal(t, "redis unreachable", response.Checks["redis"].Error)
 }
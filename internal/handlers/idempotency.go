@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/middleware"
+)
+
+// IdempotencyKeyHeader is the request header carrying an idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed for a repeated key.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// MaxIdempotencyKeyLength is the longest accepted idempotency key.
+const MaxIdempotencyKeyLength = 255
+
+// idempotencyLockTTL bounds how long a request in progress holds its key, so
+// a crash mid-request does not block the key for the whole TTL.
+const idempotencyLockTTL = time.Minute
+
+// IdempotencyCache is the storage used by IdempotencyStore. cache.Cache
+// satisfies it.
+type IdempotencyCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// idempotencyRecord is the cached state of an idempotency key.
+type idempotencyRecord struct {
+	Pending     bool   `json:"pending,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyStore remembers the responses of requests sent with an
+// Idempotency-Key header so that retries get the original response back.
+//
+// Keys are scoped to the caller's API key. The first request with a key
+// claims it atomically with SetNX; duplicates that arrive while it is in
+// progress get 409, and a key reused with a different body gets 422. Only
+// successful responses are remembered, so a failed request can be retried
+// with the same key. When the cache fails, requests are processed as if
+// they had no key.
+type IdempotencyStore struct {
+	cache  IdempotencyCache
+	prefix string
+	ttl    time.Duration
+}
+
+// NewIdempotencyStore creates an IdempotencyStore that remembers responses
+// in c for ttl, under keys starting with prefix.
+func NewIdempotencyStore(c IdempotencyCache, prefix string, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{cache: c, prefix: prefix, ttl: ttl}
+}
+
+// Serve writes the response for a request carrying idempotency key with the
+// given body, calling handle only if no response is remembered for the key.
+func (s *IdempotencyStore) Serve(w http.ResponseWriter, r *http.Request, key string, body []byte, handle func(http.ResponseWriter)) {
+	if len(key) > MaxIdempotencyKeyLength {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "Idempotency-Key must be at most 255 characters",
+			Code:  "INVALID_IDEMPOTENCY_KEY",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	cacheKey := s.prefix + idempotencyCacheKey(middleware.GetAPIKey(ctx), key)
+	fingerprint := sha256.Sum256(body)
+	record := idempotencyRecord{Pending: true, Fingerprint: hex.EncodeToString(fingerprint[:])}
+
+	// A claimed key may expire between SetNX and Get, so try once more
+	for range 2 {
+		pending, _ := json.Marshal(record)
+		claimed, err := s.cache.SetNX(ctx, cacheKey, pending, idempotencyLockTTL)
+		if err != nil {
+			handle(w)
+			return
+		}
+		if claimed {
+			s.handleClaimed(w, r, cacheKey, record, handle)
+			return
+		}
+
+		data, err := s.cache.Get(ctx, cacheKey)
+		if err != nil {
+			continue
+		}
+		var existing idempotencyRecord
+		if err := json.Unmarshal(data, &existing); err != nil {
+			break
+		}
+		switch {
+		case existing.Fingerprint != record.Fingerprint:
+			writeError(w, r, http.StatusUnprocessableEntity, ErrorResponse{
+				Error: "Idempotency-Key was already used with a different request body",
+				Code:  "IDEMPOTENCY_KEY_REUSED",
+			})
+		case existing.Pending:
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, http.StatusConflict, ErrorResponse{
+				Error: "a request with this Idempotency-Key is still in progress",
+				Code:  "IDEMPOTENCY_KEY_IN_USE",
+			})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(existing.Status)
+			_, _ = w.Write(existing.Body)
+		}
+		return
+	}
+
+	handle(w)
+}
+
+// handleClaimed runs handle for a key this request claimed, then remembers
+// a successful response or releases the key.
+func (s *IdempotencyStore) handleClaimed(w http.ResponseWriter, r *http.Request, cacheKey string, record idempotencyRecord, handle func(http.ResponseWriter)) {
+	capture := &responseCapture{header: w.Header(), status: http.StatusOK}
+	handle(capture)
+
+	// Store with a fresh context so a client disconnect does not leave the
+	// key locked
+	ctx := context.WithoutCancel(r.Context())
+	if capture.status >= 200 && capture.status < 300 {
+		record.Pending = false
+		record.Status = capture.status
+		record.Body = capture.body.Bytes()
+		if data, err := json.Marshal(record); err == nil && s.cache.Set(ctx, cacheKey, data, s.ttl) == nil {
+			capture.flush(w)
+			return
+		}
+	}
+	_ = s.cache.Delete(ctx, cacheKey)
+	capture.flush(w)
+}
+
+// idempotencyCacheKey returns the cache key, before the store's prefix, for
+// an idempotency key sent with apiKey. Both are hashed so raw secrets never
+// reach the cache.
+func idempotencyCacheKey(apiKey, key string) string {
+	sum := sha256.Sum256([]byte(apiKey + "\x00" + key))
+	return "idem:" + hex.EncodeToString(sum[:])
+}
+
+// responseCapture buffers a response so it can be stored before it is sent.
+type responseCapture struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) Header() http.Header { return c.header }
+
+func (c *responseCapture) WriteHeader(status int) { c.status = status }
+
+func (c *responseCapture) Write(b []byte) (int, error) { return c.body.Write(b) }
+
+// flush sends the captured response to w.
+func (c *responseCapture) flush(w http.ResponseWriter) {
+	w.WriteHeader(c.status)
+	_, _ = w.Write(c.body.Bytes())
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/cache"
+	"github.com/emadnahed/FastGoLink/internal/middleware"
+	"github.com/emadnahed/FastGoLink/internal/services"
+)
+
+func newIdempotentHandler(t *testing.T, svc services.URLService) *URLHandler {
+	t.Helper()
+	memCache := cache.NewMemoryCache(0, 0)
+	t.Cleanup(func() { _ = memCache.Close() })
+	return NewURLHandlerWithIdempotency(svc, NewIdempotencyStore(memCache, "test:", time.Hour))
+}
+
+func shortenWithKey(h *URLHandler, body, key, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if apiKey != "" {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyKey, apiKey))
+	}
+	rec := httptest.NewRecorder()
+	h.Shorten(rec, req)
+	return rec
+}
+
+func createResponse(code string) *services.CreateURLResponse {
+	return &services.CreateURLResponse{
+		ShortURL:    "http://localhost:8080/" + code,
+		ShortCode:   code,
+		OriginalURL: "https://example.com",
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestURLHandler_Shorten_IdempotencyKey(t *testing.T) {
+	const body = `{"url":"https://example.com"}`
+
+	t.Run("replay returns the original response", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Once()
+		h := newIdempotentHandler(t, svc)
+
+		first := shortenWithKey(h, body, "key-1", "")
+		require.Equal(t, http.StatusCreated, first.Code)
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+		second := shortenWithKey(h, body, "key-1", "")
+		require.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+		assert.Equal(t, first.Body.String(), second.Body.String())
+
+		var resp ShortenResponse
+		require.NoError(t, json.Unmarshal(second.Body.Bytes(), &resp))
+		assert.Equal(t, "abc1234", resp.ShortCode)
+		svc.AssertNumberOfCalls(t, "Create", 1)
+	})
+
+	t.Run("responses are stored under the prefix", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Once()
+		memCache := cache.NewMemoryCache(0, 0)
+		t.Cleanup(func() { _ = memCache.Close() })
+		h := NewURLHandlerWithIdempotency(svc, NewIdempotencyStore(memCache, "tenant-a:", time.Hour))
+
+		require.Equal(t, http.StatusCreated, shortenWithKey(h, body, "key-1", "").Code)
+
+		exists, err := memCache.Exists(context.Background(), "tenant-a:"+idempotencyCacheKey("", "key-1"))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("different keys create separate URLs", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Once()
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("def5678"), nil).Once()
+		h := newIdempotentHandler(t, svc)
+
+		shortenWithKey(h, body, "key-1", "")
+		rec := shortenWithKey(h, body, "key-2", "")
+
+		var resp ShortenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "def5678", resp.ShortCode)
+		svc.AssertNumberOfCalls(t, "Create", 2)
+	})
+
+	t.Run("keys are scoped to the API key", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Twice()
+		h := newIdempotentHandler(t, svc)
+
+		shortenWithKey(h, body, "key-1", "tenant-a")
+		rec := shortenWithKey(h, body, "key-1", "tenant-b")
+
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		svc.AssertNumberOfCalls(t, "Create", 2)
+	})
+
+	t.Run("reused key with a different body is rejected", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Once()
+		h := newIdempotentHandler(t, svc)
+
+		shortenWithKey(h, body, "key-1", "")
+		rec := shortenWithKey(h, `{"url":"https://example.org"}`, "key-1", "")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+		svc.AssertNumberOfCalls(t, "Create", 1)
+	})
+
+	t.Run("failed requests are not remembered", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(nil, errors.New("database down")).Once()
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Once()
+		h := newIdempotentHandler(t, svc)
+
+		rec := shortenWithKey(h, body, "key-1", "")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		rec = shortenWithKey(h, body, "key-1", "")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		svc.AssertNumberOfCalls(t, "Create", 2)
+	})
+
+	t.Run("oversized key is rejected", func(t *testing.T) {
+		h := newIdempotentHandler(t, new(MockURLService))
+
+		rec := shortenWithKey(h, body, strings.Repeat("k", MaxIdempotencyKeyLength+1), "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "INVALID_IDEMPOTENCY_KEY")
+	})
+
+	t.Run("requests without a key are not deduplicated", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Twice()
+		h := newIdempotentHandler(t, svc)
+
+		shortenWithKey(h, body, "", "")
+		shortenWithKey(h, body, "", "")
+
+		svc.AssertNumberOfCalls(t, "Create", 2)
+	})
+}
+
+func TestURLHandler_Shorten_IdempotencyKeyConcurrent(t *testing.T) {
+	const body = `{"url":"https://example.com"}`
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	svc := new(MockURLService)
+	svc.On("Create", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(createResponse("abc1234"), nil).Once()
+	h := newIdempotentHandler(t, svc)
+
+	// The first request holds the key while the duplicates arrive
+	firstDone := make(chan *httptest.ResponseRecorder)
+	go func() { firstDone <- shortenWithKey(h, body, "key-1", "") }()
+	<-started
+
+	var wg sync.WaitGroup
+	statuses := make([]int, 5)
+	for i := range statuses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = shortenWithKey(h, body, "key-1", "").Code
+		}()
+	}
+	wg.Wait()
+	for _, status := range statuses {
+		assert.Equal(t, http.StatusConflict, status)
+	}
+
+	close(release)
+	first := <-firstDone
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// Once the first request is done, duplicates get its response
+	rec := shortenWithKey(h, body, "key-1", "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, first.Body.String(), rec.Body.String())
+	svc.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestIdempotencyStore_FailsOpen(t *testing.T) {
+	svc := new(MockURLService)
+	svc.On("Create", mock.Anything, mock.Anything).Return(createResponse("abc1234"), nil).Twice()
+	h := NewURLHandlerWithIdempotency(svc, NewIdempotencyStore(failingCache{}, "test:", time.Hour))
+
+	for range 2 {
+		rec := shortenWithKey(h, `{"url":"https://example.com"}`, "key-1", "")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+	svc.AssertNumberOfCalls(t, "Create", 2)
+}
+
+// failingCache is an IdempotencyCache whose every operation fails.
+type failingCache struct{}
+
+var errCacheDown = errors.New("cache down")
+
+func (failingCache) Get(context.Context, string) ([]byte, error) { return nil, errCacheDown }
+
+func (failingCache) Set(context.Context, string, []byte, time.Duration) error { return errCacheDown }
+
+func (failingCache) SetNX(context.Context, string, []byte, time.Duration) (bool, error) {
+	return false, errCacheDown
+}
+
+func (failingCache) Delete(context.Context, string) error { return errCacheDown }
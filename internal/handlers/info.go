@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/version"
+)
+
+// InfoResponse represents the response for the info endpoint.
+type InfoResponse struct {
+	Version       string  `json:"version"`
+	Commit        string  `json:"commit"`
+	BuildTime     string  `json:"build_time"`
+	GoVersion     string  `json:"go_version"`
+	StartedAt     string  `json:"started_at"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// InfoHandler reports which build is running and for how long.
+type InfoHandler struct {
+	info    version.Info
+	started time.Time
+}
+
+// NewInfoHandler creates an InfoHandler for info, counting uptime from now.
+func NewInfoHandler(info version.Info) *InfoHandler {
+	return &InfoHandler{info: info, started: time.Now()}
+}
+
+// Info handles the /info endpoint.
+func (h *InfoHandler) Info(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, InfoResponse{
+		Version:       h.info.Version,
+		Commit:        h.info.Commit,
+		BuildTime:     h.info.BuildTime,
+		GoVersion:     h.info.GoVersion,
+		StartedAt:     h.started.UTC().Format(time.RFC3339),
+		UptimeSeconds: time.Since(h.started).Seconds(),
+	})
+}
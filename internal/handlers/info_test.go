@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/version"
+)
+
+func TestInfoHandler(t *testing.T) {
+	handler := NewInfoHandler(version.Info{
+		Version:   "v1.2.0",
+		Commit:    "abc1234",
+		BuildTime: "2026-10-01T12:00:00Z",
+		GoVersion: "go1.24.0",
+	})
+
+	get := func() (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		handler.Info(rec, httptest.NewRequest(http.MethodGet, "/info", nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	rec, first := get()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "v1.2.0", first["version"])
+	assert.Equal(t, "abc1234", first["commit"])
+	assert.Equal(t, "2026-10-01T12:00:00Z", first["build_time"])
+	assert.Equal(t, "go1.24.0", first["go_version"])
+	assert.NotEmpty(t, first["started_at"])
+	assert.Len(t, first, 6)
+
+	time.Sleep(10 * time.Millisecond)
+	_, second := get()
+	assert.Greater(t, second["uptime_seconds"], first["uptime_seconds"])
+	assert.Equal(t, first["started_at"], second["started_at"])
+}
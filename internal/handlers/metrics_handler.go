@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/cache"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/idgen"
+	"github.com/emadnahed/FastGoLink/internal/services"
+)
+
+// ClickStatsProvider exposes click channel backpressure.
+// analytics.ClickCounter satisfies it.
+type ClickStatsProvider interface {
+	GetStats() analytics.Stats
+}
+
+// CacheStatsProvider exposes URL cache lookup counters.
+type CacheStatsProvider interface {
+	CacheStats() cache.Stats
+}
+
+// GeneratorStatsProvider exposes short code generation counters.
+// idgen.CollisionAwareGenerator satisfies it.
+type GeneratorStatsProvider interface {
+	Stats() idgen.GeneratorStats
+}
+
+// PoolStatsProvider exposes connection pool statistics, one entry per shard.
+type PoolStatsProvider interface {
+	Stats() []*database.Stats
+}
+
+// MetricsHandler serves Prometheus metrics and registers application gauges.
+type MetricsHandler struct {
+	registerer prometheus.Registerer
+	handler    http.Handler
+}
+
+// NewMetricsHandler creates a MetricsHandler backed by the default Prometheus registry,
+// which also holds the HTTP metrics recorded by the metrics middleware.
+func NewMetricsHandler() *MetricsHandler {
+	return NewMetricsHandlerWithRegistry(prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
+}
+
+// NewMetricsHandlerWithRegistry creates a MetricsHandler using a custom registry.
+func NewMetricsHandlerWithRegistry(reg prometheus.Registerer, gatherer prometheus.Gatherer) *MetricsHandler {
+	return &MetricsHandler{
+		registerer: reg,
+		handler:    promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}),
+	}
+}
+
+// Metrics handles GET /metrics requests.
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// RegisterPendingClicks exposes gauges for clicks buffered by the analytics counter
+// that have not been flushed to the database yet.
+func (h *MetricsHandler) RegisterPendingClicks(provider services.PendingStatsProvider) error {
+	pendingClicks := prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "analytics_pending_clicks",
+			Help: "Number of recorded clicks awaiting flush to the database",
+		},
+		func() float64 {
+			var total int64
+			for _, count := range provider.GetPendingStats() {
+				total += count
+			}
+			return float64(total)
+		},
+	)
+	pendingCodes := prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "analytics_pending_short_codes",
+			Help: "Number of short codes with clicks awaiting flush",
+		},
+		func() float64 {
+			return float64(len(provider.GetPendingStats()))
+		},
+	)
+
+	return h.register(pendingClicks, pendingCodes)
+}
+
+// RegisterClickStats exposes the clicks dropped because the analytics
+// counter's channel buffer was full, and how full that buffer is.
+func (h *MetricsHandler) RegisterClickStats(provider ClickStatsProvider) error {
+	dropped := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "analytics_dropped_clicks_total",
+			Help: "Total number of clicks dropped because the click buffer was full",
+		},
+		func() float64 { return float64(provider.GetStats().Dropped) },
+	)
+	utilization := prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "analytics_click_buffer_utilization",
+			Help: "Fraction of the click buffer in use, from 0 to 1",
+		},
+		func() float64 { return provider.GetStats().Utilization() },
+	)
+
+	return h.register(dropped, utilization)
+}
+
+// RegisterCacheStats exposes the URL cache hit, miss, expiry and
+// coalesced lookup counters.
+func (h *MetricsHandler) RegisterCacheStats(provider CacheStatsProvider) error {
+	hits := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "url_cache_hits_total",
+			Help: "Total number of URL lookups served from cache",
+		},
+		func() float64 { return float64(provider.CacheStats().Hits) },
+	)
+	misses := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "url_cache_misses_total",
+			Help: "Total number of URL lookups not served from cache",
+		},
+		func() float64 { return float64(provider.CacheStats().Misses) },
+	)
+	expired := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "url_cache_expired_total",
+			Help: "Total number of expired URL cache entries evicted on lookup",
+		},
+		func() float64 { return float64(provider.CacheStats().Expired) },
+	)
+
+	coalesced := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "url_cache_coalesced_total",
+			Help: "Total number of URL cache misses that shared a concurrent lookup's database read",
+		},
+		func() float64 { return float64(provider.CacheStats().Coalesced) },
+	)
+
+	return h.register(hits, misses, expired, coalesced)
+}
+
+// RegisterGeneratorStats exposes the short code generation, retry,
+// collision and length bump counters. A collision rate that keeps rising means the code
+// space is filling up and URL_SHORT_CODE_LEN should be increased.
+func (h *MetricsHandler) RegisterGeneratorStats(provider GeneratorStatsProvider) error {
+	generations := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "idgen_generations_total",
+			Help: "Total number of short code generation requests",
+		},
+		func() float64 { return float64(provider.Stats().TotalGenerations) },
+	)
+	retries := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "idgen_retries_total",
+			Help: "Total number of short code generation retries after a collision",
+		},
+		func() float64 { return float64(provider.Stats().TotalRetries) },
+	)
+	collisions := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "idgen_collisions_total",
+			Help: "Total number of generated short codes that were already taken",
+		},
+		func() float64 { return float64(provider.Stats().TotalCollisions) },
+	)
+	lengthBumps := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "idgen_length_bumps_total",
+			Help: "Total number of generations that switched to longer short codes after repeated collisions",
+		},
+		func() float64 { return float64(provider.Stats().TotalLengthBumps) },
+	)
+
+	return h.register(generations, retries, collisions, lengthBumps)
+}
+
+// RegisterPoolStats exposes database connection pool statistics, labelled by shard.
+func (h *MetricsHandler) RegisterPoolStats(provider PoolStatsProvider) error {
+	return h.register(newPoolStatsCollector(provider))
+}
+
+// poolStatsCollector reads pool statistics on every scrape so all values
+// for a shard come from the same snapshot.
+type poolStatsCollector struct {
+	provider          PoolStatsProvider
+	maxConns          *prometheus.Desc
+	totalConns        *prometheus.Desc
+	idleConns         *prometheus.Desc
+	acquiredConns     *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+}
+
+func newPoolStatsCollector(provider PoolStatsProvider) *poolStatsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(name, help, []string{"shard"}, nil)
+	}
+	return &poolStatsCollector{
+		provider:          provider,
+		maxConns:          desc("db_pool_max_conns", "Maximum number of connections in the pool"),
+		totalConns:        desc("db_pool_total_conns", "Number of open connections, idle or in use"),
+		idleConns:         desc("db_pool_idle_conns", "Number of open connections not in use"),
+		acquiredConns:     desc("db_pool_acquired_conns", "Number of connections currently checked out"),
+		acquireCount:      desc("db_pool_acquires_total", "Total number of successful connection acquires"),
+		acquireDuration:   desc("db_pool_acquire_wait_seconds_total", "Total time spent waiting to acquire a connection"),
+		emptyAcquireCount: desc("db_pool_empty_acquires_total", "Total number of acquires that waited because the pool was empty"),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.acquiredConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+}
+
+// Collect implements prometheus.Collector.
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, s := range c.provider.Stats() {
+		shard := strconv.Itoa(i)
+		ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns), shard)
+		ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns), shard)
+		ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns), shard)
+		ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns), shard)
+		ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount), shard)
+		ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration.Seconds(), shard)
+		ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(s.EmptyAcquireCount), shard)
+	}
+}
+
+// register registers collectors, stopping at the first failure.
+func (h *MetricsHandler) register(collectors ...prometheus.Collector) error {
+	for _, c := range collectors {
+		if err := h.registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
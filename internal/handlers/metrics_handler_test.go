@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/cache"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/idgen"
+)
+
+type stubPendingStats map[string]int64
+
+func (s stubPendingStats) GetPendingStats() map[string]int64 {
+	return s
+}
+
+type stubClickStats analytics.Stats
+
+func (s stubClickStats) GetStats() analytics.Stats {
+	return analytics.Stats(s)
+}
+
+type stubCacheStats cache.Stats
+
+func (s stubCacheStats) CacheStats() cache.Stats {
+	return cache.Stats(s)
+}
+
+type stubPoolStats []*database.Stats
+
+func (s stubPoolStats) Stats() []*database.Stats {
+	return s
+}
+
+func scrapeMetrics(t *testing.T, h *MetricsHandler) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	h.Metrics(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMetricsHandler_DefaultRegistry(t *testing.T) {
+	body := scrapeMetrics(t, NewMetricsHandler())
+
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetricsHandler_RegisterPendingClicks(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := NewMetricsHandlerWithRegistry(reg, reg)
+
+	require.NoError(t, h.RegisterPendingClicks(stubPendingStats{"abc": 3, "xyz": 4}))
+
+	body := scrapeMetrics(t, h)
+	assert.Contains(t, body, "analytics_pending_clicks 7")
+	assert.Contains(t, body, "analytics_pending_short_codes 2")
+
+	// Registering twice reports the conflict instead of panicking
+	assert.Error(t, h.RegisterPendingClicks(stubPendingStats{}))
+}
+
+func TestMetricsHandler_RegisterClickStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := NewMetricsHandlerWithRegistry(reg, reg)
+
+	require.NoError(t, h.RegisterClickStats(stubClickStats{Dropped: 5, Buffered: 25, Capacity: 100}))
+
+	body := scrapeMetrics(t, h)
+	assert.Contains(t, body, "analytics_dropped_clicks_total 5")
+	assert.Contains(t, body, "analytics_click_buffer_utilization 0.25")
+}
+
+func TestMetricsHandler_RegisterCacheStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := NewMetricsHandlerWithRegistry(reg, reg)
+
+	require.NoError(t, h.RegisterCacheStats(stubCacheStats{Hits: 9, Misses: 3, Expired: 1, Coalesced: 4}))
+
+	body := scrapeMetrics(t, h)
+	assert.Contains(t, body, "url_cache_hits_total 9")
+	assert.Contains(t, body, "url_cache_misses_total 3")
+	assert.Contains(t, body, "url_cache_expired_total 1")
+	assert.Contains(t, body, "url_cache_coalesced_total 4")
+}
+
+// collideOnce reports the first code it is asked about as taken.
+type collideOnce struct{ checked int }
+
+func (c *collideOnce) Exists(context.Context, string) (bool, error) {
+	c.checked++
+	return c.checked == 1, nil
+}
+
+func TestMetricsHandler_RegisterGeneratorStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := NewMetricsHandlerWithRegistry(reg, reg)
+	gen := idgen.NewCollisionAwareGenerator(idgen.NewRandomGenerator(7), &collideOnce{}, 3)
+
+	require.NoError(t, h.RegisterGeneratorStats(gen))
+
+	body := scrapeMetrics(t, h)
+	assert.Contains(t, body, "idgen_generations_total 0")
+	assert.Contains(t, body, "idgen_collisions_total 0")
+
+	// The first generation collides once and retries; the second does not
+	for range 2 {
+		_, err := gen.Generate()
+		require.NoError(t, err)
+	}
+
+	body = scrapeMetrics(t, h)
+	assert.Contains(t, body, "idgen_generations_total 2")
+	assert.Contains(t, body, "idgen_retries_total 1")
+	assert.Contains(t, body, "idgen_collisions_total 1")
+	assert.Contains(t, body, "idgen_length_bumps_total 0")
+
+	assert.Error(t, h.RegisterGeneratorStats(gen))
+}
+
+func TestMetricsHandler_RegisterPoolStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := NewMetricsHandlerWithRegistry(reg, reg)
+
+	require.NoError(t, h.RegisterPoolStats(stubPoolStats{
+		{MaxConns: 10, TotalConns: 4, IdleConns: 1, AcquiredConns: 3, AcquireCount: 42, AcquireDuration: 1500 * time.Millisecond, EmptyAcquireCount: 5},
+		{MaxConns: 20, TotalConns: 2, IdleConns: 2},
+	}))
+
+	body := scrapeMetrics(t, h)
+	assert.Contains(t, body, `db_pool_max_conns{shard="0"} 10`)
+	assert.Contains(t, body, `db_pool_total_conns{shard="0"} 4`)
+	assert.Contains(t, body, `db_pool_idle_conns{shard="0"} 1`)
+	assert.Contains(t, body, `db_pool_acquired_conns{shard="0"} 3`)
+	assert.Contains(t, body, `db_pool_acquires_total{shard="0"} 42`)
+	assert.Contains(t, body, `db_pool_acquire_wait_seconds_total{shard="0"} 1.5`)
+	assert.Contains(t, body, `db_pool_empty_acquires_total{shard="0"} 5`)
+	assert.Contains(t, body, `db_pool_max_conns{shard="1"} 20`)
+	assert.Contains(t, body, `db_pool_idle_conns{shard="1"} 2`)
+
+	assert.Error(t, h.RegisterPoolStats(stubPoolStats{}))
+}
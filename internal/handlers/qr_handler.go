@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/emadnahed/FastGoLink/internal/services"
+)
+
+// QR code rendering limits.
+const (
+	DefaultQRSize = 256
+	MinQRSize     = 64
+	MaxQRSize     = 1024
+)
+
+// qrRecoveryLevels maps the ?ec= query values to error-correction levels.
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// QRHandler renders QR codes for short URLs.
+type QRHandler struct {
+	service services.URLService
+	baseURL string
+}
+
+// NewQRHandler creates a new QRHandler.
+// baseURL is used to build the full short URL encoded in the QR code.
+func NewQRHandler(svc services.URLService, baseURL string) *QRHandler {
+	return &QRHandler{
+		service: svc,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// QRCode handles GET /api/v1/urls/:code/qr requests.
+// Supports ?size= (pixels), ?ec= (L, M, Q, H) and ?format= (png, svg).
+func (h *QRHandler) QRCode(w http.ResponseWriter, r *http.Request, shortCode string) {
+	query := r.URL.Query()
+
+	size := DefaultQRSize
+	if v := query.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < MinQRSize || n > MaxQRSize {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("size must be between %d and %d", MinQRSize, MaxQRSize),
+				Code:  "INVALID_QR_SIZE",
+			})
+			return
+		}
+		size = n
+	}
+
+	level := qrcode.Medium
+	if v := query.Get("ec"); v != "" {
+		l, ok := qrRecoveryLevels[strings.ToUpper(v)]
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: "ec must be one of L, M, Q, H",
+				Code:  "INVALID_QR_EC",
+			})
+			return
+		}
+		level = l
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "format must be png or svg",
+			Code:  "INVALID_QR_FORMAT",
+		})
+		return
+	}
+
+	url, err := h.service.Get(r.Context(), shortCode)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	qr, err := qrcode.New(fmt.Sprintf("%s/%s", h.baseURL, url.ShortCode), level)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(renderQRSVG(qr.Bitmap(), size))
+		return
+	}
+
+	png, err := qr.PNG(size)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(png)
+}
+
+// renderQRSVG renders a QR bitmap as a scalable SVG document.
+// Each dark module becomes a unit square in a viewBox sized to the bitmap.
+func renderQRSVG(bitmap [][]bool, size int) []byte {
+	modules := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`, modules, modules)
+	b.WriteString(`<path fill="#000000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return []byte(b.String())
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func TestQRHandler_QRCode(t *testing.T) {
+	existing := &models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/path",
+		CreatedAt:   time.Now(),
+	}
+
+	t.Run("returns PNG by default", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("Get", mock.Anything, "abc1234").Return(existing, nil)
+		handler := NewQRHandler(mockSvc, "http://localhost:8080/")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc1234/qr?size=128", nil)
+		rec := httptest.NewRecorder()
+		handler.QRCode(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+
+		img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 128, img.Bounds().Dx())
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("returns SVG when requested", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("Get", mock.Anything, "abc1234").Return(existing, nil)
+		handler := NewQRHandler(mockSvc, "http://localhost:8080")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc1234/qr?format=svg&ec=H", nil)
+		rec := httptest.NewRecorder()
+		handler.QRCode(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+		body := rec.Body.String()
+		assert.True(t, strings.HasPrefix(body, "<svg"))
+		assert.Contains(t, body, `width="256"`)
+		assert.True(t, strings.HasSuffix(body, "</svg>"))
+	})
+
+	t.Run("unknown code returns 404", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("Get", mock.Anything, "missing").Return(nil, models.ErrURLNotFound)
+		handler := NewQRHandler(mockSvc, "http://localhost:8080")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/missing/qr", nil)
+		rec := httptest.NewRecorder()
+		handler.QRCode(rec, req, "missing")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), "NOT_FOUND")
+	})
+
+	t.Run("invalid options return 400", func(t *testing.T) {
+		tests := []struct {
+			query string
+			code  string
+		}{
+			{"size=10", "INVALID_QR_SIZE"},
+			{"size=abc", "INVALID_QR_SIZE"},
+			{"ec=Z", "INVALID_QR_EC"},
+			{"format=gif", "INVALID_QR_FORMAT"},
+		}
+
+		for _, tt := range tests {
+			mockSvc := new(MockURLService)
+			handler := NewQRHandler(mockSvc, "http://localhost:8080")
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc1234/qr?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			handler.QRCode(rec, req, "abc1234")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tt.query)
+			assert.Contains(t, rec.Body.String(), tt.code, tt.query)
+			mockSvc.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		}
+	})
+}
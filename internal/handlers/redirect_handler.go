@@ -1,16 +1,79 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"html/template"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/emadnahed/FastGoLink/internal/middleware"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/services"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
 )
 
+// passwordTemplate renders the form shown for password-protected links.
+var passwordTemplate = template.Must(template.ParseFS(templatesFS, "templates/password.html"))
+
+// interstitialTemplate renders the page shown before redirecting links that
+// have a preview.
+var interstitialTemplate = template.Must(template.ParseFS(templatesFS, "templates/interstitial.html"))
+
+// CacheHeader reports whether a redirect or URL lookup was served from the
+// URL cache, as "HIT" or "MISS". It is left out when caching is disabled.
+const CacheHeader = "X-Cache"
+
+// setCacheHeader sets CacheHeader from the result recorded in status.
+func setCacheHeader(w http.ResponseWriter, status *repository.CacheStatus) {
+	if result := status.Result(); result != "" {
+		w.Header().Set(CacheHeader, result)
+	}
+}
+
+// PreviewResponse is the metadata returned instead of a redirect for
+// ?preview=true requests.
+type PreviewResponse struct {
+	ShortCode   string  `json:"short_code"`
+	OriginalURL string  `json:"original_url"`
+	CreatedAt   string  `json:"created_at"`
+	ExpiresAt   *string `json:"expires_at,omitempty"`
+	Permanent   bool    `json:"permanent"`
+	ShowPreview bool    `json:"show_preview"`
+
+	PasswordProtected bool `json:"password_protected"`
+}
+
+// RedirectConfig customizes what RedirectHandler serves outside of redirects.
+type RedirectConfig struct {
+	RootURL string // Where GET / redirects to; empty responds 404
+
+	// NotFoundURL is where unknown short codes and paths redirect to. When
+	// empty, NotFoundPage is served with status 404 instead, or a plain 404
+	// when that is empty too.
+	NotFoundURL  string
+	NotFoundPage []byte
+
+	// ExpiredURL is where expired links redirect to, such as a "this link
+	// has expired" page. When empty, expired links respond 410.
+	ExpiredURL string
+}
+
+// LocationHook computes the Location of a redirect, for example to add a
+// click ID to the destination. url.OriginalURL holds the destination the
+// redirect would otherwise use, with platform targets and append params
+// already applied. Returning "" keeps that destination.
+type LocationHook func(ctx context.Context, url *models.URL, r *http.Request) string
+
 // RedirectHandler handles URL redirect requests.
 type RedirectHandler struct {
-	service services.RedirectService
+	service      services.RedirectService
+	cfg          RedirectConfig
+	locationHook LocationHook
 }
 
 // NewRedirectHandler creates a new RedirectHandler.
@@ -18,12 +81,98 @@ func NewRedirectHandler(svc services.RedirectService) *RedirectHandler {
 	return &RedirectHandler{service: svc}
 }
 
+// NewRedirectHandlerWithConfig creates a RedirectHandler with a root
+// redirect and custom not-found response.
+func NewRedirectHandlerWithConfig(svc services.RedirectService, cfg RedirectConfig) *RedirectHandler {
+	return &RedirectHandler{service: svc, cfg: cfg}
+}
+
+// SetLocationHook sets the hook that computes the Location of redirects.
+// It is not called for interstitial pages, only for the redirect they lead to.
+func (h *RedirectHandler) SetLocationHook(hook LocationHook) {
+	h.locationHook = hook
+}
+
+// Root handles GET / by redirecting to the configured root URL.
+func (h *RedirectHandler) Root(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.RootURL == "" {
+		h.NotFound(w, r)
+		return
+	}
+	http.Redirect(w, r, h.cfg.RootURL, http.StatusFound)
+}
+
+// NotFound responds to an unknown short code or path with the configured
+// not-found redirect or page, or a plain 404.
+func (h *RedirectHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case h.cfg.NotFoundURL != "":
+		http.Redirect(w, r, h.cfg.NotFoundURL, http.StatusFound)
+	case len(h.cfg.NotFoundPage) > 0:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(h.cfg.NotFoundPage)
+	default:
+		http.Error(w, "URL not found", http.StatusNotFound)
+	}
+}
+
 // Redirect handles GET /:code requests and redirects to the original URL.
 // This is optimized for minimal latency - cache hits should return in < 5ms.
+//
+// Password-protected links are unlocked by a "password" query parameter or
+// form field (POST /:code). Without a valid password, browsers get a password
+// form and other clients a plain 401.
+//
+// Links created with show_preview show an interstitial page with the
+// destination and a "continue" link instead of redirecting; the click is
+// counted when the page is continued. ?preview=true shows the interstitial for
+// any link and ?preview=false skips it. Clients that don't accept text/html get
+// the link's metadata as JSON instead of the interstitial; see Preview.
 func (h *RedirectHandler) Redirect(w http.ResponseWriter, r *http.Request, shortCode string) {
-	result, err := h.service.Redirect(r.Context(), shortCode)
+	var skipPreview bool
+	if v := r.URL.Query().Get("preview"); v != "" {
+		preview, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "preview must be true or false", http.StatusBadRequest)
+			return
+		}
+		if preview {
+			h.showPreview(w, r, shortCode)
+			return
+		}
+		skipPreview = true
+	}
+
+	ctx, span := tracer.Start(r.Context(), "RedirectHandler.Redirect", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+	ctx, cacheStatus := repository.WithCacheStatus(ctx)
+
+	password := r.FormValue("password")
+	result, err := h.service.RedirectWithOptions(ctx, shortCode, services.RedirectOptions{
+		Password:  password,
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+		ClientIP:  middleware.GetClientIP(r.Context()),
+
+		SkipPreview: skipPreview,
+	})
+	setCacheHeader(w, cacheStatus)
 	if err != nil {
-		h.handleError(w, err)
+		if errors.Is(err, services.ErrPasswordRequired) || errors.Is(err, services.ErrInvalidPassword) {
+			h.handlePasswordError(w, r, shortCode, err)
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+
+	if result.ShowPreview {
+		if !acceptsHTML(r) {
+			h.Preview(w, r, shortCode)
+			return
+		}
+		h.renderInterstitial(w, shortCode, result.OriginalURL, password)
 		return
 	}
 
@@ -33,17 +182,128 @@ func (h *RedirectHandler) Redirect(w http.ResponseWriter, r *http.Request, short
 		statusCode = http.StatusMovedPermanently // 301 Permanent Redirect
 	}
 
+	location := result.OriginalURL
+	if h.locationHook != nil && result.URL != nil {
+		if hooked := h.locationHook(ctx, result.URL, r); hooked != "" {
+			location = hooked
+		}
+	}
+
 	// Set Location header and send redirect response
-	http.Redirect(w, r, result.OriginalURL, statusCode)
+	http.Redirect(w, r, location, statusCode)
+}
+
+// Preview returns the link's metadata as JSON for API clients asking for a
+// preview, such as chat unfurls. Clicks are not counted.
+// Password-protected links need their password like redirects do, and
+// expired or exhausted links return 410.
+func (h *RedirectHandler) Preview(w http.ResponseWriter, r *http.Request, shortCode string) {
+	ctx, span := tracer.Start(r.Context(), "RedirectHandler.Preview", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	url, err := h.service.Preview(ctx, shortCode, r.FormValue("password"))
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	resp := PreviewResponse{
+		ShortCode:   url.ShortCode,
+		OriginalURL: url.OriginalURL,
+		CreatedAt:   formatTime(url.CreatedAt),
+		ExpiresAt:   formatOptionalTime(url.ExpiresAt),
+		Permanent:   url.Permanent,
+		ShowPreview: url.ShowPreview,
+
+		PasswordProtected: url.IsPasswordProtected(),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// showPreview handles ?preview=true, rendering the interstitial for browsers
+// and the link's metadata as JSON for other clients.
+func (h *RedirectHandler) showPreview(w http.ResponseWriter, r *http.Request, shortCode string) {
+	if !acceptsHTML(r) {
+		h.Preview(w, r, shortCode)
+		return
+	}
+
+	ctx, span := tracer.Start(r.Context(), "RedirectHandler.Interstitial", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	password := r.FormValue("password")
+	url, err := h.service.Preview(ctx, shortCode, password)
+	if err != nil {
+		if errors.Is(err, services.ErrPasswordRequired) || errors.Is(err, services.ErrInvalidPassword) {
+			h.handlePasswordError(w, r, shortCode, err)
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+	h.renderInterstitial(w, shortCode, url.OriginalURL, password)
+}
+
+// renderInterstitial writes the page shown before redirecting to originalURL.
+// Its continue action repeats the request with ?preview=false, posting the
+// password of protected links rather than putting it in the URL. The URL is
+// relative so it resolves under any base path the server is mounted at.
+func (h *RedirectHandler) renderInterstitial(w http.ResponseWriter, shortCode, originalURL, password string) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = interstitialTemplate.Execute(w, struct {
+		OriginalURL string
+		ContinueURL string
+		Password    string
+	}{
+		OriginalURL: originalURL,
+		ContinueURL: shortCode + "?preview=false",
+		Password:    password,
+	})
+}
+
+// handlePasswordError responds to a missing or wrong link password with 401,
+// rendering the password form for browsers.
+func (h *RedirectHandler) handlePasswordError(w http.ResponseWriter, r *http.Request, shortCode string, err error) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !acceptsHTML(r) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = passwordTemplate.Execute(w, struct {
+		ShortCode string
+		Invalid   bool
+	}{
+		ShortCode: shortCode,
+		Invalid:   errors.Is(err, services.ErrInvalidPassword),
+	})
+}
+
+// acceptsHTML reports whether the client is a browser that can be shown an
+// HTML page.
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
 }
 
 // handleError maps service errors to HTTP responses for redirect endpoints.
-func (h *RedirectHandler) handleError(w http.ResponseWriter, err error) {
+func (h *RedirectHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
 	switch {
 	case errors.Is(err, models.ErrURLNotFound):
-		http.Error(w, "URL not found", http.StatusNotFound)
+		h.NotFound(w, r)
+	case errors.Is(err, models.ErrURLExpired) && h.cfg.ExpiredURL != "":
+		http.Redirect(w, r, h.cfg.ExpiredURL, http.StatusFound)
 	case errors.Is(err, models.ErrURLExpired):
 		http.Error(w, "URL has expired", http.StatusGone)
+	case errors.Is(err, models.ErrURLExhausted):
+		http.Error(w, "URL has reached its click limit", http.StatusGone)
+	case errors.Is(err, models.ErrURLDisabled):
+		http.Error(w, "URL is disabled", http.StatusForbidden)
 	default:
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
@@ -2,16 +2,21 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/emadnahed/FastGoLink/internal/cache"
+	"github.com/emadnahed/FastGoLink/internal/middleware"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/services"
 )
 
@@ -28,6 +33,37 @@ func (m *MockRedirectService) Redirect(ctx context.Context, shortCode string) (*
 	return args.Get(0).(*services.RedirectResult), args.Error(1)
 }
 
+func (m *MockRedirectService) RedirectWithPassword(ctx context.Context, shortCode, password string) (*services.RedirectResult, error) {
+	args := m.Called(ctx, shortCode, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RedirectResult), args.Error(1)
+}
+
+func (m *MockRedirectService) RedirectWithOptions(ctx context.Context, shortCode string, opts services.RedirectOptions) (*services.RedirectResult, error) {
+	args := m.Called(ctx, shortCode, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RedirectResult), args.Error(1)
+}
+
+func (m *MockRedirectService) Preview(ctx context.Context, shortCode, password string) (*models.URL, error) {
+	args := m.Called(ctx, shortCode, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+// withPassword matches RedirectOptions carrying the given password.
+func withPassword(password string) interface{} {
+	return mock.MatchedBy(func(opts services.RedirectOptions) bool {
+		return opts.Password == password
+	})
+}
+
 func TestRedirectHandler_Redirect(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -41,7 +77,7 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 			name:      "valid code redirects with 302",
 			shortCode: "abc1234",
 			setupMock: func(svc *MockRedirectService) {
-				svc.On("Redirect", mock.Anything, "abc1234").Return(&services.RedirectResult{
+				svc.On("RedirectWithOptions", mock.Anything, "abc1234", withPassword("")).Return(&services.RedirectResult{
 					OriginalURL: "https://example.com/very/long/path",
 					Permanent:   false,
 				}, nil)
@@ -56,7 +92,7 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 			name:      "permanent redirect uses 301",
 			shortCode: "perm123",
 			setupMock: func(svc *MockRedirectService) {
-				svc.On("Redirect", mock.Anything, "perm123").Return(&services.RedirectResult{
+				svc.On("RedirectWithOptions", mock.Anything, "perm123", withPassword("")).Return(&services.RedirectResult{
 					OriginalURL: "https://example.com/permanent",
 					Permanent:   true,
 				}, nil)
@@ -71,7 +107,7 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 			name:      "non-existent code returns 404",
 			shortCode: "notfound",
 			setupMock: func(svc *MockRedirectService) {
-				svc.On("Redirect", mock.Anything, "notfound").Return(nil, models.ErrURLNotFound)
+				svc.On("RedirectWithOptions", mock.Anything, "notfound", withPassword("")).Return(nil, models.ErrURLNotFound)
 			},
 			expectedStatus:   http.StatusNotFound,
 			expectedLocation: "",
@@ -83,7 +119,7 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 			name:      "expired code returns 410 Gone",
 			shortCode: "expired",
 			setupMock: func(svc *MockRedirectService) {
-				svc.On("Redirect", mock.Anything, "expired").Return(nil, models.ErrURLExpired)
+				svc.On("RedirectWithOptions", mock.Anything, "expired", withPassword("")).Return(nil, models.ErrURLExpired)
 			},
 			expectedStatus:   http.StatusGone,
 			expectedLocation: "",
@@ -95,7 +131,7 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 			name:      "service error returns 500",
 			shortCode: "error",
 			setupMock: func(svc *MockRedirectService) {
-				svc.On("Redirect", mock.Anything, "error").Return(nil, errors.New("database error"))
+				svc.On("RedirectWithOptions", mock.Anything, "error", withPassword("")).Return(nil, errors.New("database error"))
 			},
 			expectedStatus:   http.StatusInternalServerError,
 			expectedLocation: "",
@@ -130,10 +166,9 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 
 func TestRedirectHandler_LatencyTracking(t *testing.T) {
 	mockSvc := new(MockRedirectService)
-	mockSvc.On("Redirect", mock.Anything, "fast123").Return(&services.RedirectResult{
+	mockSvc.On("RedirectWithOptions", mock.Anything, "fast123", withPassword("")).Return(&services.RedirectResult{
 		OriginalURL: "https://example.com/fast",
 		Permanent:   false,
-		CacheHit:    true,
 	}, nil)
 
 	handler := NewRedirectHandler(mockSvc)
@@ -151,3 +186,532 @@ func TestRedirectHandler_LatencyTracking(t *testing.T) {
 
 	mockSvc.AssertExpectations(t)
 }
+
+func TestRedirectHandler_PassesClickSource(t *testing.T) {
+	mockSvc := new(MockRedirectService)
+	mockSvc.On("RedirectWithOptions", mock.Anything, "abc1234", services.RedirectOptions{
+		Referrer:  "https://twitter.com/someone",
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0",
+		ClientIP:  "203.0.113.7",
+	}).Return(&services.RedirectResult{OriginalURL: "https://example.com"}, nil)
+
+	handler := NewRedirectHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	req.Header.Set("Referer", "https://twitter.com/someone")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ClientIPKey, "203.0.113.7"))
+	rec := httptest.NewRecorder()
+
+	handler.Redirect(rec, req, "abc1234")
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRedirectHandler_LocationHook(t *testing.T) {
+	link := &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com/page?utm_source=x"}
+	newHandler := func(hook LocationHook) *RedirectHandler {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "abc1234", mock.Anything).
+			Return(&services.RedirectResult{OriginalURL: link.OriginalURL, URL: link}, nil)
+		handler := NewRedirectHandler(mockSvc)
+		handler.SetLocationHook(hook)
+		return handler
+	}
+
+	t.Run("hook rewrites the destination", func(t *testing.T) {
+		handler := newHandler(func(ctx context.Context, url *models.URL, r *http.Request) string {
+			return url.OriginalURL + "&click_id=" + r.Header.Get("X-Request-ID") + "-" + url.ShortCode
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+		req.Header.Set("X-Request-ID", "req42")
+		rec := httptest.NewRecorder()
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/page?utm_source=x&click_id=req42-abc1234", rec.Header().Get("Location"))
+	})
+
+	t.Run("empty result keeps the default destination", func(t *testing.T) {
+		handler := newHandler(func(ctx context.Context, url *models.URL, r *http.Request) string {
+			return ""
+		})
+
+		rec := httptest.NewRecorder()
+		handler.Redirect(rec, httptest.NewRequest(http.MethodGet, "/abc1234", nil), "abc1234")
+
+		assert.Equal(t, "https://example.com/page?utm_source=x", rec.Header().Get("Location"))
+	})
+}
+
+// stubURLRepository serves a fixed set of URLs by short code.
+type stubURLRepository struct {
+	repository.URLRepository
+	urls map[string]*models.URL
+}
+
+func (r *stubURLRepository) GetByShortCode(_ context.Context, shortCode string) (*models.URL, error) {
+	url, ok := r.urls[shortCode]
+	if !ok {
+		return nil, models.ErrURLNotFound
+	}
+	copied := *url
+	return &copied, nil
+}
+
+// newCachedStubRepository returns a cached repository over a stub holding abc1234.
+func newCachedStubRepository(t *testing.T) *repository.CachedURLRepository {
+	t.Helper()
+	memCache := cache.NewMemoryCache(100, 0)
+	t.Cleanup(func() { _ = memCache.Close() })
+	base := &stubURLRepository{urls: map[string]*models.URL{
+		"abc1234": {ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()},
+	}}
+	return repository.NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Minute), time.Minute)
+}
+
+func TestRedirectHandler_CacheHeader(t *testing.T) {
+	svc := services.NewRedirectService(newCachedStubRepository(t))
+	svc.SetClickCountMode(services.ClickCountOff) // Counting clicks would invalidate the entry
+	handler := NewRedirectHandler(svc)
+
+	redirect := func(shortCode string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.Redirect(rec, httptest.NewRequest(http.MethodGet, "/"+shortCode, nil), shortCode)
+		return rec
+	}
+
+	rec := redirect("abc1234")
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get(CacheHeader), "cold cache")
+
+	rec = redirect("abc1234")
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get(CacheHeader), "warmed cache")
+
+	rec = redirect("missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get(CacheHeader), "unknown codes are never cached")
+
+	// Without a cached repository there is nothing to report
+	handler = NewRedirectHandler(services.NewRedirectService(&stubURLRepository{}))
+	rec = redirect("missing")
+	assert.Empty(t, rec.Header().Get(CacheHeader))
+}
+
+func TestRedirectHandler_PasswordProtected(t *testing.T) {
+	t.Run("API client gets 401", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "locked1", withPassword("")).Return(nil, services.ErrPasswordRequired)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/locked1", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "password required")
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("browser gets password form", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "locked1", withPassword("")).Return(nil, services.ErrPasswordRequired)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/locked1", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rec.Body.String(), `action="locked1"`)
+		assert.NotContains(t, rec.Body.String(), "Incorrect password")
+	})
+
+	t.Run("query password unlocks redirect", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "locked1", withPassword("s3cret")).Return(&services.RedirectResult{
+			OriginalURL: "https://example.com/secret",
+		}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/locked1?password=s3cret", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/secret", rec.Header().Get("Location"))
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("form password unlocks redirect", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "locked1", withPassword("s3cret")).Return(&services.RedirectResult{
+			OriginalURL: "https://example.com/secret",
+		}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodPost, "/locked1", strings.NewReader("password=s3cret"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/secret", rec.Header().Get("Location"))
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("wrong password shows error on form", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "locked1", withPassword("guess")).Return(nil, services.ErrInvalidPassword)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodPost, "/locked1", strings.NewReader("password=guess"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "text/html")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Incorrect password")
+	})
+}
+
+func TestRedirectHandler_Exhausted(t *testing.T) {
+	mockSvc := new(MockRedirectService)
+	mockSvc.On("RedirectWithOptions", mock.Anything, "once123", withPassword("")).Return(nil, models.ErrURLExhausted)
+
+	handler := NewRedirectHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/once123", nil)
+	rec := httptest.NewRecorder()
+
+	handler.Redirect(rec, req, "once123")
+
+	assert.Equal(t, http.StatusGone, rec.Code)
+	assert.Empty(t, rec.Header().Get("Location"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRedirectHandler_Disabled(t *testing.T) {
+	mockSvc := new(MockRedirectService)
+	mockSvc.On("RedirectWithOptions", mock.Anything, "paused1", withPassword("")).Return(nil, models.ErrURLDisabled)
+
+	handler := NewRedirectHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/paused1", nil)
+	rec := httptest.NewRecorder()
+
+	handler.Redirect(rec, req, "paused1")
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get("Location"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRedirectHandler_Root(t *testing.T) {
+	t.Run("redirects to root URL", func(t *testing.T) {
+		handler := NewRedirectHandlerWithConfig(new(MockRedirectService), RedirectConfig{RootURL: "https://example.com/"})
+		rec := httptest.NewRecorder()
+
+		handler.Root(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/", rec.Header().Get("Location"))
+	})
+
+	t.Run("responds 404 without root URL", func(t *testing.T) {
+		handler := NewRedirectHandler(new(MockRedirectService))
+		rec := httptest.NewRecorder()
+
+		handler.Root(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestRedirectHandler_NotFound(t *testing.T) {
+	serve := func(cfg RedirectConfig) *httptest.ResponseRecorder {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "missing", withPassword("")).Return(nil, models.ErrURLNotFound)
+
+		rec := httptest.NewRecorder()
+		NewRedirectHandlerWithConfig(mockSvc, cfg).Redirect(rec, httptest.NewRequest(http.MethodGet, "/missing", nil), "missing")
+		return rec
+	}
+
+	t.Run("plain 404 by default", func(t *testing.T) {
+		rec := serve(RedirectConfig{})
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), "URL not found")
+	})
+
+	t.Run("redirects to not-found URL", func(t *testing.T) {
+		rec := serve(RedirectConfig{NotFoundURL: "https://example.com/missing-link"})
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/missing-link", rec.Header().Get("Location"))
+	})
+
+	t.Run("serves not-found page", func(t *testing.T) {
+		rec := serve(RedirectConfig{NotFoundPage: []byte("<h1>No such link</h1>")})
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "<h1>No such link</h1>", rec.Body.String())
+	})
+
+	t.Run("expired links are not treated as unknown", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "old1234", withPassword("")).Return(nil, models.ErrURLExpired)
+
+		rec := httptest.NewRecorder()
+		NewRedirectHandlerWithConfig(mockSvc, RedirectConfig{NotFoundURL: "https://example.com/missing-link"}).
+			Redirect(rec, httptest.NewRequest(http.MethodGet, "/old1234", nil), "old1234")
+
+		assert.Equal(t, http.StatusGone, rec.Code)
+	})
+}
+
+func TestRedirectHandler_Expired(t *testing.T) {
+	serve := func(cfg RedirectConfig) *httptest.ResponseRecorder {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "old1234", withPassword("")).Return(nil, models.ErrURLExpired)
+
+		rec := httptest.NewRecorder()
+		NewRedirectHandlerWithConfig(mockSvc, cfg).Redirect(rec, httptest.NewRequest(http.MethodGet, "/old1234", nil), "old1234")
+		return rec
+	}
+
+	t.Run("410 by default", func(t *testing.T) {
+		rec := serve(RedirectConfig{})
+
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Contains(t, rec.Body.String(), "URL has expired")
+		assert.Empty(t, rec.Header().Get("Location"))
+	})
+
+	t.Run("redirects to expired URL", func(t *testing.T) {
+		rec := serve(RedirectConfig{ExpiredURL: "https://example.com/expired"})
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/expired", rec.Header().Get("Location"))
+	})
+
+	t.Run("exhausted links still respond 410", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "used1234", withPassword("")).Return(nil, models.ErrURLExhausted)
+
+		rec := httptest.NewRecorder()
+		NewRedirectHandlerWithConfig(mockSvc, RedirectConfig{ExpiredURL: "https://example.com/expired"}).
+			Redirect(rec, httptest.NewRequest(http.MethodGet, "/used1234", nil), "used1234")
+
+		assert.Equal(t, http.StatusGone, rec.Code)
+	})
+}
+
+func TestRedirectHandler_Preview(t *testing.T) {
+	createdAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(24 * time.Hour)
+
+	t.Run("returns metadata without redirecting", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("Preview", mock.Anything, "abc1234", "").Return(&models.URL{
+			ShortCode:   "abc1234",
+			OriginalURL: "https://example.com/article",
+			CreatedAt:   createdAt,
+			ExpiresAt:   &expiresAt,
+			ClickCount:  42,
+		}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/abc1234?preview=true", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+		assert.JSONEq(t, `{
+			"short_code": "abc1234",
+			"original_url": "https://example.com/article",
+			"created_at": "2024-01-15T10:30:00Z",
+			"expires_at": "2024-01-16T10:30:00Z",
+			"permanent": false,
+			"show_preview": false,
+			"password_protected": false
+		}`, rec.Body.String())
+		// The redirect path, which counts clicks, is never taken
+		mockSvc.AssertNotCalled(t, "RedirectWithOptions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("passes the password", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("Preview", mock.Anything, "locked1", "s3cret").Return(&models.URL{
+			ShortCode:    "locked1",
+			OriginalURL:  "https://example.com/secret",
+			CreatedAt:    createdAt,
+			PasswordHash: "hash",
+		}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/locked1?preview=true&password=s3cret", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"password_protected":true`)
+	})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", models.ErrURLNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"expired", models.ErrURLExpired, http.StatusGone, "EXPIRED"},
+		{"exhausted", models.ErrURLExhausted, http.StatusGone, "EXHAUSTED"},
+		{"password required", services.ErrPasswordRequired, http.StatusUnauthorized, "PASSWORD_REQUIRED"},
+		{"wrong password", services.ErrInvalidPassword, http.StatusUnauthorized, "WRONG_PASSWORD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockRedirectService)
+			mockSvc.On("Preview", mock.Anything, "abc1234", "").Return(nil, tt.err)
+
+			handler := NewRedirectHandler(mockSvc)
+			req := httptest.NewRequest(http.MethodGet, "/abc1234?preview=true", nil)
+			rec := httptest.NewRecorder()
+
+			handler.Redirect(rec, req, "abc1234")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			var errResp ErrorResponse
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+			assert.Equal(t, tt.wantCode, errResp.Code)
+		})
+	}
+
+	t.Run("preview=false redirects", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "abc1234", mock.MatchedBy(func(opts services.RedirectOptions) bool {
+			return opts.SkipPreview
+		})).Return(&services.RedirectResult{OriginalURL: "https://example.com"}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/abc1234?preview=false", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		mockSvc.AssertNotCalled(t, "Preview", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRedirectHandler_Interstitial(t *testing.T) {
+	previewResult := &services.RedirectResult{OriginalURL: "https://example.com/article", ShowPreview: true}
+
+	t.Run("browser gets interstitial", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "abc1234", withPassword("")).Return(previewResult, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rec.Body.String(), "https://example.com/article")
+		assert.Contains(t, rec.Body.String(), `href="abc1234?preview=false"`)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("API client gets JSON", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "abc1234", withPassword("")).Return(previewResult, nil)
+		mockSvc.On("Preview", mock.Anything, "abc1234", "").Return(&models.URL{
+			ShortCode:   "abc1234",
+			OriginalURL: "https://example.com/article",
+			ShowPreview: true,
+		}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+		assert.Contains(t, rec.Body.String(), `"original_url":"https://example.com/article"`)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("preview=true shows interstitial for any link", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("Preview", mock.Anything, "abc1234", "").Return(&models.URL{
+			ShortCode:   "abc1234",
+			OriginalURL: "https://example.com/article",
+		}, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/abc1234?preview=true", nil)
+		req.Header.Set("Accept", "text/html")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://example.com/article")
+		mockSvc.AssertNotCalled(t, "RedirectWithOptions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("protected link continues with a form post", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+		mockSvc.On("RedirectWithOptions", mock.Anything, "locked1", withPassword("s3cret")).Return(previewResult, nil)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodPost, "/locked1", strings.NewReader("password=s3cret"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "text/html")
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "locked1")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `action="locked1?preview=false"`)
+		assert.Contains(t, rec.Body.String(), `name="password" value="s3cret"`)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("invalid preview value", func(t *testing.T) {
+		mockSvc := new(MockRedirectService)
+
+		handler := NewRedirectHandler(mockSvc)
+		req := httptest.NewRequest(http.MethodGet, "/abc1234?preview=maybe", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Redirect(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockSvc.AssertNotCalled(t, "RedirectWithOptions", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -1,20 +1,63 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/emadnahed/FastGoLink/internal/idgen"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/services"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
 )
 
 // ShortenRequest represents the request body for creating a short URL.
 type ShortenRequest struct {
-	URL       string `json:"url"`
-	ExpiresIn string `json:"expires_in,omitempty"`
+	URL         string `json:"url"`
+	ExpiresIn   string `json:"expires_in,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"` // RFC 3339; takes precedence over ExpiresIn
+	CustomAlias string `json:"custom_alias,omitempty"`
+	Permanent   bool   `json:"permanent,omitempty"`
+	Password    string `json:"password,omitempty"`
+	MaxClicks   *int64 `json:"max_clicks,omitempty"`
+	ShowPreview bool   `json:"show_preview,omitempty"`
+
+	AppendParams    map[string]string `json:"append_params,omitempty"`
+	PlatformTargets map[string]string `json:"platform_targets,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+}
+
+// UpdateURLRequest represents the request body for changing a short URL's destination.
+type UpdateURLRequest struct {
+	URL string `json:"url"`
+}
+
+// ExtendURLRequest represents the request body for changing a short URL's
+// expiry. Exactly one of the fields must be set.
+type ExtendURLRequest struct {
+	ExpiresIn string `json:"expires_in,omitempty"` // Duration from now, such as "168h"
+	ExpiresAt string `json:"expires_at,omitempty"` // RFC 3339 timestamp
+}
+
+// CreateAliasRequest represents the request body for adding a short code to an existing URL.
+type CreateAliasRequest struct {
+	Alias string `json:"alias"`
 }
 
 // ShortenResponse represents the response for a successfully created short URL.
@@ -24,17 +67,98 @@ type ShortenResponse struct {
 	OriginalURL string  `json:"original_url"`
 	CreatedAt   string  `json:"created_at"`
 	ExpiresAt   *string `json:"expires_at,omitempty"`
+	Permanent   bool    `json:"permanent"`
+	MaxClicks   *int64  `json:"max_clicks,omitempty"`
+	ShowPreview bool    `json:"show_preview"`
+
+	AppendParams      map[string]string `json:"append_params,omitempty"`
+	PlatformTargets   map[string]string `json:"platform_targets,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	PasswordProtected bool              `json:"password_protected"`
 }
 
 // URLInfoResponse represents the response for URL info retrieval.
 type URLInfoResponse struct {
 	ShortCode   string  `json:"short_code"`
-	OriginalURL string  `json:"original_url"`
+	OriginalURL string  `json:"original_url,omitempty"` // Omitted from public responses for password-protected URLs
 	CreatedAt   string  `json:"created_at"`
 	ExpiresAt   *string `json:"expires_at,omitempty"`
 	ClickCount  int64   `json:"click_count"`
+	Permanent   bool    `json:"permanent"`
+	MaxClicks   *int64  `json:"max_clicks,omitempty"`
+	ShowPreview bool    `json:"show_preview"`
+
+	AppendParams      map[string]string `json:"append_params,omitempty"`
+	PlatformTargets   map[string]string `json:"platform_targets,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	PasswordProtected bool              `json:"password_protected"`
+	Active            bool              `json:"active"` // False while redirects are disabled
+
+	// Result of the last destination check; omitted until the link is checked
+	LinkStatus    *int    `json:"link_status,omitempty"`
+	LinkCheckedAt *string `json:"link_checked_at,omitempty"`
+	LinkBroken    bool    `json:"link_broken,omitempty"`
+}
+
+// ListURLsResponse represents a page of URLs. With cursor pagination, Total
+// and Offset are not used and NextCursor is set while more pages follow.
+type ListURLsResponse struct {
+	Items      []URLInfoResponse `json:"items"`
+	Total      int64             `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+	NextCursor int64             `json:"next_cursor,omitempty"`
 }
 
+// MaxBatchSize is the maximum number of URLs accepted by a single batch request.
+const MaxBatchSize = 500
+
+// BatchShortenItem reports the outcome of a single entry in a batch request.
+type BatchShortenItem struct {
+	Index  int              `json:"index"`
+	Status int              `json:"status"`
+	Result *ShortenResponse `json:"result,omitempty"`
+	Error  *ErrorResponse   `json:"error,omitempty"`
+}
+
+// BatchShortenResponse represents the response for a batch shorten request.
+type BatchShortenResponse struct {
+	Results   []BatchShortenItem `json:"results"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+}
+
+// BatchDeleteItem reports the outcome of deleting a single short code in a batch request.
+type BatchDeleteItem struct {
+	ShortCode string         `json:"short_code"`
+	Status    int            `json:"status"`
+	Error     *ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchDeleteResponse represents the response for a batch delete request.
+type BatchDeleteResponse struct {
+	Results   []BatchDeleteItem `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
+
+// PurgeExpiredResponse represents the response for a purge of expired URLs.
+type PurgeExpiredResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// ExportRecord is a single URL in an export.
+type ExportRecord struct {
+	ShortCode   string     `json:"short_code"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ClickCount  int64      `json:"click_count"`
+}
+
+// exportColumns is the header row of a CSV export.
+var exportColumns = []string{"short_code", "original_url", "created_at", "expires_at", "click_count"}
+
 // ErrorResponse represents an error response.
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -43,7 +167,9 @@ type ErrorResponse struct {
 
 // URLHandler handles URL shortening endpoints.
 type URLHandler struct {
-	service services.URLService
+	service      services.URLService
+	idempotency  *IdempotencyStore
+	shortDomains map[string]string // Lowercase host to the base URL short URLs use on it
 }
 
 // NewURLHandler creates a new URLHandler.
@@ -51,96 +177,946 @@ func NewURLHandler(svc services.URLService) *URLHandler {
 	return &URLHandler{service: svc}
 }
 
+// NewURLHandlerWithIdempotency creates a new URLHandler that replays the
+// response of shorten requests repeated with the same Idempotency-Key.
+func NewURLHandlerWithIdempotency(svc services.URLService, store *IdempotencyStore) *URLHandler {
+	return &URLHandler{service: svc, idempotency: store}
+}
+
+// ShortDomainHeader names the domain a shorten request wants its short URLs
+// on. It takes precedence over the Host header.
+const ShortDomainHeader = "X-Short-Domain"
+
+// SetShortDomains lets shorten requests choose which of domains their short
+// URLs use, with the X-Short-Domain or Host header. Short URLs keep the
+// scheme and path of baseURL; requests for any other domain get baseURL,
+// which the service is configured with.
+func (h *URLHandler) SetShortDomains(baseURL string, domains []string) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return
+	}
+	h.shortDomains = make(map[string]string, len(domains))
+	for _, domain := range domains {
+		domainBase := *base
+		domainBase.Host = strings.ToLower(domain)
+		h.shortDomains[domainBase.Host] = strings.TrimSuffix(domainBase.String(), "/")
+	}
+}
+
+// shortDomainContext returns the context to create short URLs for r under,
+// carrying the base URL of the allowed domain r asks for.
+func (h *URLHandler) shortDomainContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if len(h.shortDomains) == 0 {
+		return ctx
+	}
+	domain := r.Header.Get(ShortDomainHeader)
+	if domain == "" {
+		domain = r.Host
+	}
+	if baseURL, ok := h.shortDomains[strings.ToLower(domain)]; ok {
+		return services.WithBaseURL(ctx, baseURL)
+	}
+	return ctx
+}
+
 // Shorten handles POST /api/v1/shorten requests.
+// Requests with an Idempotency-Key header are served through the
+// idempotency store when one is configured.
 func (h *URLHandler) Shorten(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" || h.idempotency == nil {
+		h.shorten(w, r, body)
+		return
+	}
+	h.idempotency.Serve(w, r, key, body, func(w http.ResponseWriter) {
+		h.shorten(w, r, body)
+	})
+}
+
+// shorten creates a short URL from a shorten request body.
+func (h *URLHandler) shorten(w http.ResponseWriter, r *http.Request, body []byte) {
 	// Parse request body
 	var req ShortenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{
-			Error: "invalid request body",
-			Code:  "INVALID_REQUEST",
-		})
+	if err := decodeStrict(bytes.NewReader(body), &req); err != nil {
+		writeDecodeError(w, r, err)
 		return
 	}
 
 	// Parse expires_in duration if provided
-	var expiresIn *time.Duration
-	if req.ExpiresIn != "" {
-		d, err := time.ParseDuration(req.ExpiresIn)
+	createReq, errResp := toCreateURLRequest(req)
+	if errResp != nil {
+		writeError(w, r, http.StatusBadRequest, *errResp)
+		return
+	}
+
+	ctx, span := tracer.Start(h.shortDomainContext(r), "URLHandler.Shorten")
+	defer span.End()
+
+	// Call service
+	resp, err := h.service.Create(ctx, createReq)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newShortenResponse(resp))
+}
+
+// ShortenBatch handles POST /api/v1/shorten/batch requests.
+// Each entry is processed independently; the response reports per-item results.
+func (h *URLHandler) ShortenBatch(w http.ResponseWriter, r *http.Request) {
+	var reqs []ShortenRequest
+	if err := decodeStrict(r.Body, &reqs); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if len(reqs) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "batch must contain at least one URL",
+			Code:  "EMPTY_BATCH",
+		})
+		return
+	}
+	if len(reqs) > MaxBatchSize {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("batch exceeds maximum size of %d", MaxBatchSize),
+			Code:  "BATCH_TOO_LARGE",
+		})
+		return
+	}
+
+	results := make([]BatchShortenItem, len(reqs))
+
+	// Parse entries up front; only well-formed ones are sent to the service
+	createReqs := make([]services.CreateURLRequest, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		createReq, errResp := toCreateURLRequest(req)
+		if errResp != nil {
+			results[i] = BatchShortenItem{Index: i, Status: http.StatusBadRequest, Error: errResp}
+			continue
+		}
+		createReqs = append(createReqs, createReq)
+		indexes = append(indexes, i)
+	}
+
+	ctx, span := tracer.Start(h.shortDomainContext(r), "URLHandler.ShortenBatch")
+	defer span.End()
+
+	resps, errs := h.service.CreateBatch(ctx, createReqs)
+	for j, i := range indexes {
+		if errs[j] != nil {
+			status, errResp := mapErrorToResponse(errs[j])
+			results[i] = BatchShortenItem{Index: i, Status: status, Error: &errResp}
+			continue
+		}
+		shortenResp := newShortenResponse(&resps[j])
+		results[i] = BatchShortenItem{Index: i, Status: http.StatusCreated, Result: &shortenResp}
+	}
+
+	batchResp := BatchShortenResponse{Results: results}
+	for _, item := range results {
+		if item.Error != nil {
+			batchResp.Failed++
+		} else {
+			batchResp.Succeeded++
+		}
+	}
+
+	status := http.StatusCreated
+	if batchResp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	writeJSON(w, status, batchResp)
+}
+
+// GetURL handles GET /api/v1/urls/:code requests.
+func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request, shortCode string) {
+	ctx, span := tracer.Start(r.Context(), "URLHandler.GetURL", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+	ctx, cacheStatus := repository.WithCacheStatus(ctx)
+
+	url, err := h.service.Get(ctx, shortCode)
+	setCacheHeader(w, cacheStatus)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeConditionalJSON(w, r, newPublicURLInfoResponse(url), url.UpdatedAt)
+}
+
+// writeConditionalJSON writes data as a 200 JSON response with an ETag
+// computed from the encoded body and, when lastModified is known, a
+// Last-Modified header. If the request's validators show the client already
+// has this representation, it gets 304 with no body instead.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, data interface{}, lastModified time.Time) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	// Let clients cache the response but make them revalidate it every time
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since against the
+// current validators. As in RFC 9110, If-Modified-Since is ignored when
+// If-None-Match is present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if values := r.Header.Values("If-None-Match"); len(values) > 0 {
+		for _, tag := range strings.Split(strings.Join(values, ","), ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since := r.Header.Get("If-Modified-Since")
+	if since == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(since)
+	if err != nil {
+		return false
+	}
+	// Last-Modified only has second precision
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// ListURLs handles GET /api/v1/urls requests.
+// Supports ?limit= and ?offset= for pagination, ?search= to match the short
+// code or URL, ?created_after= and ?created_before= (RFC 3339) and
+// ?status= (active, expired). Passing ?cursor= (empty to start) switches to
+// cursor pagination in ID order, which stays fast on large tables.
+func (h *URLHandler) ListURLs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, offset := services.DefaultListLimit, 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, ErrorResponse{
-				Error: "invalid expires_in duration format",
-				Code:  "INVALID_EXPIRES_IN",
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: services.ErrInvalidPagination.Error(),
+				Code:  "INVALID_PAGINATION",
 			})
 			return
 		}
-		expiresIn = &d
+		limit = n
+	}
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: services.ErrInvalidPagination.Error(),
+				Code:  "INVALID_PAGINATION",
+			})
+			return
+		}
+		offset = n
 	}
 
-	// Call service
-	createReq := services.CreateURLRequest{
-		OriginalURL: req.URL,
-		ExpiresIn:   expiresIn,
+	useCursor := query.Has("cursor")
+	var cursor int64
+	if useCursor {
+		if query.Has("offset") {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: "cursor cannot be combined with offset",
+				Code:  "INVALID_CURSOR",
+			})
+			return
+		}
+		if v := query.Get("cursor"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				writeError(w, r, http.StatusBadRequest, ErrorResponse{
+					Error: services.ErrInvalidCursor.Error(),
+					Code:  "INVALID_CURSOR",
+				})
+				return
+			}
+			cursor = n
+		}
+	}
+
+	filter, errResp := parseListFilter(query)
+	if errResp != nil {
+		writeError(w, r, http.StatusBadRequest, *errResp)
+		return
 	}
 
-	resp, err := h.service.Create(r.Context(), createReq)
+	ctx, span := tracer.Start(r.Context(), "URLHandler.ListURLs")
+	defer span.End()
+
+	var (
+		urls        []*models.URL
+		total, next int64
+		err         error
+	)
+	if useCursor {
+		urls, next, err = h.service.ListAfter(ctx, cursor, limit, filter)
+	} else {
+		urls, total, err = h.service.List(ctx, limit, offset, filter)
+	}
 	if err != nil {
 		status, errResp := mapErrorToResponse(err)
-		writeJSON(w, status, errResp)
+		writeError(w, r, status, errResp)
 		return
 	}
 
-	// Build response
-	shortenResp := ShortenResponse{
-		ShortURL:    resp.ShortURL,
-		ShortCode:   resp.ShortCode,
-		OriginalURL: resp.OriginalURL,
-		CreatedAt:   resp.CreatedAt.Format(time.RFC3339),
+	resp := ListURLsResponse{
+		Items:      make([]URLInfoResponse, 0, len(urls)),
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		NextCursor: next,
 	}
-	if resp.ExpiresAt != nil {
-		expiresAtStr := resp.ExpiresAt.Format(time.RFC3339)
-		shortenResp.ExpiresAt = &expiresAtStr
+	for _, url := range urls {
+		resp.Items = append(resp.Items, newURLInfoResponse(url))
 	}
 
-	writeJSON(w, http.StatusCreated, shortenResp)
+	writeJSON(w, http.StatusOK, resp)
 }
 
-// GetURL handles GET /api/v1/urls/:code requests.
-func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request, shortCode string) {
-	url, err := h.service.Get(r.Context(), shortCode)
-	if err != nil {
-		status, errResp := mapErrorToResponse(err)
-		writeJSON(w, status, errResp)
+// ExportURLs handles GET /api/v1/urls/export requests.
+// Streams every URL as a file download, as CSV (?format=csv, the default)
+// or as newline-delimited JSON (?format=json).
+func (h *URLHandler) ExportURLs(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+
+	var contentType, filename string
+	switch format {
+	case "csv":
+		contentType, filename = "text/csv; charset=utf-8", "urls.csv"
+	case "json":
+		contentType, filename = "application/x-ndjson", "urls.ndjson"
+	default:
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "format must be csv or json",
+			Code:  "INVALID_EXPORT_FORMAT",
+		})
 		return
 	}
 
-	// Build response
-	infoResp := URLInfoResponse{
+	ctx, span := tracer.Start(r.Context(), "URLHandler.ExportURLs", trace.WithAttributes(attribute.String("export.format", format)))
+	defer span.End()
+
+	csvWriter := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+
+	// Headers are sent with the first record so that an error reading the
+	// first page can still be reported as a JSON error response.
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		if format == "csv" {
+			return csvWriter.Write(exportColumns)
+		}
+		return nil
+	}
+
+	err := h.service.Export(ctx, func(url *models.URL) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if format == "json" {
+			return encoder.Encode(newExportRecord(url))
+		}
+		return csvWriter.Write(exportRow(url))
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil && format == "csv" {
+		csvWriter.Flush()
+		err = csvWriter.Error()
+	}
+
+	if err != nil {
+		if !started {
+			status, errResp := mapErrorToResponse(err)
+			writeError(w, r, status, errResp)
+			return
+		}
+		// The status line is already sent; abort the connection so the
+		// client sees a truncated download instead of a complete file.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// newExportRecord converts a URL to its export form.
+func newExportRecord(url *models.URL) ExportRecord {
+	return ExportRecord{
 		ShortCode:   url.ShortCode,
 		OriginalURL: url.OriginalURL,
-		CreatedAt:   url.CreatedAt.Format(time.RFC3339),
+		CreatedAt:   url.CreatedAt,
+		ExpiresAt:   url.ExpiresAt,
 		ClickCount:  url.ClickCount,
 	}
+}
+
+// exportRow converts a URL to a CSV export row. Times are RFC 3339 and a
+// URL without an expiry has an empty expires_at.
+func exportRow(url *models.URL) []string {
+	expiresAt := ""
 	if url.ExpiresAt != nil {
-		expiresAtStr := url.ExpiresAt.Format(time.RFC3339)
-		infoResp.ExpiresAt = &expiresAtStr
+		expiresAt = formatTime(*url.ExpiresAt)
+	}
+	return []string{
+		url.ShortCode,
+		url.OriginalURL,
+		formatTime(url.CreatedAt),
+		expiresAt,
+		strconv.FormatInt(url.ClickCount, 10),
+	}
+}
+
+// parseListFilter reads the list filters from query parameters.
+// It returns an error response if a filter cannot be parsed.
+func parseListFilter(query url.Values) (repository.ListFilter, *ErrorResponse) {
+	filter := repository.ListFilter{
+		Search: strings.TrimSpace(query.Get("search")),
+		Tag:    strings.ToLower(strings.TrimSpace(query.Get("tag"))),
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		v := query.Get(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return repository.ListFilter{}, &ErrorResponse{
+				Error: param.name + " must be an RFC 3339 timestamp",
+				Code:  "INVALID_FILTER",
+			}
+		}
+		*param.dst = &t
+	}
+
+	switch status := repository.ListStatus(strings.ToLower(query.Get("status"))); status {
+	case repository.ListStatusAll, repository.ListStatusActive, repository.ListStatusExpired:
+		filter.Status = status
+	default:
+		return repository.ListFilter{}, &ErrorResponse{
+			Error: "status must be active or expired",
+			Code:  "INVALID_FILTER",
+		}
 	}
 
-	writeJSON(w, http.StatusOK, infoResp)
+	return filter, nil
+}
+
+// UpdateURL handles PATCH /api/v1/urls/:code requests.
+func (h *URLHandler) UpdateURL(w http.ResponseWriter, r *http.Request, shortCode string) {
+	var req UpdateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	ctx, span := tracer.Start(r.Context(), "URLHandler.UpdateURL", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	url, err := h.service.Update(ctx, shortCode, req.URL)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newURLInfoResponse(url))
+}
+
+// ExtendURL handles POST /api/v1/urls/:code/extend requests.
+func (h *URLHandler) ExtendURL(w http.ResponseWriter, r *http.Request, shortCode string) {
+	var req ExtendURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	var expiresAt time.Time
+	switch {
+	case req.ExpiresIn != "" && req.ExpiresAt != "":
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "expires_in and expires_at cannot be combined",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	case req.ExpiresIn != "":
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid expires_in duration format",
+				Code:  "INVALID_EXPIRES_IN",
+			})
+			return
+		}
+		expiresAt = time.Now().Add(d)
+	case req.ExpiresAt != "":
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: "expires_at must be an RFC 3339 timestamp",
+				Code:  "INVALID_EXPIRES_AT",
+			})
+			return
+		}
+		expiresAt = t
+	default:
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "expires_in or expires_at is required",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, span := tracer.Start(r.Context(), "URLHandler.ExtendURL", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	url, err := h.service.Extend(ctx, shortCode, expiresAt)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newURLInfoResponse(url))
+}
+
+// CreateAlias handles POST /api/v1/urls/:code/aliases requests.
+// The new code redirects to the same destination with its own click count.
+func (h *URLHandler) CreateAlias(w http.ResponseWriter, r *http.Request, shortCode string) {
+	var req CreateAliasRequest
+	if err := decodeStrict(r.Body, &req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if req.Alias == "" {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "alias is required",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, span := tracer.Start(h.shortDomainContext(r), "URLHandler.CreateAlias", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	resp, err := h.service.CreateAliasFor(ctx, shortCode, req.Alias)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newShortenResponse(resp))
 }
 
 // DeleteURL handles DELETE /api/v1/urls/:code requests.
+// URLs are soft-deleted and can be restored unless ?permanent=true is given.
 func (h *URLHandler) DeleteURL(w http.ResponseWriter, r *http.Request, shortCode string) {
-	err := h.service.Delete(r.Context(), shortCode)
+	permanent := false
+	if v := r.URL.Query().Get("permanent"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrorResponse{
+				Error: "permanent must be true or false",
+				Code:  "INVALID_REQUEST",
+			})
+			return
+		}
+		permanent = b
+	}
+
+	ctx, span := tracer.Start(r.Context(), "URLHandler.DeleteURL", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	var err error
+	if permanent {
+		err = h.service.DeletePermanent(ctx, shortCode)
+	} else {
+		err = h.service.Delete(ctx, shortCode)
+	}
 	if err != nil {
 		status, errResp := mapErrorToResponse(err)
-		writeJSON(w, status, errResp)
+		writeError(w, r, status, errResp)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// DeleteBatch handles POST /api/v1/urls/batch-delete requests.
+// The body is a JSON array of short codes, which are soft-deleted like
+// DeleteURL does. Results are reported per code, in request order.
+func (h *URLHandler) DeleteBatch(w http.ResponseWriter, r *http.Request) {
+	var shortCodes []string
+	if err := json.NewDecoder(r.Body).Decode(&shortCodes); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if len(shortCodes) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: "batch must contain at least one short code",
+			Code:  "EMPTY_BATCH",
+		})
+		return
+	}
+	if len(shortCodes) > MaxBatchSize {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("batch exceeds maximum size of %d", MaxBatchSize),
+			Code:  "BATCH_TOO_LARGE",
+		})
+		return
+	}
+
+	// Only non-empty codes are sent to the service
+	codes := make([]string, 0, len(shortCodes))
+	for _, shortCode := range shortCodes {
+		if shortCode != "" {
+			codes = append(codes, shortCode)
+		}
+	}
+
+	ctx, span := tracer.Start(r.Context(), "URLHandler.DeleteBatch")
+	defer span.End()
+
+	var errs map[string]error
+	if len(codes) > 0 {
+		errs = h.service.DeleteBatch(ctx, codes)
+	}
+
+	batchResp := BatchDeleteResponse{Results: make([]BatchDeleteItem, len(shortCodes))}
+	for i, shortCode := range shortCodes {
+		item := BatchDeleteItem{ShortCode: shortCode, Status: http.StatusOK}
+		switch err := errs[shortCode]; {
+		case shortCode == "":
+			item.Status = http.StatusBadRequest
+			item.Error = &ErrorResponse{Error: "short code is required", Code: "INVALID_SHORT_CODE"}
+		case err != nil:
+			status, errResp := mapErrorToResponse(err)
+			item.Status = status
+			item.Error = &errResp
+		}
+
+		if item.Error != nil {
+			batchResp.Failed++
+		} else {
+			batchResp.Succeeded++
+		}
+		batchResp.Results[i] = item
+	}
+
+	status := http.StatusOK
+	if batchResp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	writeJSON(w, status, batchResp)
+}
+
+// PurgeExpired handles POST /api/v1/admin/purge-expired requests.
+// Expired URLs are deleted immediately instead of on the reaper's next pass.
+func (h *URLHandler) PurgeExpired(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "URLHandler.PurgeExpired")
+	defer span.End()
+
+	deleted, err := h.service.PurgeExpired(ctx)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PurgeExpiredResponse{Deleted: deleted})
+}
+
+// RestoreURL handles POST /api/v1/urls/:code/restore requests.
+func (h *URLHandler) RestoreURL(w http.ResponseWriter, r *http.Request, shortCode string) {
+	ctx, span := tracer.Start(r.Context(), "URLHandler.RestoreURL", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	url, err := h.service.Restore(ctx, shortCode)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newURLInfoResponse(url))
+}
+
+// SetActive handles POST /api/v1/urls/:code/enable and
+// POST /api/v1/urls/:code/disable requests.
+func (h *URLHandler) SetActive(w http.ResponseWriter, r *http.Request, shortCode string, active bool) {
+	ctx, span := tracer.Start(r.Context(), "URLHandler.SetActive", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer span.End()
+
+	url, err := h.service.SetActive(ctx, shortCode, active)
+	if err != nil {
+		status, errResp := mapErrorToResponse(err)
+		writeError(w, r, status, errResp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newURLInfoResponse(url))
+}
+
+// validationError reports well-formed JSON that does not match the expected
+// request shape, such as an unknown field or a field of the wrong type.
+type validationError struct {
+	msg string
+}
+
+func (e *validationError) Error() string { return e.msg }
+
+// unknownFieldPrefix starts the error encoding/json returns for a field the
+// target does not have, when unknown fields are disallowed.
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeStrict decodes a single JSON value from body into v. Unlike
+// json.Unmarshal it rejects fields v does not have, so typos such as "urls"
+// are reported instead of silently ignored. Unknown fields and fields of the
+// wrong type are returned as a *validationError naming the field; other
+// errors mean the body is malformed.
+func decodeStrict(body io.Reader, v any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		// A body of the wrong type altogether stays a malformed request
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return &validationError{msg: fmt.Sprintf("%s must be %s, got %s", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value)}
+		}
+		// encoding/json has no error type for unknown fields, only this
+		// message, so it is matched by text. TestDecodeStrict_UnknownFieldMessage
+		// fails if a Go release rewords it.
+		if field, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix); ok {
+			return &validationError{msg: "unknown field " + field}
+		}
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// jsonTypeName describes the JSON value expected for a Go type.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// writeDecodeError writes the response for a request body that could not be decoded.
+// Bodies cut off by a size limit are reported as 413 rather than malformed, and
+// well-formed bodies of the wrong shape as 400 VALIDATION_ERROR.
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, r, http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error: "request body too large",
+			Code:  "BODY_TOO_LARGE",
+		})
+		return
+	}
+
+	var validationErr *validationError
+	if errors.As(err, &validationErr) {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{
+			Error: validationErr.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+		return
+	}
+
+	writeError(w, r, http.StatusBadRequest, ErrorResponse{
+		Error: "invalid request body",
+		Code:  "INVALID_REQUEST",
+	})
+}
+
+// toCreateURLRequest converts an API request into a service request.
+// It returns an error response if the request fields cannot be parsed.
+func toCreateURLRequest(req ShortenRequest) (services.CreateURLRequest, *ErrorResponse) {
+	var expiresIn *time.Duration
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			return services.CreateURLRequest{}, &ErrorResponse{
+				Error: "invalid expires_in duration format",
+				Code:  "INVALID_EXPIRES_IN",
+			}
+		}
+		expiresIn = &d
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return services.CreateURLRequest{}, &ErrorResponse{
+				Error: "expires_at must be an RFC 3339 timestamp",
+				Code:  "INVALID_EXPIRES_AT",
+			}
+		}
+		expiresAt = &t
+	}
+
+	return services.CreateURLRequest{
+		OriginalURL: req.URL,
+		ExpiresIn:   expiresIn,
+		ExpiresAt:   expiresAt,
+		CustomAlias: req.CustomAlias,
+		Permanent:   req.Permanent,
+		Password:    req.Password,
+		MaxClicks:   req.MaxClicks,
+		ShowPreview: req.ShowPreview,
+
+		AppendParams:    req.AppendParams,
+		PlatformTargets: req.PlatformTargets,
+		Tags:            req.Tags,
+	}, nil
+}
+
+// formatTime formats t as an RFC 3339 timestamp in UTC, the form every time
+// in an API response takes.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatOptionalTime formats t like formatTime. It returns nil for a nil t,
+// so that optional times are omitted from responses rather than null.
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := formatTime(*t)
+	return &formatted
+}
+
+// newShortenResponse builds the API representation of a created URL.
+func newShortenResponse(resp *services.CreateURLResponse) ShortenResponse {
+	return ShortenResponse{
+		ShortURL:    resp.ShortURL,
+		ShortCode:   resp.ShortCode,
+		OriginalURL: resp.OriginalURL,
+		CreatedAt:   formatTime(resp.CreatedAt),
+		ExpiresAt:   formatOptionalTime(resp.ExpiresAt),
+		Permanent:   resp.Permanent,
+		MaxClicks:   resp.MaxClicks,
+		ShowPreview: resp.ShowPreview,
+
+		AppendParams:      resp.AppendParams,
+		PlatformTargets:   resp.PlatformTargets,
+		Tags:              resp.Tags,
+		PasswordProtected: resp.PasswordProtected,
+	}
+}
+
+// newURLInfoResponse builds the API representation of a stored URL.
+func newURLInfoResponse(url *models.URL) URLInfoResponse {
+	infoResp := URLInfoResponse{
+		ShortCode:   url.ShortCode,
+		OriginalURL: url.OriginalURL,
+		CreatedAt:   formatTime(url.CreatedAt),
+		ExpiresAt:   formatOptionalTime(url.ExpiresAt),
+		ClickCount:  url.ClickCount,
+		Permanent:   url.Permanent,
+		MaxClicks:   url.MaxClicks,
+		ShowPreview: url.ShowPreview,
+
+		AppendParams:      url.AppendParams,
+		PlatformTargets:   url.PlatformTargets,
+		Tags:              url.Tags,
+		PasswordProtected: url.IsPasswordProtected(),
+		Active:            !url.Disabled,
+	}
+	if url.LinkCheckedAt != nil {
+		infoResp.LinkStatus = url.LinkStatus
+		infoResp.LinkCheckedAt = formatOptionalTime(url.LinkCheckedAt)
+		infoResp.LinkBroken = url.IsLinkBroken()
+	}
+	return infoResp
+}
+
+// newPublicURLInfoResponse converts a URL to the URLInfoResponse anyone may
+// read. Password-protected URLs leave out where they lead, which only the
+// password unlocks.
+func newPublicURLInfoResponse(url *models.URL) URLInfoResponse {
+	infoResp := newURLInfoResponse(url)
+	if url.IsPasswordProtected() {
+		infoResp.OriginalURL = ""
+		infoResp.AppendParams = nil
+		infoResp.PlatformTargets = nil
+	}
+	return infoResp
+}
+
 // mapErrorToResponse maps service errors to HTTP status codes and error responses.
 func mapErrorToResponse(err error) (int, ErrorResponse) {
 	switch {
@@ -154,6 +1130,11 @@ func mapErrorToResponse(err error) (int, ErrorResponse) {
 			Error: err.Error(),
 			Code:  "INVALID_URL",
 		}
+	case errors.Is(err, models.ErrShortCodeTooLong):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "SHORT_CODE_TOO_LONG",
+		}
 	case errors.Is(err, models.ErrURLNotFound):
 		return http.StatusNotFound, ErrorResponse{
 			Error: err.Error(),
@@ -164,6 +1145,16 @@ func mapErrorToResponse(err error) (int, ErrorResponse) {
 			Error: err.Error(),
 			Code:  "EXPIRED",
 		}
+	case errors.Is(err, models.ErrURLExhausted):
+		return http.StatusGone, ErrorResponse{
+			Error: err.Error(),
+			Code:  "EXHAUSTED",
+		}
+	case errors.Is(err, models.ErrURLDisabled):
+		return http.StatusForbidden, ErrorResponse{
+			Error: err.Error(),
+			Code:  "DISABLED",
+		}
 	case errors.Is(err, idgen.ErrMaxRetriesExceeded):
 		return http.StatusServiceUnavailable, ErrorResponse{
 			Error: "service temporarily unavailable",
@@ -184,11 +1175,117 @@ func mapErrorToResponse(err error) (int, ErrorResponse) {
 			Error: err.Error(),
 			Code:  "BLOCKED_HOST",
 		}
+	case errors.Is(err, services.ErrHostNotAllowed):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "HOST_NOT_ALLOWED",
+		}
 	case errors.Is(err, services.ErrURLTooLong):
 		return http.StatusBadRequest, ErrorResponse{
 			Error: err.Error(),
 			Code:  "URL_TOO_LONG",
 		}
+	case errors.Is(err, services.ErrInvalidAlias), errors.Is(err, services.ErrAliasLength):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_ALIAS",
+		}
+	case errors.Is(err, services.ErrPasswordTooLong):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_PASSWORD",
+		}
+	case errors.Is(err, services.ErrExpiresAtInPast):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_EXPIRES_AT",
+		}
+	case errors.Is(err, services.ErrExpiryTooLong):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "EXPIRY_TOO_LONG",
+		}
+	case errors.Is(err, services.ErrInvalidMaxClicks):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_MAX_CLICKS",
+		}
+	case errors.Is(err, services.ErrInvalidAppendParams):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_APPEND_PARAMS",
+		}
+	case errors.Is(err, services.ErrInvalidPlatform):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_PLATFORM",
+		}
+	case errors.Is(err, services.ErrInvalidTag):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_TAG",
+		}
+	case errors.Is(err, services.ErrTooManyTags):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "TOO_MANY_TAGS",
+		}
+	case errors.Is(err, services.ErrInvalidPagination):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_PAGINATION",
+		}
+	case errors.Is(err, services.ErrInvalidCursor):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_CURSOR",
+		}
+	case errors.Is(err, services.ErrInvalidInterval):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_INTERVAL",
+		}
+	case errors.Is(err, services.ErrInvalidTimeRange):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_TIME_RANGE",
+		}
+	case errors.Is(err, services.ErrInvalidTopOrder):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_SORT",
+		}
+	case errors.Is(err, services.ErrPasswordRequired):
+		return http.StatusUnauthorized, ErrorResponse{
+			Error: err.Error(),
+			Code:  "PASSWORD_REQUIRED",
+		}
+	case errors.Is(err, services.ErrInvalidPassword):
+		return http.StatusUnauthorized, ErrorResponse{
+			Error: err.Error(),
+			Code:  "WRONG_PASSWORD",
+		}
+	case errors.Is(err, services.ErrReservedCode):
+		return http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  "RESERVED_CODE",
+		}
+	case errors.Is(err, services.ErrAliasTaken):
+		return http.StatusConflict, ErrorResponse{
+			Error: err.Error(),
+			Code:  "ALIAS_TAKEN",
+		}
+	case errors.Is(err, services.ErrAliasClickLimited):
+		return http.StatusConflict, ErrorResponse{
+			Error: err.Error(),
+			Code:  "CLICK_LIMITED",
+		}
+	case errors.Is(err, services.ErrTimeSeriesUnavailable), errors.Is(err, services.ErrSourcesUnavailable),
+		errors.Is(err, services.ErrGeoUnavailable):
+		return http.StatusServiceUnavailable, ErrorResponse{
+			Error: err.Error(),
+			Code:  "SERVICE_UNAVAILABLE",
+		}
 	default:
 		return http.StatusInternalServerError, ErrorResponse{
 			Error: "internal server error",
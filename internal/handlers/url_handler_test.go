@@ -3,10 +3,14 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,7 +19,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/emadnahed/FastGoLink/internal/idgen"
+	"github.com/emadnahed/FastGoLink/internal/middleware"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/services"
 )
 
@@ -32,6 +38,19 @@ func (m *MockURLService) Create(ctx context.Context, req services.CreateURLReque
 	return args.Get(0).(*services.CreateURLResponse), args.Error(1)
 }
 
+func (m *MockURLService) CreateBatch(ctx context.Context, reqs []services.CreateURLRequest) ([]services.CreateURLResponse, []error) {
+	args := m.Called(ctx, reqs)
+	return args.Get(0).([]services.CreateURLResponse), args.Get(1).([]error)
+}
+
+func (m *MockURLService) CreateAliasFor(ctx context.Context, existingCode, newAlias string) (*services.CreateURLResponse, error) {
+	args := m.Called(ctx, existingCode, newAlias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CreateURLResponse), args.Error(1)
+}
+
 func (m *MockURLService) Get(ctx context.Context, shortCode string) (*models.URL, error) {
 	args := m.Called(ctx, shortCode)
 	if args.Get(0) == nil {
@@ -45,6 +64,81 @@ func (m *MockURLService) Delete(ctx context.Context, shortCode string) error {
 	return args.Error(0)
 }
 
+func (m *MockURLService) Restore(ctx context.Context, shortCode string) (*models.URL, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+func (m *MockURLService) DeleteBatch(ctx context.Context, shortCodes []string) map[string]error {
+	args := m.Called(ctx, shortCodes)
+	return args.Get(0).(map[string]error)
+}
+
+func (m *MockURLService) DeletePermanent(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+func (m *MockURLService) PurgeExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLService) Update(ctx context.Context, shortCode, newURL string) (*models.URL, error) {
+	args := m.Called(ctx, shortCode, newURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+func (m *MockURLService) List(ctx context.Context, limit, offset int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, limit, offset, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockURLService) ListAfter(ctx context.Context, afterID int64, limit int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, afterID, limit, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockURLService) Extend(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	args := m.Called(ctx, shortCode, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+func (m *MockURLService) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	args := m.Called(ctx, shortCode, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+// Export calls fn for each URL passed to Return, then returns the mocked error.
+func (m *MockURLService) Export(ctx context.Context, fn func(*models.URL) error) error {
+	args := m.Called(ctx)
+	urls, _ := args.Get(0).([]*models.URL)
+	for _, url := range urls {
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func TestURLHandler_Shorten(t *testing.T) {
 	now := time.Now()
 	futureTime := now.Add(24 * time.Hour)
@@ -86,6 +180,153 @@ func TestURLHandler_Shorten(t *testing.T) {
 				assert.Nil(t, resp.ExpiresAt)
 			},
 		},
+		{
+			name:   "POST with password creates protected URL",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:      "https://example.com/secret",
+				Password: "s3cret",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.Password == "s3cret"
+				})).Return(&services.CreateURLResponse{
+					ShortURL:          "http://localhost:8080/lock123",
+					ShortCode:         "lock123",
+					OriginalURL:       "https://example.com/secret",
+					CreatedAt:         now,
+					PasswordProtected: true,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.NotContains(t, rec.Body.String(), "s3cret")
+				var resp ShortenResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.True(t, resp.PasswordProtected)
+			},
+		},
+		{
+			name:   "POST with overlong password returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:      "https://example.com/secret",
+				Password: strings.Repeat("a", 73),
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrPasswordTooLong)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_PASSWORD", resp.Code)
+			},
+		},
+		{
+			name:   "POST with non-positive max_clicks returns 400",
+			method: http.MethodPost,
+			body:   map[string]interface{}{"url": "https://example.com", "max_clicks": 0},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.MaxClicks != nil && *req.MaxClicks == 0
+				})).Return(nil, services.ErrInvalidMaxClicks)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_MAX_CLICKS", resp.Code)
+			},
+		},
+		{
+			name:   "POST with expiry beyond the max returns 400",
+			method: http.MethodPost,
+			body:   map[string]interface{}{"url": "https://example.com", "expires_in": "8760h"},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrExpiryTooLong)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "EXPIRY_TOO_LONG", resp.Code)
+			},
+		},
+		{
+			name:   "POST with append_params passes them through",
+			method: http.MethodPost,
+			body: map[string]interface{}{
+				"url":           "https://example.com",
+				"append_params": map[string]string{"utm_source": "newsletter"},
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.AppendParams["utm_source"] == "newsletter"
+				})).Return(&services.CreateURLResponse{
+					ShortURL:     "http://localhost:8080/utm1234",
+					ShortCode:    "utm1234",
+					OriginalURL:  "https://example.com",
+					CreatedAt:    time.Now(),
+					AppendParams: map[string]string{"utm_source": "newsletter"},
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ShortenResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, map[string]string{"utm_source": "newsletter"}, resp.AppendParams)
+			},
+		},
+		{
+			name:   "POST with empty append_params name returns 400",
+			method: http.MethodPost,
+			body:   map[string]interface{}{"url": "https://example.com", "append_params": map[string]string{"": "x"}},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidAppendParams)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_APPEND_PARAMS", resp.Code)
+			},
+		},
+		{
+			name:   "POST with unknown platform target returns 400",
+			method: http.MethodPost,
+			body: map[string]interface{}{
+				"url":              "https://example.com",
+				"platform_targets": map[string]string{"windows": "https://example.com/win"},
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.PlatformTargets["windows"] == "https://example.com/win"
+				})).Return(nil, services.ErrInvalidPlatform)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_PLATFORM", resp.Code)
+			},
+		},
+		{
+			name:   "POST with too many tags returns 400",
+			method: http.MethodPost,
+			body:   map[string]interface{}{"url": "https://example.com", "tags": []string{"a", "b"}},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return len(req.Tags) == 2
+				})).Return(nil, services.ErrTooManyTags)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "TOO_MANY_TAGS", resp.Code)
+			},
+		},
 		{
 			name:   "POST with expires_in creates expiring URL",
 			method: http.MethodPost,
@@ -139,6 +380,58 @@ func TestURLHandler_Shorten(t *testing.T) {
 				err := json.Unmarshal(rec.Body.Bytes(), &resp)
 				require.NoError(t, err)
 				assert.Contains(t, resp.Error, "invalid")
+				assert.Equal(t, "INVALID_REQUEST", resp.Code)
+			},
+		},
+		{
+			name:           "POST with unknown field returns validation error",
+			method:         http.MethodPost,
+			body:           `{"urls":"https://example.com"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "VALIDATION_ERROR", resp.Code)
+				assert.Equal(t, `unknown field "urls"`, resp.Error)
+			},
+		},
+		{
+			name:           "POST with wrong field type returns validation error",
+			method:         http.MethodPost,
+			body:           `{"url":"https://example.com","max_clicks":"10"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "VALIDATION_ERROR", resp.Code)
+				assert.Equal(t, "max_clicks must be an integer, got string", resp.Error)
+			},
+		},
+		{
+			name:           "POST with wrong nested type returns validation error",
+			method:         http.MethodPost,
+			body:           `{"url":"https://example.com","tags":"promo"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "VALIDATION_ERROR", resp.Code)
+				assert.Equal(t, "tags must be an array, got string", resp.Error)
+			},
+		},
+		{
+			name:           "POST with trailing data returns 400",
+			method:         http.MethodPost,
+			body:           `{"url":"https://example.com"} {}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_REQUEST", resp.Code)
 			},
 		},
 		{
@@ -175,6 +468,23 @@ func TestURLHandler_Shorten(t *testing.T) {
 				assert.Equal(t, "INVALID_URL", resp.Code)
 			},
 		},
+		{
+			name:   "POST with short code longer than the column returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL: "https://example.com/path",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: 11 characters, max 10", models.ErrShortCodeTooLong))
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "SHORT_CODE_TOO_LONG", resp.Code)
+			},
+		},
 		{
 			name:   "POST with invalid expires_in returns 400",
 			method: http.MethodPost,
@@ -191,6 +501,65 @@ func TestURLHandler_Shorten(t *testing.T) {
 				assert.Equal(t, "INVALID_EXPIRES_IN", resp.Code)
 			},
 		},
+		{
+			name:   "POST with expires_at passes the timestamp",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:       "https://example.com/path",
+				ExpiresAt: "2099-06-01T09:00:00+02:00",
+			},
+			setupMock: func(svc *MockURLService) {
+				want := time.Date(2099, 6, 1, 7, 0, 0, 0, time.UTC)
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.ExpiresAt != nil && req.ExpiresAt.Equal(want)
+				})).Return(&services.CreateURLResponse{
+					ShortURL:    "http://localhost:8080/cal1234",
+					ShortCode:   "cal1234",
+					OriginalURL: "https://example.com/path",
+					CreatedAt:   now,
+					ExpiresAt:   &want,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ShortenResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotNil(t, resp.ExpiresAt)
+				assert.Equal(t, "2099-06-01T07:00:00Z", *resp.ExpiresAt)
+			},
+		},
+		{
+			name:   "POST with malformed expires_at returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:       "https://example.com/path",
+				ExpiresAt: "next tuesday",
+			},
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_EXPIRES_AT", resp.Code)
+			},
+		},
+		{
+			name:   "POST with past expires_at returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:       "https://example.com/path",
+				ExpiresAt: "2000-01-01T00:00:00Z",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrExpiresAtInPast)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_EXPIRES_AT", resp.Code)
+			},
+		},
 		{
 			name:   "service error returns 500",
 			method: http.MethodPost,
@@ -276,6 +645,23 @@ func TestURLHandler_Shorten(t *testing.T) {
 				assert.Equal(t, "BLOCKED_HOST", resp.Code)
 			},
 		},
+		{
+			name:   "host not allowed returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL: "https://unlisted.com/path",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrHostNotAllowed)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "HOST_NOT_ALLOWED", resp.Code)
+			},
+		},
 		{
 			name:   "URL too long returns 400",
 			method: http.MethodPost,
@@ -293,24 +679,130 @@ func TestURLHandler_Shorten(t *testing.T) {
 				assert.Equal(t, "URL_TOO_LONG", resp.Code)
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockSvc := new(MockURLService)
-			tt.setupMock(mockSvc)
-
-			handler := NewURLHandler(mockSvc)
-
-			var body []byte
-			var err error
-			if tt.body != nil {
-				switch v := tt.body.(type) {
-				case string:
-					body = []byte(v)
-				default:
-					body, err = json.Marshal(tt.body)
-					require.NoError(t, err)
+		{
+			name:   "POST with custom alias passes alias to service",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:         "https://example.com/sale",
+				CustomAlias: "summer",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.CustomAlias == "summer"
+				})).Return(&services.CreateURLResponse{
+					ShortURL:    "http://localhost:8080/summer",
+					ShortCode:   "summer",
+					OriginalURL: "https://example.com/sale",
+					CreatedAt:   now,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ShortenResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "summer", resp.ShortCode)
+			},
+		},
+		{
+			name:   "taken alias returns 409",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:         "https://example.com/sale",
+				CustomAlias: "summer",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrAliasTaken)
+			},
+			expectedStatus: http.StatusConflict,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "ALIAS_TAKEN", resp.Code)
+			},
+		},
+		{
+			name:   "reserved alias returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:         "https://example.com/sale",
+				CustomAlias: "health",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrReservedCode)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "RESERVED_CODE", resp.Code)
+			},
+		},
+		{
+			name:   "POST with permanent flag requests a 301 redirect",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:       "https://example.com/landing",
+				Permanent: true,
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.MatchedBy(func(req services.CreateURLRequest) bool {
+					return req.OriginalURL == "https://example.com/landing" && req.Permanent
+				})).Return(&services.CreateURLResponse{
+					ShortURL:    "http://localhost:8080/perm123",
+					ShortCode:   "perm123",
+					OriginalURL: "https://example.com/landing",
+					CreatedAt:   now,
+					Permanent:   true,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ShortenResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "perm123", resp.ShortCode)
+				assert.True(t, resp.Permanent)
+			},
+		},
+		{
+			name:   "invalid alias returns 400",
+			method: http.MethodPost,
+			body: ShortenRequest{
+				URL:         "https://example.com/sale",
+				CustomAlias: "bad alias!",
+			},
+			setupMock: func(svc *MockURLService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidAlias)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "INVALID_ALIAS", resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockURLService)
+			tt.setupMock(mockSvc)
+
+			handler := NewURLHandler(mockSvc)
+
+			var body []byte
+			var err error
+			if tt.body != nil {
+				switch v := tt.body.(type) {
+				case string:
+					body = []byte(v)
+				default:
+					body, err = json.Marshal(tt.body)
+					require.NoError(t, err)
 				}
 			}
 
@@ -329,6 +821,173 @@ func TestURLHandler_Shorten(t *testing.T) {
 	}
 }
 
+func TestURLHandler_Shorten_ShortDomains(t *testing.T) {
+	newHandler := func(wantBaseURL string) (*URLHandler, *MockURLService) {
+		svc := new(MockURLService)
+		svc.On("Create", mock.MatchedBy(func(ctx context.Context) bool {
+			baseURL, _ := services.BaseURLFromContext(ctx)
+			return baseURL == wantBaseURL
+		}), mock.Anything).Return(&services.CreateURLResponse{
+			ShortCode: "abc1234",
+			CreatedAt: time.Now(),
+		}, nil)
+		h := NewURLHandler(svc)
+		h.SetShortDomains("https://sho.rt/s", []string{"go.example.com", "links.example.org:8443"})
+		return h, svc
+	}
+
+	tests := []struct {
+		name        string
+		host        string
+		header      string
+		wantBaseURL string
+	}{
+		{"allowed Host", "go.example.com", "", "https://go.example.com/s"},
+		{"Host is matched case-insensitively", "Go.Example.com", "", "https://go.example.com/s"},
+		{"allowed host with port", "links.example.org:8443", "", "https://links.example.org:8443/s"},
+		{"header takes precedence over Host", "go.example.com", "links.example.org:8443", "https://links.example.org:8443/s"},
+		{"unknown Host keeps the configured base URL", "evil.example.net", "", ""},
+		{"unknown header keeps the configured base URL", "go.example.com", "evil.example.net", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, svc := newHandler(tt.wantBaseURL)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", strings.NewReader(`{"url":"https://example.com"}`))
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(ShortDomainHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			h.Shorten(rec, req)
+
+			require.Equal(t, http.StatusCreated, rec.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_Shorten_BodyTooLarge(t *testing.T) {
+	mockSvc := new(MockURLService)
+	handler := NewURLHandler(mockSvc)
+
+	body := `{"url":"https://example.com/` + string(bytes.Repeat([]byte("a"), 100)) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(rec, req.Body, 32)
+
+	handler.Shorten(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	var resp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "BODY_TOO_LARGE", resp.Code)
+
+	mockSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestURLHandler_ShortenBatch(t *testing.T) {
+	now := time.Now()
+
+	t.Run("all entries succeed returns 201", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("CreateBatch", mock.Anything, mock.MatchedBy(func(reqs []services.CreateURLRequest) bool {
+			return len(reqs) == 2
+		})).Return([]services.CreateURLResponse{
+			{ShortURL: "http://localhost:8080/aaa1111", ShortCode: "aaa1111", OriginalURL: "https://example.com/a", CreatedAt: now},
+			{ShortURL: "http://localhost:8080/bbb2222", ShortCode: "bbb2222", OriginalURL: "https://example.com/b", CreatedAt: now},
+		}, []error{nil, nil})
+
+		handler := NewURLHandler(mockSvc)
+		body := `[{"url":"https://example.com/a"},{"url":"https://example.com/b"}]`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten/batch", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+
+		handler.ShortenBatch(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp BatchShortenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 0, resp.Failed)
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, "aaa1111", resp.Results[0].Result.ShortCode)
+		assert.Equal(t, "bbb2222", resp.Results[1].Result.ShortCode)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("partial failure returns 207 with per-item errors", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("CreateBatch", mock.Anything, mock.MatchedBy(func(reqs []services.CreateURLRequest) bool {
+			// The entry with an invalid duration never reaches the service
+			return len(reqs) == 2 && reqs[0].OriginalURL == "https://example.com/a" && reqs[1].OriginalURL == "not-a-url"
+		})).Return([]services.CreateURLResponse{
+			{ShortURL: "http://localhost:8080/aaa1111", ShortCode: "aaa1111", OriginalURL: "https://example.com/a", CreatedAt: now},
+			{},
+		}, []error{nil, models.ErrInvalidURL})
+
+		handler := NewURLHandler(mockSvc)
+		body := `[{"url":"https://example.com/a"},{"url":"https://example.com/b","expires_in":"soon"},{"url":"not-a-url"}]`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten/batch", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+
+		handler.ShortenBatch(rec, req)
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		var resp BatchShortenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+		require.Len(t, resp.Results, 3)
+
+		assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+		assert.Equal(t, 1, resp.Results[1].Index)
+		assert.Equal(t, "INVALID_EXPIRES_IN", resp.Results[1].Error.Code)
+		assert.Equal(t, 2, resp.Results[2].Index)
+		assert.Equal(t, http.StatusBadRequest, resp.Results[2].Status)
+		assert.Equal(t, "INVALID_URL", resp.Results[2].Error.Code)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("rejects empty and oversized batches", func(t *testing.T) {
+		handler := NewURLHandler(new(MockURLService))
+
+		rec := httptest.NewRecorder()
+		handler.ShortenBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shorten/batch", bytes.NewReader([]byte(`[]`))))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "EMPTY_BATCH")
+
+		items := make([]ShortenRequest, MaxBatchSize+1)
+		body, err := json.Marshal(items)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		handler.ShortenBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shorten/batch", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "BATCH_TOO_LARGE")
+	})
+
+	t.Run("non-array body returns 400", func(t *testing.T) {
+		handler := NewURLHandler(new(MockURLService))
+
+		rec := httptest.NewRecorder()
+		handler.ShortenBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shorten/batch", bytes.NewReader([]byte(`{"url":"https://example.com"}`))))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "INVALID_REQUEST")
+	})
+
+	t.Run("unknown item field returns validation error", func(t *testing.T) {
+		handler := NewURLHandler(new(MockURLService))
+
+		rec := httptest.NewRecorder()
+		handler.ShortenBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shorten/batch", bytes.NewReader([]byte(`[{"url":"https://example.com","alias":"promo"}]`))))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "VALIDATION_ERROR")
+		assert.Contains(t, rec.Body.String(), `unknown field \"alias\"`)
+	})
+}
+
 func TestURLHandler_GetURL(t *testing.T) {
 	now := time.Now()
 	futureTime := now.Add(24 * time.Hour)
@@ -364,6 +1023,64 @@ func TestURLHandler_GetURL(t *testing.T) {
 				assert.NotNil(t, resp.ExpiresAt)
 			},
 		},
+		{
+			name:      "GET protected code reports protection without hash",
+			shortCode: "lock123",
+			setupMock: func(svc *MockURLService) {
+				svc.On("Get", mock.Anything, "lock123").Return(&models.URL{
+					ID:           2,
+					ShortCode:    "lock123",
+					OriginalURL:  "https://example.com/secret",
+					CreatedAt:    now,
+					PasswordHash: "$2a$10$abcdefghijklmnopqrstuv",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.NotContains(t, rec.Body.String(), "$2a$")
+				var resp URLInfoResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.True(t, resp.PasswordProtected)
+			},
+		},
+		{
+			name:      "GET exhausted code returns 410",
+			shortCode: "used123",
+			setupMock: func(svc *MockURLService) {
+				svc.On("Get", mock.Anything, "used123").Return(nil, models.ErrURLExhausted)
+			},
+			expectedStatus: http.StatusGone,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "EXHAUSTED", resp.Code)
+			},
+		},
+		{
+			name:      "GET checked code includes link status",
+			shortCode: "dead123",
+			setupMock: func(svc *MockURLService) {
+				status := http.StatusNotFound
+				checkedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+				svc.On("Get", mock.Anything, "dead123").Return(&models.URL{
+					ShortCode:     "dead123",
+					OriginalURL:   "https://example.com/gone",
+					CreatedAt:     checkedAt.Add(-time.Hour),
+					LinkStatus:    &status,
+					LinkCheckedAt: &checkedAt,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp URLInfoResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotNil(t, resp.LinkStatus)
+				assert.Equal(t, http.StatusNotFound, *resp.LinkStatus)
+				require.NotNil(t, resp.LinkCheckedAt)
+				assert.Equal(t, "2024-06-01T12:00:00Z", *resp.LinkCheckedAt)
+				assert.True(t, resp.LinkBroken)
+			},
+		},
 		{
 			name:      "GET non-existent code returns 404",
 			shortCode: "notfound",
@@ -429,30 +1146,268 @@ func TestURLHandler_GetURL(t *testing.T) {
 	}
 }
 
-func TestURLHandler_DeleteURL(t *testing.T) {
+func TestURLHandler_GetURL_ProblemDetails(t *testing.T) {
+	mockSvc := new(MockURLService)
+	mockSvc.On("Get", mock.Anything, "missing").Return(nil, models.ErrURLNotFound)
+	handler := middleware.ErrorFormatter(middleware.ErrorFormatProblem)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewURLHandler(mockSvc).GetURL(w, r, "missing")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	var problem middleware.ProblemDetails
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "url not found", problem.Detail)
+	assert.Equal(t, "NOT_FOUND", problem.Code)
+}
+
+func TestURLHandler_GetURL_PasswordProtected(t *testing.T) {
+	mockSvc := new(MockURLService)
+	mockSvc.On("Get", mock.Anything, "secret1").Return(&models.URL{
+		ShortCode:       "secret1",
+		OriginalURL:     "https://example.com/private",
+		CreatedAt:       time.Now(),
+		PasswordHash:    "$2a$10$hash",
+		AppendParams:    map[string]string{"ref": "private"},
+		PlatformTargets: map[string]string{"ios": "https://apps.example.com/private"},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	NewURLHandler(mockSvc).GetURL(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/secret1", nil), "secret1")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "private")
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, true, resp["password_protected"])
+	assert.NotContains(t, resp, "original_url")
+	assert.NotContains(t, resp, "append_params")
+	assert.NotContains(t, resp, "platform_targets")
+}
+
+func TestURLHandler_GetURL_CacheHeader(t *testing.T) {
+	handler := NewURLHandler(services.NewURLService(newCachedStubRepository(t), nil, "http://localhost:8080"))
+
+	get := func(shortCode string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.GetURL(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/"+shortCode, nil), shortCode)
+		return rec
+	}
+
+	rec := get("abc1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get(CacheHeader))
+
+	rec = get("abc1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get(CacheHeader))
+
+	rec = get("missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get(CacheHeader))
+}
+
+func TestURLHandler_GetURL_Conditional(t *testing.T) {
+	updatedAt := time.Date(2026, 3, 1, 12, 30, 45, 500, time.UTC)
+	stored := &models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/path",
+		CreatedAt:   updatedAt.Add(-time.Hour),
+		ClickCount:  7,
+		UpdatedAt:   updatedAt,
+	}
+
+	get := func(t *testing.T, url *models.URL, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		mockSvc := new(MockURLService)
+		mockSvc.On("Get", mock.Anything, "abc1234").Return(url, nil)
+		handler := NewURLHandler(mockSvc)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc1234", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.GetURL(rec, req, "abc1234")
+		return rec
+	}
+
+	first := get(t, stored, nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "Sun, 01 Mar 2026 12:30:45 GMT", first.Header().Get("Last-Modified"))
+	assert.Equal(t, "no-cache", first.Header().Get("Cache-Control"))
+
+	t.Run("matching ETag returns 304", func(t *testing.T) {
+		rec := get(t, stored, http.Header{"If-None-Match": {`"other", ` + etag}})
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("weak comparison matches", func(t *testing.T) {
+		rec := get(t, stored, http.Header{"If-None-Match": {"W/" + etag}})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("changed URL gets a new ETag", func(t *testing.T) {
+		clicked := *stored
+		clicked.ClickCount++
+		rec := get(t, &clicked, http.Header{"If-None-Match": {etag}})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+		var resp URLInfoResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, int64(8), resp.ClickCount)
+	})
+
+	t.Run("not modified since returns 304", func(t *testing.T) {
+		rec := get(t, stored, http.Header{"If-Modified-Since": {"Sun, 01 Mar 2026 12:30:45 GMT"}})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("modified since returns 200", func(t *testing.T) {
+		rec := get(t, stored, http.Header{"If-Modified-Since": {"Sun, 01 Mar 2026 12:30:44 GMT"}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("If-None-Match takes precedence over If-Modified-Since", func(t *testing.T) {
+		rec := get(t, stored, http.Header{
+			"If-None-Match":     {`"stale"`},
+			"If-Modified-Since": {"Sun, 01 Mar 2026 12:30:45 GMT"},
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("unknown modification time omits Last-Modified", func(t *testing.T) {
+		untracked := *stored
+		untracked.UpdatedAt = time.Time{}
+		rec := get(t, &untracked, http.Header{"If-Modified-Since": {"Sun, 01 Mar 2026 12:30:45 GMT"}})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Last-Modified"))
+	})
+}
+
+func TestURLResponses_JSON(t *testing.T) {
+	// Times outside UTC are converted, so clients always see the same form
+	plus2 := time.FixedZone("UTC+2", 2*60*60)
+	createdAt := time.Date(2024, 1, 15, 12, 30, 0, 500, plus2)
+	expiresAt := time.Date(2024, 1, 16, 12, 30, 0, 0, plus2)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		shorten   string
+		info      string
+	}{
+		{
+			name:      "expiring URL",
+			expiresAt: &expiresAt,
+			shorten: `{"short_url":"http://localhost:8080/abc1234","short_code":"abc1234","original_url":"https://example.com",
+				"created_at":"2024-01-15T10:30:00Z","expires_at":"2024-01-16T10:30:00Z","permanent":false,"show_preview":false,"password_protected":false}`,
+			info: `{"short_code":"abc1234","original_url":"https://example.com","created_at":"2024-01-15T10:30:00Z",
+				"expires_at":"2024-01-16T10:30:00Z","click_count":3,"permanent":false,"show_preview":false,"password_protected":false,"active":true}`,
+		},
+		{
+			name: "non-expiring URL omits expires_at",
+			shorten: `{"short_url":"http://localhost:8080/abc1234","short_code":"abc1234","original_url":"https://example.com",
+				"created_at":"2024-01-15T10:30:00Z","permanent":false,"show_preview":false,"password_protected":false}`,
+			info: `{"short_code":"abc1234","original_url":"https://example.com","created_at":"2024-01-15T10:30:00Z",
+				"click_count":3,"permanent":false,"show_preview":false,"password_protected":false,"active":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shorten, err := json.Marshal(newShortenResponse(&services.CreateURLResponse{
+				ShortURL:    "http://localhost:8080/abc1234",
+				ShortCode:   "abc1234",
+				OriginalURL: "https://example.com",
+				CreatedAt:   createdAt,
+				ExpiresAt:   tt.expiresAt,
+			}))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.shorten, string(shorten))
+
+			info, err := json.Marshal(newURLInfoResponse(&models.URL{
+				ShortCode:   "abc1234",
+				OriginalURL: "https://example.com",
+				CreatedAt:   createdAt,
+				ExpiresAt:   tt.expiresAt,
+				ClickCount:  3,
+			}))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.info, string(info))
+		})
+	}
+
+	t.Run("checked link reports the check time in UTC", func(t *testing.T) {
+		status := http.StatusNotFound
+		checkedAt := time.Date(2024, 1, 15, 14, 0, 0, 0, plus2)
+		info, err := json.Marshal(newURLInfoResponse(&models.URL{
+			ShortCode:     "abc1234",
+			OriginalURL:   "https://example.com",
+			CreatedAt:     createdAt,
+			LinkStatus:    &status,
+			LinkCheckedAt: &checkedAt,
+		}))
+		require.NoError(t, err)
+		assert.Contains(t, string(info), `"link_checked_at":"2024-01-15T12:00:00Z"`)
+		assert.NotContains(t, string(info), `"expires_at"`)
+	})
+}
+
+func TestURLHandler_UpdateURL(t *testing.T) {
+	now := time.Now()
+
 	tests := []struct {
 		name           string
 		shortCode      string
+		body           string
 		setupMock      func(*MockURLService)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
 		{
-			name:      "DELETE existing code returns 204 No Content",
+			name:      "PATCH existing code returns updated URL",
 			shortCode: "abc1234",
+			body:      `{"url":"https://example.com/new"}`,
 			setupMock: func(svc *MockURLService) {
-				svc.On("Delete", mock.Anything, "abc1234").Return(nil)
+				svc.On("Update", mock.Anything, "abc1234", "https://example.com/new").Return(&models.URL{
+					ID:          1,
+					ShortCode:   "abc1234",
+					OriginalURL: "https://example.com/new",
+					CreatedAt:   now,
+					ClickCount:  7,
+				}, nil)
 			},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
-				assert.Empty(t, rec.Body.Bytes())
+				var resp URLInfoResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "abc1234", resp.ShortCode)
+				assert.Equal(t, "https://example.com/new", resp.OriginalURL)
+				assert.Equal(t, int64(7), resp.ClickCount)
 			},
 		},
 		{
-			name:      "DELETE non-existent code returns 404",
+			name:      "PATCH non-existent code returns 404",
 			shortCode: "notfound",
+			body:      `{"url":"https://example.com/new"}`,
 			setupMock: func(svc *MockURLService) {
-				svc.On("Delete", mock.Anything, "notfound").Return(models.ErrURLNotFound)
+				svc.On("Update", mock.Anything, "notfound", "https://example.com/new").Return(nil, models.ErrURLNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
@@ -463,17 +1418,31 @@ func TestURLHandler_DeleteURL(t *testing.T) {
 			},
 		},
 		{
-			name:      "service error returns 500",
-			shortCode: "error",
+			name:      "PATCH with dangerous URL returns 400",
+			shortCode: "abc1234",
+			body:      `{"url":"javascript:alert(1)"}`,
 			setupMock: func(svc *MockURLService) {
-				svc.On("Delete", mock.Anything, "error").Return(errors.New("database error"))
+				svc.On("Update", mock.Anything, "abc1234", "javascript:alert(1)").Return(nil, services.ErrDangerousURL)
 			},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
 				var resp ErrorResponse
 				err := json.Unmarshal(rec.Body.Bytes(), &resp)
 				require.NoError(t, err)
-				assert.Equal(t, "INTERNAL_ERROR", resp.Code)
+				assert.Equal(t, "DANGEROUS_URL", resp.Code)
+			},
+		},
+		{
+			name:           "PATCH with invalid JSON returns 400",
+			shortCode:      "abc1234",
+			body:           "not valid json{",
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "INVALID_REQUEST", resp.Code)
 			},
 		},
 	}
@@ -485,15 +1454,862 @@ func TestURLHandler_DeleteURL(t *testing.T) {
 
 			handler := NewURLHandler(mockSvc)
 
-			req := httptest.NewRequest(http.MethodDelete, "/api/v1/urls/"+tt.shortCode, nil)
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/urls/"+tt.shortCode, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
-			handler.DeleteURL(rec, req, tt.shortCode)
+			handler.UpdateURL(rec, req, tt.shortCode)
 
 			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
 			tt.checkResponse(t, rec)
 
 			mockSvc.AssertExpectations(t)
 		})
 	}
 }
+
+func TestURLHandler_ExtendURL(t *testing.T) {
+	now := time.Now()
+	newExpiry := now.Add(168 * time.Hour)
+	extended := &models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com",
+		CreatedAt:   now,
+		ExpiresAt:   &newExpiry,
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockURLService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "expires_in is counted from now",
+			body: `{"expires_in":"168h"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("Extend", mock.Anything, "abc1234", mock.MatchedBy(func(t time.Time) bool {
+					return t.Sub(now) >= 168*time.Hour && t.Sub(now) < 169*time.Hour
+				})).Return(extended, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "expires_at sets an absolute time",
+			body: `{"expires_at":"2030-01-02T03:04:05Z"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("Extend", mock.Anything, "abc1234", time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)).Return(extended, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "deleted or missing URL returns 404",
+			body: `{"expires_in":"1h"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("Extend", mock.Anything, "abc1234", mock.Anything).Return(nil, models.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+		},
+		{
+			name: "time in the past returns 400",
+			body: `{"expires_in":"-1h"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("Extend", mock.Anything, "abc1234", mock.Anything).Return(nil, services.ErrExpiresAtInPast)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_EXPIRES_AT",
+		},
+		{
+			name:           "invalid duration returns 400",
+			body:           `{"expires_in":"next week"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_EXPIRES_IN",
+		},
+		{
+			name:           "invalid timestamp returns 400",
+			body:           `{"expires_at":"tomorrow"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_EXPIRES_AT",
+		},
+		{
+			name:           "missing expiry returns 400",
+			body:           `{}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+		{
+			name:           "both fields return 400",
+			body:           `{"expires_in":"1h","expires_at":"2030-01-02T03:04:05Z"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockURLService)
+			tt.setupMock(mockSvc)
+
+			handler := NewURLHandler(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/abc1234/extend", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.ExtendURL(rec, req, "abc1234")
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedCode != "" {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedCode, resp.Code)
+			} else {
+				var resp URLInfoResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.NotNil(t, resp.ExpiresAt)
+				assert.Equal(t, newExpiry.Format(time.RFC3339), *resp.ExpiresAt)
+			}
+
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_CreateAlias(t *testing.T) {
+	created := &services.CreateURLResponse{
+		ShortURL:    "http://localhost:8080/spring-tw",
+		ShortCode:   "spring-tw",
+		OriginalURL: "https://example.com/sale",
+		CreatedAt:   time.Now(),
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockURLService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "creates the alias",
+			body: `{"alias":"spring-tw"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("CreateAliasFor", mock.Anything, "abc1234", "spring-tw").Return(created, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "taken alias returns 409",
+			body: `{"alias":"spring-tw"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("CreateAliasFor", mock.Anything, "abc1234", "spring-tw").Return(nil, services.ErrAliasTaken)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "ALIAS_TAKEN",
+		},
+		{
+			name: "missing URL returns 404",
+			body: `{"alias":"spring-tw"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("CreateAliasFor", mock.Anything, "abc1234", "spring-tw").Return(nil, models.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+		},
+		{
+			name: "expired URL returns 410",
+			body: `{"alias":"spring-tw"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("CreateAliasFor", mock.Anything, "abc1234", "spring-tw").Return(nil, models.ErrURLExpired)
+			},
+			expectedStatus: http.StatusGone,
+			expectedCode:   "EXPIRED",
+		},
+		{
+			name: "click-limited URL returns 409",
+			body: `{"alias":"spring-tw"}`,
+			setupMock: func(svc *MockURLService) {
+				svc.On("CreateAliasFor", mock.Anything, "abc1234", "spring-tw").Return(nil, services.ErrAliasClickLimited)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "CLICK_LIMITED",
+		},
+		{
+			name:           "missing alias returns 400",
+			body:           `{}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+		{
+			name:           "unknown field returns 400",
+			body:           `{"alias":"spring-tw","url":"https://example.com"}`,
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockURLService)
+			tt.setupMock(mockSvc)
+
+			handler := NewURLHandler(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/abc1234/aliases", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.CreateAlias(rec, req, "abc1234")
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedCode != "" {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedCode, resp.Code)
+			} else {
+				var resp ShortenResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "spring-tw", resp.ShortCode)
+				assert.Equal(t, "https://example.com/sale", resp.OriginalURL)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_SetActive(t *testing.T) {
+	url := &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()}
+	disabled := *url
+	disabled.Disabled = true
+
+	tests := []struct {
+		name           string
+		active         bool
+		setupMock      func(*MockURLService)
+		expectedStatus int
+		expectedActive bool
+		expectedCode   string
+	}{
+		{
+			name:   "disable",
+			active: false,
+			setupMock: func(svc *MockURLService) {
+				svc.On("SetActive", mock.Anything, "abc1234", false).Return(&disabled, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedActive: false,
+		},
+		{
+			name:   "enable",
+			active: true,
+			setupMock: func(svc *MockURLService) {
+				svc.On("SetActive", mock.Anything, "abc1234", true).Return(url, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedActive: true,
+		},
+		{
+			name:   "deleted or missing URL returns 404",
+			active: false,
+			setupMock: func(svc *MockURLService) {
+				svc.On("SetActive", mock.Anything, "abc1234", false).Return(nil, models.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockURLService)
+			tt.setupMock(mockSvc)
+
+			handler := NewURLHandler(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/abc1234/disable", nil)
+			rec := httptest.NewRecorder()
+
+			handler.SetActive(rec, req, "abc1234", tt.active)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedCode != "" {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedCode, resp.Code)
+			} else {
+				var resp URLInfoResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedActive, resp.Active)
+			}
+
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_DeleteURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		shortCode      string
+		setupMock      func(*MockURLService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "DELETE existing code returns 204 No Content",
+			shortCode: "abc1234",
+			setupMock: func(svc *MockURLService) {
+				svc.On("Delete", mock.Anything, "abc1234").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Empty(t, rec.Body.Bytes())
+			},
+		},
+		{
+			name:      "DELETE non-existent code returns 404",
+			shortCode: "notfound",
+			setupMock: func(svc *MockURLService) {
+				svc.On("Delete", mock.Anything, "notfound").Return(models.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "NOT_FOUND", resp.Code)
+			},
+		},
+		{
+			name:      "service error returns 500",
+			shortCode: "error",
+			setupMock: func(svc *MockURLService) {
+				svc.On("Delete", mock.Anything, "error").Return(errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				assert.Equal(t, "INTERNAL_ERROR", resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockURLService)
+			tt.setupMock(mockSvc)
+
+			handler := NewURLHandler(mockSvc)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/urls/"+tt.shortCode, nil)
+			rec := httptest.NewRecorder()
+
+			handler.DeleteURL(rec, req, tt.shortCode)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			tt.checkResponse(t, rec)
+
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_DeleteURL_Permanent(t *testing.T) {
+	t.Run("permanent=true deletes for good", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("DeletePermanent", mock.Anything, "abc1234").Return(nil)
+		handler := NewURLHandler(mockSvc)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/urls/abc1234?permanent=true", nil)
+		rec := httptest.NewRecorder()
+		handler.DeleteURL(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockSvc.AssertExpectations(t)
+		mockSvc.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("permanent=false soft-deletes", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("Delete", mock.Anything, "abc1234").Return(nil)
+		handler := NewURLHandler(mockSvc)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/urls/abc1234?permanent=false", nil)
+		rec := httptest.NewRecorder()
+		handler.DeleteURL(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("invalid value returns 400", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		handler := NewURLHandler(mockSvc)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/urls/abc1234?permanent=maybe", nil)
+		rec := httptest.NewRecorder()
+		handler.DeleteURL(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "INVALID_REQUEST", resp.Code)
+		mockSvc.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		mockSvc.AssertNotCalled(t, "DeletePermanent", mock.Anything, mock.Anything)
+	})
+}
+
+func TestURLHandler_DeleteBatch(t *testing.T) {
+	doRequest := func(handler *URLHandler, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/batch-delete", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.DeleteBatch(rec, req)
+		return rec
+	}
+
+	t.Run("all deleted returns 200", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("DeleteBatch", mock.Anything, []string{"abc1234", "def5678"}).
+			Return(map[string]error{"abc1234": nil, "def5678": nil})
+
+		rec := doRequest(NewURLHandler(mockSvc), `["abc1234", "def5678"]`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp BatchDeleteResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 0, resp.Failed)
+	})
+
+	t.Run("partial success returns 207 with per-code results", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("DeleteBatch", mock.Anything, []string{"abc1234", "missing"}).
+			Return(map[string]error{"abc1234": nil, "missing": models.ErrURLNotFound})
+
+		rec := doRequest(NewURLHandler(mockSvc), `["abc1234", "missing", ""]`)
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		var resp BatchDeleteResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 3)
+		assert.Equal(t, BatchDeleteItem{ShortCode: "abc1234", Status: http.StatusOK}, resp.Results[0])
+		assert.Equal(t, "missing", resp.Results[1].ShortCode)
+		assert.Equal(t, http.StatusNotFound, resp.Results[1].Status)
+		assert.Equal(t, "NOT_FOUND", resp.Results[1].Error.Code)
+		assert.Equal(t, http.StatusBadRequest, resp.Results[2].Status)
+		assert.Equal(t, "INVALID_SHORT_CODE", resp.Results[2].Error.Code)
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+	})
+
+	t.Run("empty batch returns 400", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+
+		rec := doRequest(NewURLHandler(mockSvc), `[]`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "EMPTY_BATCH")
+		mockSvc.AssertNotCalled(t, "DeleteBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("oversized batch returns 400", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		codes := make([]string, MaxBatchSize+1)
+		for i := range codes {
+			codes[i] = "code" + strconv.Itoa(i)
+		}
+		body, err := json.Marshal(codes)
+		require.NoError(t, err)
+
+		rec := doRequest(NewURLHandler(mockSvc), string(body))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "BATCH_TOO_LARGE")
+		mockSvc.AssertNotCalled(t, "DeleteBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid body returns 400", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+
+		rec := doRequest(NewURLHandler(mockSvc), `{"codes": ["abc1234"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "INVALID_REQUEST")
+	})
+}
+
+func TestURLHandler_PurgeExpired(t *testing.T) {
+	t.Run("returns the deleted count", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("PurgeExpired", mock.Anything).Return(int64(3), nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/purge-expired", nil)
+		rec := httptest.NewRecorder()
+		NewURLHandler(mockSvc).PurgeExpired(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"deleted": 3}`, rec.Body.String())
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("service error returns 500", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("PurgeExpired", mock.Anything).Return(int64(0), errors.New("connection refused"))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/purge-expired", nil)
+		rec := httptest.NewRecorder()
+		NewURLHandler(mockSvc).PurgeExpired(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestURLHandler_RestoreURL(t *testing.T) {
+	t.Run("restored URL returns 200 with info", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("Restore", mock.Anything, "abc1234").Return(&models.URL{
+			ID:          1,
+			ShortCode:   "abc1234",
+			OriginalURL: "https://example.com",
+			CreatedAt:   time.Now(),
+		}, nil)
+		handler := NewURLHandler(mockSvc)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/abc1234/restore", nil)
+		rec := httptest.NewRecorder()
+		handler.RestoreURL(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp URLInfoResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "abc1234", resp.ShortCode)
+		assert.Equal(t, "https://example.com", resp.OriginalURL)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("URL that is not deleted returns 404", func(t *testing.T) {
+		mockSvc := new(MockURLService)
+		mockSvc.On("Restore", mock.Anything, "abc1234").Return(nil, models.ErrURLNotFound)
+		handler := NewURLHandler(mockSvc)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/abc1234/restore", nil)
+		rec := httptest.NewRecorder()
+		handler.RestoreURL(rec, req, "abc1234")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "NOT_FOUND", resp.Code)
+	})
+}
+
+func TestURLHandler_ListURLs(t *testing.T) {
+	now := time.Now()
+	after, _ := time.Parse(time.RFC3339, "2024-01-01T00:00:00Z")
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockURLService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "defaults to first page",
+			query: "",
+			setupMock: func(svc *MockURLService) {
+				svc.On("List", mock.Anything, services.DefaultListLimit, 0, repository.ListFilter{}).Return([]*models.URL{
+					{ID: 2, ShortCode: "def5678", OriginalURL: "https://example.com/b", CreatedAt: now},
+					{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com/a", CreatedAt: now, PasswordHash: "$2a$10$hash"},
+				}, int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.NotContains(t, rec.Body.String(), "$2a$")
+				var resp ListURLsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, int64(2), resp.Total)
+				assert.Equal(t, services.DefaultListLimit, resp.Limit)
+				assert.Equal(t, 0, resp.Offset)
+				require.Len(t, resp.Items, 2)
+				assert.Equal(t, "def5678", resp.Items[0].ShortCode)
+				assert.True(t, resp.Items[1].PasswordProtected)
+			},
+		},
+		{
+			name:  "passes pagination and filters",
+			query: "?limit=5&offset=10&search=%20promo%20&created_after=2024-01-01T00:00:00Z&status=Expired",
+			setupMock: func(svc *MockURLService) {
+				svc.On("List", mock.Anything, 5, 10, repository.ListFilter{
+					Search:       "promo",
+					CreatedAfter: &after,
+					Status:       repository.ListStatusExpired,
+				}).Return([]*models.URL{}, int64(12), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Contains(t, rec.Body.String(), `"items":[]`)
+				var resp ListURLsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, int64(12), resp.Total)
+				assert.Equal(t, 5, resp.Limit)
+				assert.Equal(t, 10, resp.Offset)
+			},
+		},
+		{
+			name:  "filters by tag case-insensitively",
+			query: "?tag=%20Promo%20",
+			setupMock: func(svc *MockURLService) {
+				svc.On("List", mock.Anything, services.DefaultListLimit, 0, repository.ListFilter{Tag: "promo"}).Return([]*models.URL{
+					{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com/a", CreatedAt: now, Tags: []string{"promo"}},
+				}, int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ListURLsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Items, 1)
+				assert.Equal(t, []string{"promo"}, resp.Items[0].Tags)
+			},
+		},
+		{
+			name:           "non-numeric limit returns 400",
+			query:          "?limit=ten",
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_PAGINATION", resp.Code)
+			},
+		},
+		{
+			name:  "out-of-range limit returns 400",
+			query: "?limit=1000",
+			setupMock: func(svc *MockURLService) {
+				svc.On("List", mock.Anything, 1000, 0, repository.ListFilter{}).Return(nil, int64(0), services.ErrInvalidPagination)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_PAGINATION", resp.Code)
+			},
+		},
+		{
+			name:           "invalid date returns 400",
+			query:          "?created_before=yesterday",
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_FILTER", resp.Code)
+				assert.Contains(t, resp.Error, "created_before")
+			},
+		},
+		{
+			name:           "unknown status returns 400",
+			query:          "?status=deleted",
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_FILTER", resp.Code)
+			},
+		},
+		{
+			name:  "empty cursor starts cursor pagination",
+			query: "?cursor=&limit=2",
+			setupMock: func(svc *MockURLService) {
+				svc.On("ListAfter", mock.Anything, int64(0), 2, repository.ListFilter{}).Return([]*models.URL{
+					{ID: 1, ShortCode: "abc1234", CreatedAt: now},
+					{ID: 2, ShortCode: "def5678", CreatedAt: now},
+				}, int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ListURLsResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Items, 2)
+				assert.Equal(t, int64(2), resp.NextCursor)
+			},
+		},
+		{
+			name:  "last cursor page omits next_cursor",
+			query: "?cursor=2&search=promo",
+			setupMock: func(svc *MockURLService) {
+				svc.On("ListAfter", mock.Anything, int64(2), services.DefaultListLimit, repository.ListFilter{Search: "promo"}).
+					Return([]*models.URL{{ID: 3, ShortCode: "promo1", CreatedAt: now}}, int64(0), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.NotContains(t, rec.Body.String(), "next_cursor")
+			},
+		},
+		{
+			name:           "invalid cursor returns 400",
+			query:          "?cursor=-5",
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_CURSOR", resp.Code)
+			},
+		},
+		{
+			name:           "cursor with offset returns 400",
+			query:          "?cursor=5&offset=10",
+			setupMock:      func(svc *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "INVALID_CURSOR", resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockURLService)
+			tt.setupMock(mockSvc)
+
+			handler := NewURLHandler(mockSvc)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.ListURLs(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			tt.checkResponse(t, rec)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_ExportURLs(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := created.Add(24 * time.Hour)
+	urls := []*models.URL{
+		{ShortCode: "abc1234", OriginalURL: "https://example.com/a,b", CreatedAt: created, ClickCount: 7},
+		{ShortCode: "def5678", OriginalURL: "https://example.com/c", CreatedAt: created, ExpiresAt: &expires},
+	}
+
+	t.Run("csv by default", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Export", mock.Anything).Return(urls, nil)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/export", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="urls.csv"`, rec.Header().Get("Content-Disposition"))
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"short_code", "original_url", "created_at", "expires_at", "click_count"},
+			{"abc1234", "https://example.com/a,b", "2024-01-02T03:04:05Z", "", "7"},
+			{"def5678", "https://example.com/c", "2024-01-02T03:04:05Z", "2024-01-03T03:04:05Z", "0"},
+		}, records)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Export", mock.Anything).Return(urls, nil)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/export?format=json", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="urls.ndjson"`, rec.Header().Get("Content-Disposition"))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		var record ExportRecord
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+		assert.Equal(t, "def5678", record.ShortCode)
+		require.NotNil(t, record.ExpiresAt)
+		assert.True(t, expires.Equal(*record.ExpiresAt))
+	})
+
+	t.Run("empty export has header row", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Export", mock.Anything).Return(nil, nil)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/export", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "short_code,original_url,created_at,expires_at,click_count\n", rec.Body.String())
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		svc := new(MockURLService)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/export?format=xml", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "INVALID_EXPORT_FORMAT")
+		svc.AssertNotCalled(t, "Export", mock.Anything)
+	})
+
+	t.Run("error before first record", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Export", mock.Anything).Return(nil, errors.New("database down"))
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/export", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("error mid-stream aborts the response", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("Export", mock.Anything).Return(urls, errors.New("database down"))
+
+		rec := httptest.NewRecorder()
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			NewURLHandler(svc).ExportURLs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/export", nil))
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestDecodeStrict_UnknownFieldMessage(t *testing.T) {
+	// decodeStrict recognises unknown fields by encoding/json's message alone
+	dec := json.NewDecoder(strings.NewReader(`{"urls": []}`))
+	dec.DisallowUnknownFields()
+	var v struct{ URL string }
+	err := dec.Decode(&v)
+	require.Error(t, err)
+	assert.Equal(t, unknownFieldPrefix+`"urls"`, err.Error())
+
+	var target struct {
+		Items []struct {
+			URL string `json:"url"`
+		} `json:"items"`
+	}
+	err = decodeStrict(strings.NewReader(`{"items": [{"url": "x", "alias": "y"}]}`), &target)
+	var vErr *validationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, `unknown field "alias"`, vErr.msg)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/emadnahed/FastGoLink/internal/services"
+)
+
+// MaxImportRows is the maximum number of URLs accepted by a single import.
+const MaxImportRows = 10000
+
+// ImportFileField is the multipart form field holding the CSV file of an import.
+const ImportFileField = "file"
+
+// importColumns are the columns of an import CSV. Only original_url is
+// required; a header row naming them is optional.
+var importColumns = []string{"original_url", "alias", "expires_in"}
+
+// ImportRowResult reports the outcome of a single row of an import.
+type ImportRowResult struct {
+	Row    int              `json:"row"` // Line of the row in the CSV file
+	Status int              `json:"status"`
+	Result *ShortenResponse `json:"result,omitempty"`
+	Error  *ErrorResponse   `json:"error,omitempty"`
+}
+
+// ImportURLsResponse represents the response for a URL import.
+type ImportURLsResponse struct {
+	Results   []ImportRowResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
+
+// importRow is a parsed import row, holding either a request or the reason
+// it was rejected.
+type importRow struct {
+	line int
+	req  services.CreateURLRequest
+	err  *ErrorResponse
+}
+
+// ImportURLs handles POST /api/v1/urls/import requests.
+// The CSV file is read from the "file" field of a multipart form as it
+// arrives. Rows are created in batches of MaxBatchSize through the batch
+// shorten path, and the response reports a per-row status like
+// ShortenBatch does. Files with more than MaxImportRows rows are rejected
+// before any URL is created.
+func (h *URLHandler) ImportURLs(w http.ResponseWriter, r *http.Request) {
+	file, err := importFile(r)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	rows, err := readImportRows(file)
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	ctx, span := tracer.Start(h.shortDomainContext(r), "URLHandler.ImportURLs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("url.import_rows", len(rows)))
+
+	resp := ImportURLsResponse{Results: make([]ImportRowResult, len(rows))}
+	for start := 0; start < len(rows); start += MaxBatchSize {
+		chunk := rows[start:min(start+MaxBatchSize, len(rows))]
+
+		createReqs := make([]services.CreateURLRequest, 0, len(chunk))
+		indexes := make([]int, 0, len(chunk))
+		for i, row := range chunk {
+			resp.Results[start+i] = ImportRowResult{Row: row.line, Status: http.StatusBadRequest, Error: row.err}
+			if row.err == nil {
+				createReqs = append(createReqs, row.req)
+				indexes = append(indexes, start+i)
+			}
+		}
+		if len(createReqs) == 0 {
+			continue
+		}
+
+		created, errs := h.service.CreateBatch(ctx, createReqs)
+		for j, i := range indexes {
+			if errs[j] != nil {
+				status, errResp := mapErrorToResponse(errs[j])
+				resp.Results[i].Status, resp.Results[i].Error = status, &errResp
+				continue
+			}
+			shortenResp := newShortenResponse(&created[j])
+			resp.Results[i].Status, resp.Results[i].Result = http.StatusCreated, &shortenResp
+		}
+	}
+
+	for _, result := range resp.Results {
+		if result.Error != nil {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+
+	status := http.StatusCreated
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	writeJSON(w, status, resp)
+}
+
+// importError rejects an import request with 400 and an error code.
+type importError struct {
+	msg  string
+	code string
+}
+
+func (e *importError) Error() string { return e.msg }
+
+// importFile returns the CSV file part of a multipart import request,
+// without reading the parts after it.
+func importFile(r *http.Request) (io.Reader, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, &importError{msg: "request must be multipart/form-data with a CSV file", code: "INVALID_REQUEST"}
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, &importError{msg: fmt.Sprintf("missing %q file field", ImportFileField), code: "MISSING_FILE"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == ImportFileField {
+			return part, nil
+		}
+	}
+}
+
+// readImportRows parses an import CSV. Rows that cannot become a shorten
+// request are returned with their error rather than failing the import;
+// malformed CSV and files with too many rows fail it.
+func readImportRows(file io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // Optional columns may be left off
+	reader.TrimLeadingSpace = true
+
+	var rows []importRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &importError{msg: fmt.Sprintf("invalid CSV: %v", parseErr), code: "INVALID_CSV"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), importColumns[0]) {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, &importError{
+				msg:  fmt.Sprintf("import exceeds maximum of %d rows", MaxImportRows),
+				code: "IMPORT_TOO_LARGE",
+			}
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, newImportRow(line, record))
+	}
+
+	if len(rows) == 0 {
+		return nil, &importError{msg: "import must contain at least one URL", code: "EMPTY_IMPORT"}
+	}
+	return rows, nil
+}
+
+// newImportRow turns a CSV record into the shorten request it describes.
+func newImportRow(line int, record []string) importRow {
+	if len(record) > len(importColumns) {
+		return importRow{line: line, err: &ErrorResponse{
+			Error: fmt.Sprintf("row has %d columns, expected at most %d (%s)",
+				len(record), len(importColumns), strings.Join(importColumns, ", ")),
+			Code: "VALIDATION_ERROR",
+		}}
+	}
+
+	fields := make([]string, len(importColumns))
+	for i, value := range record {
+		fields[i] = strings.TrimSpace(value)
+	}
+	req, errResp := toCreateURLRequest(ShortenRequest{
+		URL:         fields[0],
+		CustomAlias: fields[1],
+		ExpiresIn:   fields[2],
+	})
+	return importRow{line: line, req: req, err: errResp}
+}
+
+// writeImportError writes the response for an import that could not be read.
+func writeImportError(w http.ResponseWriter, r *http.Request, err error) {
+	var importErr *importError
+	if errors.As(err, &importErr) {
+		writeError(w, r, http.StatusBadRequest, ErrorResponse{Error: importErr.msg, Code: importErr.code})
+		return
+	}
+	writeDecodeError(w, r, err)
+}
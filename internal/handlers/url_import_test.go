@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/services"
+)
+
+// newImportRequest builds a multipart import request uploading csvData.
+func newImportRequest(t *testing.T, csvData string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("note", "ignored"))
+	part, err := mw.CreateFormFile(ImportFileField, "urls.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csvData))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestURLHandler_ImportURLs(t *testing.T) {
+	t.Run("reports a result per row", func(t *testing.T) {
+		csvData := strings.Join([]string{
+			"original_url,alias,expires_in",
+			"https://example.com/a",
+			"https://example.com/b,promo,24h",
+			"javascript:alert(1)",
+			"https://example.com/c,,forever",
+			"https://example.com/d,x,1h,extra",
+		}, "\n")
+
+		svc := new(MockURLService)
+		svc.On("CreateBatch", mock.Anything, mock.MatchedBy(func(reqs []services.CreateURLRequest) bool {
+			return len(reqs) == 3 &&
+				reqs[0].OriginalURL == "https://example.com/a" && reqs[0].CustomAlias == "" && reqs[0].ExpiresIn == nil &&
+				reqs[1].CustomAlias == "promo" && reqs[1].ExpiresIn != nil && *reqs[1].ExpiresIn == 24*time.Hour &&
+				reqs[2].OriginalURL == "javascript:alert(1)"
+		})).Return(
+			[]services.CreateURLResponse{
+				{ShortURL: "http://localhost:8080/abc1234", ShortCode: "abc1234", OriginalURL: "https://example.com/a", CreatedAt: time.Now()},
+				{ShortURL: "http://localhost:8080/promo", ShortCode: "promo", OriginalURL: "https://example.com/b", CreatedAt: time.Now()},
+				{},
+			},
+			[]error{nil, nil, services.ErrDangerousURL},
+		)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ImportURLs(rec, newImportRequest(t, csvData))
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		var resp ImportURLsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 3, resp.Failed)
+		require.Len(t, resp.Results, 5)
+
+		assert.Equal(t, 2, resp.Results[0].Row)
+		assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+		assert.Equal(t, "abc1234", resp.Results[0].Result.ShortCode)
+
+		assert.Equal(t, 3, resp.Results[1].Row)
+		assert.Equal(t, "promo", resp.Results[1].Result.ShortCode)
+
+		assert.Equal(t, 4, resp.Results[2].Row)
+		assert.Equal(t, http.StatusBadRequest, resp.Results[2].Status)
+		assert.Equal(t, "DANGEROUS_URL", resp.Results[2].Error.Code)
+
+		assert.Equal(t, 5, resp.Results[3].Row)
+		assert.Equal(t, "INVALID_EXPIRES_IN", resp.Results[3].Error.Code)
+
+		assert.Equal(t, 6, resp.Results[4].Row)
+		assert.Equal(t, "VALIDATION_ERROR", resp.Results[4].Error.Code)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("header row is optional", func(t *testing.T) {
+		svc := new(MockURLService)
+		svc.On("CreateBatch", mock.Anything, mock.MatchedBy(func(reqs []services.CreateURLRequest) bool {
+			return len(reqs) == 1 && reqs[0].OriginalURL == "https://example.com/a"
+		})).Return(
+			[]services.CreateURLResponse{{ShortCode: "abc1234", CreatedAt: time.Now()}},
+			[]error{nil},
+		)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ImportURLs(rec, newImportRequest(t, "https://example.com/a\n"))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp ImportURLsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, 1, resp.Results[0].Row)
+	})
+
+	t.Run("creates rows in batches", func(t *testing.T) {
+		var csvData strings.Builder
+		for i := range MaxBatchSize + 1 {
+			fmt.Fprintf(&csvData, "https://example.com/%d\n", i)
+		}
+
+		svc := new(MockURLService)
+		for _, size := range []int{MaxBatchSize, 1} {
+			resps := make([]services.CreateURLResponse, size)
+			svc.On("CreateBatch", mock.Anything, mock.MatchedBy(func(reqs []services.CreateURLRequest) bool {
+				return len(reqs) == size
+			})).Return(resps, make([]error, size)).Once()
+		}
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ImportURLs(rec, newImportRequest(t, csvData.String()))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("rejects files over the row limit before creating anything", func(t *testing.T) {
+		csvData := strings.Repeat("https://example.com\n", MaxImportRows+1)
+		svc := new(MockURLService)
+
+		rec := httptest.NewRecorder()
+		NewURLHandler(svc).ImportURLs(rec, newImportRequest(t, csvData))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "IMPORT_TOO_LARGE")
+		svc.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects bad uploads", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			req      func() *http.Request
+			wantCode string
+		}{
+			{"not multipart", func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/api/v1/urls/import", strings.NewReader("https://example.com"))
+			}, "INVALID_REQUEST"},
+			{"missing file field", func() *http.Request {
+				var body bytes.Buffer
+				mw := multipart.NewWriter(&body)
+				_ = mw.WriteField("note", "no file")
+				_ = mw.Close()
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/import", &body)
+				req.Header.Set("Content-Type", mw.FormDataContentType())
+				return req
+			}, "MISSING_FILE"},
+			{"empty file", func() *http.Request { return newImportRequest(t, "original_url\n") }, "EMPTY_IMPORT"},
+			{"malformed CSV", func() *http.Request { return newImportRequest(t, "\"https://example.com\n") }, "INVALID_CSV"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				NewURLHandler(new(MockURLService)).ImportURLs(rec, tt.req())
+
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				var errResp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.wantCode, errResp.Code)
+			})
+		}
+	})
+
+	t.Run("file over the body limit returns 413", func(t *testing.T) {
+		req := newImportRequest(t, strings.Repeat("https://example.com/long-path\n", 100))
+		rec := httptest.NewRecorder()
+		req.Body = http.MaxBytesReader(rec, req.Body, 512)
+
+		NewURLHandler(new(MockURLService)).ImportURLs(rec, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
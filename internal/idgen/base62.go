@@ -11,40 +11,87 @@ const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ
 
 const base = 62
 
+// UnambiguousAlphabet is Base62 without characters that are easily confused
+// when printed or read aloud: 0, O, o, 1, l and I.
+const UnambiguousAlphabet = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
 // ErrInvalidCharacter is returned when decoding encounters an invalid character.
 var ErrInvalidCharacter = errors.New("invalid base62 character")
 
 // ErrEmptyString is returned when decoding an empty string.
 var ErrEmptyString = errors.New("cannot decode empty string")
 
-// charToValue maps each character to its numeric value for fast decoding.
-var charToValue [256]int
+// ErrInvalidAlphabet is returned for alphabets that are too short, repeat a
+// character or contain characters other than ASCII letters, digits, '-' and '_'.
+var ErrInvalidAlphabet = errors.New("alphabet must have at least 2 unique letters, digits, '-' or '_'")
+
+// Alphabet is a validated set of digits for encoding numbers as short codes.
+type Alphabet struct {
+	chars       string
+	charToValue [256]int // Value of each character, -1 when not in the alphabet
+}
 
-func init() {
-	// Initialize all values to -1 (invalid)
-	for i := range charToValue {
-		charToValue[i] = -1
+// base62 is the default alphabet used by Encode and Decode.
+var base62 = mustAlphabet(alphabet)
+
+// NewAlphabet creates an Alphabet whose digits, in increasing value, are the
+// characters of chars.
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if len(chars) < 2 {
+		return nil, ErrInvalidAlphabet
 	}
-	// Map valid characters to their values
-	for i, c := range alphabet {
-		charToValue[c] = i
+
+	a := &Alphabet{chars: chars}
+	for i := range a.charToValue {
+		a.charToValue[i] = -1
+	}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if !isCodeChar(c) || a.charToValue[c] != -1 {
+			return nil, ErrInvalidAlphabet
+		}
+		a.charToValue[c] = i
+	}
+	return a, nil
+}
+
+func mustAlphabet(chars string) *Alphabet {
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
 	}
+	return a
+}
+
+// isCodeChar reports whether c may appear in a short code.
+func isCodeChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_'
+}
+
+// String returns the characters of the alphabet.
+func (a *Alphabet) String() string {
+	return a.chars
 }
 
 // Encode converts a uint64 to a Base62 string.
 func Encode(n uint64) string {
+	return EncodeWith(n, base62)
+}
+
+// EncodeWith converts a uint64 to a string of digits from a.
+func EncodeWith(n uint64, a *Alphabet) string {
 	if n == 0 {
-		return "0"
+		return a.chars[:1]
 	}
 
 	var result strings.Builder
-	// Pre-allocate for efficiency (11 chars can hold max uint64)
+	// Pre-allocate for efficiency (11 chars can hold max uint64 in Base62)
 	result.Grow(11)
 
+	radix := uint64(len(a.chars))
 	for n > 0 {
-		remainder := n % base
-		result.WriteByte(alphabet[remainder])
-		n /= base
+		result.WriteByte(a.chars[n%radix])
+		n /= radix
 	}
 
 	// Reverse the string (we built it backwards)
@@ -64,18 +111,24 @@ func EncodeWithPadding(n uint64, minLength int) string {
 
 // Decode converts a Base62 string back to a uint64.
 func Decode(s string) (uint64, error) {
+	return DecodeWith(s, base62)
+}
+
+// DecodeWith converts a string of digits from a back to a uint64.
+func DecodeWith(s string, a *Alphabet) (uint64, error) {
 	if len(s) == 0 {
 		return 0, ErrEmptyString
 	}
 
+	radix := uint64(len(a.chars))
 	var result uint64
 	for i := 0; i < len(s); i++ {
-		val := charToValue[s[i]]
+		val := a.charToValue[s[i]]
 		if val == -1 {
 			return 0, ErrInvalidCharacter
 		}
-		// #nosec G115 -- val is always in range [0, 61] from charToValue lookup
-		result = result*base + uint64(val)
+		// #nosec G115 -- val is always in range [0, len(a.chars)) from charToValue lookup
+		result = result*radix + uint64(val)
 	}
 
 	return result, nil
@@ -96,7 +149,7 @@ func IsValid(s string) bool {
 		return false
 	}
 	for i := 0; i < len(s); i++ {
-		if charToValue[s[i]] == -1 {
+		if base62.charToValue[s[i]] == -1 {
 			return false
 		}
 	}
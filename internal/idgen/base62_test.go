@@ -1,6 +1,7 @@
 package idgen
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -160,6 +161,88 @@ func TestBase62RoundTrip(t *testing.T) {
 	}
 }
 
+func TestNewAlphabet(t *testing.T) {
+	tests := []struct {
+		name    string
+		chars   string
+		wantErr bool
+	}{
+		{"base62", alphabet, false},
+		{"unambiguous", UnambiguousAlphabet, false},
+		{"binary", "01", false},
+		{"dash and underscore", "ab-_", false},
+		{"empty", "", true},
+		{"single character", "a", true},
+		{"repeated character", "abca", true},
+		{"unsafe character", "ab/c", true},
+		{"non-ASCII", "abcé", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAlphabet(tt.chars)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAlphabet)
+				assert.Nil(t, a)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.chars, a.String())
+		})
+	}
+}
+
+func TestUnambiguousAlphabet(t *testing.T) {
+	for _, c := range "0Oo1lI" {
+		assert.NotContains(t, UnambiguousAlphabet, string(c))
+	}
+}
+
+func TestEncodeWith(t *testing.T) {
+	binary, err := NewAlphabet("01")
+	require.NoError(t, err)
+	assert.Equal(t, "0", EncodeWith(0, binary))
+	assert.Equal(t, "101", EncodeWith(5, binary))
+
+	unambiguous, err := NewAlphabet(UnambiguousAlphabet)
+	require.NoError(t, err)
+	assert.Equal(t, "2", EncodeWith(0, unambiguous))
+	assert.Equal(t, "32", EncodeWith(uint64(len(UnambiguousAlphabet)), unambiguous))
+
+	// The default alphabet matches Encode
+	assert.Equal(t, Encode(916132832), EncodeWith(916132832, base62))
+}
+
+func TestDecodeWith(t *testing.T) {
+	unambiguous, err := NewAlphabet(UnambiguousAlphabet)
+	require.NoError(t, err)
+
+	_, err = DecodeWith("", unambiguous)
+	assert.ErrorIs(t, err, ErrEmptyString)
+
+	// Ambiguous characters are not part of the alphabet
+	_, err = DecodeWith("a0b", unambiguous)
+	assert.ErrorIs(t, err, ErrInvalidCharacter)
+}
+
+func TestEncodeWith_RoundTrip(t *testing.T) {
+	testValues := []uint64{
+		0, 1, 2, 55, 56, 57, 1000, 916132832, math.MaxUint32, math.MaxUint64,
+	}
+
+	for _, chars := range []string{"01", "abc", "ab-_", UnambiguousAlphabet, alphabet} {
+		a, err := NewAlphabet(chars)
+		require.NoError(t, err)
+
+		for _, val := range testValues {
+			encoded := EncodeWith(val, a)
+			decoded, err := DecodeWith(encoded, a)
+			require.NoError(t, err, "failed to decode %s (original: %d, alphabet %q)", encoded, val, chars)
+			assert.Equal(t, val, decoded, "round trip failed for %d with alphabet %q", val, chars)
+		}
+	}
+}
+
 func TestBase62EncodeWithPadding(t *testing.T) {
 	tests := []struct {
 		name      string
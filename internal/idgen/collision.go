@@ -2,7 +2,9 @@ package idgen
 
 import (
 	"context"
+	"math/rand/v2"
 	"sync/atomic"
+	"time"
 )
 
 // ExistenceChecker defines the interface for checking if a code exists.
@@ -16,32 +18,77 @@ type GeneratorStats struct {
 	TotalGenerations int64
 	TotalRetries     int64
 	TotalCollisions  int64
+	TotalLengthBumps int64 // Generations that switched to longer codes after repeated collisions
+}
+
+// contextGenerator is implemented by generators whose codes come from
+// context-aware storage, such as a SequentialGenerator backed by a database.
+type contextGenerator interface {
+	GenerateWithContext(ctx context.Context) (string, error)
+}
+
+// lengthGenerator is implemented by generators that can produce codes of a
+// given length, such as RandomGenerator.
+type lengthGenerator interface {
+	Length() int
+	GenerateWithLength(length int) (string, error)
+}
+
+// CollisionOptions configures how a CollisionAwareGenerator retries.
+type CollisionOptions struct {
+	MaxRetries int // Retries after a collision; 0 means no retries
+
+	// Backoff is the wait before the first retry, doubled for each further
+	// one up to MaxBackoff. Waits are jittered so concurrent generations do
+	// not retry in lockstep. Zero retries immediately.
+	Backoff    time.Duration
+	MaxBackoff time.Duration // Zero leaves the wait uncapped
+
+	// LengthBumpAfter switches a generation to codes one character longer
+	// after this many consecutive collisions, if the base generator supports
+	// it. Zero disables length bumps.
+	LengthBumpAfter int
+	MaxLength       int // Longest code a bump may produce; zero for no limit
+
+	// Reserved codes are never returned; a generated one is skipped and
+	// retried like a collision. Nil reserves nothing.
+	Reserved []string
 }
 
 // CollisionAwareGenerator wraps a base generator and handles collisions.
 type CollisionAwareGenerator struct {
-	base       Generator
-	checker    ExistenceChecker
-	maxRetries int
+	base     Generator
+	checker  ExistenceChecker
+	opts     CollisionOptions
+	reserved ReservedSet
 
 	// Statistics
 	totalGenerations atomic.Int64
 	totalRetries     atomic.Int64
 	totalCollisions  atomic.Int64
+	totalLengthBumps atomic.Int64
 }
 
 // NewCollisionAwareGenerator creates a new collision-aware generator.
-// base: The underlying generator (Random or Snowflake)
+// base: The underlying generator (Random, Snowflake or Sequential)
 // checker: Used to check if a code already exists
 // maxRetries: Maximum number of retries on collision (0 means no retries)
 func NewCollisionAwareGenerator(base Generator, checker ExistenceChecker, maxRetries int) *CollisionAwareGenerator {
-	if maxRetries < 0 {
-		maxRetries = 0
-	}
+	return NewCollisionAwareGeneratorWithOptions(base, checker, CollisionOptions{MaxRetries: maxRetries})
+}
+
+// NewCollisionAwareGeneratorWithOptions creates a collision-aware generator
+// that retries as configured by opts. Negative values are treated as zero.
+func NewCollisionAwareGeneratorWithOptions(base Generator, checker ExistenceChecker, opts CollisionOptions) *CollisionAwareGenerator {
+	opts.MaxRetries = max(opts.MaxRetries, 0)
+	opts.Backoff = max(opts.Backoff, 0)
+	opts.MaxBackoff = max(opts.MaxBackoff, 0)
+	opts.LengthBumpAfter = max(opts.LengthBumpAfter, 0)
 	return &CollisionAwareGenerator{
-		base:       base,
-		checker:    checker,
-		maxRetries: maxRetries,
+		base:     base,
+		checker:  checker,
+		opts:     opts,
+		reserved: NewReservedSet(opts.Reserved),
 	}
 }
 
@@ -52,11 +99,14 @@ func (g *CollisionAwareGenerator) Generate() (string, error) {
 }
 
 // GenerateWithContext creates a unique short code with context support.
-// Respects context cancellation during retry loop.
+// Respects context cancellation during retry loop, including while waiting
+// between retries.
 func (g *CollisionAwareGenerator) GenerateWithContext(ctx context.Context) (string, error) {
 	g.totalGenerations.Add(1)
 
-	for attempt := 0; attempt <= g.maxRetries; attempt++ {
+	length := 0 // The base generator's own length until a bump
+	backoff := g.opts.Backoff
+	for attempt := 0; attempt <= g.opts.MaxRetries; attempt++ {
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
@@ -65,15 +115,18 @@ func (g *CollisionAwareGenerator) GenerateWithContext(ctx context.Context) (stri
 		}
 
 		// Generate a candidate code
-		code, err := g.base.Generate()
+		code, err := g.generate(ctx, length)
 		if err != nil {
 			return "", err
 		}
 
-		// Check if it already exists
-		exists, err := g.checker.Exists(ctx, code)
-		if err != nil {
-			return "", err
+		// Check if it is reserved or already exists
+		exists := g.reserved.Contains(code)
+		if !exists {
+			exists, err = g.checker.Exists(ctx, code)
+			if err != nil {
+				return "", err
+			}
 		}
 
 		if !exists {
@@ -83,20 +136,75 @@ func (g *CollisionAwareGenerator) GenerateWithContext(ctx context.Context) (stri
 
 		// Collision detected, will retry
 		g.totalCollisions.Add(1)
-		if attempt < g.maxRetries {
-			g.totalRetries.Add(1)
+		if attempt >= g.opts.MaxRetries {
+			break
+		}
+		g.totalRetries.Add(1)
+
+		if length == 0 && g.opts.LengthBumpAfter > 0 && attempt+1 >= g.opts.LengthBumpAfter {
+			length = g.bumpedLength()
+		}
+
+		if backoff > 0 {
+			if err := sleep(ctx, backoff/2+rand.N(backoff/2+1)); err != nil {
+				return "", err
+			}
+			backoff *= 2
+			if g.opts.MaxBackoff > 0 {
+				backoff = min(backoff, g.opts.MaxBackoff)
+			}
 		}
 	}
 
 	return "", ErrMaxRetriesExceeded
 }
 
+// bumpedLength returns the code length to use after repeated collisions,
+// or 0 when the base generator cannot produce longer codes.
+func (g *CollisionAwareGenerator) bumpedLength() int {
+	lg, ok := g.base.(lengthGenerator)
+	if !ok {
+		return 0
+	}
+	length := lg.Length() + 1
+	if g.opts.MaxLength > 0 && length > g.opts.MaxLength {
+		return 0
+	}
+	g.totalLengthBumps.Add(1)
+	return length
+}
+
+// sleep waits for d, returning early with the context's error when ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// generate calls the base generator, passing ctx when it accepts one. A
+// non-zero length asks for codes of that length instead of the default.
+func (g *CollisionAwareGenerator) generate(ctx context.Context, length int) (string, error) {
+	if length > 0 {
+		return g.base.(lengthGenerator).GenerateWithLength(length)
+	}
+	if cg, ok := g.base.(contextGenerator); ok {
+		return cg.GenerateWithContext(ctx)
+	}
+	return g.base.Generate()
+}
+
 // Stats returns the current generation statistics.
 func (g *CollisionAwareGenerator) Stats() GeneratorStats {
 	return GeneratorStats{
 		TotalGenerations: g.totalGenerations.Load(),
 		TotalRetries:     g.totalRetries.Load(),
 		TotalCollisions:  g.totalCollisions.Load(),
+		TotalLengthBumps: g.totalLengthBumps.Load(),
 	}
 }
 
@@ -105,4 +213,5 @@ func (g *CollisionAwareGenerator) ResetStats() {
 	g.totalGenerations.Store(0)
 	g.totalRetries.Store(0)
 	g.totalCollisions.Store(0)
+	g.totalLengthBumps.Store(0)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -258,6 +259,136 @@ func TestCollisionAwareGenerator_CheckerError(t *testing.T) {
 	})
 }
 
+// collidingChecker reports the first collisions codes it sees as taken and
+// records every code it is asked about.
+type collidingChecker struct {
+	collisions int
+	seen       []string
+}
+
+func (c *collidingChecker) Exists(ctx context.Context, code string) (bool, error) {
+	c.seen = append(c.seen, code)
+	return len(c.seen) <= c.collisions, nil
+}
+
+func TestCollisionAwareGenerator_Backoff(t *testing.T) {
+	t.Run("waits between retries", func(t *testing.T) {
+		checker := &collidingChecker{collisions: 3}
+		gen := NewCollisionAwareGeneratorWithOptions(NewRandomGenerator(7), checker, CollisionOptions{
+			MaxRetries: 5,
+			Backoff:    10 * time.Millisecond,
+		})
+
+		start := time.Now()
+		_, err := gen.Generate()
+		require.NoError(t, err)
+
+		// Each wait is at least half the backoff: 5ms + 10ms + 20ms
+		assert.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+		assert.Equal(t, int64(3), gen.Stats().TotalRetries)
+	})
+
+	t.Run("caps the wait at MaxBackoff", func(t *testing.T) {
+		checker := &collidingChecker{collisions: 4}
+		gen := NewCollisionAwareGeneratorWithOptions(NewRandomGenerator(7), checker, CollisionOptions{
+			MaxRetries: 5,
+			Backoff:    10 * time.Millisecond,
+			MaxBackoff: 10 * time.Millisecond,
+		})
+
+		start := time.Now()
+		_, err := gen.Generate()
+		require.NoError(t, err)
+
+		// Uncapped, the waits would add up to at least 75ms
+		assert.Less(t, time.Since(start), 75*time.Millisecond)
+	})
+
+	t.Run("stops waiting when the context is cancelled", func(t *testing.T) {
+		gen := NewCollisionAwareGeneratorWithOptions(NewRandomGenerator(7), &alwaysExistsChecker{}, CollisionOptions{
+			MaxRetries: 3,
+			Backoff:    time.Hour,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := gen.GenerateWithContext(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestCollisionAwareGenerator_LengthBump(t *testing.T) {
+	t.Run("uses longer codes after consecutive collisions", func(t *testing.T) {
+		checker := &collidingChecker{collisions: 4}
+		gen := NewCollisionAwareGeneratorWithOptions(NewRandomGenerator(7), checker, CollisionOptions{
+			MaxRetries:      5,
+			LengthBumpAfter: 2,
+		})
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Len(t, code, 8)
+
+		require.Len(t, checker.seen, 5)
+		for i, seen := range checker.seen {
+			if i < 2 {
+				assert.Len(t, seen, 7)
+			} else {
+				assert.Len(t, seen, 8, "code %d", i)
+			}
+		}
+
+		stats := gen.Stats()
+		assert.Equal(t, int64(1), stats.TotalLengthBumps)
+		assert.Equal(t, int64(4), stats.TotalCollisions)
+		assert.Equal(t, int64(4), stats.TotalRetries)
+
+		// The next generation starts at the configured length again
+		code, err = gen.Generate()
+		require.NoError(t, err)
+		assert.Len(t, code, 7)
+	})
+
+	t.Run("does not bump past MaxLength", func(t *testing.T) {
+		checker := &collidingChecker{collisions: 3}
+		gen := NewCollisionAwareGeneratorWithOptions(NewRandomGenerator(7), checker, CollisionOptions{
+			MaxRetries:      5,
+			LengthBumpAfter: 1,
+			MaxLength:       7,
+		})
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Len(t, code, 7)
+		assert.Zero(t, gen.Stats().TotalLengthBumps)
+	})
+
+	t.Run("ignores generators without a length", func(t *testing.T) {
+		base, err := NewSnowflakeGenerator(1, 0)
+		require.NoError(t, err)
+		checker := &collidingChecker{collisions: 3}
+		gen := NewCollisionAwareGeneratorWithOptions(base, checker, CollisionOptions{
+			MaxRetries:      5,
+			LengthBumpAfter: 1,
+		})
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+		assert.Zero(t, gen.Stats().TotalLengthBumps)
+	})
+
+	t.Run("still fails after max retries", func(t *testing.T) {
+		gen := NewCollisionAwareGeneratorWithOptions(NewRandomGenerator(7), &alwaysExistsChecker{}, CollisionOptions{
+			MaxRetries:      3,
+			LengthBumpAfter: 2,
+		})
+
+		_, err := gen.Generate()
+		assert.ErrorIs(t, err, ErrMaxRetriesExceeded)
+		assert.Equal(t, int64(1), gen.Stats().TotalLengthBumps)
+	})
+}
+
 func BenchmarkCollisionAwareGenerator(b *testing.B) {
 	checker := &neverExistsChecker{}
 	base := NewRandomGenerator(7)
@@ -268,3 +399,52 @@ func BenchmarkCollisionAwareGenerator(b *testing.B) {
 		_, _ = gen.Generate()
 	}
 }
+
+// sequenceGenerator returns codes in order, then errors.
+type sequenceGenerator struct {
+	codes []string
+}
+
+func (s *sequenceGenerator) Generate() (string, error) {
+	if len(s.codes) == 0 {
+		return "", assert.AnError
+	}
+	code := s.codes[0]
+	s.codes = s.codes[1:]
+	return code, nil
+}
+
+func TestCollisionAwareGenerator_Reserved(t *testing.T) {
+	t.Run("retries when a reserved code is generated", func(t *testing.T) {
+		base := &sequenceGenerator{codes: []string{"health", "api", "abc1234"}}
+		gen := NewCollisionAwareGeneratorWithOptions(base, &neverExistsChecker{}, CollisionOptions{
+			MaxRetries: 3,
+			Reserved:   DefaultReservedCodes,
+		})
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Equal(t, "abc1234", code)
+		assert.Equal(t, int64(2), gen.Stats().TotalRetries)
+	})
+
+	t.Run("gives up when only reserved codes are generated", func(t *testing.T) {
+		base := &sequenceGenerator{codes: []string{"docs", "docs"}}
+		gen := NewCollisionAwareGeneratorWithOptions(base, &neverExistsChecker{}, CollisionOptions{
+			MaxRetries: 1,
+			Reserved:   []string{"docs"},
+		})
+
+		_, err := gen.Generate()
+		assert.ErrorIs(t, err, ErrMaxRetriesExceeded)
+	})
+
+	t.Run("nil reserves nothing", func(t *testing.T) {
+		base := &sequenceGenerator{codes: []string{"health"}}
+		gen := NewCollisionAwareGenerator(base, &neverExistsChecker{}, 0)
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Equal(t, "health", code)
+	})
+}
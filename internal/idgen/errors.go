@@ -7,9 +7,19 @@ var (
 	// ErrInvalidNodeID is returned when the node ID is out of valid range (0-1023).
 	ErrInvalidNodeID = errors.New("node ID must be between 0 and 1023")
 
+	// ErrInvalidEpoch is returned when a snowflake epoch is in the future or
+	// so far in the past that IDs would overflow.
+	ErrInvalidEpoch = errors.New("epoch must be in the past and less than 69 years ago")
+
 	// ErrClockMovedBackwards is returned when the system clock moves backwards.
 	ErrClockMovedBackwards = errors.New("clock moved backwards, refusing to generate ID")
 
 	// ErrMaxRetriesExceeded is returned when collision retry limit is reached.
 	ErrMaxRetriesExceeded = errors.New("maximum retries exceeded for unique ID generation")
+
+	// ErrCounterExhausted is returned when a sequential counter has no values left.
+	ErrCounterExhausted = errors.New("sequential ID counter exhausted")
+
+	// ErrReservedCode is returned when a short code is in the reserved set.
+	ErrReservedCode = errors.New("short code is reserved")
 )
@@ -0,0 +1,61 @@
+package idgen
+
+import (
+	"time"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// Generation strategies accepted by NewGenerator.
+const (
+	StrategyRandom     = "random"
+	StrategySequential = "sequential"
+	StrategySnowflake  = "snowflake"
+)
+
+// GeneratorConfig holds the settings NewGenerator uses. Each strategy reads
+// only the fields it needs.
+type GeneratorConfig struct {
+	// CodeLength is the length of random codes and the minimum length of
+	// snowflake codes.
+	CodeLength int
+
+	// Counter backs sequential codes. Nil counts in memory from 1, which
+	// starts over on restart.
+	Counter CounterSource
+
+	// NodeID and Epoch configure snowflake codes; a zero Epoch uses
+	// DefaultSnowflakeEpoch.
+	NodeID int64
+	Epoch  time.Time
+
+	// Logger receives a warning when the strategy is unknown. Nil disables it.
+	Logger *logger.Logger
+}
+
+// NewGenerator returns the Generator for strategy. Unknown strategies fall
+// back to random codes with a warning rather than failing, so a typo does
+// not keep the service from starting.
+func NewGenerator(strategy string, cfg GeneratorConfig) (Generator, error) {
+	switch strategy {
+	case StrategyRandom:
+		return NewRandomGenerator(cfg.CodeLength), nil
+	case StrategySequential:
+		if cfg.Counter == nil {
+			return NewSequentialGenerator(1), nil
+		}
+		return NewSequentialGeneratorWithSource(cfg.Counter), nil
+	case StrategySnowflake:
+		epoch := cfg.Epoch
+		if epoch.IsZero() {
+			epoch = DefaultSnowflakeEpoch
+		}
+		return NewSnowflakeGeneratorWithEpoch(cfg.NodeID, epoch, cfg.CodeLength)
+	default:
+		if cfg.Logger != nil {
+			cfg.Logger.Warn("unknown ID generation strategy, using random",
+				"strategy", strategy)
+		}
+		return NewRandomGenerator(cfg.CodeLength), nil
+	}
+}
@@ -0,0 +1,77 @@
+package idgen
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedCounter struct{ n uint64 }
+
+func (c *fixedCounter) Next(ctx context.Context) (uint64, error) {
+	c.n++
+	return c.n, nil
+}
+
+func TestNewGenerator(t *testing.T) {
+	t.Run("random", func(t *testing.T) {
+		gen, err := NewGenerator(StrategyRandom, GeneratorConfig{CodeLength: 8})
+		require.NoError(t, err)
+		require.IsType(t, &RandomGenerator{}, gen)
+		assert.Equal(t, 8, gen.(*RandomGenerator).Length())
+	})
+
+	t.Run("sequential uses the counter", func(t *testing.T) {
+		gen, err := NewGenerator(StrategySequential, GeneratorConfig{Counter: &fixedCounter{n: 61}})
+		require.NoError(t, err)
+		require.IsType(t, &SequentialGenerator{}, gen)
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Equal(t, "10", code)
+	})
+
+	t.Run("sequential without a counter counts in memory", func(t *testing.T) {
+		gen, err := NewGenerator(StrategySequential, GeneratorConfig{})
+		require.NoError(t, err)
+		require.IsType(t, &SequentialGenerator{}, gen)
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Equal(t, "1", code)
+	})
+
+	t.Run("snowflake", func(t *testing.T) {
+		epoch := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		gen, err := NewGenerator(StrategySnowflake, GeneratorConfig{NodeID: 7, Epoch: epoch})
+		require.NoError(t, err)
+		require.IsType(t, &SnowflakeGenerator{}, gen)
+		assert.Equal(t, int64(7), gen.(*SnowflakeGenerator).NodeID())
+		assert.True(t, gen.(*SnowflakeGenerator).Epoch().Equal(epoch))
+	})
+
+	t.Run("snowflake defaults the epoch", func(t *testing.T) {
+		gen, err := NewGenerator(StrategySnowflake, GeneratorConfig{})
+		require.NoError(t, err)
+		assert.True(t, gen.(*SnowflakeGenerator).Epoch().Equal(DefaultSnowflakeEpoch))
+	})
+
+	t.Run("snowflake rejects an invalid node ID", func(t *testing.T) {
+		_, err := NewGenerator(StrategySnowflake, GeneratorConfig{NodeID: 2048})
+		assert.ErrorIs(t, err, ErrInvalidNodeID)
+	})
+
+	t.Run("unknown strategy falls back to random with a warning", func(t *testing.T) {
+		var buf bytes.Buffer
+		gen, err := NewGenerator("uuid", GeneratorConfig{CodeLength: 7, Logger: logger.New(&buf, "warn")})
+		require.NoError(t, err)
+		assert.IsType(t, &RandomGenerator{}, gen)
+		assert.Contains(t, buf.String(), "unknown ID generation strategy")
+		assert.Contains(t, buf.String(), "uuid")
+	})
+}
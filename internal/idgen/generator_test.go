@@ -101,6 +101,19 @@ func TestDefaultGenerator(t *testing.T) {
 	assert.True(t, IsValid(code))
 }
 
+func TestRandomGenerator_GenerateWithLength(t *testing.T) {
+	gen := NewRandomGenerator(7)
+
+	code, err := gen.GenerateWithLength(9)
+	require.NoError(t, err)
+	assert.Len(t, code, 9)
+	assert.True(t, IsValid(code))
+
+	code, err = gen.GenerateWithLength(0)
+	require.NoError(t, err)
+	assert.Len(t, code, 7)
+}
+
 func TestGeneratorInterface(t *testing.T) {
 	// Verify interface compliance at compile time
 	var _ Generator = (*RandomGenerator)(nil)
@@ -124,6 +137,63 @@ func TestRandomGenerator_Length(t *testing.T) {
 	})
 }
 
+func TestNewRandomGeneratorWithAlphabet(t *testing.T) {
+	t.Run("draws characters from the alphabet", func(t *testing.T) {
+		gen, err := NewRandomGeneratorWithAlphabet(8, UnambiguousAlphabet)
+		require.NoError(t, err)
+		assert.Equal(t, UnambiguousAlphabet, gen.Alphabet())
+
+		for i := 0; i < 200; i++ {
+			code, err := gen.Generate()
+			require.NoError(t, err)
+			assert.Len(t, code, 8)
+			assert.NotContains(t, code, "0")
+			assert.NotContains(t, code, "O")
+			assert.NotContains(t, code, "l")
+			assert.NotContains(t, code, "I")
+		}
+	})
+
+	t.Run("codes decode with the same alphabet", func(t *testing.T) {
+		gen, err := NewRandomGeneratorWithAlphabet(6, "abc")
+		require.NoError(t, err)
+		a, err := NewAlphabet("abc")
+		require.NoError(t, err)
+
+		for i := 0; i < 50; i++ {
+			code, err := gen.Generate()
+			require.NoError(t, err)
+			_, err = DecodeWith(code, a)
+			assert.NoError(t, err, "code %q", code)
+		}
+	})
+
+	t.Run("rejects invalid alphabet", func(t *testing.T) {
+		gen, err := NewRandomGeneratorWithAlphabet(7, "aa")
+		assert.ErrorIs(t, err, ErrInvalidAlphabet)
+		assert.Nil(t, gen)
+	})
+
+	t.Run("default length when zero provided", func(t *testing.T) {
+		gen, err := NewRandomGeneratorWithAlphabet(0, UnambiguousAlphabet)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultCodeLength, gen.Length())
+	})
+
+	t.Run("works with collision detection", func(t *testing.T) {
+		gen, err := NewRandomGeneratorWithAlphabet(1, "ab")
+		require.NoError(t, err)
+		checker := newMockExistenceChecker()
+		checker.Add("a")
+
+		// With "a" taken, every unique code is "b"
+		code, err := NewCollisionAwareGenerator(gen, checker, 100).Generate()
+
+		require.NoError(t, err)
+		assert.Equal(t, "b", code)
+	})
+}
+
 func BenchmarkRandomGenerator_Generate(b *testing.B) {
 	gen := NewRandomGenerator(7)
 	b.ResetTimer()
@@ -17,7 +17,8 @@ type Generator interface {
 
 // RandomGenerator generates random Base62 short codes.
 type RandomGenerator struct {
-	length int
+	length   int
+	alphabet string
 }
 
 // NewRandomGenerator creates a new RandomGenerator with the specified code length.
@@ -25,7 +26,20 @@ func NewRandomGenerator(length int) *RandomGenerator {
 	if length < 1 {
 		length = DefaultCodeLength
 	}
-	return &RandomGenerator{length: length}
+	return &RandomGenerator{length: length, alphabet: alphabet}
+}
+
+// NewRandomGeneratorWithAlphabet creates a RandomGenerator drawing characters
+// from alphabet, such as UnambiguousAlphabet for codes that are read by people.
+// Smaller alphabets give fewer distinct codes of a length, so consider a longer
+// length to keep collisions rare.
+func NewRandomGeneratorWithAlphabet(length int, alphabet string) (*RandomGenerator, error) {
+	if _, err := NewAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	g := NewRandomGenerator(length)
+	g.alphabet = alphabet
+	return g, nil
 }
 
 // NewDefaultGenerator creates a RandomGenerator with the default code length.
@@ -33,18 +47,28 @@ func NewDefaultGenerator() *RandomGenerator {
 	return NewRandomGenerator(DefaultCodeLength)
 }
 
-// Generate creates a new random Base62 short code.
+// Generate creates a new random short code from the generator's alphabet.
 // Uses crypto/rand for cryptographically secure randomness.
 func (g *RandomGenerator) Generate() (string, error) {
-	result := make([]byte, g.length)
-	max := big.NewInt(int64(len(alphabet)))
+	return g.GenerateWithLength(g.length)
+}
 
-	for i := 0; i < g.length; i++ {
+// GenerateWithLength is like Generate but creates a code of the given
+// length instead of the configured one. A length below 1 uses the
+// configured length.
+func (g *RandomGenerator) GenerateWithLength(length int) (string, error) {
+	if length < 1 {
+		length = g.length
+	}
+	result := make([]byte, length)
+	max := big.NewInt(int64(len(g.alphabet)))
+
+	for i := 0; i < length; i++ {
 		n, err := rand.Int(rand.Reader, max)
 		if err != nil {
 			return "", err
 		}
-		result[i] = alphabet[n.Int64()]
+		result[i] = g.alphabet[n.Int64()]
 	}
 
 	return string(result), nil
@@ -54,3 +78,8 @@ func (g *RandomGenerator) Generate() (string, error) {
 func (g *RandomGenerator) Length() int {
 	return g.length
 }
+
+// Alphabet returns the characters codes are drawn from.
+func (g *RandomGenerator) Alphabet() string {
+	return g.alphabet
+}
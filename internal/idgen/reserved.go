@@ -0,0 +1,25 @@
+package idgen
+
+// DefaultReservedCodes are the first path segments of the server's own
+// routes. A short code equal to one of them would be shadowed by, or shadow,
+// that route.
+var DefaultReservedCodes = []string{"api", "debug", "docs", "health", "info", "metrics", "ready"}
+
+// ReservedSet is a set of short codes that must never be minted or accepted
+// as custom aliases.
+type ReservedSet map[string]struct{}
+
+// NewReservedSet creates a ReservedSet holding codes.
+func NewReservedSet(codes []string) ReservedSet {
+	set := make(ReservedSet, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return set
+}
+
+// Contains reports whether code is reserved.
+func (s ReservedSet) Contains(code string) bool {
+	_, ok := s[code]
+	return ok
+}
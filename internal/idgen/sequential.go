@@ -0,0 +1,74 @@
+package idgen
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+)
+
+// CounterSource supplies the counter values a SequentialGenerator encodes.
+// Backing it with durable storage, such as a database sequence, keeps codes
+// unique across restarts and between instances.
+type CounterSource interface {
+	// Next returns a counter value that was never returned before.
+	Next(ctx context.Context) (uint64, error)
+}
+
+// memoryCounter is an in-process CounterSource.
+type memoryCounter struct {
+	next atomic.Uint64
+}
+
+// Next returns the current value and advances the counter. The maximum
+// uint64 is never returned, so the counter cannot wrap around to reused values.
+func (c *memoryCounter) Next(ctx context.Context) (uint64, error) {
+	for {
+		n := c.next.Load()
+		if n == math.MaxUint64 {
+			return 0, ErrCounterExhausted
+		}
+		if c.next.CompareAndSwap(n, n+1) {
+			return n, nil
+		}
+	}
+}
+
+// SequentialGenerator generates the shortest possible codes by Base62-encoding
+// an increasing counter: "1", "2", ..., "Z", "10", "11", and so on.
+//
+// Sequential codes trade guessability for brevity: anyone who sees one code
+// can enumerate the others, so only use it where links are not private.
+type SequentialGenerator struct {
+	source CounterSource
+}
+
+// NewSequentialGenerator creates a SequentialGenerator counting in memory from
+// start. The counter is lost on restart, so wrap it in a
+// CollisionAwareGenerator or use NewSequentialGeneratorWithSource instead.
+func NewSequentialGenerator(start uint64) *SequentialGenerator {
+	c := &memoryCounter{}
+	c.next.Store(start)
+	return NewSequentialGeneratorWithSource(c)
+}
+
+// NewSequentialGeneratorWithSource creates a SequentialGenerator that encodes
+// the values of source.
+func NewSequentialGeneratorWithSource(source CounterSource) *SequentialGenerator {
+	return &SequentialGenerator{source: source}
+}
+
+// Generate creates the code of the next counter value.
+// Uses a background context.
+func (g *SequentialGenerator) Generate() (string, error) {
+	return g.GenerateWithContext(context.Background())
+}
+
+// GenerateWithContext creates the code of the next counter value, passing ctx
+// to the counter source.
+func (g *SequentialGenerator) GenerateWithContext(ctx context.Context) (string, error) {
+	n, err := g.source.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return Encode(n), nil
+}
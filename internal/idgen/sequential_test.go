@@ -0,0 +1,153 @@
+package idgen
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCtxKey marks contexts that must reach the counter source.
+type testCtxKey struct{}
+
+// stubCounterSource returns a fixed sequence of values, then err.
+type stubCounterSource struct {
+	values []uint64
+	err    error
+	ctx    context.Context // Context of the last Next call
+}
+
+func (s *stubCounterSource) Next(ctx context.Context) (uint64, error) {
+	s.ctx = ctx
+	if len(s.values) == 0 {
+		return 0, s.err
+	}
+	n := s.values[0]
+	s.values = s.values[1:]
+	return n, nil
+}
+
+func TestSequentialGenerator_Generate(t *testing.T) {
+	t.Run("encodes the counter from start", func(t *testing.T) {
+		gen := NewSequentialGenerator(60)
+
+		var codes []string
+		for i := 0; i < 4; i++ {
+			code, err := gen.Generate()
+			require.NoError(t, err)
+			codes = append(codes, code)
+		}
+
+		assert.Equal(t, []string{"Y", "Z", "10", "11"}, codes)
+	})
+
+	t.Run("first codes are a single character", func(t *testing.T) {
+		gen := NewSequentialGenerator(1)
+
+		for i := 1; i < base; i++ {
+			code, err := gen.Generate()
+			require.NoError(t, err)
+			assert.Len(t, code, 1)
+		}
+	})
+
+	t.Run("codes are unique under concurrency", func(t *testing.T) {
+		gen := NewSequentialGenerator(0)
+		const goroutines, perGoroutine = 50, 200
+
+		var mu sync.Mutex
+		seen := make(map[string]bool, goroutines*perGoroutine)
+		var wg sync.WaitGroup
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < perGoroutine; j++ {
+					code, err := gen.Generate()
+					assert.NoError(t, err)
+					mu.Lock()
+					assert.False(t, seen[code], "duplicate code %q", code)
+					seen[code] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, goroutines*perGoroutine)
+		// Every counter value was issued exactly once
+		for n := uint64(0); n < goroutines*perGoroutine; n++ {
+			assert.True(t, seen[Encode(n)], "missing code for %d", n)
+		}
+	})
+
+	t.Run("does not wrap around", func(t *testing.T) {
+		gen := NewSequentialGenerator(math.MaxUint64 - 1)
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		assert.Equal(t, Encode(math.MaxUint64-1), code)
+
+		_, err = gen.Generate()
+		assert.ErrorIs(t, err, ErrCounterExhausted)
+		_, err = gen.Generate()
+		assert.ErrorIs(t, err, ErrCounterExhausted)
+	})
+}
+
+func TestSequentialGenerator_WithSource(t *testing.T) {
+	t.Run("encodes source values", func(t *testing.T) {
+		source := &stubCounterSource{values: []uint64{1, 62, 3844}}
+		gen := NewSequentialGeneratorWithSource(source)
+
+		var codes []string
+		for i := 0; i < 3; i++ {
+			code, err := gen.Generate()
+			require.NoError(t, err)
+			codes = append(codes, code)
+		}
+
+		assert.Equal(t, []string{"1", "10", "100"}, codes)
+	})
+
+	t.Run("passes the context to the source", func(t *testing.T) {
+		source := &stubCounterSource{values: []uint64{1}}
+		gen := NewSequentialGeneratorWithSource(source)
+		ctx := context.WithValue(context.Background(), testCtxKey{}, "request")
+
+		_, err := gen.GenerateWithContext(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, ctx, source.ctx)
+	})
+
+	t.Run("returns source errors", func(t *testing.T) {
+		sourceErr := errors.New("sequence unavailable")
+		gen := NewSequentialGeneratorWithSource(&stubCounterSource{err: sourceErr})
+
+		code, err := gen.Generate()
+
+		assert.ErrorIs(t, err, sourceErr)
+		assert.Empty(t, code)
+	})
+}
+
+func TestSequentialGenerator_CollisionAware(t *testing.T) {
+	// Codes taken by custom aliases are skipped by advancing the counter
+	source := &stubCounterSource{values: []uint64{1, 2, 3}}
+	checker := newMockExistenceChecker()
+	checker.Add("1")
+	checker.Add("2")
+	ctx := context.WithValue(context.Background(), testCtxKey{}, "request")
+
+	gen := NewCollisionAwareGenerator(NewSequentialGeneratorWithSource(source), checker, 3)
+	code, err := gen.GenerateWithContext(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, "3", code)
+	assert.Equal(t, ctx, source.ctx)
+}
@@ -1,6 +1,7 @@
 package idgen
 
 import (
+	"math"
 	"sync"
 	"time"
 )
@@ -9,6 +10,9 @@ import (
 // Using a custom epoch allows for 69 years of IDs from this date.
 const snowflakeEpoch int64 = 1704067200000 // milliseconds
 
+// DefaultSnowflakeEpoch is the epoch used by NewSnowflakeGenerator.
+var DefaultSnowflakeEpoch = time.UnixMilli(snowflakeEpoch).UTC()
+
 // Bit allocation for Snowflake IDs:
 // - 41 bits for timestamp (milliseconds since epoch) - ~69 years
 // - 10 bits for node ID (0-1023)
@@ -17,8 +21,9 @@ const (
 	nodeBits     = 10
 	sequenceBits = 12
 
-	maxNodeID   = (1 << nodeBits) - 1     // 1023
-	maxSequence = (1 << sequenceBits) - 1 // 4095
+	maxNodeID    = (1 << nodeBits) - 1     // 1023
+	maxSequence  = (1 << sequenceBits) - 1 // 4095
+	maxTimestamp = (1 << 41) - 1           // ~69 years of milliseconds
 
 	nodeShift      = sequenceBits
 	timestampShift = nodeBits + sequenceBits
@@ -29,6 +34,7 @@ const (
 type SnowflakeGenerator struct {
 	mu        sync.Mutex
 	nodeID    int64
+	epoch     int64 // Milliseconds since the Unix epoch
 	sequence  int64
 	lastTime  int64
 	minLength int
@@ -38,14 +44,28 @@ type SnowflakeGenerator struct {
 // nodeID must be between 0 and 1023 (inclusive).
 // minLength specifies the minimum length of the generated Base62 code.
 func NewSnowflakeGenerator(nodeID int64, minLength int) (*SnowflakeGenerator, error) {
+	return NewSnowflakeGeneratorWithEpoch(nodeID, DefaultSnowflakeEpoch, minLength)
+}
+
+// NewSnowflakeGeneratorWithEpoch creates a SnowflakeGenerator that counts
+// time from epoch instead of DefaultSnowflakeEpoch. IDs run out about 69
+// years after the epoch, so a later epoch extends their lifespan, but it
+// must not be in the future. Every generator sharing a database must use
+// the same epoch and a distinct node ID.
+func NewSnowflakeGeneratorWithEpoch(nodeID int64, epoch time.Time, minLength int) (*SnowflakeGenerator, error) {
 	if nodeID < 0 || nodeID > maxNodeID {
 		return nil, ErrInvalidNodeID
 	}
+	elapsed := time.Now().UnixMilli() - epoch.UnixMilli()
+	if elapsed < 0 || elapsed > maxTimestamp {
+		return nil, ErrInvalidEpoch
+	}
 	if minLength < 1 {
 		minLength = DefaultCodeLength
 	}
 	return &SnowflakeGenerator{
 		nodeID:    nodeID,
+		epoch:     epoch.UnixMilli(),
 		minLength: minLength,
 	}, nil
 }
@@ -76,7 +96,7 @@ func (g *SnowflakeGenerator) Generate() (string, error) {
 	g.lastTime = now
 
 	// Generate Snowflake ID
-	id := ((now - snowflakeEpoch) << timestampShift) |
+	id := ((now - g.epoch) << timestampShift) |
 		(g.nodeID << nodeShift) |
 		g.sequence
 
@@ -88,3 +108,28 @@ func (g *SnowflakeGenerator) Generate() (string, error) {
 func (g *SnowflakeGenerator) NodeID() int64 {
 	return g.nodeID
 }
+
+// SnowflakeCodesFitUntil returns when snowflake codes counted from epoch stop
+// fitting in maxLength characters, whatever the node ID. Codes grow a character longer whenever the
+// elapsed time passes a power of 62, so with the 10-character default a code
+// lasts about six years after the epoch, not the 69 the timestamp allows.
+func SnowflakeCodesFitUntil(epoch time.Time, maxLength int) time.Time {
+	lifespan := int64(maxTimestamp) + 1
+	codes := uint64(1) // Number of IDs encodable in maxLength characters
+	for i := 0; i < maxLength; i++ {
+		if codes > math.MaxUint64/base {
+			return epoch.Add(time.Duration(lifespan) * time.Millisecond)
+		}
+		codes *= base
+	}
+	// #nosec G115 -- shifted right by timestampShift, codes fits in an int64
+	if ms := int64(codes >> timestampShift); ms < lifespan {
+		lifespan = ms
+	}
+	return epoch.Add(time.Duration(lifespan) * time.Millisecond)
+}
+
+// Epoch returns the time IDs are counted from.
+func (g *SnowflakeGenerator) Epoch() time.Time {
+	return time.UnixMilli(g.epoch).UTC()
+}
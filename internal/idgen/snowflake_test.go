@@ -3,6 +3,7 @@ package idgen
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,6 +46,48 @@ func TestNewSnowflakeGenerator(t *testing.T) {
 	})
 }
 
+func TestNewSnowflakeGeneratorWithEpoch(t *testing.T) {
+	t.Run("counts time from the given epoch", func(t *testing.T) {
+		epoch := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		gen, err := NewSnowflakeGeneratorWithEpoch(5, epoch, 1)
+		require.NoError(t, err)
+		assert.True(t, gen.Epoch().Equal(epoch))
+
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		id, err := Decode(code)
+		require.NoError(t, err)
+
+		elapsed := time.Duration(id>>timestampShift) * time.Millisecond
+		assert.InDelta(t, time.Hour, elapsed, float64(time.Minute))
+		assert.Equal(t, uint64(5), (id>>nodeShift)&maxNodeID)
+	})
+
+	t.Run("default epoch", func(t *testing.T) {
+		gen, err := NewSnowflakeGenerator(1, 7)
+		require.NoError(t, err)
+		assert.True(t, gen.Epoch().Equal(DefaultSnowflakeEpoch))
+	})
+
+	t.Run("rejects an epoch in the future", func(t *testing.T) {
+		gen, err := NewSnowflakeGeneratorWithEpoch(1, time.Now().Add(time.Hour), 7)
+		assert.ErrorIs(t, err, ErrInvalidEpoch)
+		assert.Nil(t, gen)
+	})
+
+	t.Run("rejects an epoch too far in the past", func(t *testing.T) {
+		gen, err := NewSnowflakeGeneratorWithEpoch(1, time.Now().AddDate(-70, 0, 0), 7)
+		assert.ErrorIs(t, err, ErrInvalidEpoch)
+		assert.Nil(t, gen)
+	})
+
+	t.Run("rejects an invalid node ID", func(t *testing.T) {
+		gen, err := NewSnowflakeGeneratorWithEpoch(1024, DefaultSnowflakeEpoch, 7)
+		assert.ErrorIs(t, err, ErrInvalidNodeID)
+		assert.Nil(t, gen)
+	})
+}
+
 func TestSnowflakeGenerator_Generate(t *testing.T) {
 	t.Run("generates valid base62 codes", func(t *testing.T) {
 		gen, err := NewSnowflakeGenerator(1, 7)
@@ -160,6 +203,41 @@ func TestSnowflakeGenerator_DifferentNodes(t *testing.T) {
 	}
 }
 
+func TestSnowflakeGenerator_DifferentMachinesNeverCollide(t *testing.T) {
+	// Generators on different machines run concurrently and share the
+	// same milliseconds, so only the machine ID keeps their codes apart.
+	const perGenerator = 20000
+	epoch := time.Now().Add(-time.Minute)
+
+	results := make([][]string, 2)
+	var wg sync.WaitGroup
+	for i := range results {
+		gen, err := NewSnowflakeGeneratorWithEpoch(int64(i+1), epoch, 7)
+		require.NoError(t, err)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perGenerator; j++ {
+				code, err := gen.Generate()
+				if err != nil {
+					continue
+				}
+				results[i] = append(results[i], code)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, 2*perGenerator)
+	for _, codes := range results {
+		require.Len(t, codes, perGenerator)
+		for _, code := range codes {
+			assert.False(t, seen[code], "duplicate code %s", code)
+			seen[code] = true
+		}
+	}
+}
+
 func BenchmarkSnowflakeGenerator_Generate(b *testing.B) {
 	gen, _ := NewSnowflakeGenerator(1, 7)
 	b.ResetTimer()
@@ -219,3 +297,18 @@ func TestSnowflakeGenerator_SequenceOverflow(t *testing.T) {
 		assert.NotEqual(t, code1, code2)
 	})
 }
+
+func TestSnowflakeCodesFitUntil(t *testing.T) {
+	until := SnowflakeCodesFitUntil(DefaultSnowflakeEpoch, 10)
+	assert.Equal(t, 2030, until.Year())
+	assert.Equal(t, time.May, until.Month())
+
+	// The largest ID of the last millisecond that fits encodes to 10 characters,
+	// and that of the next millisecond does not
+	last := until.Sub(DefaultSnowflakeEpoch).Milliseconds() - 1
+	assert.Len(t, EncodeWithPadding(uint64(last<<timestampShift|maxNodeID<<nodeShift|maxSequence), 1), 10)
+	assert.Len(t, EncodeWithPadding(uint64((last+1)<<timestampShift|maxNodeID<<nodeShift|maxSequence), 1), 11)
+
+	// Longer codes last until the timestamp runs out
+	assert.Equal(t, DefaultSnowflakeEpoch.Add((maxTimestamp+1)*time.Millisecond), SnowflakeCodesFitUntil(DefaultSnowflakeEpoch, 11))
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// ContextLogger returns a middleware that stores log in the request context,
+// so code handling the request can log through logger.FromContext with the
+// request ID attached.
+func ContextLogger(log *logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(logger.NewContext(r.Context(), log)))
+		})
+	}
+}
+
+// AccessLog returns a middleware that emits a structured log line for each request.
+// It must run after RequestID and ClientIP so their context values are available.
+// Successful requests log at info, client errors at warn and server errors at error,
+// so LOG_LEVEL can be used to keep only failing requests.
+func AccessLog(log *logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := newResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			keyvals := []interface{}{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rw.statusCode,
+				"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"bytes", rw.bytesWritten,
+				"client_ip", GetClientIP(r.Context()),
+				"request_id", GetRequestID(r.Context()),
+			}
+
+			switch {
+			case rw.statusCode >= http.StatusInternalServerError:
+				log.Error("request completed", keyvals...)
+			case rw.statusCode >= http.StatusBadRequest:
+				log.Warn("request completed", keyvals...)
+			default:
+				log.Info("request completed", keyvals...)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "info")
+
+	handler := New(RequestID(), ContextLogger(log)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handling")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	req.Header.Set(HeaderXRequestID, "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "handling", entry["msg"])
+	assert.Equal(t, "req-123", entry["request_id"])
+}
+
+func TestAccessLog(t *testing.T) {
+	serve := func(t *testing.T, level string, status int, body string) map[string]interface{} {
+		t.Helper()
+
+		var buf bytes.Buffer
+		log := logger.New(&buf, level)
+
+		handler := New(RequestID(), ClientIP(false, nil), AccessLog(log)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+		req.Header.Set(HeaderXRequestID, "req-123")
+		req.RemoteAddr = "192.168.1.10:5555"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if buf.Len() == 0 {
+			return nil
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		return entry
+	}
+
+	t.Run("logs request fields", func(t *testing.T) {
+		entry := serve(t, "info", http.StatusFound, "redirecting")
+		require.NotNil(t, entry)
+
+		assert.Equal(t, "INFO", entry["level"])
+		assert.Equal(t, "request completed", entry["msg"])
+		assert.Equal(t, "GET", entry["method"])
+		assert.Equal(t, "/abc1234", entry["path"])
+		assert.Equal(t, float64(http.StatusFound), entry["status"])
+		assert.Equal(t, float64(len("redirecting")), entry["bytes"])
+		assert.Equal(t, "192.168.1.10", entry["client_ip"])
+		assert.Equal(t, "req-123", entry["request_id"])
+		assert.Contains(t, entry, "latency_ms")
+	})
+
+	t.Run("logs client errors at warn", func(t *testing.T) {
+		entry := serve(t, "info", http.StatusNotFound, "")
+		require.NotNil(t, entry)
+		assert.Equal(t, "WARN", entry["level"])
+	})
+
+	t.Run("logs server errors at error", func(t *testing.T) {
+		entry := serve(t, "info", http.StatusInternalServerError, "")
+		require.NotNil(t, entry)
+		assert.Equal(t, "ERROR", entry["level"])
+	})
+
+	t.Run("respects the logger level", func(t *testing.T) {
+		assert.Nil(t, serve(t, "warn", http.StatusOK, "ok"))
+		assert.NotNil(t, serve(t, "warn", http.StatusBadRequest, ""))
+	})
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+)
+
+// ErrInvalidAPIKey is returned by a KeyStore when a key is not recognised.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// KeyStore validates API keys.
+type KeyStore interface {
+	// Validate returns nil if key is valid, ErrInvalidAPIKey if it is not,
+	// or another error if the store could not be consulted.
+	Validate(ctx context.Context, key string) error
+}
+
+// StaticKeyStore is a KeyStore backed by a fixed set of keys.
+// Keys are held as SHA-256 digests so lookups do not compare raw secrets.
+type StaticKeyStore struct {
+	keys map[[sha256.Size]byte]struct{}
+}
+
+// NewStaticKeyStore creates a StaticKeyStore from the given keys.
+// Empty keys are ignored.
+func NewStaticKeyStore(keys []string) *StaticKeyStore {
+	s := &StaticKeyStore{keys: make(map[[sha256.Size]byte]struct{}, len(keys))}
+	for _, key := range keys {
+		if key != "" {
+			s.keys[sha256.Sum256([]byte(key))] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Validate implements KeyStore.
+func (s *StaticKeyStore) Validate(_ context.Context, key string) error {
+	if _, ok := s.keys[sha256.Sum256([]byte(key))]; !ok {
+		return ErrInvalidAPIKey
+	}
+	return nil
+}
+
+// APIKeyConfig holds configuration for the API key middleware.
+type APIKeyConfig struct {
+	Header    string                     // Header carrying the key (default "X-API-Key")
+	Protected func(r *http.Request) bool // Requests that require a key; nil protects every request
+}
+
+// APIKeyAuth returns a middleware that requires a valid API key on protected requests.
+// Missing or invalid keys are rejected with 401. The accepted key is stored in the
+// request context, where the rate limiter picks it up as its identifier.
+func APIKeyAuth(store KeyStore, cfg APIKeyConfig) Middleware {
+	header := cfg.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Protected != nil && !cfg.Protected(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(header)
+			if key == "" {
+				WriteError(w, r, http.StatusUnauthorized, "missing API key", "UNAUTHORIZED")
+				return
+			}
+
+			if err := store.Validate(r.Context(), key); err != nil {
+				if errors.Is(err, ErrInvalidAPIKey) {
+					WriteError(w, r, http.StatusUnauthorized, "invalid API key", "UNAUTHORIZED")
+					return
+				}
+				WriteError(w, r, http.StatusInternalServerError, "failed to validate API key", "INTERNAL_ERROR")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), APIKeyKey, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
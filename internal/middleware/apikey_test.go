@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingKeyStore is a KeyStore that always fails with err.
+type failingKeyStore struct {
+	err error
+}
+
+func (f failingKeyStore) Validate(ctx context.Context, key string) error {
+	return f.err
+}
+
+func TestStaticKeyStore(t *testing.T) {
+	store := NewStaticKeyStore([]string{"key-one", "", "key-two"})
+
+	assert.NoError(t, store.Validate(context.Background(), "key-one"))
+	assert.NoError(t, store.Validate(context.Background(), "key-two"))
+	assert.ErrorIs(t, store.Validate(context.Background(), "key-three"), ErrInvalidAPIKey)
+	assert.ErrorIs(t, store.Validate(context.Background(), ""), ErrInvalidAPIKey)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	store := NewStaticKeyStore([]string{"secret"})
+
+	t.Run("accepts a valid key and stores it in context", func(t *testing.T) {
+		var gotKey string
+		handler := APIKeyAuth(store, APIKeyConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotKey = GetAPIKey(r.Context())
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+		req.Header.Set("X-API-Key", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "secret", gotKey)
+	})
+
+	t.Run("uses the configured header", func(t *testing.T) {
+		handler := APIKeyAuth(store, APIKeyConfig{Header: "X-Custom-Key"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+		req.Header.Set("X-Custom-Key", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	tests := []struct {
+		name    string
+		key     string
+		message string
+	}{
+		{name: "rejects a missing key", key: "", message: "missing API key"},
+		{name: "rejects an invalid key", key: "wrong", message: "invalid API key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := APIKeyAuth(store, APIKeyConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.False(t, called, "handler should not run")
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var resp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.message, resp.Error)
+			assert.Equal(t, "UNAUTHORIZED", resp.Code)
+		})
+	}
+
+	t.Run("returns 500 when the store fails", func(t *testing.T) {
+		handler := APIKeyAuth(failingKeyStore{err: errors.New("store down")}, APIKeyConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not run")
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+		req.Header.Set("X-API-Key", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("skips unprotected requests", func(t *testing.T) {
+		cfg := APIKeyConfig{
+			Protected: func(r *http.Request) bool { return r.Method != http.MethodGet },
+		}
+		handler := APIKeyAuth(store, cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, GetAPIKey(r.Context()))
+			w.WriteHeader(http.StatusFound)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+}
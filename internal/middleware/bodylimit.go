@@ -0,0 +1,21 @@
+package middleware
+
+import "net/http"
+
+// MaxBodyBytes returns a middleware that limits request bodies to n bytes.
+// Requests declaring a larger Content-Length are rejected with 413 up front;
+// other bodies are wrapped with http.MaxBytesReader so reads fail once the
+// limit is crossed, which handlers report as 413 as well.
+func MaxBodyBytes(n int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				WriteError(w, r, http.StatusRequestEntityTooLarge, "request body too large", "BODY_TOO_LARGE")
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	t.Run("passes bodies within the limit", func(t *testing.T) {
+		var got []byte
+		handler := MaxBodyBytes(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			got, err = io.ReadAll(r.Body)
+			require.NoError(t, err)
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", strings.NewReader("small body"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "small body", string(got))
+	})
+
+	t.Run("rejects oversized content length with 413", func(t *testing.T) {
+		called := false
+		handler := MaxBodyBytes(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+		body := `{"url":"https://example.com/a/very/long/path"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.False(t, called, "handler should not run")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var resp ErrorResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "BODY_TOO_LARGE", resp.Code)
+	})
+
+	t.Run("limits bodies without content length", func(t *testing.T) {
+		var readErr error
+		handler := MaxBodyBytes(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, readErr = io.ReadAll(r.Body)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", bytes.NewReader(make([]byte, 64)))
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var maxBytesErr *http.MaxBytesError
+		assert.ErrorAs(t, readErr, &maxBytesErr)
+	})
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorFormat selects how error responses are rendered.
+type ErrorFormat string
+
+const (
+	// ErrorFormatJSON renders errors as an ErrorResponse.
+	ErrorFormatJSON ErrorFormat = "json"
+	// ErrorFormatProblem renders errors as RFC 7807 application/problem+json.
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// ProblemDetails is the RFC 7807 body written in ErrorFormatProblem mode.
+// Code and RetryAfter are extension members carrying the same values as
+// the default format.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"` // Request ID
+	Code     string `json:"code,omitempty"`
+
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// ErrorFormatter returns a middleware that makes errors for the request
+// render in format. It should run before anything that can write an error.
+func ErrorFormatter(format ErrorFormat) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ErrorFormatKey, format)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetErrorFormat retrieves the error format from context, defaulting to
+// ErrorFormatJSON.
+func GetErrorFormat(ctx context.Context) ErrorFormat {
+	if format, ok := ctx.Value(ErrorFormatKey).(ErrorFormat); ok {
+		return format
+	}
+	return ErrorFormatJSON
+}
+
+// WriteError writes an error with the given status, message and code in the
+// format selected for r. Handlers and middleware both render errors through
+// it so every error response has the same shape.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message, code string) {
+	if GetErrorFormat(r.Context()) == ErrorFormatProblem {
+		writeProblem(w, r, ProblemDetails{Status: status, Detail: message, Code: code})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{
+		Error: message,
+		Code:  code,
+	})
+}
+
+// writeProblem fills in the type, title and instance of p and writes it.
+func writeProblem(w http.ResponseWriter, r *http.Request, p ProblemDetails) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.Instance = GetRequestID(r.Context())
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
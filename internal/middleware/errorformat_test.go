@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/ratelimit"
+)
+
+func TestWriteError(t *testing.T) {
+	t.Run("defaults to the JSON error format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc", nil)
+		rec := httptest.NewRecorder()
+
+		WriteError(rec, req, http.StatusNotFound, "url not found", "NOT_FOUND")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"url not found","code":"NOT_FOUND"}`, rec.Body.String())
+	})
+
+	t.Run("renders problem details when selected", func(t *testing.T) {
+		handler := New(RequestID(), ErrorFormatter(ErrorFormatProblem)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteError(w, r, http.StatusNotFound, "url not found", "NOT_FOUND")
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc", nil)
+		req.Header.Set("X-Request-ID", "req-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+		var problem ProblemDetails
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, ProblemDetails{
+			Type:     "about:blank",
+			Title:    "Not Found",
+			Status:   http.StatusNotFound,
+			Detail:   "url not found",
+			Instance: "req-123",
+			Code:     "NOT_FOUND",
+		}, problem)
+	})
+
+	t.Run("JSON format can be selected explicitly", func(t *testing.T) {
+		handler := ErrorFormatter(ErrorFormatJSON)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteError(w, r, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil))
+
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"invalid request body","code":"INVALID_REQUEST"}`, rec.Body.String())
+	})
+}
+
+func TestWriteRateLimitResponse_Problem(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ErrorFormatKey, ErrorFormatProblem)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	writeRateLimitResponse(rec, req, &ratelimit.Result{RetryAfter: 3 * time.Second})
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, "Too Many Requests", problem.Title)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED", problem.Code)
+	assert.Equal(t, 3, problem.RetryAfter)
+}
@@ -7,10 +7,11 @@ import (
 	"github.com/emadnahed/FastGoLink/internal/metrics"
 )
 
-// responseWriter wraps http.ResponseWriter to capture the status code.
+// responseWriter wraps http.ResponseWriter to capture the status code and body size.
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
@@ -25,6 +26,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += n
+	return n, err
+}
+
 // Metrics returns a middleware that records Prometheus metrics.
 func Metrics() Middleware {
 	return func(next http.Handler) http.Handler {
@@ -57,7 +64,7 @@ func normalizePath(path string) string {
 		return "/api/v1/urls/{code}"
 	case len(path) > 18 && path[:18] == "/api/v1/analytics/":
 		return "/api/v1/analytics/{code}"
-	case path == "/api/v1/shorten":
+	case path == "/api/v1/shorten" || path == "/api/v1/shorten/batch":
 		return path
 	default:
 		return "/other"
@@ -25,6 +25,17 @@ func TestResponseWriter(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, rw.statusCode)
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 	})
+
+	t.Run("counts bytes written", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := newResponseWriter(rec)
+
+		_, _ = rw.Write([]byte("hello "))
+		_, _ = rw.Write([]byte("world"))
+
+		assert.Equal(t, 11, rw.bytesWritten)
+		assert.Equal(t, "hello world", rec.Body.String())
+	})
 }
 
 func TestMetrics(t *testing.T) {
@@ -89,6 +100,11 @@ func TestNormalizePath(t *testing.T) {
 			path:     "/api/v1/shorten",
 			expected: "/api/v1/shorten",
 		},
+		{
+			name:     "batch shorten endpoint",
+			path:     "/api/v1/shorten/batch",
+			expected: "/api/v1/shorten/batch",
+		},
 		{
 			name:     "short code redirect",
 			path:     "/abc123",
@@ -4,6 +4,8 @@ package middleware
 import (
 	"context"
 	"net/http"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
 )
 
 // Middleware wraps an http.Handler with additional behavior.
@@ -13,12 +15,23 @@ type Middleware func(http.Handler) http.Handler
 type contextKey string
 
 const (
-	// RequestIDKey is the context key for request ID.
-	RequestIDKey contextKey = "request_id"
+	// RequestIDKey is the context key for request ID. It is the logger's
+	// key, so loggers from logger.FromContext include the ID.
+	RequestIDKey = logger.RequestIDKey
 	// ClientIPKey is the context key for client IP.
 	ClientIPKey contextKey = "client_ip"
+	// APIKeyKey is the context key for the authenticated API key.
+	APIKeyKey contextKey = "api_key"
+	// ErrorFormatKey is the context key for the error format.
+	ErrorFormatKey contextKey = "error_format"
 )
 
+// ErrorResponse is the JSON body middleware writes when it rejects a request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
 // GetRequestID retrieves the request ID from context.
 func GetRequestID(ctx context.Context) string {
 	if id, ok := ctx.Value(RequestIDKey).(string); ok {
@@ -35,6 +48,14 @@ func GetClientIP(ctx context.Context) string {
 	return ""
 }
 
+// GetAPIKey retrieves the authenticated API key from context.
+func GetAPIKey(ctx context.Context) string {
+	if key, ok := ctx.Value(APIKeyKey).(string); ok {
+		return key
+	}
+	return ""
+}
+
 // Chain holds a sequence of middlewares to be applied to handlers.
 type Chain struct {
 	middlewares []Middleware
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+)
+
+// proxySet matches addresses against trusted proxy IPs and CIDR ranges.
+// An empty set trusts every proxy.
+type proxySet []*net.IPNet
+
+// newProxySet parses trusted proxy entries, each a single IP such as
+// "10.0.0.1" or a CIDR range such as "10.0.0.0/8". Entries that are neither
+// are ignored; configuration validates them before they get here.
+func newProxySet(entries []string) proxySet {
+	set := make(proxySet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			set = append(set, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			continue
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		set = append(set, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return set
+}
+
+// trusts reports whether a request from ip may set forwarding headers.
+func (s proxySet) trusts(ip string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	return s.contains(ip)
+}
+
+// contains reports whether ip falls within one of the trusted ranges.
+func (s proxySet) contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range s {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP returns the client IP from an X-Forwarded-For chain of
+// the form "client, proxy1, proxy2". Each proxy appends the address it
+// received the request from, so only the entries added by trusted proxies
+// are reliable: the chain is walked from the right, skipping trusted hops,
+// and the first untrusted address is the client. When every hop is trusted
+// the leftmost entry is used, as it is when no proxies are configured. It
+// returns "" for an empty chain.
+func forwardedClientIP(xff string, trustedProxies proxySet) string {
+	hops := strings.Split(xff, ",")
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		client = hop
+		if !trustedProxies.trusts(hop) {
+			break
+		}
+	}
+	return client
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxySet_Trusts(t *testing.T) {
+	set := newProxySet([]string{"10.0.0.0/8", " 192.168.1.5 ", "2001:db8::/32", "not-an-ip"})
+
+	assert.True(t, set.trusts("10.255.0.1"))
+	assert.True(t, set.trusts("192.168.1.5"))
+	assert.True(t, set.trusts("2001:db8::1"))
+	assert.False(t, set.trusts("192.168.1.4"))
+	assert.False(t, set.trusts("11.0.0.1"))
+	assert.False(t, set.trusts("not-an-ip"))
+
+	assert.True(t, newProxySet(nil).trusts("203.0.113.1"), "an empty set trusts everyone")
+}
+
+func TestForwardedClientIP(t *testing.T) {
+	proxies := newProxySet([]string{"10.0.0.0/8", "172.16.0.1"})
+
+	tests := []struct {
+		name    string
+		xff     string
+		proxies proxySet
+		want    string
+	}{
+		{"single client", "203.0.113.7", proxies, "203.0.113.7"},
+		{"skips trusted hops", "203.0.113.7, 10.0.0.2, 172.16.0.1", proxies, "203.0.113.7"},
+		{"ignores spoofed entries left of the client", "1.2.3.4, 203.0.113.7, 10.0.0.2", proxies, "203.0.113.7"},
+		{"untrusted last hop is the client", "203.0.113.7, 198.51.100.9", proxies, "198.51.100.9"},
+		{"all hops trusted uses the leftmost", "10.0.0.3, 10.0.0.2", proxies, "10.0.0.3"},
+		{"no proxies configured uses the leftmost", "203.0.113.7, 198.51.100.9", nil, "203.0.113.7"},
+		{"skips empty entries", "203.0.113.7, , 10.0.0.2,", proxies, "203.0.113.7"},
+		{"empty chain", " ", proxies, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, forwardedClientIP(tt.xff, tt.proxies))
+		})
+	}
+}
+
+func TestClientIP_MultiHopChain(t *testing.T) {
+	mw := ClientIP(true, []string{"10.0.0.0/8"})
+	var capturedIP string
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedIP = GetClientIP(r.Context())
+	}))
+
+	// client, proxy1, proxy2 - the request arrives from a third trusted proxy
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "10.0.0.1:80"
+	req.Header.Set("X-Forwarded-For", "6.6.6.6, 203.0.113.195, 10.0.0.5, 10.0.0.9")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "203.0.113.195", capturedIP)
+}
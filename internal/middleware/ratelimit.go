@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,9 +14,18 @@ import (
 
 // RateLimitConfig holds configuration for the rate limit middleware.
 type RateLimitConfig struct {
-	TrustProxy   bool     // Trust X-Forwarded-For header
-	APIKeyHeader string   // Header name for API key (e.g., "X-API-Key")
-	TrustedProxies []string // List of trusted proxy IPs
+	TrustProxy     bool         // Trust X-Forwarded-For header
+	APIKeyHeader   string       // Header name for API key (e.g., "X-API-Key")
+	TrustedProxies []string     // Trusted proxy IPs and CIDR ranges; empty trusts all
+	AllowlistCIDRs []string     // Client IPs and CIDR ranges that are never rate limited
+	Routes         []RouteLimit // Per-route limiters; unmatched requests use the default limiter
+}
+
+// RouteLimit applies a dedicated limiter to requests whose path starts with Prefix.
+// When several prefixes match, the longest one wins.
+type RouteLimit struct {
+	Prefix  string
+	Limiter ratelimit.Limiter
 }
 
 // RateLimitResponse is the JSON response for rate limited requests.
@@ -26,20 +36,41 @@ type RateLimitResponse struct {
 }
 
 // RateLimit returns a middleware that rate limits requests.
-// It uses the provided limiter to check if requests should be allowed.
+// It uses the limiter of the longest matching route in cfg.Routes, falling back
+// to the provided limiter. A nil limiter leaves unmatched routes unlimited.
+// Requests from clients in cfg.AllowlistCIDRs skip the limiter entirely, so
+// they neither consume its budget nor get rate limit headers.
 func RateLimit(limiter ratelimit.Limiter, cfg RateLimitConfig) Middleware {
-	trustedSet := make(map[string]bool)
-	for _, ip := range cfg.TrustedProxies {
-		trustedSet[ip] = true
-	}
+	trustedSet := newProxySet(cfg.TrustedProxies)
+	allowlist := newProxySet(cfg.AllowlistCIDRs)
+
+	// Sort routes so the longest (most specific) prefix is matched first
+	routes := make([]RouteLimit, len(cfg.Routes))
+	copy(routes, cfg.Routes)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Prefix) > len(routes[j].Prefix)
+	})
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Pick the limiter for this route
+			l := matchLimiter(r.URL.Path, routes, limiter)
+			if l == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Allowlisted clients, such as health checkers, are not limited
+			if len(allowlist) > 0 && allowlist.contains(getClientIPForRateLimit(r, cfg.TrustProxy, trustedSet)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Determine the identifier for rate limiting
 			identifier := getIdentifier(r, cfg, trustedSet)
 
 			// Check rate limit
-			result, err := limiter.Allow(r.Context(), identifier)
+			result, err := l.Allow(r.Context(), identifier)
 			if err != nil {
 				// Fail open on error - log and continue
 				next.ServeHTTP(w, r)
@@ -51,7 +82,7 @@ func RateLimit(limiter ratelimit.Limiter, cfg RateLimitConfig) Middleware {
 
 			if !result.Allowed {
 				// Rate limited
-				writeRateLimitResponse(w, result)
+				writeRateLimitResponse(w, r, result)
 				return
 			}
 
@@ -60,10 +91,27 @@ func RateLimit(limiter ratelimit.Limiter, cfg RateLimitConfig) Middleware {
 	}
 }
 
+// matchLimiter returns the limiter of the longest matching route prefix,
+// or the default limiter if no route matches. Routes must be sorted by
+// descending prefix length.
+func matchLimiter(path string, routes []RouteLimit, def ratelimit.Limiter) ratelimit.Limiter {
+	for _, route := range routes {
+		if strings.HasPrefix(path, route.Prefix) {
+			return route.Limiter
+		}
+	}
+	return def
+}
+
 // getIdentifier determines the rate limit identifier for the request.
-// It prefers API key if configured and provided, otherwise uses client IP.
-func getIdentifier(r *http.Request, cfg RateLimitConfig, trustedProxies map[string]bool) string {
-	// Check for API key first
+// It prefers an API key authenticated by APIKeyAuth, then the API key header
+// if configured and provided, otherwise uses client IP.
+func getIdentifier(r *http.Request, cfg RateLimitConfig, trustedProxies proxySet) string {
+	if apiKey := GetAPIKey(r.Context()); apiKey != "" {
+		return "api:" + apiKey
+	}
+
+	// Fall back to the raw API key header
 	if cfg.APIKeyHeader != "" {
 		apiKey := r.Header.Get(cfg.APIKeyHeader)
 		if apiKey != "" {
@@ -77,7 +125,7 @@ func getIdentifier(r *http.Request, cfg RateLimitConfig, trustedProxies map[stri
 }
 
 // getClientIPForRateLimit extracts the client IP for rate limiting.
-func getClientIPForRateLimit(r *http.Request, trustProxy bool, trustedProxies map[string]bool) string {
+func getClientIPForRateLimit(r *http.Request, trustProxy bool, trustedProxies proxySet) string {
 	// First check if IP is in context (from ClientIP middleware)
 	if ip := GetClientIP(r.Context()); ip != "" {
 		return ip
@@ -90,19 +138,13 @@ func getClientIPForRateLimit(r *http.Request, trustProxy bool, trustedProxies ma
 	}
 
 	// Check if the immediate connection is from a trusted proxy
-	if len(trustedProxies) > 0 && !trustedProxies[remoteIP] {
+	if !trustedProxies.trusts(remoteIP) {
 		return remoteIP
 	}
 
 	// Check X-Forwarded-For header
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			clientIP := strings.TrimSpace(ips[0])
-			if clientIP != "" {
-				return clientIP
-			}
-		}
+	if clientIP := forwardedClientIP(r.Header.Get("X-Forwarded-For"), trustedProxies); clientIP != "" {
+		return clientIP
 	}
 
 	// Check X-Real-IP header
@@ -142,15 +184,25 @@ func setRateLimitHeaders(w http.ResponseWriter, result *ratelimit.Result) {
 }
 
 // writeRateLimitResponse writes the 429 response.
-func writeRateLimitResponse(w http.ResponseWriter, result *ratelimit.Result) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusTooManyRequests)
-
+func writeRateLimitResponse(w http.ResponseWriter, r *http.Request, result *ratelimit.Result) {
 	retrySeconds := int(result.RetryAfter.Seconds())
 	if retrySeconds < 1 {
 		retrySeconds = 1
 	}
 
+	if GetErrorFormat(r.Context()) == ErrorFormatProblem {
+		writeProblem(w, r, ProblemDetails{
+			Status:     http.StatusTooManyRequests,
+			Detail:     "rate limit exceeded",
+			Code:       "RATE_LIMIT_EXCEEDED",
+			RetryAfter: retrySeconds,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+
 	resp := RateLimitResponse{
 		Error:      "rate limit exceeded",
 		Code:       "RATE_LIMIT_EXCEEDED",
@@ -159,6 +159,35 @@ func TestRateLimit(t *testing.T) {
 		assert.Equal(t, "api:my-api-key-123", limiter.calls[0])
 	})
 
+	t.Run("uses authenticated API key from context", func(t *testing.T) {
+		limiter := &mockLimiter{
+			result: &ratelimit.Result{
+				Allowed:   true,
+				Remaining: 9,
+				Limit:     10,
+			},
+		}
+
+		chain := New(
+			APIKeyAuth(NewStaticKeyStore([]string{"secret"}), APIKeyConfig{Header: "Authorization-Key"}),
+			RateLimit(limiter, RateLimitConfig{APIKeyHeader: "X-API-Key"}),
+		)
+
+		handler := chain.Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		req.Header.Set("Authorization-Key", "secret")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Len(t, limiter.calls, 1)
+		assert.Equal(t, "api:secret", limiter.calls[0])
+	})
+
 	t.Run("falls back to IP when API key not provided", func(t *testing.T) {
 		limiter := &mockLimiter{
 			result: &ratelimit.Result{
@@ -382,6 +411,27 @@ func TestRateLimit(t *testing.T) {
 		assert.Equal(t, "ip:192.168.1.1", limiter.calls[0])
 	})
 
+	t.Run("uses X-Forwarded-For from trusted CIDR ranges only", func(t *testing.T) {
+		limiter := &mockLimiter{
+			result: &ratelimit.Result{Allowed: true, Remaining: 9, Limit: 10},
+		}
+
+		mw := RateLimit(limiter, RateLimitConfig{
+			TrustProxy:     true,
+			TrustedProxies: []string{"10.0.0.0/8", "172.16.0.1"},
+		})
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		for _, remoteAddr := range []string{"10.1.2.3:1234", "172.16.0.1:1234", "172.16.0.2:1234"} {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.195")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		assert.Equal(t, []string{"ip:203.0.113.195", "ip:203.0.113.195", "ip:172.16.0.2"}, limiter.calls)
+	})
+
 	t.Run("handles empty X-Forwarded-For value", func(t *testing.T) {
 		limiter := &mockLimiter{
 			result: &ratelimit.Result{
@@ -489,3 +539,150 @@ func TestRateLimit(t *testing.T) {
 		assert.Empty(t, rec.Header().Get("Retry-After"))
 	})
 }
+
+func TestRateLimit_Routes(t *testing.T) {
+	newLimiter := func(limit int) *mockLimiter {
+		return &mockLimiter{result: &ratelimit.Result{Allowed: true, Remaining: limit - 1, Limit: limit}}
+	}
+
+	serve := func(mw Middleware, path string) *httptest.ResponseRecorder {
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("uses route limiter for matching prefix", func(t *testing.T) {
+		def := newLimiter(1000)
+		shorten := newLimiter(10)
+
+		mw := RateLimit(def, RateLimitConfig{
+			Routes: []RouteLimit{{Prefix: "/api/v1/shorten", Limiter: shorten}},
+		})
+
+		rec := serve(mw, "/api/v1/shorten")
+
+		assert.Equal(t, "10", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Len(t, shorten.calls, 1)
+		assert.Empty(t, def.calls)
+	})
+
+	t.Run("falls back to default limiter", func(t *testing.T) {
+		def := newLimiter(1000)
+		shorten := newLimiter(10)
+
+		mw := RateLimit(def, RateLimitConfig{
+			Routes: []RouteLimit{{Prefix: "/api/v1/shorten", Limiter: shorten}},
+		})
+
+		rec := serve(mw, "/abc1234")
+
+		assert.Equal(t, "1000", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Len(t, def.calls, 1)
+		assert.Empty(t, shorten.calls)
+	})
+
+	t.Run("longest prefix wins", func(t *testing.T) {
+		api := newLimiter(100)
+		batch := newLimiter(5)
+
+		mw := RateLimit(nil, RateLimitConfig{
+			Routes: []RouteLimit{
+				{Prefix: "/api/", Limiter: api},
+				{Prefix: "/api/v1/shorten/batch", Limiter: batch},
+			},
+		})
+
+		rec := serve(mw, "/api/v1/shorten/batch")
+
+		assert.Equal(t, "5", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Empty(t, api.calls)
+	})
+
+	t.Run("nil default leaves unmatched routes unlimited", func(t *testing.T) {
+		shorten := newLimiter(10)
+
+		mw := RateLimit(nil, RateLimitConfig{
+			Routes: []RouteLimit{{Prefix: "/api/v1/shorten", Limiter: shorten}},
+		})
+
+		rec := serve(mw, "/health")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+	})
+
+	t.Run("identifier extraction is unchanged per route", func(t *testing.T) {
+		shorten := newLimiter(10)
+
+		mw := RateLimit(nil, RateLimitConfig{
+			APIKeyHeader: "X-API-Key",
+			Routes:       []RouteLimit{{Prefix: "/api/v1/shorten", Limiter: shorten}},
+		})
+
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+		req.Header.Set("X-API-Key", "secret")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, shorten.calls, 1)
+		assert.Equal(t, "api:secret", shorten.calls[0])
+	})
+}
+
+func TestRateLimit_Allowlist(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.Config{Requests: 2, Window: time.Minute})
+	defer limiter.Close()
+
+	chain := New(
+		ClientIP(true, []string{"172.16.0.1"}),
+		RateLimit(limiter, RateLimitConfig{
+			TrustProxy:     true,
+			TrustedProxies: []string{"172.16.0.1"},
+			AllowlistCIDRs: []string{"10.0.0.0/8", "127.0.0.1"},
+		}),
+	)
+	handler := chain.Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(clientIP string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.RemoteAddr = "172.16.0.1:1234"
+		req.Header.Set("X-Forwarded-For", clientIP)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("allowlisted clients are never limited", func(t *testing.T) {
+		for _, ip := range []string{"10.1.2.3", "127.0.0.1"} {
+			for i := 0; i < 10; i++ {
+				rec := serve(ip)
+				require.Equal(t, http.StatusOK, rec.Code, "%s request %d", ip, i+1)
+				assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+			}
+		}
+	})
+
+	t.Run("other clients are limited", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("203.0.113.7").Code)
+		assert.Equal(t, http.StatusOK, serve("203.0.113.7").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.7").Code)
+	})
+
+	t.Run("matches the resolved client IP, not the proxy", func(t *testing.T) {
+		// The proxy itself is outside the allowlist, but its forwarded
+		// client is in it; a forwarded outsider is still limited
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, serve("10.9.9.9").Code)
+		}
+		serve("198.51.100.1")
+		serve("198.51.100.1")
+		assert.Equal(t, http.StatusTooManyRequests, serve("198.51.100.1").Code)
+	})
+}
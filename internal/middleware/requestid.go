@@ -60,12 +60,10 @@ func isValidRequestID(id string) bool {
 
 // ClientIP returns a middleware that extracts the client IP address and stores it in context.
 // If trustProxy is true, it will check X-Forwarded-For and X-Real-IP headers.
-// trustedProxies can be used to limit which proxy IPs are trusted.
+// trustedProxies can be used to limit which proxies are trusted; entries are
+// single IPs or CIDR ranges such as "10.0.0.0/8".
 func ClientIP(trustProxy bool, trustedProxies []string) Middleware {
-	trustedSet := make(map[string]bool)
-	for _, ip := range trustedProxies {
-		trustedSet[ip] = true
-	}
+	trustedSet := newProxySet(trustedProxies)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -80,7 +78,7 @@ func ClientIP(trustProxy bool, trustedProxies []string) Middleware {
 }
 
 // extractClientIP extracts the client IP from the request.
-func extractClientIP(r *http.Request, trustProxy bool, trustedProxies map[string]bool) string {
+func extractClientIP(r *http.Request, trustProxy bool, trustedProxies proxySet) string {
 	remoteIP := extractIPFromAddr(r.RemoteAddr)
 
 	if !trustProxy {
@@ -88,21 +86,13 @@ func extractClientIP(r *http.Request, trustProxy bool, trustedProxies map[string
 	}
 
 	// Check if the immediate connection is from a trusted proxy
-	if len(trustedProxies) > 0 && !trustedProxies[remoteIP] {
+	if !trustedProxies.trusts(remoteIP) {
 		return remoteIP
 	}
 
 	// Check X-Forwarded-For header first
-	if xff := r.Header.Get(HeaderXForwardedFor); xff != "" {
-		// X-Forwarded-For can contain multiple IPs: client, proxy1, proxy2
-		// The first IP is typically the original client
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			clientIP := strings.TrimSpace(ips[0])
-			if clientIP != "" {
-				return clientIP
-			}
-		}
+	if clientIP := forwardedClientIP(r.Header.Get(HeaderXForwardedFor), trustedProxies); clientIP != "" {
+		return clientIP
 	}
 
 	// Check X-Real-IP header
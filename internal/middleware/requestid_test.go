@@ -285,6 +285,37 @@ func TestClientIP(t *testing.T) {
 		assert.Equal(t, "192.168.1.1", capturedIP)
 	})
 
+	t.Run("trusts proxies by CIDR range and single IP", func(t *testing.T) {
+		mw := ClientIP(true, []string{"10.0.0.0/8", "192.168.1.5", "2001:db8::/32"})
+
+		tests := []struct {
+			name       string
+			remoteAddr string
+			want       string
+		}{
+			{"inside IPv4 range", "10.20.30.40:80", "203.0.113.195"},
+			{"single IP", "192.168.1.5:80", "203.0.113.195"},
+			{"inside IPv6 range", "[2001:db8::7]:80", "203.0.113.195"},
+			{"outside ranges", "192.168.1.6:80", "192.168.1.6"},
+			{"outside IPv6 range", "[2001:db9::1]:80", "2001:db9::1"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var capturedIP string
+				handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					capturedIP = GetClientIP(r.Context())
+				}))
+
+				req := httptest.NewRequest(http.MethodGet, "/test", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Forwarded-For", "203.0.113.195")
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+
+				assert.Equal(t, tt.want, capturedIP)
+			})
+		}
+	})
+
 	t.Run("falls back to X-Real-IP when X-Forwarded-For is empty", func(t *testing.T) {
 		mw := ClientIP(true, nil)
 		var capturedIP string
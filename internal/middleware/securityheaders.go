@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SecurityHeadersConfig holds configuration for the security headers middleware.
+// Empty values leave the corresponding header unset.
+type SecurityHeadersConfig struct {
+	FrameOptions              string // X-Frame-Options value (e.g., "DENY")
+	ReferrerPolicy            string // Referrer-Policy value (e.g., "no-referrer")
+	ContentSecurityPolicy     string // CSP for API responses
+	DocsPrefix                string // Path prefix of the documentation pages (e.g., "/docs")
+	DocsContentSecurityPolicy string // CSP for paths under DocsPrefix, which load CDN assets
+}
+
+// SecurityHeaders returns a middleware that sets browser security headers on every response.
+// X-Content-Type-Options is always "nosniff"; paths under DocsPrefix get the docs CSP
+// so the Scalar, Swagger and Redoc pages can load their scripts and styles.
+func SecurityHeaders(cfg SecurityHeadersConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if cfg.FrameOptions != "" {
+				h.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			if cfg.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+
+			csp := cfg.ContentSecurityPolicy
+			if cfg.DocsPrefix != "" && strings.HasPrefix(r.URL.Path, cfg.DocsPrefix) {
+				csp = cfg.DocsContentSecurityPolicy
+			}
+			if csp != "" {
+				h.Set("Content-Security-Policy", csp)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
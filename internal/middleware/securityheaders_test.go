@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := SecurityHeadersConfig{
+		FrameOptions:              "DENY",
+		ReferrerPolicy:            "no-referrer",
+		ContentSecurityPolicy:     "default-src 'none'",
+		DocsPrefix:                "/docs",
+		DocsContentSecurityPolicy: "default-src 'self' https://cdn.example.com",
+	}
+	handler := SecurityHeaders(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("sets headers on API responses", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "default-src 'none'", rec.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("uses docs CSP under the docs prefix", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs/swagger", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "default-src 'self' https://cdn.example.com", rec.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("sets headers on error responses", func(t *testing.T) {
+		errHandler := SecurityHeaders(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		rec := httptest.NewRecorder()
+		errHandler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	})
+
+	t.Run("omits empty headers", func(t *testing.T) {
+		handler := SecurityHeaders(SecurityHeadersConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, rec.Header().Get("X-Frame-Options"))
+		assert.Empty(t, rec.Header().Get("Referrer-Policy"))
+		assert.Empty(t, rec.Header().Get("Content-Security-Policy"))
+	})
+}
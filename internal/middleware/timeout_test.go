@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("passes fast responses through", func(t *testing.T) {
+		handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline := r.Context().Deadline()
+			assert.True(t, hasDeadline, "request context should carry the deadline")
+			w.Header().Set("X-Custom", "yes")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "yes", rec.Header().Get("X-Custom"))
+		assert.Equal(t, "created", rec.Body.String())
+	})
+
+	t.Run("cuts off slow handlers with 503", func(t *testing.T) {
+		lateWrite := make(chan error, 1)
+		handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Simulates a repository call that honours cancellation
+			<-r.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			_, err := w.Write([]byte("too late"))
+			lateWrite <- err
+		}))
+
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		body := rec.Body.String()
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		assert.Equal(t, "TIMEOUT", resp.Code)
+
+		select {
+		case err := <-lateWrite:
+			assert.ErrorIs(t, err, http.ErrHandlerTimeout)
+		case <-time.After(time.Second):
+			t.Fatal("handler did not finish")
+		}
+		assert.Equal(t, body, rec.Body.String(), "late writes should be discarded")
+	})
+
+	t.Run("propagates panics", func(t *testing.T) {
+		handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		assert.PanicsWithValue(t, "boom", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+}
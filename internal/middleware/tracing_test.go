@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	// The global provider can only be bound once per process, so all cases share it
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	findSpan := func(t *testing.T, name string) sdktrace.ReadOnlySpan {
+		t.Helper()
+		for _, span := range recorder.Ended() {
+			if span.Name() == name {
+				return span
+			}
+		}
+		t.Fatalf("span %q not recorded", name)
+		return nil
+	}
+
+	attrValue := func(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+		for _, attr := range span.Attributes() {
+			if attr.Key == key {
+				return attr.Value
+			}
+		}
+		return attribute.Value{}
+	}
+
+	t.Run("starts a server span with the request context", func(t *testing.T) {
+		var handlerSpan trace.SpanContext
+		handler := Tracing()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerSpan = trace.SpanContextFromContext(r.Context())
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shorten", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		span := findSpan(t, "POST /api/v1/shorten")
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, span.SpanContext().SpanID(), handlerSpan.SpanID())
+		assert.Equal(t, int64(http.StatusCreated), attrValue(span, "http.response.status_code").AsInt64())
+		assert.Equal(t, "/api/v1/shorten", attrValue(span, "http.route").AsString())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+	t.Run("continues an incoming traceparent", func(t *testing.T) {
+		handler := Tracing()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusFound)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		span := findSpan(t, "GET /{code}")
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.True(t, span.Parent().IsRemote())
+	})
+
+	t.Run("marks server errors", func(t *testing.T) {
+		handler := Tracing()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		span := findSpan(t, "GET /health")
+		require.Equal(t, codes.Error, span.Status().Code)
+	})
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Interval is the width of the windows in a click time series.
+type Interval string
+
+// Supported time-series intervals.
+const (
+	IntervalHour Interval = "hour"
+	IntervalDay  Interval = "day"
+)
+
+// Duration returns the width of the interval, or 0 if it is not supported.
+func (i Interval) Duration() time.Duration {
+	switch i {
+	case IntervalHour:
+		return time.Hour
+	case IntervalDay:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Truncate returns the start of the UTC window containing t. Days start at
+// midnight UTC.
+func (i Interval) Truncate(t time.Time) time.Time {
+	return t.UTC().Truncate(i.Duration())
+}
+
+// ClickBucket is the number of clicks a short code received in one time window.
+type ClickBucket struct {
+	Timestamp time.Time `json:"timestamp"` // Start of the window in UTC
+	Count     int64     `json:"count"`
+}
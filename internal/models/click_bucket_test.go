@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterval_Truncate(t *testing.T) {
+	at := time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), IntervalHour.Truncate(at))
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), IntervalDay.Truncate(at))
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), IntervalDay.Truncate(at.Add(time.Second)))
+
+	// Days start at midnight UTC regardless of the input's zone
+	zone := time.FixedZone("UTC-8", -8*3600)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		IntervalDay.Truncate(time.Date(2024, 3, 10, 17, 0, 0, 0, zone)))
+}
+
+func TestInterval_Duration(t *testing.T) {
+	assert.Equal(t, time.Hour, IntervalHour.Duration())
+	assert.Equal(t, 24*time.Hour, IntervalDay.Duration())
+	assert.Zero(t, Interval("week").Duration())
+}
@@ -0,0 +1,20 @@
+package models
+
+// ReferrerCount is the number of clicks a short code received from one referring host.
+type ReferrerCount struct {
+	Referrer string `json:"referrer"` // Host, "direct" or "unknown"
+	Count    int64  `json:"count"`
+}
+
+// AgentCount is the number of clicks a short code received from one browser and OS.
+type AgentCount struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Count   int64  `json:"count"`
+}
+
+// CountryCount is the number of clicks a short code received from one country.
+type CountryCount struct {
+	Country string `json:"country"` // ISO 3166-1 alpha-2 code, or "XX" when unknown
+	Count   int64  `json:"count"`
+}
@@ -3,9 +3,12 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/security"
 )
 
 // URL represents a shortened URL entity.
@@ -16,33 +19,101 @@ type URL struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	ClickCount  int64      `json:"click_count"`
+	Permanent   bool       `json:"permanent"`
+	MaxClicks   *int64     `json:"max_clicks,omitempty"` // Redirects allowed before the link is exhausted; nil for unlimited
+	ShowPreview bool       `json:"show_preview"`         // Show an interstitial page before redirecting
+
+	// AppendParams are query parameters, such as UTM tags, added to the
+	// destination on redirect unless it already has them.
+	AppendParams map[string]string `json:"append_params,omitempty"`
+
+	// PlatformTargets maps a platform (PlatformIOS, PlatformAndroid) to the
+	// destination used for its clients instead of OriginalURL.
+	PlatformTargets map[string]string `json:"platform_targets,omitempty"`
+
+	// Tags are lowercase labels for grouping links, e.g. by campaign.
+	Tags []string `json:"tags,omitempty"`
+
+	// PasswordHash is the bcrypt hash guarding the redirect; empty when the
+	// link is public. It is never serialized.
+	PasswordHash string `json:"-"`
+
+	// LinkStatus is the HTTP status the destination returned when the link
+	// checker last probed it, or LinkStatusUnreachable if the request failed.
+	// Both are nil until the link has been checked.
+	LinkStatus    *int       `json:"link_status,omitempty"`
+	LinkCheckedAt *time.Time `json:"link_checked_at,omitempty"`
+
+	// Disabled links keep their code and analytics but do not redirect until
+	// enabled again. It is stored as the inverse of the active column, so
+	// the zero value is an active link.
+	Disabled bool `json:"disabled"`
+
+	// UpdatedAt is when the row last changed, clicks included. It is zero
+	// for URLs from stores that do not track it.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LinkStatusUnreachable is recorded when the destination could not be reached.
+const LinkStatusUnreachable = 0
+
+// IsLinkBroken reports whether the last link check found the destination
+// unreachable or answering with a 4xx or 5xx status.
+func (u *URL) IsLinkBroken() bool {
+	if u.LinkStatus == nil {
+		return false
+	}
+	status := *u.LinkStatus
+	return status == LinkStatusUnreachable || status >= 400
 }
 
 // URLCreate represents the data needed to create a new URL.
 type URLCreate struct {
-	OriginalURL string
-	ShortCode   string
-	ExpiresAt   *time.Time
+	OriginalURL  string
+	ShortCode    string
+	ExpiresAt    *time.Time
+	Permanent    bool
+	PasswordHash string // bcrypt hash; empty for public links
+	MaxClicks    *int64 // nil for unlimited
+	ShowPreview  bool
+	AppendParams map[string]string // nil for none
+
+	PlatformTargets map[string]string // nil for none
+	Tags            []string          // nil for none
 }
 
+// Platforms that can have their own destination in URL.PlatformTargets.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
 // Validation errors
 var (
-	ErrEmptyURL        = errors.New("url cannot be empty")
-	ErrInvalidURL      = errors.New("invalid url format")
-	ErrEmptyShortCode  = errors.New("short code cannot be empty")
-	ErrShortCodeLength = errors.New("short code must be between 1 and 10 characters")
-	ErrURLExpired      = errors.New("url has expired")
-	ErrURLNotFound     = errors.New("url not found")
+	ErrEmptyURL         = errors.New("url cannot be empty")
+	ErrInvalidURL       = errors.New("invalid url format")
+	ErrEmptyShortCode   = errors.New("short code cannot be empty")
+	ErrShortCodeTooLong = errors.New("short code is too long")
+	ErrURLExpired       = errors.New("url has expired")
+	ErrURLNotFound      = errors.New("url not found")
+	ErrURLExhausted     = errors.New("url has reached its click limit")
+	ErrURLDisabled      = errors.New("url is disabled")
+	ErrShortCodeExists  = errors.New("short code already exists")
 )
 
+// ValidateShortCodeLen rejects short codes longer than maxLen characters.
+func ValidateShortCodeLen(shortCode string, maxLen int) error {
+	if len(shortCode) > maxLen {
+		return fmt.Errorf("%w: %d characters, max %d", ErrShortCodeTooLong, len(shortCode), maxLen)
+	}
+	return nil
+}
+
 // Validate validates the URL model.
 func (u *URL) Validate() error {
 	if u.ShortCode == "" {
 		return ErrEmptyShortCode
 	}
-	if len(u.ShortCode) > 10 {
-		return ErrShortCodeLength
-	}
 	if u.OriginalURL == "" {
 		return ErrEmptyURL
 	}
@@ -52,6 +123,25 @@ func (u *URL) Validate() error {
 	return nil
 }
 
+// IsPasswordProtected reports whether the URL requires a password to redirect.
+func (u *URL) IsPasswordProtected() bool {
+	return u.PasswordHash != ""
+}
+
+// TargetFor returns the destination for clients on platform, falling back to
+// OriginalURL when the platform has no target of its own.
+func (u *URL) TargetFor(platform string) string {
+	if target, ok := u.PlatformTargets[platform]; ok {
+		return target
+	}
+	return u.OriginalURL
+}
+
+// IsExhausted reports whether the URL has used up its click limit.
+func (u *URL) IsExhausted() bool {
+	return u.MaxClicks != nil && u.ClickCount >= *u.MaxClicks
+}
+
 // IsExpired checks if the URL has expired.
 func (u *URL) IsExpired() bool {
 	if u.ExpiresAt == nil {
@@ -68,11 +158,6 @@ func (c *URLCreate) Validate() error {
 	if !isValidURL(c.OriginalURL) {
 		return ErrInvalidURL
 	}
-	if c.ShortCode != "" {
-		if len(c.ShortCode) > 10 {
-			return ErrShortCodeLength
-		}
-	}
 	return nil
 }
 
@@ -88,8 +173,8 @@ func isValidURL(s string) bool {
 		return false
 	}
 
-	// Must have a scheme (http or https)
-	if u.Scheme != "http" && u.Scheme != "https" {
+	// Must have a scheme; which schemes are accepted is enforced by the security sanitizer
+	if u.Scheme == "" {
 		return false
 	}
 
@@ -98,5 +183,11 @@ func isValidURL(s string) bool {
 		return false
 	}
 
+	// International hosts must have a valid punycode form, the same one the
+	// host is stored in after normalization
+	if _, err := security.ASCIIHost(u.Hostname()); err != nil {
+		return false
+	}
+
 	return true
 }
@@ -29,14 +29,6 @@ func TestURL_Validate(t *testing.T) {
 			},
 			wantErr: ErrEmptyShortCode,
 		},
-		{
-			name: "short code too long",
-			url: URL{
-				ShortCode:   "12345678901", // 11 chars
-				OriginalURL: "https://example.com",
-			},
-			wantErr: ErrShortCodeLength,
-		},
 		{
 			name: "empty original url",
 			url: URL{
@@ -62,12 +54,12 @@ func TestURL_Validate(t *testing.T) {
 			wantErr: ErrInvalidURL,
 		},
 		{
-			name: "ftp scheme not allowed",
+			name: "non-http scheme with host",
 			url: URL{
 				ShortCode:   "abc123",
 				OriginalURL: "ftp://files.example.com",
 			},
-			wantErr: ErrInvalidURL,
+			wantErr: nil,
 		},
 	}
 
@@ -122,6 +114,60 @@ func TestURL_IsExpired(t *testing.T) {
 	}
 }
 
+func TestURL_IsExhausted(t *testing.T) {
+	one := int64(1)
+	three := int64(3)
+
+	tests := []struct {
+		name       string
+		maxClicks  *int64
+		clickCount int64
+		expected   bool
+	}{
+		{"unlimited", nil, 100, false},
+		{"single-use unused", &one, 0, false},
+		{"single-use used", &one, 1, true},
+		{"clicks left", &three, 2, false},
+		{"limit reached", &three, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := URL{
+				ShortCode:   "test",
+				OriginalURL: "https://example.com",
+				MaxClicks:   tt.maxClicks,
+				ClickCount:  tt.clickCount,
+			}
+			assert.Equal(t, tt.expected, u.IsExhausted())
+		})
+	}
+}
+
+func TestURL_IsLinkBroken(t *testing.T) {
+	status := func(code int) *int { return &code }
+
+	tests := []struct {
+		name     string
+		status   *int
+		expected bool
+	}{
+		{"unchecked", nil, false},
+		{"ok", status(200), false},
+		{"redirect", status(301), false},
+		{"not found", status(404), true},
+		{"server error", status(503), true},
+		{"unreachable", status(LinkStatusUnreachable), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := URL{ShortCode: "test", OriginalURL: "https://example.com", LinkStatus: tt.status}
+			assert.Equal(t, tt.expected, u.IsLinkBroken())
+		})
+	}
+}
+
 func TestURLCreate_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -157,14 +203,6 @@ func TestURLCreate_Validate(t *testing.T) {
 			},
 			wantErr: ErrInvalidURL,
 		},
-		{
-			name: "short code too long",
-			create: URLCreate{
-				OriginalURL: "https://example.com",
-				ShortCode:   "12345678901",
-			},
-			wantErr: ErrShortCodeLength,
-		},
 	}
 
 	for _, tt := range tests {
@@ -188,7 +226,7 @@ func TestIsValidURL(t *testing.T) {
 		{"http://example.com", true},
 		{"https://example.com/path?query=1", true},
 		{"https://sub.example.com:8080/path", true},
-		{"ftp://files.example.com", false},
+		{"ftp://files.example.com", true}, // scheme policy lives in the sanitizer
 		{"example.com", false},
 		{"not a url", false},
 		{"", false},
@@ -199,6 +237,12 @@ func TestIsValidURL(t *testing.T) {
 		{"https://?query=1", false},                    // scheme with query but no host
 		{"://missing-scheme.com", false},               // missing scheme
 		{"\x00invalid\x00", false},                     // control characters (parse error)
+		{"https://münchen.de/", true},                  // international host
+		{"https://xn--mnchen-3ya.de/", true},           // punycode host
+		{"https://例え.jp/path", true},                   // non-latin host
+		{"https://[::1]:8080/", true},                  // IPv6 literal is not converted
+		{"https://xn--a.example/", false},              // invalid punycode
+		{"https://a\u200eb.example/", false},           // bidi control character in host
 	}
 
 	for _, tt := range tests {
@@ -207,3 +251,32 @@ func TestIsValidURL(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateShortCodeLen(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLen    int
+		shortCode string
+		wantErr   bool
+	}{
+		{name: "max minus one", maxLen: 10, shortCode: "123456789"},
+		{name: "exactly max", maxLen: 10, shortCode: "1234567890"},
+		{name: "max plus one", maxLen: 10, shortCode: "12345678901", wantErr: true},
+		{name: "exactly smaller max", maxLen: 6, shortCode: "abcdef"},
+		{name: "smaller max plus one", maxLen: 6, shortCode: "abcdefg", wantErr: true},
+		{name: "raised max accepts longer codes", maxLen: 16, shortCode: "abcdefghijklmnop"},
+		{name: "raised max plus one", maxLen: 16, shortCode: "abcdefghijklmnopq", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateShortCodeLen(tt.shortCode, tt.maxLen)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrShortCodeTooLong)
+				assert.Contains(t, err.Error(), "max")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
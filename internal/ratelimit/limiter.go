@@ -36,6 +36,10 @@ type Limiter interface {
 type Config struct {
 	Requests int           // Maximum requests per window
 	Window   time.Duration // Time window size
+
+	// Token bucket settings (TokenBucketLimiter only)
+	BurstSize  int     // Maximum tokens in the bucket; defaults to Requests
+	RefillRate float64 // Tokens added per second; defaults to Requests/Window
 }
 
 // DefaultConfig returns a default configuration.
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript implements a sliding window log on a sorted set.
+// It trims entries older than the window, and records the request only if
+// the limit has not been reached, so check and increment are atomic.
+//
+// KEYS[1] = rate limit key
+// ARGV[1] = current time (microseconds)
+// ARGV[2] = window size (microseconds)
+// ARGV[3] = request limit
+// ARGV[4] = unique member for this request
+//
+// Returns {allowed, count, resetAfter (microseconds)}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local count = redis.call('ZCARD', key)
+local resetAfter = 0
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	resetAfter = tonumber(oldest[2]) + window - now
+	if resetAfter < 0 then
+		resetAfter = 0
+	end
+end
+
+if count >= limit then
+	return {0, count, resetAfter}
+end
+
+redis.call('ZADD', key, now, ARGV[4])
+redis.call('PEXPIRE', key, math.ceil(window / 1000))
+
+return {1, count + 1, resetAfter}
+`)
+
+// RedisLimiter implements a distributed sliding window rate limiter backed by Redis.
+// All replicas sharing the same Redis instance and key prefix enforce a single limit.
+type RedisLimiter struct {
+	client    redis.UniversalClient
+	config    Config
+	keyPrefix string
+}
+
+// NewRedisLimiter creates a new Redis-backed rate limiter.
+// The client may be a single node, cluster or sentinel client.
+// The limiter takes ownership of the client and closes it on Close.
+func NewRedisLimiter(client redis.UniversalClient, keyPrefix string, cfg Config) *RedisLimiter {
+	if keyPrefix == "" {
+		keyPrefix = "ratelimit:"
+	}
+	return &RedisLimiter{
+		client:    client,
+		config:    cfg,
+		keyPrefix: keyPrefix,
+	}
+}
+
+// Allow checks if a request from the given identifier is allowed.
+func (r *RedisLimiter) Allow(ctx context.Context, identifier string) (*Result, error) {
+	now := time.Now().UnixMicro()
+	window := r.config.Window.Microseconds()
+
+	vals, err := slidingWindowScript.Run(ctx, r.client,
+		[]string{r.key(identifier)},
+		now, window, r.config.Requests, uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("rate limit check failed: %w", err)
+	}
+	if len(vals) != 3 {
+		return nil, fmt.Errorf("rate limit check failed: unexpected script result %v", vals)
+	}
+
+	allowed := vals[0] == 1
+	count := int(vals[1])
+	resetAfter := time.Duration(vals[2]) * time.Microsecond
+
+	if !allowed {
+		return &Result{
+			Allowed:    false,
+			Remaining:  0,
+			ResetAfter: resetAfter,
+			RetryAfter: resetAfter,
+			Limit:      r.config.Requests,
+		}, nil
+	}
+
+	return &Result{
+		Allowed:    true,
+		Remaining:  r.config.Requests - count,
+		ResetAfter: resetAfter,
+		RetryAfter: 0,
+		Limit:      r.config.Requests,
+	}, nil
+}
+
+// Reset clears the rate limit state for an identifier.
+func (r *RedisLimiter) Reset(ctx context.Context, identifier string) error {
+	if err := r.client.Del(ctx, r.key(identifier)).Err(); err != nil {
+		return fmt.Errorf("rate limit reset failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying Redis client.
+func (r *RedisLimiter) Close() error {
+	return r.client.Close()
+}
+
+// key generates the Redis key for an identifier.
+func (r *RedisLimiter) key(identifier string) string {
+	return r.keyPrefix + identifier
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func skipIfNoRedis(t *testing.T) {
+	t.Helper()
+	if os.Getenv("TEST_REDIS") != "true" {
+		t.Skip("Skipping: TEST_REDIS not set. Run with docker-compose up -d")
+	}
+}
+
+func newTestRedisLimiter(t *testing.T, cfg Config) *RedisLimiter {
+	t.Helper()
+	skipIfNoRedis(t)
+
+	host := os.Getenv("REDIS_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:6379", host)})
+	require.NoError(t, client.Ping(context.Background()).Err())
+
+	limiter := NewRedisLimiter(client, "test:ratelimit:", cfg)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		iter := client.Scan(ctx, 0, "test:ratelimit:*", 0).Iterator()
+		for iter.Next(ctx) {
+			_ = client.Del(ctx, iter.Val())
+		}
+		_ = limiter.Close()
+	})
+
+	return limiter
+}
+
+func TestRedisLimiter_Allow(t *testing.T) {
+	t.Run("allows requests under limit", func(t *testing.T) {
+		limiter := newTestRedisLimiter(t, Config{Requests: 5, Window: time.Minute})
+
+		ctx := context.Background()
+		for i := 0; i < 5; i++ {
+			result, err := limiter.Allow(ctx, "allow-under")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed, "request %d should be allowed", i+1)
+			assert.Equal(t, 5-i-1, result.Remaining)
+			assert.Equal(t, 5, result.Limit)
+		}
+	})
+
+	t.Run("blocks requests over limit", func(t *testing.T) {
+		limiter := newTestRedisLimiter(t, Config{Requests: 2, Window: time.Minute})
+
+		ctx := context.Background()
+		for i := 0; i < 2; i++ {
+			result, err := limiter.Allow(ctx, "block-over")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+
+		result, err := limiter.Allow(ctx, "block-over")
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.True(t, result.RetryAfter > 0, "should have retry-after duration")
+		assert.True(t, result.ResetAfter <= time.Minute)
+	})
+
+	t.Run("allows again after window slides", func(t *testing.T) {
+		limiter := newTestRedisLimiter(t, Config{Requests: 1, Window: 200 * time.Millisecond})
+
+		ctx := context.Background()
+		result, err := limiter.Allow(ctx, "slide")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = limiter.Allow(ctx, "slide")
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		time.Sleep(250 * time.Millisecond)
+
+		result, err = limiter.Allow(ctx, "slide")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+}
+
+func TestRedisLimiter_Reset(t *testing.T) {
+	limiter := newTestRedisLimiter(t, Config{Requests: 1, Window: time.Minute})
+
+	ctx := context.Background()
+	result, err := limiter.Allow(ctx, "reset")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "reset")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	require.NoError(t, limiter.Reset(ctx, "reset"))
+
+	result, err = limiter.Allow(ctx, "reset")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed, "should be allowed after reset")
+}
+
+func TestRedisLimiter_SharedAcrossInstances(t *testing.T) {
+	cfg := Config{Requests: 50, Window: time.Minute}
+	first := newTestRedisLimiter(t, cfg)
+	second := newTestRedisLimiter(t, cfg)
+
+	ctx := context.Background()
+	var allowed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		limiter := first
+		if i%2 == 1 {
+			limiter = second
+		}
+		go func(l *RedisLimiter) {
+			defer wg.Done()
+			result, err := l.Allow(ctx, "shared")
+			if err == nil && result.Allowed {
+				atomic.AddInt64(&allowed, 1)
+			}
+		}(limiter)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(50), allowed, "limit should be enforced across instances")
+}
+
+func TestRedisLimiter_ConnectionFailure(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	limiter := NewRedisLimiter(client, "", DefaultConfig())
+	defer limiter.Close()
+
+	result, err := limiter.Allow(context.Background(), "unreachable")
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucketLimiter implements an in-memory token bucket rate limiter.
+// Each identifier may burst up to BurstSize requests, after which requests
+// are admitted at RefillRate per second.
+type TokenBucketLimiter struct {
+	config  Config
+	buckets sync.Map // map[string]*bucket
+
+	// For cleanup
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// bucket holds the token state for a single identifier.
+type bucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewTokenBucketLimiter creates a new token bucket rate limiter.
+// When BurstSize or RefillRate are unset they are derived from Requests and Window,
+// so the steady-state rate matches the equivalent sliding window limiter.
+func NewTokenBucketLimiter(cfg Config) *TokenBucketLimiter {
+	if cfg.BurstSize <= 0 {
+		cfg.BurstSize = cfg.Requests
+	}
+	if cfg.RefillRate <= 0 && cfg.Window > 0 {
+		cfg.RefillRate = float64(cfg.Requests) / cfg.Window.Seconds()
+	}
+
+	t := &TokenBucketLimiter{
+		config: cfg,
+		done:   make(chan struct{}),
+	}
+
+	// Start cleanup goroutine
+	t.wg.Add(1)
+	go t.cleanupLoop()
+
+	return t
+}
+
+// Allow checks if a request from the given identifier is allowed.
+func (t *TokenBucketLimiter) Allow(ctx context.Context, identifier string) (*Result, error) {
+	// Check context
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	now := time.Now()
+
+	// Get or create bucket (new buckets start full)
+	bucketVal, _ := t.buckets.LoadOrStore(identifier, &bucket{
+		tokens:   float64(t.config.BurstSize),
+		lastFill: now,
+	})
+	b := bucketVal.(*bucket)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t.refill(b, now)
+
+	if b.tokens < 1 {
+		return &Result{
+			Allowed:    false,
+			Remaining:  0,
+			ResetAfter: t.timeUntil(float64(t.config.BurstSize) - b.tokens),
+			RetryAfter: t.timeUntil(1 - b.tokens),
+			Limit:      t.config.BurstSize,
+		}, nil
+	}
+
+	// Consume a token
+	b.tokens--
+
+	return &Result{
+		Allowed:    true,
+		Remaining:  int(math.Floor(b.tokens)),
+		ResetAfter: t.timeUntil(float64(t.config.BurstSize) - b.tokens),
+		RetryAfter: 0,
+		Limit:      t.config.BurstSize,
+	}, nil
+}
+
+// Reset clears the rate limit state for an identifier.
+func (t *TokenBucketLimiter) Reset(ctx context.Context, identifier string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	t.buckets.Delete(identifier)
+	return nil
+}
+
+// Close releases resources held by the limiter.
+func (t *TokenBucketLimiter) Close() error {
+	close(t.done)
+	t.wg.Wait()
+	return nil
+}
+
+// refill adds the tokens accrued since the last fill, capped at BurstSize.
+// Must be called with b.mu held.
+func (t *TokenBucketLimiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.lastFill).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(t.config.BurstSize), b.tokens+elapsed*t.config.RefillRate)
+		b.lastFill = now
+	}
+}
+
+// timeUntil returns how long it takes to accrue the given number of tokens.
+func (t *TokenBucketLimiter) timeUntil(tokens float64) time.Duration {
+	if tokens <= 0 || t.config.RefillRate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / t.config.RefillRate * float64(time.Second))
+}
+
+// cleanupLoop periodically removes idle buckets.
+func (t *TokenBucketLimiter) cleanupLoop() {
+	defer t.wg.Done()
+
+	interval := t.timeUntil(float64(t.config.BurstSize))
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			t.cleanup()
+		}
+	}
+}
+
+// cleanup removes buckets that have refilled completely, since a missing
+// bucket is equivalent to a full one.
+func (t *TokenBucketLimiter) cleanup() {
+	now := time.Now()
+
+	t.buckets.Range(func(key, value interface{}) bool {
+		b := value.(*bucket)
+		b.mu.Lock()
+		t.refill(b, now)
+		full := b.tokens >= float64(t.config.BurstSize)
+		b.mu.Unlock()
+
+		if full {
+			t.buckets.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketLimiter_Allow(t *testing.T) {
+	t.Run("allows a burst up to bucket size", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(Config{BurstSize: 5, RefillRate: 1})
+		defer limiter.Close()
+
+		ctx := context.Background()
+		for i := 0; i < 5; i++ {
+			result, err := limiter.Allow(ctx, "192.168.1.1")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed, "request %d should be allowed", i+1)
+			assert.Equal(t, 5-i-1, result.Remaining)
+			assert.Equal(t, 5, result.Limit)
+		}
+	})
+
+	t.Run("blocks when bucket is empty", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(Config{BurstSize: 2, RefillRate: 1})
+		defer limiter.Close()
+
+		ctx := context.Background()
+		for i := 0; i < 2; i++ {
+			result, err := limiter.Allow(ctx, "192.168.1.1")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+
+		result, err := limiter.Allow(ctx, "192.168.1.1")
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.True(t, result.RetryAfter > 0, "should have retry-after duration")
+		assert.True(t, result.RetryAfter <= time.Second, "next token arrives within 1s at 1 token/s")
+		assert.True(t, result.ResetAfter >= result.RetryAfter)
+	})
+
+	t.Run("refills tokens over time", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(Config{BurstSize: 1, RefillRate: 10})
+		defer limiter.Close()
+
+		ctx := context.Background()
+		result, err := limiter.Allow(ctx, "192.168.1.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = limiter.Allow(ctx, "192.168.1.1")
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		time.Sleep(150 * time.Millisecond)
+
+		result, err = limiter.Allow(ctx, "192.168.1.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed, "should be allowed after refill")
+	})
+
+	t.Run("tracks identifiers separately", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(Config{BurstSize: 1, RefillRate: 1})
+		defer limiter.Close()
+
+		ctx := context.Background()
+		result, err := limiter.Allow(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = limiter.Allow(ctx, "b")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("derives defaults from requests and window", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(Config{Requests: 60, Window: time.Minute})
+		defer limiter.Close()
+
+		assert.Equal(t, 60, limiter.config.BurstSize)
+		assert.InDelta(t, 1.0, limiter.config.RefillRate, 0.0001)
+	})
+}
+
+func TestTokenBucketLimiter_Reset(t *testing.T) {
+	limiter := NewTokenBucketLimiter(Config{BurstSize: 1, RefillRate: 0.1})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	result, err := limiter.Allow(ctx, "192.168.1.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "192.168.1.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	require.NoError(t, limiter.Reset(ctx, "192.168.1.1"))
+
+	result, err = limiter.Allow(ctx, "192.168.1.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed, "should be allowed after reset")
+}
+
+func TestTokenBucketLimiter_Concurrency(t *testing.T) {
+	limiter := NewTokenBucketLimiter(Config{BurstSize: 50, RefillRate: 0.001})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	var allowed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := limiter.Allow(ctx, "shared")
+			if err == nil && result.Allowed {
+				atomic.AddInt64(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(50), allowed)
+}
+
+func TestTokenBucketLimiter_ContextCancellation(t *testing.T) {
+	limiter := NewTokenBucketLimiter(Config{BurstSize: 1, RefillRate: 1})
+	defer limiter.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := limiter.Allow(ctx, "192.168.1.1")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = limiter.Reset(ctx, "192.168.1.1")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTokenBucketLimiter_Cleanup(t *testing.T) {
+	limiter := NewTokenBucketLimiter(Config{BurstSize: 1, RefillRate: 100})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	_, err := limiter.Allow(ctx, "idle")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	limiter.cleanup()
+
+	_, exists := limiter.buckets.Load("idle")
+	assert.False(t, exists, "full buckets should be removed")
+}
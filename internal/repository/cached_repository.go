@@ -2,18 +2,72 @@ package repository
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+
 	"github.com/emadnahed/FastGoLink/internal/cache"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
 )
 
 // CachedURLRepository wraps a URLRepository with caching.
 // It implements write-through caching with fallback to database on cache miss.
+// Concurrent misses for the same short code share a single database read.
+// While the cache reports itself unhealthy, reads and fills bypass it and
+// are served straight from the database; invalidations are still attempted
+// so no stale entry survives the outage.
 type CachedURLRepository struct {
-	repo     URLRepository
-	cache    cache.URLCacher
-	cacheTTL time.Duration
+	repo      URLRepository
+	cache     cache.URLCacher
+	cacheTTL  time.Duration
+	group     singleflight.Group
+	coalesced atomic.Uint64 // Misses served by another caller's database read
+}
+
+// Values reported by CacheStatus.Result.
+const (
+	CacheHit  = "HIT"
+	CacheMiss = "MISS"
+)
+
+// CacheStatus records whether a short code lookup made through a
+// CachedURLRepository was served from the cache. Lookups of codes that do
+// not exist always go to the database, so they are misses.
+type CacheStatus struct {
+	result atomic.Value // string
+}
+
+type cacheStatusKey struct{}
+
+// WithCacheStatus returns a context that records the cache result of the
+// short code lookups made with it into the returned CacheStatus.
+func WithCacheStatus(ctx context.Context) (context.Context, *CacheStatus) {
+	status := &CacheStatus{}
+	return context.WithValue(ctx, cacheStatusKey{}, status), status
+}
+
+// Result returns CacheHit or CacheMiss for the last lookup recorded, or ""
+// when no lookup went through a cached repository.
+func (s *CacheStatus) Result() string {
+	result, _ := s.result.Load().(string)
+	return result
+}
+
+// recordCacheResult records the result of a lookup into the CacheStatus of
+// ctx, if it has one.
+func recordCacheResult(ctx context.Context, hit bool) {
+	status, ok := ctx.Value(cacheStatusKey{}).(*CacheStatus)
+	if !ok {
+		return
+	}
+	if hit {
+		status.result.Store(CacheHit)
+	} else {
+		status.result.Store(CacheMiss)
+	}
 }
 
 // NewCachedURLRepository creates a new cached URL repository.
@@ -42,24 +96,76 @@ func (c *CachedURLRepository) Create(ctx context.Context, create *models.URLCrea
 	return url, nil
 }
 
-// GetByShortCode retrieves a URL, checking cache first then falling back to database.
-func (c *CachedURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
-	// Try cache first
-	cached, err := c.cache.Get(ctx, shortCode)
-	if err == nil {
-		return c.cachedToURL(cached), nil
+// CreateBatch stores URLs in the database and caches each one created.
+func (c *CachedURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	// A *BatchError comes with the URLs that were stored despite it
+	urls, err := c.repo.CreateBatch(ctx, creates)
+	for _, url := range urls {
+		if url != nil {
+			_ = c.cacheURL(ctx, url)
+		}
 	}
 
-	// Cache miss or error - fallback to database
-	url, err := c.repo.GetByShortCode(ctx, shortCode)
-	if err != nil {
-		return nil, err
+	return urls, err
+}
+
+// GetByShortCode retrieves a URL, checking cache first then falling back to database.
+func (c *CachedURLRepository) GetByShortCode(ctx context.Context, shortCode string) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "CachedURLRepository.GetByShortCode",
+		trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	// Try cache first, unless it is down
+	if c.cache.Healthy() {
+		cached, err := c.cache.Get(ctx, shortCode)
+		span.SetAttributes(tracing.CacheHitKey.Bool(err == nil))
+		recordCacheResult(ctx, err == nil)
+		if err == nil {
+			return c.cachedToURL(cached), nil
+		}
+	} else {
+		span.SetAttributes(tracing.CacheHitKey.Bool(false))
+		recordCacheResult(ctx, false)
 	}
 
-	// Cache the result for next time
-	_ = c.cacheURL(ctx, url)
+	// Cache miss or error - fallback to database, coalescing concurrent lookups
+	var read bool
+	ch := c.group.DoChan(shortCode, func() (interface{}, error) {
+		read = true
+		// Detach from the caller's cancellation since other callers may share this result
+		dbCtx := context.WithoutCancel(ctx)
 
-	return url, nil
+		url, err := c.repo.GetByShortCode(dbCtx, shortCode)
+		if err != nil {
+			return nil, err
+		}
+
+		// Cache the result for next time (errors are never cached)
+		_ = c.cacheURL(dbCtx, url)
+
+		return url, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if !read {
+			c.coalesced.Add(1)
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Give each caller its own copy of the shared result
+		url := *res.Val.(*models.URL)
+		return &url, nil
+	}
+}
+
+// GetByShortCodes reads from the database; one query is cheaper than a
+// cache lookup per code.
+func (c *CachedURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	return c.repo.GetByShortCodes(ctx, shortCodes)
 }
 
 // GetByID retrieves a URL by ID from database (not cached by ID).
@@ -67,13 +173,79 @@ func (c *CachedURLRepository) GetByID(ctx context.Context, id int64) (*models.UR
 	return c.repo.GetByID(ctx, id)
 }
 
-// Delete removes a URL from both cache and database.
+// GetByOriginalURL retrieves a URL by destination from database (not cached).
+func (c *CachedURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	return c.repo.GetByOriginalURL(ctx, originalURL)
+}
+
+// Delete soft-deletes a URL in the database and evicts it from cache.
+// Eviction happens after the write so a concurrent read cannot re-cache
+// the row before it is marked deleted.
 func (c *CachedURLRepository) Delete(ctx context.Context, shortCode string) error {
-	// Delete from cache first
+	err := c.repo.Delete(ctx, shortCode)
+	_ = c.cache.Delete(ctx, shortCode)
+	return err
+}
+
+// DeleteBatch soft-deletes URLs in the database and evicts every requested
+// code from cache, deleted or not.
+func (c *CachedURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	deleted, err := c.repo.DeleteBatch(ctx, shortCodes)
+	for _, shortCode := range shortCodes {
+		_ = c.cache.Delete(ctx, shortCode)
+	}
+	return deleted, err
+}
+
+// Restore undoes a soft delete. The cache never holds deleted URLs, so the
+// restored row is picked up on the next read.
+func (c *CachedURLRepository) Restore(ctx context.Context, shortCode string) error {
+	return c.repo.Restore(ctx, shortCode)
+}
+
+// DeletePermanent removes a URL from both database and cache.
+func (c *CachedURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	err := c.repo.DeletePermanent(ctx, shortCode)
+	_ = c.cache.Delete(ctx, shortCode)
+	return err
+}
+
+// UpdateOriginalURL updates the destination in the database
+// and invalidates the cache so the next read picks up the new URL.
+func (c *CachedURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	if err := c.repo.UpdateOriginalURL(ctx, shortCode, newURL); err != nil {
+		return err
+	}
 	_ = c.cache.Delete(ctx, shortCode)
+	return nil
+}
 
-	// Then delete from database
-	return c.repo.Delete(ctx, shortCode)
+// UpdateExpiry updates the expiry in the database and re-caches the URL,
+// so its cache TTL is capped by the new expiry time.
+func (c *CachedURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	url, err := c.repo.UpdateExpiry(ctx, shortCode, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cacheURL(ctx, url); err != nil {
+		// Drop the old entry rather than keep serving it with the old TTL
+		_ = c.cache.Delete(ctx, shortCode)
+	}
+	return url, nil
+}
+
+// SetActive updates the state in the database and re-caches the URL, so
+// redirects served from cache see the change right away.
+func (c *CachedURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	url, err := c.repo.SetActive(ctx, shortCode, active)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cacheURL(ctx, url); err != nil {
+		// Drop the old entry rather than keep serving the old state
+		_ = c.cache.Delete(ctx, shortCode)
+	}
+	return url, nil
 }
 
 // IncrementClickCount increments the click count in the database
@@ -87,57 +259,115 @@ func (c *CachedURLRepository) IncrementClickCount(ctx context.Context, shortCode
 	return nil
 }
 
+// ClaimClick claims a click in the database and invalidates the cache
+// so the next lookup sees the remaining clicks.
+func (c *CachedURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
+	clickCount, err := c.repo.ClaimClick(ctx, shortCode)
+	// Invalidate even when exhausted so a stale entry can't keep serving the URL
+	_ = c.cache.Delete(ctx, shortCode)
+	return clickCount, err
+}
+
 // BatchIncrementClickCounts increments click counts for multiple URLs
 // and invalidates their cache entries.
-func (c *CachedURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error {
-	if err := c.repo.BatchIncrementClickCounts(ctx, counts); err != nil {
-		return err
+func (c *CachedURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
+	totals, err := c.repo.BatchIncrementClickCounts(ctx, counts)
+	if err != nil {
+		return nil, err
 	}
 	// Invalidate cache entries for all updated URLs
 	for shortCode := range counts {
 		_ = c.cache.Delete(ctx, shortCode)
 	}
-	return nil
+	return totals, nil
+}
+
+// DeleteExpired removes expired URLs from the database and evicts the deleted
+// codes from cache, so they respond as unknown rather than expired.
+func (c *CachedURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
+	deleted, err := c.repo.DeleteExpired(ctx)
+	for _, shortCode := range deleted {
+		_ = c.cache.Delete(ctx, shortCode)
+	}
+	return deleted, err
 }
 
-// DeleteExpired removes expired URLs from database and doesn't touch cache
-// (cache entries have their own TTL).
-func (c *CachedURLRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	return c.repo.DeleteExpired(ctx)
+// List reads from the database; listings are not cached.
+func (c *CachedURLRepository) List(ctx context.Context, limit, offset int, filter ListFilter) ([]*models.URL, int64, error) {
+	return c.repo.List(ctx, limit, offset, filter)
+}
+
+// TopByClicks reads from the database; leaderboards are not cached.
+func (c *CachedURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	return c.repo.TopByClicks(ctx, limit)
+}
+
+// ListAfter reads from the database; listings are not cached.
+func (c *CachedURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter ListFilter) ([]*models.URL, int64, error) {
+	return c.repo.ListAfter(ctx, afterID, limit, filter)
 }
 
 // Exists checks if a URL exists, checking cache first.
 func (c *CachedURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
-	// Try cache first
-	exists, err := c.cache.Exists(ctx, shortCode)
-	if err == nil && exists {
-		return true, nil
+	// Try cache first, unless it is down
+	if c.cache.Healthy() {
+		exists, err := c.cache.Exists(ctx, shortCode)
+		if err == nil && exists {
+			return true, nil
+		}
 	}
 
 	// Fallback to database
 	return c.repo.Exists(ctx, shortCode)
 }
 
-// HealthCheck checks both cache and database health.
+// HealthCheck checks both cache and database health. A cache already known
+// to be down is skipped, since lookups are being served from the database.
 func (c *CachedURLRepository) HealthCheck(ctx context.Context) error {
 	// Check cache health
-	if err := c.cache.Ping(ctx); err != nil {
-		return err
+	if c.cache.Healthy() {
+		if err := c.cache.Ping(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Check database health
 	return c.repo.HealthCheck(ctx)
 }
 
+// CacheStats returns the hit/miss counters of the underlying URL cache and
+// the number of misses that shared another lookup's database read.
+func (c *CachedURLRepository) CacheStats() cache.Stats {
+	stats := c.cache.Stats()
+	stats.Coalesced = c.coalesced.Load()
+	return stats
+}
+
 // cacheURL stores a URL in the cache with all fields.
+// It does nothing while the cache is down.
 func (c *CachedURLRepository) cacheURL(ctx context.Context, url *models.URL) error {
+	if !c.cache.Healthy() {
+		return nil
+	}
 	cached := &cache.CachedURL{
-		ID:          url.ID,
-		ShortCode:   url.ShortCode,
-		OriginalURL: url.OriginalURL,
-		CreatedAt:   url.CreatedAt,
-		ExpiresAt:   url.ExpiresAt,
-		ClickCount:  url.ClickCount,
+		ID:           url.ID,
+		ShortCode:    url.ShortCode,
+		OriginalURL:  url.OriginalURL,
+		CreatedAt:    url.CreatedAt,
+		ExpiresAt:    url.ExpiresAt,
+		ClickCount:   url.ClickCount,
+		Permanent:    url.Permanent,
+		PasswordHash: url.PasswordHash,
+		MaxClicks:    url.MaxClicks,
+		ShowPreview:  url.ShowPreview,
+		AppendParams: url.AppendParams,
+
+		PlatformTargets: url.PlatformTargets,
+		Tags:            url.Tags,
+		Disabled:        url.Disabled,
+		UpdatedAt:       url.UpdatedAt,
+		LinkStatus:      url.LinkStatus,
+		LinkCheckedAt:   url.LinkCheckedAt,
 	}
 	return c.cache.SetWithTTL(ctx, cached, c.cacheTTL)
 }
@@ -146,11 +376,23 @@ func (c *CachedURLRepository) cacheURL(ctx context.Context, url *models.URL) err
 // All fields are now fully populated from the cache.
 func (c *CachedURLRepository) cachedToURL(cached *cache.CachedURL) *models.URL {
 	return &models.URL{
-		ID:          cached.ID,
-		ShortCode:   cached.ShortCode,
-		OriginalURL: cached.OriginalURL,
-		CreatedAt:   cached.CreatedAt,
-		ExpiresAt:   cached.ExpiresAt,
-		ClickCount:  cached.ClickCount,
+		ID:           cached.ID,
+		ShortCode:    cached.ShortCode,
+		OriginalURL:  cached.OriginalURL,
+		CreatedAt:    cached.CreatedAt,
+		ExpiresAt:    cached.ExpiresAt,
+		ClickCount:   cached.ClickCount,
+		Permanent:    cached.Permanent,
+		PasswordHash: cached.PasswordHash,
+		MaxClicks:    cached.MaxClicks,
+		ShowPreview:  cached.ShowPreview,
+		AppendParams: cached.AppendParams,
+
+		PlatformTargets: cached.PlatformTargets,
+		Tags:            cached.Tags,
+		Disabled:        cached.Disabled,
+		UpdatedAt:       cached.UpdatedAt,
+		LinkStatus:      cached.LinkStatus,
+		LinkCheckedAt:   cached.LinkCheckedAt,
 	}
 }
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -54,7 +57,19 @@ func setupCachedTestDB(t *testing.T) (*CachedURLRepository, func()) {
 			original_url TEXT NOT NULL,
 			created_at TIMESTAMPTZ DEFAULT NOW(),
 			expires_at TIMESTAMPTZ,
-			click_count BIGINT DEFAULT 0
+			click_count BIGINT DEFAULT 0,
+			permanent BOOLEAN NOT NULL DEFAULT FALSE,
+			password_hash TEXT,
+			max_clicks BIGINT,
+			deleted_at TIMESTAMPTZ,
+			show_preview BOOLEAN NOT NULL DEFAULT FALSE,
+			append_params JSONB,
+			platform_targets JSONB,
+			tags TEXT[],
+			link_status SMALLINT,
+			link_checked_at TIMESTAMPTZ,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		)
 	`)
 	require.NoError(t, err)
@@ -109,7 +124,7 @@ func TestCachedURLRepository_Create(t *testing.T) {
 		assert.True(t, exists)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "cached1")
+		_ = repo.DeletePermanent(ctx, "cached1")
 	})
 }
 
@@ -139,7 +154,7 @@ func TestCachedURLRepository_GetByShortCode(t *testing.T) {
 		assert.Equal(t, "https://example.com/hit", url2.OriginalURL)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "cached2")
+		_ = repo.DeletePermanent(ctx, "cached2")
 	})
 
 	t.Run("cache miss falls back to db", func(t *testing.T) {
@@ -165,7 +180,7 @@ func TestCachedURLRepository_GetByShortCode(t *testing.T) {
 		assert.True(t, exists)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "cached3")
+		_ = repo.DeletePermanent(ctx, "cached3")
 	})
 
 	t.Run("not found returns error", func(t *testing.T) {
@@ -187,6 +202,11 @@ func TestCachedURLRepository_Delete(t *testing.T) {
 
 	_, err := repo.Create(ctx, create)
 	require.NoError(t, err)
+	defer func() { _ = repo.DeletePermanent(ctx, "cached4") }()
+
+	// Warm the cache
+	_, err = repo.GetByShortCode(ctx, "cached4")
+	require.NoError(t, err)
 
 	// Delete should remove from both
 	err = repo.Delete(ctx, "cached4")
@@ -200,6 +220,39 @@ func TestCachedURLRepository_Delete(t *testing.T) {
 	// Verify gone from db
 	_, err = repo.GetByShortCode(ctx, "cached4")
 	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	// Restoring makes it resolvable again
+	require.NoError(t, repo.Restore(ctx, "cached4"))
+	url, err := repo.GetByShortCode(ctx, "cached4")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/delete", url.OriginalURL)
+}
+
+func TestCachedURLRepository_UpdateOriginalURL(t *testing.T) {
+	repo, cleanup := setupCachedTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	create := &models.URLCreate{
+		ShortCode:   "cached8",
+		OriginalURL: "https://example.com/old",
+	}
+
+	_, err := repo.Create(ctx, create)
+	require.NoError(t, err)
+
+	err = repo.UpdateOriginalURL(ctx, "cached8", "https://example.com/new")
+	require.NoError(t, err)
+
+	// Stale entry should be evicted from cache
+	exists, err := repo.cache.Exists(ctx, "cached8")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	url, err := repo.GetByShortCode(ctx, "cached8")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/new", url.OriginalURL)
 }
 
 func TestCachedURLRepository_Exists(t *testing.T) {
@@ -222,7 +275,7 @@ func TestCachedURLRepository_Exists(t *testing.T) {
 		assert.True(t, exists)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "cached5")
+		_ = repo.DeletePermanent(ctx, "cached5")
 	})
 
 	t.Run("exists falls back to db when not in cache", func(t *testing.T) {
@@ -242,7 +295,7 @@ func TestCachedURLRepository_Exists(t *testing.T) {
 		assert.True(t, exists)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "cached6")
+		_ = repo.DeletePermanent(ctx, "cached6")
 	})
 
 	t.Run("exists returns false for non-existent", func(t *testing.T) {
@@ -277,7 +330,7 @@ func TestCachedURLRepository_IncrementClickCount(t *testing.T) {
 	// Note: cached version won't have click count, need to query DB directly
 	// but at least we verified the increment doesn't error
 
-	_ = repo.Delete(ctx, "cached7")
+	_ = repo.DeletePermanent(ctx, "cached7")
 	_ = url // silence unused
 }
 
@@ -326,7 +379,7 @@ func TestCachedURLRepository_MockCache(t *testing.T) {
 		assert.Contains(t, mockCache.data, "mock1")
 
 		// Cleanup
-		_ = cachedRepo2.Delete(ctx, "mock1")
+		_ = baseRepo.DeletePermanent(ctx, "mock1")
 	})
 
 	_ = cachedRepo // silence unused warning
@@ -368,6 +421,14 @@ func (m *mockURLCache) Ping(_ context.Context) error {
 	return nil
 }
 
+func (m *mockURLCache) Healthy() bool {
+	return true
+}
+
+func (m *mockURLCache) Stats() cache.Stats {
+	return cache.Stats{}
+}
+
 // CachedURLRepositoryWithMock is a version that uses a mock cache interface
 type CachedURLRepositoryWithMock struct {
 	repo     URLRepository
@@ -437,7 +498,7 @@ func (c *CachedURLRepositoryWithMock) IncrementClickCount(ctx context.Context, s
 	return c.repo.IncrementClickCount(ctx, shortCode)
 }
 
-func (c *CachedURLRepositoryWithMock) DeleteExpired(ctx context.Context) (int64, error) {
+func (c *CachedURLRepositoryWithMock) DeleteExpired(ctx context.Context) ([]string, error) {
 	return c.repo.DeleteExpired(ctx)
 }
 
@@ -452,3 +513,465 @@ func (c *CachedURLRepositoryWithMock) Exists(ctx context.Context, shortCode stri
 func (c *CachedURLRepositoryWithMock) HealthCheck(ctx context.Context) error {
 	return c.repo.HealthCheck(ctx)
 }
+
+// countingURLRepository counts GetByShortCode calls and blocks them until
+// release is closed, so concurrent lookups are guaranteed to overlap.
+type countingURLRepository struct {
+	URLRepository
+	calls   atomic.Int64
+	release chan struct{}
+	url     *models.URL
+	err     error
+}
+
+func (r *countingURLRepository) GetByShortCode(_ context.Context, _ string) (*models.URL, error) {
+	r.calls.Add(1)
+	<-r.release
+	if r.err != nil {
+		return nil, r.err
+	}
+	url := *r.url
+	return &url, nil
+}
+
+// getConcurrently issues n concurrent lookups and returns their results once
+// every goroutine is waiting on the repository.
+func getConcurrently(t testing.TB, repo *CachedURLRepository, base *countingURLRepository, n int) ([]*models.URL, []error) {
+	t.Helper()
+
+	urls := make([]*models.URL, n)
+	errs := make([]error, n)
+	var started, done sync.WaitGroup
+	for i := 0; i < n; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			urls[i], errs[i] = repo.GetByShortCode(context.Background(), "hot")
+		}(i)
+	}
+	started.Wait()
+	time.Sleep(10 * time.Millisecond) // Let callers join the in-flight lookup
+	close(base.release)
+	done.Wait()
+
+	return urls, errs
+}
+
+func TestCachedURLRepository_GetByShortCode_Singleflight(t *testing.T) {
+	t.Run("concurrent misses share one database read", func(t *testing.T) {
+		base := &countingURLRepository{
+			release: make(chan struct{}),
+			url:     &models.URL{ID: 1, ShortCode: "hot", OriginalURL: "https://example.com/hot"},
+		}
+		memCache := cache.NewMemoryCache(100, 0)
+		defer memCache.Close()
+		repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Minute), time.Minute)
+
+		urls, errs := getConcurrently(t, repo, base, 50)
+
+		assert.Equal(t, int64(1), base.calls.Load())
+		for i := range urls {
+			require.NoError(t, errs[i])
+			assert.Equal(t, "https://example.com/hot", urls[i].OriginalURL)
+		}
+		assert.NotSame(t, urls[0], urls[1], "callers should not share the same pointer")
+		assert.Equal(t, uint64(49), repo.CacheStats().Coalesced)
+
+		// Result is cached for subsequent lookups
+		_, err := repo.GetByShortCode(context.Background(), "hot")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), base.calls.Load())
+	})
+
+	t.Run("errors are shared and not cached", func(t *testing.T) {
+		base := &countingURLRepository{
+			release: make(chan struct{}),
+			err:     models.ErrURLNotFound,
+		}
+		memCache := cache.NewMemoryCache(100, 0)
+		defer memCache.Close()
+		repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Minute), time.Minute)
+
+		urls, errs := getConcurrently(t, repo, base, 20)
+
+		assert.Equal(t, int64(1), base.calls.Load())
+		for i := range errs {
+			assert.ErrorIs(t, errs[i], models.ErrURLNotFound)
+			assert.Nil(t, urls[i])
+		}
+
+		// A later lookup goes back to the database
+		_, err := repo.GetByShortCode(context.Background(), "hot")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		assert.Equal(t, int64(2), base.calls.Load())
+	})
+
+	t.Run("canceled caller returns without waiting", func(t *testing.T) {
+		base := &countingURLRepository{
+			release: make(chan struct{}),
+			url:     &models.URL{ID: 1, ShortCode: "hot", OriginalURL: "https://example.com/hot"},
+		}
+		defer close(base.release)
+		memCache := cache.NewMemoryCache(100, 0)
+		defer memCache.Close()
+		repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Minute), time.Minute)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := repo.GetByShortCode(ctx, "hot")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// BenchmarkCachedURLRepository_ThunderingHerd measures database reads when
+// many goroutines miss the cache for the same code at once. Without
+// coalescing, db_calls/op would equal the herd size.
+func BenchmarkCachedURLRepository_ThunderingHerd(b *testing.B) {
+	const herd = 100
+
+	var totalCalls int64
+	for i := 0; i < b.N; i++ {
+		base := &countingURLRepository{
+			release: make(chan struct{}),
+			url:     &models.URL{ID: 1, ShortCode: "hot", OriginalURL: "https://example.com/hot"},
+		}
+		memCache := cache.NewMemoryCache(100, 0)
+		repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "bench:", time.Minute), time.Minute)
+
+		getConcurrently(b, repo, base, herd)
+
+		totalCalls += base.calls.Load()
+		_ = memCache.Close()
+	}
+
+	b.ReportMetric(float64(totalCalls)/float64(b.N), "db_calls/op")
+	b.ReportMetric(herd, "callers/op")
+}
+
+func TestCachedURLRepository_CacheStats(t *testing.T) {
+	base := &countingURLRepository{
+		release: make(chan struct{}),
+		url:     &models.URL{ID: 1, ShortCode: "hot", OriginalURL: "https://example.com/hot"},
+	}
+	close(base.release)
+	memCache := cache.NewMemoryCache(100, 0)
+	defer memCache.Close()
+	repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Minute), time.Minute)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := repo.GetByShortCode(ctx, "hot")
+		require.NoError(t, err)
+	}
+
+	stats := repo.CacheStats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(0), stats.Expired)
+}
+
+func TestCachedURLRepository_CacheStatus(t *testing.T) {
+	base := &countingURLRepository{
+		release: make(chan struct{}),
+		url:     &models.URL{ID: 1, ShortCode: "hot", OriginalURL: "https://example.com/hot"},
+	}
+	close(base.release)
+	memCache := cache.NewMemoryCache(100, 0)
+	defer memCache.Close()
+	repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Minute), time.Minute)
+
+	lookup := func(shortCode string) string {
+		ctx, status := WithCacheStatus(context.Background())
+		_, _ = repo.GetByShortCode(ctx, shortCode)
+		return status.Result()
+	}
+
+	assert.Equal(t, CacheMiss, lookup("hot"), "cold cache")
+	assert.Equal(t, CacheHit, lookup("hot"), "warmed cache")
+
+	base.url, base.err = nil, models.ErrURLNotFound
+	assert.Equal(t, CacheMiss, lookup("missing"), "unknown codes are not cached")
+	assert.Equal(t, CacheMiss, lookup("missing"))
+
+	_, status := WithCacheStatus(context.Background())
+	assert.Empty(t, status.Result(), "nothing recorded without a lookup")
+}
+
+// batchCreateURLRepository creates every entry except those with taken codes.
+type batchCreateURLRepository struct {
+	URLRepository
+	taken map[string]bool
+}
+
+func (r *batchCreateURLRepository) CreateBatch(_ context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	urls := make([]*models.URL, len(creates))
+	for i, create := range creates {
+		if !r.taken[create.ShortCode] {
+			urls[i] = &models.URL{ID: int64(i + 1), ShortCode: create.ShortCode, OriginalURL: create.OriginalURL}
+		}
+	}
+	return urls, nil
+}
+
+func TestCachedURLRepository_CreateBatch(t *testing.T) {
+	urlCache := &mockURLCache{data: map[string]*cache.CachedURL{}}
+	repo := NewCachedURLRepository(&batchCreateURLRepository{taken: map[string]bool{"taken1": true}}, urlCache, time.Hour)
+
+	urls, err := repo.CreateBatch(context.Background(), []*models.URLCreate{
+		{ShortCode: "new1", OriginalURL: "https://example.com/1"},
+		{ShortCode: "taken1", OriginalURL: "https://example.com/2"},
+		{ShortCode: "new2", OriginalURL: "https://example.com/3"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, urls, 3)
+	assert.Nil(t, urls[1])
+	// Only created entries are cached
+	assert.Len(t, urlCache.data, 2)
+	assert.Equal(t, "https://example.com/1", urlCache.data["new1"].OriginalURL)
+	assert.Equal(t, "https://example.com/3", urlCache.data["new2"].OriginalURL)
+}
+
+// batchDeleteURLRepository reports a fixed set of codes as deleted by batch
+// and expiry deletes.
+type batchDeleteURLRepository struct {
+	URLRepository
+	deleted []string
+}
+
+func (r *batchDeleteURLRepository) DeleteBatch(_ context.Context, _ []string) ([]string, error) {
+	return r.deleted, nil
+}
+
+func TestCachedURLRepository_DeleteBatch(t *testing.T) {
+	urlCache := &mockURLCache{data: map[string]*cache.CachedURL{
+		"gone1": {ShortCode: "gone1"},
+		"gone2": {ShortCode: "gone2"},
+		"stale": {ShortCode: "stale"},
+		"keep1": {ShortCode: "keep1"},
+	}}
+	repo := NewCachedURLRepository(&batchDeleteURLRepository{deleted: []string{"gone1", "gone2"}}, urlCache, time.Hour)
+
+	deleted, err := repo.DeleteBatch(context.Background(), []string{"gone1", "gone2", "stale", "missing"})
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"gone1", "gone2"}, deleted)
+	// Every requested code is evicted, even ones the database did not delete
+	assert.Len(t, urlCache.data, 1)
+	assert.Contains(t, urlCache.data, "keep1")
+}
+
+func (r *batchDeleteURLRepository) DeleteExpired(_ context.Context) ([]string, error) {
+	return r.deleted, nil
+}
+
+func TestCachedURLRepository_DeleteExpired(t *testing.T) {
+	urlCache := &mockURLCache{data: map[string]*cache.CachedURL{
+		"gone1": {ShortCode: "gone1"},
+		"gone2": {ShortCode: "gone2"},
+		"keep1": {ShortCode: "keep1"},
+	}}
+	repo := NewCachedURLRepository(&batchDeleteURLRepository{deleted: []string{"gone1", "gone2"}}, urlCache, time.Hour)
+
+	deleted, err := repo.DeleteExpired(context.Background())
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"gone1", "gone2"}, deleted)
+	assert.Len(t, urlCache.data, 1)
+	assert.Contains(t, urlCache.data, "keep1")
+}
+
+// expiryURLRepository holds a single URL whose expiry can be updated.
+type expiryURLRepository struct {
+	URLRepository
+	url *models.URL
+}
+
+func (r *expiryURLRepository) UpdateExpiry(_ context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	if shortCode != r.url.ShortCode {
+		return nil, models.ErrURLNotFound
+	}
+	url := *r.url
+	url.ExpiresAt = &expiresAt
+	return &url, nil
+}
+
+// ttlRecordingCache records the TTL of every entry it stores.
+type ttlRecordingCache struct {
+	*cache.MemoryCache
+	ttls []time.Duration
+}
+
+func (c *ttlRecordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.ttls = append(c.ttls, ttl)
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func TestCachedURLRepository_UpdateExpiry(t *testing.T) {
+	memCache := &ttlRecordingCache{MemoryCache: cache.NewMemoryCache(100, 0)}
+	defer memCache.Close()
+	base := &expiryURLRepository{url: &models.URL{ID: 1, ShortCode: "ext1", OriginalURL: "https://example.com"}}
+	urlCache := cache.NewURLCache(memCache, "test:", time.Hour)
+	repo := NewCachedURLRepository(base, urlCache, time.Hour)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(5 * time.Minute)
+	url, err := repo.UpdateExpiry(ctx, "ext1", expiresAt)
+	require.NoError(t, err)
+	require.NotNil(t, url.ExpiresAt)
+	assert.True(t, url.ExpiresAt.Equal(expiresAt))
+
+	// The entry is re-cached with its TTL capped by the new expiry
+	require.Len(t, memCache.ttls, 1)
+	assert.LessOrEqual(t, memCache.ttls[0], 5*time.Minute)
+	assert.Greater(t, memCache.ttls[0], 4*time.Minute)
+
+	cached, err := urlCache.Get(ctx, "ext1")
+	require.NoError(t, err)
+	require.NotNil(t, cached.ExpiresAt)
+	assert.True(t, cached.ExpiresAt.Equal(expiresAt))
+
+	_, err = repo.UpdateExpiry(ctx, "missing", expiresAt)
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
+
+// toggleURLRepository holds a single URL that can be enabled and disabled,
+// counting the lookups that reach it.
+type toggleURLRepository struct {
+	URLRepository
+	url  models.URL
+	gets int
+}
+
+func (r *toggleURLRepository) GetByShortCode(_ context.Context, shortCode string) (*models.URL, error) {
+	r.gets++
+	if shortCode != r.url.ShortCode {
+		return nil, models.ErrURLNotFound
+	}
+	url := r.url
+	return &url, nil
+}
+
+func (r *toggleURLRepository) SetActive(_ context.Context, shortCode string, active bool) (*models.URL, error) {
+	if shortCode != r.url.ShortCode {
+		return nil, models.ErrURLNotFound
+	}
+	r.url.Disabled = !active
+	url := r.url
+	return &url, nil
+}
+
+func TestCachedURLRepository_SetActive(t *testing.T) {
+	memCache := cache.NewMemoryCache(100, 0)
+	defer memCache.Close()
+	base := &toggleURLRepository{url: models.URL{ID: 1, ShortCode: "tog1", OriginalURL: "https://example.com"}}
+	repo := NewCachedURLRepository(base, cache.NewURLCache(memCache, "test:", time.Hour), time.Hour)
+	ctx := context.Background()
+
+	// Warm the cache with the active URL
+	url, err := repo.GetByShortCode(ctx, "tog1")
+	require.NoError(t, err)
+	assert.False(t, url.Disabled)
+	require.Equal(t, 1, base.gets)
+
+	url, err = repo.SetActive(ctx, "tog1", false)
+	require.NoError(t, err)
+	assert.True(t, url.Disabled)
+
+	// The cached entry reflects the new state without a database read
+	url, err = repo.GetByShortCode(ctx, "tog1")
+	require.NoError(t, err)
+	assert.True(t, url.Disabled)
+
+	_, err = repo.SetActive(ctx, "tog1", true)
+	require.NoError(t, err)
+
+	url, err = repo.GetByShortCode(ctx, "tog1")
+	require.NoError(t, err)
+	assert.False(t, url.Disabled)
+	assert.Equal(t, 1, base.gets)
+
+	_, err = repo.SetActive(ctx, "missing", false)
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
+
+// flakyURLCache fails every operation while down, like a dropped Redis
+// connection, and counts the calls that reach it.
+type flakyURLCache struct {
+	mockURLCache
+	down  atomic.Bool
+	calls atomic.Int64
+}
+
+var errCacheDown = errors.New("cache get failed: connection refused")
+
+func (m *flakyURLCache) Get(ctx context.Context, shortCode string) (*cache.CachedURL, error) {
+	m.calls.Add(1)
+	if m.down.Load() {
+		return nil, errCacheDown
+	}
+	return m.mockURLCache.Get(ctx, shortCode)
+}
+
+func (m *flakyURLCache) SetWithTTL(ctx context.Context, url *cache.CachedURL, ttl time.Duration) error {
+	m.calls.Add(1)
+	if m.down.Load() {
+		return errCacheDown
+	}
+	return m.mockURLCache.SetWithTTL(ctx, url, ttl)
+}
+
+func (m *flakyURLCache) Exists(ctx context.Context, shortCode string) (bool, error) {
+	m.calls.Add(1)
+	if m.down.Load() {
+		return false, errCacheDown
+	}
+	return m.mockURLCache.Exists(ctx, shortCode)
+}
+
+func (m *flakyURLCache) Ping(_ context.Context) error {
+	if m.down.Load() {
+		return errCacheDown
+	}
+	return nil
+}
+
+func (m *flakyURLCache) Healthy() bool {
+	return !m.down.Load()
+}
+
+func TestCachedURLRepository_DegradedCache(t *testing.T) {
+	base := &countingURLRepository{
+		release: make(chan struct{}),
+		url:     &models.URL{ID: 1, ShortCode: "hot", OriginalURL: "https://example.com/hot"},
+	}
+	close(base.release)
+	urlCache := &flakyURLCache{mockURLCache: mockURLCache{data: map[string]*cache.CachedURL{}}}
+	repo := NewCachedURLRepository(base, urlCache, time.Minute)
+	ctx := context.Background()
+
+	// Redis drops: lookups are served from the database without touching the cache
+	urlCache.down.Store(true)
+	for i := 0; i < 3; i++ {
+		url, err := repo.GetByShortCode(ctx, "hot")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/hot", url.OriginalURL)
+	}
+	assert.Equal(t, int64(3), base.calls.Load())
+	assert.Zero(t, urlCache.calls.Load())
+	assert.Empty(t, urlCache.data)
+
+	// Redis recovers: the next miss refills the cache and later reads hit it
+	urlCache.down.Store(false)
+	_, err := repo.GetByShortCode(ctx, "hot")
+	require.NoError(t, err)
+	assert.Contains(t, urlCache.data, "hot")
+
+	url, err := repo.GetByShortCode(ctx, "hot")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hot", url.OriginalURL)
+	assert.Equal(t, int64(4), base.calls.Load())
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// clickCountColumn selects a URL's total clicks: the live urls.click_count
+// plus whatever ArchiveClickCounts has moved out of it. Queries that return
+// URLs read from urlsWithArchive and use it in place of click_count so
+// totals survive an archive cycle.
+const clickCountColumn = `urls.click_count + COALESCE(a.click_count, 0) AS click_count`
+
+// urlsWithArchive joins each URL to its archived clicks. USING keeps
+// short_code a single, unambiguous column.
+const urlsWithArchive = `urls LEFT JOIN url_click_archive a USING (short_code)`
+
+// returningClickCountColumn is clickCountColumn for UPDATE ... RETURNING,
+// which can't join. The lookup runs once per updated row, by primary key.
+const returningClickCountColumn = `urls.click_count + COALESCE((SELECT a.click_count FROM url_click_archive a WHERE a.short_code = urls.short_code), 0) AS click_count`
+
+// ClickArchiveRepository defines the interface for archiving click totals.
+type ClickArchiveRepository interface {
+	// ArchiveClickCounts moves the live click count of every URL with at
+	// least threshold clicks into the archive and resets it, returning the
+	// number of URLs archived.
+	ArchiveClickCounts(ctx context.Context, threshold int64) (int64, error)
+}
+
+// PostgresClickArchiveRepository implements ClickArchiveRepository using
+// PostgreSQL. Archived clicks are kept per short code in url_click_archive.
+type PostgresClickArchiveRepository struct {
+	pool *database.Pool
+}
+
+// NewPostgresClickArchiveRepository creates a new PostgreSQL-backed click archive repository.
+func NewPostgresClickArchiveRepository(pool *database.Pool) *PostgresClickArchiveRepository {
+	return &PostgresClickArchiveRepository{pool: pool}
+}
+
+// ArchiveClickCounts moves hot click counts into url_click_archive in a
+// single statement. URLs with a click limit are skipped, because ClaimClick
+// compares the live counter against max_clicks. Counts are subtracted rather
+// than zeroed, so clicks flushed while the statement waits for row locks
+// are kept.
+func (r *PostgresClickArchiveRepository) ArchiveClickCounts(ctx context.Context, threshold int64) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "PostgresClickArchiveRepository.ArchiveClickCounts", attribute.Int64("click.archive_threshold", threshold))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		WITH hot AS (
+			SELECT short_code, click_count FROM urls
+			WHERE click_count >= $1 AND max_clicks IS NULL
+			FOR UPDATE
+		), reset AS (
+			UPDATE urls SET click_count = urls.click_count - hot.click_count
+			FROM hot WHERE urls.short_code = hot.short_code
+		)
+		INSERT INTO url_click_archive (short_code, click_count, archived_at)
+		SELECT short_code, click_count, NOW() FROM hot
+		ON CONFLICT (short_code)
+		DO UPDATE SET click_count = url_click_archive.click_count + EXCLUDED.click_count,
+			archived_at = EXCLUDED.archived_at
+	`
+
+	result, err := r.pool.Exec(ctx, query, threshold)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive click counts: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
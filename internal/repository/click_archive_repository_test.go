@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func TestPostgresClickArchiveRepository(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	urls := NewPostgresURLRepository(pool)
+	archive := NewPostgresClickArchiveRepository(pool)
+	ctx := context.Background()
+
+	limit := int64(100)
+	for _, create := range []*models.URLCreate{
+		{ShortCode: "arc1", OriginalURL: "https://example.com/1"},
+		{ShortCode: "arc2", OriginalURL: "https://example.com/2"},
+		{ShortCode: "arc3", OriginalURL: "https://example.com/3", MaxClicks: &limit},
+	} {
+		_, err := urls.Create(ctx, create)
+		require.NoError(t, err)
+	}
+
+	clickCount := func(shortCode string) int64 {
+		t.Helper()
+		url, err := urls.GetByShortCode(ctx, shortCode)
+		require.NoError(t, err)
+		return url.ClickCount
+	}
+	liveCount := func(shortCode string) int64 {
+		t.Helper()
+		var n int64
+		require.NoError(t, pool.QueryRow(ctx, "SELECT click_count FROM urls WHERE short_code = $1", shortCode).Scan(&n))
+		return n
+	}
+
+	_, err := urls.BatchIncrementClickCounts(ctx, map[string]int64{"arc1": 50, "arc2": 5, "arc3": 60})
+	require.NoError(t, err)
+
+	archived, err := archive.ArchiveClickCounts(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), archived, "only arc1 is hot; arc3 has a click limit")
+
+	assert.Zero(t, liveCount("arc1"))
+	assert.Equal(t, int64(50), clickCount("arc1"))
+	assert.Equal(t, int64(5), clickCount("arc2"))
+	assert.Equal(t, int64(60), liveCount("arc3"))
+
+	// Clicks after an archive cycle add to the archived total
+	_, err = urls.BatchIncrementClickCounts(ctx, map[string]int64{"arc1": 20})
+	require.NoError(t, err)
+	_, err = archive.ArchiveClickCounts(ctx, 10)
+	require.NoError(t, err)
+	totals, err := urls.BatchIncrementClickCounts(ctx, map[string]int64{"arc1": 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"arc1": 73}, totals, "returned totals include archived clicks")
+
+	assert.Equal(t, int64(3), liveCount("arc1"))
+	assert.Equal(t, int64(73), clickCount("arc1"))
+
+	top, err := urls.TopByClicks(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, "arc1", top[0].ShortCode, "ranking uses archived clicks too")
+
+	// Deleting a URL drops its archive, so a reused code starts at zero
+	require.NoError(t, urls.DeletePermanent(ctx, "arc1"))
+	_, err = urls.Create(ctx, &models.URLCreate{ShortCode: "arc1", OriginalURL: "https://example.com/again"})
+	require.NoError(t, err)
+	assert.Zero(t, clickCount("arc1"))
+}
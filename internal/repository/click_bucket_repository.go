@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// ClickBucketRepository defines the interface for time-series click persistence.
+type ClickBucketRepository interface {
+	// IncrementClickBuckets adds click counts to their time windows,
+	// creating windows as needed.
+	IncrementClickBuckets(ctx context.Context, buckets map[analytics.BucketKey]int64) error
+
+	// GetClickBuckets returns the clicks of a short code in [from, to),
+	// summed per interval window and ordered by time. Windows without
+	// clicks are omitted.
+	GetClickBuckets(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) ([]models.ClickBucket, error)
+}
+
+// PostgresClickBucketRepository implements ClickBucketRepository using PostgreSQL.
+// Clicks are stored in analytics.BucketWidth windows and summed into coarser
+// intervals at query time.
+type PostgresClickBucketRepository struct {
+	pool *database.Pool
+}
+
+// NewPostgresClickBucketRepository creates a new PostgreSQL-backed click bucket repository.
+func NewPostgresClickBucketRepository(pool *database.Pool) *PostgresClickBucketRepository {
+	return &PostgresClickBucketRepository{pool: pool}
+}
+
+// IncrementClickBuckets upserts all buckets in a single statement.
+func (r *PostgresClickBucketRepository) IncrementClickBuckets(ctx context.Context, buckets map[analytics.BucketKey]int64) (err error) {
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	ctx, span := startSpan(ctx, "PostgresClickBucketRepository.IncrementClickBuckets", attribute.Int("click.bucket_count", len(buckets)))
+	defer func() { tracing.End(span, err) }()
+
+	codes := make([]string, 0, len(buckets))
+	starts := make([]time.Time, 0, len(buckets))
+	counts := make([]int64, 0, len(buckets))
+	for key, count := range buckets {
+		codes = append(codes, key.ShortCode)
+		starts = append(starts, key.Start)
+		counts = append(counts, count)
+	}
+
+	query := `
+		INSERT INTO click_buckets (short_code, bucket_start, click_count)
+		SELECT * FROM unnest($1::VARCHAR[], $2::TIMESTAMPTZ[], $3::BIGINT[])
+		ON CONFLICT (short_code, bucket_start)
+		DO UPDATE SET click_count = click_buckets.click_count + EXCLUDED.click_count
+	`
+
+	_, err = r.pool.Exec(ctx, query, codes, starts, counts)
+	if err != nil {
+		return fmt.Errorf("failed to increment click buckets: %w", err)
+	}
+
+	return nil
+}
+
+// GetClickBuckets returns the clicks of a short code in [from, to) per interval window.
+func (r *PostgresClickBucketRepository) GetClickBuckets(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) (_ []models.ClickBucket, err error) {
+	ctx, span := startSpan(ctx, "PostgresClickBucketRepository.GetClickBuckets",
+		tracing.ShortCodeKey.String(shortCode), attribute.String("click.interval", string(interval)))
+	defer func() { tracing.End(span, err) }()
+
+	if interval.Duration() == 0 {
+		return nil, fmt.Errorf("unsupported interval %q", interval)
+	}
+
+	// Truncate in UTC so days line up with models.Interval.Truncate
+	query := `
+		SELECT date_trunc($4::TEXT, bucket_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS window_start,
+			SUM(click_count)::BIGINT
+		FROM click_buckets
+		WHERE short_code = $1 AND bucket_start >= $2 AND bucket_start < $3
+		GROUP BY window_start
+		ORDER BY window_start
+	`
+
+	rows, err := r.pool.Query(ctx, query, shortCode, from, to, string(interval))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get click buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []models.ClickBucket
+	for rows.Next() {
+		var b models.ClickBucket
+		if err := rows.Scan(&b.Timestamp, &b.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan click bucket: %w", err)
+		}
+		b.Timestamp = b.Timestamp.UTC()
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get click buckets: %w", err)
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func setupClickBucketTestDB(t *testing.T) (*database.Pool, func()) {
+	t.Helper()
+
+	ctx := context.Background()
+	pool, err := database.NewPool(ctx, testDBConfig())
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS click_buckets (
+			short_code VARCHAR(10) NOT NULL,
+			bucket_start TIMESTAMPTZ NOT NULL,
+			click_count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (short_code, bucket_start)
+		)
+	`)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		_, _ = pool.Exec(ctx, "DELETE FROM click_buckets")
+		pool.Close()
+	}
+
+	return pool, cleanup
+}
+
+func TestPostgresClickBucketRepository(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupClickBucketTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresClickBucketRepository(pool)
+	ctx := context.Background()
+
+	midnight := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	// Flushes for the same window accumulate
+	require.NoError(t, repo.IncrementClickBuckets(ctx, map[analytics.BucketKey]int64{
+		{ShortCode: "ts1", Start: midnight.Add(-time.Hour)}:     1, // 23:00 the day before
+		{ShortCode: "ts1", Start: midnight}:                     2,
+		{ShortCode: "ts1", Start: midnight.Add(23 * time.Hour)}: 3,
+		{ShortCode: "ts2", Start: midnight}:                     7,
+	}))
+	require.NoError(t, repo.IncrementClickBuckets(ctx, map[analytics.BucketKey]int64{
+		{ShortCode: "ts1", Start: midnight}: 4,
+	}))
+
+	t.Run("hourly", func(t *testing.T) {
+		buckets, err := repo.GetClickBuckets(ctx, "ts1", midnight.Add(-time.Hour), midnight.Add(24*time.Hour), models.IntervalHour)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ClickBucket{
+			{Timestamp: midnight.Add(-time.Hour), Count: 1},
+			{Timestamp: midnight, Count: 6},
+			{Timestamp: midnight.Add(23 * time.Hour), Count: 3},
+		}, buckets)
+	})
+
+	t.Run("daily sums hours on UTC day boundaries", func(t *testing.T) {
+		buckets, err := repo.GetClickBuckets(ctx, "ts1", midnight.Add(-24*time.Hour), midnight.Add(24*time.Hour), models.IntervalDay)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ClickBucket{
+			{Timestamp: midnight.Add(-24 * time.Hour), Count: 1},
+			{Timestamp: midnight, Count: 9},
+		}, buckets)
+	})
+
+	t.Run("range end is exclusive", func(t *testing.T) {
+		buckets, err := repo.GetClickBuckets(ctx, "ts1", midnight, midnight.Add(23*time.Hour), models.IntervalHour)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ClickBucket{{Timestamp: midnight, Count: 6}}, buckets)
+	})
+
+	t.Run("unknown code has no buckets", func(t *testing.T) {
+		buckets, err := repo.GetClickBuckets(ctx, "nope", midnight, midnight.Add(time.Hour), models.IntervalHour)
+		require.NoError(t, err)
+		assert.Empty(t, buckets)
+	})
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// ClickCountryRepository defines the interface for persisting clicks per country.
+type ClickCountryRepository interface {
+	// IncrementClickCountries adds click counts per short code and country.
+	IncrementClickCountries(ctx context.Context, countries map[analytics.CountryKey]int64) error
+
+	// GetCountries returns up to limit countries of a short code, most clicks first.
+	GetCountries(ctx context.Context, shortCode string, limit int) ([]models.CountryCount, error)
+}
+
+// PostgresClickCountryRepository implements ClickCountryRepository using PostgreSQL.
+type PostgresClickCountryRepository struct {
+	pool *database.Pool
+}
+
+// NewPostgresClickCountryRepository creates a new PostgreSQL-backed click country repository.
+func NewPostgresClickCountryRepository(pool *database.Pool) *PostgresClickCountryRepository {
+	return &PostgresClickCountryRepository{pool: pool}
+}
+
+// IncrementClickCountries upserts all country counts in a single statement.
+func (r *PostgresClickCountryRepository) IncrementClickCountries(ctx context.Context, countries map[analytics.CountryKey]int64) (err error) {
+	if len(countries) == 0 {
+		return nil
+	}
+
+	ctx, span := startSpan(ctx, "PostgresClickCountryRepository.IncrementClickCountries", attribute.Int("click.country_count", len(countries)))
+	defer func() { tracing.End(span, err) }()
+
+	codes := make([]string, 0, len(countries))
+	names := make([]string, 0, len(countries))
+	counts := make([]int64, 0, len(countries))
+	for key, count := range countries {
+		codes = append(codes, key.ShortCode)
+		names = append(names, key.Country)
+		counts = append(counts, count)
+	}
+
+	query := `
+		INSERT INTO click_countries (short_code, country, click_count)
+		SELECT * FROM unnest($1::VARCHAR[], $2::VARCHAR[], $3::BIGINT[])
+		ON CONFLICT (short_code, country)
+		DO UPDATE SET click_count = click_countries.click_count + EXCLUDED.click_count
+	`
+
+	_, err = r.pool.Exec(ctx, query, codes, names, counts)
+	if err != nil {
+		return fmt.Errorf("failed to increment click countries: %w", err)
+	}
+
+	return nil
+}
+
+// GetCountries returns up to limit countries of a short code, most clicks first.
+func (r *PostgresClickCountryRepository) GetCountries(ctx context.Context, shortCode string, limit int) (_ []models.CountryCount, err error) {
+	ctx, span := startSpan(ctx, "PostgresClickCountryRepository.GetCountries", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT country, click_count
+		FROM click_countries
+		WHERE short_code = $1
+		ORDER BY click_count DESC, country
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, shortCode, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+	defer rows.Close()
+
+	countries := []models.CountryCount{}
+	for rows.Next() {
+		var cc models.CountryCount
+		if err := rows.Scan(&cc.Country, &cc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan country: %w", err)
+		}
+		countries = append(countries, cc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	return countries, nil
+}
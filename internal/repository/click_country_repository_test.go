@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func setupClickCountryTestDB(t *testing.T) (*database.Pool, func()) {
+	t.Helper()
+
+	ctx := context.Background()
+	pool, err := database.NewPool(ctx, testDBConfig())
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS click_countries (
+			short_code VARCHAR(10) NOT NULL,
+			country CHAR(2) NOT NULL,
+			click_count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (short_code, country)
+		)
+	`)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		_, _ = pool.Exec(ctx, "DELETE FROM click_countries")
+		pool.Close()
+	}
+
+	return pool, cleanup
+}
+
+func TestPostgresClickCountryRepository(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupClickCountryTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresClickCountryRepository(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.IncrementClickCountries(ctx, map[analytics.CountryKey]int64{
+		{ShortCode: "geo1", Country: "DE"}: 2,
+		{ShortCode: "geo1", Country: "US"}: 1,
+		{ShortCode: "geo2", Country: "JP"}: 4,
+	}))
+	// Later flushes accumulate
+	require.NoError(t, repo.IncrementClickCountries(ctx, map[analytics.CountryKey]int64{
+		{ShortCode: "geo1", Country: "US"}: 3,
+	}))
+
+	t.Run("countries by clicks", func(t *testing.T) {
+		countries, err := repo.GetCountries(ctx, "geo1", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []models.CountryCount{
+			{Country: "US", Count: 4},
+			{Country: "DE", Count: 2},
+		}, countries)
+	})
+
+	t.Run("honors limit", func(t *testing.T) {
+		countries, err := repo.GetCountries(ctx, "geo1", 1)
+		require.NoError(t, err)
+		assert.Len(t, countries, 1)
+	})
+
+	t.Run("unknown code is empty", func(t *testing.T) {
+		countries, err := repo.GetCountries(ctx, "nope", 10)
+		require.NoError(t, err)
+		assert.Empty(t, countries)
+	})
+}
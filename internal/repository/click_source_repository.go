@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// ClickSourceRepository defines the interface for persisting where clicks came from.
+type ClickSourceRepository interface {
+	// IncrementClickSources adds click counts per referrer and per browser/OS.
+	IncrementClickSources(ctx context.Context, referrers map[analytics.ReferrerKey]int64, agents map[analytics.AgentKey]int64) error
+
+	// GetReferrers returns up to limit referrers of a short code, most clicks first.
+	GetReferrers(ctx context.Context, shortCode string, limit int) ([]models.ReferrerCount, error)
+
+	// GetAgents returns up to limit browser/OS pairs of a short code, most clicks first.
+	GetAgents(ctx context.Context, shortCode string, limit int) ([]models.AgentCount, error)
+}
+
+// PostgresClickSourceRepository implements ClickSourceRepository using PostgreSQL.
+type PostgresClickSourceRepository struct {
+	pool *database.Pool
+}
+
+// NewPostgresClickSourceRepository creates a new PostgreSQL-backed click source repository.
+func NewPostgresClickSourceRepository(pool *database.Pool) *PostgresClickSourceRepository {
+	return &PostgresClickSourceRepository{pool: pool}
+}
+
+// IncrementClickSources upserts referrer and agent counts in one batch.
+func (r *PostgresClickSourceRepository) IncrementClickSources(ctx context.Context, referrers map[analytics.ReferrerKey]int64, agents map[analytics.AgentKey]int64) (err error) {
+	if len(referrers) == 0 && len(agents) == 0 {
+		return nil
+	}
+
+	ctx, span := startSpan(ctx, "PostgresClickSourceRepository.IncrementClickSources",
+		attribute.Int("click.referrer_count", len(referrers)), attribute.Int("click.agent_count", len(agents)))
+	defer func() { tracing.End(span, err) }()
+
+	batch := &pgx.Batch{}
+
+	if len(referrers) > 0 {
+		codes := make([]string, 0, len(referrers))
+		hosts := make([]string, 0, len(referrers))
+		counts := make([]int64, 0, len(referrers))
+		for key, count := range referrers {
+			codes = append(codes, key.ShortCode)
+			hosts = append(hosts, key.Referrer)
+			counts = append(counts, count)
+		}
+		batch.Queue(`
+			INSERT INTO click_referrers (short_code, referrer, click_count)
+			SELECT * FROM unnest($1::VARCHAR[], $2::VARCHAR[], $3::BIGINT[])
+			ON CONFLICT (short_code, referrer)
+			DO UPDATE SET click_count = click_referrers.click_count + EXCLUDED.click_count
+		`, codes, hosts, counts)
+	}
+
+	if len(agents) > 0 {
+		codes := make([]string, 0, len(agents))
+		browsers := make([]string, 0, len(agents))
+		systems := make([]string, 0, len(agents))
+		counts := make([]int64, 0, len(agents))
+		for key, count := range agents {
+			codes = append(codes, key.ShortCode)
+			browsers = append(browsers, key.Browser)
+			systems = append(systems, key.OS)
+			counts = append(counts, count)
+		}
+		batch.Queue(`
+			INSERT INTO click_agents (short_code, browser, os, click_count)
+			SELECT * FROM unnest($1::VARCHAR[], $2::VARCHAR[], $3::VARCHAR[], $4::BIGINT[])
+			ON CONFLICT (short_code, browser, os)
+			DO UPDATE SET click_count = click_agents.click_count + EXCLUDED.click_count
+		`, codes, browsers, systems, counts)
+	}
+
+	if err = r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to increment click sources: %w", err)
+	}
+
+	return nil
+}
+
+// GetReferrers returns up to limit referrers of a short code, most clicks first.
+func (r *PostgresClickSourceRepository) GetReferrers(ctx context.Context, shortCode string, limit int) (_ []models.ReferrerCount, err error) {
+	ctx, span := startSpan(ctx, "PostgresClickSourceRepository.GetReferrers", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT referrer, click_count
+		FROM click_referrers
+		WHERE short_code = $1
+		ORDER BY click_count DESC, referrer
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, shortCode, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referrers: %w", err)
+	}
+	defer rows.Close()
+
+	referrers := []models.ReferrerCount{}
+	for rows.Next() {
+		var rc models.ReferrerCount
+		if err := rows.Scan(&rc.Referrer, &rc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan referrer: %w", err)
+		}
+		referrers = append(referrers, rc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get referrers: %w", err)
+	}
+
+	return referrers, nil
+}
+
+// GetAgents returns up to limit browser/OS pairs of a short code, most clicks first.
+func (r *PostgresClickSourceRepository) GetAgents(ctx context.Context, shortCode string, limit int) (_ []models.AgentCount, err error) {
+	ctx, span := startSpan(ctx, "PostgresClickSourceRepository.GetAgents", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT browser, os, click_count
+		FROM click_agents
+		WHERE short_code = $1
+		ORDER BY click_count DESC, browser, os
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, shortCode, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agents: %w", err)
+	}
+	defer rows.Close()
+
+	agents := []models.AgentCount{}
+	for rows.Next() {
+		var ac models.AgentCount
+		if err := rows.Scan(&ac.Browser, &ac.OS, &ac.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan agent: %w", err)
+		}
+		agents = append(agents, ac)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get agents: %w", err)
+	}
+
+	return agents, nil
+}
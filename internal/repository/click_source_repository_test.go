@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func setupClickSourceTestDB(t *testing.T) (*database.Pool, func()) {
+	t.Helper()
+
+	ctx := context.Background()
+	pool, err := database.NewPool(ctx, testDBConfig())
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS click_referrers (
+			short_code VARCHAR(10) NOT NULL,
+			referrer VARCHAR(253) NOT NULL,
+			click_count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (short_code, referrer)
+		);
+		CREATE TABLE IF NOT EXISTS click_agents (
+			short_code VARCHAR(10) NOT NULL,
+			browser VARCHAR(64) NOT NULL,
+			os VARCHAR(64) NOT NULL,
+			click_count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (short_code, browser, os)
+		)
+	`)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		_, _ = pool.Exec(ctx, "DELETE FROM click_referrers")
+		_, _ = pool.Exec(ctx, "DELETE FROM click_agents")
+		pool.Close()
+	}
+
+	return pool, cleanup
+}
+
+func TestPostgresClickSourceRepository(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupClickSourceTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresClickSourceRepository(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.IncrementClickSources(ctx,
+		map[analytics.ReferrerKey]int64{
+			{ShortCode: "src1", Referrer: "google.com"}:  2,
+			{ShortCode: "src1", Referrer: "direct"}:      1,
+			{ShortCode: "src1", Referrer: "bing.com"}:    1,
+			{ShortCode: "src2", Referrer: "example.com"}: 9,
+		},
+		map[analytics.AgentKey]int64{
+			{ShortCode: "src1", Browser: "Firefox", OS: "Linux"}:  3,
+			{ShortCode: "src1", Browser: "Chrome", OS: "Windows"}: 1,
+		},
+	))
+	// Later flushes accumulate, and either map may be empty
+	require.NoError(t, repo.IncrementClickSources(ctx,
+		map[analytics.ReferrerKey]int64{{ShortCode: "src1", Referrer: "bing.com"}: 5},
+		nil,
+	))
+
+	t.Run("referrers by clicks", func(t *testing.T) {
+		referrers, err := repo.GetReferrers(ctx, "src1", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ReferrerCount{
+			{Referrer: "bing.com", Count: 6},
+			{Referrer: "google.com", Count: 2},
+			{Referrer: "direct", Count: 1},
+		}, referrers)
+	})
+
+	t.Run("referrers honor limit", func(t *testing.T) {
+		referrers, err := repo.GetReferrers(ctx, "src1", 1)
+		require.NoError(t, err)
+		assert.Len(t, referrers, 1)
+	})
+
+	t.Run("agents by clicks", func(t *testing.T) {
+		agents, err := repo.GetAgents(ctx, "src1", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []models.AgentCount{
+			{Browser: "Firefox", OS: "Linux", Count: 3},
+			{Browser: "Chrome", OS: "Windows", Count: 1},
+		}, agents)
+	})
+
+	t.Run("unknown code is empty", func(t *testing.T) {
+		agents, err := repo.GetAgents(ctx, "nope", 10)
+		require.NoError(t, err)
+		assert.Empty(t, agents)
+	})
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/emadnahed/FastGoLink/internal/cache"
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// LinkStatusRepository defines the persistence used by the link checker.
+type LinkStatusRepository interface {
+	// ListLinksToCheck returns up to limit live URLs that were never checked
+	// or were last checked before checkedBefore, least recently checked first.
+	// Only the ID, ShortCode and OriginalURL fields are set.
+	ListLinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]*models.URL, error)
+
+	// UpdateLinkStatus records the result of checking a URL's destination.
+	UpdateLinkStatus(ctx context.Context, shortCode string, status int, checkedAt time.Time) error
+}
+
+// PostgresLinkStatusRepository implements LinkStatusRepository using PostgreSQL.
+// It writes the link_status columns of the urls table directly; wrap it in a
+// CachedLinkStatusRepository to drop cached copies of the URLs it updates.
+type PostgresLinkStatusRepository struct {
+	pool *database.Pool
+}
+
+// NewPostgresLinkStatusRepository creates a new PostgreSQL-backed link status repository.
+func NewPostgresLinkStatusRepository(pool *database.Pool) *PostgresLinkStatusRepository {
+	return &PostgresLinkStatusRepository{pool: pool}
+}
+
+// ListLinksToCheck returns the URLs due for a check. Expired URLs are skipped
+// since they no longer redirect.
+func (r *PostgresLinkStatusRepository) ListLinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) (_ []*models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresLinkStatusRepository.ListLinksToCheck", attribute.Int("list.limit", limit))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT id, short_code, original_url
+		FROM urls
+		WHERE deleted_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND (link_checked_at IS NULL OR link_checked_at < $1)
+		ORDER BY link_checked_at NULLS FIRST, id
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, checkedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links to check: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		var url models.URL
+		if err := rows.Scan(&url.ID, &url.ShortCode, &url.OriginalURL); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		urls = append(urls, &url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list links to check: %w", err)
+	}
+
+	return urls, nil
+}
+
+// UpdateLinkStatus records the result of checking a URL's destination.
+func (r *PostgresLinkStatusRepository) UpdateLinkStatus(ctx context.Context, shortCode string, status int, checkedAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "PostgresLinkStatusRepository.UpdateLinkStatus", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE urls SET link_status = $2, link_checked_at = $3, updated_at = NOW() WHERE short_code = $1 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, shortCode, status, checkedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update link status: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// CachedLinkStatusRepository wraps a LinkStatusRepository and removes a URL
+// from the cache when its link status changes, so lookups show the new result.
+type CachedLinkStatusRepository struct {
+	repo  LinkStatusRepository
+	cache cache.URLCacher
+}
+
+// NewCachedLinkStatusRepository creates a LinkStatusRepository that
+// invalidates urlCache entries on update.
+func NewCachedLinkStatusRepository(repo LinkStatusRepository, urlCache cache.URLCacher) *CachedLinkStatusRepository {
+	return &CachedLinkStatusRepository{repo: repo, cache: urlCache}
+}
+
+// ListLinksToCheck returns the URLs due for a check.
+func (r *CachedLinkStatusRepository) ListLinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]*models.URL, error) {
+	return r.repo.ListLinksToCheck(ctx, checkedBefore, limit)
+}
+
+// UpdateLinkStatus records the result of a check and invalidates the cache.
+func (r *CachedLinkStatusRepository) UpdateLinkStatus(ctx context.Context, shortCode string, status int, checkedAt time.Time) error {
+	err := r.repo.UpdateLinkStatus(ctx, shortCode, status, checkedAt)
+	if err == nil {
+		_ = r.cache.Delete(ctx, shortCode)
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/cache"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func TestPostgresLinkStatusRepository(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	urlRepo := NewPostgresURLRepository(pool)
+	repo := NewPostgresLinkStatusRepository(pool)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	for _, create := range []*models.URLCreate{
+		{ShortCode: "lsfresh", OriginalURL: "https://example.com/fresh"},
+		{ShortCode: "lsstale", OriginalURL: "https://example.com/stale"},
+		{ShortCode: "lsnew", OriginalURL: "https://example.com/new"},
+		{ShortCode: "lsexp", OriginalURL: "https://example.com/expired", ExpiresAt: &past},
+	} {
+		_, err := urlRepo.Create(ctx, create)
+		require.NoError(t, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.UpdateLinkStatus(ctx, "lsfresh", 200, now))
+	require.NoError(t, repo.UpdateLinkStatus(ctx, "lsstale", 404, now.Add(-48*time.Hour)))
+	assert.ErrorIs(t, repo.UpdateLinkStatus(ctx, "nosuch", 200, now), models.ErrURLNotFound)
+
+	t.Run("lists unchecked links first, then stale ones", func(t *testing.T) {
+		urls, err := repo.ListLinksToCheck(ctx, now.Add(-24*time.Hour), 10)
+		require.NoError(t, err)
+
+		var codes []string
+		for _, url := range urls {
+			codes = append(codes, url.ShortCode)
+		}
+		assert.Equal(t, []string{"lsnew", "lsstale"}, codes)
+	})
+
+	t.Run("respects limit", func(t *testing.T) {
+		urls, err := repo.ListLinksToCheck(ctx, now.Add(-24*time.Hour), 1)
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "lsnew", urls[0].ShortCode)
+	})
+
+	t.Run("status is returned with the URL", func(t *testing.T) {
+		url, err := urlRepo.GetByShortCode(ctx, "lsstale")
+		require.NoError(t, err)
+		require.NotNil(t, url.LinkStatus)
+		assert.Equal(t, 404, *url.LinkStatus)
+		require.NotNil(t, url.LinkCheckedAt)
+		assert.True(t, url.LinkCheckedAt.Equal(now.Add(-48*time.Hour)))
+		assert.True(t, url.IsLinkBroken())
+	})
+}
+
+// toggleLinkStatusRepository records link statuses on a toggleURLRepository's URL.
+type toggleLinkStatusRepository struct {
+	LinkStatusRepository
+	urls *toggleURLRepository
+}
+
+func (r *toggleLinkStatusRepository) UpdateLinkStatus(_ context.Context, shortCode string, status int, checkedAt time.Time) error {
+	if shortCode != r.urls.url.ShortCode {
+		return models.ErrURLNotFound
+	}
+	r.urls.url.LinkStatus = &status
+	r.urls.url.LinkCheckedAt = &checkedAt
+	return nil
+}
+
+func TestCachedLinkStatusRepository(t *testing.T) {
+	memCache := cache.NewMemoryCache(100, 0)
+	defer memCache.Close()
+	urlCache := cache.NewURLCache(memCache, "test:", time.Hour)
+	status, checkedAt := 200, time.Now().UTC().Truncate(time.Second)
+	base := &toggleURLRepository{url: models.URL{
+		ID: 1, ShortCode: "ls1", OriginalURL: "https://example.com", LinkStatus: &status, LinkCheckedAt: &checkedAt,
+	}}
+	urlRepo := NewCachedURLRepository(base, urlCache, time.Hour)
+	repo := NewCachedLinkStatusRepository(&toggleLinkStatusRepository{urls: base}, urlCache)
+	ctx := context.Background()
+
+	// Cache hits carry the link status like the database read did
+	_, err := urlRepo.GetByShortCode(ctx, "ls1")
+	require.NoError(t, err)
+	url, err := urlRepo.GetByShortCode(ctx, "ls1")
+	require.NoError(t, err)
+	require.Equal(t, 1, base.gets)
+	require.NotNil(t, url.LinkStatus)
+	assert.Equal(t, 200, *url.LinkStatus)
+	require.NotNil(t, url.LinkCheckedAt)
+	assert.True(t, url.LinkCheckedAt.Equal(checkedAt))
+
+	// An update drops the cached entry, so the next lookup sees the new result
+	require.NoError(t, repo.UpdateLinkStatus(ctx, "ls1", 404, checkedAt.Add(time.Hour)))
+	url, err = urlRepo.GetByShortCode(ctx, "ls1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, base.gets)
+	assert.Equal(t, 404, *url.LinkStatus)
+
+	assert.ErrorIs(t, repo.UpdateLinkStatus(ctx, "missing", 200, checkedAt), models.ErrURLNotFound)
+}
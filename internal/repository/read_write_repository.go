@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+// ReadWriteURLRepository sends point lookups to read replicas and everything
+// else to the primary. Replicas are used round-robin; a lookup that fails on
+// a replica is retried on the primary.
+type ReadWriteURLRepository struct {
+	primary  URLRepository
+	replicas []URLRepository
+	next     atomic.Uint64
+}
+
+// NewReadWriteURLRepository creates a repository that writes to primary and
+// reads short code and ID lookups from replicas. With no replicas every
+// call goes to primary.
+func NewReadWriteURLRepository(primary *database.Pool, replicas ...*database.Pool) *ReadWriteURLRepository {
+	replicaRepos := make([]URLRepository, len(replicas))
+	for i, pool := range replicas {
+		replicaRepos[i] = NewPostgresURLRepository(pool)
+	}
+	return newReadWriteURLRepository(NewPostgresURLRepository(primary), replicaRepos...)
+}
+
+// newReadWriteURLRepository builds a ReadWriteURLRepository from existing repositories.
+func newReadWriteURLRepository(primary URLRepository, replicas ...URLRepository) *ReadWriteURLRepository {
+	return &ReadWriteURLRepository{
+		primary:  primary,
+		replicas: replicas,
+	}
+}
+
+// replica picks the next replica in turn, or nil when there are none.
+func (r *ReadWriteURLRepository) replica() URLRepository {
+	if len(r.replicas) == 0 {
+		return nil
+	}
+	n := r.next.Add(1) - 1
+	return r.replicas[n%uint64(len(r.replicas))]
+}
+
+// Create stores a new URL on the primary.
+func (r *ReadWriteURLRepository) Create(ctx context.Context, create *models.URLCreate) (*models.URL, error) {
+	return r.primary.Create(ctx, create)
+}
+
+// CreateBatch stores URLs on the primary.
+func (r *ReadWriteURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	return r.primary.CreateBatch(ctx, creates)
+}
+
+// GetByShortCode reads from a replica. Not-found results are confirmed on
+// the primary, since a URL created moments ago may not have replicated yet.
+func (r *ReadWriteURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	if replica := r.replica(); replica != nil {
+		url, err := replica.GetByShortCode(ctx, shortCode)
+		if err == nil || ctx.Err() != nil {
+			return url, err
+		}
+	}
+	return r.primary.GetByShortCode(ctx, shortCode)
+}
+
+// GetByShortCodes reads from a replica and looks up codes it is missing on
+// the primary.
+func (r *ReadWriteURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	replica := r.replica()
+	if replica == nil {
+		return r.primary.GetByShortCodes(ctx, shortCodes)
+	}
+
+	urls, err := replica.GetByShortCodes(ctx, shortCodes)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return r.primary.GetByShortCodes(ctx, shortCodes)
+	}
+	if len(urls) == len(shortCodes) {
+		return urls, nil
+	}
+
+	found := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		found[url.ShortCode] = true
+	}
+	var missing []string
+	for _, shortCode := range shortCodes {
+		if !found[shortCode] {
+			missing = append(missing, shortCode)
+		}
+	}
+	primaryURLs, err := r.primary.GetByShortCodes(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	return append(urls, primaryURLs...), nil
+}
+
+// GetByID reads from a replica, confirming not-found results on the primary.
+func (r *ReadWriteURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
+	if replica := r.replica(); replica != nil {
+		url, err := replica.GetByID(ctx, id)
+		if err == nil || ctx.Err() != nil {
+			return url, err
+		}
+	}
+	return r.primary.GetByID(ctx, id)
+}
+
+// GetByOriginalURL reads from a replica, confirming not-found results on the
+// primary so a URL created moments ago is still found.
+func (r *ReadWriteURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	if replica := r.replica(); replica != nil {
+		url, err := replica.GetByOriginalURL(ctx, originalURL)
+		if err == nil || ctx.Err() != nil {
+			return url, err
+		}
+	}
+	return r.primary.GetByOriginalURL(ctx, originalURL)
+}
+
+// Delete soft-deletes a URL on the primary.
+func (r *ReadWriteURLRepository) Delete(ctx context.Context, shortCode string) error {
+	return r.primary.Delete(ctx, shortCode)
+}
+
+// DeleteBatch soft-deletes URLs on the primary.
+func (r *ReadWriteURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	return r.primary.DeleteBatch(ctx, shortCodes)
+}
+
+// Restore undoes a soft delete on the primary.
+func (r *ReadWriteURLRepository) Restore(ctx context.Context, shortCode string) error {
+	return r.primary.Restore(ctx, shortCode)
+}
+
+// DeletePermanent removes a URL from the primary.
+func (r *ReadWriteURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	return r.primary.DeletePermanent(ctx, shortCode)
+}
+
+// UpdateExpiry changes a URL's expiry time on the primary.
+func (r *ReadWriteURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	return r.primary.UpdateExpiry(ctx, shortCode, expiresAt)
+}
+
+// SetActive enables or disables a URL on the primary.
+func (r *ReadWriteURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	return r.primary.SetActive(ctx, shortCode, active)
+}
+
+// UpdateOriginalURL changes a URL's destination on the primary.
+func (r *ReadWriteURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	return r.primary.UpdateOriginalURL(ctx, shortCode, newURL)
+}
+
+// IncrementClickCount increments the click counter on the primary.
+func (r *ReadWriteURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
+	return r.primary.IncrementClickCount(ctx, shortCode)
+}
+
+// ClaimClick claims a click on the primary.
+func (r *ReadWriteURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
+	return r.primary.ClaimClick(ctx, shortCode)
+}
+
+// BatchIncrementClickCounts increments click counts on the primary.
+func (r *ReadWriteURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
+	return r.primary.BatchIncrementClickCounts(ctx, counts)
+}
+
+// DeleteExpired removes expired URLs from the primary.
+func (r *ReadWriteURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
+	return r.primary.DeleteExpired(ctx)
+}
+
+// List lists URLs from the primary so pages reflect recent writes.
+func (r *ReadWriteURLRepository) List(ctx context.Context, limit, offset int, filter ListFilter) ([]*models.URL, int64, error) {
+	return r.primary.List(ctx, limit, offset, filter)
+}
+
+// TopByClicks lists the most clicked URLs from the primary.
+func (r *ReadWriteURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	return r.primary.TopByClicks(ctx, limit)
+}
+
+// ListAfter lists URLs after a cursor from the primary.
+func (r *ReadWriteURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter ListFilter) ([]*models.URL, int64, error) {
+	return r.primary.ListAfter(ctx, afterID, limit, filter)
+}
+
+// Exists checks a replica for the short code, falling back to the primary
+// if the replica fails. A code that has not replicated yet is still caught
+// by the primary's unique constraint on insert.
+func (r *ReadWriteURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
+	if replica := r.replica(); replica != nil {
+		exists, err := replica.Exists(ctx, shortCode)
+		if err == nil || ctx.Err() != nil {
+			return exists, err
+		}
+	}
+	return r.primary.Exists(ctx, shortCode)
+}
+
+// HealthCheck checks the primary. Replicas are left out because reads fall
+// back to the primary when a replica is down.
+func (r *ReadWriteURLRepository) HealthCheck(ctx context.Context) error {
+	return r.primary.HealthCheck(ctx)
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+// stubReadRepository answers lookups with a fixed result and counts them.
+// Calls it does not override panic through the nil embedded interface.
+type stubReadRepository struct {
+	URLRepository
+	name    string
+	err     error
+	lookups int
+	creates int
+
+	// known limits the codes GetByShortCodes finds; nil finds every code
+	known map[string]bool
+	// batches records the codes of each GetByShortCodes call
+	batches [][]string
+}
+
+func (r *stubReadRepository) GetByShortCode(_ context.Context, shortCode string) (*models.URL, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.URL{ShortCode: shortCode, OriginalURL: "https://" + r.name + ".example.com"}, nil
+}
+
+func (r *stubReadRepository) GetByShortCodes(_ context.Context, shortCodes []string) ([]*models.URL, error) {
+	r.batches = append(r.batches, shortCodes)
+	if r.err != nil {
+		return nil, r.err
+	}
+	var urls []*models.URL
+	for _, shortCode := range shortCodes {
+		if r.known == nil || r.known[shortCode] {
+			urls = append(urls, &models.URL{ShortCode: shortCode, OriginalURL: "https://" + r.name + ".example.com"})
+		}
+	}
+	return urls, nil
+}
+
+func (r *stubReadRepository) GetByID(_ context.Context, id int64) (*models.URL, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.URL{ID: id, OriginalURL: "https://" + r.name + ".example.com"}, nil
+}
+
+func (r *stubReadRepository) Exists(_ context.Context, _ string) (bool, error) {
+	r.lookups++
+	if r.err != nil {
+		return false, r.err
+	}
+	return true, nil
+}
+
+func (r *stubReadRepository) Create(_ context.Context, create *models.URLCreate) (*models.URL, error) {
+	r.creates++
+	return &models.URL{ShortCode: create.ShortCode, OriginalURL: create.OriginalURL}, nil
+}
+
+func TestReadWriteURLRepository_RoundRobin(t *testing.T) {
+	primary := &stubReadRepository{name: "primary"}
+	replicaA := &stubReadRepository{name: "replica-a"}
+	replicaB := &stubReadRepository{name: "replica-b"}
+	repo := newReadWriteURLRepository(primary, replicaA, replicaB)
+	ctx := context.Background()
+
+	var hosts []string
+	for i := 0; i < 4; i++ {
+		url, err := repo.GetByShortCode(ctx, "abc")
+		require.NoError(t, err)
+		hosts = append(hosts, url.OriginalURL)
+	}
+
+	assert.Equal(t, []string{
+		"https://replica-a.example.com",
+		"https://replica-b.example.com",
+		"https://replica-a.example.com",
+		"https://replica-b.example.com",
+	}, hosts)
+	assert.Equal(t, 0, primary.lookups)
+
+	_, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	exists, err := repo.Exists(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 0, primary.lookups)
+	assert.Equal(t, 6, replicaA.lookups+replicaB.lookups)
+}
+
+func TestReadWriteURLRepository_FallsBackToPrimary(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replica error", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary"}
+		replica := &stubReadRepository{name: "replica", err: errors.New("connection refused")}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		url, err := repo.GetByShortCode(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "https://primary.example.com", url.OriginalURL)
+
+		_, err = repo.GetByID(ctx, 1)
+		require.NoError(t, err)
+
+		exists, err := repo.Exists(ctx, "abc")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		assert.Equal(t, 3, replica.lookups)
+		assert.Equal(t, 3, primary.lookups)
+	})
+
+	t.Run("not yet replicated", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary"}
+		replica := &stubReadRepository{name: "replica", err: models.ErrURLNotFound}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		url, err := repo.GetByShortCode(ctx, "fresh")
+		require.NoError(t, err)
+		assert.Equal(t, "https://primary.example.com", url.OriginalURL)
+	})
+
+	t.Run("missing everywhere", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary", err: models.ErrURLNotFound}
+		replica := &stubReadRepository{name: "replica", err: models.ErrURLNotFound}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		_, err := repo.GetByShortCode(ctx, "nope")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+
+	t.Run("cancelled context is not retried", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary"}
+		replica := &stubReadRepository{name: "replica", err: context.Canceled}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := repo.GetByShortCode(cancelled, "abc")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, primary.lookups)
+	})
+}
+
+func TestReadWriteURLRepository_GetByShortCodes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("looks up codes missing on the replica on the primary", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary", known: map[string]bool{"fresh": true}}
+		replica := &stubReadRepository{name: "replica", known: map[string]bool{"old": true}}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		urls, err := repo.GetByShortCodes(ctx, []string{"old", "fresh", "gone"})
+		require.NoError(t, err)
+
+		origins := make(map[string]string)
+		for _, url := range urls {
+			origins[url.ShortCode] = url.OriginalURL
+		}
+		assert.Equal(t, map[string]string{
+			"old":   "https://replica.example.com",
+			"fresh": "https://primary.example.com",
+		}, origins)
+		assert.Equal(t, [][]string{{"fresh", "gone"}}, primary.batches)
+	})
+
+	t.Run("skips the primary when the replica has every code", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary"}
+		replica := &stubReadRepository{name: "replica"}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		urls, err := repo.GetByShortCodes(ctx, []string{"a", "b"})
+		require.NoError(t, err)
+		assert.Len(t, urls, 2)
+		assert.Empty(t, primary.batches)
+	})
+
+	t.Run("replica error", func(t *testing.T) {
+		primary := &stubReadRepository{name: "primary"}
+		replica := &stubReadRepository{name: "replica", err: errors.New("connection refused")}
+		repo := newReadWriteURLRepository(primary, replica)
+
+		urls, err := repo.GetByShortCodes(ctx, []string{"a"})
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "https://primary.example.com", urls[0].OriginalURL)
+	})
+}
+
+func TestReadWriteURLRepository_WritesGoToPrimary(t *testing.T) {
+	primary := &stubReadRepository{name: "primary"}
+	replica := &stubReadRepository{name: "replica"}
+	repo := newReadWriteURLRepository(primary, replica)
+
+	_, err := repo.Create(context.Background(), &models.URLCreate{ShortCode: "abc", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, primary.creates)
+	assert.Equal(t, 0, replica.creates)
+}
+
+func TestReadWriteURLRepository_NoReplicas(t *testing.T) {
+	primary := &stubReadRepository{name: "primary"}
+	repo := newReadWriteURLRepository(primary)
+
+	url, err := repo.GetByShortCode(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://primary.example.com", url.OriginalURL)
+	assert.Equal(t, 1, primary.lookups)
+}
+
+func TestReadWriteURLRepository_Postgres(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	primaryPool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// A second pool on the same database stands in for a replica
+	replicaPool, err := database.NewPool(context.Background(), testDBConfig())
+	require.NoError(t, err)
+	defer replicaPool.Close()
+
+	repo := NewReadWriteURLRepository(primaryPool, replicaPool)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &models.URLCreate{
+		ShortCode:   "rwtest1",
+		OriginalURL: "https://example.com/rw",
+	})
+	require.NoError(t, err)
+
+	url, err := repo.GetByShortCode(ctx, "rwtest1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/rw", url.OriginalURL)
+
+	url, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rwtest1", url.ShortCode)
+
+	exists, err := repo.Exists(ctx, "rwtest1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Closing the replica forces every lookup back to the primary
+	replicaPool.Close()
+
+	url, err = repo.GetByShortCode(ctx, "rwtest1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/rw", url.OriginalURL)
+
+	require.NoError(t, repo.Delete(ctx, "rwtest1"))
+	_, err = repo.GetByShortCode(ctx, "rwtest1")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	assert.NoError(t, repo.HealthCheck(ctx))
+}
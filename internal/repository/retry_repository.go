@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+// RetryingURLRepository retries operations that are safe to repeat when they
+// fail with a transient database error. Reads and idempotent writes are
+// retried; writes whose repetition would change the outcome, such as
+// creating a URL or counting a click, are passed through once.
+type RetryingURLRepository struct {
+	repo   URLRepository
+	policy database.RetryPolicy
+}
+
+// NewRetryingURLRepository wraps repo, retrying safe operations under policy.
+func NewRetryingURLRepository(repo URLRepository, policy database.RetryPolicy) *RetryingURLRepository {
+	return &RetryingURLRepository{
+		repo:   repo,
+		policy: policy,
+	}
+}
+
+// Create stores a new URL without retrying; a retry after a lost reply
+// would report the code as taken.
+func (r *RetryingURLRepository) Create(ctx context.Context, create *models.URLCreate) (*models.URL, error) {
+	return r.repo.Create(ctx, create)
+}
+
+// CreateBatch stores several URLs without retrying.
+func (r *RetryingURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	return r.repo.CreateBatch(ctx, creates)
+}
+
+// GetByShortCode retrieves a URL by its short code, retrying transient errors.
+func (r *RetryingURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	var url *models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		url, err = r.repo.GetByShortCode(ctx, shortCode)
+		return err
+	})
+	return url, err
+}
+
+// GetByShortCodes retrieves URLs by their short codes, retrying transient errors.
+func (r *RetryingURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	var urls []*models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		urls, err = r.repo.GetByShortCodes(ctx, shortCodes)
+		return err
+	})
+	return urls, err
+}
+
+// GetByID retrieves a URL by its ID, retrying transient errors.
+func (r *RetryingURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
+	var url *models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		url, err = r.repo.GetByID(ctx, id)
+		return err
+	})
+	return url, err
+}
+
+// GetByOriginalURL retrieves a URL by destination, retrying transient errors.
+func (r *RetryingURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	var url *models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		url, err = r.repo.GetByOriginalURL(ctx, originalURL)
+		return err
+	})
+	return url, err
+}
+
+// Delete soft-deletes a URL without retrying; a retry would report an
+// already deleted URL as not found.
+func (r *RetryingURLRepository) Delete(ctx context.Context, shortCode string) error {
+	return r.repo.Delete(ctx, shortCode)
+}
+
+// DeleteBatch soft-deletes URLs without retrying.
+func (r *RetryingURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	return r.repo.DeleteBatch(ctx, shortCodes)
+}
+
+// Restore undoes a soft delete without retrying.
+func (r *RetryingURLRepository) Restore(ctx context.Context, shortCode string) error {
+	return r.repo.Restore(ctx, shortCode)
+}
+
+// DeletePermanent removes a URL without retrying.
+func (r *RetryingURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	return r.repo.DeletePermanent(ctx, shortCode)
+}
+
+// UpdateOriginalURL changes a URL's destination, retrying transient errors.
+// Setting the same destination twice has the same effect as once.
+func (r *RetryingURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	return database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		return r.repo.UpdateOriginalURL(ctx, shortCode, newURL)
+	})
+}
+
+// UpdateExpiry changes a URL's expiry time, retrying transient errors.
+// Setting the same expiry twice has the same effect as once.
+func (r *RetryingURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	var url *models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		url, err = r.repo.UpdateExpiry(ctx, shortCode, expiresAt)
+		return err
+	})
+	return url, err
+}
+
+// SetActive enables or disables a URL, retrying transient errors. Setting
+// the same state twice has the same effect as once.
+func (r *RetryingURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	var url *models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		url, err = r.repo.SetActive(ctx, shortCode, active)
+		return err
+	})
+	return url, err
+}
+
+// IncrementClickCount increments the click counter without retrying, so a
+// click is never counted twice.
+func (r *RetryingURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
+	return r.repo.IncrementClickCount(ctx, shortCode)
+}
+
+// ClaimClick claims a click without retrying.
+func (r *RetryingURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
+	return r.repo.ClaimClick(ctx, shortCode)
+}
+
+// BatchIncrementClickCounts increments click counts without retrying.
+func (r *RetryingURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
+	return r.repo.BatchIncrementClickCounts(ctx, counts)
+}
+
+// DeleteExpired removes expired URLs, retrying transient errors. A retry
+// only removes what the failed attempt left behind.
+func (r *RetryingURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
+	var deleted []string
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		deleted, err = r.repo.DeleteExpired(ctx)
+		return err
+	})
+	return deleted, err
+}
+
+// List returns a page of URLs, retrying transient errors.
+func (r *RetryingURLRepository) List(ctx context.Context, limit, offset int, filter ListFilter) ([]*models.URL, int64, error) {
+	var urls []*models.URL
+	var total int64
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		urls, total, err = r.repo.List(ctx, limit, offset, filter)
+		return err
+	})
+	return urls, total, err
+}
+
+// TopByClicks returns the most clicked URLs, retrying transient errors.
+func (r *RetryingURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	var urls []*models.URL
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		urls, err = r.repo.TopByClicks(ctx, limit)
+		return err
+	})
+	return urls, err
+}
+
+// ListAfter returns a page of URLs after a cursor, retrying transient errors.
+func (r *RetryingURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter ListFilter) ([]*models.URL, int64, error) {
+	var urls []*models.URL
+	var next int64
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		urls, next, err = r.repo.ListAfter(ctx, afterID, limit, filter)
+		return err
+	})
+	return urls, next, err
+}
+
+// Exists checks if a short code exists, retrying transient errors.
+func (r *RetryingURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
+	var exists bool
+	err := database.WithRetry(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+		exists, err = r.repo.Exists(ctx, shortCode)
+		return err
+	})
+	return exists, err
+}
+
+// HealthCheck checks the repository once, so failures are reported promptly.
+func (r *RetryingURLRepository) HealthCheck(ctx context.Context) error {
+	return r.repo.HealthCheck(ctx)
+}
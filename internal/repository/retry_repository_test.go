@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+// flakyURLRepository fails each call with err until failures calls have
+// been made, then succeeds.
+type flakyURLRepository struct {
+	URLRepository
+	failures int
+	err      error
+	calls    int
+}
+
+func (r *flakyURLRepository) fail() error {
+	r.calls++
+	if r.calls <= r.failures {
+		return r.err
+	}
+	return nil
+}
+
+func (r *flakyURLRepository) GetByShortCode(_ context.Context, shortCode string) (*models.URL, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	return &models.URL{ShortCode: shortCode, OriginalURL: "https://example.com"}, nil
+}
+
+func (r *flakyURLRepository) Exists(_ context.Context, _ string) (bool, error) {
+	if err := r.fail(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *flakyURLRepository) UpdateOriginalURL(_ context.Context, _, _ string) error {
+	return r.fail()
+}
+
+func (r *flakyURLRepository) Create(_ context.Context, create *models.URLCreate) (*models.URL, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	return &models.URL{ShortCode: create.ShortCode}, nil
+}
+
+func (r *flakyURLRepository) IncrementClickCount(_ context.Context, _ string) error {
+	return r.fail()
+}
+
+func testRetryPolicy() database.RetryPolicy {
+	return database.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+func TestRetryingURLRepository_RetriesReads(t *testing.T) {
+	ctx := context.Background()
+
+	base := &flakyURLRepository{failures: 2, err: io.ErrUnexpectedEOF}
+	repo := NewRetryingURLRepository(base, testRetryPolicy())
+
+	url, err := repo.GetByShortCode(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", url.ShortCode)
+	assert.Equal(t, 3, base.calls)
+
+	base = &flakyURLRepository{failures: 1, err: io.ErrUnexpectedEOF}
+	repo = NewRetryingURLRepository(base, testRetryPolicy())
+
+	exists, err := repo.Exists(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 2, base.calls)
+}
+
+func TestRetryingURLRepository_RetriesIdempotentWrites(t *testing.T) {
+	base := &flakyURLRepository{failures: 1, err: io.ErrUnexpectedEOF}
+	repo := NewRetryingURLRepository(base, testRetryPolicy())
+
+	require.NoError(t, repo.UpdateOriginalURL(context.Background(), "abc", "https://example.com/new"))
+	assert.Equal(t, 2, base.calls)
+}
+
+func TestRetryingURLRepository_GivesUp(t *testing.T) {
+	base := &flakyURLRepository{failures: 10, err: io.ErrUnexpectedEOF}
+	repo := NewRetryingURLRepository(base, testRetryPolicy())
+
+	_, err := repo.GetByShortCode(context.Background(), "abc")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 3, base.calls)
+}
+
+func TestRetryingURLRepository_DoesNotRetryNotFound(t *testing.T) {
+	base := &flakyURLRepository{failures: 10, err: models.ErrURLNotFound}
+	repo := NewRetryingURLRepository(base, testRetryPolicy())
+
+	_, err := repo.GetByShortCode(context.Background(), "abc")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+	assert.Equal(t, 1, base.calls)
+}
+
+func TestRetryingURLRepository_DoesNotRetryUnsafeWrites(t *testing.T) {
+	ctx := context.Background()
+
+	base := &flakyURLRepository{failures: 1, err: io.ErrUnexpectedEOF}
+	repo := NewRetryingURLRepository(base, testRetryPolicy())
+
+	_, err := repo.Create(ctx, &models.URLCreate{ShortCode: "abc", OriginalURL: "https://example.com"})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 1, base.calls)
+
+	base = &flakyURLRepository{failures: 1, err: io.ErrUnexpectedEOF}
+	repo = NewRetryingURLRepository(base, testRetryPolicy())
+
+	assert.ErrorIs(t, repo.IncrementClickCount(ctx, "abc"), io.ErrUnexpectedEOF)
+	assert.Equal(t, 1, base.calls)
+}
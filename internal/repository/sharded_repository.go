@@ -1,21 +1,220 @@
 package repository
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 
 	"github.com/emadnahed/FastGoLink/internal/database"
 	"github.com/emadnahed/FastGoLink/internal/models"
 )
 
+// ShardedConfig holds tunable settings for ShardedURLRepository.
+type ShardedConfig struct {
+	// FallbackScan makes short code lookups that miss on the routed shard
+	// search the other shards before reporting the code as not found. When
+	// the code turns up elsewhere, its shard is noted and later operations
+	// on it go straight there. This keeps codes reachable while rows are
+	// moved after the shard count changes, at the cost of querying every
+	// shard for codes that really don't exist.
+	FallbackScan bool
+
+	// FallbackConcurrency caps how many shards a fallback scan queries at
+	// once. Zero or less queries them all at once.
+	FallbackConcurrency int
+
+	// RelocatedMax caps how many shards found by fallback scans are noted;
+	// the least recently used are forgotten first. RelocatedTTL is how long
+	// a noted shard is trusted before the code is routed, and scanned for,
+	// again. Once rows have been moved, forgotten codes are simply found on
+	// their routed shard.
+	RelocatedMax int
+	RelocatedTTL time.Duration
+}
+
+// DefaultShardedConfig returns the default sharded repository configuration,
+// which routes by short code only.
+//
+// The sharded repository is not wired to the server's configuration: the
+// server runs on a single shard. Programs that shard, such as migration
+// tools, set these fields directly.
+func DefaultShardedConfig() ShardedConfig {
+	return ShardedConfig{
+		FallbackScan:        false,
+		FallbackConcurrency: 4,
+		RelocatedMax:        10000,
+		RelocatedTTL:        10 * time.Minute,
+	}
+}
+
 // ShardedURLRepository implements URLRepository with database sharding.
 type ShardedURLRepository struct {
-	router *database.ShardRouter
+	shards []URLRepository
+	route  func(shortCode string) int // Index of the shard a short code hashes to
+	cfg    ShardedConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	relocated map[string]*list.Element // Shards of codes found off their routed shard
+	lru       *list.List               // Of *relocation, most recently used first
+}
+
+// relocation notes the shard a fallback scan found a short code on.
+type relocation struct {
+	shortCode string
+	shard     int
+	expiresAt time.Time
 }
 
 // NewShardedURLRepository creates a new sharded URL repository.
 func NewShardedURLRepository(router *database.ShardRouter) *ShardedURLRepository {
-	return &ShardedURLRepository{router: router}
+	return NewShardedURLRepositoryWithConfig(router, DefaultShardedConfig())
+}
+
+// NewShardedURLRepositoryWithConfig creates a sharded URL repository with
+// custom settings.
+func NewShardedURLRepositoryWithConfig(router *database.ShardRouter, cfg ShardedConfig) *ShardedURLRepository {
+	pools := router.GetAllShards()
+	shards := make([]URLRepository, len(pools))
+	for i, pool := range pools {
+		shards[i] = NewPostgresURLRepository(pool)
+	}
+	return newShardedURLRepository(shards, router.GetShardIndex, cfg)
+}
+
+// newShardedURLRepository creates a sharded repository over shards, routing
+// short codes with route.
+func newShardedURLRepository(shards []URLRepository, route func(string) int, cfg ShardedConfig) *ShardedURLRepository {
+	defaults := DefaultShardedConfig()
+	if cfg.RelocatedMax <= 0 {
+		cfg.RelocatedMax = defaults.RelocatedMax
+	}
+	if cfg.RelocatedTTL <= 0 {
+		cfg.RelocatedTTL = defaults.RelocatedTTL
+	}
+	return &ShardedURLRepository{
+		shards:    shards,
+		route:     route,
+		cfg:       cfg,
+		now:       time.Now,
+		relocated: make(map[string]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// shardIndex returns the index of the shard holding shortCode: the one a
+// fallback scan found it on, or else the one it routes to.
+func (r *ShardedURLRepository) shardIndex(shortCode string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if elem, ok := r.relocated[shortCode]; ok {
+		reloc := elem.Value.(*relocation)
+		if r.now().Before(reloc.expiresAt) {
+			r.lru.MoveToFront(elem)
+			return reloc.shard
+		}
+		r.removeRelocation(elem)
+	}
+	return r.route(shortCode)
+}
+
+// noteRelocation remembers that shortCode is stored on shard, evicting the
+// least recently used note when full.
+func (r *ShardedURLRepository) noteRelocation(shortCode string, shard int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expiresAt := r.now().Add(r.cfg.RelocatedTTL)
+	if elem, ok := r.relocated[shortCode]; ok {
+		reloc := elem.Value.(*relocation)
+		reloc.shard, reloc.expiresAt = shard, expiresAt
+		r.lru.MoveToFront(elem)
+		return
+	}
+	r.relocated[shortCode] = r.lru.PushFront(&relocation{shortCode: shortCode, shard: shard, expiresAt: expiresAt})
+	for r.lru.Len() > r.cfg.RelocatedMax {
+		r.removeRelocation(r.lru.Back())
+	}
+}
+
+// removeRelocation drops a note. Callers hold r.mu.
+func (r *ShardedURLRepository) removeRelocation(elem *list.Element) {
+	r.lru.Remove(elem)
+	delete(r.relocated, elem.Value.(*relocation).shortCode)
+}
+
+// shardFor returns the repository of the shard holding shortCode.
+func (r *ShardedURLRepository) shardFor(shortCode string) URLRepository {
+	return r.shards[r.shardIndex(shortCode)]
+}
+
+// forget drops the noted shards of short codes that no longer exist.
+func (r *ShardedURLRepository) forget(shortCodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, shortCode := range shortCodes {
+		if elem, ok := r.relocated[shortCode]; ok {
+			r.removeRelocation(elem)
+		}
+	}
+}
+
+// locate searches every shard except skip for shortCode, in parallel, and
+// notes the shard it is found on. It returns models.ErrURLNotFound when no
+// shard has it, and a shard's error only when no other shard has it.
+func (r *ShardedURLRepository) locate(ctx context.Context, shortCode string, skip int) (*models.URL, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		g     errgroup.Group
+		mu    sync.Mutex
+		found *models.URL
+		at    int
+	)
+	if r.cfg.FallbackConcurrency > 0 {
+		g.SetLimit(r.cfg.FallbackConcurrency)
+	}
+	for i, shard := range r.shards {
+		if i == skip {
+			continue
+		}
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil // Another shard already has it
+			}
+			url, err := shard.GetByShortCode(ctx, shortCode)
+			if errors.Is(err, models.ErrURLNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("shard %d: %w", i, err)
+			}
+			mu.Lock()
+			if found == nil {
+				found, at = url, i
+			}
+			mu.Unlock()
+			cancel()
+			return nil
+		})
+	}
+	err := g.Wait()
+
+	if found == nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, models.ErrURLNotFound
+	}
+
+	r.noteRelocation(shortCode, at)
+	return found, nil
 }
 
 // Create stores a new URL in the appropriate shard.
@@ -25,28 +224,86 @@ func (r *ShardedURLRepository) Create(ctx context.Context, create *models.URLCre
 	}
 
 	// Route to shard based on short code
-	pool := r.router.GetShard(create.ShortCode)
-	repo := NewPostgresURLRepository(pool)
+	return r.shardFor(create.ShortCode).Create(ctx, create)
+}
 
-	return repo.Create(ctx, create)
+// CreateBatch stores URLs with one batch per shard. Each shard's batch is
+// atomic, but a failure on one shard does not undo the others: the URLs
+// stored elsewhere are still returned, alongside a *BatchError naming the
+// codes that were not stored. Their entries in the result are nil.
+func (r *ShardedURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	for _, create := range creates {
+		if err := create.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	byShard := make(map[int][]int)
+	for i, create := range creates {
+		idx := r.shardIndex(create.ShortCode)
+		byShard[idx] = append(byShard[idx], i)
+	}
+
+	urls := make([]*models.URL, len(creates))
+	batchErr := &BatchError{Failed: make(map[string]error)}
+	for idx, positions := range byShard {
+		shardCreates := make([]*models.URLCreate, len(positions))
+		shardCodes := make([]string, len(positions))
+		for j, pos := range positions {
+			shardCreates[j] = creates[pos]
+			shardCodes[j] = creates[pos].ShortCode
+		}
+
+		created, err := r.shards[idx].CreateBatch(ctx, shardCreates)
+		if err != nil {
+			batchErr.add(shardCodes, fmt.Errorf("shard %d: %w", idx, err))
+			continue
+		}
+		for j, pos := range positions {
+			urls[pos] = created[j]
+		}
+	}
+	if len(batchErr.Failed) > 0 {
+		return urls, batchErr
+	}
+	return urls, nil
 }
 
-// GetByShortCode retrieves a URL from the appropriate shard.
+// GetByShortCode retrieves a URL from the appropriate shard. With
+// FallbackScan set, a code missing there is searched for on the other shards.
 func (r *ShardedURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
-	pool := r.router.GetShard(shortCode)
-	repo := NewPostgresURLRepository(pool)
+	idx := r.shardIndex(shortCode)
+	url, err := r.shards[idx].GetByShortCode(ctx, shortCode)
+	if !r.cfg.FallbackScan || len(r.shards) == 1 || !errors.Is(err, models.ErrURLNotFound) {
+		return url, err
+	}
+	return r.locate(ctx, shortCode, idx)
+}
+
+// GetByShortCodes retrieves URLs with one query per shard.
+func (r *ShardedURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	byShard := make(map[int][]string)
+	for _, shortCode := range shortCodes {
+		idx := r.shardIndex(shortCode)
+		byShard[idx] = append(byShard[idx], shortCode)
+	}
 
-	return repo.GetByShortCode(ctx, shortCode)
+	var urls []*models.URL
+	for idx, codes := range byShard {
+		shardURLs, err := r.shards[idx].GetByShortCodes(ctx, codes)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", idx, err)
+		}
+		urls = append(urls, shardURLs...)
+	}
+	return urls, nil
 }
 
 // GetByID retrieves a URL by ID. Since ID-based lookups can't be sharded
 // without knowing the short code, this searches all shards.
 func (r *ShardedURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
-	shards := r.router.GetAllShards()
-
-	for _, pool := range shards {
-		repo := NewPostgresURLRepository(pool)
-		url, err := repo.GetByID(ctx, id)
+	for _, shard := range r.shards {
+		url, err := shard.GetByID(ctx, id)
 		if err == nil {
 			return url, nil
 		}
@@ -58,53 +315,253 @@ func (r *ShardedURLRepository) GetByID(ctx context.Context, id int64) (*models.U
 	return nil, models.ErrURLNotFound
 }
 
-// Delete removes a URL from the appropriate shard.
+// GetByOriginalURL searches all shards, since URLs are sharded by short
+// code, and returns the oldest match.
+func (r *ShardedURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	var oldest *models.URL
+	for i, shard := range r.shards {
+		url, err := shard.GetByOriginalURL(ctx, originalURL)
+		if err == models.ErrURLNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get URL from shard %d: %w", i, err)
+		}
+		if oldest == nil || url.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = url
+		}
+	}
+	if oldest == nil {
+		return nil, models.ErrURLNotFound
+	}
+	return oldest, nil
+}
+
+// Delete soft-deletes a URL in the appropriate shard.
 func (r *ShardedURLRepository) Delete(ctx context.Context, shortCode string) error {
-	pool := r.router.GetShard(shortCode)
-	repo := NewPostgresURLRepository(pool)
+	return r.shardFor(shortCode).Delete(ctx, shortCode)
+}
+
+// DeleteBatch soft-deletes URLs with one statement per shard. A failing
+// shard does not stop the others: the codes deleted elsewhere are still
+// returned, alongside a *BatchError naming the codes that failed.
+func (r *ShardedURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	byShard := make(map[int][]string)
+	for _, shortCode := range shortCodes {
+		idx := r.shardIndex(shortCode)
+		byShard[idx] = append(byShard[idx], shortCode)
+	}
 
-	return repo.Delete(ctx, shortCode)
+	var deleted []string
+	batchErr := &BatchError{Failed: make(map[string]error)}
+	for idx, codes := range byShard {
+		shardDeleted, err := r.shards[idx].DeleteBatch(ctx, codes)
+		if err != nil {
+			batchErr.add(codes, fmt.Errorf("shard %d: %w", idx, err))
+			continue
+		}
+		deleted = append(deleted, shardDeleted...)
+	}
+	if len(batchErr.Failed) > 0 {
+		return deleted, batchErr
+	}
+	return deleted, nil
+}
+
+// Restore undoes a soft delete in the appropriate shard.
+func (r *ShardedURLRepository) Restore(ctx context.Context, shortCode string) error {
+	return r.shardFor(shortCode).Restore(ctx, shortCode)
+}
+
+// DeletePermanent removes a URL from the appropriate shard for good.
+func (r *ShardedURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	if err := r.shardFor(shortCode).DeletePermanent(ctx, shortCode); err != nil {
+		return err
+	}
+	r.forget(shortCode)
+	return nil
+}
+
+// UpdateExpiry updates the expiry time in the appropriate shard.
+func (r *ShardedURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	return r.shardFor(shortCode).UpdateExpiry(ctx, shortCode, expiresAt)
+}
+
+// SetActive enables or disables a URL in the appropriate shard.
+func (r *ShardedURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	return r.shardFor(shortCode).SetActive(ctx, shortCode, active)
+}
+
+// UpdateOriginalURL updates the destination in the appropriate shard.
+func (r *ShardedURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	return r.shardFor(shortCode).UpdateOriginalURL(ctx, shortCode, newURL)
 }
 
 // IncrementClickCount increments the click counter in the appropriate shard.
 func (r *ShardedURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
-	pool := r.router.GetShard(shortCode)
-	repo := NewPostgresURLRepository(pool)
+	return r.shardFor(shortCode).IncrementClickCount(ctx, shortCode)
+}
 
-	return repo.IncrementClickCount(ctx, shortCode)
+// ClaimClick claims a click in the appropriate shard.
+func (r *ShardedURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
+	return r.shardFor(shortCode).ClaimClick(ctx, shortCode)
 }
 
-// DeleteExpired removes expired URLs from all shards.
-func (r *ShardedURLRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	shards := r.router.GetAllShards()
-	var totalDeleted int64
+// DeleteExpired removes expired URLs from all shards. When a shard fails,
+// the codes already deleted from earlier shards are returned with the error.
+func (r *ShardedURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
+	var allDeleted []string
+	defer func() { r.forget(allDeleted...) }()
 
-	for i, pool := range shards {
-		repo := NewPostgresURLRepository(pool)
-		deleted, err := repo.DeleteExpired(ctx)
+	for i, shard := range r.shards {
+		deleted, err := shard.DeleteExpired(ctx)
 		if err != nil {
-			return totalDeleted, fmt.Errorf("failed to delete expired from shard %d: %w", i, err)
+			return allDeleted, fmt.Errorf("failed to delete expired from shard %d: %w", i, err)
 		}
-		totalDeleted += deleted
+		allDeleted = append(allDeleted, deleted...)
 	}
 
-	return totalDeleted, nil
+	return allDeleted, nil
+}
+
+// List merges pages from all shards. Each shard returns its first offset+limit
+// matches, which is enough to assemble the requested global page.
+func (r *ShardedURLRepository) List(ctx context.Context, limit, offset int, filter ListFilter) ([]*models.URL, int64, error) {
+	var (
+		merged []*models.URL
+		total  int64
+	)
+
+	for i, shard := range r.shards {
+		urls, count, err := shard.List(ctx, offset+limit, 0, filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list URLs from shard %d: %w", i, err)
+		}
+		merged = append(merged, urls...)
+		total += count
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+
+	if offset >= len(merged) {
+		return []*models.URL{}, total, nil
+	}
+	end := offset + limit
+	if end > len(merged) {
+		end = len(merged)
+	}
+	return merged[offset:end], total, nil
+}
+
+// TopByClicks merges the top URLs of all shards.
+func (r *ShardedURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	var merged []*models.URL
+	for i, shard := range r.shards {
+		urls, err := shard.TopByClicks(ctx, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top URLs from shard %d: %w", i, err)
+		}
+		merged = append(merged, urls...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].ClickCount != merged[j].ClickCount {
+			return merged[i].ClickCount > merged[j].ClickCount
+		}
+		return merged[i].CreatedAt.Before(merged[j].CreatedAt)
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// shardCursorBits is the number of low cursor bits that hold a row ID in
+// ShardedURLRepository.ListAfter. The bits above hold the shard index.
+const shardCursorBits = 48
+
+// ListAfter pages through the shards one after another, since row IDs are
+// only unique within a shard. The cursor encodes the shard index along with
+// the last ID read from it. A page may hold fewer than limit URLs when a
+// shard runs out; callers should follow the next cursor until it is zero.
+func (r *ShardedURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter ListFilter) ([]*models.URL, int64, error) {
+	shards := r.shards
+	idx := int(afterID >> shardCursorBits)
+	id := afterID & (1<<shardCursorBits - 1)
+
+	for ; idx < len(shards); idx, id = idx+1, 0 {
+		urls, next, err := shards[idx].ListAfter(ctx, id, limit, filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list URLs from shard %d: %w", idx, err)
+		}
+		if len(urls) == 0 {
+			continue
+		}
+		if next != 0 {
+			return urls, int64(idx)<<shardCursorBits | next, nil
+		}
+		if idx+1 < len(shards) {
+			return urls, int64(idx+1) << shardCursorBits, nil
+		}
+		return urls, 0, nil
+	}
+	return []*models.URL{}, 0, nil
 }
 
 // Exists checks if a short code exists in the appropriate shard.
 func (r *ShardedURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
-	pool := r.router.GetShard(shortCode)
-	repo := NewPostgresURLRepository(pool)
-
-	return repo.Exists(ctx, shortCode)
+	return r.shardFor(shortCode).Exists(ctx, shortCode)
 }
 
 // HealthCheck checks the health of all shards.
 func (r *ShardedURLRepository) HealthCheck(ctx context.Context) error {
-	return r.router.HealthCheck(ctx)
+	for i, shard := range r.shards {
+		if err := shard.HealthCheck(ctx); err != nil {
+			return fmt.Errorf("shard %d health check failed: %w", i, err)
+		}
+	}
+	return nil
 }
 
 // ShardCount returns the number of shards.
 func (r *ShardedURLRepository) ShardCount() int {
-	return r.router.ShardCount()
+	return len(r.shards)
+}
+
+// BatchError reports a batch operation that only partly went through, as
+// when one shard of a ShardedURLRepository fails and the others succeed.
+// Failed maps each short code that was not applied to the error that stopped
+// it; every other code in the batch was applied.
+type BatchError struct {
+	Failed map[string]error
+}
+
+// add records err against every code in codes.
+func (e *BatchError) add(codes []string, err error) {
+	for _, code := range codes {
+		e.Failed[code] = err
+	}
+}
+
+// Error summarizes the distinct causes of the failure.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch failed for %d short codes: %v", len(e.Failed), errors.Join(e.Unwrap()...))
+}
+
+// Unwrap returns the distinct errors behind the failed codes, so errors.Is
+// and errors.As see through to them.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	seen := make(map[error]bool)
+	for _, err := range e.Failed {
+		if !seen[err] {
+			seen[err] = true
+			errs = append(errs, err)
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
 }
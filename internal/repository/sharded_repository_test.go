@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -31,7 +33,19 @@ func setupShardedTestDB(t *testing.T) (*database.ShardRouter, func()) {
 			original_url TEXT NOT NULL,
 			created_at TIMESTAMPTZ DEFAULT NOW(),
 			expires_at TIMESTAMPTZ,
-			click_count BIGINT DEFAULT 0
+			click_count BIGINT DEFAULT 0,
+			permanent BOOLEAN NOT NULL DEFAULT FALSE,
+			password_hash TEXT,
+			max_clicks BIGINT,
+			deleted_at TIMESTAMPTZ,
+			show_preview BOOLEAN NOT NULL DEFAULT FALSE,
+			append_params JSONB,
+			platform_targets JSONB,
+			tags TEXT[],
+			link_status SMALLINT,
+			link_checked_at TIMESTAMPTZ,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		)
 	`)
 	require.NoError(t, err)
@@ -63,7 +77,7 @@ func TestShardedURLRepository_Create(t *testing.T) {
 		assert.Equal(t, "shard1", url.ShortCode)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "shard1")
+		_ = repo.DeletePermanent(ctx, "shard1")
 	})
 }
 
@@ -87,7 +101,7 @@ func TestShardedURLRepository_GetByShortCode(t *testing.T) {
 		assert.Equal(t, "shget1", url.ShortCode)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "shget1")
+		_ = repo.DeletePermanent(ctx, "shget1")
 	})
 
 	t.Run("get non-existent URL", func(t *testing.T) {
@@ -109,12 +123,19 @@ func TestShardedURLRepository_Delete(t *testing.T) {
 	}
 	_, err := repo.Create(ctx, create)
 	require.NoError(t, err)
+	defer func() { _ = repo.DeletePermanent(ctx, "shdel1") }()
 
 	err = repo.Delete(ctx, "shdel1")
 	assert.NoError(t, err)
 
 	_, err = repo.GetByShortCode(ctx, "shdel1")
 	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	err = repo.Restore(ctx, "shdel1")
+	assert.NoError(t, err)
+
+	_, err = repo.GetByShortCode(ctx, "shdel1")
+	assert.NoError(t, err)
 }
 
 func TestShardedURLRepository_IncrementClickCount(t *testing.T) {
@@ -141,7 +162,7 @@ func TestShardedURLRepository_IncrementClickCount(t *testing.T) {
 	assert.Equal(t, int64(3), url.ClickCount)
 
 	// Cleanup
-	_ = repo.Delete(ctx, "shclk1")
+	_ = repo.DeletePermanent(ctx, "shclk1")
 }
 
 func TestShardedURLRepository_Exists(t *testing.T) {
@@ -167,7 +188,7 @@ func TestShardedURLRepository_Exists(t *testing.T) {
 	assert.False(t, exists)
 
 	// Cleanup
-	_ = repo.Delete(ctx, "shex1")
+	_ = repo.DeletePermanent(ctx, "shex1")
 }
 
 func TestShardedURLRepository_DeleteExpired(t *testing.T) {
@@ -197,9 +218,9 @@ func TestShardedURLRepository_DeleteExpired(t *testing.T) {
 	_, err = repo.Create(ctx, notExpired)
 	require.NoError(t, err)
 
-	count, err := repo.DeleteExpired(ctx)
+	deleted, err := repo.DeleteExpired(ctx)
 	require.NoError(t, err)
-	assert.Equal(t, int64(1), count)
+	assert.Equal(t, []string{"shexp1"}, deleted)
 
 	// Verify expired is gone
 	_, err = repo.GetByShortCode(ctx, "shexp1")
@@ -210,7 +231,38 @@ func TestShardedURLRepository_DeleteExpired(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Cleanup
-	_ = repo.Delete(ctx, "shfut1")
+	_ = repo.DeletePermanent(ctx, "shfut1")
+}
+
+func TestShardedURLRepository_ListAfter(t *testing.T) {
+	router, cleanup := setupShardedTestDB(t)
+	defer cleanup()
+
+	repo := NewShardedURLRepository(router)
+	ctx := context.Background()
+
+	codes := []string{"shpg1", "shpg2", "shpg3"}
+	for _, code := range codes {
+		_, err := repo.Create(ctx, &models.URLCreate{ShortCode: code, OriginalURL: "https://example.com/" + code})
+		require.NoError(t, err)
+	}
+
+	var (
+		seen   []string
+		cursor int64
+	)
+	for {
+		urls, next, err := repo.ListAfter(ctx, cursor, 2, ListFilter{Search: "shpg"})
+		require.NoError(t, err)
+		for _, url := range urls {
+			seen = append(seen, url.ShortCode)
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, codes, seen)
 }
 
 func TestShardedURLRepository_HealthCheck(t *testing.T) {
@@ -252,5 +304,220 @@ func TestShardedURLRepository_GetByID(t *testing.T) {
 	assert.Equal(t, created.ID, url.ID)
 
 	// Cleanup
-	_ = repo.Delete(ctx, "shid1")
+	_ = repo.DeletePermanent(ctx, "shid1")
+}
+
+// shardStub is a shard holding a fixed set of URLs that counts lookups.
+type shardStub struct {
+	URLRepository
+	mu      sync.Mutex
+	urls    map[string]*models.URL
+	err     error
+	lookups int
+}
+
+func newShardStub(codes ...string) *shardStub {
+	s := &shardStub{urls: make(map[string]*models.URL)}
+	for _, code := range codes {
+		s.urls[code] = &models.URL{ShortCode: code, OriginalURL: "https://example.com/" + code}
+	}
+	return s
+}
+
+func (s *shardStub) GetByShortCode(_ context.Context, shortCode string) (*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	if s.err != nil {
+		return nil, s.err
+	}
+	url, ok := s.urls[shortCode]
+	if !ok {
+		return nil, models.ErrURLNotFound
+	}
+	return url, nil
+}
+
+func (s *shardStub) DeletePermanent(_ context.Context, shortCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.urls[shortCode]; !ok {
+		return models.ErrURLNotFound
+	}
+	delete(s.urls, shortCode)
+	return nil
+}
+
+func (s *shardStub) DeleteBatch(_ context.Context, shortCodes []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	var deleted []string
+	for _, code := range shortCodes {
+		if _, ok := s.urls[code]; ok {
+			delete(s.urls, code)
+			deleted = append(deleted, code)
+		}
+	}
+	return deleted, nil
+}
+
+func (s *shardStub) CreateBatch(_ context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	urls := make([]*models.URL, len(creates))
+	for i, create := range creates {
+		urls[i] = &models.URL{ShortCode: create.ShortCode, OriginalURL: create.OriginalURL}
+		s.urls[create.ShortCode] = urls[i]
+	}
+	return urls, nil
+}
+
+func (s *shardStub) lookupCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookups
+}
+
+func TestShardedURLRepository_FallbackScan(t *testing.T) {
+	ctx := context.Background()
+	routeToFirst := func(string) int { return 0 }
+	fallback := ShardedConfig{FallbackScan: true, FallbackConcurrency: 2}
+
+	t.Run("finds a code stored on a non-routed shard", func(t *testing.T) {
+		routed, other, holder := newShardStub(), newShardStub(), newShardStub("moved1")
+		repo := newShardedURLRepository([]URLRepository{routed, other, holder}, routeToFirst, fallback)
+
+		url, err := repo.GetByShortCode(ctx, "moved1")
+		require.NoError(t, err)
+		assert.Equal(t, "moved1", url.ShortCode)
+
+		// The noted shard is used directly from then on, writes included
+		_, err = repo.GetByShortCode(ctx, "moved1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, routed.lookupCount())
+		assert.LessOrEqual(t, other.lookupCount(), 1, "the scan may stop before reaching every shard")
+		assert.Equal(t, 2, holder.lookupCount())
+
+		require.NoError(t, repo.DeletePermanent(ctx, "moved1"))
+		assert.Empty(t, holder.urls)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		routed, holder := newShardStub(), newShardStub("moved1")
+		repo := newShardedURLRepository([]URLRepository{routed, holder}, routeToFirst, DefaultShardedConfig())
+
+		_, err := repo.GetByShortCode(ctx, "moved1")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		assert.Zero(t, holder.lookupCount())
+	})
+
+	t.Run("unknown code searches every shard once", func(t *testing.T) {
+		shards := []*shardStub{newShardStub(), newShardStub(), newShardStub(), newShardStub()}
+		repo := newShardedURLRepository([]URLRepository{shards[0], shards[1], shards[2], shards[3]}, routeToFirst, fallback)
+
+		_, err := repo.GetByShortCode(ctx, "missing")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		for i, shard := range shards {
+			assert.Equal(t, 1, shard.lookupCount(), "shard %d", i)
+		}
+	})
+
+	t.Run("found despite a failing shard", func(t *testing.T) {
+		failing := newShardStub()
+		failing.err = errors.New("connection refused")
+		repo := newShardedURLRepository([]URLRepository{newShardStub(), failing, newShardStub("moved1")}, routeToFirst, fallback)
+
+		url, err := repo.GetByShortCode(ctx, "moved1")
+		require.NoError(t, err)
+		assert.Equal(t, "moved1", url.ShortCode)
+	})
+
+	t.Run("noted shards are bounded and expire", func(t *testing.T) {
+		routed, holder := newShardStub(), newShardStub("moved1", "moved2")
+		cfg := fallback
+		cfg.RelocatedMax = 1
+		cfg.RelocatedTTL = time.Minute
+		repo := newShardedURLRepository([]URLRepository{routed, holder}, routeToFirst, cfg)
+		now := time.Now()
+		repo.now = func() time.Time { return now }
+
+		for _, code := range []string{"moved1", "moved2"} {
+			_, err := repo.GetByShortCode(ctx, code)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, repo.lru.Len(), "moved1 was evicted to make room")
+		assert.Equal(t, 0, repo.shardIndex("moved1"))
+		assert.Equal(t, 1, repo.shardIndex("moved2"))
+
+		now = now.Add(time.Minute)
+		assert.Equal(t, 0, repo.shardIndex("moved2"), "expired notes fall back to routing")
+		assert.Empty(t, repo.relocated)
+	})
+
+	t.Run("shard error is returned when no shard has the code", func(t *testing.T) {
+		failing := newShardStub()
+		failing.err = errors.New("connection refused")
+		repo := newShardedURLRepository([]URLRepository{newShardStub(), failing}, routeToFirst, fallback)
+
+		_, err := repo.GetByShortCode(ctx, "missing")
+		assert.ErrorContains(t, err, "shard 1: connection refused")
+	})
+}
+
+func TestShardedURLRepository_DeleteBatchPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	healthy, failing := newShardStub("aaa1111", "bbb2222"), newShardStub("zzz9999")
+	failing.err = errors.New("connection refused")
+	route := func(code string) int {
+		if code[0] == 'z' {
+			return 1
+		}
+		return 0
+	}
+	repo := newShardedURLRepository([]URLRepository{healthy, failing}, route, DefaultShardedConfig())
+
+	deleted, err := repo.DeleteBatch(ctx, []string{"aaa1111", "zzz9999", "bbb2222", "missing"})
+
+	assert.ElementsMatch(t, []string{"aaa1111", "bbb2222"}, deleted)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Failed, 1)
+	assert.ErrorContains(t, batchErr.Failed["zzz9999"], "shard 1: connection refused")
+	assert.ErrorIs(t, err, failing.err)
+	assert.Empty(t, healthy.urls)
+}
+
+func TestShardedURLRepository_CreateBatchPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	healthy, failing := newShardStub(), newShardStub()
+	failing.err = errors.New("connection refused")
+	route := func(code string) int {
+		if code[0] == 'z' {
+			return 1
+		}
+		return 0
+	}
+	repo := newShardedURLRepository([]URLRepository{healthy, failing}, route, DefaultShardedConfig())
+
+	urls, err := repo.CreateBatch(ctx, []*models.URLCreate{
+		{ShortCode: "aaa1111", OriginalURL: "https://example.com/a"},
+		{ShortCode: "zzz9999", OriginalURL: "https://example.com/z"},
+		{ShortCode: "bbb2222", OriginalURL: "https://example.com/b"},
+	})
+
+	require.Len(t, urls, 3)
+	assert.Equal(t, "aaa1111", urls[0].ShortCode)
+	assert.Nil(t, urls[1])
+	assert.Equal(t, "bbb2222", urls[2].ShortCode)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Failed, 1)
+	assert.ErrorContains(t, batchErr.Failed["zzz9999"], "shard 1: connection refused")
+	assert.Len(t, healthy.urls, 2)
 }
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// ShortCodeSequence is the Postgres sequence sequential short codes are drawn from.
+const ShortCodeSequence = "short_code_seq"
+
+// PostgresCounterSource implements idgen.CounterSource using a PostgreSQL
+// sequence, so sequential short codes survive restarts and are shared by all
+// instances using the database.
+type PostgresCounterSource struct {
+	pool     *database.Pool
+	sequence string
+}
+
+// NewPostgresCounterSource creates a counter source drawing from the named sequence.
+func NewPostgresCounterSource(pool *database.Pool, sequence string) *PostgresCounterSource {
+	return &PostgresCounterSource{pool: pool, sequence: sequence}
+}
+
+// Next returns the next value of the sequence.
+func (s *PostgresCounterSource) Next(ctx context.Context) (_ uint64, err error) {
+	ctx, span := startSpan(ctx, "PostgresCounterSource.Next")
+	defer func() { tracing.End(span, err) }()
+
+	var n int64
+	if err = s.pool.QueryRow(ctx, "SELECT nextval($1::regclass)", s.sequence).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to get next sequence value: %w", err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("sequence %s returned negative value %d", s.sequence, n)
+	}
+
+	return uint64(n), nil
+}
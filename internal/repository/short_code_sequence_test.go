@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/database"
+	"github.com/emadnahed/FastGoLink/internal/idgen"
+)
+
+func TestPostgresCounterSource(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	ctx := context.Background()
+	pool, err := database.NewPool(ctx, testDBConfig())
+	require.NoError(t, err)
+	defer pool.Close()
+
+	_, err = pool.Exec(ctx, "CREATE SEQUENCE IF NOT EXISTS "+ShortCodeSequence)
+	require.NoError(t, err)
+
+	gen := idgen.NewSequentialGeneratorWithSource(NewPostgresCounterSource(pool, ShortCodeSequence))
+
+	t.Run("values increase", func(t *testing.T) {
+		source := NewPostgresCounterSource(pool, ShortCodeSequence)
+		first, err := source.Next(ctx)
+		require.NoError(t, err)
+		second, err := source.Next(ctx)
+		require.NoError(t, err)
+
+		assert.Greater(t, second, first)
+	})
+
+	t.Run("codes are unique", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			code, err := gen.GenerateWithContext(ctx)
+			require.NoError(t, err)
+			assert.False(t, seen[code], "duplicate code %q", code)
+			seen[code] = true
+		}
+	})
+
+	t.Run("missing sequence returns error", func(t *testing.T) {
+		_, err := NewPostgresCounterSource(pool, "missing_seq").Next(ctx)
+		assert.Error(t, err)
+	})
+}
@@ -5,36 +5,107 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/emadnahed/FastGoLink/internal/database"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/emadnahed/FastGoLink/internal/repository")
+
 // URLRepository defines the interface for URL persistence operations.
 type URLRepository interface {
 	// Create stores a new URL and returns the created entity.
 	Create(ctx context.Context, url *models.URLCreate) (*models.URL, error)
 
+	// CreateBatch stores several URLs at once. The result is index-aligned
+	// with creates; an entry is nil when its short code was already taken,
+	// including by an earlier entry in the same batch.
+	CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error)
+
 	// GetByShortCode retrieves a URL by its short code.
 	GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error)
 
+	// GetByShortCodes retrieves the URLs with the given short codes at once.
+	// Codes that do not exist or are soft-deleted are left out, and the
+	// result is in no particular order.
+	GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error)
+
 	// GetByID retrieves a URL by its ID.
 	GetByID(ctx context.Context, id int64) (*models.URL, error)
 
-	// Delete removes a URL by its short code.
+	// GetByOriginalURL retrieves the oldest URL pointing at originalURL that
+	// has none of the per-link settings (expiry, password, click limit,
+	// permanent redirect, preview, appended parameters, platform targets or
+	// tags), the kind a plain shorten request creates. It returns
+	// models.ErrURLNotFound when there is none.
+	GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error)
+
+	// Delete soft-deletes a URL by its short code. Soft-deleted URLs are
+	// treated as not found until restored.
 	Delete(ctx context.Context, shortCode string) error
 
+	// DeleteBatch soft-deletes the URLs with the given short codes and
+	// returns the codes that were deleted. Codes that do not exist or are
+	// already deleted are left out.
+	DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error)
+
+	// Restore undoes a soft delete.
+	Restore(ctx context.Context, shortCode string) error
+
+	// DeletePermanent removes a URL for good, whether or not it is soft-deleted.
+	DeletePermanent(ctx context.Context, shortCode string) error
+
+	// UpdateOriginalURL changes the destination of an existing short code.
+	UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error
+
+	// UpdateExpiry sets the expiry time of an existing short code and returns
+	// the updated URL. Soft-deleted URLs are reported as models.ErrURLNotFound.
+	UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error)
+
+	// SetActive enables or disables redirects for an existing short code and
+	// returns the updated URL. Soft-deleted URLs are reported as
+	// models.ErrURLNotFound.
+	SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error)
+
 	// IncrementClickCount increments the click counter for a URL.
 	IncrementClickCount(ctx context.Context, shortCode string) error
 
-	// BatchIncrementClickCounts increments click counts for multiple URLs in a single transaction.
-	BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error
+	// ClaimClick atomically increments the click counter unless the URL has
+	// reached its click limit, returning the new count. It returns
+	// models.ErrURLExhausted when no clicks are left.
+	ClaimClick(ctx context.Context, shortCode string) (int64, error)
+
+	// BatchIncrementClickCounts increments click counts for multiple URLs in a
+	// single transaction and returns the new total of every URL it updated.
+	BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error)
+
+	// DeleteExpired permanently removes all expired URLs, including
+	// soft-deleted ones, and returns their short codes.
+	DeleteExpired(ctx context.Context) ([]string, error)
+
+	// List returns a page of URLs matching filter, newest first, along with the
+	// total number of matching URLs.
+	List(ctx context.Context, limit, offset int, filter ListFilter) ([]*models.URL, int64, error)
+
+	// TopByClicks returns up to limit URLs with the most clicks, most clicked
+	// first. Ties are broken by age, oldest first.
+	TopByClicks(ctx context.Context, limit int) ([]*models.URL, error)
 
-	// DeleteExpired removes all expired URLs and returns the count.
-	DeleteExpired(ctx context.Context) (int64, error)
+	// ListAfter returns up to limit URLs matching filter that come after the
+	// cursor afterID, along with the cursor of the next page. A zero afterID
+	// starts from the beginning and a zero next cursor means there are no
+	// more pages. Unlike List it pages without OFFSET scans.
+	ListAfter(ctx context.Context, afterID int64, limit int, filter ListFilter) ([]*models.URL, int64, error)
 
 	// Exists checks if a short code already exists.
 	Exists(ctx context.Context, shortCode string) (bool, error)
@@ -43,6 +114,54 @@ type URLRepository interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// ListStatus selects URLs by expiry state in List.
+type ListStatus string
+
+// List status filters.
+const (
+	ListStatusAll     ListStatus = ""        // No status filter
+	ListStatusActive  ListStatus = "active"  // Not expired
+	ListStatusExpired ListStatus = "expired" // Past their expiry time
+)
+
+// ListFilter narrows the URLs returned by List. Zero values disable a filter.
+type ListFilter struct {
+	Search        string     // Case-insensitive substring of the short code or original URL
+	CreatedAfter  *time.Time // Only URLs created at or after this time
+	CreatedBefore *time.Time // Only URLs created before this time
+	Status        ListStatus
+	Tag           string // Only URLs with this tag
+}
+
+// Matches reports whether url passes the filter as of now. It mirrors the
+// database query for repositories that filter in memory.
+func (f ListFilter) Matches(url *models.URL, now time.Time) bool {
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(url.ShortCode), search) &&
+			!strings.Contains(strings.ToLower(url.OriginalURL), search) {
+			return false
+		}
+	}
+	if f.CreatedAfter != nil && url.CreatedAt.Before(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !url.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(url.Tags, f.Tag) {
+		return false
+	}
+	expired := url.ExpiresAt != nil && url.ExpiresAt.Before(now)
+	switch f.Status {
+	case ListStatusActive:
+		return !expired
+	case ListStatusExpired:
+		return expired
+	}
+	return true
+}
+
 // PostgresURLRepository implements URLRepository using PostgreSQL.
 type PostgresURLRepository struct {
 	pool *database.Pool
@@ -54,29 +173,52 @@ func NewPostgresURLRepository(pool *database.Pool) *PostgresURLRepository {
 }
 
 // Create stores a new URL.
-func (r *PostgresURLRepository) Create(ctx context.Context, create *models.URLCreate) (*models.URL, error) {
+func (r *PostgresURLRepository) Create(ctx context.Context, create *models.URLCreate) (_ *models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.Create", tracing.ShortCodeKey.String(create.ShortCode))
+	defer func() { tracing.End(span, err) }()
+
 	if err := create.Validate(); err != nil {
 		return nil, err
 	}
 
 	query := `
-		INSERT INTO urls (short_code, original_url, expires_at)
-		VALUES ($1, $2, $3)
-		RETURNING id, short_code, original_url, created_at, expires_at, click_count
+		INSERT INTO urls (short_code, original_url, expires_at, permanent, password_hash, max_clicks,
+			show_preview, append_params, platform_targets, tags)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10)
+		RETURNING id, short_code, original_url, created_at, expires_at, click_count, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
 	`
 
 	var url models.URL
-	err := r.pool.QueryRow(ctx, query, create.ShortCode, create.OriginalURL, create.ExpiresAt).Scan(
+	err = r.pool.QueryRow(ctx, query,
+		create.ShortCode, create.OriginalURL, create.ExpiresAt, create.Permanent, create.PasswordHash, create.MaxClicks,
+		create.ShowPreview, create.AppendParams, create.PlatformTargets, create.Tags,
+	).Scan(
 		&url.ID,
 		&url.ShortCode,
 		&url.OriginalURL,
 		&url.CreatedAt,
 		&url.ExpiresAt,
 		&url.ClickCount,
+		&url.Permanent,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ShowPreview,
+		&url.AppendParams,
+		&url.PlatformTargets,
+		&url.Tags,
+		&url.LinkStatus,
+		&url.LinkCheckedAt,
+		&url.Disabled,
+		&url.UpdatedAt,
 	)
 	if err != nil {
 		if isDuplicateKeyError(err) {
-			return nil, fmt.Errorf("short code already exists: %s", create.ShortCode)
+			return nil, fmt.Errorf("%w: %s", models.ErrShortCodeExists, create.ShortCode)
+		}
+		if isStringTooLongError(err) {
+			return nil, shortCodeTooLongError(create.ShortCode)
 		}
 		return nil, fmt.Errorf("failed to create URL: %w", err)
 	}
@@ -84,22 +226,161 @@ func (r *PostgresURLRepository) Create(ctx context.Context, create *models.URLCr
 	return &url, nil
 }
 
+// createBatchChunkSize caps the rows in one INSERT, keeping it well under
+// the Postgres limit of 65535 bind parameters.
+const createBatchChunkSize = 1000
+
+// CreateBatch stores several URLs in one transaction, using a multi-row
+// INSERT per chunk of createBatchChunkSize rows. Short codes that already
+// exist are skipped rather than failing the batch. Every entry is validated
+// before anything is inserted.
+func (r *PostgresURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) (_ []*models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.CreateBatch", attribute.Int("url.batch_size", len(creates)))
+	defer func() { tracing.End(span, err) }()
+
+	for _, create := range creates {
+		if err := create.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	urls := make([]*models.URL, len(creates))
+	if len(creates) == 0 {
+		return urls, nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for start := 0; start < len(creates); start += createBatchChunkSize {
+		end := min(start+createBatchChunkSize, len(creates))
+		if err := insertURLChunk(ctx, tx, creates[start:end], urls[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit URL batch: %w", err)
+	}
+
+	return urls, nil
+}
+
+// insertURLChunk inserts creates with a single statement and stores each
+// created URL at the matching index of out.
+func insertURLChunk(ctx context.Context, tx pgx.Tx, creates []*models.URLCreate, out []*models.URL) error {
+	const columns = 10
+
+	var query strings.Builder
+	query.WriteString(`
+		INSERT INTO urls (short_code, original_url, expires_at, permanent, password_hash, max_clicks,
+			show_preview, append_params, platform_targets, tags)
+		VALUES `)
+	args := make([]any, 0, len(creates)*columns)
+	// A code repeated within the batch is credited to its first entry
+	index := make(map[string]int, len(creates))
+	for i, create := range creates {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, NULLIF($%d, ''), $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+		args = append(args,
+			create.ShortCode, create.OriginalURL, create.ExpiresAt, create.Permanent, create.PasswordHash, create.MaxClicks,
+			create.ShowPreview, create.AppendParams, create.PlatformTargets, create.Tags,
+		)
+		if _, ok := index[create.ShortCode]; !ok {
+			index[create.ShortCode] = i
+		}
+	}
+	query.WriteString(`
+		ON CONFLICT (short_code) DO NOTHING
+		RETURNING id, short_code, original_url, created_at, expires_at, click_count, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+	`)
+
+	rows, err := tx.Query(ctx, query.String(), args...)
+	if err != nil {
+		if isStringTooLongError(err) {
+			return longestShortCodeError(creates)
+		}
+		return fmt.Errorf("failed to create URLs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var url models.URL
+		err := rows.Scan(
+			&url.ID,
+			&url.ShortCode,
+			&url.OriginalURL,
+			&url.CreatedAt,
+			&url.ExpiresAt,
+			&url.ClickCount,
+			&url.Permanent,
+			&url.PasswordHash,
+			&url.MaxClicks,
+			&url.ShowPreview,
+			&url.AppendParams,
+			&url.PlatformTargets,
+			&url.Tags,
+			&url.LinkStatus,
+			&url.LinkCheckedAt,
+			&url.Disabled,
+			&url.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan created URL: %w", err)
+		}
+		out[index[url.ShortCode]] = &url
+	}
+	if err := rows.Err(); err != nil {
+		if isStringTooLongError(err) {
+			return longestShortCodeError(creates)
+		}
+		return fmt.Errorf("failed to create URLs: %w", err)
+	}
+
+	return nil
+}
+
 // GetByShortCode retrieves a URL by its short code.
-func (r *PostgresURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+func (r *PostgresURLRepository) GetByShortCode(ctx context.Context, shortCode string) (_ *models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.GetByShortCode", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
 	query := `
-		SELECT id, short_code, original_url, created_at, expires_at, click_count
-		FROM urls
-		WHERE short_code = $1
+		SELECT id, short_code, original_url, created_at, expires_at, ` + clickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM ` + urlsWithArchive + `
+		WHERE short_code = $1 AND deleted_at IS NULL
 	`
 
 	var url models.URL
-	err := r.pool.QueryRow(ctx, query, shortCode).Scan(
+	err = r.pool.QueryRow(ctx, query, shortCode).Scan(
 		&url.ID,
 		&url.ShortCode,
 		&url.OriginalURL,
 		&url.CreatedAt,
 		&url.ExpiresAt,
 		&url.ClickCount,
+		&url.Permanent,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ShowPreview,
+		&url.AppendParams,
+		&url.PlatformTargets,
+		&url.Tags,
+		&url.LinkStatus,
+		&url.LinkCheckedAt,
+		&url.Disabled,
+		&url.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -111,22 +392,144 @@ func (r *PostgresURLRepository) GetByShortCode(ctx context.Context, shortCode st
 	return &url, nil
 }
 
+// GetByShortCodes retrieves the URLs with the given short codes in one query.
+func (r *PostgresURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) (_ []*models.URL, err error) {
+	if len(shortCodes) == 0 {
+		return nil, nil
+	}
+
+	ctx, span := startSpan(ctx, "PostgresURLRepository.GetByShortCodes", attribute.Int("url.batch_size", len(shortCodes)))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT id, short_code, original_url, created_at, expires_at, ` + clickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM ` + urlsWithArchive + `
+		WHERE short_code = ANY($1) AND deleted_at IS NULL
+	`
+
+	rows, err := r.pool.Query(ctx, query, shortCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URLs: %w", err)
+	}
+	defer rows.Close()
+
+	urls := make([]*models.URL, 0, len(shortCodes))
+	for rows.Next() {
+		var url models.URL
+		if err := rows.Scan(
+			&url.ID,
+			&url.ShortCode,
+			&url.OriginalURL,
+			&url.CreatedAt,
+			&url.ExpiresAt,
+			&url.ClickCount,
+			&url.Permanent,
+			&url.PasswordHash,
+			&url.MaxClicks,
+			&url.ShowPreview,
+			&url.AppendParams,
+			&url.PlatformTargets,
+			&url.Tags,
+			&url.LinkStatus,
+			&url.LinkCheckedAt,
+			&url.Disabled,
+			&url.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, &url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get URLs: %w", err)
+	}
+
+	return urls, nil
+}
+
 // GetByID retrieves a URL by its ID.
-func (r *PostgresURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
+func (r *PostgresURLRepository) GetByID(ctx context.Context, id int64) (_ *models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.GetByID", attribute.Int64("url.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT id, short_code, original_url, created_at, expires_at, ` + clickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM ` + urlsWithArchive + `
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	var url models.URL
+	err = r.pool.QueryRow(ctx, query, id).Scan(
+		&url.ID,
+		&url.ShortCode,
+		&url.OriginalURL,
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.ClickCount,
+		&url.Permanent,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ShowPreview,
+		&url.AppendParams,
+		&url.PlatformTargets,
+		&url.Tags,
+		&url.LinkStatus,
+		&url.LinkCheckedAt,
+		&url.Disabled,
+		&url.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrURLNotFound
+		}
+		return nil, fmt.Errorf("failed to get URL: %w", err)
+	}
+
+	return &url, nil
+}
+
+// GetByOriginalURL retrieves the oldest plain URL pointing at originalURL.
+func (r *PostgresURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (_ *models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.GetByOriginalURL")
+	defer func() { tracing.End(span, err) }()
+
 	query := `
-		SELECT id, short_code, original_url, created_at, expires_at, click_count
-		FROM urls
-		WHERE id = $1
+		SELECT id, short_code, original_url, created_at, expires_at, ` + clickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM ` + urlsWithArchive + `
+		WHERE original_url = $1 AND deleted_at IS NULL
+			AND expires_at IS NULL AND password_hash IS NULL AND max_clicks IS NULL
+			AND NOT permanent AND NOT show_preview
+			AND (append_params IS NULL OR append_params IN ('null', '{}'))
+			AND (platform_targets IS NULL OR platform_targets IN ('null', '{}'))
+			AND COALESCE(cardinality(tags), 0) = 0 AND active
+		ORDER BY id
+		LIMIT 1
 	`
 
 	var url models.URL
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err = r.pool.QueryRow(ctx, query, originalURL).Scan(
 		&url.ID,
 		&url.ShortCode,
 		&url.OriginalURL,
 		&url.CreatedAt,
 		&url.ExpiresAt,
 		&url.ClickCount,
+		&url.Permanent,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ShowPreview,
+		&url.AppendParams,
+		&url.PlatformTargets,
+		&url.Tags,
+		&url.LinkStatus,
+		&url.LinkCheckedAt,
+		&url.Disabled,
+		&url.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -138,8 +541,82 @@ func (r *PostgresURLRepository) GetByID(ctx context.Context, id int64) (*models.
 	return &url, nil
 }
 
-// Delete removes a URL by its short code.
-func (r *PostgresURLRepository) Delete(ctx context.Context, shortCode string) error {
+// Delete soft-deletes a URL by its short code.
+func (r *PostgresURLRepository) Delete(ctx context.Context, shortCode string) (err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.Delete", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE urls SET deleted_at = NOW(), updated_at = NOW() WHERE short_code = $1 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, shortCode)
+	if err != nil {
+		return fmt.Errorf("failed to delete URL: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// DeleteBatch soft-deletes the URLs with the given short codes in a single
+// statement and returns the codes that were deleted.
+func (r *PostgresURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.DeleteBatch", attribute.Int("url.batch_size", len(shortCodes)))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE urls SET deleted_at = NOW(), updated_at = NOW()
+		WHERE short_code = ANY($1) AND deleted_at IS NULL
+		RETURNING short_code
+	`
+
+	rows, err := r.pool.Query(ctx, query, shortCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete URLs: %w", err)
+	}
+	defer rows.Close()
+
+	deleted := make([]string, 0, len(shortCodes))
+	for rows.Next() {
+		var shortCode string
+		if err := rows.Scan(&shortCode); err != nil {
+			return nil, fmt.Errorf("failed to scan short code: %w", err)
+		}
+		deleted = append(deleted, shortCode)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete URLs: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// Restore undoes a soft delete.
+func (r *PostgresURLRepository) Restore(ctx context.Context, shortCode string) (err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.Restore", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE urls SET deleted_at = NULL, updated_at = NOW() WHERE short_code = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.pool.Exec(ctx, query, shortCode)
+	if err != nil {
+		return fmt.Errorf("failed to restore URL: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// DeletePermanent removes a URL for good, whether or not it is soft-deleted.
+func (r *PostgresURLRepository) DeletePermanent(ctx context.Context, shortCode string) (err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.DeletePermanent", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
 	query := `DELETE FROM urls WHERE short_code = $1`
 
 	result, err := r.pool.Exec(ctx, query, shortCode)
@@ -154,9 +631,117 @@ func (r *PostgresURLRepository) Delete(ctx context.Context, shortCode string) er
 	return nil
 }
 
+// UpdateOriginalURL changes the destination of an existing short code.
+func (r *PostgresURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) (err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.UpdateOriginalURL", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE urls SET original_url = $2, updated_at = NOW() WHERE short_code = $1 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, shortCode, newURL)
+	if err != nil {
+		return fmt.Errorf("failed to update URL: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// UpdateExpiry sets the expiry time of an existing short code.
+func (r *PostgresURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (_ *models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.UpdateExpiry", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE urls SET expires_at = $2, updated_at = NOW()
+		WHERE short_code = $1 AND deleted_at IS NULL
+		RETURNING id, short_code, original_url, created_at, expires_at, ` + returningClickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+	`
+
+	var url models.URL
+	err = r.pool.QueryRow(ctx, query, shortCode, expiresAt).Scan(
+		&url.ID,
+		&url.ShortCode,
+		&url.OriginalURL,
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.ClickCount,
+		&url.Permanent,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ShowPreview,
+		&url.AppendParams,
+		&url.PlatformTargets,
+		&url.Tags,
+		&url.LinkStatus,
+		&url.LinkCheckedAt,
+		&url.Disabled,
+		&url.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrURLNotFound
+		}
+		return nil, fmt.Errorf("failed to update URL expiry: %w", err)
+	}
+
+	return &url, nil
+}
+
+// SetActive enables or disables redirects for an existing short code.
+func (r *PostgresURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (_ *models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.SetActive", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE urls SET active = $2, updated_at = NOW()
+		WHERE short_code = $1 AND deleted_at IS NULL
+		RETURNING id, short_code, original_url, created_at, expires_at, ` + returningClickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+	`
+
+	var url models.URL
+	err = r.pool.QueryRow(ctx, query, shortCode, active).Scan(
+		&url.ID,
+		&url.ShortCode,
+		&url.OriginalURL,
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.ClickCount,
+		&url.Permanent,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.ShowPreview,
+		&url.AppendParams,
+		&url.PlatformTargets,
+		&url.Tags,
+		&url.LinkStatus,
+		&url.LinkCheckedAt,
+		&url.Disabled,
+		&url.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrURLNotFound
+		}
+		return nil, fmt.Errorf("failed to update URL state: %w", err)
+	}
+
+	return &url, nil
+}
+
 // IncrementClickCount increments the click counter for a URL.
-func (r *PostgresURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
-	query := `UPDATE urls SET click_count = click_count + 1 WHERE short_code = $1`
+func (r *PostgresURLRepository) IncrementClickCount(ctx context.Context, shortCode string) (err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.IncrementClickCount", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE urls SET click_count = click_count + 1, updated_at = NOW() WHERE short_code = $1 AND deleted_at IS NULL`
 
 	result, err := r.pool.Exec(ctx, query, shortCode)
 	if err != nil {
@@ -170,12 +755,54 @@ func (r *PostgresURLRepository) IncrementClickCount(ctx context.Context, shortCo
 	return nil
 }
 
-// BatchIncrementClickCounts increments click counts for multiple URLs in a single batch.
-func (r *PostgresURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error {
+// ClaimClick atomically increments the click counter unless the URL has reached
+// its click limit. The limit check and increment happen in a single UPDATE, so
+// concurrent redirects can never exceed max_clicks.
+func (r *PostgresURLRepository) ClaimClick(ctx context.Context, shortCode string) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.ClaimClick", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE urls SET click_count = click_count + 1, updated_at = NOW()
+		WHERE short_code = $1 AND deleted_at IS NULL
+			AND (max_clicks IS NULL OR click_count < max_clicks)
+		RETURNING click_count
+	`
+
+	var clickCount int64
+	err = r.pool.QueryRow(ctx, query, shortCode).Scan(&clickCount)
+	if err == nil {
+		return clickCount, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("failed to claim click: %w", err)
+	}
+
+	// No row was updated: either the URL is gone or its clicks are used up
+	var exists bool
+	err = r.pool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1 AND deleted_at IS NULL)`, shortCode,
+	).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check existence: %w", err)
+	}
+	if !exists {
+		return 0, models.ErrURLNotFound
+	}
+	return 0, models.ErrURLExhausted
+}
+
+// BatchIncrementClickCounts increments click counts for multiple URLs in a
+// single batch. The totals come back from the UPDATE itself, archived clicks
+// included, so callers need not read the rows again.
+func (r *PostgresURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (_ map[string]int64, err error) {
 	if len(counts) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	ctx, span := startSpan(ctx, "PostgresURLRepository.BatchIncrementClickCounts", attribute.Int("url.batch_size", len(counts)))
+	defer func() { tracing.End(span, err) }()
+
 	// Use a single UPDATE with CASE for efficiency
 	// UPDATE urls SET click_count = click_count + CASE
 	//   WHEN short_code = 'abc' THEN 5
@@ -196,7 +823,7 @@ func (r *PostgresURLRepository) BatchIncrementClickCounts(ctx context.Context, c
 		argIdx += 2
 	}
 
-	query += " ELSE 0 END WHERE short_code IN ("
+	query += " ELSE 0 END, updated_at = NOW() WHERE short_code IN ("
 	for i, code := range shortCodes {
 		if i > 0 {
 			query += ", "
@@ -205,34 +832,282 @@ func (r *PostgresURLRepository) BatchIncrementClickCounts(ctx context.Context, c
 		args = append(args, code)
 		argIdx++
 	}
-	query += ")"
+	query += ") RETURNING urls.short_code, " + returningClickCountColumn
 
-	_, err := r.pool.Exec(ctx, query, args...)
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to batch increment click counts: %w", err)
+		return nil, fmt.Errorf("failed to batch increment click counts: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	totals := make(map[string]int64, len(counts))
+	for rows.Next() {
+		var (
+			shortCode string
+			total     int64
+		)
+		if err := rows.Scan(&shortCode, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan click count: %w", err)
+		}
+		totals[shortCode] = total
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to batch increment click counts: %w", err)
+	}
+
+	return totals, nil
+}
+
+// DeleteExpired permanently removes all expired URLs and returns their short
+// codes. Soft-deleted URLs are included: once expired they can no longer
+// redirect, so there is nothing left to restore.
+func (r *PostgresURLRepository) DeleteExpired(ctx context.Context) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.DeleteExpired")
+	defer func() { tracing.End(span, err) }()
+
+	query := `DELETE FROM urls WHERE expires_at IS NOT NULL AND expires_at < $1 RETURNING short_code`
+
+	rows, err := r.pool.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired URLs: %w", err)
+	}
+	deleted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired URLs: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("url.deleted", len(deleted)))
+	return deleted, nil
+}
+
+// List returns a page of URLs matching filter, newest first, along with the
+// total number of matching URLs.
+func (r *PostgresURLRepository) List(ctx context.Context, limit, offset int, filter ListFilter) (_ []*models.URL, _ int64, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.List",
+		attribute.Int("list.limit", limit), attribute.Int("list.offset", offset))
+	defer func() { tracing.End(span, err) }()
+
+	where, args := listWhereClause(filter, time.Now())
+
+	var total int64
+	err = r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM urls"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count URLs: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, short_code, original_url, created_at, expires_at, `+clickCountColumn+`, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM `+urlsWithArchive+`%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs: %w", err)
+	}
+	defer rows.Close()
+
+	urls := make([]*models.URL, 0, limit)
+	for rows.Next() {
+		var url models.URL
+		if err := rows.Scan(
+			&url.ID,
+			&url.ShortCode,
+			&url.OriginalURL,
+			&url.CreatedAt,
+			&url.ExpiresAt,
+			&url.ClickCount,
+			&url.Permanent,
+			&url.PasswordHash,
+			&url.MaxClicks,
+			&url.ShowPreview,
+			&url.AppendParams,
+			&url.PlatformTargets,
+			&url.Tags,
+			&url.LinkStatus,
+			&url.LinkCheckedAt,
+			&url.Disabled,
+			&url.UpdatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, &url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs: %w", err)
+	}
+
+	return urls, total, nil
 }
 
-// DeleteExpired removes all expired URLs and returns the count.
-func (r *PostgresURLRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	query := `DELETE FROM urls WHERE expires_at IS NOT NULL AND expires_at < $1`
+// TopByClicks returns up to limit URLs with the most clicks, most clicked first.
+func (r *PostgresURLRepository) TopByClicks(ctx context.Context, limit int) (_ []*models.URL, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.TopByClicks", attribute.Int("list.limit", limit))
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT id, short_code, original_url, created_at, expires_at, ` + clickCountColumn + `, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM ` + urlsWithArchive + `
+		WHERE deleted_at IS NULL
+		ORDER BY click_count DESC, id
+		LIMIT $1
+	`
 
-	result, err := r.pool.Exec(ctx, query, time.Now())
+	rows, err := r.pool.Query(ctx, query, limit)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete expired URLs: %w", err)
+		return nil, fmt.Errorf("failed to get top URLs: %w", err)
+	}
+	defer rows.Close()
+
+	urls := make([]*models.URL, 0, limit)
+	for rows.Next() {
+		var url models.URL
+		if err := rows.Scan(
+			&url.ID,
+			&url.ShortCode,
+			&url.OriginalURL,
+			&url.CreatedAt,
+			&url.ExpiresAt,
+			&url.ClickCount,
+			&url.Permanent,
+			&url.PasswordHash,
+			&url.MaxClicks,
+			&url.ShowPreview,
+			&url.AppendParams,
+			&url.PlatformTargets,
+			&url.Tags,
+			&url.LinkStatus,
+			&url.LinkCheckedAt,
+			&url.Disabled,
+			&url.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, &url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get top URLs: %w", err)
 	}
 
-	return result.RowsAffected(), nil
+	return urls, nil
+}
+
+// ListAfter returns up to limit URLs matching filter with IDs above afterID,
+// in ID order. The next cursor is the ID of the last URL returned, or zero
+// when no URLs are left.
+func (r *PostgresURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter ListFilter) (_ []*models.URL, _ int64, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.ListAfter",
+		attribute.Int64("list.after_id", afterID), attribute.Int("list.limit", limit))
+	defer func() { tracing.End(span, err) }()
+
+	where, args := listWhereClause(filter, time.Now())
+
+	// One extra row tells whether another page follows.
+	query := fmt.Sprintf(`
+		SELECT id, short_code, original_url, created_at, expires_at, `+clickCountColumn+`, permanent,
+			COALESCE(password_hash, ''), max_clicks, show_preview, append_params, platform_targets, tags,
+			link_status, link_checked_at, NOT active, updated_at
+		FROM `+urlsWithArchive+`%s AND id > $%d
+		ORDER BY id
+		LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := r.pool.Query(ctx, query, append(args, afterID, limit+1)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs: %w", err)
+	}
+	defer rows.Close()
+
+	urls := make([]*models.URL, 0, limit+1)
+	for rows.Next() {
+		var url models.URL
+		if err := rows.Scan(
+			&url.ID,
+			&url.ShortCode,
+			&url.OriginalURL,
+			&url.CreatedAt,
+			&url.ExpiresAt,
+			&url.ClickCount,
+			&url.Permanent,
+			&url.PasswordHash,
+			&url.MaxClicks,
+			&url.ShowPreview,
+			&url.AppendParams,
+			&url.PlatformTargets,
+			&url.Tags,
+			&url.LinkStatus,
+			&url.LinkCheckedAt,
+			&url.Disabled,
+			&url.UpdatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, &url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list URLs: %w", err)
+	}
+
+	if len(urls) <= limit {
+		return urls, 0, nil
+	}
+	urls = urls[:limit]
+	return urls, urls[limit-1].ID, nil
+}
+
+// listWhereClause builds the WHERE clause and arguments for a ListFilter.
+func listWhereClause(filter ListFilter, now time.Time) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Search != "" {
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf("(short_code ILIKE $%d OR original_url ILIKE $%d)", len(args), len(args)))
+	}
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.CreatedBefore != nil {
+		args = append(args, *filter.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		// Containment rather than = ANY(tags), which can't use the GIN index on tags
+		conditions = append(conditions, fmt.Sprintf("tags @> ARRAY[$%d]::text[]", len(args)))
+	}
+	switch filter.Status {
+	case ListStatusActive:
+		args = append(args, now)
+		conditions = append(conditions, fmt.Sprintf("(expires_at IS NULL OR expires_at >= $%d)", len(args)))
+	case ListStatusExpired:
+		args = append(args, now)
+		conditions = append(conditions, fmt.Sprintf("expires_at < $%d", len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// escapeLike escapes LIKE wildcards so search terms match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 // Exists checks if a short code already exists.
-func (r *PostgresURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
+func (r *PostgresURLRepository) Exists(ctx context.Context, shortCode string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "PostgresURLRepository.Exists", tracing.ShortCodeKey.String(shortCode))
+	defer func() { tracing.End(span, err) }()
+
 	query := `SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)`
 
 	var exists bool
-	err := r.pool.QueryRow(ctx, query, shortCode).Scan(&exists)
+	err = r.pool.QueryRow(ctx, query, shortCode).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check existence: %w", err)
 	}
@@ -245,12 +1120,46 @@ func (r *PostgresURLRepository) HealthCheck(ctx context.Context) error {
 	return r.pool.HealthCheck(ctx)
 }
 
+// startSpan starts a client span for a database operation.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system.name", "postgresql"))
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
 // isDuplicateKeyError checks if the error is a duplicate key violation.
 func isDuplicateKeyError(err error) bool {
 	// PostgreSQL error code for unique violation is 23505
 	return err != nil && (contains(err.Error(), "23505") || contains(err.Error(), "duplicate key"))
 }
 
+// isStringTooLongError checks if the error is a value too long for its
+// column. Of the urls columns, only short_code has a length limit, so on an
+// insert into urls it means the short code does not fit.
+func isStringTooLongError(err error) bool {
+	var pgErr *pgconn.PgError
+	// PostgreSQL error code for string_data_right_truncation is 22001
+	return errors.As(err, &pgErr) && pgErr.Code == "22001"
+}
+
+// shortCodeTooLongError reports a short code the short_code column rejected.
+// The service normally rejects these first against URL_MAX_SHORT_CODE_LEN;
+// this covers that limit being set above the column's actual size.
+func shortCodeTooLongError(shortCode string) error {
+	return fmt.Errorf("%w: %d characters do not fit the short_code column", models.ErrShortCodeTooLong, len(shortCode))
+}
+
+// longestShortCodeError reports the longest short code of a rejected batch,
+// since Postgres does not say which row failed.
+func longestShortCodeError(creates []*models.URLCreate) error {
+	longest := ""
+	for _, create := range creates {
+		if len(create.ShortCode) > len(longest) {
+			longest = create.ShortCode
+		}
+	}
+	return shortCodeTooLongError(longest)
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsAt(s, substr, 0))
 }
@@ -2,10 +2,15 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -14,7 +19,7 @@ import (
 	"github.com/emadnahed/FastGoLink/internal/models"
 )
 
-func skipIfNoPostgres(t *testing.T) {
+func skipIfNoPostgres(t testing.TB) {
 	t.Helper()
 	if os.Getenv("TEST_POSTGRES") != "true" {
 		t.Skip("Skipping: TEST_POSTGRES not set. Run with docker-compose up -d")
@@ -42,7 +47,7 @@ func testDBConfig() *config.DatabaseConfig {
 	}
 }
 
-func setupTestDB(t *testing.T) (*database.Pool, func()) {
+func setupTestDB(t testing.TB) (*database.Pool, func()) {
 	t.Helper()
 
 	ctx := context.Background()
@@ -59,7 +64,29 @@ func setupTestDB(t *testing.T) (*database.Pool, func()) {
 			original_url TEXT NOT NULL,
 			created_at TIMESTAMPTZ DEFAULT NOW(),
 			expires_at TIMESTAMPTZ,
-			click_count BIGINT DEFAULT 0
+			click_count BIGINT DEFAULT 0,
+			permanent BOOLEAN NOT NULL DEFAULT FALSE,
+			password_hash TEXT,
+			max_clicks BIGINT,
+			deleted_at TIMESTAMPTZ,
+			show_preview BOOLEAN NOT NULL DEFAULT FALSE,
+			append_params JSONB,
+			platform_targets JSONB,
+			tags TEXT[],
+			link_status SMALLINT,
+			link_checked_at TIMESTAMPTZ,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	require.NoError(t, err)
+
+	// URL queries add archived clicks to the live count
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS url_click_archive (
+			short_code VARCHAR(10) PRIMARY KEY REFERENCES urls(short_code) ON DELETE CASCADE,
+			click_count BIGINT NOT NULL DEFAULT 0,
+			archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		)
 	`)
 	require.NoError(t, err)
@@ -97,7 +124,7 @@ func TestPostgresURLRepository_Create(t *testing.T) {
 		assert.Zero(t, url.ClickCount)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "test123")
+		_ = repo.DeletePermanent(ctx, "test123")
 	})
 
 	t.Run("create with expiry", func(t *testing.T) {
@@ -113,7 +140,7 @@ func TestPostgresURLRepository_Create(t *testing.T) {
 		assert.NotNil(t, url.ExpiresAt)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "exp123")
+		_ = repo.DeletePermanent(ctx, "exp123")
 	})
 
 	t.Run("duplicate short code", func(t *testing.T) {
@@ -132,11 +159,10 @@ func TestPostgresURLRepository_Create(t *testing.T) {
 		}
 
 		_, err = repo.Create(ctx, create2)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "already exists")
+		assert.ErrorIs(t, err, models.ErrShortCodeExists)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "dup123")
+		_ = repo.DeletePermanent(ctx, "dup123")
 	})
 
 	t.Run("invalid URL", func(t *testing.T) {
@@ -148,6 +174,33 @@ func TestPostgresURLRepository_Create(t *testing.T) {
 		_, err := repo.Create(ctx, create)
 		assert.ErrorIs(t, err, models.ErrInvalidURL)
 	})
+
+	t.Run("short code longer than the column", func(t *testing.T) {
+		create := &models.URLCreate{
+			ShortCode:   "abcdefghijk",
+			OriginalURL: "https://example.com/long",
+		}
+
+		_, err := repo.Create(ctx, create)
+		assert.ErrorIs(t, err, models.ErrShortCodeTooLong)
+
+		_, err = repo.CreateBatch(ctx, []*models.URLCreate{create})
+		assert.ErrorIs(t, err, models.ErrShortCodeTooLong)
+	})
+}
+
+func TestIsStringTooLongError(t *testing.T) {
+	tooLong := &pgconn.PgError{Code: "22001", Message: "value too long for type character varying(10)"}
+
+	assert.True(t, isStringTooLongError(tooLong))
+	assert.True(t, isStringTooLongError(fmt.Errorf("insert: %w", tooLong)))
+	assert.False(t, isStringTooLongError(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, isStringTooLongError(errors.New("value too long")))
+	assert.False(t, isStringTooLongError(nil))
+
+	err := longestShortCodeError([]*models.URLCreate{{ShortCode: "abc"}, {ShortCode: "abcdefghijk"}})
+	assert.ErrorIs(t, err, models.ErrShortCodeTooLong)
+	assert.ErrorContains(t, err, "11 characters")
 }
 
 func TestPostgresURLRepository_GetByShortCode(t *testing.T) {
@@ -173,7 +226,7 @@ func TestPostgresURLRepository_GetByShortCode(t *testing.T) {
 		assert.Equal(t, "https://example.com/get", url.OriginalURL)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "get123")
+		_ = repo.DeletePermanent(ctx, "get123")
 	})
 
 	t.Run("get non-existent URL", func(t *testing.T) {
@@ -198,6 +251,7 @@ func TestPostgresURLRepository_Delete(t *testing.T) {
 		}
 		_, err := repo.Create(ctx, create)
 		require.NoError(t, err)
+		defer func() { _ = repo.DeletePermanent(ctx, "del123") }()
 
 		err = repo.Delete(ctx, "del123")
 		assert.NoError(t, err)
@@ -211,6 +265,210 @@ func TestPostgresURLRepository_Delete(t *testing.T) {
 		err := repo.Delete(ctx, "nonexistent")
 		assert.ErrorIs(t, err, models.ErrURLNotFound)
 	})
+
+	t.Run("delete and restore", func(t *testing.T) {
+		_, err := repo.Create(ctx, &models.URLCreate{
+			ShortCode:   "soft123",
+			OriginalURL: "https://example.com/soft",
+		})
+		require.NoError(t, err)
+		defer func() { _ = repo.DeletePermanent(ctx, "soft123") }()
+
+		require.NoError(t, repo.Delete(ctx, "soft123"))
+
+		// Soft-deleted URLs are hidden but keep their short code reserved
+		_, err = repo.GetByShortCode(ctx, "soft123")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		assert.ErrorIs(t, repo.IncrementClickCount(ctx, "soft123"), models.ErrURLNotFound)
+		_, err = repo.ClaimClick(ctx, "soft123")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		exists, err := repo.Exists(ctx, "soft123")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		// Deleting twice is not allowed
+		assert.ErrorIs(t, repo.Delete(ctx, "soft123"), models.ErrURLNotFound)
+
+		require.NoError(t, repo.Restore(ctx, "soft123"))
+		url, err := repo.GetByShortCode(ctx, "soft123")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/soft", url.OriginalURL)
+
+		// Restoring a live URL is not allowed
+		assert.ErrorIs(t, repo.Restore(ctx, "soft123"), models.ErrURLNotFound)
+	})
+
+	t.Run("delete permanently", func(t *testing.T) {
+		_, err := repo.Create(ctx, &models.URLCreate{
+			ShortCode:   "hard123",
+			OriginalURL: "https://example.com/hard",
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, "hard123"))
+
+		// Permanent deletion also purges soft-deleted rows
+		require.NoError(t, repo.DeletePermanent(ctx, "hard123"))
+		exists, err := repo.Exists(ctx, "hard123")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.ErrorIs(t, repo.Restore(ctx, "hard123"), models.ErrURLNotFound)
+		assert.ErrorIs(t, repo.DeletePermanent(ctx, "hard123"), models.ErrURLNotFound)
+	})
+}
+
+func TestPostgresURLRepository_CreateBatch(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	_, err := repo.Create(ctx, &models.URLCreate{ShortCode: "taken1", OriginalURL: "https://example.com/taken"})
+	require.NoError(t, err)
+
+	maxClicks := int64(3)
+	urls, err := repo.CreateBatch(ctx, []*models.URLCreate{
+		{ShortCode: "bulk1", OriginalURL: "https://example.com/1", MaxClicks: &maxClicks, Tags: []string{"promo"}},
+		{ShortCode: "taken1", OriginalURL: "https://example.com/other"},
+		{ShortCode: "bulk2", OriginalURL: "https://example.com/2", PasswordHash: "hash"},
+		{ShortCode: "bulk1", OriginalURL: "https://example.com/repeat"},
+	})
+	require.NoError(t, err)
+	require.Len(t, urls, 4)
+
+	require.NotNil(t, urls[0])
+	assert.NotZero(t, urls[0].ID)
+	assert.Equal(t, "https://example.com/1", urls[0].OriginalURL)
+	assert.Equal(t, &maxClicks, urls[0].MaxClicks)
+	assert.Equal(t, []string{"promo"}, urls[0].Tags)
+	assert.Nil(t, urls[1], "existing code is skipped")
+	require.NotNil(t, urls[2])
+	assert.Equal(t, "hash", urls[2].PasswordHash)
+	assert.Nil(t, urls[3], "repeated code is credited to its first entry")
+
+	// The existing row is untouched
+	existing, err := repo.GetByShortCode(ctx, "taken1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/taken", existing.OriginalURL)
+
+	t.Run("invalid entry inserts nothing", func(t *testing.T) {
+		_, err := repo.CreateBatch(ctx, []*models.URLCreate{
+			{ShortCode: "bulk3", OriginalURL: "https://example.com/3"},
+			{ShortCode: "bulk4", OriginalURL: "not-a-url"},
+		})
+		assert.ErrorIs(t, err, models.ErrInvalidURL)
+
+		exists, err := repo.Exists(ctx, "bulk3")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("spans several chunks", func(t *testing.T) {
+		creates := make([]*models.URLCreate, createBatchChunkSize+5)
+		for i := range creates {
+			creates[i] = &models.URLCreate{ShortCode: fmt.Sprintf("ch%05d", i), OriginalURL: "https://example.com/chunk"}
+		}
+
+		urls, err := repo.CreateBatch(ctx, creates)
+		require.NoError(t, err)
+		for i, url := range urls {
+			require.NotNil(t, url, "entry %d", i)
+			assert.Equal(t, creates[i].ShortCode, url.ShortCode)
+		}
+	})
+}
+
+func TestPostgresURLRepository_GetByShortCodes(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	for _, code := range []string{"bget1", "bget2", "bget3"} {
+		_, err := repo.Create(ctx, &models.URLCreate{ShortCode: code, OriginalURL: "https://example.com/" + code})
+		require.NoError(t, err)
+		defer func(code string) { _ = repo.DeletePermanent(ctx, code) }(code)
+	}
+	require.NoError(t, repo.IncrementClickCount(ctx, "bget2"))
+	require.NoError(t, repo.Delete(ctx, "bget3"))
+
+	urls, err := repo.GetByShortCodes(ctx, []string{"bget1", "bget2", "bget3", "nonexistent"})
+	require.NoError(t, err)
+
+	// Deleted and unknown codes are left out
+	clicks := make(map[string]int64)
+	for _, url := range urls {
+		clicks[url.ShortCode] = url.ClickCount
+	}
+	assert.Equal(t, map[string]int64{"bget1": 0, "bget2": 1}, clicks)
+
+	urls, err = repo.GetByShortCodes(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
+
+func TestPostgresURLRepository_DeleteBatch(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	for _, code := range []string{"bdel1", "bdel2", "bdel3"} {
+		_, err := repo.Create(ctx, &models.URLCreate{ShortCode: code, OriginalURL: "https://example.com/" + code})
+		require.NoError(t, err)
+		defer func(code string) { _ = repo.DeletePermanent(ctx, code) }(code)
+	}
+	require.NoError(t, repo.Delete(ctx, "bdel3"))
+
+	deleted, err := repo.DeleteBatch(ctx, []string{"bdel1", "bdel2", "bdel3", "nonexistent"})
+	require.NoError(t, err)
+	// Already deleted and unknown codes are not reported
+	assert.ElementsMatch(t, []string{"bdel1", "bdel2"}, deleted)
+
+	_, err = repo.GetByShortCode(ctx, "bdel1")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	// Batch deletes are soft deletes
+	require.NoError(t, repo.Restore(ctx, "bdel2"))
+}
+
+func TestPostgresURLRepository_UpdateOriginalURL(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	t.Run("update existing URL", func(t *testing.T) {
+		create := &models.URLCreate{
+			ShortCode:   "upd123",
+			OriginalURL: "https://example.com/old",
+		}
+		_, err := repo.Create(ctx, create)
+		require.NoError(t, err)
+
+		err = repo.UpdateOriginalURL(ctx, "upd123", "https://example.com/new")
+		require.NoError(t, err)
+
+		url, err := repo.GetByShortCode(ctx, "upd123")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/new", url.OriginalURL)
+	})
+
+	t.Run("update non-existent URL", func(t *testing.T) {
+		err := repo.UpdateOriginalURL(ctx, "nonexistent", "https://example.com/new")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
 }
 
 func TestPostgresURLRepository_IncrementClickCount(t *testing.T) {
@@ -241,7 +499,27 @@ func TestPostgresURLRepository_IncrementClickCount(t *testing.T) {
 		assert.Equal(t, int64(5), url.ClickCount)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "click1")
+		_ = repo.DeletePermanent(ctx, "click1")
+	})
+
+	t.Run("codes sharing a destination count separately", func(t *testing.T) {
+		for _, code := range []string{"shared1", "shared2"} {
+			_, err := repo.Create(ctx, &models.URLCreate{ShortCode: code, OriginalURL: "https://example.com/shared"})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, repo.IncrementClickCount(ctx, "shared2"))
+		require.NoError(t, repo.IncrementClickCount(ctx, "shared2"))
+
+		first, err := repo.GetByShortCode(ctx, "shared1")
+		require.NoError(t, err)
+		second, err := repo.GetByShortCode(ctx, "shared2")
+		require.NoError(t, err)
+		assert.Zero(t, first.ClickCount)
+		assert.Equal(t, int64(2), second.ClickCount)
+
+		_ = repo.DeletePermanent(ctx, "shared1")
+		_ = repo.DeletePermanent(ctx, "shared2")
 	})
 
 	t.Run("increment non-existent URL", func(t *testing.T) {
@@ -250,6 +528,99 @@ func TestPostgresURLRepository_IncrementClickCount(t *testing.T) {
 	})
 }
 
+func TestPostgresURLRepository_ClaimClick(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		maxClicks := int64(2)
+		_, err := repo.Create(ctx, &models.URLCreate{
+			ShortCode:   "limit1",
+			OriginalURL: "https://example.com/limit",
+			MaxClicks:   &maxClicks,
+		})
+		require.NoError(t, err)
+		defer func() { _ = repo.DeletePermanent(ctx, "limit1") }()
+
+		count, err := repo.ClaimClick(ctx, "limit1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		count, err = repo.ClaimClick(ctx, "limit1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		_, err = repo.ClaimClick(ctx, "limit1")
+		assert.ErrorIs(t, err, models.ErrURLExhausted)
+
+		url, err := repo.GetByShortCode(ctx, "limit1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), url.ClickCount)
+		require.NotNil(t, url.MaxClicks)
+		assert.True(t, url.IsExhausted())
+	})
+
+	t.Run("concurrent claims race for the last click", func(t *testing.T) {
+		maxClicks := int64(1)
+		_, err := repo.Create(ctx, &models.URLCreate{
+			ShortCode:   "race1",
+			OriginalURL: "https://example.com/race",
+			MaxClicks:   &maxClicks,
+		})
+		require.NoError(t, err)
+		defer func() { _ = repo.DeletePermanent(ctx, "race1") }()
+
+		const workers = 20
+		var (
+			wg        sync.WaitGroup
+			succeeded atomic.Int64
+			exhausted atomic.Int64
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.ClaimClick(ctx, "race1")
+				switch {
+				case err == nil:
+					succeeded.Add(1)
+				case errors.Is(err, models.ErrURLExhausted):
+					exhausted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(1), succeeded.Load())
+		assert.Equal(t, int64(workers-1), exhausted.Load())
+	})
+
+	t.Run("unlimited URL always succeeds", func(t *testing.T) {
+		_, err := repo.Create(ctx, &models.URLCreate{
+			ShortCode:   "nolimit1",
+			OriginalURL: "https://example.com/nolimit",
+		})
+		require.NoError(t, err)
+		defer func() { _ = repo.DeletePermanent(ctx, "nolimit1") }()
+
+		for i := 1; i <= 3; i++ {
+			count, err := repo.ClaimClick(ctx, "nolimit1")
+			require.NoError(t, err)
+			assert.Equal(t, int64(i), count)
+		}
+	})
+
+	t.Run("non-existent URL", func(t *testing.T) {
+		_, err := repo.ClaimClick(ctx, "nonexistent")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+}
+
 func TestPostgresURLRepository_DeleteExpired(t *testing.T) {
 	skipIfNoPostgres(t)
 
@@ -287,14 +658,26 @@ func TestPostgresURLRepository_DeleteExpired(t *testing.T) {
 	_, err = repo.Create(ctx, noExpiry)
 	require.NoError(t, err)
 
-	// Delete expired
-	count, err := repo.DeleteExpired(ctx)
+	// Create expired URL that was already soft-deleted
+	_, err = repo.Create(ctx, &models.URLCreate{
+		ShortCode:   "expired2",
+		OriginalURL: "https://example.com/expired-deleted",
+		ExpiresAt:   &expiredTime,
+	})
 	require.NoError(t, err)
-	assert.Equal(t, int64(1), count)
+	require.NoError(t, repo.Delete(ctx, "expired2"))
 
-	// Verify expired is gone
+	// Delete expired, including soft-deleted rows
+	deleted, err := repo.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"expired1", "expired2"}, deleted)
+
+	// Verify expired rows are gone for good
 	_, err = repo.GetByShortCode(ctx, "expired1")
 	assert.ErrorIs(t, err, models.ErrURLNotFound)
+	exists, err := repo.Exists(ctx, "expired2")
+	require.NoError(t, err)
+	assert.False(t, exists)
 
 	// Verify others still exist
 	_, err = repo.GetByShortCode(ctx, "future1")
@@ -304,8 +687,8 @@ func TestPostgresURLRepository_DeleteExpired(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Cleanup
-	_ = repo.Delete(ctx, "future1")
-	_ = repo.Delete(ctx, "noexp1")
+	_ = repo.DeletePermanent(ctx, "future1")
+	_ = repo.DeletePermanent(ctx, "noexp1")
 }
 
 func TestPostgresURLRepository_Exists(t *testing.T) {
@@ -330,7 +713,7 @@ func TestPostgresURLRepository_Exists(t *testing.T) {
 		assert.True(t, exists)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "exists1")
+		_ = repo.DeletePermanent(ctx, "exists1")
 	})
 
 	t.Run("exists returns false for non-existing", func(t *testing.T) {
@@ -363,7 +746,7 @@ func TestPostgresURLRepository_GetByID(t *testing.T) {
 		assert.Equal(t, "byid1", url.ShortCode)
 
 		// Cleanup
-		_ = repo.Delete(ctx, "byid1")
+		_ = repo.DeletePermanent(ctx, "byid1")
 	})
 
 	t.Run("get by non-existent ID", func(t *testing.T) {
@@ -371,3 +754,416 @@ func TestPostgresURLRepository_GetByID(t *testing.T) {
 		assert.ErrorIs(t, err, models.ErrURLNotFound)
 	})
 }
+
+func TestPostgresURLRepository_UpdateExpiry(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	_, err := repo.Create(ctx, &models.URLCreate{ShortCode: "ext1", OriginalURL: "https://example.com/ext"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &models.URLCreate{ShortCode: "extdel", OriginalURL: "https://example.com/del"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, "extdel"))
+
+	expiresAt := time.Now().Add(7 * 24 * time.Hour).UTC().Truncate(time.Microsecond)
+
+	t.Run("sets expiry on a non-expiring URL", func(t *testing.T) {
+		url, err := repo.UpdateExpiry(ctx, "ext1", expiresAt)
+		require.NoError(t, err)
+		require.NotNil(t, url.ExpiresAt)
+		assert.True(t, url.ExpiresAt.Equal(expiresAt))
+
+		stored, err := repo.GetByShortCode(ctx, "ext1")
+		require.NoError(t, err)
+		assert.True(t, stored.ExpiresAt.Equal(expiresAt))
+	})
+
+	t.Run("deleted URL", func(t *testing.T) {
+		_, err := repo.UpdateExpiry(ctx, "extdel", expiresAt)
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+
+	t.Run("non-existent URL", func(t *testing.T) {
+		_, err := repo.UpdateExpiry(ctx, "nosuch", expiresAt)
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+}
+
+func TestPostgresURLRepository_SetActive(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &models.URLCreate{ShortCode: "tog1", OriginalURL: "https://example.com/tog"})
+	require.NoError(t, err)
+	assert.False(t, created.Disabled)
+	assert.False(t, created.UpdatedAt.IsZero())
+	_, err = repo.Create(ctx, &models.URLCreate{ShortCode: "togdel", OriginalURL: "https://example.com/del"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, "togdel"))
+
+	t.Run("disable and enable", func(t *testing.T) {
+		url, err := repo.SetActive(ctx, "tog1", false)
+		require.NoError(t, err)
+		assert.True(t, url.Disabled)
+		assert.True(t, url.UpdatedAt.After(created.UpdatedAt), "updated_at should advance")
+
+		stored, err := repo.GetByShortCode(ctx, "tog1")
+		require.NoError(t, err)
+		assert.True(t, stored.Disabled)
+
+		url, err = repo.SetActive(ctx, "tog1", true)
+		require.NoError(t, err)
+		assert.False(t, url.Disabled)
+	})
+
+	t.Run("deleted URL", func(t *testing.T) {
+		_, err := repo.SetActive(ctx, "togdel", false)
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+
+	t.Run("non-existent URL", func(t *testing.T) {
+		_, err := repo.SetActive(ctx, "nosuch", false)
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+}
+
+func TestPostgresURLRepository_GetByOriginalURL(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	future := time.Now().Add(time.Hour)
+	maxClicks := int64(3)
+	for _, create := range []*models.URLCreate{
+		// URLs with per-link settings are never returned
+		{ShortCode: "dupexp", OriginalURL: "https://example.com/dup", ExpiresAt: &future},
+		{ShortCode: "dupmax", OriginalURL: "https://example.com/dup", MaxClicks: &maxClicks},
+		{ShortCode: "duptag", OriginalURL: "https://example.com/dup", Tags: []string{"promo"}},
+		{ShortCode: "dupdel", OriginalURL: "https://example.com/dup"},
+		{ShortCode: "dupold", OriginalURL: "https://example.com/dup"},
+		{ShortCode: "dupnew", OriginalURL: "https://example.com/dup"},
+		{ShortCode: "other", OriginalURL: "https://example.com/other"},
+	} {
+		_, err := repo.Create(ctx, create)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Delete(ctx, "dupdel"))
+
+	t.Run("returns the oldest plain URL", func(t *testing.T) {
+		url, err := repo.GetByOriginalURL(ctx, "https://example.com/dup")
+		require.NoError(t, err)
+		assert.Equal(t, "dupold", url.ShortCode)
+	})
+
+	t.Run("unknown destination", func(t *testing.T) {
+		_, err := repo.GetByOriginalURL(ctx, "https://example.com/none")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+}
+
+func TestPostgresURLRepository_List(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	for _, create := range []*models.URLCreate{
+		{ShortCode: "list1", OriginalURL: "https://example.com/alpha"},
+		{ShortCode: "list2", OriginalURL: "https://example.com/beta"},
+		{ShortCode: "list3", OriginalURL: "https://other.org/alpha", ExpiresAt: &past},
+	} {
+		_, err := repo.Create(ctx, create)
+		require.NoError(t, err)
+	}
+	defer func() {
+		for _, code := range []string{"list1", "list2", "list3"} {
+			_ = repo.DeletePermanent(ctx, code)
+		}
+	}()
+
+	t.Run("paginates newest first", func(t *testing.T) {
+		urls, total, err := repo.List(ctx, 2, 0, ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, urls, 2)
+		assert.Equal(t, "list3", urls[0].ShortCode)
+		assert.Equal(t, "list2", urls[1].ShortCode)
+
+		urls, total, err = repo.List(ctx, 2, 2, ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "list1", urls[0].ShortCode)
+	})
+
+	t.Run("searches short code and URL", func(t *testing.T) {
+		urls, total, err := repo.List(ctx, 10, 0, ListFilter{Search: "ALPHA"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, urls, 2)
+	})
+
+	t.Run("filters by status", func(t *testing.T) {
+		urls, total, err := repo.List(ctx, 10, 0, ListFilter{Status: ListStatusExpired})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "list3", urls[0].ShortCode)
+
+		_, total, err = repo.List(ctx, 10, 0, ListFilter{Status: ListStatusActive})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+	})
+
+	t.Run("filters by creation date", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		urls, total, err := repo.List(ctx, 10, 0, ListFilter{CreatedAfter: &future})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, urls)
+	})
+}
+
+func TestPostgresURLRepository_TopByClicks(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	for _, code := range []string{"top1", "top2", "top3", "top4"} {
+		_, err := repo.Create(ctx, &models.URLCreate{ShortCode: code, OriginalURL: "https://example.com/" + code})
+		require.NoError(t, err)
+	}
+	defer func() {
+		for _, code := range []string{"top1", "top2", "top3", "top4"} {
+			_ = repo.DeletePermanent(ctx, code)
+		}
+	}()
+	_, err := repo.BatchIncrementClickCounts(ctx, map[string]int64{"top1": 3, "top2": 7, "top3": 7, "top4": 50})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, "top4"))
+
+	urls, err := repo.TopByClicks(ctx, 3)
+	require.NoError(t, err)
+
+	// Soft-deleted URLs are left out and ties go to the older URL
+	codes := make([]string, len(urls))
+	for i, url := range urls {
+		codes[i] = url.ShortCode
+	}
+	assert.Equal(t, []string{"top2", "top3", "top1"}, codes)
+	assert.Equal(t, int64(7), urls[0].ClickCount)
+}
+
+func TestPostgresURLRepository_ListAfter(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	codes := []string{"page1", "page2", "page3", "page4", "page5"}
+	for _, code := range codes {
+		_, err := repo.Create(ctx, &models.URLCreate{ShortCode: code, OriginalURL: "https://example.com/" + code})
+		require.NoError(t, err)
+	}
+	defer func() {
+		for _, code := range codes {
+			_ = repo.DeletePermanent(ctx, code)
+		}
+	}()
+	require.NoError(t, repo.Delete(ctx, "page3"))
+
+	t.Run("pages in ID order without gaps or duplicates", func(t *testing.T) {
+		var (
+			seen   []string
+			cursor int64
+			pages  int
+		)
+		for {
+			urls, next, err := repo.ListAfter(ctx, cursor, 2, ListFilter{Search: "page"})
+			require.NoError(t, err)
+			for _, url := range urls {
+				seen = append(seen, url.ShortCode)
+			}
+			pages++
+			if next == 0 {
+				break
+			}
+			assert.Equal(t, urls[len(urls)-1].ID, next)
+			cursor = next
+		}
+
+		// Soft-deleted URLs are left out
+		assert.Equal(t, []string{"page1", "page2", "page4", "page5"}, seen)
+		assert.Equal(t, 2, pages)
+	})
+
+	t.Run("applies filters", func(t *testing.T) {
+		urls, next, err := repo.ListAfter(ctx, 0, 10, ListFilter{Search: "PAGE4"})
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "page4", urls[0].ShortCode)
+		assert.Zero(t, next)
+	})
+}
+
+func TestPostgresURLRepository_Tags(t *testing.T) {
+	skipIfNoPostgres(t)
+
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &models.URLCreate{
+		ShortCode:   "tagged1",
+		OriginalURL: "https://example.com/tagged",
+		Tags:        []string{"promo", "summer"},
+	})
+	require.NoError(t, err)
+	defer func() { _ = repo.DeletePermanent(ctx, "tagged1") }()
+	assert.Equal(t, []string{"promo", "summer"}, created.Tags)
+
+	_, err = repo.Create(ctx, &models.URLCreate{ShortCode: "untagd1", OriginalURL: "https://example.com/untagged"})
+	require.NoError(t, err)
+	defer func() { _ = repo.DeletePermanent(ctx, "untagd1") }()
+
+	urls, total, err := repo.List(ctx, 10, 0, ListFilter{Tag: "summer"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, urls, 1)
+	assert.Equal(t, "tagged1", urls[0].ShortCode)
+}
+
+func TestListFilter_Matches(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	url := &models.URL{
+		ShortCode:   "Promo1",
+		OriginalURL: "https://example.com/summer",
+		CreatedAt:   now.Add(-24 * time.Hour),
+		ExpiresAt:   &past,
+		Tags:        []string{"promo", "summer"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   ListFilter
+		expected bool
+	}{
+		{"empty filter", ListFilter{}, true},
+		{"search short code case-insensitively", ListFilter{Search: "promo"}, true},
+		{"search original URL", ListFilter{Search: "SUMMER"}, true},
+		{"search miss", ListFilter{Search: "winter"}, false},
+		{"created after excludes older URL", ListFilter{CreatedAfter: &past}, false},
+		{"created before later time", ListFilter{CreatedBefore: &future}, true},
+		{"expired status", ListFilter{Status: ListStatusExpired}, true},
+		{"active status", ListFilter{Status: ListStatusActive}, false},
+		{"tag match", ListFilter{Tag: "summer"}, true},
+		{"tag miss", ListFilter{Tag: "winter"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Matches(url, now))
+		})
+	}
+}
+
+func TestListWhereClause(t *testing.T) {
+	now := time.Now()
+
+	where, args := listWhereClause(ListFilter{}, now)
+	assert.Equal(t, " WHERE deleted_at IS NULL", where)
+	assert.Empty(t, args)
+
+	where, args = listWhereClause(ListFilter{Search: "50%_off", CreatedAfter: &now, Status: ListStatusActive}, now)
+	assert.Equal(t, " WHERE deleted_at IS NULL AND (short_code ILIKE $1 OR original_url ILIKE $1) AND created_at >= $2 AND (expires_at IS NULL OR expires_at >= $3)", where)
+	require.Len(t, args, 3)
+	assert.Equal(t, `%50\%\_off%`, args[0])
+
+	where, args = listWhereClause(ListFilter{Tag: "promo"}, now)
+	assert.Equal(t, " WHERE deleted_at IS NULL AND tags @> ARRAY[$1]::text[]", where)
+	assert.Equal(t, []interface{}{"promo"}, args)
+}
+
+// BenchmarkPostgresURLRepository_Insert compares storing 100 URLs one at a
+// time against a single CreateBatch call.
+func BenchmarkPostgresURLRepository_Insert(b *testing.B) {
+	skipIfNoPostgres(b)
+
+	pool, cleanup := setupTestDB(b)
+	defer cleanup()
+
+	repo := NewPostgresURLRepository(pool)
+	ctx := context.Background()
+	const batchSize = 100
+
+	newCreates := func(prefix string, n int) []*models.URLCreate {
+		creates := make([]*models.URLCreate, batchSize)
+		for i := range creates {
+			creates[i] = &models.URLCreate{
+				ShortCode:   fmt.Sprintf("%s%03d%04d", prefix, i, n%10000),
+				OriginalURL: "https://example.com/bench",
+			}
+		}
+		return creates
+	}
+
+	b.Run("single", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			b.StopTimer()
+			_, _ = pool.Exec(ctx, "DELETE FROM urls")
+			creates := newCreates("s", n)
+			b.StartTimer()
+
+			for _, create := range creates {
+				if _, err := repo.Create(ctx, create); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "urls/s")
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			b.StopTimer()
+			_, _ = pool.Exec(ctx, "DELETE FROM urls")
+			creates := newCreates("b", n)
+			b.StartTimer()
+
+			if _, err := repo.CreateBatch(ctx, creates); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "urls/s")
+	})
+}
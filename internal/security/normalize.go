@@ -0,0 +1,98 @@
+package security
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// defaultPorts maps schemes to the port implied when none is given.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ftp":   "21",
+}
+
+// idnaProfile maps international hostnames to punycode for lookup, while still
+// accepting underscores and other characters that resolve in practice.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false))
+
+// ASCIIHost returns the lowercase ASCII form of host, converting international
+// names to punycode. Compatibility characters are mapped first, so fullwidth
+// "ｌｏｃａｌｈｏｓｔ" becomes "localhost". IP literals are returned unchanged.
+func ASCIIHost(host string) (string, error) {
+	host = strings.ToLower(host)
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	return idnaProfile.ToASCII(host)
+}
+
+// NormalizeOptions controls the optional URL normalization steps.
+type NormalizeOptions struct {
+	StripTrailingSlash bool // Remove a trailing slash from non-root paths
+	StripFragment      bool // Drop the #fragment
+}
+
+// Normalize returns the canonical form of rawURL using the default options.
+// See NormalizeWithOptions.
+func Normalize(rawURL string) (string, error) {
+	return NormalizeWithOptions(rawURL, NormalizeOptions{})
+}
+
+// NormalizeWithOptions returns the canonical form of rawURL so that equivalent
+// URLs compare equal. It lowercases the scheme and host, converts international
+// hostnames to punycode, removes the scheme's default port, resolves "." and ".."
+// path segments and uses "/" for an empty path. Query strings are kept as is.
+func NormalizeWithOptions(rawURL string, opts NormalizeOptions) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", ErrInvalidURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, err := ASCIIHost(u.Hostname())
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	} else {
+		// Resolving the path as a reference removes dot segments
+		resolved := u.ResolveReference(&url.URL{Path: u.Path, RawPath: u.RawPath})
+		u.Path, u.RawPath = resolved.Path, resolved.RawPath
+	}
+
+	if opts.StripTrailingSlash && len(u.Path) > 1 && strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimRight(u.Path, "/")
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		u.RawPath = ""
+	}
+
+	if opts.StripFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,113 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"lowercases host", "https://Example.COM/Path", "https://example.com/Path"},
+		{"lowercases scheme", "HTTPS://example.com/", "https://example.com/"},
+		{"empty path becomes root", "https://example.com", "https://example.com/"},
+		{"removes default https port", "https://example.com:443/a", "https://example.com/a"},
+		{"removes default http port", "http://example.com:80/a", "http://example.com/a"},
+		{"keeps non-default port", "https://example.com:8443/a", "https://example.com:8443/a"},
+		{"resolves dot segments", "https://example.com/a/./b/../c", "https://example.com/a/c"},
+		{"resolves leading dot-dot", "https://example.com/../a", "https://example.com/a"},
+		{"keeps trailing slash by default", "https://example.com/a/", "https://example.com/a/"},
+		{"keeps fragment by default", "https://example.com/a#top", "https://example.com/a#top"},
+		{"keeps query", "https://example.com/a?B=1&a=2", "https://example.com/a?B=1&a=2"},
+		{"keeps userinfo", "https://user@Example.com/", "https://user@example.com/"},
+		{"handles IPv6 hosts", "http://[::1]:80/a", "http://[::1]/a"},
+		{"handles IPv6 hosts with port", "http://[2001:DB8::1]:8080/a", "http://[2001:db8::1]:8080/a"},
+		{"keeps underscores in host", "https://my_host.example.com/", "https://my_host.example.com/"},
+		{"trims whitespace", "  https://example.com/a  ", "https://example.com/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalize_Deduplicates(t *testing.T) {
+	a, err := Normalize("https://Example.com/")
+	require.NoError(t, err)
+	b, err := Normalize("https://example.com")
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+}
+
+func TestNormalize_IDN(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"unicode host to punycode", "https://bücher.example/", "https://xn--bcher-kva.example/"},
+		{"uppercase unicode host", "https://BÜCHER.example/", "https://xn--bcher-kva.example/"},
+		{"punycode host unchanged", "https://xn--bcher-kva.example/", "https://xn--bcher-kva.example/"},
+		{"uppercase punycode host lowercased", "https://XN--BCHER-KVA.example/", "https://xn--bcher-kva.example/"},
+		{"non-latin host", "https://例え.jp/path", "https://xn--r8jz45g.jp/path"},
+		{"german host", "https://münchen.de", "https://xn--mnchen-3ya.de/"},
+		{"fullwidth host mapped to ascii", "https://ｅｘａｍｐｌｅ.com/", "https://example.com/"},
+		{"ideographic full stop as separator", "https://example。com/", "https://example.com/"},
+		{"cyrillic lookalike kept distinct", "https://аpple.com/", "https://xn--pple-43d.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalizeWithOptions(t *testing.T) {
+	t.Run("strips trailing slash", func(t *testing.T) {
+		got, err := NormalizeWithOptions("https://example.com/a/b/", NormalizeOptions{StripTrailingSlash: true})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/a/b", got)
+	})
+
+	t.Run("keeps root slash", func(t *testing.T) {
+		got, err := NormalizeWithOptions("https://example.com/", NormalizeOptions{StripTrailingSlash: true})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/", got)
+	})
+
+	t.Run("strips fragment", func(t *testing.T) {
+		got, err := NormalizeWithOptions("https://example.com/a?q=1#section", NormalizeOptions{StripFragment: true})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/a?q=1", got)
+	})
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"example.com/path",
+		"https://",
+		"://example.com",
+		"https://exa mple.com/",
+	}
+
+	for _, raw := range invalid {
+		t.Run(raw, func(t *testing.T) {
+			_, err := Normalize(raw)
+			assert.ErrorIs(t, err, ErrInvalidURL)
+		})
+	}
+}
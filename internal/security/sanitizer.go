@@ -2,10 +2,13 @@
 package security
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/url"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // Sanitization errors
@@ -13,10 +16,12 @@ var (
 	ErrDangerousScheme = errors.New("dangerous URL scheme detected")
 	ErrPrivateIP       = errors.New("private IP addresses not allowed")
 	ErrBlockedHost     = errors.New("host is blocked")
+	ErrHostNotAllowed  = errors.New("host is not in the allowlist")
+	ErrUnresolvable    = errors.New("host could not be resolved")
 	ErrURLTooLong      = errors.New("URL exceeds maximum length")
 	ErrInvalidURL      = errors.New("invalid URL format")
 	ErrEmptyURL        = errors.New("URL cannot be empty")
-	ErrInvalidScheme   = errors.New("URL must use http or https scheme")
+	ErrInvalidScheme   = errors.New("URL scheme is not allowed")
 )
 
 // dangerousSchemes contains URL schemes that can execute code.
@@ -32,38 +37,88 @@ type Config struct {
 	MaxURLLength    int      // Maximum allowed URL length
 	AllowPrivateIPs bool     // Allow localhost, 10.x, 192.168.x, etc.
 	BlockedHosts    []string // Explicitly blocked hostnames
+	AllowedHosts    []string // If non-empty, only these hostnames (and subdomains) are allowed
+	AllowedSchemes  []string // Accepted URL schemes (default: http, https); dangerous schemes are always rejected
+
+	// ResolveHosts resolves hostnames and rejects those with any private address,
+	// guarding against DNS names that point inside the network. It only applies
+	// when AllowPrivateIPs is false and adds a DNS lookup to every validation.
+	ResolveHosts   bool
+	ResolveTimeout time.Duration // Lookup timeout (default: 2s)
+	Resolver       Resolver      // Defaults to net.DefaultResolver
+}
+
+// Resolver looks up the IP addresses of a host. *net.Resolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
 }
 
+// defaultResolveTimeout bounds host lookups when Config.ResolveTimeout is unset.
+const defaultResolveTimeout = 2 * time.Second
+
 // DefaultConfig returns the default sanitizer configuration.
 func DefaultConfig() Config {
 	return Config{
 		MaxURLLength:    2048,
 		AllowPrivateIPs: false,
 		BlockedHosts:    nil,
+		AllowedHosts:    nil,
+		AllowedSchemes:  []string{"http", "https"},
 	}
 }
 
 // Sanitizer validates and sanitizes URLs.
 type Sanitizer struct {
-	config       Config
-	blockedHosts map[string]bool
+	config         Config
+	blockedHosts   map[string]bool
+	allowedHosts   map[string]bool
+	allowedSchemes map[string]bool
 }
 
 // NewSanitizer creates a new URL sanitizer.
 func NewSanitizer(cfg Config) *Sanitizer {
 	blockedHosts := make(map[string]bool)
 	for _, host := range cfg.BlockedHosts {
-		blockedHosts[strings.ToLower(host)] = true
+		blockedHosts[configuredHost(host)] = true
+	}
+
+	allowedHosts := make(map[string]bool)
+	for _, host := range cfg.AllowedHosts {
+		allowedHosts[configuredHost(host)] = true
+	}
+
+	schemes := cfg.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = DefaultConfig().AllowedSchemes
+	}
+	allowedSchemes := make(map[string]bool)
+	for _, scheme := range schemes {
+		allowedSchemes[strings.ToLower(scheme)] = true
+	}
+
+	if cfg.ResolveTimeout <= 0 {
+		cfg.ResolveTimeout = defaultResolveTimeout
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = net.DefaultResolver
 	}
 
 	return &Sanitizer{
-		config:       cfg,
-		blockedHosts: blockedHosts,
+		config:         cfg,
+		blockedHosts:   blockedHosts,
+		allowedHosts:   allowedHosts,
+		allowedSchemes: allowedSchemes,
 	}
 }
 
 // Validate checks if a URL is safe and valid.
 func (s *Sanitizer) Validate(rawURL string) error {
+	return s.ValidateContext(context.Background(), rawURL)
+}
+
+// ValidateContext checks if a URL is safe and valid. The context bounds the
+// host lookup performed when ResolveHosts is enabled.
+func (s *Sanitizer) ValidateContext(ctx context.Context, rawURL string) error {
 	// Check for empty URL
 	rawURL = strings.TrimSpace(rawURL)
 	if rawURL == "" {
@@ -87,24 +142,33 @@ func (s *Sanitizer) Validate(rawURL string) error {
 		return ErrInvalidScheme
 	}
 
-	// Check for dangerous schemes
+	// Check for dangerous schemes, even if configured as allowed
 	if dangerousSchemes[scheme] {
 		return ErrDangerousScheme
 	}
 
-	// Only allow http and https
-	if scheme != "http" && scheme != "https" {
+	// Only allow configured schemes
+	if !s.allowedSchemes[scheme] {
 		return ErrInvalidScheme
 	}
 
-	// Check host
-	host := strings.ToLower(u.Hostname())
-	if host == "" {
+	// Check host. Checks run on the ASCII form so an international or
+	// fullwidth spelling cannot slip past the blocklist or private IP checks.
+	if u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	host, err := ASCIIHost(u.Hostname())
+	if err != nil || host == "" {
 		return ErrInvalidURL
 	}
 
-	// Check for blocked hosts
-	if s.isBlockedHost(host) {
+	// An allowlist takes precedence over the blocklist: when configured, only
+	// allowlisted hosts pass, and the blocklist is not consulted for them
+	if len(s.allowedHosts) > 0 {
+		if !matchesHost(s.allowedHosts, host) {
+			return ErrHostNotAllowed
+		}
+	} else if s.isBlockedHost(host) {
 		return ErrBlockedHost
 	}
 
@@ -113,15 +177,58 @@ func (s *Sanitizer) Validate(rawURL string) error {
 		if isPrivateHost(host) {
 			return ErrPrivateIP
 		}
+		if s.config.ResolveHosts {
+			if err := s.checkResolvedHost(ctx, host); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// checkResolvedHost resolves host and rejects it if any address is private.
+// IP literals were already checked by isPrivateHost and are not looked up.
+func (s *Sanitizer) checkResolvedHost(ctx context.Context, host string) error {
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.ResolveTimeout)
+	defer cancel()
+
+	addrs, err := s.config.Resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvable
+	}
+
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP.String()) {
+			return ErrPrivateIP
+		}
+	}
+
+	return nil
+}
+
+// configuredHost returns the ASCII form of a blocklist or allowlist entry,
+// so entries may be written in either Unicode or punycode.
+func configuredHost(host string) string {
+	if ascii, err := ASCIIHost(host); err == nil {
+		return ascii
+	}
+	return strings.ToLower(host)
+}
+
 // isBlockedHost checks if a host or any of its parent domains is blocked.
 func (s *Sanitizer) isBlockedHost(host string) bool {
+	return matchesHost(s.blockedHosts, host)
+}
+
+// matchesHost checks if a host or any of its parent domains is in hosts.
+func matchesHost(hosts map[string]bool, host string) bool {
 	// Check exact match
-	if s.blockedHosts[host] {
+	if hosts[host] {
 		return true
 	}
 
@@ -129,7 +236,7 @@ func (s *Sanitizer) isBlockedHost(host string) bool {
 	parts := strings.Split(host, ".")
 	for i := 1; i < len(parts); i++ {
 		parent := strings.Join(parts[i:], ".")
-		if s.blockedHosts[parent] {
+		if hosts[parent] {
 			return true
 		}
 	}
@@ -137,6 +244,21 @@ func (s *Sanitizer) isBlockedHost(host string) bool {
 	return false
 }
 
+// PublicDialControl is a net.Dialer Control function that refuses to
+// connect to the addresses the sanitizer rejects as private. Checking the
+// address actually dialed, rather than the URL, also covers redirects and
+// hostnames that resolve, or are rebound, to internal addresses.
+func PublicDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if isPrivateIP(host) {
+		return ErrPrivateIP
+	}
+	return nil
+}
+
 // isPrivateHost checks if a host is a private/local address.
 func isPrivateHost(host string) bool {
 	// Check for localhost
@@ -1,6 +1,9 @@
 package security
 
 import (
+	"context"
+	"errors"
+	"net"
 	"strings"
 	"testing"
 
@@ -223,12 +226,88 @@ func TestSanitizer_BlockedHosts(t *testing.T) {
 	})
 }
 
+func TestSanitizer_AllowedHosts(t *testing.T) {
+	t.Run("allows listed hosts and subdomains", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			AllowedHosts: []string{"Example.com", "tools.internal.io"},
+		})
+
+		assert.NoError(t, sanitizer.Validate("https://example.com/path"))
+		assert.NoError(t, sanitizer.Validate("https://docs.example.com/path"))
+		assert.NoError(t, sanitizer.Validate("https://tools.internal.io/dash"))
+	})
+
+	t.Run("rejects hosts outside the allowlist", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			AllowedHosts: []string{"example.com"},
+		})
+
+		assert.ErrorIs(t, sanitizer.Validate("https://other.com/path"), ErrHostNotAllowed)
+		assert.ErrorIs(t, sanitizer.Validate("https://notexample.com/path"), ErrHostNotAllowed)
+		assert.ErrorIs(t, sanitizer.Validate("https://example.com.evil.net/path"), ErrHostNotAllowed)
+	})
+
+	t.Run("takes precedence over the blocklist", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			AllowedHosts: []string{"example.com"},
+			BlockedHosts: []string{"example.com", "evil.com"},
+		})
+
+		assert.NoError(t, sanitizer.Validate("https://example.com/path"))
+		assert.ErrorIs(t, sanitizer.Validate("https://evil.com/path"), ErrHostNotAllowed)
+	})
+
+	t.Run("still rejects private IPs", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			AllowedHosts: []string{"localhost"},
+		})
+
+		assert.ErrorIs(t, sanitizer.Validate("http://localhost/admin"), ErrPrivateIP)
+	})
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 
 	assert.Equal(t, 2048, cfg.MaxURLLength)
 	assert.False(t, cfg.AllowPrivateIPs)
 	assert.Empty(t, cfg.BlockedHosts)
+	assert.Equal(t, []string{"http", "https"}, cfg.AllowedSchemes)
+}
+
+func TestSanitizer_AllowedSchemes(t *testing.T) {
+	t.Run("defaults to http and https", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{MaxURLLength: 2048})
+
+		assert.NoError(t, sanitizer.Validate("https://example.com"))
+		assert.NoError(t, sanitizer.Validate("http://example.com"))
+		assert.ErrorIs(t, sanitizer.Validate("ftp://example.com"), ErrInvalidScheme)
+	})
+
+	t.Run("accepts configured schemes case-insensitively", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength:   2048,
+			AllowedSchemes: []string{"HTTPS", "ftp"},
+		})
+
+		assert.NoError(t, sanitizer.Validate("https://example.com"))
+		assert.NoError(t, sanitizer.Validate("FTP://files.example.com/pub"))
+		assert.ErrorIs(t, sanitizer.Validate("http://example.com"), ErrInvalidScheme)
+	})
+
+	t.Run("still rejects dangerous schemes", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength:   2048,
+			AllowedSchemes: []string{"https", "javascript", "file"},
+		})
+
+		assert.ErrorIs(t, sanitizer.Validate("javascript:alert(1)"), ErrDangerousScheme)
+		assert.ErrorIs(t, sanitizer.Validate("FILE:///etc/passwd"), ErrDangerousScheme)
+	})
 }
 
 func TestIsPrivateIP(t *testing.T) {
@@ -285,3 +364,196 @@ func TestSanitizer_IntegrationWithModels(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+// fakeResolver is a Resolver returning canned addresses per host.
+type fakeResolver struct {
+	addrs map[string][]string
+	err   error
+	calls int
+}
+
+func (f *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []net.IPAddr
+	for _, a := range f.addrs[host] {
+		result = append(result, net.IPAddr{IP: net.ParseIP(a)})
+	}
+	return result, nil
+}
+
+func TestSanitizer_ResolveHosts(t *testing.T) {
+	resolver := &fakeResolver{addrs: map[string][]string{
+		"public.example.com":   {"93.184.216.34"},
+		"internal.attacker.io": {"192.168.1.10"},
+		"mixed.attacker.io":    {"93.184.216.34", "127.0.0.1"},
+		"v6.attacker.io":       {"fe80::1"},
+	}}
+	sanitizer := NewSanitizer(Config{
+		MaxURLLength: 2048,
+		ResolveHosts: true,
+		Resolver:     resolver,
+	})
+
+	t.Run("allows hosts resolving to public addresses", func(t *testing.T) {
+		assert.NoError(t, sanitizer.Validate("https://public.example.com/path"))
+	})
+
+	t.Run("rejects hosts resolving to private addresses", func(t *testing.T) {
+		assert.ErrorIs(t, sanitizer.Validate("https://internal.attacker.io/"), ErrPrivateIP)
+		assert.ErrorIs(t, sanitizer.Validate("https://mixed.attacker.io/"), ErrPrivateIP)
+		assert.ErrorIs(t, sanitizer.Validate("https://v6.attacker.io/"), ErrPrivateIP)
+	})
+
+	t.Run("rejects hosts that do not resolve", func(t *testing.T) {
+		assert.ErrorIs(t, sanitizer.Validate("https://nothing.example.com/"), ErrUnresolvable)
+	})
+
+	t.Run("does not resolve IP literals", func(t *testing.T) {
+		before := resolver.calls
+		assert.NoError(t, sanitizer.Validate("https://93.184.216.34/"))
+		assert.Equal(t, before, resolver.calls)
+	})
+
+	t.Run("reports lookup errors as unresolvable", func(t *testing.T) {
+		failing := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			ResolveHosts: true,
+			Resolver:     &fakeResolver{err: errors.New("lookup timeout")},
+		})
+		assert.ErrorIs(t, failing.Validate("https://public.example.com/"), ErrUnresolvable)
+	})
+
+	t.Run("is skipped when private IPs are allowed", func(t *testing.T) {
+		resolver := &fakeResolver{}
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength:    2048,
+			AllowPrivateIPs: true,
+			ResolveHosts:    true,
+			Resolver:        resolver,
+		})
+		assert.NoError(t, sanitizer.Validate("https://internal.attacker.io/"))
+		assert.Zero(t, resolver.calls)
+	})
+
+	t.Run("is off by default", func(t *testing.T) {
+		resolver := &fakeResolver{}
+		sanitizer := NewSanitizer(Config{MaxURLLength: 2048, Resolver: resolver})
+		assert.NoError(t, sanitizer.Validate("https://internal.attacker.io/"))
+		assert.Zero(t, resolver.calls)
+	})
+
+	t.Run("passes a bounded context to the resolver", func(t *testing.T) {
+		var deadline bool
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			ResolveHosts: true,
+			Resolver: resolverFunc(func(ctx context.Context, host string) ([]net.IPAddr, error) {
+				_, deadline = ctx.Deadline()
+				return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+			}),
+		})
+		require.NoError(t, sanitizer.ValidateContext(context.Background(), "https://public.example.com/"))
+		assert.True(t, deadline)
+	})
+}
+
+// resolverFunc adapts a function to the Resolver interface.
+type resolverFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+func (f resolverFunc) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return f(ctx, host)
+}
+
+func TestSanitizer_IDNHosts(t *testing.T) {
+	t.Run("accepts international hosts", func(t *testing.T) {
+		sanitizer := NewSanitizer(DefaultConfig())
+
+		for _, rawURL := range []string{
+			"https://münchen.de/",
+			"https://xn--mnchen-3ya.de/",
+			"https://例え.jp/path",
+			"https://пример.рф/",
+			"https://ÄPFEL.example/",
+		} {
+			assert.NoError(t, sanitizer.Validate(rawURL), rawURL)
+		}
+	})
+
+	t.Run("blocklist matches either spelling", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			BlockedHosts: []string{"münchen.de", "xn--bcher-kva.example"},
+		})
+
+		assert.ErrorIs(t, sanitizer.Validate("https://münchen.de/"), ErrBlockedHost)
+		assert.ErrorIs(t, sanitizer.Validate("https://xn--mnchen-3ya.de/"), ErrBlockedHost)
+		assert.ErrorIs(t, sanitizer.Validate("https://MÜNCHEN.de/"), ErrBlockedHost)
+		assert.ErrorIs(t, sanitizer.Validate("https://sub.münchen.de/"), ErrBlockedHost)
+		assert.ErrorIs(t, sanitizer.Validate("https://bücher.example/"), ErrBlockedHost)
+	})
+
+	t.Run("allowlist matches either spelling", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			AllowedHosts: []string{"xn--mnchen-3ya.de"},
+		})
+
+		assert.NoError(t, sanitizer.Validate("https://münchen.de/"))
+		assert.ErrorIs(t, sanitizer.Validate("https://munchen.de/"), ErrHostNotAllowed)
+	})
+
+	t.Run("fullwidth spellings cannot bypass private IP checks", func(t *testing.T) {
+		sanitizer := NewSanitizer(DefaultConfig())
+
+		assert.ErrorIs(t, sanitizer.Validate("http://ｌｏｃａｌｈｏｓｔ/admin"), ErrPrivateIP)
+		assert.ErrorIs(t, sanitizer.Validate("http://１２７.０.０.１/admin"), ErrPrivateIP)
+		assert.ErrorIs(t, sanitizer.Validate("http://１９２。１６８。１。１/"), ErrPrivateIP)
+	})
+
+	t.Run("fullwidth spellings cannot bypass the blocklist", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			BlockedHosts: []string{"evil.com"},
+		})
+
+		assert.ErrorIs(t, sanitizer.Validate("https://ｅｖｉｌ.com/"), ErrBlockedHost)
+		assert.ErrorIs(t, sanitizer.Validate("https://EVIL．com/"), ErrBlockedHost)
+	})
+
+	t.Run("mixed-script homographs are distinct hosts", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			BlockedHosts: []string{"apple.com"},
+		})
+
+		// Cyrillic "а" looks like Latin "a" but is a different domain
+		assert.NoError(t, sanitizer.Validate("https://аpple.com/"))
+
+		// Blocking the lookalike needs its punycode or Unicode form
+		lookalike := NewSanitizer(Config{
+			MaxURLLength: 2048,
+			BlockedHosts: []string{"xn--pple-43d.com"},
+		})
+		assert.ErrorIs(t, lookalike.Validate("https://аpple.com/"), ErrBlockedHost)
+		assert.NoError(t, lookalike.Validate("https://apple.com/"))
+	})
+
+	t.Run("rejects hosts without a valid punycode form", func(t *testing.T) {
+		sanitizer := NewSanitizer(DefaultConfig())
+
+		assert.ErrorIs(t, sanitizer.Validate("https://xn--a.example/"), ErrInvalidURL)
+		assert.ErrorIs(t, sanitizer.Validate("https://a\u200eb.example/"), ErrInvalidURL) // Bidi control character
+	})
+}
+
+func TestPublicDialControl(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:80", "10.1.2.3:443", "169.254.169.254:80", "[::1]:8080", "[::ffff:192.168.0.1]:80"} {
+		assert.ErrorIs(t, PublicDialControl("tcp", address, nil), ErrPrivateIP, address)
+	}
+	assert.NoError(t, PublicDialControl("tcp", "8.8.8.8:443", nil))
+	assert.NoError(t, PublicDialControl("tcp6", "[2001:4860:4860::8888]:443", nil))
+	assert.Error(t, PublicDialControl("tcp", "no-port", nil))
+}
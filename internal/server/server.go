@@ -6,15 +6,19 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/pprof"
+	"os"
 	"strings"
 	"sync"
 
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/cache"
 	"github.com/emadnahed/FastGoLink/internal/config"
 	"github.com/emadnahed/FastGoLink/internal/handlers"
-	"github.com/emadnahed/FastGoLink/internal/metrics"
 	"github.com/emadnahed/FastGoLink/internal/middleware"
 	"github.com/emadnahed/FastGoLink/internal/ratelimit"
 	"github.com/emadnahed/FastGoLink/internal/repository"
+	"github.com/emadnahed/FastGoLink/internal/version"
 	"github.com/emadnahed/FastGoLink/pkg/logger"
 )
 
@@ -24,12 +28,17 @@ type Server struct {
 	log              *logger.Logger
 	httpServer       *http.Server
 	healthHandler    *handlers.HealthHandler
+	infoHandler      *handlers.InfoHandler
 	urlHandler       *handlers.URLHandler
+	qrHandler        *handlers.QRHandler
 	redirectHandler  *handlers.RedirectHandler
 	analyticsHandler *handlers.AnalyticsHandler
 	docsHandler      *handlers.DocsHandler
+	metricsHandler   *handlers.MetricsHandler
 	urlRepo          repository.URLRepository
+	clickCounter     *analytics.ClickCounter
 	rateLimiter      ratelimit.Limiter
+	routeLimiters    []ratelimit.Limiter
 	listener         net.Listener
 	running          bool
 	mu               sync.RWMutex
@@ -41,7 +50,11 @@ func New(cfg *config.Config, log *logger.Logger) *Server {
 		cfg:           cfg,
 		log:           log,
 		healthHandler: handlers.NewHealthHandler(),
-		docsHandler:   handlers.NewDocsHandler(cfg.URL.BaseURL, "", log),
+		infoHandler:   handlers.NewInfoHandler(version.Get()),
+		docsHandler:   handlers.NewDocsHandler(cfg.PublicBaseURL(), "", log),
+	}
+	if cfg.Metrics.Enabled {
+		s.metricsHandler = handlers.NewMetricsHandler()
 	}
 
 	// Create HTTP server
@@ -49,56 +62,236 @@ func New(cfg *config.Config, log *logger.Logger) *Server {
 	s.registerRoutes(mux)
 
 	// Build middleware chain
-	handler := s.buildMiddlewareChain(mux)
+	handler := s.withBasePath(s.buildMiddlewareChain(mux))
 
 	s.httpServer = &http.Server{
-		Addr:         cfg.Server.Address(),
-		Handler:      handler,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
+		Addr:              cfg.Server.Address(),
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	if cfg.Server.H2CEnabled {
+		// Serve cleartext HTTP/2 for clients using prior knowledge, typically
+		// a TLS-terminating proxy in front of the service.
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.httpServer.Protocols = protocols
 	}
 
 	return s
 }
 
+// withBasePath serves h under the configured base path, stripping the prefix
+// so routing and middleware see the usual paths. Other requests get 404,
+// except for health, readiness, build info, metrics and profiling, which
+// also stay at the root where probes and scrapers reach the instance directly.
+func (s *Server) withBasePath(h http.Handler) http.Handler {
+	base := s.cfg.Server.BasePath
+	if base == "" {
+		return h
+	}
+
+	stripped := http.StripPrefix(base, h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case path == base || strings.HasPrefix(path, base+"/"):
+			stripped.ServeHTTP(w, r)
+		case path == "/health" || path == "/ready" || path == "/info" || path == "/metrics" || strings.HasPrefix(path, "/debug/pprof/"):
+			h.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
 // buildMiddlewareChain creates the middleware chain for the server.
 func (s *Server) buildMiddlewareChain(handler http.Handler) http.Handler {
-	// Start with metrics and request ID middleware (always enabled)
-	chain := middleware.New(
-		middleware.Metrics(),
+	// Start the root span first if tracing is enabled, so it covers the whole request
+	chain := middleware.New()
+	if s.cfg.Tracing.Enabled {
+		chain = chain.Append(middleware.Tracing())
+	}
+
+	// Record request metrics if enabled, so every response is counted
+	if s.metricsHandler != nil {
+		chain = chain.Append(middleware.Metrics())
+	}
+
+	// Security headers are set before anything can write a response
+	if s.cfg.Security.Headers {
+		chain = chain.Append(middleware.SecurityHeaders(middleware.SecurityHeadersConfig{
+			FrameOptions:              s.cfg.Security.FrameOptions,
+			ReferrerPolicy:            s.cfg.Security.ReferrerPolicy,
+			ContentSecurityPolicy:     s.cfg.Security.ContentSecurityPolicy,
+			DocsPrefix:                "/docs",
+			DocsContentSecurityPolicy: s.cfg.Security.DocsContentSecurityPolicy,
+		}))
+	}
+
+	// Request ID and client IP middleware are always enabled, and so is the
+	// request-scoped logger that tags service log lines with the request ID
+	chain = chain.Append(
 		middleware.RequestID(),
-		middleware.ClientIP(s.cfg.Rate.TrustProxy, nil),
+		middleware.ClientIP(s.cfg.Rate.TrustProxy, s.cfg.Rate.TrustedProxiesList()),
+		middleware.ContextLogger(s.log),
 	)
 
+	// Error rendering is chosen before any middleware or handler can fail
+	if s.cfg.Server.ErrorFormat != "" {
+		chain = chain.Append(middleware.ErrorFormatter(middleware.ErrorFormat(s.cfg.Server.ErrorFormat)))
+	}
+
+	// Access logging reads the request ID and client IP, so it runs after them
+	if s.cfg.App.AccessLog {
+		chain = chain.Append(middleware.AccessLog(s.log))
+	}
+
+	// Require an API key on write endpoints when keys are configured. This runs
+	// before rate limiting so the limiter keys on the authenticated identity.
+	if keys := s.cfg.Security.APIKeysList(); len(keys) > 0 {
+		chain = chain.Append(middleware.APIKeyAuth(middleware.NewStaticKeyStore(keys), middleware.APIKeyConfig{
+			Header:    s.cfg.Rate.APIKeyHeader,
+			Protected: s.isProtectedRequest,
+		}))
+
+		s.log.Info("API key authentication enabled",
+			"header", s.cfg.Rate.APIKeyHeader,
+			"keys", len(keys),
+		)
+	}
+
 	// Add rate limiting if enabled
 	if s.cfg.Rate.Enabled {
-		s.rateLimiter = ratelimit.NewMemoryLimiter(ratelimit.Config{
-			Requests: s.cfg.Rate.Requests,
-			Window:   s.cfg.Rate.Window,
-		})
+		s.rateLimiter = s.newRateLimiter(ratelimit.Config{
+			Requests:   s.cfg.Rate.Requests,
+			Window:     s.cfg.Rate.Window,
+			BurstSize:  s.cfg.Rate.BurstSize,
+			RefillRate: s.cfg.Rate.RefillRate,
+		}, "ratelimit:")
+
+		// Per-route limiters derive burst and refill from their own window
+		routes := make([]middleware.RouteLimit, 0, len(s.cfg.Rate.Routes))
+		for _, route := range s.cfg.Rate.Routes {
+			limiter := s.newRateLimiter(ratelimit.Config{
+				Requests: route.Requests,
+				Window:   route.Window,
+			}, "ratelimit:"+route.Prefix+":")
+			s.routeLimiters = append(s.routeLimiters, limiter)
+			routes = append(routes, middleware.RouteLimit{Prefix: route.Prefix, Limiter: limiter})
+
+			s.log.Info("route rate limit configured",
+				"prefix", route.Prefix,
+				"requests", route.Requests,
+				"window", route.Window.String(),
+			)
+		}
 
 		chain = chain.Append(middleware.RateLimit(s.rateLimiter, middleware.RateLimitConfig{
-			TrustProxy:   s.cfg.Rate.TrustProxy,
-			APIKeyHeader: s.cfg.Rate.APIKeyHeader,
+			TrustProxy:     s.cfg.Rate.TrustProxy,
+			APIKeyHeader:   s.cfg.Rate.APIKeyHeader,
+			TrustedProxies: s.cfg.Rate.TrustedProxiesList(),
+			AllowlistCIDRs: s.cfg.Rate.AllowlistCIDRsList(),
+			Routes:         routes,
 		}))
 
 		s.log.Info("rate limiting enabled",
+			"backend", s.cfg.Rate.Backend,
+			"algorithm", s.cfg.Rate.Algorithm,
 			"requests", s.cfg.Rate.Requests,
 			"window", s.cfg.Rate.Window.String(),
 		)
 	}
 
+	// The request deadline comes last so it only covers the handler itself
+	if s.cfg.Server.RequestTimeout > 0 {
+		chain = chain.Append(unlessStreaming(middleware.Timeout(s.cfg.Server.RequestTimeout)))
+	}
+
 	return chain.Then(handler)
 }
 
+// unlessStreaming applies m to every request except those to endpoints that
+// stream long responses: the URL export and the profiling endpoints.
+func unlessStreaming(m middleware.Middleware) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := m(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/urls/export" || strings.HasPrefix(r.URL.Path, "/debug/pprof/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isProtectedRequest reports whether r targets a write endpoint of the URL API,
+// the URL listing or export, which expose every link, or a profiling endpoint, or the
+// build info when configured to require a key. Redirects and other read
+// endpoints, including the batch analytics lookup which only uses POST to
+// carry its list of codes, stay public.
+func (s *Server) isProtectedRequest(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/debug/pprof/") {
+		return true
+	}
+	if r.URL.Path == "/info" {
+		return s.cfg.Security.InfoRequiresKey
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		return false
+	}
+	switch r.Method {
+	case http.MethodPost:
+		return r.URL.Path != "/api/v1/analytics/batch"
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	case http.MethodGet:
+		return r.URL.Path == "/api/v1/urls" || r.URL.Path == "/api/v1/urls/export"
+	}
+	return false
+}
+
+// newRateLimiter creates the limiter for the configured backend and algorithm.
+// The Redis backend shares a sliding window across replicas; connection failures
+// surface as limiter errors, which the middleware treats as fail-open.
+// The memory backend uses either a sliding window or a token bucket.
+func (s *Server) newRateLimiter(limiterCfg ratelimit.Config, keyPrefix string) ratelimit.Limiter {
+	if s.cfg.Rate.Backend == "redis" {
+		// Fail fast instead of retrying; the middleware lets the request through
+		client, err := cache.NewRedisClient(&s.cfg.Redis, -1)
+		if err == nil {
+			return ratelimit.NewRedisLimiter(client, keyPrefix, limiterCfg)
+		}
+		s.log.Error("invalid Redis configuration, using in-memory rate limiter", "error", err.Error())
+	}
+
+	if s.cfg.Rate.Algorithm == "token_bucket" {
+		return ratelimit.NewTokenBucketLimiter(limiterCfg)
+	}
+
+	return ratelimit.NewMemoryLimiter(limiterCfg)
+}
+
 // registerRoutes sets up the HTTP routes.
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Health check routes (GET only)
 	mux.HandleFunc("GET /health", s.healthHandler.Health)
 	mux.HandleFunc("GET /ready", s.healthHandler.Ready)
+	mux.HandleFunc("GET /info", s.infoHandler.Info)
 
 	// Metrics endpoint for Prometheus
-	mux.Handle("GET /metrics", metrics.Handler())
+	if s.metricsHandler != nil {
+		mux.HandleFunc("GET /metrics", s.metricsHandler.Metrics)
+	}
+
+	// Profiling endpoints, only when explicitly enabled
+	if s.cfg.Debug.PprofEnabled {
+		s.registerPprofRoutes(mux)
+	}
 
 	// API Documentation routes (Scalar, ReDoc, Swagger UI)
 	// Register specific routes first, then general prefix-based routes
@@ -108,17 +301,69 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /docs/", s.docsHandler.ScalarUI) // Default to Scalar UI for other /docs/* paths
 	mux.HandleFunc("GET /docs", s.docsHandler.ScalarUI)
 
+	// Write endpoints accept request bodies, so cap their size
+	limitBody := middleware.New()
+	if s.cfg.Security.MaxBodyBytes > 0 {
+		limitBody = limitBody.Append(middleware.MaxBodyBytes(s.cfg.Security.MaxBodyBytes))
+	}
+
 	// API v1 routes - URL shortening
-	mux.HandleFunc("POST /api/v1/shorten", s.handleShorten)
+	mux.Handle("POST /api/v1/shorten", limitBody.ThenFunc(s.handleShorten))
+	mux.Handle("POST /api/v1/shorten/batch", limitBody.ThenFunc(s.handleShortenBatch))
+	mux.HandleFunc("GET /api/v1/urls", s.handleListURLs)
+	mux.HandleFunc("GET /api/v1/urls/export", s.handleExportURLs)
+	mux.Handle("POST /api/v1/urls/import", limitBody.ThenFunc(s.handleImportURLs))
 	mux.HandleFunc("GET /api/v1/urls/", s.handleGetURL)
+	mux.HandleFunc("GET /api/v1/urls/{code}/qr", s.handleQRCode)
+	mux.Handle("PATCH /api/v1/urls/", limitBody.ThenFunc(s.handleUpdateURL))
 	mux.HandleFunc("DELETE /api/v1/urls/", s.handleDeleteURL)
+	mux.HandleFunc("POST /api/v1/urls/{code}/restore", s.handleRestoreURL)
+	mux.Handle("POST /api/v1/urls/{code}/extend", limitBody.ThenFunc(s.handleExtendURL))
+	mux.Handle("POST /api/v1/urls/{code}/aliases", limitBody.ThenFunc(s.handleCreateAlias))
+	mux.HandleFunc("POST /api/v1/urls/{code}/disable", s.handleDisableURL)
+	mux.HandleFunc("POST /api/v1/urls/{code}/enable", s.handleEnableURL)
+	mux.Handle("POST /api/v1/urls/batch-delete", limitBody.ThenFunc(s.handleDeleteBatch))
+
+	// Admin routes
+	mux.HandleFunc("POST /api/v1/admin/purge-expired", s.handlePurgeExpired)
 
 	// Analytics routes
 	mux.HandleFunc("GET /api/v1/analytics/", s.handleAnalytics)
+	mux.HandleFunc("GET /api/v1/analytics/{code}/timeseries", s.handleTimeSeries)
+	mux.HandleFunc("GET /api/v1/analytics/{code}/referrers", s.handleReferrers)
+	mux.HandleFunc("GET /api/v1/analytics/{code}/agents", s.handleAgents)
+	mux.HandleFunc("GET /api/v1/analytics/{code}/geo", s.handleCountries)
+	mux.HandleFunc("GET /api/v1/analytics/top", s.handleTopURLs)
+	mux.Handle("POST /api/v1/analytics/batch", limitBody.ThenFunc(s.handleStatsBatch))
 
 	// Redirect route - GET /{code} for URL redirects
 	// Note: More specific routes like /health, /ready are matched first by Go's ServeMux
 	mux.HandleFunc("GET /{code}", s.handleRedirect)
+	mux.HandleFunc("GET /{$}", s.handleRoot)
+	// Any request no other route matches. Registered for every method so
+	// unknown paths are 404 rather than 405 for methods other than GET.
+	mux.HandleFunc("/", s.handleNotFound)
+	// POST /{code} submits the password form of a protected link
+	mux.Handle("POST /{code}", limitBody.ThenFunc(s.handleRedirect))
+}
+
+// registerPprofRoutes serves the net/http/pprof handlers under /debug/pprof/.
+// They are registered on the server's own mux rather than
+// http.DefaultServeMux, so they pass through the middleware chain and
+// require an API key when keys are configured.
+func (s *Server) registerPprofRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index) // Also serves named profiles such as heap and goroutine
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+
+	if len(s.cfg.Security.APIKeysList()) == 0 {
+		s.log.Warn("pprof endpoints enabled without API keys; /debug/pprof/ is publicly reachable")
+	} else {
+		s.log.Info("pprof endpoints enabled", "path", "/debug/pprof/")
+	}
 }
 
 // handleShorten routes to the URL handler for shortening.
@@ -130,6 +375,42 @@ func (s *Server) handleShorten(w http.ResponseWriter, r *http.Request) {
 	s.urlHandler.Shorten(w, r)
 }
 
+// handleShortenBatch routes to the URL handler for batch shortening.
+func (s *Server) handleShortenBatch(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.ShortenBatch(w, r)
+}
+
+// handleListURLs routes to the URL handler for listing URLs.
+func (s *Server) handleListURLs(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.ListURLs(w, r)
+}
+
+// handleExportURLs routes to the URL handler for exporting URLs.
+func (s *Server) handleExportURLs(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.ExportURLs(w, r)
+}
+
+// handleImportURLs routes to the URL handler for importing URLs.
+func (s *Server) handleImportURLs(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.ImportURLs(w, r)
+}
+
 // handleGetURL routes to the URL handler for getting URL info.
 func (s *Server) handleGetURL(w http.ResponseWriter, r *http.Request) {
 	if s.urlHandler == nil {
@@ -144,6 +425,29 @@ func (s *Server) handleGetURL(w http.ResponseWriter, r *http.Request) {
 	s.urlHandler.GetURL(w, r, shortCode)
 }
 
+// handleQRCode routes to the QR handler for rendering QR codes.
+func (s *Server) handleQRCode(w http.ResponseWriter, r *http.Request) {
+	if s.qrHandler == nil {
+		http.Error(w, "QR service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.qrHandler.QRCode(w, r, r.PathValue("code"))
+}
+
+// handleUpdateURL routes to the URL handler for changing a URL's destination.
+func (s *Server) handleUpdateURL(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	shortCode := extractShortCode(r.URL.Path, "/api/v1/urls/")
+	if shortCode == "" || strings.Contains(shortCode, "/") {
+		http.Error(w, "invalid short code format", http.StatusBadRequest)
+		return
+	}
+	s.urlHandler.UpdateURL(w, r, shortCode)
+}
+
 // handleDeleteURL routes to the URL handler for deleting URLs.
 func (s *Server) handleDeleteURL(w http.ResponseWriter, r *http.Request) {
 	if s.urlHandler == nil {
@@ -158,6 +462,69 @@ func (s *Server) handleDeleteURL(w http.ResponseWriter, r *http.Request) {
 	s.urlHandler.DeleteURL(w, r, shortCode)
 }
 
+// handleDeleteBatch routes to the URL handler for batch deletion.
+func (s *Server) handleDeleteBatch(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.DeleteBatch(w, r)
+}
+
+// handlePurgeExpired routes to the URL handler for purging expired URLs.
+func (s *Server) handlePurgeExpired(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.PurgeExpired(w, r)
+}
+
+// handleRestoreURL routes to the URL handler for restoring deleted URLs.
+func (s *Server) handleRestoreURL(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.RestoreURL(w, r, r.PathValue("code"))
+}
+
+// handleExtendURL routes to the URL handler for changing a URL's expiry.
+func (s *Server) handleExtendURL(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.ExtendURL(w, r, r.PathValue("code"))
+}
+
+// handleCreateAlias routes to the URL handler for adding a short code to a URL.
+func (s *Server) handleCreateAlias(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.CreateAlias(w, r, r.PathValue("code"))
+}
+
+// handleDisableURL routes to the URL handler for pausing a URL's redirects.
+func (s *Server) handleDisableURL(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.SetActive(w, r, r.PathValue("code"), false)
+}
+
+// handleEnableURL routes to the URL handler for resuming a URL's redirects.
+func (s *Server) handleEnableURL(w http.ResponseWriter, r *http.Request) {
+	if s.urlHandler == nil {
+		http.Error(w, "URL service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.urlHandler.SetActive(w, r, r.PathValue("code"), true)
+}
+
 // handleRedirect routes to the redirect handler for URL redirects.
 func (s *Server) handleRedirect(w http.ResponseWriter, r *http.Request) {
 	if s.redirectHandler == nil {
@@ -172,6 +539,27 @@ func (s *Server) handleRedirect(w http.ResponseWriter, r *http.Request) {
 	s.redirectHandler.Redirect(w, r, shortCode)
 }
 
+// handleRoot serves GET /, redirecting to the configured landing page.
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if s.redirectHandler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.redirectHandler.Root(w, r)
+}
+
+// handleNotFound serves requests no route matches. API paths and methods
+// other than GET get a JSON error; others get the redirect handler's
+// not-found response.
+func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	if s.redirectHandler == nil || strings.HasPrefix(r.URL.Path, "/api/") ||
+		(r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		middleware.WriteError(w, r, http.StatusNotFound, "route not found", "NOT_FOUND")
+		return
+	}
+	s.redirectHandler.NotFound(w, r)
+}
+
 // handleAnalytics routes to the analytics handler for stats.
 func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 	if s.analyticsHandler == nil {
@@ -186,6 +574,60 @@ func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 	s.analyticsHandler.GetStats(w, r, shortCode)
 }
 
+// handleTimeSeries routes to the analytics handler for click time series.
+func (s *Server) handleTimeSeries(w http.ResponseWriter, r *http.Request) {
+	if s.analyticsHandler == nil {
+		http.Error(w, "Analytics service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.analyticsHandler.GetTimeSeries(w, r, r.PathValue("code"))
+}
+
+// handleReferrers routes to the analytics handler for top referrers.
+func (s *Server) handleReferrers(w http.ResponseWriter, r *http.Request) {
+	if s.analyticsHandler == nil {
+		http.Error(w, "Analytics service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.analyticsHandler.GetReferrers(w, r, r.PathValue("code"))
+}
+
+// handleAgents routes to the analytics handler for top browsers and operating systems.
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if s.analyticsHandler == nil {
+		http.Error(w, "Analytics service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.analyticsHandler.GetAgents(w, r, r.PathValue("code"))
+}
+
+// handleCountries routes to the analytics handler for top client countries.
+func (s *Server) handleCountries(w http.ResponseWriter, r *http.Request) {
+	if s.analyticsHandler == nil {
+		http.Error(w, "Analytics service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.analyticsHandler.GetCountries(w, r, r.PathValue("code"))
+}
+
+// handleTopURLs routes to the analytics handler for the top-URLs leaderboard.
+func (s *Server) handleTopURLs(w http.ResponseWriter, r *http.Request) {
+	if s.analyticsHandler == nil {
+		http.Error(w, "Analytics service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.analyticsHandler.GetTopURLs(w, r)
+}
+
+// handleStatsBatch routes to the analytics handler for batch stats lookups.
+func (s *Server) handleStatsBatch(w http.ResponseWriter, r *http.Request) {
+	if s.analyticsHandler == nil {
+		http.Error(w, "Analytics service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	s.analyticsHandler.GetStatsBatch(w, r)
+}
+
 // extractShortCode extracts the short code from the URL path.
 func extractShortCode(path, prefix string) string {
 	if !strings.HasPrefix(path, prefix) {
@@ -196,10 +638,8 @@ func extractShortCode(path, prefix string) string {
 
 // Start starts the HTTP server.
 func (s *Server) Start() error {
-	addr := s.cfg.Server.Address()
-
 	// Create listener first to get the actual address (important when port is 0)
-	listener, err := net.Listen("tcp", addr)
+	listener, err := s.listen()
 	if err != nil {
 		return fmt.Errorf("failed to create listener: %w", err)
 	}
@@ -224,6 +664,36 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// listen opens the configured Unix domain socket, or the TCP address when no
+// socket path is set.
+func (s *Server) listen() (net.Listener, error) {
+	path := s.cfg.Server.UnixSocket
+	if path == "" {
+		return net.Listen("tcp", s.cfg.Server.Address())
+	}
+
+	// A socket left behind by a crashed process blocks the bind; remove it,
+	// but never delete anything that is not a socket
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, s.cfg.Server.UnixSocketMode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.log.Info("server shutting down")
@@ -233,17 +703,40 @@ func (s *Server) Shutdown(ctx context.Context) error {
 
 	err := s.httpServer.Shutdown(ctx)
 
-	// Close rate limiter if it exists
+	// Persist pending clicks while the database is still open; no more
+	// clicks arrive once the HTTP server has stopped
+	if s.clickCounter != nil {
+		if flushErr := s.clickCounter.Flush(ctx); flushErr != nil {
+			s.log.Error("failed to flush pending clicks", "error", flushErr.Error())
+		}
+		s.clickCounter.Stop()
+	}
+
+	// Close rate limiters if they exist
 	if s.rateLimiter != nil {
 		if closeErr := s.rateLimiter.Close(); closeErr != nil {
 			s.log.Error("failed to close rate limiter", "error", closeErr.Error())
 		}
 	}
+	for _, limiter := range s.routeLimiters {
+		if closeErr := limiter.Close(); closeErr != nil {
+			s.log.Error("failed to close route rate limiter", "error", closeErr.Error())
+		}
+	}
 
 	s.mu.Lock()
 	s.running = false
+	listener := s.listener
 	s.mu.Unlock()
 
+	// Closing the listener normally unlinks the socket; make sure it is gone
+	if listener != nil && listener.Addr().Network() == "unix" {
+		path := listener.Addr().String()
+		if rmErr := os.Remove(path); rmErr != nil && !os.IsNotExist(rmErr) {
+			s.log.Error("failed to remove unix socket", "path", path, "error", rmErr.Error())
+		}
+	}
+
 	if err != nil {
 		s.log.Error("shutdown error", "error", err.Error())
 		return err
@@ -260,7 +753,8 @@ func (s *Server) IsRunning() bool {
 	return s.running
 }
 
-// Addr returns the server's address.
+// Addr returns the server's address, or the socket path when listening on a
+// Unix domain socket.
 func (s *Server) Addr() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -275,6 +769,11 @@ func (s *Server) HealthHandler() *handlers.HealthHandler {
 	return s.healthHandler
 }
 
+// MetricsHandler returns the metrics handler, or nil if metrics are disabled.
+func (s *Server) MetricsHandler() *handlers.MetricsHandler {
+	return s.metricsHandler
+}
+
 // SetURLRepository sets the URL repository for the server.
 func (s *Server) SetURLRepository(repo repository.URLRepository) {
 	s.urlRepo = repo
@@ -285,6 +784,11 @@ func (s *Server) URLRepository() repository.URLRepository {
 	return s.urlRepo
 }
 
+// SetClickCounter sets the click counter that is flushed and stopped on shutdown.
+func (s *Server) SetClickCounter(c *analytics.ClickCounter) {
+	s.clickCounter = c
+}
+
 // SetURLHandler sets the URL handler for the server.
 func (s *Server) SetURLHandler(h *handlers.URLHandler) {
 	s.urlHandler = h
@@ -295,6 +799,16 @@ func (s *Server) URLHandler() *handlers.URLHandler {
 	return s.urlHandler
 }
 
+// SetQRHandler sets the QR code handler for the server.
+func (s *Server) SetQRHandler(h *handlers.QRHandler) {
+	s.qrHandler = h
+}
+
+// QRHandler returns the QR code handler.
+func (s *Server) QRHandler() *handlers.QRHandler {
+	return s.qrHandler
+}
+
 // SetRedirectHandler sets the redirect handler for the server.
 func (s *Server) SetRedirectHandler(h *handlers.RedirectHandler) {
 	s.redirectHandler = h
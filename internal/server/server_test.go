@@ -4,13 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/emadnahed/FastGoLink/internal/analytics"
 	"github.com/emadnahed/FastGoLink/internal/config"
 	"github.com/emadnahed/FastGoLink/internal/handlers"
 	"github.com/emadnahed/FastGoLink/pkg/logger"
@@ -220,6 +228,54 @@ func TestServer_ShutdownTimeout(t *testing.T) {
 	assert.False(t, srv.IsRunning())
 }
 
+// recordingFlusher records flushed click counts.
+type recordingFlusher struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (f *recordingFlusher) FlushClicks(ctx context.Context, counts map[string]int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for code, n := range counts {
+		f.counts[code] += n
+	}
+	return nil
+}
+
+func TestServer_ShutdownFlushesClicks(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	cfg := testConfig()
+
+	srv := New(cfg, log)
+	flusher := &recordingFlusher{counts: make(map[string]int64)}
+	counter := analytics.NewClickCounter(analytics.Config{
+		FlushInterval: time.Hour, // Only shutdown flushes
+		BatchSize:     1000,
+	}, flusher)
+	srv.SetClickCounter(counter)
+
+	go func() { _ = srv.Start() }()
+	time.Sleep(100 * time.Millisecond)
+
+	counter.RecordClick(context.Background(), "abc123")
+	counter.RecordClick(context.Background(), "abc123")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, srv.Shutdown(ctx))
+
+	// Clicks are persisted by the time Shutdown returns and the caller closes the database
+	flusher.mu.Lock()
+	defer flusher.mu.Unlock()
+	assert.Equal(t, map[string]int64{"abc123": 2}, flusher.counts)
+
+	// The counter is stopped
+	counter.RecordClick(context.Background(), "abc123")
+	assert.Empty(t, counter.GetPendingStats())
+}
+
 func TestServer_SetterGetters(t *testing.T) {
 	var buf bytes.Buffer
 	log := logger.New(&buf, "error")
@@ -237,6 +293,16 @@ func TestServer_SetterGetters(t *testing.T) {
 		assert.Equal(t, urlHandler, srv.URLHandler())
 	})
 
+	// Test QR handler setter/getter
+	t.Run("QR handler", func(t *testing.T) {
+		assert.Nil(t, srv.QRHandler())
+
+		qrHandler := &handlers.QRHandler{}
+		srv.SetQRHandler(qrHandler)
+
+		assert.Equal(t, qrHandler, srv.QRHandler())
+	})
+
 	// Test redirect handler setter/getter
 	t.Run("redirect handler", func(t *testing.T) {
 		assert.Nil(t, srv.RedirectHandler())
@@ -374,6 +440,25 @@ func TestServer_HandleRedirect_NoHandler(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
 }
 
+func TestServer_HandleRedirectPasswordForm_NoHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	cfg := testConfig()
+
+	srv := New(cfg, log)
+
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	// POST /{code} submits the password form and is routed to the redirect handler
+	resp, err := http.PostForm("http://"+srv.Addr()+"/abc123", url.Values{"password": {"s3cret"}})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
 func TestServer_HandleAnalytics_NoHandler(t *testing.T) {
 	var buf bytes.Buffer
 	log := logger.New(&buf, "error")
@@ -561,6 +646,555 @@ func TestServer_WithRateLimiting(t *testing.T) {
 	assert.NotEmpty(t, resp.Header.Get("X-RateLimit-Remaining"))
 }
 
+func TestServer_WithTokenBucketRateLimiting(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	cfg := testConfig()
+	cfg.Rate.Enabled = true
+	cfg.Rate.Algorithm = "token_bucket"
+	cfg.Rate.BurstSize = 2
+	cfg.Rate.RefillRate = 0.01
+
+	srv := New(cfg, log)
+
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	addr := srv.Addr()
+	ctx := context.Background()
+
+	statuses := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/health", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		statuses = append(statuses, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, statuses)
+}
+
+func TestServer_WithRouteRateLimits(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	cfg := testConfig()
+	cfg.Rate.Enabled = true
+	cfg.Rate.Requests = 100
+	cfg.Rate.Window = time.Minute
+	cfg.Rate.Routes = []config.RouteRateLimit{
+		{Prefix: "/ready", Requests: 1, Window: time.Minute},
+	}
+
+	srv := New(cfg, log)
+
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	addr := srv.Addr()
+	ctx := context.Background()
+
+	get := func(path string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get("/health")
+	assert.Equal(t, "100", resp.Header.Get("X-RateLimit-Limit"))
+
+	resp = get("/ready")
+	assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Limit"))
+	assert.NotEqual(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	resp = get("/ready")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// The default limit is unaffected by the route limit
+	resp = get("/health")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_WithRedisRateLimiting_FailsOpen(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	cfg := testConfig()
+	cfg.Rate.Enabled = true
+	cfg.Rate.Backend = "redis"
+	cfg.Rate.Requests = 100
+	cfg.Rate.Window = time.Minute
+	cfg.Redis.Host = "127.0.0.1"
+	cfg.Redis.Port = 1 // Nothing listens here
+
+	srv := New(cfg, log)
+
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	addr := srv.Addr()
+
+	// Unreachable Redis must not block traffic
+	ctx := context.Background()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/health", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("X-RateLimit-Limit"))
+}
+
+func TestServer_MetricsEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	t.Run("enabled", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Metrics.Enabled = true
+
+		srv := New(cfg, log)
+		require.NotNil(t, srv.MetricsHandler())
+
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		// Hit the health endpoint first so request metrics have been observed
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/health", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/metrics", nil)
+		require.NoError(t, err)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), `http_requests_total{method="GET",path="/health",status="200"}`)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Metrics.Enabled = false
+
+		srv := New(cfg, log)
+		assert.Nil(t, srv.MetricsHandler())
+
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/metrics", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// Falls through to the redirect route, which is not configured
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestServer_PprofEndpoints(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	get := func(srv *Server, path, key string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+path, nil)
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("absent by default", func(t *testing.T) {
+		srv := New(testConfig(), log)
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline"} {
+			assert.Equal(t, http.StatusNotFound, get(srv, path, "").StatusCode, path)
+		}
+	})
+
+	t.Run("present when enabled", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Debug.PprofEnabled = true
+
+		srv := New(cfg, log)
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		resp := get(srv, "/debug/pprof/", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "goroutine")
+
+		assert.Equal(t, http.StatusOK, get(srv, "/debug/pprof/goroutine?debug=1", "").StatusCode)
+		assert.Equal(t, http.StatusOK, get(srv, "/debug/pprof/cmdline", "").StatusCode)
+	})
+
+	t.Run("requires an API key when keys are configured", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Debug.PprofEnabled = true
+		cfg.Security.APIKeys = "secret"
+
+		srv := New(cfg, log)
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		assert.Equal(t, http.StatusUnauthorized, get(srv, "/debug/pprof/", "").StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, get(srv, "/debug/pprof/heap", "wrong").StatusCode)
+		assert.Equal(t, http.StatusOK, get(srv, "/debug/pprof/", "secret").StatusCode)
+	})
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "info")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.App.AccessLog = true
+
+	srv := New(cfg, log)
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/health", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "access-log-test")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Contains(t, buf.String(), `"msg":"request completed"`)
+	assert.Contains(t, buf.String(), `"request_id":"access-log-test"`)
+	assert.Contains(t, buf.String(), `"path":"/health"`)
+}
+
+func TestServer_MaxBodyBytes(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.Security.MaxBodyBytes = 64
+
+	srv := New(cfg, log)
+	srv.SetURLHandler(handlers.NewURLHandler(nil))
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	body := `{"url":"https://example.com/` + strings.Repeat("a", 128) + `"}`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+srv.Addr()+"/api/v1/shorten", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	var errResp handlers.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	assert.Equal(t, "BODY_TOO_LARGE", errResp.Code)
+}
+
+func TestServer_RootAndUnknownPaths(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	srv := New(testConfig(), log)
+	srv.SetRedirectHandler(handlers.NewRedirectHandlerWithConfig(nil, handlers.RedirectConfig{
+		RootURL:     "https://example.com/",
+		NotFoundURL: "https://example.com/missing",
+	}))
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	get := func(t *testing.T, path string) *http.Response {
+		t.Helper()
+		resp, err := client.Get("http://" + srv.Addr() + path)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("root redirects", func(t *testing.T) {
+		resp := get(t, "/")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://example.com/", resp.Header.Get("Location"))
+	})
+
+	t.Run("unknown path uses not-found redirect", func(t *testing.T) {
+		resp := get(t, "/some/deep/path")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://example.com/missing", resp.Header.Get("Location"))
+	})
+
+	t.Run("unknown API path keeps JSON error", func(t *testing.T) {
+		resp := get(t, "/api/v1/nothing")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, "NOT_FOUND", errResp.Code)
+	})
+
+	t.Run("unknown path is 404 for other methods", func(t *testing.T) {
+		for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+			req, err := http.NewRequest(method, "http://"+srv.Addr()+"/some/deep/path", nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, method)
+		}
+	})
+}
+
+func TestServer_BasePath(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.Server.BasePath = "/shortener"
+
+	srv := New(cfg, log)
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/shortener/health", http.StatusOK},
+		{"/shortener/api/v1/urls/abc1234", http.StatusServiceUnavailable},
+		{"/shortener/abc1234", http.StatusServiceUnavailable},
+		{"/health", http.StatusOK},
+		{"/ready", http.StatusOK},
+		{"/api/v1/urls/abc1234", http.StatusNotFound},
+		{"/abc1234", http.StatusNotFound},
+		{"/shortenerx/abc1234", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get("http://" + srv.Addr() + tt.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestServer_APIKeyAuth(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.Security.APIKeys = "secret"
+
+	srv := New(cfg, log)
+	srv.SetURLHandler(handlers.NewURLHandler(nil))
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	do := func(method, path, key string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, method, "http://"+srv.Addr()+path, strings.NewReader("not json"))
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("rejects writes without a key", func(t *testing.T) {
+		for _, tc := range []struct{ method, path string }{
+			{http.MethodPost, "/api/v1/shorten"},
+			{http.MethodPost, "/api/v1/shorten/batch"},
+			{http.MethodPost, "/api/v1/urls/import"},
+			{http.MethodPost, "/api/v1/urls/abc123/aliases"},
+			{http.MethodPatch, "/api/v1/urls/abc123"},
+			{http.MethodDelete, "/api/v1/urls/abc123"},
+			{http.MethodPost, "/api/v1/admin/purge-expired"},
+		} {
+			resp := do(tc.method, tc.path, "")
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "%s %s", tc.method, tc.path)
+
+			var errResp handlers.ErrorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+			assert.Equal(t, "UNAUTHORIZED", errResp.Code)
+		}
+	})
+
+	t.Run("rejects writes with an invalid key", func(t *testing.T) {
+		resp := do(http.MethodPost, "/api/v1/shorten", "wrong")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("accepts writes with a valid key", func(t *testing.T) {
+		// The handler rejects the malformed body, so auth let the request through
+		resp := do(http.MethodPost, "/api/v1/shorten", "secret")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("protects URL listing", func(t *testing.T) {
+		resp := do(http.MethodGet, "/api/v1/urls", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		// The handler rejects the malformed limit, so auth let the request through
+		resp = do(http.MethodGet, "/api/v1/urls?limit=x", "secret")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("protects URL export", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/urls/export", "/api/v1/urls/export?format=json"} {
+			resp := do(http.MethodGet, path, "")
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, path)
+		}
+
+		// The handler rejects the unknown format, so auth let the request through
+		resp := do(http.MethodGet, "/api/v1/urls/export?format=xml", "secret")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("leaves redirects and reads public", func(t *testing.T) {
+		resp := do(http.MethodGet, "/abc123", "")
+		assert.NotEqual(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = do(http.MethodGet, "/health", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do(http.MethodGet, "/info", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do(http.MethodPost, "/api/v1/analytics/batch", "")
+		assert.NotEqual(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestServer_Info(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.Security.APIKeys = "secret"
+	cfg.Security.InfoRequiresKey = true
+
+	srv := New(cfg, log)
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	get := func(t *testing.T, key string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/info", nil)
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("requires a key when configured", func(t *testing.T) {
+		resp := get(t, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("reports the build with a key", func(t *testing.T) {
+		resp := get(t, "secret")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var info handlers.InfoResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		assert.NotEmpty(t, info.Version)
+		assert.NotEmpty(t, info.GoVersion)
+	})
+}
+
+func TestServer_SecurityHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.Security.Headers = true
+	cfg.Security.FrameOptions = "DENY"
+	cfg.Security.ReferrerPolicy = "no-referrer"
+	cfg.Security.ContentSecurityPolicy = config.DefaultContentSecurityPolicy
+	cfg.Security.DocsContentSecurityPolicy = config.DefaultDocsContentSecurityPolicy
+
+	srv := New(cfg, log)
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	get := func(path string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := get("/health")
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", resp.Header.Get("Referrer-Policy"))
+	assert.Equal(t, config.DefaultContentSecurityPolicy, resp.Header.Get("Content-Security-Policy"))
+
+	resp = get("/docs/swagger")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, config.DefaultDocsContentSecurityPolicy, resp.Header.Get("Content-Security-Policy"))
+}
+
 func TestServer_Addr_NotRunning(t *testing.T) {
 	var buf bytes.Buffer
 	log := logger.New(&buf, "error")
@@ -572,3 +1206,148 @@ func TestServer_Addr_NotRunning(t *testing.T) {
 	assert.Empty(t, srv.Addr())
 }
 
+func TestServer_ReadHeaderTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	cfg := testConfig()
+	cfg.Server.ReadHeaderTimeout = 200 * time.Millisecond
+
+	srv := New(cfg, log)
+	go func() { _ = srv.Start() }()
+	defer func() { _ = srv.Shutdown(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", srv.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Send the request line and one header, then stall before the blank line
+	_, err = conn.Write([]byte("GET /health HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+	_, err = io.ReadAll(conn)
+	require.NoError(t, err, "server should close the connection before the client deadline")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestServer_H2C(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	get := func(t *testing.T, srv *Server) (*http.Response, error) {
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/health", nil)
+		require.NoError(t, err)
+		return client.Do(req)
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		srv := New(testConfig(), log)
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		resp, err := get(t, srv)
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Server.H2CEnabled = true
+
+		srv := New(cfg, log)
+		go func() { _ = srv.Start() }()
+		defer func() { _ = srv.Shutdown(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+
+		resp, err := get(t, srv)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, resp.ProtoMajor)
+
+		// HTTP/1.1 clients keep working
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.Addr()+"/health", nil)
+		require.NoError(t, err)
+		resp1, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp1.Body.Close()
+		assert.Equal(t, 1, resp1.ProtoMajor)
+	})
+}
+
+func TestServer_UnixSocket(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+	ctx := context.Background()
+
+	// Socket paths are limited to ~108 bytes, so avoid the long t.TempDir()
+	dir, err := os.MkdirTemp("", "fgl")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "api.sock")
+
+	// A stale socket from a previous run must not block startup
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	cfg := testConfig()
+	cfg.Server.UnixSocket = path
+	cfg.Server.UnixSocketMode = 0o600
+
+	srv := New(cfg, log)
+	go func() { _ = srv.Start() }()
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, path, srv.Addr())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix/health", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, srv.Shutdown(ctx))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket file should be removed on shutdown")
+}
+
+func TestServer_UnixSocketRefusesNonSocket(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, "error")
+
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	cfg := testConfig()
+	cfg.Server.UnixSocket = path
+
+	err := New(cfg, log).Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a socket")
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "regular file must be left in place")
+}
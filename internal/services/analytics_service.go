@@ -2,10 +2,56 @@ package services
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/emadnahed/FastGoLink/internal/models"
 	"github.com/emadnahed/FastGoLink/internal/repository"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+)
+
+// Time-series analytics errors.
+var (
+	ErrInvalidInterval       = errors.New("interval must be hour or day")
+	ErrInvalidTimeRange      = errors.New("from must be before to and the range must span at most 1000 intervals")
+	ErrTimeSeriesUnavailable = errors.New("time-series analytics are not configured")
+	ErrSourcesUnavailable    = errors.New("click source analytics are not configured")
+	ErrGeoUnavailable        = errors.New("country analytics are not configured")
+	ErrInvalidTopOrder       = errors.New("by must be clicks or created")
+)
+
+// Time-series limits and defaults.
+const (
+	MaxTimeSeriesPoints = 1000                // Maximum number of windows in one time series
+	DefaultHourlyRange  = 24 * time.Hour      // Range used for hourly series when from is omitted
+	DefaultDailyRange   = 30 * 24 * time.Hour // Range used for daily series when from is omitted
 )
 
+// DefaultTopLimit is the number of URLs GetTopURLs returns when no limit is given.
+const DefaultTopLimit = 10
+
+// TopOrder selects how the top-URLs leaderboard is ranked.
+type TopOrder string
+
+// Supported leaderboard orders.
+const (
+	TopOrderClicks  TopOrder = "clicks"  // Most clicks first
+	TopOrderCreated TopOrder = "created" // Newest first
+)
+
+// TopURL is an entry of the top-URLs leaderboard.
+type TopURL struct {
+	ShortCode    string    `json:"short_code"`
+	OriginalURL  string    `json:"original_url,omitempty"` // Empty for password-protected URLs
+	ClickCount   int64     `json:"click_count"`
+	PendingCount int64     `json:"pending_count,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 // URLStats represents click statistics for a URL.
 type URLStats struct {
 	ShortCode    string `json:"short_code"`
@@ -21,18 +67,29 @@ type PendingStatsProvider interface {
 // AnalyticsService defines the interface for analytics operations.
 type AnalyticsService interface {
 	GetURLStats(ctx context.Context, shortCode string) (*URLStats, error)
+	GetURLStatsBatch(ctx context.Context, shortCodes []string) (map[string]*URLStats, error)
+	GetTimeSeries(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) ([]models.ClickBucket, error)
+	GetReferrers(ctx context.Context, shortCode string, limit int) ([]models.ReferrerCount, error)
+	GetAgents(ctx context.Context, shortCode string, limit int) ([]models.AgentCount, error)
+	GetCountries(ctx context.Context, shortCode string, limit int) ([]models.CountryCount, error)
+	GetTopURLs(ctx context.Context, limit int, by TopOrder) ([]TopURL, error)
 }
 
 // AnalyticsServiceImpl implements AnalyticsService.
 type AnalyticsServiceImpl struct {
 	repo            repository.URLRepository
 	pendingProvider PendingStatsProvider
+	bucketRepo      repository.ClickBucketRepository
+	sourceRepo      repository.ClickSourceRepository
+	countryRepo     repository.ClickCountryRepository
+	now             func() time.Time
 }
 
 // NewAnalyticsService creates a new AnalyticsService.
 func NewAnalyticsService(repo repository.URLRepository) *AnalyticsServiceImpl {
 	return &AnalyticsServiceImpl{
 		repo: repo,
+		now:  time.Now,
 	}
 }
 
@@ -41,6 +98,43 @@ func NewAnalyticsServiceWithPendingStats(repo repository.URLRepository, provider
 	return &AnalyticsServiceImpl{
 		repo:            repo,
 		pendingProvider: provider,
+		now:             time.Now,
+	}
+}
+
+// NewAnalyticsServiceWithTimeSeries creates an AnalyticsService with pending
+// stats and time-series support.
+func NewAnalyticsServiceWithTimeSeries(repo repository.URLRepository, provider PendingStatsProvider, buckets repository.ClickBucketRepository) *AnalyticsServiceImpl {
+	return &AnalyticsServiceImpl{
+		repo:            repo,
+		pendingProvider: provider,
+		bucketRepo:      buckets,
+		now:             time.Now,
+	}
+}
+
+// NewAnalyticsServiceWithSources creates an AnalyticsService with pending
+// stats, time-series and click source support.
+func NewAnalyticsServiceWithSources(repo repository.URLRepository, provider PendingStatsProvider, buckets repository.ClickBucketRepository, sources repository.ClickSourceRepository) *AnalyticsServiceImpl {
+	return &AnalyticsServiceImpl{
+		repo:            repo,
+		pendingProvider: provider,
+		bucketRepo:      buckets,
+		sourceRepo:      sources,
+		now:             time.Now,
+	}
+}
+
+// NewAnalyticsServiceWithGeo creates an AnalyticsService with pending stats,
+// time-series, click source and country support.
+func NewAnalyticsServiceWithGeo(repo repository.URLRepository, provider PendingStatsProvider, buckets repository.ClickBucketRepository, sources repository.ClickSourceRepository, countries repository.ClickCountryRepository) *AnalyticsServiceImpl {
+	return &AnalyticsServiceImpl{
+		repo:            repo,
+		pendingProvider: provider,
+		bucketRepo:      buckets,
+		sourceRepo:      sources,
+		countryRepo:     countries,
+		now:             time.Now,
 	}
 }
 
@@ -66,3 +160,247 @@ func (s *AnalyticsServiceImpl) GetURLStats(ctx context.Context, shortCode string
 
 	return stats, nil
 }
+
+// GetURLStatsBatch retrieves click statistics for several URLs with one
+// repository query. The result is keyed by short code; codes that do not
+// exist are left out.
+func (s *AnalyticsServiceImpl) GetURLStatsBatch(ctx context.Context, shortCodes []string) (_ map[string]*URLStats, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsService.GetURLStatsBatch",
+		trace.WithAttributes(attribute.Int("url.batch_size", len(shortCodes))))
+	defer func() { tracing.End(span, err) }()
+
+	urls, err := s.repo.GetByShortCodes(ctx, shortCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending map[string]int64
+	if s.pendingProvider != nil {
+		pending = s.pendingProvider.GetPendingStats()
+	}
+
+	stats := make(map[string]*URLStats, len(urls))
+	for _, url := range urls {
+		stats[url.ShortCode] = &URLStats{
+			ShortCode:    url.ShortCode,
+			ClickCount:   url.ClickCount,
+			PendingCount: pending[url.ShortCode],
+		}
+	}
+	return stats, nil
+}
+
+// GetTimeSeries returns the clicks of a URL per interval window in [from, to).
+// from is rounded down to the start of its window, and windows without clicks
+// are included with a zero count. A zero to means now; a zero from means
+// DefaultHourlyRange or DefaultDailyRange before to. Clicks appear once the
+// click counter has flushed them.
+func (s *AnalyticsServiceImpl) GetTimeSeries(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) (_ []models.ClickBucket, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsService.GetTimeSeries",
+		trace.WithAttributes(tracing.ShortCodeKey.String(shortCode), attribute.String("click.interval", string(interval))))
+	defer func() { tracing.End(span, err) }()
+
+	if s.bucketRepo == nil {
+		return nil, ErrTimeSeriesUnavailable
+	}
+
+	step := interval.Duration()
+	if step == 0 {
+		return nil, ErrInvalidInterval
+	}
+	if to.IsZero() {
+		to = s.now()
+	}
+	if from.IsZero() {
+		if interval == models.IntervalHour {
+			from = to.Add(-DefaultHourlyRange)
+		} else {
+			from = to.Add(-DefaultDailyRange)
+		}
+	}
+	start := interval.Truncate(from)
+	if !from.Before(to) || to.Sub(start) > MaxTimeSeriesPoints*step {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if _, err := s.repo.GetByShortCode(ctx, shortCode); err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.bucketRepo.GetClickBuckets(ctx, shortCode, start, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[time.Time]int64, len(buckets))
+	for _, b := range buckets {
+		counts[b.Timestamp.UTC()] += b.Count
+	}
+
+	points := make([]models.ClickBucket, 0, to.Sub(start)/step+1)
+	for t := start; t.Before(to); t = t.Add(step) {
+		points = append(points, models.ClickBucket{Timestamp: t, Count: counts[t]})
+	}
+
+	return points, nil
+}
+
+// GetReferrers returns the top referrer hosts of a URL, most clicks first.
+// limit must be between 1 and MaxListLimit. Clicks appear once the click
+// counter has flushed them.
+func (s *AnalyticsServiceImpl) GetReferrers(ctx context.Context, shortCode string, limit int) (_ []models.ReferrerCount, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsService.GetReferrers", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.checkSourceQuery(ctx, shortCode, limit); err != nil {
+		return nil, err
+	}
+	return s.sourceRepo.GetReferrers(ctx, shortCode, limit)
+}
+
+// GetAgents returns the top browser/OS pairs of a URL, most clicks first.
+// limit must be between 1 and MaxListLimit. Clicks appear once the click
+// counter has flushed them.
+func (s *AnalyticsServiceImpl) GetAgents(ctx context.Context, shortCode string, limit int) (_ []models.AgentCount, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsService.GetAgents", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.checkSourceQuery(ctx, shortCode, limit); err != nil {
+		return nil, err
+	}
+	return s.sourceRepo.GetAgents(ctx, shortCode, limit)
+}
+
+// GetCountries returns the top client countries of a URL, most clicks first.
+// limit must be between 1 and MaxListLimit. Countries are only recorded while
+// a GeoIP database is configured, and appear once the click counter has
+// flushed them.
+func (s *AnalyticsServiceImpl) GetCountries(ctx context.Context, shortCode string, limit int) (_ []models.CountryCount, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsService.GetCountries", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	if s.countryRepo == nil {
+		return nil, ErrGeoUnavailable
+	}
+	if err := s.checkListQuery(ctx, shortCode, limit); err != nil {
+		return nil, err
+	}
+	return s.countryRepo.GetCountries(ctx, shortCode, limit)
+}
+
+// checkSourceQuery validates a click source query and that the URL exists.
+func (s *AnalyticsServiceImpl) checkSourceQuery(ctx context.Context, shortCode string, limit int) error {
+	if s.sourceRepo == nil {
+		return ErrSourcesUnavailable
+	}
+	return s.checkListQuery(ctx, shortCode, limit)
+}
+
+// checkListQuery validates the limit of a top-N query and that the URL exists.
+func (s *AnalyticsServiceImpl) checkListQuery(ctx context.Context, shortCode string, limit int) error {
+	if limit < 1 || limit > MaxListLimit {
+		return ErrInvalidPagination
+	}
+	_, err := s.repo.GetByShortCode(ctx, shortCode)
+	return err
+}
+
+// GetTopURLs returns up to limit URLs ranked by clicks or by creation date.
+// limit must be between 1 and MaxListLimit. With a pending stats provider,
+// unflushed clicks are included, so clicks-ranked leaderboards reflect recent
+// activity before it is flushed.
+func (s *AnalyticsServiceImpl) GetTopURLs(ctx context.Context, limit int, by TopOrder) (_ []TopURL, err error) {
+	ctx, span := tracer.Start(ctx, "AnalyticsService.GetTopURLs",
+		trace.WithAttributes(attribute.Int("list.limit", limit), attribute.String("list.order", string(by))))
+	defer func() { tracing.End(span, err) }()
+
+	if by != TopOrderClicks && by != TopOrderCreated {
+		return nil, ErrInvalidTopOrder
+	}
+	if limit < 1 || limit > MaxListLimit {
+		return nil, ErrInvalidPagination
+	}
+
+	var urls []*models.URL
+	if by == TopOrderClicks {
+		urls, err = s.repo.TopByClicks(ctx, limit)
+	} else {
+		urls, _, err = s.repo.List(ctx, limit, 0, repository.ListFilter{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending map[string]int64
+	if s.pendingProvider != nil {
+		pending = s.pendingProvider.GetPendingStats()
+	}
+
+	top := make([]TopURL, 0, len(urls))
+	for _, url := range urls {
+		top = append(top, newTopURL(url, pending[url.ShortCode]))
+	}
+	if by == TopOrderClicks && len(pending) > 0 {
+		if top, err = s.foldPendingClicks(ctx, top, urls, pending, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	return top, nil
+}
+
+// foldPendingClicks adds URLs whose pending clicks lift them onto a
+// clicks-ranked leaderboard, then re-ranks it by total clicks.
+func (s *AnalyticsServiceImpl) foldPendingClicks(ctx context.Context, top []TopURL, urls []*models.URL, pending map[string]int64, limit int) ([]TopURL, error) {
+	// URLs outside a full board have at most as many flushed clicks as its
+	// last entry, and need enough pending clicks to beat its lowest total
+	var floor, lowest int64
+	if len(urls) == limit {
+		floor = urls[len(urls)-1].ClickCount
+		lowest = top[0].ClickCount + top[0].PendingCount
+		for _, t := range top {
+			lowest = min(lowest, t.ClickCount+t.PendingCount)
+		}
+	}
+
+	onBoard := make(map[string]bool, len(top))
+	for _, t := range top {
+		onBoard[t.ShortCode] = true
+	}
+	for code, count := range pending {
+		if onBoard[code] || (len(urls) == limit && floor+count <= lowest) {
+			continue
+		}
+		url, err := s.repo.GetByShortCode(ctx, code)
+		if errors.Is(err, models.ErrURLNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		top = append(top, newTopURL(url, count))
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].ClickCount+top[i].PendingCount > top[j].ClickCount+top[j].PendingCount
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+// newTopURL converts url to a leaderboard entry. The leaderboard is public,
+// so password-protected URLs leave out their destination.
+func newTopURL(url *models.URL, pending int64) TopURL {
+	top := TopURL{
+		ShortCode:    url.ShortCode,
+		ClickCount:   url.ClickCount,
+		PendingCount: pending,
+		CreatedAt:    url.CreatedAt,
+	}
+	if !url.IsPasswordProtected() {
+		top.OriginalURL = url.OriginalURL
+	}
+	return top
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/emadnahed/FastGoLink/internal/analytics"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 )
 
 // mockPendingStatsProvider implements PendingStatsProvider for testing.
@@ -130,3 +132,417 @@ func TestAnalyticsServiceImpl_GetURLStats(t *testing.T) {
 		repo.AssertExpectations(t)
 	})
 }
+
+// MockClickBucketRepository is a mock implementation of repository.ClickBucketRepository.
+type MockClickBucketRepository struct {
+	mock.Mock
+}
+
+func (m *MockClickBucketRepository) IncrementClickBuckets(ctx context.Context, buckets map[analytics.BucketKey]int64) error {
+	args := m.Called(ctx, buckets)
+	return args.Error(0)
+}
+
+func (m *MockClickBucketRepository) GetClickBuckets(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) ([]models.ClickBucket, error) {
+	args := m.Called(ctx, shortCode, from, to, interval)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ClickBucket), args.Error(1)
+}
+
+func TestAnalyticsServiceImpl_GetURLStatsBatch(t *testing.T) {
+	t.Run("merges pending clicks and leaves out missing codes", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		provider := &mockPendingStatsProvider{stats: map[string]int64{"abc123": 5, "missing": 9}}
+		svc := NewAnalyticsServiceWithPendingStats(repo, provider)
+
+		codes := []string{"abc123", "missing", "xyz789"}
+		repo.On("GetByShortCodes", mock.Anything, codes).Return([]*models.URL{
+			{ShortCode: "xyz789", ClickCount: 7},
+			{ShortCode: "abc123", ClickCount: 42},
+		}, nil)
+
+		stats, err := svc.GetURLStatsBatch(context.Background(), codes)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]*URLStats{
+			"abc123": {ShortCode: "abc123", ClickCount: 42, PendingCount: 5},
+			"xyz789": {ShortCode: "xyz789", ClickCount: 7},
+		}, stats)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("returns repository errors", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		svc := NewAnalyticsService(repo)
+		repo.On("GetByShortCodes", mock.Anything, []string{"abc123"}).Return(nil, errors.New("db down"))
+
+		_, err := svc.GetURLStatsBatch(context.Background(), []string{"abc123"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAnalyticsServiceImpl_GetTimeSeries(t *testing.T) {
+	ctx := context.Background()
+	url := &models.URL{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+	midnight := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	newService := func() (*AnalyticsServiceImpl, *MockURLRepository, *MockClickBucketRepository) {
+		repo := &MockURLRepository{}
+		buckets := &MockClickBucketRepository{}
+		return NewAnalyticsServiceWithTimeSeries(repo, nil, buckets), repo, buckets
+	}
+
+	t.Run("fills empty windows with zero", func(t *testing.T) {
+		svc, repo, buckets := newService()
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		buckets.On("GetClickBuckets", mock.Anything, "abc123", midnight, midnight.Add(3*24*time.Hour), models.IntervalDay).
+			Return([]models.ClickBucket{{Timestamp: midnight.Add(24 * time.Hour), Count: 5}}, nil)
+
+		points, err := svc.GetTimeSeries(ctx, "abc123", midnight, midnight.Add(3*24*time.Hour), models.IntervalDay)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.ClickBucket{
+			{Timestamp: midnight, Count: 0},
+			{Timestamp: midnight.Add(24 * time.Hour), Count: 5},
+			{Timestamp: midnight.Add(48 * time.Hour), Count: 0},
+		}, points)
+	})
+
+	t.Run("rounds from down to its window", func(t *testing.T) {
+		svc, repo, buckets := newService()
+		from := midnight.Add(90 * time.Minute) // 01:30
+		to := midnight.Add(3 * time.Hour)
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		buckets.On("GetClickBuckets", mock.Anything, "abc123", midnight.Add(time.Hour), to, models.IntervalHour).
+			Return([]models.ClickBucket{}, nil)
+
+		points, err := svc.GetTimeSeries(ctx, "abc123", from, to, models.IntervalHour)
+
+		require.NoError(t, err)
+		require.Len(t, points, 2)
+		assert.Equal(t, midnight.Add(time.Hour), points[0].Timestamp)
+		assert.Equal(t, midnight.Add(2*time.Hour), points[1].Timestamp)
+	})
+
+	t.Run("partial last window is included", func(t *testing.T) {
+		svc, repo, buckets := newService()
+		to := midnight.Add(24*time.Hour + time.Minute)
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		buckets.On("GetClickBuckets", mock.Anything, "abc123", midnight, to, models.IntervalDay).
+			Return([]models.ClickBucket{}, nil)
+
+		points, err := svc.GetTimeSeries(ctx, "abc123", midnight, to, models.IntervalDay)
+
+		require.NoError(t, err)
+		require.Len(t, points, 2)
+		assert.Equal(t, midnight.Add(24*time.Hour), points[1].Timestamp)
+	})
+
+	t.Run("defaults to the last 30 days", func(t *testing.T) {
+		svc, repo, buckets := newService()
+		now := midnight.Add(12 * time.Hour)
+		svc.now = func() time.Time { return now }
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		buckets.On("GetClickBuckets", mock.Anything, "abc123", midnight.Add(-30*24*time.Hour), now, models.IntervalDay).
+			Return([]models.ClickBucket{}, nil)
+
+		points, err := svc.GetTimeSeries(ctx, "abc123", time.Time{}, time.Time{}, models.IntervalDay)
+
+		require.NoError(t, err)
+		assert.Len(t, points, 31)
+		buckets.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid arguments", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		_, err := svc.GetTimeSeries(ctx, "abc123", midnight, midnight.Add(time.Hour), models.Interval("week"))
+		assert.ErrorIs(t, err, ErrInvalidInterval)
+
+		_, err = svc.GetTimeSeries(ctx, "abc123", midnight, midnight, models.IntervalHour)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+
+		_, err = svc.GetTimeSeries(ctx, "abc123", midnight, midnight.Add((MaxTimeSeriesPoints+1)*time.Hour), models.IntervalHour)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	})
+
+	t.Run("unknown URL returns not found", func(t *testing.T) {
+		svc, repo, buckets := newService()
+		repo.On("GetByShortCode", mock.Anything, "nope").Return(nil, models.ErrURLNotFound)
+
+		_, err := svc.GetTimeSeries(ctx, "nope", midnight, midnight.Add(time.Hour), models.IntervalHour)
+
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		buckets.AssertNotCalled(t, "GetClickBuckets")
+	})
+
+	t.Run("unavailable without bucket repository", func(t *testing.T) {
+		svc := NewAnalyticsService(&MockURLRepository{})
+
+		_, err := svc.GetTimeSeries(ctx, "abc123", midnight, midnight.Add(time.Hour), models.IntervalHour)
+
+		assert.ErrorIs(t, err, ErrTimeSeriesUnavailable)
+	})
+}
+
+// MockClickSourceRepository is a mock implementation of repository.ClickSourceRepository.
+type MockClickSourceRepository struct {
+	mock.Mock
+}
+
+func (m *MockClickSourceRepository) IncrementClickSources(ctx context.Context, referrers map[analytics.ReferrerKey]int64, agents map[analytics.AgentKey]int64) error {
+	args := m.Called(ctx, referrers, agents)
+	return args.Error(0)
+}
+
+func (m *MockClickSourceRepository) GetReferrers(ctx context.Context, shortCode string, limit int) ([]models.ReferrerCount, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ReferrerCount), args.Error(1)
+}
+
+func (m *MockClickSourceRepository) GetAgents(ctx context.Context, shortCode string, limit int) ([]models.AgentCount, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AgentCount), args.Error(1)
+}
+
+func TestAnalyticsServiceImpl_ClickSources(t *testing.T) {
+	ctx := context.Background()
+	url := &models.URL{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+
+	newService := func() (*AnalyticsServiceImpl, *MockURLRepository, *MockClickSourceRepository) {
+		repo := &MockURLRepository{}
+		sources := &MockClickSourceRepository{}
+		return NewAnalyticsServiceWithSources(repo, nil, nil, sources), repo, sources
+	}
+
+	t.Run("returns referrers", func(t *testing.T) {
+		svc, repo, sources := newService()
+		want := []models.ReferrerCount{{Referrer: "twitter.com", Count: 7}, {Referrer: "direct", Count: 3}}
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		sources.On("GetReferrers", mock.Anything, "abc123", 10).Return(want, nil)
+
+		got, err := svc.GetReferrers(ctx, "abc123", 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("returns agents", func(t *testing.T) {
+		svc, repo, sources := newService()
+		want := []models.AgentCount{{Browser: "Chrome", OS: "Windows", Count: 4}}
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		sources.On("GetAgents", mock.Anything, "abc123", 10).Return(want, nil)
+
+		got, err := svc.GetAgents(ctx, "abc123", 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("rejects out-of-range limit", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		_, err := svc.GetReferrers(ctx, "abc123", 0)
+		assert.ErrorIs(t, err, ErrInvalidPagination)
+
+		_, err = svc.GetAgents(ctx, "abc123", MaxListLimit+1)
+		assert.ErrorIs(t, err, ErrInvalidPagination)
+	})
+
+	t.Run("unknown URL returns not found", func(t *testing.T) {
+		svc, repo, sources := newService()
+		repo.On("GetByShortCode", mock.Anything, "nope").Return(nil, models.ErrURLNotFound)
+
+		_, err := svc.GetReferrers(ctx, "nope", 10)
+
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		sources.AssertNotCalled(t, "GetReferrers")
+	})
+
+	t.Run("unavailable without source repository", func(t *testing.T) {
+		svc := NewAnalyticsService(&MockURLRepository{})
+
+		_, err := svc.GetAgents(ctx, "abc123", 10)
+
+		assert.ErrorIs(t, err, ErrSourcesUnavailable)
+	})
+}
+
+// MockClickCountryRepository is a mock implementation of repository.ClickCountryRepository.
+type MockClickCountryRepository struct {
+	mock.Mock
+}
+
+func (m *MockClickCountryRepository) IncrementClickCountries(ctx context.Context, countries map[analytics.CountryKey]int64) error {
+	args := m.Called(ctx, countries)
+	return args.Error(0)
+}
+
+func (m *MockClickCountryRepository) GetCountries(ctx context.Context, shortCode string, limit int) ([]models.CountryCount, error) {
+	args := m.Called(ctx, shortCode, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CountryCount), args.Error(1)
+}
+
+func TestAnalyticsServiceImpl_GetCountries(t *testing.T) {
+	ctx := context.Background()
+	url := &models.URL{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+
+	newService := func() (*AnalyticsServiceImpl, *MockURLRepository, *MockClickCountryRepository) {
+		repo := &MockURLRepository{}
+		countries := &MockClickCountryRepository{}
+		return NewAnalyticsServiceWithGeo(repo, nil, nil, nil, countries), repo, countries
+	}
+
+	t.Run("returns countries", func(t *testing.T) {
+		svc, repo, countries := newService()
+		want := []models.CountryCount{{Country: "DE", Count: 5}, {Country: "XX", Count: 1}}
+		repo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		countries.On("GetCountries", mock.Anything, "abc123", 10).Return(want, nil)
+
+		got, err := svc.GetCountries(ctx, "abc123", 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("rejects out-of-range limit", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		_, err := svc.GetCountries(ctx, "abc123", MaxListLimit+1)
+
+		assert.ErrorIs(t, err, ErrInvalidPagination)
+	})
+
+	t.Run("unknown URL returns not found", func(t *testing.T) {
+		svc, repo, countries := newService()
+		repo.On("GetByShortCode", mock.Anything, "nope").Return(nil, models.ErrURLNotFound)
+
+		_, err := svc.GetCountries(ctx, "nope", 10)
+
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		countries.AssertNotCalled(t, "GetCountries")
+	})
+
+	t.Run("unavailable without country repository", func(t *testing.T) {
+		svc := NewAnalyticsServiceWithSources(&MockURLRepository{}, nil, nil, &MockClickSourceRepository{})
+
+		_, err := svc.GetCountries(ctx, "abc123", 10)
+
+		assert.ErrorIs(t, err, ErrGeoUnavailable)
+	})
+}
+
+func TestAnalyticsServiceImpl_GetTopURLs(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newURL := func(code string, clicks int64) *models.URL {
+		return &models.URL{ShortCode: code, OriginalURL: "https://example.com/" + code, ClickCount: clicks, CreatedAt: created}
+	}
+	codes := func(top []TopURL) []string {
+		var out []string
+		for _, u := range top {
+			out = append(out, u.ShortCode)
+		}
+		return out
+	}
+
+	t.Run("ranks by clicks", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		svc := NewAnalyticsService(repo)
+		repo.On("TopByClicks", mock.Anything, 2).Return([]*models.URL{newURL("a", 9), newURL("b", 4)}, nil)
+
+		top, err := svc.GetTopURLs(ctx, 2, TopOrderClicks)
+
+		require.NoError(t, err)
+		assert.Equal(t, []TopURL{
+			{ShortCode: "a", OriginalURL: "https://example.com/a", ClickCount: 9, CreatedAt: created},
+			{ShortCode: "b", OriginalURL: "https://example.com/b", ClickCount: 4, CreatedAt: created},
+		}, top)
+	})
+
+	t.Run("leaves out destinations of password-protected URLs", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		svc := NewAnalyticsService(repo)
+		protected := newURL("p", 9)
+		protected.PasswordHash = "$2a$10$hash"
+		repo.On("TopByClicks", mock.Anything, 2).Return([]*models.URL{protected, newURL("b", 4)}, nil)
+
+		top, err := svc.GetTopURLs(ctx, 2, TopOrderClicks)
+
+		require.NoError(t, err)
+		require.Len(t, top, 2)
+		assert.Empty(t, top[0].OriginalURL)
+		assert.Equal(t, "https://example.com/b", top[1].OriginalURL)
+	})
+
+	t.Run("folds in pending clicks", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		provider := &mockPendingStatsProvider{stats: map[string]int64{"b": 10, "c": 20, "d": 1, "gone": 50}}
+		svc := NewAnalyticsServiceWithPendingStats(repo, provider)
+		repo.On("TopByClicks", mock.Anything, 2).Return([]*models.URL{newURL("a", 9), newURL("b", 4)}, nil)
+		repo.On("GetByShortCode", mock.Anything, "c").Return(newURL("c", 3), nil)
+		repo.On("GetByShortCode", mock.Anything, "gone").Return(nil, models.ErrURLNotFound)
+
+		top, err := svc.GetTopURLs(ctx, 2, TopOrderClicks)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "b"}, codes(top))
+		assert.Equal(t, int64(20), top[0].PendingCount)
+		assert.Equal(t, int64(10), top[1].PendingCount)
+		// d cannot reach the board, so it is never looked up
+		repo.AssertNotCalled(t, "GetByShortCode", mock.Anything, "d")
+	})
+
+	t.Run("ranks by creation date", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		provider := &mockPendingStatsProvider{stats: map[string]int64{"old": 100, "other": 5}}
+		svc := NewAnalyticsServiceWithPendingStats(repo, provider)
+		repo.On("List", mock.Anything, 2, 0, repository.ListFilter{}).
+			Return([]*models.URL{newURL("new", 0), newURL("old", 1)}, int64(3), nil)
+
+		top, err := svc.GetTopURLs(ctx, 2, TopOrderCreated)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new", "old"}, codes(top))
+		assert.Equal(t, int64(100), top[1].PendingCount)
+		repo.AssertNotCalled(t, "GetByShortCode", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects unknown order", func(t *testing.T) {
+		svc := NewAnalyticsService(&MockURLRepository{})
+
+		_, err := svc.GetTopURLs(ctx, 10, "views")
+
+		assert.ErrorIs(t, err, ErrInvalidTopOrder)
+	})
+
+	t.Run("rejects out-of-range limit", func(t *testing.T) {
+		svc := NewAnalyticsService(&MockURLRepository{})
+
+		_, err := svc.GetTopURLs(ctx, 0, TopOrderClicks)
+
+		assert.ErrorIs(t, err, ErrInvalidPagination)
+	})
+
+	t.Run("propagates repository errors", func(t *testing.T) {
+		repo := &MockURLRepository{}
+		svc := NewAnalyticsService(repo)
+		repo.On("TopByClicks", mock.Anything, 10).Return(nil, errors.New("db down"))
+
+		_, err := svc.GetTopURLs(ctx, 10, TopOrderClicks)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/emadnahed/FastGoLink/internal/repository"
+)
+
+// ClickMilestoneNotifier sends EventURLClickMilestone when a URL's click
+// count reaches one of a fixed set of milestones, such as 100 or 1000.
+type ClickMilestoneNotifier struct {
+	repo       repository.URLRepository
+	notifier   Notifier
+	milestones []int64
+}
+
+// NewClickMilestoneNotifier creates a milestone notifier. Non-positive
+// milestones are ignored.
+func NewClickMilestoneNotifier(repo repository.URLRepository, notifier Notifier, milestones []int64) *ClickMilestoneNotifier {
+	sorted := make([]int64, 0, len(milestones))
+	for _, m := range milestones {
+		if m > 0 {
+			sorted = append(sorted, m)
+		}
+	}
+	slices.Sort(sorted)
+	return &ClickMilestoneNotifier{
+		repo:       repo,
+		notifier:   notifier,
+		milestones: slices.Compact(sorted),
+	}
+}
+
+// BatchIncrementClickCounts increments the click counts through the
+// repository and notifies for the milestones each new total passed. It
+// implements analytics.ClickRepository, so it can sit between the click
+// counter and the repository.
+//
+// The totals come from the update itself; a URL is only read back, for its
+// destination, when it passed a milestone.
+func (m *ClickMilestoneNotifier) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
+	totals, err := m.repo.BatchIncrementClickCounts(ctx, counts)
+	if err != nil {
+		return nil, err
+	}
+
+	for shortCode, total := range totals {
+		before := total - counts[shortCode]
+		if !m.passes(before, total) {
+			continue
+		}
+		var originalURL string
+		if url, err := m.repo.GetByShortCode(ctx, shortCode); err == nil {
+			originalURL = url.OriginalURL
+		}
+		m.Observe(shortCode, originalURL, before, total)
+	}
+	return totals, nil
+}
+
+// passes reports whether a milestone lies in (before, after].
+func (m *ClickMilestoneNotifier) passes(before, after int64) bool {
+	i, found := slices.BinarySearch(m.milestones, before)
+	if found {
+		i++
+	}
+	return i < len(m.milestones) && m.milestones[i] <= after
+}
+
+// Observe notifies for every milestone in (before, after].
+func (m *ClickMilestoneNotifier) Observe(shortCode, originalURL string, before, after int64) {
+	for _, milestone := range m.milestones {
+		if milestone > after {
+			return
+		}
+		if milestone > before {
+			m.notifier.Notify(LinkEvent{
+				Type:        EventURLClickMilestone,
+				ShortCode:   shortCode,
+				OriginalURL: originalURL,
+				ClickCount:  after,
+				Milestone:   milestone,
+			})
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+func TestClickMilestoneNotifier_Observe(t *testing.T) {
+	notifier := &recordingNotifier{}
+	m := NewClickMilestoneNotifier(nil, notifier, []int64{1000, 10, 100, 0, 100})
+
+	m.Observe("abc1234", "https://example.com", 5, 9)
+	assert.Empty(t, notifier.Events())
+
+	m.Observe("abc1234", "https://example.com", 9, 10)
+	m.Observe("abc1234", "https://example.com", 10, 11)
+	// A large batch can pass several milestones at once
+	m.Observe("abc1234", "https://example.com", 50, 1500)
+
+	events := notifier.Events()
+	require.Len(t, events, 3)
+	for i, milestone := range []int64{10, 100, 1000} {
+		assert.Equal(t, EventURLClickMilestone, events[i].Type)
+		assert.Equal(t, "abc1234", events[i].ShortCode)
+		assert.Equal(t, "https://example.com", events[i].OriginalURL)
+		assert.Equal(t, milestone, events[i].Milestone)
+	}
+	assert.Equal(t, int64(1500), events[2].ClickCount)
+}
+
+func TestClickMilestoneNotifier_BatchIncrementClickCounts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("notifies from the totals the flush returns", func(t *testing.T) {
+		repo := new(MockURLRepository)
+		notifier := &recordingNotifier{}
+		counts := map[string]int64{"hot": 5, "cold": 1}
+		totals := map[string]int64{"hot": 102, "cold": 3}
+		repo.On("BatchIncrementClickCounts", ctx, counts).Return(totals, nil)
+		repo.On("GetByShortCode", ctx, "hot").Return(&models.URL{ShortCode: "hot", OriginalURL: "https://hot.example", ClickCount: 90}, nil)
+
+		m := NewClickMilestoneNotifier(repo, notifier, []int64{100})
+		got, err := m.BatchIncrementClickCounts(ctx, counts)
+		require.NoError(t, err)
+		assert.Equal(t, totals, got)
+
+		events := notifier.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, "hot", events[0].ShortCode)
+		assert.Equal(t, "https://hot.example", events[0].OriginalURL)
+		assert.Equal(t, int64(100), events[0].Milestone)
+		// The count comes from the flush, not from a possibly stale read
+		assert.Equal(t, int64(102), events[0].ClickCount)
+		// Only URLs that passed a milestone are read back
+		repo.AssertNotCalled(t, "GetByShortCode", ctx, "cold")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("a total landing on a milestone counts as passing it", func(t *testing.T) {
+		repo := new(MockURLRepository)
+		notifier := &recordingNotifier{}
+		counts := map[string]int64{"hot": 2, "warm": 1}
+		repo.On("BatchIncrementClickCounts", ctx, counts).Return(map[string]int64{"hot": 100, "warm": 101}, nil)
+		repo.On("GetByShortCode", ctx, "hot").Return(nil, models.ErrURLNotFound)
+
+		m := NewClickMilestoneNotifier(repo, notifier, []int64{10, 100})
+		_, err := m.BatchIncrementClickCounts(ctx, counts)
+		require.NoError(t, err)
+
+		events := notifier.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, "hot", events[0].ShortCode)
+		assert.Empty(t, events[0].OriginalURL)
+		repo.AssertNotCalled(t, "GetByShortCode", ctx, "warm")
+	})
+
+	t.Run("flush errors are returned without lookups", func(t *testing.T) {
+		repo := new(MockURLRepository)
+		counts := map[string]int64{"hot": 5}
+		repo.On("BatchIncrementClickCounts", ctx, counts).Return(nil, errors.New("db down"))
+
+		m := NewClickMilestoneNotifier(repo, &recordingNotifier{}, []int64{100})
+		_, err := m.BatchIncrementClickCounts(ctx, counts)
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "GetByShortCode", mock.Anything, mock.Anything)
+	})
+}
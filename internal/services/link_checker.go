@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
+	"github.com/emadnahed/FastGoLink/internal/security"
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// LinkCheckerConfig holds tunable settings for LinkChecker.
+type LinkCheckerConfig struct {
+	Interval     time.Duration // How often a batch of links is checked
+	RecheckAfter time.Duration // Minimum time between checks of the same link
+	BatchSize    int           // Links checked per pass
+	Concurrency  int           // Maximum requests in flight
+	RateLimit    int           // Maximum requests started per second; 0 for no limit
+	Timeout      time.Duration // Timeout for a single check
+	UserAgent    string        // User-Agent sent with each check
+
+	// AllowPrivateIPs lets checks connect to loopback, private and link-local
+	// addresses. Leave it off unless such destinations are allowed at create
+	// time too, or the checker can be used to probe the internal network.
+	AllowPrivateIPs bool
+}
+
+// DefaultLinkCheckerConfig returns the default LinkChecker configuration.
+func DefaultLinkCheckerConfig() LinkCheckerConfig {
+	return LinkCheckerConfig{
+		Interval:     10 * time.Minute,
+		RecheckAfter: 24 * time.Hour,
+		BatchSize:    100,
+		Concurrency:  4,
+		RateLimit:    5,
+		Timeout:      10 * time.Second,
+		UserAgent:    "FastGoLink-LinkChecker/1.0",
+	}
+}
+
+// LinkChecker periodically probes the destinations of stored URLs and records
+// the HTTP status each returns, so dead links can be found. It sends HEAD
+// requests, falling back to GET for servers that do not support HEAD, and
+// follows redirects. Unless AllowPrivateIPs is set, connections to private
+// addresses fail and the link is recorded as unreachable, including when a
+// redirect or a DNS answer leads there. robots.txt is not consulted.
+type LinkChecker struct {
+	repo   repository.LinkStatusRepository
+	client *http.Client
+	cfg    LinkCheckerConfig
+	log    *logger.Logger
+	now    func() time.Time
+}
+
+// NewLinkChecker creates a link checker. Zero config fields other than
+// RateLimit take their defaults, and log may be nil.
+func NewLinkChecker(repo repository.LinkStatusRepository, cfg LinkCheckerConfig, log *logger.Logger) *LinkChecker {
+	return NewLinkCheckerWithClient(repo, newLinkCheckClient(cfg.AllowPrivateIPs), cfg, log)
+}
+
+// newLinkCheckClient creates the client checks are sent with. Every
+// connection, including those made for redirects, is checked against the
+// address actually dialed, so hostnames resolving to private addresses are
+// caught too. Proxies are not used, since the check would see only the
+// proxy's address.
+func newLinkCheckClient(allowPrivateIPs bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivateIPs {
+		dialer.Control = security.PublicDialControl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+// NewLinkCheckerWithClient creates a link checker that sends its requests
+// with client. cfg.Timeout applies per request on top of the client's own.
+func NewLinkCheckerWithClient(repo repository.LinkStatusRepository, client *http.Client, cfg LinkCheckerConfig, log *logger.Logger) *LinkChecker {
+	defaults := DefaultLinkCheckerConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.RecheckAfter <= 0 {
+		cfg.RecheckAfter = defaults.RecheckAfter
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaults.Concurrency
+	}
+	if cfg.RateLimit < 0 {
+		cfg.RateLimit = 0
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaults.UserAgent
+	}
+	return &LinkChecker{
+		repo:   repo,
+		client: client,
+		cfg:    cfg,
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+// Run checks a batch of links every interval until ctx is cancelled.
+// A failed pass is logged and retried on the next tick.
+func (c *LinkChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.checkBatch(ctx)
+		}
+	}
+}
+
+// checkBatch checks the links that are due, at most Concurrency at a time
+// and RateLimit per second, and returns how many were checked.
+func (c *LinkChecker) checkBatch(ctx context.Context) int {
+	urls, err := c.repo.ListLinksToCheck(ctx, c.now().Add(-c.cfg.RecheckAfter), c.cfg.BatchSize)
+	if err != nil {
+		if c.log != nil && ctx.Err() == nil {
+			c.log.Error("failed to list links to check", "error", err.Error())
+		}
+		return 0
+	}
+
+	var throttle <-chan time.Time
+	if c.cfg.RateLimit > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(c.cfg.RateLimit))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+		broken  int
+	)
+	sem := make(chan struct{}, c.cfg.Concurrency)
+
+dispatch:
+	for _, url := range urls {
+		if throttle != nil {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case <-throttle:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(url *models.URL) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status := c.probe(ctx, url.OriginalURL)
+			if err := c.repo.UpdateLinkStatus(ctx, url.ShortCode, status, c.now()); err != nil {
+				if c.log != nil && ctx.Err() == nil {
+					c.log.Error("failed to record link status", "short_code", url.ShortCode, "error", err.Error())
+				}
+				return
+			}
+
+			url.LinkStatus = &status
+			isBroken := url.IsLinkBroken()
+			mu.Lock()
+			checked++
+			if isBroken {
+				broken++
+			}
+			mu.Unlock()
+			if isBroken && c.log != nil {
+				c.log.Warn("broken link", "short_code", url.ShortCode, "status", status)
+			}
+		}(url)
+	}
+	wg.Wait()
+
+	if c.log != nil && checked > 0 {
+		c.log.Info("checked links", "count", checked, "broken", broken)
+	}
+	return checked
+}
+
+// probe requests rawURL and returns the response status, or
+// models.LinkStatusUnreachable if no response was received.
+func (c *LinkChecker) probe(ctx context.Context, rawURL string) int {
+	status := c.request(ctx, http.MethodHead, rawURL)
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		status = c.request(ctx, http.MethodGet, rawURL)
+	}
+	return status
+}
+
+// request sends a single request and returns the response status.
+func (c *LinkChecker) request(ctx context.Context, method, rawURL string) int {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return models.LinkStatusUnreachable
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return models.LinkStatusUnreachable
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	return resp.StatusCode
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emadnahed/FastGoLink/internal/models"
+)
+
+// fakeLinkStatusRepository keeps links and recorded statuses in memory.
+type fakeLinkStatusRepository struct {
+	mu       sync.Mutex
+	links    []*models.URL
+	statuses map[string]int
+	before   time.Time
+}
+
+func (r *fakeLinkStatusRepository) ListLinksToCheck(_ context.Context, checkedBefore time.Time, limit int) ([]*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.before = checkedBefore
+	var urls []*models.URL
+	for _, link := range r.links {
+		if _, done := r.statuses[link.ShortCode]; done || len(urls) == limit {
+			continue
+		}
+		urls = append(urls, &models.URL{ID: link.ID, ShortCode: link.ShortCode, OriginalURL: link.OriginalURL})
+	}
+	return urls, nil
+}
+
+func (r *fakeLinkStatusRepository) UpdateLinkStatus(_ context.Context, shortCode string, status int, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.statuses == nil {
+		r.statuses = make(map[string]int)
+	}
+	r.statuses[shortCode] = status
+	return nil
+}
+
+func TestLinkChecker_RecordsStatuses(t *testing.T) {
+	var userAgent atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent.Store(r.UserAgent())
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	repo := &fakeLinkStatusRepository{links: []*models.URL{
+		{ID: 1, ShortCode: "ok", OriginalURL: srv.URL + "/ok"},
+		{ID: 2, ShortCode: "moved", OriginalURL: srv.URL + "/moved"},
+		{ID: 3, ShortCode: "gone", OriginalURL: srv.URL + "/gone"},
+		{ID: 4, ShortCode: "missing", OriginalURL: srv.URL + "/missing"},
+		{ID: 5, ShortCode: "error", OriginalURL: srv.URL + "/error"},
+		{ID: 6, ShortCode: "nohead", OriginalURL: srv.URL + "/no-head"},
+		{ID: 7, ShortCode: "down", OriginalURL: closed.URL + "/ok"},
+	}}
+
+	checker := NewLinkCheckerWithClient(repo, srv.Client(), LinkCheckerConfig{Timeout: time.Second}, nil)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	checker.now = func() time.Time { return now }
+
+	checked := checker.checkBatch(context.Background())
+
+	assert.Equal(t, 7, checked)
+	assert.Equal(t, now.Add(-DefaultLinkCheckerConfig().RecheckAfter), repo.before)
+	assert.Equal(t, map[string]int{
+		"ok":      http.StatusOK,
+		"moved":   http.StatusOK,
+		"gone":    http.StatusGone,
+		"missing": http.StatusNotFound,
+		"error":   http.StatusInternalServerError,
+		"nohead":  http.StatusOK,
+		"down":    models.LinkStatusUnreachable,
+	}, repo.statuses)
+	assert.Equal(t, DefaultLinkCheckerConfig().UserAgent, userAgent.Load())
+
+	// Checked links are not returned again
+	assert.Zero(t, checker.checkBatch(context.Background()))
+}
+
+func TestLinkChecker_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	repo := &fakeLinkStatusRepository{}
+	for i := range 8 {
+		repo.links = append(repo.links, &models.URL{
+			ID: int64(i + 1), ShortCode: string(rune('a' + i)), OriginalURL: srv.URL,
+		})
+	}
+
+	checker := NewLinkCheckerWithClient(repo, srv.Client(), LinkCheckerConfig{Concurrency: 2}, nil)
+
+	assert.Equal(t, 8, checker.checkBatch(context.Background()))
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestLinkChecker_RateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	repo := &fakeLinkStatusRepository{}
+	for i := range 4 {
+		repo.links = append(repo.links, &models.URL{
+			ID: int64(i + 1), ShortCode: string(rune('a' + i)), OriginalURL: srv.URL,
+		})
+	}
+
+	checker := NewLinkCheckerWithClient(repo, srv.Client(), LinkCheckerConfig{Concurrency: 4, RateLimit: 20}, nil)
+
+	start := time.Now()
+	assert.Equal(t, 4, checker.checkBatch(context.Background()))
+	// 20 per second spaces request starts 50ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestLinkChecker_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	links := func() *fakeLinkStatusRepository {
+		return &fakeLinkStatusRepository{links: []*models.URL{
+			{ID: 1, ShortCode: "local", OriginalURL: srv.URL + "/ok"},
+			{ID: 2, ShortCode: "redirect", OriginalURL: srv.URL + "/redirect"},
+		}}
+	}
+
+	repo := links()
+	NewLinkChecker(repo, LinkCheckerConfig{}, nil).checkBatch(context.Background())
+	assert.Equal(t, map[string]int{
+		"local":    models.LinkStatusUnreachable,
+		"redirect": models.LinkStatusUnreachable,
+	}, repo.statuses)
+
+	repo = links()
+	NewLinkChecker(repo, LinkCheckerConfig{AllowPrivateIPs: true}, nil).checkBatch(context.Background())
+	assert.Equal(t, map[string]int{"local": http.StatusOK, "redirect": http.StatusOK}, repo.statuses)
+}
+
+func TestLinkChecker_RunStopsOnCancel(t *testing.T) {
+	repo := &fakeLinkStatusRepository{}
+	checker := NewLinkChecker(repo, LinkCheckerConfig{Interval: 10 * time.Millisecond}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "Run did not return after cancel")
+	}
+}
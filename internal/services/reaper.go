@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/internal/repository"
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// Reaper periodically deletes expired URLs from the repository.
+type Reaper struct {
+	repo     repository.URLRepository
+	interval time.Duration
+	log      *logger.Logger
+	notifier Notifier
+}
+
+// NewReaper creates a reaper that deletes expired URLs every interval.
+// log may be nil.
+func NewReaper(repo repository.URLRepository, interval time.Duration, log *logger.Logger) *Reaper {
+	return &Reaper{
+		repo:     repo,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// SetNotifier sets the notifier sent EventURLsExpired after a pass that
+// deleted URLs.
+func (r *Reaper) SetNotifier(n Notifier) {
+	r.notifier = n
+}
+
+// Run deletes expired URLs every interval until ctx is cancelled.
+// A failed pass is logged and retried on the next tick.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+// reap runs a single DeleteExpired pass.
+func (r *Reaper) reap(ctx context.Context) {
+	deleted, err := r.repo.DeleteExpired(ctx)
+	count := int64(len(deleted))
+	if err == nil && count > 0 && r.notifier != nil {
+		r.notifier.Notify(LinkEvent{Type: EventURLsExpired, Count: count})
+	}
+	if r.log == nil {
+		return
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			r.log.Error("failed to delete expired URLs", "error", err.Error())
+		}
+		return
+	}
+	if count > 0 {
+		r.log.Info("deleted expired URLs", "count", count)
+	} else {
+		r.log.Debug("no expired URLs to delete")
+	}
+}
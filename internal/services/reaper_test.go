@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReaper_DeletesExpiredRepeatedly(t *testing.T) {
+	repo := new(MockURLRepository)
+	calls := make(chan struct{}, 10)
+	repo.On("DeleteExpired", mock.Anything).Return([]string{"abc1234", "def5678"}, nil).Run(func(mock.Arguments) {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+	})
+
+	var buf bytes.Buffer
+	reaper := NewReaper(repo, 10*time.Millisecond, logger.New(&buf, "info"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		reaper.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("DeleteExpired called %d times, want at least 3", i)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after context cancellation")
+	}
+	assert.Contains(t, buf.String(), "deleted expired URLs")
+}
+
+func TestReaper_StopsOnCancellation(t *testing.T) {
+	repo := new(MockURLRepository)
+	reaper := NewReaper(repo, time.Hour, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		reaper.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after context cancellation")
+	}
+	repo.AssertNotCalled(t, "DeleteExpired", mock.Anything)
+}
+
+func TestReaper_ContinuesAfterError(t *testing.T) {
+	repo := new(MockURLRepository)
+	calls := make(chan struct{}, 10)
+	repo.On("DeleteExpired", mock.Anything).Return(nil, errors.New("database down")).Run(func(mock.Arguments) {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+	})
+
+	var buf bytes.Buffer
+	reaper := NewReaper(repo, 10*time.Millisecond, logger.New(&buf, "info"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		reaper.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("DeleteExpired called %d times, want at least 2", i)
+		}
+	}
+
+	cancel()
+	<-done
+	assert.Contains(t, buf.String(), "failed to delete expired URLs")
+}
+
+func TestReaper_NotifiesExpired(t *testing.T) {
+	repo := new(MockURLRepository)
+	repo.On("DeleteExpired", mock.Anything).Return([]string{"a1", "b2", "c3", "d4"}, nil).Once()
+	repo.On("DeleteExpired", mock.Anything).Return([]string(nil), nil)
+	notifier := &recordingNotifier{}
+
+	reaper := NewReaper(repo, time.Hour, nil)
+	reaper.SetNotifier(notifier)
+	reaper.reap(context.Background())
+	reaper.reap(context.Background())
+
+	// Passes that delete nothing send no event
+	events := notifier.Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventURLsExpired, events[0].Type)
+	assert.Equal(t, int64(4), events[0].Count)
+}
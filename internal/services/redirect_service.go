@@ -3,32 +3,99 @@ package services
 
 import (
 	"context"
+	"errors"
+	neturl "net/url"
 
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/geo"
 	"github.com/emadnahed/FastGoLink/internal/models"
 	"github.com/emadnahed/FastGoLink/internal/repository"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// Password errors returned for protected short links.
+var (
+	ErrPasswordRequired = errors.New("password required")
+	ErrInvalidPassword  = errors.New("invalid password")
 )
 
 // ClickRecorder records click events for analytics.
 type ClickRecorder interface {
-	RecordClick(shortCode string)
+	RecordClick(ctx context.Context, shortCode string)
+}
+
+// ClickSourceRecorder is implemented by ClickRecorders that also record where
+// a click came from.
+type ClickSourceRecorder interface {
+	RecordClickFrom(ctx context.Context, shortCode string, src analytics.ClickSource)
+}
+
+// ClickCountMode selects how redirects count clicks.
+type ClickCountMode string
+
+// Click count modes accepted by SetClickCountMode.
+const (
+	// ClickCountBatched hands clicks to the ClickRecorder, which counts them
+	// in memory and flushes them in batches. It is the default, and falls back
+	// to ClickCountSync when there is no recorder.
+	ClickCountBatched ClickCountMode = "batched"
+
+	// ClickCountSync increments the click count in the database during the
+	// redirect. Counts are never lost to a crash, but each redirect pays for a
+	// write and no time-series, referrer or country analytics are recorded.
+	ClickCountSync ClickCountMode = "sync"
+
+	// ClickCountOff does not count clicks. Click-limited links still claim
+	// their clicks, since their limit depends on it.
+	ClickCountOff ClickCountMode = "off"
+)
+
+// RedirectOptions carries the optional inputs of a redirect.
+type RedirectOptions struct {
+	Password  string // Unlocks password-protected links
+	Referrer  string // Referer header of the request, for analytics
+	UserAgent string // User-Agent header of the request, for analytics
+	ClientIP  string // Client IP of the request, resolved to a country for analytics
+
+	// SkipPreview follows links created with ShowPreview instead of
+	// returning a result with ShowPreview set.
+	SkipPreview bool
 }
 
 // RedirectResult represents the result of a redirect lookup.
 type RedirectResult struct {
 	OriginalURL string
 	Permanent   bool
-	CacheHit    bool
+
+	// URL is the resolved link, with OriginalURL set to the destination
+	// above rather than the one stored for the link.
+	URL *models.URL
+
+	// ShowPreview is set when the link shows an interstitial page before
+	// redirecting. No click has been counted; following the link takes a
+	// second redirect with RedirectOptions.SkipPreview.
+	ShowPreview bool
 }
 
 // RedirectService defines the interface for URL redirect operations.
 type RedirectService interface {
 	Redirect(ctx context.Context, shortCode string) (*RedirectResult, error)
+	RedirectWithPassword(ctx context.Context, shortCode, password string) (*RedirectResult, error)
+	RedirectWithOptions(ctx context.Context, shortCode string, opts RedirectOptions) (*RedirectResult, error)
+	Preview(ctx context.Context, shortCode, password string) (*models.URL, error)
 }
 
 // RedirectServiceImpl implements RedirectService.
 type RedirectServiceImpl struct {
 	repo          repository.URLRepository
 	clickRecorder ClickRecorder
+	geo           geo.Resolver
+	milestones    *ClickMilestoneNotifier
+	clickMode     ClickCountMode
 }
 
 // NewRedirectService creates a new RedirectService instance.
@@ -46,31 +113,210 @@ func NewRedirectServiceWithAnalytics(repo repository.URLRepository, clickRecorde
 	}
 }
 
+// NewRedirectServiceWithGeo creates a new RedirectService with click analytics
+// that also records the country of each click, resolved with resolver.
+func NewRedirectServiceWithGeo(repo repository.URLRepository, clickRecorder ClickRecorder, resolver geo.Resolver) *RedirectServiceImpl {
+	return &RedirectServiceImpl{
+		repo:          repo,
+		clickRecorder: clickRecorder,
+		geo:           resolver,
+	}
+}
+
+// SetClickMilestones sets the notifier told when clicks claimed by
+// click-limited URLs reach a milestone. Other clicks are counted in batches
+// and reach milestones through the click counter's repository.
+func (s *RedirectServiceImpl) SetClickMilestones(m *ClickMilestoneNotifier) {
+	s.milestones = m
+}
+
+// SetClickCountMode sets how clicks are counted. The default is
+// ClickCountBatched. Clicks counted with ClickCountSync do not reach click
+// milestones, since the repository does not report the new count.
+func (s *RedirectServiceImpl) SetClickCountMode(mode ClickCountMode) {
+	s.clickMode = mode
+}
+
 // Redirect looks up a URL by short code and returns the original URL for redirecting.
 // It records click events for analytics (non-blocking to not impact redirect latency).
+// Password-protected links return ErrPasswordRequired; see RedirectWithPassword.
 func (s *RedirectServiceImpl) Redirect(ctx context.Context, shortCode string) (*RedirectResult, error) {
+	return s.RedirectWithPassword(ctx, shortCode, "")
+}
+
+// RedirectWithPassword is like Redirect but unlocks password-protected links.
+// It returns ErrPasswordRequired when the link is protected and no password is
+// given, and ErrInvalidPassword when the password does not match. Clicks are
+// only recorded for successful redirects. Links created with ShowPreview are
+// followed without an interstitial.
+func (s *RedirectServiceImpl) RedirectWithPassword(ctx context.Context, shortCode, password string) (*RedirectResult, error) {
+	return s.RedirectWithOptions(ctx, shortCode, RedirectOptions{Password: password, SkipPreview: true})
+}
+
+// RedirectWithOptions is like RedirectWithPassword and also passes the click's
+// referrer, user agent and, when a GeoIP resolver is configured, country to the
+// click recorder when it is a ClickSourceRecorder. Clicks on click-limited
+// links are counted by ClaimClick and carry no source. The user agent also
+// picks the destination of links with platform targets.
+//
+// Unless opts.SkipPreview is set, links created with ShowPreview return a
+// result with ShowPreview set and are not counted.
+func (s *RedirectServiceImpl) RedirectWithOptions(ctx context.Context, shortCode string, opts RedirectOptions) (_ *RedirectResult, err error) {
+	ctx, span := tracer.Start(ctx, "RedirectService.Redirect", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	url, err := s.resolve(ctx, shortCode, opts.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := url.OriginalURL
+	if len(url.PlatformTargets) > 0 {
+		destination = url.TargetFor(platformOf(opts.UserAgent))
+	}
+	destination = appendQueryParams(destination, url.AppendParams)
+	resolved := *url
+	resolved.OriginalURL = destination
+
+	// The click is counted when the interstitial is continued, not shown
+	if url.ShowPreview && !opts.SkipPreview {
+		return &RedirectResult{OriginalURL: destination, ShowPreview: true, URL: &resolved}, nil
+	}
+
+	// Click-limited URLs claim their click synchronously so concurrent
+	// redirects can't exceed the limit; the claim also counts the click
+	if url.MaxClicks != nil {
+		clickCount, err := s.repo.ClaimClick(ctx, shortCode)
+		if err != nil {
+			return nil, err
+		}
+		if s.milestones != nil {
+			s.milestones.Observe(shortCode, url.OriginalURL, clickCount-1, clickCount)
+		}
+	} else {
+		s.countClick(ctx, shortCode, opts)
+	}
+
+	return &RedirectResult{
+		OriginalURL: destination,
+		// 301 when requested, otherwise 302 (allows analytics updates). Click-limited
+		// URLs always use 302 since browsers cache 301s and would bypass the limit
+		Permanent: url.Permanent && url.MaxClicks == nil,
+		URL:       &resolved,
+	}, nil
+}
+
+// countClick counts a click on a URL without a click limit according to the
+// click count mode.
+func (s *RedirectServiceImpl) countClick(ctx context.Context, shortCode string, opts RedirectOptions) {
+	switch {
+	case s.clickMode == ClickCountOff:
+		return
+	case s.clickMode != ClickCountSync && s.clickRecorder != nil:
+		// Record click for analytics (non-blocking)
+		if sr, ok := s.clickRecorder.(ClickSourceRecorder); ok {
+			src := analytics.ClickSource{Referrer: opts.Referrer, UserAgent: opts.UserAgent}
+			if s.geo != nil {
+				src.Country = geo.CountryOf(s.geo, opts.ClientIP)
+			}
+			sr.RecordClickFrom(ctx, shortCode, src)
+		} else {
+			s.clickRecorder.RecordClick(ctx, shortCode)
+		}
+	default:
+		// Increment directly; errors are logged rather than failing the redirect
+		if err := s.repo.IncrementClickCount(ctx, shortCode); err != nil {
+			logger.FromContext(ctx).Warn("failed to count click", "short_code", shortCode, "error", err.Error())
+		}
+	}
+}
+
+// Preview returns the URL a redirect would lead to without redirecting or
+// counting a click, for link previews. It fails like RedirectWithPassword for
+// missing, disabled, expired, exhausted and locked links.
+func (s *RedirectServiceImpl) Preview(ctx context.Context, shortCode, password string) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "RedirectService.Preview", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	return s.resolve(ctx, shortCode, password)
+}
+
+// resolve looks up a URL that can be followed, unlocking it with password
+// when it is protected.
+func (s *RedirectServiceImpl) resolve(ctx context.Context, shortCode, password string) (*models.URL, error) {
 	// Look up URL (cache-first via CachedURLRepository)
 	url, err := s.repo.GetByShortCode(ctx, shortCode)
 	if err != nil {
 		return nil, err
 	}
 
+	// Disabled URLs keep their code but do not redirect
+	if url.Disabled {
+		return nil, models.ErrURLDisabled
+	}
+
 	// Check if URL has expired
 	if url.IsExpired() {
 		return nil, models.ErrURLExpired
 	}
 
-	// Record click for analytics (non-blocking)
-	if s.clickRecorder != nil {
-		s.clickRecorder.RecordClick(shortCode)
-	} else {
-		// Fallback: increment directly (swallow errors to not impact latency)
-		_ = s.repo.IncrementClickCount(ctx, shortCode)
+	// Check if URL has used up its click limit
+	if url.IsExhausted() {
+		return nil, models.ErrURLExhausted
 	}
 
-	return &RedirectResult{
-		OriginalURL: url.OriginalURL,
-		Permanent:   false, // Use 302 for temporary redirects (allows analytics updates)
-		CacheHit:    false, // This would be set by the cache layer if we had access to that info
-	}, nil
+	if url.IsPasswordProtected() {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(url.PasswordHash), []byte(password)) != nil {
+			logger.FromContext(ctx).Warn("wrong link password", "short_code", shortCode)
+			return nil, ErrInvalidPassword
+		}
+	}
+
+	return url, nil
+}
+
+// platformOf returns the models platform of a User-Agent header, or "" when
+// it has no platform targets of its own.
+func platformOf(userAgent string) string {
+	_, os := analytics.ParseUserAgent(userAgent)
+	switch os {
+	case "iOS":
+		return models.PlatformIOS
+	case "Android":
+		return models.PlatformAndroid
+	}
+	return ""
+}
+
+// appendQueryParams adds params to the query of rawURL, skipping parameters
+// it already has. The existing query and fragment are kept as they are.
+func appendQueryParams(rawURL string, params map[string]string) string {
+	if len(params) == 0 {
+		return rawURL
+	}
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	existing := u.Query()
+	extra := neturl.Values{}
+	for name, value := range params {
+		if !existing.Has(name) {
+			extra.Set(name, value)
+		}
+	}
+	if len(extra) == 0 {
+		return rawURL
+	}
+
+	if u.RawQuery == "" {
+		u.RawQuery = extra.Encode()
+	} else {
+		u.RawQuery += "&" + extra.Encode()
+	}
+	return u.String()
 }
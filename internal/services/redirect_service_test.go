@@ -1,15 +1,23 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
+	"github.com/emadnahed/FastGoLink/internal/analytics"
+	"github.com/emadnahed/FastGoLink/internal/geo"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/pkg/logger"
 )
 
 // Note: MockURLRepository is defined in url_service_test.go
@@ -79,6 +87,27 @@ func TestRedirectService_Redirect_Expired(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestRedirectService_Redirect_Disabled(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	recorder := &mockClickRecorder{}
+	service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+	mockRepo.On("GetByShortCode", mock.Anything, "paused").Return(&models.URL{
+		ID:          3,
+		ShortCode:   "paused",
+		OriginalURL: "https://example.com/paused",
+		CreatedAt:   time.Now(),
+		Disabled:    true,
+	}, nil)
+
+	result, err := service.Redirect(context.Background(), "paused")
+
+	assert.ErrorIs(t, err, models.ErrURLDisabled)
+	assert.Nil(t, result)
+	assert.Empty(t, recorder.recordedCodes)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestRedirectService_Redirect_NoExpiry(t *testing.T) {
 	mockRepo := new(MockURLRepository)
 	service := NewRedirectService(mockRepo)
@@ -102,6 +131,28 @@ func TestRedirectService_Redirect_NoExpiry(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestRedirectService_Redirect_Permanent(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	service := NewRedirectService(mockRepo)
+
+	mockRepo.On("GetByShortCode", mock.Anything, "perm123").Return(&models.URL{
+		ID:          4,
+		ShortCode:   "perm123",
+		OriginalURL: "https://example.com/landing",
+		CreatedAt:   time.Now(),
+		Permanent:   true,
+	}, nil)
+	mockRepo.On("IncrementClickCount", mock.Anything, "perm123").Return(nil)
+
+	result, err := service.Redirect(context.Background(), "perm123")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.True(t, result.Permanent)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestRedirectService_Redirect_IncrementFailure(t *testing.T) {
 	// Click count increment failures should not fail the redirect
 	mockRepo := new(MockURLRepository)
@@ -147,7 +198,7 @@ type mockClickRecorder struct {
 	recordedCodes []string
 }
 
-func (m *mockClickRecorder) RecordClick(shortCode string) {
+func (m *mockClickRecorder) RecordClick(_ context.Context, shortCode string) {
 	m.recordedCodes = append(m.recordedCodes, shortCode)
 }
 
@@ -187,3 +238,517 @@ func TestRedirectService_Redirect_WithClickRecorder(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestRedirectService_ClickCountMode(t *testing.T) {
+	newURL := func() *models.URL {
+		return &models.URL{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()}
+	}
+
+	t.Run("batched records through the recorder", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(newURL(), nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+		service.SetClickCountMode(ClickCountBatched)
+
+		_, err := service.Redirect(context.Background(), "abc1234")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"abc1234"}, recorder.recordedCodes)
+		mockRepo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
+	})
+
+	t.Run("sync increments inline and bypasses the recorder", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(newURL(), nil)
+		mockRepo.On("IncrementClickCount", mock.Anything, "abc1234").Return(nil).Once()
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+		service.SetClickCountMode(ClickCountSync)
+
+		_, err := service.Redirect(context.Background(), "abc1234")
+
+		require.NoError(t, err)
+		assert.Empty(t, recorder.recordedCodes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("off counts nothing", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(newURL(), nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+		service.SetClickCountMode(ClickCountOff)
+
+		result, err := service.Redirect(context.Background(), "abc1234")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", result.OriginalURL)
+		assert.Empty(t, recorder.recordedCodes)
+		mockRepo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
+	})
+
+	t.Run("off still claims clicks on limited links", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		limited := newURL()
+		maxClicks := int64(5)
+		limited.MaxClicks = &maxClicks
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(limited, nil)
+		mockRepo.On("ClaimClick", mock.Anything, "abc1234").Return(int64(1), nil).Once()
+		service := NewRedirectService(mockRepo)
+		service.SetClickCountMode(ClickCountOff)
+
+		_, err := service.Redirect(context.Background(), "abc1234")
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestRedirectService_Redirect_AliasCountsSeparately(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	recorder := &mockClickRecorder{}
+	for _, code := range []string{"spring", "spring-tw"} {
+		mockRepo.On("GetByShortCode", mock.Anything, code).Return(&models.URL{
+			ShortCode:   code,
+			OriginalURL: "https://example.com/sale",
+			CreatedAt:   time.Now(),
+		}, nil)
+	}
+	service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+	for _, code := range []string{"spring-tw", "spring", "spring-tw"} {
+		result, err := service.Redirect(context.Background(), code)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/sale", result.OriginalURL)
+	}
+
+	// Each code's clicks are recorded under that code
+	assert.Equal(t, []string{"spring-tw", "spring", "spring-tw"}, recorder.recordedCodes)
+}
+
+// mockSourceRecorder implements ClickRecorder and ClickSourceRecorder for testing.
+type mockSourceRecorder struct {
+	mockClickRecorder
+	sources []analytics.ClickSource
+}
+
+func (m *mockSourceRecorder) RecordClickFrom(_ context.Context, shortCode string, src analytics.ClickSource) {
+	m.recordedCodes = append(m.recordedCodes, shortCode)
+	m.sources = append(m.sources, src)
+}
+
+// mockGeoResolver resolves IPs from a fixed table.
+type mockGeoResolver map[string]string
+
+func (m mockGeoResolver) Country(ip net.IP) (string, error) {
+	return m[ip.String()], nil
+}
+
+func TestRedirectService_RedirectWithOptions_RecordsSource(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	recorder := &mockSourceRecorder{}
+	service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+	mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(&models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/path",
+		CreatedAt:   time.Now(),
+	}, nil)
+
+	result, err := service.RedirectWithOptions(context.Background(), "abc1234", RedirectOptions{
+		Referrer:  "https://news.ycombinator.com/item?id=1",
+		UserAgent: "Mozilla/5.0",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/path", result.OriginalURL)
+	assert.Equal(t, []string{"abc1234"}, recorder.recordedCodes)
+	assert.Equal(t, []analytics.ClickSource{{
+		Referrer:  "https://news.ycombinator.com/item?id=1",
+		UserAgent: "Mozilla/5.0",
+	}}, recorder.sources, "no country without a GeoIP resolver")
+}
+
+func TestRedirectService_RedirectWithOptions_RecordsCountry(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	recorder := &mockSourceRecorder{}
+	service := NewRedirectServiceWithGeo(mockRepo, recorder, mockGeoResolver{"203.0.113.7": "NZ"})
+
+	mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(&models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/path",
+		CreatedAt:   time.Now(),
+	}, nil)
+
+	for _, ip := range []string{"203.0.113.7", "10.0.0.1"} {
+		_, err := service.RedirectWithOptions(context.Background(), "abc1234", RedirectOptions{ClientIP: ip})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, recorder.sources, 2)
+	assert.Equal(t, "NZ", recorder.sources[0].Country)
+	assert.Equal(t, geo.UnknownCountry, recorder.sources[1].Country)
+}
+
+// requestContext returns a context carrying a logger that writes to buf and
+// the request ID the RequestID middleware would have set.
+func requestContext(buf *bytes.Buffer, requestID string) context.Context {
+	ctx := logger.NewContext(context.Background(), logger.New(buf, "debug"))
+	return context.WithValue(ctx, logger.RequestIDKey, requestID)
+}
+
+func TestRedirectService_LogsRequestID(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("GetByShortCode", mock.Anything, "locked1").Return(&models.URL{
+		ShortCode:    "locked1",
+		OriginalURL:  "https://example.com/secret",
+		PasswordHash: string(hash),
+	}, nil)
+	service := NewRedirectService(mockRepo)
+
+	var buf bytes.Buffer
+	_, err = service.RedirectWithPassword(requestContext(&buf, "req-42"), "locked1", "guess")
+	require.ErrorIs(t, err, ErrInvalidPassword)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "wrong link password", entry["msg"])
+	assert.Equal(t, "locked1", entry["short_code"])
+	assert.Equal(t, "req-42", entry["request_id"])
+}
+
+func TestRedirectService_RedirectWithPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	protected := &models.URL{
+		ID:           3,
+		ShortCode:    "locked1",
+		OriginalURL:  "https://example.com/secret",
+		CreatedAt:    time.Now(),
+		PasswordHash: string(hash),
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"missing password", "", ErrPasswordRequired},
+		{"wrong password", "guess", ErrInvalidPassword},
+		{"correct password", "s3cret", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockRepo.On("GetByShortCode", mock.Anything, "locked1").Return(protected, nil)
+			mockRepo.On("IncrementClickCount", mock.Anything, "locked1").Return(nil).Maybe()
+			service := NewRedirectService(mockRepo)
+
+			result, err := service.RedirectWithPassword(context.Background(), "locked1", tt.password)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, result)
+				// Failed attempts are not counted as clicks
+				mockRepo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/secret", result.OriginalURL)
+			mockRepo.AssertCalled(t, "IncrementClickCount", mock.Anything, "locked1")
+		})
+	}
+
+	t.Run("Redirect requires password", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShortCode", mock.Anything, "locked1").Return(protected, nil)
+		service := NewRedirectService(mockRepo)
+
+		_, err := service.Redirect(context.Background(), "locked1")
+		assert.ErrorIs(t, err, ErrPasswordRequired)
+	})
+}
+
+func TestRedirectService_Redirect_ClickLimit(t *testing.T) {
+	newLimitedURL := func(maxClicks, clickCount int64) *models.URL {
+		return &models.URL{
+			ID:          4,
+			ShortCode:   "once123",
+			OriginalURL: "https://example.com/once",
+			CreatedAt:   time.Now(),
+			ClickCount:  clickCount,
+			MaxClicks:   &maxClicks,
+			Permanent:   true,
+		}
+	}
+
+	t.Run("claims click synchronously", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "once123").Return(newLimitedURL(1, 0), nil)
+		mockRepo.On("ClaimClick", mock.Anything, "once123").Return(int64(1), nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+		result, err := service.Redirect(context.Background(), "once123")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/once", result.OriginalURL)
+		// Limited links never use cacheable 301s
+		assert.False(t, result.Permanent)
+		// The claim already counted the click
+		assert.Empty(t, recorder.recordedCodes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("claimed clicks reach milestones", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShortCode", mock.Anything, "once123").Return(newLimitedURL(200, 99), nil)
+		mockRepo.On("ClaimClick", mock.Anything, "once123").Return(int64(100), nil)
+		notifier := &recordingNotifier{}
+		service := NewRedirectService(mockRepo)
+		service.SetClickMilestones(NewClickMilestoneNotifier(mockRepo, notifier, []int64{100}))
+
+		_, err := service.Redirect(context.Background(), "once123")
+
+		require.NoError(t, err)
+		events := notifier.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, int64(100), events[0].Milestone)
+		assert.Equal(t, "https://example.com/once", events[0].OriginalURL)
+	})
+
+	t.Run("exhausted URL is rejected without claiming", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShortCode", mock.Anything, "once123").Return(newLimitedURL(1, 1), nil)
+		service := NewRedirectService(mockRepo)
+
+		result, err := service.Redirect(context.Background(), "once123")
+
+		assert.ErrorIs(t, err, models.ErrURLExhausted)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "ClaimClick", mock.Anything, mock.Anything)
+	})
+
+	t.Run("losing the race for the last click", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		// The lookup still shows a click left, but another redirect claims it first
+		mockRepo.On("GetByShortCode", mock.Anything, "once123").Return(newLimitedURL(1, 0), nil)
+		mockRepo.On("ClaimClick", mock.Anything, "once123").Return(int64(0), models.ErrURLExhausted)
+		service := NewRedirectService(mockRepo)
+
+		result, err := service.Redirect(context.Background(), "once123")
+
+		assert.ErrorIs(t, err, models.ErrURLExhausted)
+		assert.Nil(t, result)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestRedirectService_Preview(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	expired := time.Now().Add(-time.Hour)
+	maxClicks := int64(1)
+
+	tests := []struct {
+		name     string
+		url      *models.URL
+		password string
+		wantErr  error
+	}{
+		{"active URL", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com"}, "", nil},
+		{"click-limited URL with clicks left", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", MaxClicks: &maxClicks}, "", nil},
+		{"expired URL", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", ExpiresAt: &expired}, "", models.ErrURLExpired},
+		{"exhausted URL", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", MaxClicks: &maxClicks, ClickCount: 1}, "", models.ErrURLExhausted},
+		{"locked URL without password", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", PasswordHash: string(hash)}, "", ErrPasswordRequired},
+		{"locked URL with wrong password", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", PasswordHash: string(hash)}, "guess", ErrInvalidPassword},
+		{"locked URL with password", &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", PasswordHash: string(hash)}, "s3cret", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			recorder := &mockClickRecorder{}
+			mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(tt.url, nil)
+			service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+			url, err := service.Preview(context.Background(), "abc1234", tt.password)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, url)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "https://example.com", url.OriginalURL)
+			}
+			// Previews never count as clicks
+			assert.Empty(t, recorder.recordedCodes)
+			mockRepo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "ClaimClick", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShortCode", mock.Anything, "missing").Return(nil, models.ErrURLNotFound)
+		service := NewRedirectService(mockRepo)
+
+		_, err := service.Preview(context.Background(), "missing", "")
+
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+}
+
+func TestRedirectService_RedirectWithOptions_ShowPreview(t *testing.T) {
+	previewURL := &models.URL{ShortCode: "abc1234", OriginalURL: "https://example.com", ShowPreview: true}
+
+	t.Run("returns the interstitial without counting", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(previewURL, nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+		result, err := service.RedirectWithOptions(context.Background(), "abc1234", RedirectOptions{})
+
+		require.NoError(t, err)
+		assert.True(t, result.ShowPreview)
+		assert.Equal(t, "https://example.com", result.OriginalURL)
+		assert.Empty(t, recorder.recordedCodes)
+	})
+
+	t.Run("skipping the preview counts the click", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(previewURL, nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+		result, err := service.RedirectWithOptions(context.Background(), "abc1234", RedirectOptions{SkipPreview: true})
+
+		require.NoError(t, err)
+		assert.False(t, result.ShowPreview)
+		assert.Equal(t, []string{"abc1234"}, recorder.recordedCodes)
+	})
+
+	t.Run("RedirectWithPassword follows the link", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		recorder := &mockClickRecorder{}
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(previewURL, nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+		result, err := service.RedirectWithPassword(context.Background(), "abc1234", "")
+
+		require.NoError(t, err)
+		assert.False(t, result.ShowPreview)
+		assert.Equal(t, []string{"abc1234"}, recorder.recordedCodes)
+	})
+}
+
+func TestAppendQueryParams(t *testing.T) {
+	utm := map[string]string{"utm_source": "newsletter", "utm_medium": "email"}
+
+	tests := []struct {
+		name   string
+		rawURL string
+		params map[string]string
+		want   string
+	}{
+		{"no params", "https://example.com/page?a=1", nil, "https://example.com/page?a=1"},
+		{"no query", "https://example.com/page", utm, "https://example.com/page?utm_medium=email&utm_source=newsletter"},
+		{"existing query", "https://example.com/page?a=1&b=2", utm, "https://example.com/page?a=1&b=2&utm_medium=email&utm_source=newsletter"},
+		{"keeps existing values", "https://example.com/page?utm_source=twitter", utm, "https://example.com/page?utm_source=twitter&utm_medium=email"},
+		{"all params present", "https://example.com/page?utm_source=a&utm_medium=b", utm, "https://example.com/page?utm_source=a&utm_medium=b"},
+		{"fragment", "https://example.com/page#section-2", utm, "https://example.com/page?utm_medium=email&utm_source=newsletter#section-2"},
+		{"query and fragment", "https://example.com/page?a=1#top", utm, "https://example.com/page?a=1&utm_medium=email&utm_source=newsletter#top"},
+		{"escapes values", "https://example.com/", map[string]string{"utm_campaign": "spring sale&more"}, "https://example.com/?utm_campaign=spring+sale%26more"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, appendQueryParams(tt.rawURL, tt.params))
+		})
+	}
+}
+
+func TestRedirectService_Redirect_AppendParams(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("GetByShortCode", mock.Anything, "utm1234").Return(&models.URL{
+		ShortCode:    "utm1234",
+		OriginalURL:  "https://example.com/page?ref=home#pricing",
+		AppendParams: map[string]string{"utm_source": "newsletter", "ref": "ignored"},
+	}, nil)
+	mockRepo.On("IncrementClickCount", mock.Anything, "utm1234").Return(nil)
+	service := NewRedirectService(mockRepo)
+
+	result, err := service.Redirect(context.Background(), "utm1234")
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/page?ref=home&utm_source=newsletter#pricing", result.OriginalURL)
+	require.NotNil(t, result.URL)
+	assert.Equal(t, "utm1234", result.URL.ShortCode)
+	assert.Equal(t, result.OriginalURL, result.URL.OriginalURL, "the link carries the resolved destination")
+}
+
+func TestRedirectService_RedirectWithOptions_PlatformTargets(t *testing.T) {
+	appURL := &models.URL{
+		ShortCode:   "app1234",
+		OriginalURL: "https://example.com/app",
+		PlatformTargets: map[string]string{
+			models.PlatformIOS:     "https://apps.apple.com/app/id123",
+			models.PlatformAndroid: "https://play.google.com/store/apps/details?id=com.example",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{"iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148 Safari/604.1", "https://apps.apple.com/app/id123"},
+		{"iPad", "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", "https://apps.apple.com/app/id123"},
+		{"Android", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0.0.0 Mobile Safari/537.36", "https://play.google.com/store/apps/details?id=com.example"},
+		{"desktop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0.0.0 Safari/537.36", "https://example.com/app"},
+		{"macOS", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Version/17.2 Safari/605.1.15", "https://example.com/app"},
+		{"no user agent", "", "https://example.com/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			recorder := &mockSourceRecorder{}
+			mockRepo.On("GetByShortCode", mock.Anything, "app1234").Return(appURL, nil)
+			service := NewRedirectServiceWithAnalytics(mockRepo, recorder)
+
+			result, err := service.RedirectWithOptions(context.Background(), "app1234", RedirectOptions{UserAgent: tt.userAgent})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.OriginalURL)
+			// The click is counted once, against the short code
+			assert.Equal(t, []string{"app1234"}, recorder.recordedCodes)
+			mockRepo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("appends params to the platform target", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShortCode", mock.Anything, "app1234").Return(&models.URL{
+			ShortCode:       "app1234",
+			OriginalURL:     "https://example.com/app",
+			PlatformTargets: map[string]string{models.PlatformAndroid: "https://play.google.com/store/apps/details?id=com.example"},
+			AppendParams:    map[string]string{"utm_source": "qr"},
+		}, nil)
+		service := NewRedirectServiceWithAnalytics(mockRepo, &mockClickRecorder{})
+
+		result, err := service.RedirectWithOptions(context.Background(), "app1234", RedirectOptions{UserAgent: "Mozilla/5.0 (Linux; Android 14)"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://play.google.com/store/apps/details?id=com.example&utm_source=qr", result.OriginalURL)
+	})
+}
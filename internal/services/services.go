@@ -1,2 +1,6 @@
 // Package services contains business logic.
 package services
+
+import "go.opentelemetry.io/otel"
+
+var tracer = otel.Tracer("github.com/emadnahed/FastGoLink/internal/services")
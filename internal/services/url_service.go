@@ -5,12 +5,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/emadnahed/FastGoLink/internal/idgen"
 	"github.com/emadnahed/FastGoLink/internal/models"
 	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/security"
+	"github.com/emadnahed/FastGoLink/internal/tracing"
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/sync/singleflight"
 )
 
 // Security-related errors for URL validation.
@@ -18,13 +28,99 @@ var (
 	ErrDangerousURL   = errors.New("URL contains dangerous scheme")
 	ErrPrivateIPURL   = errors.New("private IP addresses are not allowed")
 	ErrBlockedHostURL = errors.New("host is blocked")
+	ErrHostNotAllowed = errors.New("host is not allowed")
 	ErrURLTooLong     = errors.New("URL exceeds maximum length")
 )
 
+// Custom alias errors.
+var (
+	ErrInvalidAlias = errors.New("alias may only contain letters, digits, '-' and '_'")
+	ErrAliasLength  = errors.New("alias length is out of range")
+	ErrAliasTaken   = errors.New("alias is already taken")
+
+	// ErrAliasClickLimited is returned when aliasing a URL with a click limit,
+	// since the alias would get a click budget of its own.
+	ErrAliasClickLimited = errors.New("URLs with a click limit cannot be aliased")
+
+	// ErrReservedCode is returned for aliases that would shadow a route.
+	ErrReservedCode = idgen.ErrReservedCode
+)
+
+// Link password errors.
+var (
+	ErrPasswordTooLong = errors.New("password must be at most 72 bytes")
+)
+
+// ErrExpiresAtInPast is returned when an absolute expiry time has already passed.
+var ErrExpiresAtInPast = errors.New("expires_at must be in the future")
+
+// ErrExpiryTooLong is returned when a requested expiry is further away than
+// URLServiceConfig.MaxExpiry allows.
+var ErrExpiryTooLong = errors.New("expiry exceeds the maximum allowed")
+
+// ErrInvalidMaxClicks is returned when a click limit is not positive.
+var ErrInvalidMaxClicks = errors.New("max_clicks must be positive")
+
+// ErrInvalidAppendParams is returned when an appended query parameter has no name.
+var ErrInvalidAppendParams = errors.New("append_params names must not be empty")
+
+// ErrInvalidPlatform is returned when a platform target names an unsupported platform.
+var ErrInvalidPlatform = errors.New("platform_targets keys must be ios or android")
+
+// Tag limits for URLs.
+const (
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+// Tag validation errors.
+var (
+	ErrInvalidTag  = fmt.Errorf("tags may only contain letters, digits, '-' and '_' and be at most %d characters", MaxTagLength)
+	ErrTooManyTags = fmt.Errorf("a URL may have at most %d tags", MaxTags)
+)
+
+// Pagination limits for List.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ExportPageSize is the number of URLs Export reads from the repository at a time.
+const ExportPageSize = 500
+
+// ErrInvalidPagination is returned when List is called with an out-of-range limit or offset.
+var ErrInvalidPagination = errors.New("limit must be between 1 and 100 and offset must not be negative")
+
+// ErrInvalidCursor is returned when ListAfter is called with a negative cursor.
+var ErrInvalidCursor = errors.New("cursor must be a non-negative integer")
+
+// validAliasRegex matches alphanumeric aliases with dashes and underscores.
+var validAliasRegex = regexp.MustCompile(`^[a-zA-Z0-9\-_]+$`)
+
+// validTagRegex matches a tag after it is lowercased.
+var validTagRegex = regexp.MustCompile(`^[a-z0-9\-_]+$`)
+
 // CreateURLRequest represents the input for creating a short URL.
 type CreateURLRequest struct {
 	OriginalURL string
 	ExpiresIn   *time.Duration
+	ExpiresAt   *time.Time
+	CustomAlias string // Optional vanity short code; generated when empty
+	Permanent   bool   // Redirect with 301 instead of 302
+	Password    string // Optional password required to follow the link
+	MaxClicks   *int64 // Optional number of redirects before the link is exhausted; 1 for single-use
+	ShowPreview bool   // Show an interstitial page with the destination before redirecting
+
+	// AppendParams are query parameters, such as UTM tags, merged into the
+	// destination on redirect without overwriting parameters it already has.
+	AppendParams map[string]string
+
+	// PlatformTargets maps models.PlatformIOS and models.PlatformAndroid to
+	// destinations used instead of OriginalURL for clients on that platform.
+	PlatformTargets map[string]string
+
+	// Tags label the URL for grouping. They are matched case-insensitively.
+	Tags []string
 }
 
 // CreateURLResponse represents the result of creating a short URL.
@@ -34,13 +130,80 @@ type CreateURLResponse struct {
 	OriginalURL string
 	CreatedAt   time.Time
 	ExpiresAt   *time.Time
+	Permanent   bool
+	MaxClicks   *int64
+	ShowPreview bool
+
+	AppendParams      map[string]string
+	PlatformTargets   map[string]string
+	Tags              []string
+	PasswordProtected bool
 }
 
 // URLService defines the interface for URL shortening operations.
 type URLService interface {
 	Create(ctx context.Context, req CreateURLRequest) (*CreateURLResponse, error)
+	CreateBatch(ctx context.Context, reqs []CreateURLRequest) ([]CreateURLResponse, []error)
+	CreateAliasFor(ctx context.Context, existingCode, newAlias string) (*CreateURLResponse, error)
 	Get(ctx context.Context, shortCode string) (*models.URL, error)
 	Delete(ctx context.Context, shortCode string) error
+	DeleteBatch(ctx context.Context, shortCodes []string) map[string]error
+	Restore(ctx context.Context, shortCode string) (*models.URL, error)
+	DeletePermanent(ctx context.Context, shortCode string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+	Update(ctx context.Context, shortCode, newURL string) (*models.URL, error)
+	Extend(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error)
+	SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error)
+	List(ctx context.Context, limit, offset int, filter repository.ListFilter) ([]*models.URL, int64, error)
+	ListAfter(ctx context.Context, afterID int64, limit int, filter repository.ListFilter) ([]*models.URL, int64, error)
+	Export(ctx context.Context, fn func(*models.URL) error) error
+}
+
+// URLServiceConfig holds tunable settings for URLService.
+type URLServiceConfig struct {
+	AliasMinLength int                       // Minimum length of a custom alias
+	AliasMaxLength int                       // Maximum length of a custom alias
+	Normalize      security.NormalizeOptions // Optional steps applied when canonicalizing destination URLs
+
+	// Dedupe makes plain shorten requests return the existing short code of
+	// a plain URL with the same destination instead of minting a new one.
+	// Requests with any per-link setting, such as an alias or expiry, always
+	// create a new URL.
+	Dedupe bool
+
+	// ReservedCodes may not be used as custom aliases. Nil uses
+	// idgen.DefaultReservedCodes.
+	ReservedCodes []string
+
+	// DefaultExpiry is applied to URLs created without an expiry. Zero
+	// leaves them without one unless MaxExpiry is set.
+	DefaultExpiry time.Duration
+
+	// MaxExpiry caps how far in the future a URL may expire. Requests for a
+	// later expiry fail with ErrExpiryTooLong, and URLs created without an
+	// expiry and no DefaultExpiry expire after MaxExpiry. Zero means no cap.
+	MaxExpiry time.Duration
+
+	// CreateMaxRetries is how many times a generated short code that another
+	// request stored first is replaced with a new one and the insert retried.
+	// Once they are used up, Create fails with idgen.ErrMaxRetriesExceeded.
+	// Zero fails on the first such collision.
+	CreateMaxRetries int
+
+	// MaxShortCodeLen is the longest short code or alias Create accepts;
+	// longer ones fail with models.ErrShortCodeTooLong. Zero leaves the
+	// check to the short_code column.
+	MaxShortCodeLen int
+}
+
+// DefaultURLServiceConfig returns the default URLService configuration.
+func DefaultURLServiceConfig() URLServiceConfig {
+	return URLServiceConfig{
+		AliasMinLength:   3,
+		AliasMaxLength:   10,
+		ReservedCodes:    idgen.DefaultReservedCodes,
+		CreateMaxRetries: 3,
+	}
 }
 
 // URLServiceImpl implements URLService.
@@ -49,6 +212,10 @@ type URLServiceImpl struct {
 	generator idgen.Generator
 	sanitizer *security.Sanitizer
 	baseURL   string
+	cfg       URLServiceConfig
+	reserved  idgen.ReservedSet
+	notifier  Notifier
+	aliases   singleflight.Group // Serializes inserts of the same custom alias
 }
 
 // NewURLService creates a new URLService instance.
@@ -58,74 +225,462 @@ func NewURLService(repo repository.URLRepository, gen idgen.Generator, baseURL s
 		generator: gen,
 		sanitizer: security.NewSanitizer(security.DefaultConfig()),
 		baseURL:   baseURL,
+		cfg:       DefaultURLServiceConfig(),
+		reserved:  idgen.NewReservedSet(idgen.DefaultReservedCodes),
 	}
 }
 
 // NewURLServiceWithSanitizer creates a new URLService with a custom sanitizer.
 func NewURLServiceWithSanitizer(repo repository.URLRepository, gen idgen.Generator, sanitizer *security.Sanitizer, baseURL string) *URLServiceImpl {
+	return NewURLServiceWithConfig(repo, gen, sanitizer, baseURL, DefaultURLServiceConfig())
+}
+
+// NewURLServiceWithConfig creates a new URLService with a custom sanitizer and configuration.
+func NewURLServiceWithConfig(repo repository.URLRepository, gen idgen.Generator, sanitizer *security.Sanitizer, baseURL string, cfg URLServiceConfig) *URLServiceImpl {
+	defaults := DefaultURLServiceConfig()
+	if cfg.AliasMinLength < 1 {
+		cfg.AliasMinLength = defaults.AliasMinLength
+	}
+	if cfg.AliasMaxLength < cfg.AliasMinLength {
+		cfg.AliasMaxLength = defaults.AliasMaxLength
+	}
+	if cfg.ReservedCodes == nil {
+		cfg.ReservedCodes = defaults.ReservedCodes
+	}
+	if cfg.CreateMaxRetries < 0 {
+		cfg.CreateMaxRetries = 0
+	}
 	return &URLServiceImpl{
 		repo:      repo,
 		generator: gen,
 		sanitizer: sanitizer,
 		baseURL:   baseURL,
+		cfg:       cfg,
+		reserved:  idgen.NewReservedSet(cfg.ReservedCodes),
 	}
 }
 
-// Create creates a new short URL.
-func (s *URLServiceImpl) Create(ctx context.Context, req CreateURLRequest) (*CreateURLResponse, error) {
-	// Validate the original URL first
-	if req.OriginalURL == "" {
-		return nil, models.ErrEmptyURL
+// Create creates a new short URL. In dedupe mode a plain request for a
+// destination that already has a plain URL returns that URL instead.
+func (s *URLServiceImpl) Create(ctx context.Context, req CreateURLRequest) (_ *CreateURLResponse, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Create",
+		trace.WithAttributes(attribute.Bool("url.custom_alias", req.CustomAlias != "")))
+	defer func() { tracing.End(span, err) }()
+
+	originalURL, err := s.normalizeOriginalURL(ctx, req.OriginalURL)
+	if err != nil {
+		return nil, err
 	}
 
-	// Security validation using sanitizer
-	if s.sanitizer != nil {
-		if err := s.sanitizer.Validate(req.OriginalURL); err != nil {
-			return nil, mapSecurityError(err)
+	existing, err := s.findDuplicate(ctx, req, originalURL)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		span.SetAttributes(tracing.ShortCodeKey.String(existing.ShortCode), attribute.Bool("url.deduplicated", true))
+		logger.FromContext(ctx).Debug("existing short URL reused", "short_code", existing.ShortCode)
+		return s.newCreateURLResponse(ctx, existing), nil
+	}
+
+	urlCreate, err := s.prepareCreate(ctx, req, originalURL)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.ShortCodeKey.String(urlCreate.ShortCode))
+
+	// Create the URL in repository
+	var url *models.URL
+	if req.CustomAlias != "" {
+		url, err = s.createAlias(ctx, urlCreate)
+	} else {
+		url, err = s.createGenerated(ctx, urlCreate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("short URL created", "short_code", url.ShortCode, "custom_alias", req.CustomAlias != "")
+	s.notifyCreated(url)
+
+	return s.newCreateURLResponse(ctx, url), nil
+}
+
+// createAlias stores a URL with a custom alias. Concurrent requests for the
+// same alias share a single insert: the request that ran it gets the URL and
+// the others get ErrAliasTaken without reaching the database. Requests on
+// other instances are caught by the unique constraint, which also reports
+// ErrAliasTaken.
+func (s *URLServiceImpl) createAlias(ctx context.Context, create *models.URLCreate) (*models.URL, error) {
+	for {
+		ran := false
+		v, err, _ := s.aliases.Do(create.ShortCode, func() (any, error) {
+			ran = true
+			return s.repo.Create(ctx, create)
+		})
+		switch {
+		case errors.Is(err, models.ErrShortCodeExists):
+			return nil, ErrAliasTaken
+		case ran && err != nil:
+			return nil, err
+		case ran:
+			return v.(*models.URL), nil
+		case err == nil:
+			return nil, ErrAliasTaken
 		}
+		// The shared insert failed for another reason, such as its request
+		// being canceled, so the alias may still be free
 	}
+}
 
-	// Use URLCreate's validation for URL format
-	urlCreate := &models.URLCreate{
-		OriginalURL: req.OriginalURL,
+// createGenerated stores a URL with a generated short code. The generator
+// checks that a code is free, but another request may store the same code
+// before this insert runs. The code is then regenerated and the insert
+// retried, up to CreateMaxRetries times.
+func (s *URLServiceImpl) createGenerated(ctx context.Context, create *models.URLCreate) (*models.URL, error) {
+	for attempt := 0; ; attempt++ {
+		url, err := s.repo.Create(ctx, create)
+		if !errors.Is(err, models.ErrShortCodeExists) {
+			return url, err
+		}
+		if attempt >= s.cfg.CreateMaxRetries {
+			return nil, fmt.Errorf("%w: generated short code taken on %d inserts", idgen.ErrMaxRetriesExceeded, attempt+1)
+		}
+		logger.FromContext(ctx).Debug("generated short code taken, retrying", "short_code", create.ShortCode, "attempt", attempt+1)
+
+		code, err := s.generator.Generate()
+		if err != nil {
+			return nil, err
+		}
+		create.ShortCode = code
+	}
+}
+
+// CreateAliasFor creates newAlias as an additional short code for the URL
+// existingCode points to. The alias is stored as a URL of its own with the
+// same destination and link options, so it redirects like the original but
+// has its own click count and analytics. The two codes are independent
+// afterwards: updating, extending or deleting one leaves the other as it is.
+// Expired, exhausted and disabled URLs cannot be aliased, nor can URLs with
+// a click limit, whose alias would start with a full budget of its own.
+func (s *URLServiceImpl) CreateAliasFor(ctx context.Context, existingCode, newAlias string) (_ *CreateURLResponse, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.CreateAliasFor",
+		trace.WithAttributes(tracing.ShortCodeKey.String(existingCode), attribute.String("url.alias", newAlias)))
+	defer func() { tracing.End(span, err) }()
+
+	source, err := s.Get(ctx, existingCode)
+	if err != nil {
+		return nil, err
+	}
+	if source.Disabled {
+		return nil, models.ErrURLDisabled
+	}
+	if source.MaxClicks != nil {
+		return nil, ErrAliasClickLimited
 	}
-	if err := urlCreate.Validate(); err != nil {
+	if err := s.validateAlias(ctx, newAlias); err != nil {
+		return nil, err
+	}
+	if err := s.validateShortCodeLen(newAlias); err != nil {
 		return nil, err
 	}
 
-	// Generate short code
-	shortCode, err := s.generator.Generate()
+	url, err := s.createAlias(ctx, &models.URLCreate{
+		OriginalURL:  source.OriginalURL,
+		ShortCode:    newAlias,
+		ExpiresAt:    source.ExpiresAt,
+		Permanent:    source.Permanent,
+		PasswordHash: source.PasswordHash,
+		ShowPreview:  source.ShowPreview,
+		AppendParams: source.AppendParams,
+
+		PlatformTargets: source.PlatformTargets,
+		Tags:            source.Tags,
+	})
 	if err != nil {
 		return nil, err
 	}
+	logger.FromContext(ctx).Info("short URL alias created", "short_code", url.ShortCode, "alias_of", existingCode)
+	s.notifyCreated(url)
 
-	// Calculate expiry time if provided
-	var expiresAt *time.Time
-	if req.ExpiresIn != nil {
-		exp := time.Now().Add(*req.ExpiresIn)
-		expiresAt = &exp
+	return s.newCreateURLResponse(ctx, url), nil
+}
+
+// SetNotifier sets the notifier told about created URLs and purges of
+// expired ones.
+func (s *URLServiceImpl) SetNotifier(n Notifier) {
+	s.notifier = n
+}
+
+// notifyCreated sends EventURLCreated for url when a notifier is set.
+func (s *URLServiceImpl) notifyCreated(url *models.URL) {
+	if s.notifier == nil {
+		return
 	}
+	s.notifier.Notify(LinkEvent{
+		Type:        EventURLCreated,
+		ShortCode:   url.ShortCode,
+		OriginalURL: url.OriginalURL,
+	})
+}
 
-	// Create the URL in repository
-	urlCreate.ShortCode = shortCode
-	urlCreate.ExpiresAt = expiresAt
+// findDuplicate returns the existing URL a plain request for originalURL is
+// answered with in dedupe mode, or nil when a new URL must be created.
+func (s *URLServiceImpl) findDuplicate(ctx context.Context, req CreateURLRequest, originalURL string) (*models.URL, error) {
+	if !s.cfg.Dedupe || !isPlainRequest(req) {
+		return nil, nil
+	}
+	url, err := s.repo.GetByOriginalURL(ctx, originalURL)
+	if errors.Is(err, models.ErrURLNotFound) {
+		return nil, nil
+	}
+	return url, err
+}
+
+// isPlainRequest reports whether req sets nothing but the destination.
+func isPlainRequest(req CreateURLRequest) bool {
+	return req.ExpiresIn == nil && req.ExpiresAt == nil && req.CustomAlias == "" &&
+		!req.Permanent && req.Password == "" && req.MaxClicks == nil && !req.ShowPreview &&
+		len(req.AppendParams) == 0 && len(req.PlatformTargets) == 0 && len(req.Tags) == 0
+}
 
-	url, err := s.repo.Create(ctx, urlCreate)
+// validateShortCodeLen checks shortCode against MaxShortCodeLen, if set.
+func (s *URLServiceImpl) validateShortCodeLen(shortCode string) error {
+	if s.cfg.MaxShortCodeLen <= 0 {
+		return nil
+	}
+	return models.ValidateShortCodeLen(shortCode, s.cfg.MaxShortCodeLen)
+}
+
+// prepareCreate validates req and turns it into a URLCreate with its short
+// code chosen. originalURL is the canonical form of req.OriginalURL, so
+// equivalent URLs are saved identically.
+func (s *URLServiceImpl) prepareCreate(ctx context.Context, req CreateURLRequest, originalURL string) (*models.URLCreate, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiresAtInPast
+	}
+	if req.MaxClicks != nil && *req.MaxClicks < 1 {
+		return nil, ErrInvalidMaxClicks
+	}
+	if _, ok := req.AppendParams[""]; ok {
+		return nil, ErrInvalidAppendParams
+	}
+	platformTargets, err := s.normalizePlatformTargets(ctx, req.PlatformTargets)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
 	if err != nil {
 		return nil, err
 	}
+	urlCreate := &models.URLCreate{
+		OriginalURL:  originalURL,
+		Permanent:    req.Permanent,
+		MaxClicks:    req.MaxClicks,
+		ShowPreview:  req.ShowPreview,
+		AppendParams: req.AppendParams,
+
+		PlatformTargets: platformTargets,
+		Tags:            tags,
+	}
+
+	// Store only a bcrypt hash of the link password
+	if req.Password != "" {
+		hash, err := hashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		urlCreate.PasswordHash = hash
+	}
+
+	// Use the custom alias if provided, otherwise generate a short code
+	if req.CustomAlias != "" {
+		if err := s.validateAlias(ctx, req.CustomAlias); err != nil {
+			return nil, err
+		}
+		urlCreate.ShortCode = req.CustomAlias
+	} else {
+		code, err := s.generator.Generate()
+		if err != nil {
+			return nil, err
+		}
+		urlCreate.ShortCode = code
+	}
+	if err := s.validateShortCodeLen(urlCreate.ShortCode); err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := s.resolveExpiry(req)
+	if err != nil {
+		return nil, err
+	}
+	urlCreate.ExpiresAt = expiresAt
+
+	return urlCreate, nil
+}
+
+// resolveExpiry returns the expiry time for req, preferring an absolute time
+// over a duration and falling back to the configured default. It fails with
+// ErrExpiryTooLong when the result is beyond MaxExpiry.
+func (s *URLServiceImpl) resolveExpiry(req CreateURLRequest) (*time.Time, error) {
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if expiresAt == nil {
+		var d time.Duration
+		switch {
+		case req.ExpiresIn != nil:
+			d = *req.ExpiresIn
+		case s.cfg.DefaultExpiry > 0:
+			d = s.cfg.DefaultExpiry
+		case s.cfg.MaxExpiry > 0:
+			d = s.cfg.MaxExpiry
+		default:
+			return nil, nil
+		}
+		exp := now.Add(d)
+		expiresAt = &exp
+	}
+	if err := s.checkMaxExpiry(now, *expiresAt); err != nil {
+		return nil, err
+	}
+	return expiresAt, nil
+}
+
+// checkMaxExpiry reports ErrExpiryTooLong when expiresAt is more than
+// MaxExpiry after now.
+func (s *URLServiceImpl) checkMaxExpiry(now, expiresAt time.Time) error {
+	if s.cfg.MaxExpiry > 0 && expiresAt.Sub(now) > s.cfg.MaxExpiry {
+		return ErrExpiryTooLong
+	}
+	return nil
+}
+
+// baseURLKey is the context key of a per-request base URL.
+type baseURLKey struct{}
+
+// WithBaseURL returns a context under which created URLs get short URLs on
+// baseURL rather than the service's base URL, for deployments that serve
+// several domains.
+func WithBaseURL(ctx context.Context, baseURL string) context.Context {
+	return context.WithValue(ctx, baseURLKey{}, baseURL)
+}
+
+// BaseURLFromContext returns the base URL set with WithBaseURL, if any.
+func BaseURLFromContext(ctx context.Context) (string, bool) {
+	baseURL, ok := ctx.Value(baseURLKey{}).(string)
+	return baseURL, ok && baseURL != ""
+}
+
+// baseURLFor returns the base URL short URLs are built on for ctx.
+func (s *URLServiceImpl) baseURLFor(ctx context.Context) string {
+	if baseURL, ok := BaseURLFromContext(ctx); ok {
+		return baseURL
+	}
+	return s.baseURL
+}
 
+// newCreateURLResponse builds the response for a created URL.
+func (s *URLServiceImpl) newCreateURLResponse(ctx context.Context, url *models.URL) *CreateURLResponse {
 	return &CreateURLResponse{
-		ShortURL:    fmt.Sprintf("%s/%s", s.baseURL, url.ShortCode),
+		ShortURL:    fmt.Sprintf("%s/%s", s.baseURLFor(ctx), url.ShortCode),
 		ShortCode:   url.ShortCode,
 		OriginalURL: url.OriginalURL,
 		CreatedAt:   url.CreatedAt,
 		ExpiresAt:   url.ExpiresAt,
-	}, nil
+		Permanent:   url.Permanent,
+		MaxClicks:   url.MaxClicks,
+		ShowPreview: url.ShowPreview,
+
+		AppendParams:      url.AppendParams,
+		PlatformTargets:   url.PlatformTargets,
+		Tags:              url.Tags,
+		PasswordProtected: url.IsPasswordProtected(),
+	}
+}
+
+// CreateBatch creates short URLs for each request independently.
+// The returned slices are index-aligned with reqs: for every i, either errs[i]
+// is non-nil or resps[i] holds the created URL.
+//
+// Valid requests are stored with a single repository call. A request whose
+// short code was taken in the meantime is retried on its own, so generated
+// codes are regenerated and taken aliases report ErrAliasTaken. When the
+// repository reports a *repository.BatchError, only the requests it names
+// fail; the rest were stored.
+func (s *URLServiceImpl) CreateBatch(ctx context.Context, reqs []CreateURLRequest) ([]CreateURLResponse, []error) {
+	ctx, span := tracer.Start(ctx, "URLService.CreateBatch",
+		trace.WithAttributes(attribute.Int("url.batch_size", len(reqs))))
+	defer span.End()
+
+	resps := make([]CreateURLResponse, len(reqs))
+	errs := make([]error, len(reqs))
+
+	creates := make([]*models.URLCreate, 0, len(reqs))
+	positions := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		originalURL, err := s.normalizeOriginalURL(ctx, req.OriginalURL)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		existing, err := s.findDuplicate(ctx, req, originalURL)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if existing != nil {
+			resps[i] = *s.newCreateURLResponse(ctx, existing)
+			continue
+		}
+		create, err := s.prepareCreate(ctx, req, originalURL)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		creates = append(creates, create)
+		positions = append(positions, i)
+	}
+	if len(creates) == 0 {
+		return resps, errs
+	}
+
+	urls, err := s.repo.CreateBatch(ctx, creates)
+	var batchErr *repository.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		for _, i := range positions {
+			errs[i] = err
+		}
+		return resps, errs
+	}
+
+	for j, url := range urls {
+		i := positions[j]
+		if batchErr != nil {
+			if failErr, failed := batchErr.Failed[creates[j].ShortCode]; failed {
+				errs[i] = failErr
+				continue
+			}
+		}
+		if url == nil {
+			resp, err := s.Create(ctx, reqs[i])
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			resps[i] = *resp
+			continue
+		}
+		s.notifyCreated(url)
+		resps[i] = *s.newCreateURLResponse(ctx, url)
+	}
+
+	return resps, errs
 }
 
 // Get retrieves a URL by its short code.
-func (s *URLServiceImpl) Get(ctx context.Context, shortCode string) (*models.URL, error) {
+func (s *URLServiceImpl) Get(ctx context.Context, shortCode string) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Get", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
 	url, err := s.repo.GetByShortCode(ctx, shortCode)
 	if err != nil {
 		return nil, err
@@ -136,12 +691,318 @@ func (s *URLServiceImpl) Get(ctx context.Context, shortCode string) (*models.URL
 		return nil, models.ErrURLExpired
 	}
 
+	// Check if URL has used up its click limit
+	if url.IsExhausted() {
+		return nil, models.ErrURLExhausted
+	}
+
 	return url, nil
 }
 
-// Delete removes a URL by its short code.
-func (s *URLServiceImpl) Delete(ctx context.Context, shortCode string) error {
-	return s.repo.Delete(ctx, shortCode)
+// Delete soft-deletes a URL by its short code. It stops redirecting until
+// restored.
+func (s *URLServiceImpl) Delete(ctx context.Context, shortCode string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Delete", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.repo.Delete(ctx, shortCode); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("short URL deleted", "short_code", shortCode)
+	return nil
+}
+
+// DeleteBatch soft-deletes several URLs at once. The result has an entry for
+// every requested code: nil when it was deleted, models.ErrURLNotFound when it
+// does not exist, or the repository error when it could not be deleted. When
+// the repository reports a *repository.BatchError, only the codes it names
+// get an error; the rest of the batch went through.
+func (s *URLServiceImpl) DeleteBatch(ctx context.Context, shortCodes []string) map[string]error {
+	ctx, span := tracer.Start(ctx, "URLService.DeleteBatch",
+		trace.WithAttributes(attribute.Int("url.batch_size", len(shortCodes))))
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	results := make(map[string]error, len(shortCodes))
+	deleted, err := s.repo.DeleteBatch(ctx, shortCodes)
+	var batchErr *repository.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		for _, shortCode := range shortCodes {
+			results[shortCode] = err
+		}
+		return results
+	}
+
+	for _, shortCode := range shortCodes {
+		results[shortCode] = models.ErrURLNotFound
+	}
+	for _, shortCode := range deleted {
+		results[shortCode] = nil
+	}
+	if batchErr != nil {
+		for shortCode, failErr := range batchErr.Failed {
+			results[shortCode] = failErr
+		}
+	}
+	return results
+}
+
+// Restore undoes a soft delete and returns the restored URL.
+func (s *URLServiceImpl) Restore(ctx context.Context, shortCode string) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Restore", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.repo.Restore(ctx, shortCode); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByShortCode(ctx, shortCode)
+}
+
+// DeletePermanent removes a URL for good, including soft-deleted ones.
+func (s *URLServiceImpl) DeletePermanent(ctx context.Context, shortCode string) (err error) {
+	ctx, span := tracer.Start(ctx, "URLService.DeletePermanent", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.repo.DeletePermanent(ctx, shortCode); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("short URL permanently deleted", "short_code", shortCode)
+	return nil
+}
+
+// PurgeExpired deletes every expired URL now rather than waiting for the
+// reaper, and returns how many were deleted.
+func (s *URLServiceImpl) PurgeExpired(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.PurgeExpired")
+	defer func() { tracing.End(span, err) }()
+
+	deleted, err := s.repo.DeleteExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(len(deleted))
+	span.SetAttributes(attribute.Int64("url.deleted", count))
+	if count > 0 && s.notifier != nil {
+		s.notifier.Notify(LinkEvent{Type: EventURLsExpired, Count: count})
+	}
+	logger.FromContext(ctx).Info("expired URLs purged", "count", count)
+	return count, nil
+}
+
+// Update changes the destination URL of an existing short code.
+func (s *URLServiceImpl) Update(ctx context.Context, shortCode, newURL string) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Update", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	newURL, err = s.normalizeOriginalURL(ctx, newURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateOriginalURL(ctx, shortCode, newURL); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByShortCode(ctx, shortCode)
+}
+
+// Extend sets a new expiry time for an existing short code, which may
+// revive a URL that has expired but not been removed yet. The time must be
+// in the future and within MaxExpiry. Deleted URLs return models.ErrURLNotFound.
+func (s *URLServiceImpl) Extend(ctx context.Context, shortCode string, expiresAt time.Time) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Extend", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, ErrExpiresAtInPast
+	}
+	if err := s.checkMaxExpiry(now, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateExpiry(ctx, shortCode, expiresAt)
+}
+
+// SetActive enables or disables redirects for an existing short code. A
+// disabled URL keeps its code and analytics and still appears in listings,
+// but redirects fail with models.ErrURLDisabled until it is enabled again.
+// Deleted URLs return models.ErrURLNotFound.
+func (s *URLServiceImpl) SetActive(ctx context.Context, shortCode string, active bool) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.SetActive", trace.WithAttributes(tracing.ShortCodeKey.String(shortCode)))
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.SetActive(ctx, shortCode, active)
+}
+
+// List returns a page of URLs matching filter, newest first, and the total
+// number of matching URLs.
+func (s *URLServiceImpl) List(ctx context.Context, limit, offset int, filter repository.ListFilter) (_ []*models.URL, _ int64, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.List",
+		trace.WithAttributes(attribute.Int("list.limit", limit), attribute.Int("list.offset", offset)))
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 || limit > MaxListLimit || offset < 0 {
+		return nil, 0, ErrInvalidPagination
+	}
+
+	return s.repo.List(ctx, limit, offset, filter)
+}
+
+// ListAfter returns a page of URLs matching filter that come after the
+// cursor afterID, and the cursor of the next page, or zero on the last page.
+func (s *URLServiceImpl) ListAfter(ctx context.Context, afterID int64, limit int, filter repository.ListFilter) (_ []*models.URL, _ int64, err error) {
+	ctx, span := tracer.Start(ctx, "URLService.ListAfter",
+		trace.WithAttributes(attribute.Int64("list.after_id", afterID), attribute.Int("list.limit", limit)))
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 1 || limit > MaxListLimit {
+		return nil, 0, ErrInvalidPagination
+	}
+	if afterID < 0 {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	return s.repo.ListAfter(ctx, afterID, limit, filter)
+}
+
+// Export calls fn for every URL, reading ExportPageSize URLs at a time by
+// cursor so the full set is never held in memory. It stops at the first
+// error returned by the repository or fn.
+func (s *URLServiceImpl) Export(ctx context.Context, fn func(*models.URL) error) (err error) {
+	ctx, span := tracer.Start(ctx, "URLService.Export")
+	defer func() { tracing.End(span, err) }()
+
+	var cursor int64
+	for {
+		urls, next, err := s.repo.ListAfter(ctx, cursor, ExportPageSize, repository.ListFilter{})
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			if err := fn(url); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// hashPassword returns the bcrypt hash of a link password. bcrypt ignores
+// input beyond 72 bytes, so longer passwords are rejected rather than truncated.
+func hashPassword(password string) (string, error) {
+	if len(password) > 72 {
+		return "", ErrPasswordTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// validateOriginalURL runs the sanitizer and format checks on a destination URL.
+func (s *URLServiceImpl) validateOriginalURL(ctx context.Context, rawURL string) error {
+	if rawURL == "" {
+		return models.ErrEmptyURL
+	}
+
+	// Security validation using sanitizer
+	if s.sanitizer != nil {
+		if err := s.sanitizer.ValidateContext(ctx, rawURL); err != nil {
+			return mapSecurityError(err)
+		}
+	}
+
+	// Use URLCreate's validation for URL format
+	urlCreate := &models.URLCreate{
+		OriginalURL: rawURL,
+	}
+	return urlCreate.Validate()
+}
+
+// normalizeOriginalURL validates a destination URL and returns its canonical form.
+func (s *URLServiceImpl) normalizeOriginalURL(ctx context.Context, rawURL string) (string, error) {
+	if err := s.validateOriginalURL(ctx, rawURL); err != nil {
+		return "", err
+	}
+
+	normalized, err := security.NormalizeWithOptions(rawURL, s.cfg.Normalize)
+	if err != nil {
+		return "", models.ErrInvalidURL
+	}
+	return normalized, nil
+}
+
+// normalizePlatformTargets validates platform targets like the destination
+// itself and returns them in canonical form.
+func (s *URLServiceImpl) normalizePlatformTargets(ctx context.Context, targets map[string]string) (map[string]string, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	normalized := make(map[string]string, len(targets))
+	for platform, target := range targets {
+		if platform != models.PlatformIOS && platform != models.PlatformAndroid {
+			return nil, ErrInvalidPlatform
+		}
+		u, err := s.normalizeOriginalURL(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		normalized[platform] = u
+	}
+	return normalized, nil
+}
+
+// normalizeTags lowercases tags, drops duplicates and checks their format
+// and number.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) > MaxTagLength || !validTagRegex.MatchString(tag) {
+			return nil, ErrInvalidTag
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+// validateAlias checks a custom alias against the allowed charset and length
+// and ensures it is neither reserved nor already in use.
+func (s *URLServiceImpl) validateAlias(ctx context.Context, alias string) error {
+	if len(alias) < s.cfg.AliasMinLength || len(alias) > s.cfg.AliasMaxLength {
+		return ErrAliasLength
+	}
+	if !validAliasRegex.MatchString(alias) {
+		return ErrInvalidAlias
+	}
+	if s.reserved.Contains(alias) {
+		return ErrReservedCode
+	}
+
+	exists, err := s.repo.Exists(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrAliasTaken
+	}
+
+	return nil
 }
 
 // mapSecurityError maps security package errors to service errors.
@@ -153,6 +1014,8 @@ func mapSecurityError(err error) error {
 		return ErrPrivateIPURL
 	case errors.Is(err, security.ErrBlockedHost):
 		return ErrBlockedHostURL
+	case errors.Is(err, security.ErrHostNotAllowed):
+		return ErrHostNotAllowed
 	case errors.Is(err, security.ErrURLTooLong):
 		return ErrURLTooLong
 	default:
@@ -1,17 +1,24 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/emadnahed/FastGoLink/internal/idgen"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/security"
 )
 
@@ -28,6 +35,14 @@ func (m *MockURLRepository) Create(ctx context.Context, url *models.URLCreate) (
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
+func (m *MockURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	args := m.Called(ctx, creates)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
 func (m *MockURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
 	args := m.Called(ctx, shortCode)
 	if args.Get(0) == nil {
@@ -36,6 +51,14 @@ func (m *MockURLRepository) GetByShortCode(ctx context.Context, shortCode string
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
+func (m *MockURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	args := m.Called(ctx, shortCodes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
 func (m *MockURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -44,24 +67,106 @@ func (m *MockURLRepository) GetByID(ctx context.Context, id int64) (*models.URL,
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
+func (m *MockURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	args := m.Called(ctx, originalURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
 func (m *MockURLRepository) Delete(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	args := m.Called(ctx, shortCodes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockURLRepository) Restore(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	args := m.Called(ctx, shortCode, newURL)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	args := m.Called(ctx, shortCode, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	args := m.Called(ctx, shortCode, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
 func (m *MockURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
 	return args.Error(0)
 }
 
-func (m *MockURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error {
+func (m *MockURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
+	args := m.Called(ctx, shortCode)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
 	args := m.Called(ctx, counts)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
 }
 
-func (m *MockURLRepository) DeleteExpired(ctx context.Context) (int64, error) {
+func (m *MockURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
 	args := m.Called(ctx)
-	return args.Get(0).(int64), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockURLRepository) List(ctx context.Context, limit, offset int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, limit, offset, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, afterID, limit, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *MockURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
@@ -103,7 +208,7 @@ func TestURLService_Create(t *testing.T) {
 			},
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
 				gen.On("Generate").Return("abc1234", nil)
-				repo.On("Create", ctx, mock.MatchedBy(func(u *models.URLCreate) bool {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
 					return u.OriginalURL == "https://example.com/very/long/path" &&
 						u.ShortCode == "abc1234" &&
 						u.ExpiresAt == nil
@@ -127,7 +232,7 @@ func TestURLService_Create(t *testing.T) {
 			},
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
 				gen.On("Generate").Return("xyz9876", nil)
-				repo.On("Create", ctx, mock.MatchedBy(func(u *models.URLCreate) bool {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
 					return u.OriginalURL == "https://example.com/path" &&
 						u.ShortCode == "xyz9876" &&
 						u.ExpiresAt != nil
@@ -184,7 +289,7 @@ func TestURLService_Create(t *testing.T) {
 			},
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
 				gen.On("Generate").Return("abc1234", nil)
-				repo.On("Create", ctx, mock.Anything).Return(nil, errors.New("database error"))
+				repo.On("Create", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
 			},
 			expectedError: errors.New("database error"),
 		},
@@ -235,6 +340,248 @@ func TestURLService_Create(t *testing.T) {
 	}
 }
 
+func TestURLService_CreateBatch(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	mockGen.On("Generate").Return("abc1234", nil).Once()
+	// Invalid requests never reach the repository
+	mockRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(creates []*models.URLCreate) bool {
+		return len(creates) == 1 && creates[0].ShortCode == "abc1234"
+	})).Return([]*models.URL{{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/a",
+		CreatedAt:   time.Now(),
+	}}, nil)
+
+	svc := NewURLService(mockRepo, mockGen, baseURL)
+	resps, errs := svc.CreateBatch(ctx, []CreateURLRequest{
+		{OriginalURL: "https://example.com/a"},
+		{OriginalURL: "not-a-valid-url"},
+	})
+
+	require.Len(t, resps, 2)
+	require.Len(t, errs, 2)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "abc1234", resps[0].ShortCode)
+	assert.Equal(t, "http://localhost:8080/abc1234", resps[0].ShortURL)
+	assert.ErrorIs(t, errs[1], models.ErrInvalidURL)
+	assert.Empty(t, resps[1].ShortCode)
+
+	mockRepo.AssertExpectations(t)
+	mockGen.AssertExpectations(t)
+}
+
+func TestURLService_NotifiesCreated(t *testing.T) {
+	ctx := context.Background()
+	created := &models.URL{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com/", CreatedAt: time.Now()}
+
+	t.Run("create", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("abc1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(created, nil)
+		notifier := &recordingNotifier{}
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		svc.SetNotifier(notifier)
+		_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+		require.NoError(t, err)
+
+		events := notifier.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, EventURLCreated, events[0].Type)
+		assert.Equal(t, "abc1234", events[0].ShortCode)
+		assert.Equal(t, "https://example.com/", events[0].OriginalURL)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("abc1234", nil)
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return([]*models.URL{created}, nil)
+		notifier := &recordingNotifier{}
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		svc.SetNotifier(notifier)
+		_, errs := svc.CreateBatch(ctx, []CreateURLRequest{{OriginalURL: "https://example.com"}})
+		require.NoError(t, errs[0])
+
+		events := notifier.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, "abc1234", events[0].ShortCode)
+	})
+
+	t.Run("failed create sends nothing", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		notifier := &recordingNotifier{}
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		svc.SetNotifier(notifier)
+		_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "not-a-valid-url"})
+		require.Error(t, err)
+		assert.Empty(t, notifier.Events())
+	})
+}
+
+func TestURLService_CreateBatch_RetriesTakenCodes(t *testing.T) {
+	ctx := context.Background()
+
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	mockGen.On("Generate").Return("abc1234", nil).Once()
+	mockGen.On("Generate").Return("def5678", nil).Once()
+	mockGen.On("Generate").Return("ghi9012", nil).Once()
+
+	// The second code was taken after it was generated
+	mockRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(creates []*models.URLCreate) bool {
+		return len(creates) == 2
+	})).Return([]*models.URL{
+		{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com/a"},
+		nil,
+	}, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+		return u.ShortCode == "ghi9012"
+	})).Return(&models.URL{ID: 2, ShortCode: "ghi9012", OriginalURL: "https://example.com/b"}, nil)
+
+	svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+	resps, errs := svc.CreateBatch(ctx, []CreateURLRequest{
+		{OriginalURL: "https://example.com/a"},
+		{OriginalURL: "https://example.com/b"},
+	})
+
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	assert.Equal(t, "abc1234", resps[0].ShortCode)
+	assert.Equal(t, "ghi9012", resps[1].ShortCode)
+
+	mockRepo.AssertExpectations(t)
+	mockGen.AssertExpectations(t)
+}
+
+func TestURLService_Create_RetriesTakenCode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries with a new code", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("abc1234", nil).Once()
+		mockGen.On("Generate").Return("def5678", nil).Once()
+
+		// Another request stored the first code after it was generated
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ShortCode == "abc1234"
+		})).Return(nil, fmt.Errorf("%w: abc1234", models.ErrShortCodeExists)).Once()
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ShortCode == "def5678"
+		})).Return(&models.URL{ID: 1, ShortCode: "def5678", OriginalURL: "https://example.com"}, nil).Once()
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "def5678", resp.ShortCode)
+		mockRepo.AssertExpectations(t)
+		mockGen.AssertExpectations(t)
+	})
+
+	t.Run("fails once the retries are used up", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("abc1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil, models.ErrShortCodeExists)
+
+		cfg := DefaultURLServiceConfig()
+		cfg.CreateMaxRetries = 2
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, "http://localhost:8080", cfg)
+		_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+
+		assert.ErrorIs(t, err, idgen.ErrMaxRetriesExceeded)
+		mockRepo.AssertNumberOfCalls(t, "Create", 3)
+		mockGen.AssertNumberOfCalls(t, "Generate", 3)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("abc1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+
+		assert.ErrorContains(t, err, "connection refused")
+		mockRepo.AssertNumberOfCalls(t, "Create", 1)
+	})
+}
+
+func TestURLService_CreateBatch_RepositoryError(t *testing.T) {
+	ctx := context.Background()
+	dbErr := errors.New("connection refused")
+
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	mockGen.On("Generate").Return("abc1234", nil).Once()
+	mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(nil, dbErr)
+
+	svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+	_, errs := svc.CreateBatch(ctx, []CreateURLRequest{
+		{OriginalURL: "https://example.com/a"},
+		{OriginalURL: "not-a-valid-url"},
+	})
+
+	assert.ErrorIs(t, errs[0], dbErr)
+	assert.ErrorIs(t, errs[1], models.ErrInvalidURL)
+}
+
+func TestURLService_CreateBatch_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	shardErr := errors.New("shard 1: connection refused")
+
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	mockGen.On("Generate").Return("abc1234", nil).Once()
+	mockGen.On("Generate").Return("def5678", nil).Once()
+	mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return([]*models.URL{
+		{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com/a"},
+		nil,
+	}, &repository.BatchError{Failed: map[string]error{"def5678": shardErr}})
+
+	svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+	resps, errs := svc.CreateBatch(ctx, []CreateURLRequest{
+		{OriginalURL: "https://example.com/a"},
+		{OriginalURL: "https://example.com/b"},
+	})
+
+	require.NoError(t, errs[0])
+	assert.Equal(t, "abc1234", resps[0].ShortCode)
+	assert.ErrorIs(t, errs[1], shardErr)
+	// The failed code is reported, not retried through Create
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestURLService_Get_DoesNotCountClicks(t *testing.T) {
+	repo := new(MockURLRepository)
+	repo.On("GetByShortCode", mock.Anything, "abc1234").Return(&models.URL{
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com",
+		ClickCount:  7,
+	}, nil)
+	svc := NewURLService(repo, new(MockGenerator), "http://localhost:8080")
+
+	url, err := svc.Get(context.Background(), "abc1234")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), url.ClickCount)
+	repo.AssertNotCalled(t, "IncrementClickCount", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "ClaimClick", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "BatchIncrementClickCounts", mock.Anything, mock.Anything)
+}
+
 func TestURLService_Get(t *testing.T) {
 	ctx := context.Background()
 	baseURL := "http://localhost:8080"
@@ -254,7 +601,7 @@ func TestURLService_Get(t *testing.T) {
 			name:      "existing code returns URL info",
 			shortCode: "abc1234",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("GetByShortCode", ctx, "abc1234").Return(&models.URL{
+				repo.On("GetByShortCode", mock.Anything, "abc1234").Return(&models.URL{
 					ID:          1,
 					ShortCode:   "abc1234",
 					OriginalURL: "https://example.com/path",
@@ -276,7 +623,7 @@ func TestURLService_Get(t *testing.T) {
 			name:      "existing code with future expiry returns URL",
 			shortCode: "xyz9876",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("GetByShortCode", ctx, "xyz9876").Return(&models.URL{
+				repo.On("GetByShortCode", mock.Anything, "xyz9876").Return(&models.URL{
 					ID:          2,
 					ShortCode:   "xyz9876",
 					OriginalURL: "https://example.com/other",
@@ -298,7 +645,7 @@ func TestURLService_Get(t *testing.T) {
 			name:      "non-existent code returns not found error",
 			shortCode: "notfound",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("GetByShortCode", ctx, "notfound").Return(nil, models.ErrURLNotFound)
+				repo.On("GetByShortCode", mock.Anything, "notfound").Return(nil, models.ErrURLNotFound)
 			},
 			expectedError: models.ErrURLNotFound,
 		},
@@ -306,7 +653,7 @@ func TestURLService_Get(t *testing.T) {
 			name:      "expired URL returns expired error",
 			shortCode: "expired",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("GetByShortCode", ctx, "expired").Return(&models.URL{
+				repo.On("GetByShortCode", mock.Anything, "expired").Return(&models.URL{
 					ID:          3,
 					ShortCode:   "expired",
 					OriginalURL: "https://example.com/expired",
@@ -321,7 +668,7 @@ func TestURLService_Get(t *testing.T) {
 			name:      "repository error returns error",
 			shortCode: "error",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("GetByShortCode", ctx, "error").Return(nil, errors.New("database error"))
+				repo.On("GetByShortCode", mock.Anything, "error").Return(nil, errors.New("database error"))
 			},
 			expectedError: errors.New("database error"),
 		},
@@ -359,6 +706,34 @@ func TestURLService_Get(t *testing.T) {
 	}
 }
 
+func TestURLService_LogsRequestID(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	mockGen.On("Generate").Return("abc1234", nil)
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.URL{
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com",
+		CreatedAt:   time.Now(),
+	}, nil)
+	mockRepo.On("Delete", mock.Anything, "abc1234").Return(nil)
+	service := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+
+	var buf bytes.Buffer
+	ctx := requestContext(&buf, "req-42")
+	_, err := service.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	require.NoError(t, service.Delete(ctx, "abc1234"))
+
+	dec := json.NewDecoder(&buf)
+	for _, msg := range []string{"short URL created", "short URL deleted"} {
+		var entry map[string]interface{}
+		require.NoError(t, dec.Decode(&entry))
+		assert.Equal(t, msg, entry["msg"])
+		assert.Equal(t, "abc1234", entry["short_code"])
+		assert.Equal(t, "req-42", entry["request_id"])
+	}
+}
+
 func TestURLService_Delete(t *testing.T) {
 	ctx := context.Background()
 	baseURL := "http://localhost:8080"
@@ -373,7 +748,7 @@ func TestURLService_Delete(t *testing.T) {
 			name:      "existing code deletes successfully",
 			shortCode: "abc1234",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("Delete", ctx, "abc1234").Return(nil)
+				repo.On("Delete", mock.Anything, "abc1234").Return(nil)
 			},
 			expectedError: nil,
 		},
@@ -381,7 +756,7 @@ func TestURLService_Delete(t *testing.T) {
 			name:      "non-existent code returns not found error",
 			shortCode: "notfound",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("Delete", ctx, "notfound").Return(models.ErrURLNotFound)
+				repo.On("Delete", mock.Anything, "notfound").Return(models.ErrURLNotFound)
 			},
 			expectedError: models.ErrURLNotFound,
 		},
@@ -389,7 +764,7 @@ func TestURLService_Delete(t *testing.T) {
 			name:      "repository error returns error",
 			shortCode: "error",
 			setupMocks: func(repo *MockURLRepository, gen *MockGenerator) {
-				repo.On("Delete", ctx, "error").Return(errors.New("database error"))
+				repo.On("Delete", mock.Anything, "error").Return(errors.New("database error"))
 			},
 			expectedError: errors.New("database error"),
 		},
@@ -421,110 +796,307 @@ func TestURLService_Delete(t *testing.T) {
 	}
 }
 
-// Helper functions
-func durationPtr(d time.Duration) *time.Duration {
-	return &d
-}
-
-func timePtr(t time.Time) *time.Time {
-	return &t
-}
-
-func TestNewURLServiceWithSanitizer(t *testing.T) {
-	mockRepo := new(MockURLRepository)
-	mockGen := new(MockGenerator)
-	sanitizer := security.NewSanitizer(security.DefaultConfig())
-
-	svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, "http://localhost:8080")
-
-	assert.NotNil(t, svc)
-	assert.NotNil(t, svc.repo)
-	assert.NotNil(t, svc.generator)
-	assert.NotNil(t, svc.sanitizer)
-	assert.Equal(t, "http://localhost:8080", svc.baseURL)
-}
-
-func TestURLService_Create_WithSanitizer(t *testing.T) {
+func TestURLService_Restore(t *testing.T) {
 	ctx := context.Background()
 	baseURL := "http://localhost:8080"
 
-	t.Run("blocks dangerous scheme", func(t *testing.T) {
-		mockRepo := new(MockURLRepository)
-		mockGen := new(MockGenerator)
-		sanitizer := security.NewSanitizer(security.DefaultConfig())
-		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
-
-		resp, err := svc.Create(ctx, CreateURLRequest{
-			OriginalURL: "javascript:alert(1)",
-		})
-
-		assert.Error(t, err)
-		assert.Nil(t, resp)
-		assert.ErrorIs(t, err, ErrDangerousURL)
-	})
-
-	t.Run("blocks private IP", func(t *testing.T) {
+	t.Run("restores soft-deleted URL", func(t *testing.T) {
 		mockRepo := new(MockURLRepository)
-		mockGen := new(MockGenerator)
-		sanitizer := security.NewSanitizer(security.Config{
-			MaxURLLength:    2048,
-			AllowPrivateIPs: false,
-		})
-		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+		restored := &models.URL{ID: 1, ShortCode: "abc1234", OriginalURL: "https://example.com"}
+		mockRepo.On("Restore", mock.Anything, "abc1234").Return(nil)
+		mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(restored, nil)
 
-		resp, err := svc.Create(ctx, CreateURLRequest{
-			OriginalURL: "http://192.168.1.1/admin",
-		})
+		svc := NewURLService(mockRepo, new(MockGenerator), baseURL)
+		url, err := svc.Restore(ctx, "abc1234")
 
-		assert.Error(t, err)
-		assert.Nil(t, resp)
-		assert.ErrorIs(t, err, ErrPrivateIPURL)
+		require.NoError(t, err)
+		assert.Equal(t, restored, url)
+		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("blocks URL too long", func(t *testing.T) {
+	t.Run("URL that is not deleted returns not found", func(t *testing.T) {
 		mockRepo := new(MockURLRepository)
-		mockGen := new(MockGenerator)
-		sanitizer := security.NewSanitizer(security.Config{
-			MaxURLLength:    50,
-			AllowPrivateIPs: true,
-		})
-		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+		mockRepo.On("Restore", mock.Anything, "abc1234").Return(models.ErrURLNotFound)
 
-		longURL := "https://example.com/" + string(make([]byte, 100))
-		resp, err := svc.Create(ctx, CreateURLRequest{
-			OriginalURL: longURL,
-		})
+		svc := NewURLService(mockRepo, new(MockGenerator), baseURL)
+		url, err := svc.Restore(ctx, "abc1234")
 
-		assert.Error(t, err)
-		assert.Nil(t, resp)
-		assert.ErrorIs(t, err, ErrURLTooLong)
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+		assert.Nil(t, url)
+		mockRepo.AssertNotCalled(t, "GetByShortCode", mock.Anything, mock.Anything)
 	})
+}
 
-	t.Run("blocks blocked host", func(t *testing.T) {
-		mockRepo := new(MockURLRepository)
-		mockGen := new(MockGenerator)
-		sanitizer := security.NewSanitizer(security.Config{
-			MaxURLLength:    2048,
-			AllowPrivateIPs: true,
-			BlockedHosts:    []string{"evil.com"},
-		})
-		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+func TestURLService_DeletePermanent(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("DeletePermanent", mock.Anything, "abc1234").Return(nil)
+	mockRepo.On("DeletePermanent", mock.Anything, "notfound").Return(models.ErrURLNotFound)
 
-		resp, err := svc.Create(ctx, CreateURLRequest{
-			OriginalURL: "https://evil.com/malware",
-		})
+	svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
 
-		assert.Error(t, err)
-		assert.Nil(t, resp)
-		assert.ErrorIs(t, err, ErrBlockedHostURL)
-	})
+	assert.NoError(t, svc.DeletePermanent(context.Background(), "abc1234"))
+	assert.ErrorIs(t, svc.DeletePermanent(context.Background(), "notfound"), models.ErrURLNotFound)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 }
 
-func TestMapSecurityError(t *testing.T) {
+func TestURLService_Update(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+
 	tests := []struct {
-		name     string
-		input    error
-		expected error
+		name          string
+		shortCode     string
+		newURL        string
+		setupMocks    func(*MockURLRepository)
+		expectedError error
+	}{
+		{
+			name:      "existing code is updated",
+			shortCode: "abc1234",
+			newURL:    "https://example.com/new",
+			setupMocks: func(repo *MockURLRepository) {
+				repo.On("UpdateOriginalURL", mock.Anything, "abc1234", "https://example.com/new").Return(nil)
+				repo.On("GetByShortCode", mock.Anything, "abc1234").Return(&models.URL{
+					ID:          1,
+					ShortCode:   "abc1234",
+					OriginalURL: "https://example.com/new",
+					CreatedAt:   time.Now(),
+				}, nil)
+			},
+		},
+		{
+			name:      "non-existent code returns not found error",
+			shortCode: "notfound",
+			newURL:    "https://example.com/new",
+			setupMocks: func(repo *MockURLRepository) {
+				repo.On("UpdateOriginalURL", mock.Anything, "notfound", "https://example.com/new").Return(models.ErrURLNotFound)
+			},
+			expectedError: models.ErrURLNotFound,
+		},
+		{
+			name:          "empty URL is rejected",
+			shortCode:     "abc1234",
+			newURL:        "",
+			setupMocks:    func(repo *MockURLRepository) {},
+			expectedError: models.ErrEmptyURL,
+		},
+		{
+			name:          "dangerous URL is rejected",
+			shortCode:     "abc1234",
+			newURL:        "javascript:alert(1)",
+			setupMocks:    func(repo *MockURLRepository) {},
+			expectedError: ErrDangerousURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+
+			tt.setupMocks(mockRepo)
+
+			svc := NewURLService(mockRepo, mockGen, baseURL)
+			url, err := svc.Update(ctx, tt.shortCode, tt.newURL)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, url)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.newURL, url.OriginalURL)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Helper functions
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestURLService_Extend(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sets expiry on a non-expiring URL", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		expiresAt := time.Now().Add(168 * time.Hour)
+		mockRepo.On("UpdateExpiry", mock.Anything, "abc1234", expiresAt).Return(&models.URL{
+			ID:          1,
+			ShortCode:   "abc1234",
+			OriginalURL: "https://example.com",
+			ExpiresAt:   &expiresAt,
+		}, nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		url, err := svc.Extend(ctx, "abc1234", expiresAt)
+
+		require.NoError(t, err)
+		require.NotNil(t, url.ExpiresAt)
+		assert.True(t, url.ExpiresAt.Equal(expiresAt))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a time in the past", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		_, err := svc.Extend(ctx, "abc1234", time.Now().Add(-time.Minute))
+
+		assert.ErrorIs(t, err, ErrExpiresAtInPast)
+		mockRepo.AssertNotCalled(t, "UpdateExpiry", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a time beyond the max expiry", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		cfg := DefaultURLServiceConfig()
+		cfg.MaxExpiry = 24 * time.Hour
+
+		svc := NewURLServiceWithConfig(mockRepo, new(MockGenerator), nil, "http://localhost:8080", cfg)
+		_, err := svc.Extend(ctx, "abc1234", time.Now().Add(48*time.Hour))
+
+		assert.ErrorIs(t, err, ErrExpiryTooLong)
+		mockRepo.AssertNotCalled(t, "UpdateExpiry", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("deleted URL is not found", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("UpdateExpiry", mock.Anything, "gone", mock.Anything).Return(nil, models.ErrURLNotFound)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		_, err := svc.Extend(ctx, "gone", time.Now().Add(time.Hour))
+
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+}
+
+func TestURLService_SetActive(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("SetActive", mock.Anything, "abc1234", false).Return(&models.URL{
+		ShortCode: "abc1234", OriginalURL: "https://example.com", Disabled: true,
+	}, nil).Once()
+	mockRepo.On("SetActive", mock.Anything, "abc1234", true).Return(&models.URL{
+		ShortCode: "abc1234", OriginalURL: "https://example.com",
+	}, nil).Once()
+	mockRepo.On("SetActive", mock.Anything, "gone", false).Return(nil, models.ErrURLNotFound)
+
+	svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+
+	url, err := svc.SetActive(ctx, "abc1234", false)
+	require.NoError(t, err)
+	assert.True(t, url.Disabled)
+
+	url, err = svc.SetActive(ctx, "abc1234", true)
+	require.NoError(t, err)
+	assert.False(t, url.Disabled)
+
+	_, err = svc.SetActive(ctx, "gone", false)
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNewURLServiceWithSanitizer(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	sanitizer := security.NewSanitizer(security.DefaultConfig())
+
+	svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, "http://localhost:8080")
+
+	assert.NotNil(t, svc)
+	assert.NotNil(t, svc.repo)
+	assert.NotNil(t, svc.generator)
+	assert.NotNil(t, svc.sanitizer)
+	assert.Equal(t, "http://localhost:8080", svc.baseURL)
+}
+
+func TestURLService_Create_WithSanitizer(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+
+	t.Run("blocks dangerous scheme", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		sanitizer := security.NewSanitizer(security.DefaultConfig())
+		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "javascript:alert(1)",
+		})
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrDangerousURL)
+	})
+
+	t.Run("blocks private IP", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		sanitizer := security.NewSanitizer(security.Config{
+			MaxURLLength:    2048,
+			AllowPrivateIPs: false,
+		})
+		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "http://192.168.1.1/admin",
+		})
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPrivateIPURL)
+	})
+
+	t.Run("blocks URL too long", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		sanitizer := security.NewSanitizer(security.Config{
+			MaxURLLength:    50,
+			AllowPrivateIPs: true,
+		})
+		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+
+		longURL := "https://example.com/" + string(make([]byte, 100))
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: longURL,
+		})
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrURLTooLong)
+	})
+
+	t.Run("blocks blocked host", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		sanitizer := security.NewSanitizer(security.Config{
+			MaxURLLength:    2048,
+			AllowPrivateIPs: true,
+			BlockedHosts:    []string{"evil.com"},
+		})
+		svc := NewURLServiceWithSanitizer(mockRepo, mockGen, sanitizer, baseURL)
+
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://evil.com/malware",
+		})
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrBlockedHostURL)
+	})
+}
+
+func TestMapSecurityError(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    error
+		expected error
 	}{
 		{
 			name:     "dangerous scheme",
@@ -541,11 +1113,21 @@ func TestMapSecurityError(t *testing.T) {
 			input:    security.ErrBlockedHost,
 			expected: ErrBlockedHostURL,
 		},
+		{
+			name:     "host not allowed",
+			input:    security.ErrHostNotAllowed,
+			expected: ErrHostNotAllowed,
+		},
 		{
 			name:     "URL too long",
 			input:    security.ErrURLTooLong,
 			expected: ErrURLTooLong,
 		},
+		{
+			name:     "unresolvable host",
+			input:    security.ErrUnresolvable,
+			expected: models.ErrInvalidURL,
+		},
 		{
 			name:     "unknown error",
 			input:    errors.New("unknown error"),
@@ -582,3 +1164,1183 @@ func TestURLService_Create_WithNilSanitizer(t *testing.T) {
 		assert.ErrorIs(t, err, models.ErrInvalidURL)
 	})
 }
+
+func TestURLService_Update_NormalizesURL(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("UpdateOriginalURL", mock.Anything, "abc1234", "https://example.com/new").Return(nil)
+	mockRepo.On("GetByShortCode", mock.Anything, "abc1234").Return(&models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/new",
+		CreatedAt:   time.Now(),
+	}, nil)
+
+	svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+	url, err := svc.Update(context.Background(), "abc1234", "https://Example.COM:443/a/../new")
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/new", url.OriginalURL)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLService_Create_NormalizesURL(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+
+	tests := []struct {
+		name     string
+		cfg      URLServiceConfig
+		input    string
+		expected string
+	}{
+		{
+			name:     "canonicalizes host, port and path",
+			cfg:      DefaultURLServiceConfig(),
+			input:    "https://Example.com:443/a/./b/../c",
+			expected: "https://example.com/a/c",
+		},
+		{
+			name:     "equivalent root URLs match",
+			cfg:      DefaultURLServiceConfig(),
+			input:    "https://Example.com",
+			expected: "https://example.com/",
+		},
+		{
+			name: "applies configured options",
+			cfg: URLServiceConfig{
+				Normalize: security.NormalizeOptions{StripTrailingSlash: true, StripFragment: true},
+			},
+			input:    "https://example.com/docs/#intro",
+			expected: "https://example.com/docs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+			mockGen.On("Generate").Return("norm123", nil)
+			mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+				return u.OriginalURL == tt.expected
+			})).Return(&models.URL{
+				ID:          1,
+				ShortCode:   "norm123",
+				OriginalURL: tt.expected,
+				CreatedAt:   time.Now(),
+			}, nil)
+
+			sanitizer := security.NewSanitizer(security.DefaultConfig())
+			svc := NewURLServiceWithConfig(mockRepo, mockGen, sanitizer, baseURL, tt.cfg)
+			resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: tt.input})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resp.OriginalURL)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLService_Create_CustomAlias(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+
+	t.Run("uses alias instead of generator", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("Exists", mock.Anything, "summer-sale").Return(false, nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ShortCode == "summer-sale"
+		})).Return(&models.URL{
+			ID:          1,
+			ShortCode:   "summer-sale",
+			OriginalURL: "https://example.com/sale",
+			CreatedAt:   time.Now(),
+		}, nil)
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{
+			AliasMinLength: 3,
+			AliasMaxLength: 20,
+		})
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "summer-sale",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "summer-sale", resp.ShortCode)
+		assert.Equal(t, "http://localhost:8080/summer-sale", resp.ShortURL)
+		mockRepo.AssertExpectations(t)
+		mockGen.AssertNotCalled(t, "Generate")
+	})
+
+	t.Run("rejects alias longer than MaxShortCodeLen", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("Exists", mock.Anything, "summer-sale").Return(false, nil)
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{
+			AliasMinLength:  3,
+			AliasMaxLength:  20,
+			MaxShortCodeLen: 10,
+		})
+		_, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "summer-sale",
+		})
+
+		assert.ErrorIs(t, err, models.ErrShortCodeTooLong)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("passes permanent flag to repository", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("perm123", nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ShortCode == "perm123" && u.Permanent
+		})).Return(&models.URL{
+			ID:          2,
+			ShortCode:   "perm123",
+			OriginalURL: "https://example.com/landing",
+			CreatedAt:   time.Now(),
+			Permanent:   true,
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/landing",
+			Permanent:   true,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Permanent)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects taken alias", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("Exists", mock.Anything, "promo").Return(true, nil)
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "promo",
+		})
+
+		assert.ErrorIs(t, err, ErrAliasTaken)
+		assert.Nil(t, resp)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("maps a duplicate key on insert to ErrAliasTaken", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("Exists", mock.Anything, "promo").Return(false, nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: promo", models.ErrShortCodeExists))
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "promo",
+		})
+
+		assert.ErrorIs(t, err, ErrAliasTaken)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("concurrent requests for one alias create it once", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		// Every request sees the alias as free, as when they race past Exists
+		mockRepo.On("Exists", mock.Anything, "launch").Return(false, nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { time.Sleep(20 * time.Millisecond) }).
+			Return(&models.URL{ShortCode: "launch", OriginalURL: "https://example.com/launch"}, nil).Once()
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil, models.ErrShortCodeExists)
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+
+		const requests = 20
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, requests)
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, errs[i] = svc.Create(ctx, CreateURLRequest{
+					OriginalURL: "https://example.com/launch",
+					CustomAlias: "launch",
+				})
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, ErrAliasTaken)
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+
+	t.Run("rejects reserved alias", func(t *testing.T) {
+		for _, alias := range []string{"api", "health", "docs"} {
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+
+			svc := NewURLService(mockRepo, mockGen, baseURL)
+			resp, err := svc.Create(ctx, CreateURLRequest{
+				OriginalURL: "https://example.com/sale",
+				CustomAlias: alias,
+			})
+
+			assert.ErrorIs(t, err, ErrReservedCode)
+			assert.Nil(t, resp)
+			mockRepo.AssertNotCalled(t, "Exists", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("uses configured reserved codes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{
+			ReservedCodes: []string{"admin"},
+		})
+		_, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "admin",
+		})
+
+		assert.ErrorIs(t, err, ErrReservedCode)
+	})
+
+	t.Run("rejects invalid characters", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "bad/alias",
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidAlias)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("enforces length bounds", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		for _, alias := range []string{"ab", "abcdefghijk"} {
+			resp, err := svc.Create(ctx, CreateURLRequest{
+				OriginalURL: "https://example.com/sale",
+				CustomAlias: alias,
+			})
+
+			assert.ErrorIs(t, err, ErrAliasLength)
+			assert.Nil(t, resp)
+		}
+	})
+
+	t.Run("propagates existence check errors", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("Exists", mock.Anything, "promo").Return(false, errors.New("database error"))
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: "promo",
+		})
+
+		assert.EqualError(t, err, "database error")
+		assert.Nil(t, resp)
+	})
+}
+
+func TestURLService_Create_WithPassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores bcrypt hash", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("pass123", nil)
+
+		var stored string
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			stored = u.PasswordHash
+			return u.PasswordHash != "" && u.PasswordHash != "s3cret"
+		})).Return(&models.URL{
+			ID:           1,
+			ShortCode:    "pass123",
+			OriginalURL:  "https://example.com/",
+			CreatedAt:    time.Now(),
+			PasswordHash: "$2a$10$hash",
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", Password: "s3cret"})
+
+		require.NoError(t, err)
+		assert.True(t, resp.PasswordProtected)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored), []byte("s3cret")))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects passwords longer than 72 bytes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		_, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			Password:    strings.Repeat("a", 73),
+		})
+
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestURLService_Create_MaxClicks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores click limit", func(t *testing.T) {
+		maxClicks := int64(1)
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("once123", nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.MaxClicks != nil && *u.MaxClicks == 1
+		})).Return(&models.URL{
+			ID:          1,
+			ShortCode:   "once123",
+			OriginalURL: "https://example.com/",
+			CreatedAt:   time.Now(),
+			MaxClicks:   &maxClicks,
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", MaxClicks: &maxClicks})
+
+		require.NoError(t, err)
+		require.NotNil(t, resp.MaxClicks)
+		assert.Equal(t, int64(1), *resp.MaxClicks)
+		mockRepo.AssertExpectations(t)
+	})
+
+	for _, n := range []int64{0, -1} {
+		t.Run(fmt.Sprintf("rejects %d", n), func(t *testing.T) {
+			maxClicks := n
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+
+			svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+			_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", MaxClicks: &maxClicks})
+
+			assert.ErrorIs(t, err, ErrInvalidMaxClicks)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestURLService_Create_AppendParams(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores params", func(t *testing.T) {
+		params := map[string]string{"utm_source": "newsletter", "utm_medium": "email"}
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("utm1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.AppendParams["utm_source"] == "newsletter" && u.AppendParams["utm_medium"] == "email"
+		})).Return(&models.URL{
+			ID:           1,
+			ShortCode:    "utm1234",
+			OriginalURL:  "https://example.com/",
+			CreatedAt:    time.Now(),
+			AppendParams: params,
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", AppendParams: params})
+
+		require.NoError(t, err)
+		assert.Equal(t, params, resp.AppendParams)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects empty names", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", AppendParams: map[string]string{"": "x"}})
+
+		assert.ErrorIs(t, err, ErrInvalidAppendParams)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestURLService_Create_ExpiresAt(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("prefers absolute expiry over duration", func(t *testing.T) {
+		expiresAt := time.Date(2099, 12, 31, 23, 59, 0, 0, time.UTC)
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("cal1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ExpiresAt != nil && u.ExpiresAt.Equal(expiresAt)
+		})).Return(&models.URL{
+			ID:          1,
+			ShortCode:   "cal1234",
+			OriginalURL: "https://example.com/",
+			CreatedAt:   time.Now(),
+			ExpiresAt:   &expiresAt,
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			ExpiresIn:   durationPtr(time.Hour),
+			ExpiresAt:   &expiresAt,
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, resp.ExpiresAt)
+		assert.True(t, expiresAt.Equal(*resp.ExpiresAt))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects expiry in the past", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		_, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			ExpiresAt:   timePtr(time.Now().Add(-time.Minute)),
+		})
+
+		assert.ErrorIs(t, err, ErrExpiresAtInPast)
+		mockGen.AssertNotCalled(t, "Generate")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestURLService_CreateAliasFor(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+	maxClicks := int64(50)
+	expiresAt := time.Now().Add(time.Hour)
+	newSource := func() *models.URL {
+		return &models.URL{
+			ID:           1,
+			ShortCode:    "spring",
+			OriginalURL:  "https://example.com/sale",
+			CreatedAt:    time.Now(),
+			ExpiresAt:    &expiresAt,
+			ClickCount:   42,
+			PasswordHash: "hash",
+			AppendParams: map[string]string{"utm_source": "mail"},
+			Tags:         []string{"sale"},
+		}
+	}
+
+	t.Run("copies the destination and options into a new code", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		notifier := &recordingNotifier{}
+		mockRepo.On("GetByShortCode", mock.Anything, "spring").Return(newSource(), nil)
+		mockRepo.On("Exists", mock.Anything, "spring-tw").Return(false, nil)
+		mockRepo.On("Create", mock.Anything, &models.URLCreate{
+			OriginalURL:  "https://example.com/sale",
+			ShortCode:    "spring-tw",
+			ExpiresAt:    &expiresAt,
+			PasswordHash: "hash",
+			AppendParams: map[string]string{"utm_source": "mail"},
+			Tags:         []string{"sale"},
+		}).Return(&models.URL{
+			ID:          2,
+			ShortCode:   "spring-tw",
+			OriginalURL: "https://example.com/sale",
+			CreatedAt:   time.Now(),
+			ExpiresAt:   &expiresAt,
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, baseURL)
+		svc.SetNotifier(notifier)
+		resp, err := svc.CreateAliasFor(ctx, "spring", "spring-tw")
+
+		require.NoError(t, err)
+		assert.Equal(t, "spring-tw", resp.ShortCode)
+		assert.Equal(t, "http://localhost:8080/spring-tw", resp.ShortURL)
+		assert.Equal(t, "https://example.com/sale", resp.OriginalURL)
+		require.Len(t, notifier.Events(), 1)
+		assert.Equal(t, "spring-tw", notifier.Events()[0].ShortCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects sources that cannot redirect", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		exhausted := int64(42)
+		tests := []struct {
+			name    string
+			modify  func(*models.URL)
+			wantErr error
+		}{
+			{"expired", func(u *models.URL) { u.ExpiresAt = &past }, models.ErrURLExpired},
+			{"exhausted", func(u *models.URL) { u.MaxClicks = &exhausted }, models.ErrURLExhausted},
+			{"disabled", func(u *models.URL) { u.Disabled = true }, models.ErrURLDisabled},
+			{"click-limited", func(u *models.URL) { u.MaxClicks = &maxClicks }, ErrAliasClickLimited},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				source := newSource()
+				tt.modify(source)
+				mockRepo := new(MockURLRepository)
+				mockRepo.On("GetByShortCode", mock.Anything, "spring").Return(source, nil)
+
+				svc := NewURLService(mockRepo, new(MockGenerator), baseURL)
+				_, err := svc.CreateAliasFor(ctx, "spring", "spring-tw")
+
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("rejects missing sources and taken aliases", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShortCode", mock.Anything, "missing").Return(nil, models.ErrURLNotFound)
+		mockRepo.On("GetByShortCode", mock.Anything, "spring").Return(newSource(), nil)
+		mockRepo.On("Exists", mock.Anything, "summer").Return(true, nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), baseURL)
+
+		_, err := svc.CreateAliasFor(ctx, "missing", "spring-tw")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+		_, err = svc.CreateAliasFor(ctx, "spring", "summer")
+		assert.ErrorIs(t, err, ErrAliasTaken)
+
+		_, err = svc.CreateAliasFor(ctx, "spring", "api")
+		assert.ErrorIs(t, err, ErrReservedCode)
+	})
+}
+
+func TestURLService_Create_BaseURLFromContext(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	mockGen := new(MockGenerator)
+	mockGen.On("Generate").Return("abc1234", nil)
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.URL{
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com",
+		CreatedAt:   time.Now(),
+	}, nil)
+	svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+
+	resp, err := svc.Create(WithBaseURL(context.Background(), "https://go.example.com"), CreateURLRequest{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.example.com/abc1234", resp.ShortURL)
+
+	resp, err = svc.Create(context.Background(), CreateURLRequest{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/abc1234", resp.ShortURL)
+}
+
+func TestURLService_Create_ExpiryLimits(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+
+	newService := func(repo *MockURLRepository, defaultExpiry, maxExpiry time.Duration) *URLServiceImpl {
+		gen := new(MockGenerator)
+		gen.On("Generate").Return("exp1234", nil)
+		cfg := DefaultURLServiceConfig()
+		cfg.DefaultExpiry = defaultExpiry
+		cfg.MaxExpiry = maxExpiry
+		return NewURLServiceWithConfig(repo, gen, nil, "http://localhost:8080", cfg)
+	}
+	expiresWithin := func(from, to time.Time) interface{} {
+		return mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ExpiresAt != nil && !u.ExpiresAt.Before(from) && !u.ExpiresAt.After(to)
+		})
+	}
+	created := &models.URL{ID: 1, ShortCode: "exp1234", OriginalURL: "https://example.com/", CreatedAt: time.Now()}
+
+	t.Run("applies the default expiry", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		start := time.Now()
+		mockRepo.On("Create", mock.Anything, expiresWithin(start.Add(90*day), time.Now().Add(90*day+time.Minute))).
+			Return(created, nil)
+
+		_, err := newService(mockRepo, 90*day, 0).Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("requested expiry overrides the default", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		start := time.Now()
+		mockRepo.On("Create", mock.Anything, expiresWithin(start.Add(time.Hour), time.Now().Add(time.Hour+time.Minute))).
+			Return(created, nil)
+
+		_, err := newService(mockRepo, 90*day, 0).Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			ExpiresIn:   durationPtr(time.Hour),
+		})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("max expiry applies when there is no default", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		start := time.Now()
+		mockRepo.On("Create", mock.Anything, expiresWithin(start.Add(30*day), time.Now().Add(30*day+time.Minute))).
+			Return(created, nil)
+
+		_, err := newService(mockRepo, 0, 30*day).Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no limits leaves the URL without expiry", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.ExpiresAt == nil
+		})).Return(created, nil)
+
+		_, err := newService(mockRepo, 0, 0).Create(ctx, CreateURLRequest{OriginalURL: "https://example.com"})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects expires_in beyond the max", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+
+		_, err := newService(mockRepo, 0, 30*day).Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			ExpiresIn:   durationPtr(31 * day),
+		})
+
+		assert.ErrorIs(t, err, ErrExpiryTooLong)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects expires_at beyond the max", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+
+		_, err := newService(mockRepo, 0, 30*day).Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			ExpiresAt:   timePtr(time.Now().Add(31 * day)),
+		})
+
+		assert.ErrorIs(t, err, ErrExpiryTooLong)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestURLService_Create_PlatformTargets(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores normalized targets", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("app1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return u.PlatformTargets[models.PlatformIOS] == "https://apps.apple.com/app/id123"
+		})).Return(&models.URL{
+			ID:              1,
+			ShortCode:       "app1234",
+			OriginalURL:     "https://example.com/",
+			CreatedAt:       time.Now(),
+			PlatformTargets: map[string]string{models.PlatformIOS: "https://apps.apple.com/app/id123"},
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL:     "https://example.com",
+			PlatformTargets: map[string]string{models.PlatformIOS: "HTTPS://Apps.Apple.com/app/id123"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://apps.apple.com/app/id123", resp.PlatformTargets[models.PlatformIOS])
+		mockRepo.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name    string
+		targets map[string]string
+		wantErr error
+	}{
+		{"unknown platform", map[string]string{"windows": "https://example.com/win"}, ErrInvalidPlatform},
+		{"invalid target", map[string]string{models.PlatformAndroid: "not a url"}, models.ErrInvalidURL},
+		{"dangerous target", map[string]string{models.PlatformIOS: "javascript:alert(1)"}, ErrDangerousURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+
+			svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+			_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", PlatformTargets: tt.targets})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestURLService_Create_Tags(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores normalized tags", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("tag1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.URLCreate) bool {
+			return assert.ObjectsAreEqual([]string{"promo", "q3_launch"}, u.Tags)
+		})).Return(&models.URL{
+			ID:          1,
+			ShortCode:   "tag1234",
+			OriginalURL: "https://example.com/",
+			CreatedAt:   time.Now(),
+			Tags:        []string{"promo", "q3_launch"},
+		}, nil)
+
+		svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+		resp, err := svc.Create(ctx, CreateURLRequest{
+			OriginalURL: "https://example.com",
+			Tags:        []string{" Promo ", "q3_launch", "PROMO"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"promo", "q3_launch"}, resp.Tags)
+		mockRepo.AssertExpectations(t)
+	})
+
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		wantErr error
+	}{
+		{"empty tag", []string{"promo", " "}, ErrInvalidTag},
+		{"invalid characters", []string{"summer sale"}, ErrInvalidTag},
+		{"too long", []string{strings.Repeat("a", MaxTagLength+1)}, ErrInvalidTag},
+		{"too many tags", tooMany, ErrTooManyTags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+
+			svc := NewURLService(mockRepo, mockGen, "http://localhost:8080")
+			_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com", Tags: tt.tags})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestURLService_Create_Dedupe(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "http://localhost:8080"
+	existing := &models.URL{
+		ID:          1,
+		ShortCode:   "abc1234",
+		OriginalURL: "https://example.com/",
+		CreatedAt:   time.Now().Add(-time.Hour),
+	}
+
+	t.Run("returns the existing code for a plain request", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		// The lookup uses the canonical form of the URL
+		mockRepo.On("GetByOriginalURL", mock.Anything, "https://example.com/").Return(existing, nil)
+		notifier := &recordingNotifier{}
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{Dedupe: true})
+		svc.SetNotifier(notifier)
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://Example.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc1234", resp.ShortCode)
+		assert.Equal(t, "http://localhost:8080/abc1234", resp.ShortURL)
+		mockGen.AssertNotCalled(t, "Generate")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		assert.Empty(t, notifier.Events())
+	})
+
+	t.Run("creates a URL when the destination is new", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("GetByOriginalURL", mock.Anything, "https://example.com/new").Return(nil, models.ErrURLNotFound)
+		mockGen.On("Generate").Return("new1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.URL{
+			ID:          2,
+			ShortCode:   "new1234",
+			OriginalURL: "https://example.com/new",
+			CreatedAt:   time.Now(),
+		}, nil)
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{Dedupe: true})
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com/new"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "new1234", resp.ShortCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("lookup errors fail the request", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		dbErr := errors.New("connection refused")
+		mockRepo.On("GetByOriginalURL", mock.Anything, mock.Anything).Return(nil, dbErr)
+
+		svc := NewURLServiceWithConfig(mockRepo, new(MockGenerator), nil, baseURL, URLServiceConfig{Dedupe: true})
+		_, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com/"})
+
+		assert.ErrorIs(t, err, dbErr)
+	})
+
+	maxClicks := int64(5)
+	overrides := []struct {
+		name string
+		req  CreateURLRequest
+	}{
+		{"custom alias", CreateURLRequest{CustomAlias: "my-link"}},
+		{"expires in", CreateURLRequest{ExpiresIn: durationPtr(time.Hour)}},
+		{"permanent", CreateURLRequest{Permanent: true}},
+		{"max clicks", CreateURLRequest{MaxClicks: &maxClicks}},
+		{"tags", CreateURLRequest{Tags: []string{"promo"}}},
+	}
+	for _, tt := range overrides {
+		t.Run("per-request "+tt.name+" forces a new code", func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockGen := new(MockGenerator)
+			mockGen.On("Generate").Return("new1234", nil).Maybe()
+			mockRepo.On("Exists", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+			mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.URL{
+				ID:          2,
+				ShortCode:   "new1234",
+				OriginalURL: "https://example.com/",
+				CreatedAt:   time.Now(),
+			}, nil)
+
+			svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{Dedupe: true})
+			req := tt.req
+			req.OriginalURL = "https://example.com/"
+			_, err := svc.Create(ctx, req)
+
+			require.NoError(t, err)
+			mockRepo.AssertNotCalled(t, "GetByOriginalURL", mock.Anything, mock.Anything)
+			mockRepo.AssertCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("disabled always creates a new code", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockGen.On("Generate").Return("new1234", nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.URL{
+			ID:          2,
+			ShortCode:   "new1234",
+			OriginalURL: "https://example.com/",
+			CreatedAt:   time.Now(),
+		}, nil)
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, DefaultURLServiceConfig())
+		resp, err := svc.Create(ctx, CreateURLRequest{OriginalURL: "https://example.com/"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "new1234", resp.ShortCode)
+		mockRepo.AssertNotCalled(t, "GetByOriginalURL", mock.Anything, mock.Anything)
+	})
+
+	t.Run("batch entries are deduplicated", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockGen := new(MockGenerator)
+		mockRepo.On("GetByOriginalURL", mock.Anything, "https://example.com/").Return(existing, nil)
+		mockRepo.On("GetByOriginalURL", mock.Anything, "https://example.com/b").Return(nil, models.ErrURLNotFound)
+		mockGen.On("Generate").Return("bbb1234", nil).Once()
+		mockRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(creates []*models.URLCreate) bool {
+			return len(creates) == 1 && creates[0].OriginalURL == "https://example.com/b"
+		})).Return([]*models.URL{{
+			ID:          3,
+			ShortCode:   "bbb1234",
+			OriginalURL: "https://example.com/b",
+			CreatedAt:   time.Now(),
+		}}, nil)
+
+		svc := NewURLServiceWithConfig(mockRepo, mockGen, nil, baseURL, URLServiceConfig{Dedupe: true})
+		resps, errs := svc.CreateBatch(ctx, []CreateURLRequest{
+			{OriginalURL: "https://example.com"},
+			{OriginalURL: "https://example.com/b"},
+		})
+
+		require.Len(t, resps, 2)
+		assert.NoError(t, errs[0])
+		assert.NoError(t, errs[1])
+		assert.Equal(t, "abc1234", resps[0].ShortCode)
+		assert.Equal(t, "bbb1234", resps[1].ShortCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestURLService_DeleteBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports missing codes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("DeleteBatch", mock.Anything, []string{"abc1234", "missing", "def5678"}).
+			Return([]string{"abc1234", "def5678"}, nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		results := svc.DeleteBatch(ctx, []string{"abc1234", "missing", "def5678"})
+
+		assert.Len(t, results, 3)
+		assert.NoError(t, results["abc1234"])
+		assert.NoError(t, results["def5678"])
+		assert.ErrorIs(t, results["missing"], models.ErrURLNotFound)
+	})
+
+	t.Run("repository error fails every code", func(t *testing.T) {
+		dbErr := errors.New("connection refused")
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("DeleteBatch", mock.Anything, []string{"abc1234", "def5678"}).Return(nil, dbErr)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		results := svc.DeleteBatch(ctx, []string{"abc1234", "def5678"})
+
+		assert.ErrorIs(t, results["abc1234"], dbErr)
+		assert.ErrorIs(t, results["def5678"], dbErr)
+	})
+
+	t.Run("partial failure only fails the named codes", func(t *testing.T) {
+		shardErr := errors.New("shard 1: connection refused")
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("DeleteBatch", mock.Anything, []string{"abc1234", "def5678", "missing"}).
+			Return([]string{"abc1234"}, &repository.BatchError{Failed: map[string]error{"def5678": shardErr}})
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		results := svc.DeleteBatch(ctx, []string{"abc1234", "def5678", "missing"})
+
+		assert.NoError(t, results["abc1234"])
+		assert.ErrorIs(t, results["def5678"], shardErr)
+		assert.ErrorIs(t, results["missing"], models.ErrURLNotFound)
+	})
+}
+
+func TestURLService_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports and announces deleted URLs", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("DeleteExpired", mock.Anything).Return([]string{"old1234", "old5678"}, nil)
+		notifier := &recordingNotifier{}
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		svc.SetNotifier(notifier)
+		count, err := svc.PurgeExpired(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		events := notifier.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, EventURLsExpired, events[0].Type)
+		assert.Equal(t, int64(2), events[0].Count)
+	})
+
+	t.Run("nothing expired sends nothing", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("DeleteExpired", mock.Anything).Return(nil, nil)
+		notifier := &recordingNotifier{}
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		svc.SetNotifier(notifier)
+		count, err := svc.PurgeExpired(ctx)
+
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Empty(t, notifier.Events())
+	})
+
+	t.Run("returns repository errors", func(t *testing.T) {
+		dbErr := errors.New("connection refused")
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("DeleteExpired", mock.Anything).Return(nil, dbErr)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		_, err := svc.PurgeExpired(ctx)
+
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
+
+func TestURLService_Get_Exhausted(t *testing.T) {
+	maxClicks := int64(2)
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("GetByShortCode", mock.Anything, "used123").Return(&models.URL{
+		ID:          1,
+		ShortCode:   "used123",
+		OriginalURL: "https://example.com/",
+		CreatedAt:   time.Now(),
+		ClickCount:  2,
+		MaxClicks:   &maxClicks,
+	}, nil)
+
+	svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+	_, err := svc.Get(context.Background(), "used123")
+
+	assert.ErrorIs(t, err, models.ErrURLExhausted)
+}
+
+func TestURLService_List(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delegates to repository", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		filter := repository.ListFilter{Search: "promo", Status: repository.ListStatusActive}
+		urls := []*models.URL{{ID: 1, ShortCode: "promo1", OriginalURL: "https://example.com/"}}
+		mockRepo.On("List", mock.Anything, 20, 40, filter).Return(urls, int64(41), nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		got, total, err := svc.List(ctx, 20, 40, filter)
+
+		require.NoError(t, err)
+		assert.Equal(t, urls, got)
+		assert.Equal(t, int64(41), total)
+		mockRepo.AssertExpectations(t)
+	})
+
+	for _, tt := range []struct {
+		name          string
+		limit, offset int
+	}{
+		{"zero limit", 0, 0},
+		{"limit above max", MaxListLimit + 1, 0},
+		{"negative offset", 10, -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+
+			_, _, err := svc.List(ctx, tt.limit, tt.offset, repository.ListFilter{})
+
+			assert.ErrorIs(t, err, ErrInvalidPagination)
+			mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestURLService_ListAfter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delegates to repository", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		filter := repository.ListFilter{Search: "promo"}
+		urls := []*models.URL{{ID: 11, ShortCode: "promo1"}, {ID: 12, ShortCode: "promo2"}}
+		mockRepo.On("ListAfter", mock.Anything, int64(10), 2, filter).Return(urls, int64(12), nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		got, next, err := svc.ListAfter(ctx, 10, 2, filter)
+
+		require.NoError(t, err)
+		assert.Equal(t, urls, got)
+		assert.Equal(t, int64(12), next)
+		mockRepo.AssertExpectations(t)
+	})
+
+	for _, tt := range []struct {
+		name    string
+		afterID int64
+		limit   int
+		wantErr error
+	}{
+		{"zero limit", 0, 0, ErrInvalidPagination},
+		{"limit above max", 0, MaxListLimit + 1, ErrInvalidPagination},
+		{"negative cursor", -1, 10, ErrInvalidCursor},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+
+			_, _, err := svc.ListAfter(ctx, tt.afterID, tt.limit, repository.ListFilter{})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "ListAfter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestURLService_Export(t *testing.T) {
+	ctx := context.Background()
+
+	firstPage := []*models.URL{{ID: 1, ShortCode: "a1"}, {ID: 2, ShortCode: "a2"}}
+	lastPage := []*models.URL{{ID: 5, ShortCode: "a5"}}
+
+	t.Run("follows the cursor through all pages", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("ListAfter", mock.Anything, int64(0), ExportPageSize, repository.ListFilter{}).Return(firstPage, int64(2), nil)
+		mockRepo.On("ListAfter", mock.Anything, int64(2), ExportPageSize, repository.ListFilter{}).Return(lastPage, int64(0), nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		var codes []string
+		err := svc.Export(ctx, func(url *models.URL) error {
+			codes = append(codes, url.ShortCode)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a1", "a2", "a5"}, codes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("stops on callback error", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("ListAfter", mock.Anything, int64(0), ExportPageSize, repository.ListFilter{}).Return(firstPage, int64(2), nil)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		writeErr := errors.New("write failed")
+		calls := 0
+		err := svc.Export(ctx, func(url *models.URL) error {
+			calls++
+			return writeErr
+		})
+
+		assert.ErrorIs(t, err, writeErr)
+		assert.Equal(t, 1, calls)
+		mockRepo.AssertNumberOfCalls(t, "ListAfter", 1)
+	})
+
+	t.Run("returns repository error", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		dbErr := errors.New("connection refused")
+		mockRepo.On("ListAfter", mock.Anything, int64(0), ExportPageSize, repository.ListFilter{}).Return(nil, int64(0), dbErr)
+
+		svc := NewURLService(mockRepo, new(MockGenerator), "http://localhost:8080")
+		err := svc.Export(ctx, func(url *models.URL) error { return nil })
+
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
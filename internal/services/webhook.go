@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emadnahed/FastGoLink/pkg/logger"
+)
+
+// Link event types sent to webhooks.
+const (
+	EventURLCreated        = "url.created"         // A short URL was created
+	EventURLClickMilestone = "url.click_milestone" // A URL's click count reached a milestone
+	EventURLsExpired       = "urls.expired"        // The reaper or a purge deleted expired URLs
+)
+
+// Webhook request headers.
+const (
+	WebhookEventHeader     = "X-FastGoLink-Event"
+	WebhookDeliveryHeader  = "X-FastGoLink-Delivery"
+	WebhookSignatureHeader = "X-FastGoLink-Signature"
+)
+
+// LinkEvent is the JSON payload POSTed to the webhook endpoint. Fields that
+// do not apply to the event type are omitted.
+type LinkEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+
+	ShortCode   string `json:"short_code,omitempty"`
+	OriginalURL string `json:"original_url,omitempty"`
+	ClickCount  int64  `json:"click_count,omitempty"` // Clicks when the milestone was detected
+	Milestone   int64  `json:"milestone,omitempty"`   // Milestone reached, for EventURLClickMilestone
+	Count       int64  `json:"count,omitempty"`       // URLs deleted, for EventURLsExpired
+}
+
+// Notifier receives link events.
+type Notifier interface {
+	Notify(event LinkEvent)
+}
+
+// WebhookConfig holds tunable settings for WebhookNotifier.
+type WebhookConfig struct {
+	URL          string        // Endpoint events are POSTed to
+	Secret       string        // Shared secret for signing payloads; empty sends them unsigned
+	Timeout      time.Duration // Timeout for a single delivery attempt
+	MaxRetries   int           // Retries after a failed delivery
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each further one
+	QueueSize    int           // Events buffered for delivery before new ones are dropped
+}
+
+// DefaultWebhookConfig returns the default WebhookNotifier configuration.
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout:      5 * time.Second,
+		MaxRetries:   3,
+		RetryBackoff: time.Second,
+		QueueSize:    1000,
+	}
+}
+
+// WebhookNotifier POSTs link events to a webhook endpoint. Notify never
+// blocks: events are queued and delivered in order by a background worker,
+// which retries network errors, 429 and 5xx responses with exponential
+// backoff. When a secret is configured, the hex HMAC-SHA256 of the body is
+// sent in the X-FastGoLink-Signature header as "sha256=<hex>".
+type WebhookNotifier struct {
+	client *http.Client
+	cfg    WebhookConfig
+	log    *logger.Logger
+
+	events    chan LinkEvent
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewWebhookNotifier creates a webhook notifier and starts its delivery
+// worker. Zero config fields other than URL and Secret take their defaults,
+// and log may be nil. Close stops the worker.
+func NewWebhookNotifier(cfg WebhookConfig, log *logger.Logger) *WebhookNotifier {
+	return NewWebhookNotifierWithClient(&http.Client{}, cfg, log)
+}
+
+// NewWebhookNotifierWithClient is like NewWebhookNotifier but delivers
+// events with client.
+func NewWebhookNotifierWithClient(client *http.Client, cfg WebhookConfig, log *logger.Logger) *WebhookNotifier {
+	defaults := DefaultWebhookConfig()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaults.RetryBackoff
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+
+	n := &WebhookNotifier{
+		client: client,
+		cfg:    cfg,
+		log:    log,
+		events: make(chan LinkEvent, cfg.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// Notify queues event for delivery, filling in its ID and timestamp when
+// unset. The event is dropped if the queue is full or the notifier is closed.
+func (n *WebhookNotifier) Notify(event LinkEvent) {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	select {
+	case <-n.stop:
+		return
+	default:
+	}
+	select {
+	case n.events <- event:
+	default:
+		if n.log != nil {
+			n.log.Warn("webhook queue full, dropping event", "type", event.Type, "id", event.ID)
+		}
+	}
+}
+
+// Close stops accepting events and waits until the queued ones have been
+// delivered or ctx is done. Deliveries in progress when ctx is done are
+// abandoned.
+func (n *WebhookNotifier) Close(ctx context.Context) error {
+	n.closeOnce.Do(func() { close(n.stop) })
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers queued events until the notifier is closed and the queue
+// is drained.
+func (n *WebhookNotifier) run() {
+	defer close(n.done)
+	for {
+		select {
+		case event := <-n.events:
+			n.deliver(event)
+		case <-n.stop:
+			for {
+				select {
+				case event := <-n.events:
+					n.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends event, retrying failed attempts.
+func (n *WebhookNotifier) deliver(event LinkEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		if n.log != nil {
+			n.log.Error("failed to encode webhook event", "type", event.Type, "error", err.Error())
+		}
+		return
+	}
+
+	backoff := n.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(event, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= n.cfg.MaxRetries {
+			if n.log != nil {
+				n.log.Error("webhook delivery failed",
+					"type", event.Type, "id", event.ID, "attempts", attempt+1, "error", err.Error())
+			}
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post makes a single delivery attempt and reports whether a failure is
+// worth retrying.
+func (n *WebhookNotifier) post(event LinkEvent, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.Type)
+	req.Header.Set(WebhookDeliveryHeader, event.ID)
+	if n.cfg.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(n.cfg.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+}
+
+// SignWebhookPayload returns the X-FastGoLink-Signature value for body:
+// "sha256=" followed by the hex HMAC-SHA256 of body keyed with secret.
+// Receivers should compare it to their own with hmac.Equal.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEventID returns a random identifier for an event.
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
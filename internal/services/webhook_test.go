@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedRequest is a webhook delivery seen by a capture server.
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newCaptureServer records webhook deliveries and answers with the status
+// returned by status for each attempt, counting from 1.
+func newCaptureServer(t *testing.T, status func(attempt int) int) (*httptest.Server, func() []capturedRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []capturedRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, capturedRequest{header: r.Header.Clone(), body: body})
+		attempt := len(requests)
+		mu.Unlock()
+		w.WriteHeader(status(attempt))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []capturedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedRequest(nil), requests...)
+	}
+}
+
+func closeNotifier(t *testing.T, n *WebhookNotifier) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, n.Close(ctx))
+}
+
+func TestWebhookNotifier_PayloadAndSignature(t *testing.T) {
+	srv, requests := newCaptureServer(t, func(int) int { return http.StatusNoContent })
+
+	n := NewWebhookNotifierWithClient(srv.Client(), WebhookConfig{URL: srv.URL, Secret: "s3cret"}, nil)
+	n.Notify(LinkEvent{Type: EventURLCreated, ShortCode: "abc1234", OriginalURL: "https://example.com"})
+	closeNotifier(t, n)
+
+	got := requests()
+	require.Len(t, got, 1)
+	req := got[0]
+
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, EventURLCreated, req.header.Get(WebhookEventHeader))
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, "url.created", payload["type"])
+	assert.Equal(t, "abc1234", payload["short_code"])
+	assert.Equal(t, "https://example.com", payload["original_url"])
+	assert.Len(t, payload["id"], 32)
+	assert.Equal(t, payload["id"], req.header.Get(WebhookDeliveryHeader))
+	_, err := time.Parse(time.RFC3339Nano, payload["timestamp"].(string))
+	assert.NoError(t, err)
+	assert.NotContains(t, payload, "milestone")
+	assert.NotContains(t, payload, "count")
+
+	// The signature is the hex HMAC-SHA256 of the exact body
+	signature := req.header.Get(WebhookSignatureHeader)
+	assert.Equal(t, SignWebhookPayload("s3cret", req.body), signature)
+	assert.True(t, hmac.Equal([]byte(signature), []byte(SignWebhookPayload("s3cret", req.body))))
+	assert.NotEqual(t, SignWebhookPayload("other", req.body), signature)
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Known answer computed with: printf '{"a":1}' | openssl dgst -sha256 -hmac key
+	assert.Equal(t,
+		"sha256=88a67f24bbcdaed0e6c997404bb79a743baf44c6bab2f4c27328e3009d22e342",
+		SignWebhookPayload("key", []byte(`{"a":1}`)))
+}
+
+func TestWebhookNotifier_UnsignedWithoutSecret(t *testing.T) {
+	srv, requests := newCaptureServer(t, func(int) int { return http.StatusOK })
+
+	n := NewWebhookNotifierWithClient(srv.Client(), WebhookConfig{URL: srv.URL}, nil)
+	n.Notify(LinkEvent{Type: EventURLsExpired, Count: 3})
+	closeNotifier(t, n)
+
+	got := requests()
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].header.Get(WebhookSignatureHeader))
+
+	var event LinkEvent
+	require.NoError(t, json.Unmarshal(got[0].body, &event))
+	assert.Equal(t, int64(3), event.Count)
+}
+
+func TestWebhookNotifier_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   func(attempt int) int
+		attempts int
+	}{
+		{"server error then success", func(attempt int) int {
+			if attempt < 3 {
+				return http.StatusInternalServerError
+			}
+			return http.StatusOK
+		}, 3},
+		{"rate limited then success", func(attempt int) int {
+			if attempt == 1 {
+				return http.StatusTooManyRequests
+			}
+			return http.StatusOK
+		}, 2},
+		{"gives up after max retries", func(int) int { return http.StatusBadGateway }, 3},
+		{"client error is not retried", func(int) int { return http.StatusBadRequest }, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newCaptureServer(t, tt.status)
+
+			n := NewWebhookNotifierWithClient(srv.Client(), WebhookConfig{
+				URL:          srv.URL,
+				MaxRetries:   2,
+				RetryBackoff: time.Millisecond,
+			}, nil)
+			n.Notify(LinkEvent{Type: EventURLCreated, ShortCode: "abc1234"})
+			closeNotifier(t, n)
+
+			got := requests()
+			require.Len(t, got, tt.attempts)
+			// Every attempt carries the same delivery ID
+			for _, req := range got {
+				assert.Equal(t, got[0].header.Get(WebhookDeliveryHeader), req.header.Get(WebhookDeliveryHeader))
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier_NotifyDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		delivered.Add(1)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifierWithClient(srv.Client(), WebhookConfig{URL: srv.URL, QueueSize: 2}, nil)
+
+	start := time.Now()
+	for range 10 {
+		n.Notify(LinkEvent{Type: EventURLCreated})
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	close(release)
+	closeNotifier(t, n)
+	// One in flight plus a full queue; the rest were dropped
+	assert.LessOrEqual(t, delivered.Load(), int32(3))
+	assert.GreaterOrEqual(t, delivered.Load(), int32(2))
+
+	// Events after Close are dropped
+	n.Notify(LinkEvent{Type: EventURLCreated})
+}
+
+// recordingNotifier collects the events it is sent.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []LinkEvent
+}
+
+func (n *recordingNotifier) Notify(event LinkEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+}
+
+func (n *recordingNotifier) Events() []LinkEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]LinkEvent(nil), n.events...)
+}
@@ -0,0 +1,78 @@
+// Package tracing configures OpenTelemetry distributed tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/emadnahed/FastGoLink/internal/config"
+)
+
+// Span attribute keys shared across the request path.
+const (
+	ShortCodeKey = attribute.Key("url.short_code")
+	CacheHitKey  = attribute.Key("cache.hit")
+)
+
+// ShutdownFunc flushes pending spans and releases exporter resources.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs a global tracer provider exporting to the configured OTLP endpoint
+// and a W3C trace context propagator. When tracing is disabled the global no-op
+// provider is left in place, so instrumented code records nothing.
+func Setup(ctx context.Context, cfg config.TracingConfig) (ShutdownFunc, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider := newProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// newProvider creates a tracer provider with the service resource and sampler.
+// Child spans follow their parent's sampling decision, so traces started
+// upstream are kept intact.
+func newProvider(cfg config.TracingConfig, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))
+
+	opts = append(opts,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// End records err on the span, if any, and ends it.
+// Use it with a named error return: defer func() { tracing.End(span, err) }().
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/emadnahed/FastGoLink/internal/config"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	before := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Enabled: false})
+	require.NoError(t, err)
+	require.NotNil(t, shutdown)
+
+	assert.Equal(t, before, otel.GetTracerProvider(), "disabled tracing should keep the no-op provider")
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestNewProvider_Sampling(t *testing.T) {
+	t.Run("samples all traces at ratio 1", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := newProvider(config.TracingConfig{ServiceName: "test", SampleRatio: 1}, sdktrace.WithSpanProcessor(recorder))
+		defer provider.Shutdown(context.Background())
+
+		_, span := provider.Tracer("test").Start(context.Background(), "op")
+		span.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "op", spans[0].Name())
+
+		var serviceName string
+		for _, attr := range spans[0].Resource().Attributes() {
+			if attr.Key == "service.name" {
+				serviceName = attr.Value.AsString()
+			}
+		}
+		assert.Equal(t, "test", serviceName)
+	})
+
+	t.Run("drops all traces at ratio 0", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := newProvider(config.TracingConfig{ServiceName: "test", SampleRatio: 0}, sdktrace.WithSpanProcessor(recorder))
+		defer provider.Shutdown(context.Background())
+
+		_, span := provider.Tracer("test").Start(context.Background(), "op")
+		span.End()
+
+		assert.Empty(t, recorder.Ended())
+	})
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("boom"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Empty(t, spans[0].Events())
+
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "boom", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+}
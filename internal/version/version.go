@@ -0,0 +1,57 @@
+// Package version reports which build of the service is running.
+//
+// Version, Commit and BuildTime are set at build time with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/emadnahed/FastGoLink/internal/version.Version=v1.2.0 \
+//	  -X github.com/emadnahed/FastGoLink/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/emadnahed/FastGoLink/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata injected with -ldflags -X.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes a build.
+type Info struct {
+	Version   string
+	Commit    string
+	BuildTime string
+	GoVersion string
+}
+
+// Get returns the running build's metadata. A commit or build time that was
+// not injected is taken from the VCS stamp Go embeds when building from a
+// repository, and is "unknown" when that is missing too.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
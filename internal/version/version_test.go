@@ -0,0 +1,29 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	t.Run("injected values win", func(t *testing.T) {
+		defer func(v, c, b string) { Version, Commit, BuildTime = v, c, b }(Version, Commit, BuildTime)
+		Version, Commit, BuildTime = "v1.2.0", "abc1234", "2026-10-01T12:00:00Z"
+
+		assert.Equal(t, Info{
+			Version:   "v1.2.0",
+			Commit:    "abc1234",
+			BuildTime: "2026-10-01T12:00:00Z",
+			GoVersion: runtime.Version(),
+		}, Get())
+	})
+
+	t.Run("never empty", func(t *testing.T) {
+		info := Get()
+		assert.Equal(t, "dev", info.Version)
+		assert.NotEmpty(t, info.Commit)
+		assert.NotEmpty(t, info.BuildTime)
+	})
+}
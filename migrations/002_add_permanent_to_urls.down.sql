@@ -0,0 +1,2 @@
+-- Drop the permanent redirect flag
+ALTER TABLE urls DROP COLUMN IF EXISTS permanent;
@@ -0,0 +1,2 @@
+-- Add permanent flag to choose between 301 and 302 redirects
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS permanent BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Drop the link password hash
+ALTER TABLE urls DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,2 @@
+-- Add optional bcrypt password hash for password-protected links
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS password_hash TEXT;
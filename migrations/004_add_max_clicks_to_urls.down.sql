@@ -0,0 +1,2 @@
+-- Drop the link click limit
+ALTER TABLE urls DROP COLUMN IF EXISTS max_clicks;
@@ -0,0 +1,2 @@
+-- Add optional click limit for single-use and limited-use links
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT CHECK (max_clicks > 0);
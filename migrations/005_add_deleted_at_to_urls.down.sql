@@ -0,0 +1,2 @@
+-- Drop the soft-delete timestamp
+ALTER TABLE urls DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,2 @@
+-- Add soft-delete timestamp so deleted URLs can be restored
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Drop click_buckets table
+DROP TABLE IF EXISTS click_buckets;
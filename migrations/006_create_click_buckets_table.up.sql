@@ -0,0 +1,7 @@
+-- Create click_buckets table for time-series click analytics
+CREATE TABLE IF NOT EXISTS click_buckets (
+    short_code VARCHAR(10) NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    click_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, bucket_start)
+);
@@ -0,0 +1,3 @@
+-- Drop click source tables
+DROP TABLE IF EXISTS click_agents;
+DROP TABLE IF EXISTS click_referrers;
@@ -0,0 +1,15 @@
+-- Create tables counting clicks per referrer host and per browser/OS
+CREATE TABLE IF NOT EXISTS click_referrers (
+    short_code VARCHAR(10) NOT NULL,
+    referrer VARCHAR(253) NOT NULL,
+    click_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, referrer)
+);
+
+CREATE TABLE IF NOT EXISTS click_agents (
+    short_code VARCHAR(10) NOT NULL,
+    browser VARCHAR(64) NOT NULL,
+    os VARCHAR(64) NOT NULL,
+    click_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, browser, os)
+);
@@ -0,0 +1,2 @@
+-- Drop click country table
+DROP TABLE IF EXISTS click_countries;
@@ -0,0 +1,7 @@
+-- Create table counting clicks per client country
+CREATE TABLE IF NOT EXISTS click_countries (
+    short_code VARCHAR(10) NOT NULL,
+    country CHAR(2) NOT NULL,
+    click_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, country)
+);
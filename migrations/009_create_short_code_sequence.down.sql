@@ -0,0 +1,2 @@
+-- Drop sequential short code sequence
+DROP SEQUENCE IF EXISTS short_code_seq;
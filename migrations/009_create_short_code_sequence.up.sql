@@ -0,0 +1,2 @@
+-- Create sequence sequential short codes are drawn from
+CREATE SEQUENCE IF NOT EXISTS short_code_seq;
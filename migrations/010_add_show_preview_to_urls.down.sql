@@ -0,0 +1,2 @@
+-- Drop the interstitial preview flag
+ALTER TABLE urls DROP COLUMN IF EXISTS show_preview;
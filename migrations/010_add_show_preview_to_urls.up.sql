@@ -0,0 +1,2 @@
+-- Add flag to show an interstitial preview page before redirecting
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS show_preview BOOLEAN NOT NULL DEFAULT FALSE;
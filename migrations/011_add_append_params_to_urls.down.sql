@@ -0,0 +1,2 @@
+-- Drop the appended query parameters
+ALTER TABLE urls DROP COLUMN IF EXISTS append_params;
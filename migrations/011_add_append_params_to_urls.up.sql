@@ -0,0 +1,2 @@
+-- Add query parameters (e.g. UTM tags) appended to the destination on redirect
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS append_params JSONB;
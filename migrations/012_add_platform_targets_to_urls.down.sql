@@ -0,0 +1,2 @@
+-- Drop the per-platform destinations
+ALTER TABLE urls DROP COLUMN IF EXISTS platform_targets;
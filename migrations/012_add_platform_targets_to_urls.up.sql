@@ -0,0 +1,2 @@
+-- Add per-platform destinations, e.g. app store links for iOS and Android
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS platform_targets JSONB;
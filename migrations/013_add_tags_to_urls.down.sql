@@ -0,0 +1,3 @@
+-- Drop the link labels
+DROP INDEX IF EXISTS idx_urls_tags;
+ALTER TABLE urls DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,3 @@
+-- Add labels for grouping links, e.g. by campaign
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS tags TEXT[];
+CREATE INDEX IF NOT EXISTS idx_urls_tags ON urls USING GIN (tags);
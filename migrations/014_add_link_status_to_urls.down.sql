@@ -0,0 +1,4 @@
+-- Drop the destination check results
+DROP INDEX IF EXISTS idx_urls_link_checked_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS link_checked_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS link_status;
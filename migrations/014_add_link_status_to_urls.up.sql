@@ -0,0 +1,4 @@
+-- Record the result of the last destination check
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS link_status SMALLINT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS link_checked_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_urls_link_checked_at ON urls (link_checked_at NULLS FIRST) WHERE deleted_at IS NULL;
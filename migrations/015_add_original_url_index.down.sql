@@ -0,0 +1,2 @@
+-- Drop the destination lookup index
+DROP INDEX IF EXISTS idx_urls_original_url;
@@ -0,0 +1,3 @@
+-- Look up short codes by destination for DEDUPE_URLS. A hash index has no
+-- length limit, unlike a B-tree over long URLs
+CREATE INDEX IF NOT EXISTS idx_urls_original_url ON urls USING hash (original_url) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Drop the active flag
+ALTER TABLE urls DROP COLUMN IF EXISTS active;
@@ -0,0 +1,2 @@
+-- Allow pausing a link's redirect without deleting it
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
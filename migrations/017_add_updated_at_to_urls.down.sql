@@ -0,0 +1,2 @@
+-- Drop the last-modified timestamp
+ALTER TABLE urls DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,2 @@
+-- Track when a URL last changed, for conditional GET support
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
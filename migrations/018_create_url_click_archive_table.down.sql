@@ -0,0 +1,2 @@
+-- Drop url_click_archive table
+DROP TABLE IF EXISTS url_click_archive;
@@ -0,0 +1,6 @@
+-- Create url_click_archive table for click totals moved out of urls.click_count
+CREATE TABLE IF NOT EXISTS url_click_archive (
+    short_code VARCHAR(10) PRIMARY KEY REFERENCES urls(short_code) ON DELETE CASCADE,
+    click_count BIGINT NOT NULL DEFAULT 0,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
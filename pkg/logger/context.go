@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"io"
+)
+
+// contextKey is the type for context keys used by the logger.
+type contextKey string
+
+const (
+	// RequestIDKey is the context key the request ID is stored under. The
+	// RequestID middleware sets it; log lines from FromContext include it.
+	RequestIDKey contextKey = "request_id"
+
+	loggerKey contextKey = "logger"
+)
+
+// discard is returned by FromContext when no logger was stored.
+var discard = New(io.Discard, "error")
+
+// NewContext returns a copy of ctx carrying l, for FromContext to retrieve.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, with the
+// request ID from ctx as a request_id field. Without a stored logger, log
+// lines are discarded, so code called outside a request can log freely.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerKey).(*Logger)
+	if !ok || l == nil {
+		l = discard
+	}
+	return l.WithContext(ctx)
+}
+
+// WithContext returns a child logger with the request ID from ctx as a
+// request_id field, or l itself when ctx has no request ID.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if id, ok := ctx.Value(RequestIDKey).(string); ok && id != "" {
+		return l.With("request_id", id)
+	}
+	return l
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("adds request ID to stored logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := NewContext(context.Background(), New(&buf, "info").With("service", "api"))
+		ctx = context.WithValue(ctx, RequestIDKey, "req-123")
+
+		FromContext(ctx).Info("handled", "key", "value")
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "req-123", entry["request_id"])
+		assert.Equal(t, "api", entry["service"])
+		assert.Equal(t, "value", entry["key"])
+	})
+
+	t.Run("omits missing request ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := NewContext(context.Background(), New(&buf, "info"))
+
+		FromContext(ctx).Info("handled")
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.NotContains(t, entry, "request_id")
+	})
+
+	t.Run("discards without stored logger", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), RequestIDKey, "req-123")
+
+		log := FromContext(ctx)
+		require.NotNil(t, log)
+		assert.NotPanics(t, func() { log.Error("nowhere") })
+	})
+}
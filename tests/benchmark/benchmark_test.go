@@ -18,6 +18,7 @@ import (
 	"github.com/emadnahed/FastGoLink/internal/handlers"
 	"github.com/emadnahed/FastGoLink/internal/idgen"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/server"
 	"github.com/emadnahed/FastGoLink/internal/services"
 	"github.com/emadnahed/FastGoLink/pkg/logger"
@@ -25,14 +26,16 @@ import (
 
 // InMemoryURLRepository implements repository.URLRepository for benchmarking.
 type InMemoryURLRepository struct {
-	mu   sync.RWMutex
-	urls map[string]*models.URL
-	seq  int64
+	mu      sync.RWMutex
+	urls    map[string]*models.URL
+	deleted map[string]*models.URL // soft-deleted URLs, restorable
+	seq     int64
 }
 
 func NewInMemoryURLRepository() *InMemoryURLRepository {
 	return &InMemoryURLRepository{
-		urls: make(map[string]*models.URL),
+		urls:    make(map[string]*models.URL),
+		deleted: make(map[string]*models.URL),
 	}
 }
 
@@ -44,7 +47,7 @@ func (r *InMemoryURLRepository) Create(ctx context.Context, create *models.URLCr
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.urls[create.ShortCode]; exists {
+	if r.existsLocked(create.ShortCode) {
 		return nil, errors.New("duplicate short code")
 	}
 
@@ -56,11 +59,29 @@ func (r *InMemoryURLRepository) Create(ctx context.Context, create *models.URLCr
 		CreatedAt:   time.Now(),
 		ExpiresAt:   create.ExpiresAt,
 		ClickCount:  0,
+		MaxClicks:   create.MaxClicks,
 	}
 	r.urls[create.ShortCode] = url
 	return url, nil
 }
 
+func (r *InMemoryURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	for _, create := range creates {
+		if err := create.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	urls := make([]*models.URL, len(creates))
+	for i, create := range creates {
+		url, err := r.Create(ctx, create)
+		if err == nil {
+			urls[i] = url
+		}
+	}
+	return urls, nil
+}
+
 func (r *InMemoryURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -72,6 +93,19 @@ func (r *InMemoryURLRepository) GetByShortCode(ctx context.Context, shortCode st
 	return url, nil
 }
 
+func (r *InMemoryURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var urls []*models.URL
+	for _, shortCode := range shortCodes {
+		if url, exists := r.urls[shortCode]; exists {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
 func (r *InMemoryURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -84,17 +118,117 @@ func (r *InMemoryURLRepository) GetByID(ctx context.Context, id int64) (*models.
 	return nil, models.ErrURLNotFound
 }
 
+func (r *InMemoryURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var oldest *models.URL
+	for _, url := range r.urls {
+		plain := url.ExpiresAt == nil && url.PasswordHash == "" && url.MaxClicks == nil &&
+			!url.Permanent && !url.ShowPreview && !url.Disabled &&
+			len(url.AppendParams) == 0 && len(url.PlatformTargets) == 0 && len(url.Tags) == 0
+		if url.OriginalURL == originalURL && plain && (oldest == nil || url.ID < oldest.ID) {
+			oldest = url
+		}
+	}
+	if oldest == nil {
+		return nil, models.ErrURLNotFound
+	}
+	return oldest, nil
+}
+
 func (r *InMemoryURLRepository) Delete(ctx context.Context, shortCode string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.urls[shortCode]; !exists {
+	url, exists := r.urls[shortCode]
+	if !exists {
 		return models.ErrURLNotFound
 	}
 	delete(r.urls, shortCode)
+	r.deleted[shortCode] = url
 	return nil
 }
 
+func (r *InMemoryURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted []string
+	for _, shortCode := range shortCodes {
+		url, exists := r.urls[shortCode]
+		if !exists {
+			continue
+		}
+		delete(r.urls, shortCode)
+		r.deleted[shortCode] = url
+		deleted = append(deleted, shortCode)
+	}
+	return deleted, nil
+}
+
+func (r *InMemoryURLRepository) Restore(ctx context.Context, shortCode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.deleted[shortCode]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	delete(r.deleted, shortCode)
+	r.urls[shortCode] = url
+	return nil
+}
+
+func (r *InMemoryURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.existsLocked(shortCode) {
+		return models.ErrURLNotFound
+	}
+	delete(r.urls, shortCode)
+	delete(r.deleted, shortCode)
+	return nil
+}
+
+func (r *InMemoryURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	url.OriginalURL = newURL
+	return nil
+}
+
+func (r *InMemoryURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return nil, models.ErrURLNotFound
+	}
+	url.ExpiresAt = &expiresAt
+	return url, nil
+}
+
+func (r *InMemoryURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return nil, models.ErrURLNotFound
+	}
+	url.Disabled = !active
+	copied := *url
+	return &copied, nil
+}
+
 func (r *InMemoryURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -107,39 +241,140 @@ func (r *InMemoryURLRepository) IncrementClickCount(ctx context.Context, shortCo
 	return nil
 }
 
-func (r *InMemoryURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error {
+func (r *InMemoryURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return 0, models.ErrURLNotFound
+	}
+	if url.MaxClicks != nil && url.ClickCount >= *url.MaxClicks {
+		return 0, models.ErrURLExhausted
+	}
+	url.ClickCount++
+	return url.ClickCount, nil
+}
+
+func (r *InMemoryURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	totals := make(map[string]int64, len(counts))
 	for shortCode, count := range counts {
 		if url, exists := r.urls[shortCode]; exists {
 			url.ClickCount += count
+			totals[shortCode] = url.ClickCount
 		}
 	}
-	return nil
+	return totals, nil
 }
 
-func (r *InMemoryURLRepository) DeleteExpired(ctx context.Context) (int64, error) {
+func (r *InMemoryURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	var count int64
+	var deleted []string
+	now := time.Now()
+	for _, urls := range []map[string]*models.URL{r.urls, r.deleted} {
+		for code, url := range urls {
+			if url.ExpiresAt != nil && url.ExpiresAt.Before(now) {
+				delete(urls, code)
+				deleted = append(deleted, code)
+			}
+		}
+	}
+	return deleted, nil
+}
+
+func (r *InMemoryURLRepository) List(ctx context.Context, limit, offset int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	now := time.Now()
-	for code, url := range r.urls {
-		if url.ExpiresAt != nil && url.ExpiresAt.Before(now) {
-			delete(r.urls, code)
-			count++
+	matches := make([]*models.URL, 0, len(r.urls))
+	for _, url := range r.urls {
+		if filter.Matches(url, now) {
+			copied := *url
+			matches = append(matches, &copied)
 		}
 	}
-	return count, nil
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID > matches[j].ID
+	})
+
+	total := int64(len(matches))
+	if offset >= len(matches) {
+		return []*models.URL{}, total, nil
+	}
+	end := offset + limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[offset:end], total, nil
+}
+
+func (r *InMemoryURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	urls := make([]*models.URL, 0, len(r.urls))
+	for _, url := range r.urls {
+		if url.ID > afterID && filter.Matches(url, now) {
+			copied := *url
+			urls = append(urls, &copied)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].ID < urls[j].ID
+	})
+
+	if len(urls) <= limit {
+		return urls, 0, nil
+	}
+	urls = urls[:limit]
+	return urls, urls[limit-1].ID, nil
+}
+
+func (r *InMemoryURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	urls := make([]*models.URL, 0, len(r.urls))
+	for _, url := range r.urls {
+		copied := *url
+		urls = append(urls, &copied)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if urls[i].ClickCount != urls[j].ClickCount {
+			return urls[i].ClickCount > urls[j].ClickCount
+		}
+		return urls[i].ID < urls[j].ID
+	})
+
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
 }
 
 func (r *InMemoryURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	_, exists := r.urls[shortCode]
-	return exists, nil
+	return r.existsLocked(shortCode), nil
+}
+
+// existsLocked reports whether shortCode is taken, including by a
+// soft-deleted URL. The caller must hold r.mu.
+func (r *InMemoryURLRepository) existsLocked(shortCode string) bool {
+	_, live := r.urls[shortCode]
+	_, deleted := r.deleted[shortCode]
+	return live || deleted
 }
 
 func (r *InMemoryURLRepository) HealthCheck(ctx context.Context) error {
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/emadnahed/FastGoLink/internal/config"
 	"github.com/emadnahed/FastGoLink/internal/handlers"
 	"github.com/emadnahed/FastGoLink/internal/idgen"
+	"github.com/emadnahed/FastGoLink/internal/models"
 	"github.com/emadnahed/FastGoLink/internal/server"
 	"github.com/emadnahed/FastGoLink/internal/services"
 	"github.com/emadnahed/FastGoLink/pkg/logger"
@@ -127,6 +131,437 @@ func TestE2E_ClickCounterBatching(t *testing.T) {
 	_ = srv
 }
 
+func TestE2E_AnalyticsTimeSeries(t *testing.T) {
+	_, baseURL, clickCounter, cleanup := testServerWithAnalytics(t)
+	defer cleanup()
+
+	body := map[string]string{"url": "https://example.com/timeseries-test"}
+	resp := httpPost(t, baseURL+"/api/v1/shorten", body)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var createResp map[string]interface{}
+	err := json.NewDecoder(resp.Body).Decode(&createResp)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	shortCode := createResp["short_code"].(string)
+
+	for i := 0; i < 3; i++ {
+		resp := httpGetNoRedirect(t, baseURL+"/"+shortCode)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+	}
+
+	// Stop flushes the buckets synchronously
+	time.Sleep(50 * time.Millisecond)
+	clickCounter.Stop()
+
+	t.Run("hourly series covers the last 24 hours", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/timeseries?interval=hour")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var points []models.ClickBucket
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&points))
+
+		// 24 full hours plus the current, partial one
+		require.Len(t, points, 25)
+		var total int64
+		for i, p := range points {
+			assert.Equal(t, p.Timestamp.Truncate(time.Hour), p.Timestamp, "points start on the hour")
+			if i > 0 {
+				assert.Equal(t, time.Hour, p.Timestamp.Sub(points[i-1].Timestamp))
+			}
+			total += p.Count
+		}
+		assert.Equal(t, int64(3), total)
+	})
+
+	t.Run("daily series", func(t *testing.T) {
+		now := time.Now().UTC()
+		from := now.Add(-48 * time.Hour).Format(time.RFC3339)
+		to := now.Add(time.Hour).Format(time.RFC3339)
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/timeseries?from="+from+"&to="+to)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var points []models.ClickBucket
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&points))
+		require.NotEmpty(t, points)
+		var total int64
+		for _, p := range points {
+			assert.Equal(t, 0, p.Timestamp.Hour(), "days start at midnight UTC")
+			total += p.Count
+		}
+		assert.Equal(t, int64(3), total)
+	})
+
+	t.Run("invalid interval returns 400", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/timeseries?interval=week")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("unknown URL returns 404", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/nonexistent/timeseries")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestE2E_AnalyticsSources(t *testing.T) {
+	_, baseURL, clickCounter, cleanup := testServerWithAnalytics(t)
+	defer cleanup()
+
+	body := map[string]string{"url": "https://example.com/sources-test"}
+	resp := httpPost(t, baseURL+"/api/v1/shorten", body)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var createResp map[string]interface{}
+	err := json.NewDecoder(resp.Body).Decode(&createResp)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	shortCode := createResp["short_code"].(string)
+
+	const (
+		chromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+		firefoxLinux  = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+	)
+	clicks := []struct{ referer, userAgent string }{
+		{"https://www.twitter.com/someone/status/1", chromeWindows},
+		{"https://twitter.com/other", chromeWindows},
+		{"", firefoxLinux},
+	}
+	for _, c := range clicks {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, baseURL+"/"+shortCode, nil)
+		require.NoError(t, err)
+		if c.referer != "" {
+			req.Header.Set("Referer", c.referer)
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+		resp, err := noRedirectClient().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+	}
+
+	// Stop flushes the sources synchronously
+	time.Sleep(50 * time.Millisecond)
+	clickCounter.Stop()
+
+	t.Run("referrers", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/referrers")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var referrers []models.ReferrerCount
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&referrers))
+		assert.Equal(t, []models.ReferrerCount{
+			{Referrer: "twitter.com", Count: 2},
+			{Referrer: analytics.ReferrerDirect, Count: 1},
+		}, referrers)
+	})
+
+	t.Run("agents", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/agents?limit=1")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var agents []models.AgentCount
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&agents))
+		assert.Equal(t, []models.AgentCount{{Browser: "Chrome", OS: "Windows", Count: 2}}, agents)
+	})
+
+	t.Run("countries", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/geo")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var countries []models.CountryCount
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&countries))
+		assert.Equal(t, []models.CountryCount{{Country: "NZ", Count: 3}}, countries)
+	})
+
+	t.Run("invalid limit returns 400", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/"+shortCode+"/agents?limit=500")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("unknown URL returns 404", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/nonexistent/referrers")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestE2E_AnalyticsBatch(t *testing.T) {
+	_, baseURL, _, cleanup := testServerWithAnalytics(t)
+	defer cleanup()
+
+	var codes []string
+	for _, path := range []string{"batch-a", "batch-b"} {
+		resp := httpPost(t, baseURL+"/api/v1/shorten", map[string]string{"url": "https://example.com/" + path})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var createResp map[string]interface{}
+		err := json.NewDecoder(resp.Body).Decode(&createResp)
+		resp.Body.Close()
+		require.NoError(t, err)
+		codes = append(codes, createResp["short_code"].(string))
+	}
+
+	resp, err := noRedirectClient().Get(baseURL + "/" + codes[1])
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	// The click counts once recorded, flushed or still pending
+	var batchResp handlers.BatchStatsResponse
+	require.Eventually(t, func() bool {
+		resp := httpPost(t, baseURL+"/api/v1/analytics/batch", []string{codes[0], "nonexistent", codes[1]})
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusMultiStatus {
+			return false
+		}
+		batchResp = handlers.BatchStatsResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+			return false
+		}
+		stats := batchResp.Results[2].Stats
+		return stats != nil && stats.ClickCount+stats.PendingCount == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, 2, batchResp.Succeeded)
+	assert.Equal(t, 1, batchResp.Failed)
+	require.NotNil(t, batchResp.Results[0].Stats)
+	assert.Equal(t, codes[0], batchResp.Results[0].Stats.ShortCode)
+	assert.Zero(t, batchResp.Results[0].Stats.ClickCount)
+	assert.Equal(t, http.StatusNotFound, batchResp.Results[1].Status)
+	assert.Equal(t, "NOT_FOUND", batchResp.Results[1].Error.Code)
+}
+
+func TestE2E_AnalyticsTopURLs(t *testing.T) {
+	_, baseURL, clickCounter, cleanup := testServerWithAnalytics(t)
+	defer cleanup()
+
+	// Create URLs in order and click them 1, 3 and 2 times
+	clicks := []int{1, 3, 2}
+	var codes []string
+	for i, n := range clicks {
+		body := map[string]string{"url": "https://example.com/top-" + string(rune('a'+i))}
+		resp := httpPost(t, baseURL+"/api/v1/shorten", body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var createResp map[string]interface{}
+		err := json.NewDecoder(resp.Body).Decode(&createResp)
+		resp.Body.Close()
+		require.NoError(t, err)
+		code := createResp["short_code"].(string)
+		codes = append(codes, code)
+
+		for j := 0; j < n; j++ {
+			resp, err := noRedirectClient().Get(baseURL + "/" + code)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusFound, resp.StatusCode)
+		}
+		// Keep creation times distinct for the created order
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Stop flushes the click counts synchronously
+	time.Sleep(50 * time.Millisecond)
+	clickCounter.Stop()
+
+	getTop := func(t *testing.T, query string) []services.TopURL {
+		t.Helper()
+		resp := httpGet(t, baseURL+"/api/v1/analytics/top"+query)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var top []services.TopURL
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&top))
+		return top
+	}
+
+	t.Run("by clicks", func(t *testing.T) {
+		top := getTop(t, "?limit=2")
+
+		require.Len(t, top, 2)
+		assert.Equal(t, codes[1], top[0].ShortCode)
+		assert.Equal(t, int64(3), top[0].ClickCount)
+		assert.Equal(t, codes[2], top[1].ShortCode)
+		assert.Equal(t, int64(2), top[1].ClickCount)
+	})
+
+	t.Run("by creation date", func(t *testing.T) {
+		top := getTop(t, "?by=created")
+
+		require.Len(t, top, 3)
+		assert.Equal(t, []string{codes[2], codes[1], codes[0]},
+			[]string{top[0].ShortCode, top[1].ShortCode, top[2].ShortCode})
+	})
+
+	t.Run("unknown order returns 400", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/analytics/top?by=views")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+// InMemoryClickBucketRepository implements repository.ClickBucketRepository for testing.
+type InMemoryClickBucketRepository struct {
+	mu      sync.Mutex
+	buckets map[analytics.BucketKey]int64
+}
+
+func NewInMemoryClickBucketRepository() *InMemoryClickBucketRepository {
+	return &InMemoryClickBucketRepository{
+		buckets: make(map[analytics.BucketKey]int64),
+	}
+}
+
+func (r *InMemoryClickBucketRepository) IncrementClickBuckets(ctx context.Context, buckets map[analytics.BucketKey]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, count := range buckets {
+		r.buckets[key] += count
+	}
+	return nil
+}
+
+func (r *InMemoryClickBucketRepository) GetClickBuckets(ctx context.Context, shortCode string, from, to time.Time, interval models.Interval) ([]models.ClickBucket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sums := make(map[time.Time]int64)
+	for key, count := range r.buckets {
+		if key.ShortCode == shortCode && !key.Start.Before(from) && key.Start.Before(to) {
+			sums[interval.Truncate(key.Start)] += count
+		}
+	}
+
+	buckets := make([]models.ClickBucket, 0, len(sums))
+	for ts, count := range sums {
+		buckets = append(buckets, models.ClickBucket{Timestamp: ts, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Timestamp.Before(buckets[j].Timestamp) })
+	return buckets, nil
+}
+
+// InMemoryClickSourceRepository implements repository.ClickSourceRepository for testing.
+type InMemoryClickSourceRepository struct {
+	mu        sync.Mutex
+	referrers map[analytics.ReferrerKey]int64
+	agents    map[analytics.AgentKey]int64
+}
+
+func NewInMemoryClickSourceRepository() *InMemoryClickSourceRepository {
+	return &InMemoryClickSourceRepository{
+		referrers: make(map[analytics.ReferrerKey]int64),
+		agents:    make(map[analytics.AgentKey]int64),
+	}
+}
+
+func (r *InMemoryClickSourceRepository) IncrementClickSources(ctx context.Context, referrers map[analytics.ReferrerKey]int64, agents map[analytics.AgentKey]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, count := range referrers {
+		r.referrers[key] += count
+	}
+	for key, count := range agents {
+		r.agents[key] += count
+	}
+	return nil
+}
+
+func (r *InMemoryClickSourceRepository) GetReferrers(ctx context.Context, shortCode string, limit int) ([]models.ReferrerCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := []models.ReferrerCount{}
+	for key, count := range r.referrers {
+		if key.ShortCode == shortCode {
+			result = append(result, models.ReferrerCount{Referrer: key.Referrer, Count: count})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *InMemoryClickSourceRepository) GetAgents(ctx context.Context, shortCode string, limit int) ([]models.AgentCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := []models.AgentCount{}
+	for key, count := range r.agents {
+		if key.ShortCode == shortCode {
+			result = append(result, models.AgentCount{Browser: key.Browser, OS: key.OS, Count: count})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// InMemoryClickCountryRepository implements repository.ClickCountryRepository for testing.
+type InMemoryClickCountryRepository struct {
+	mu        sync.Mutex
+	countries map[analytics.CountryKey]int64
+}
+
+func NewInMemoryClickCountryRepository() *InMemoryClickCountryRepository {
+	return &InMemoryClickCountryRepository{
+		countries: make(map[analytics.CountryKey]int64),
+	}
+}
+
+func (r *InMemoryClickCountryRepository) IncrementClickCountries(ctx context.Context, countries map[analytics.CountryKey]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, count := range countries {
+		r.countries[key] += count
+	}
+	return nil
+}
+
+func (r *InMemoryClickCountryRepository) GetCountries(ctx context.Context, shortCode string, limit int) ([]models.CountryCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := []models.CountryCount{}
+	for key, count := range r.countries {
+		if key.ShortCode == shortCode {
+			result = append(result, models.CountryCount{Country: key.Country, Count: count})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// loopbackResolver places loopback clients in New Zealand.
+type loopbackResolver struct{}
+
+func (loopbackResolver) Country(ip net.IP) (string, error) {
+	if ip.IsLoopback() {
+		return "NZ", nil
+	}
+	return "", nil
+}
+
 // testServerWithAnalytics creates a test server with analytics configured.
 func testServerWithAnalytics(t *testing.T) (*server.Server, string, *analytics.ClickCounter, func()) {
 	t.Helper()
@@ -166,7 +601,10 @@ func testServerWithAnalytics(t *testing.T) (*server.Server, string, *analytics.C
 	srv.SetURLHandler(urlHandler)
 
 	// Set up analytics
-	flusher := analytics.NewRepositoryFlusher(repo, log)
+	bucketRepo := NewInMemoryClickBucketRepository()
+	sourceRepo := NewInMemoryClickSourceRepository()
+	countryRepo := NewInMemoryClickCountryRepository()
+	flusher := analytics.NewRepositoryFlusherWithGeo(repo, bucketRepo, sourceRepo, countryRepo, log)
 	clickCounter := analytics.NewClickCounter(analytics.Config{
 		FlushInterval: 100 * time.Millisecond, // Short interval for testing
 		BatchSize:     100,
@@ -174,12 +612,12 @@ func testServerWithAnalytics(t *testing.T) (*server.Server, string, *analytics.C
 	}, flusher)
 
 	// Set up redirect with analytics
-	redirectService := services.NewRedirectServiceWithAnalytics(repo, clickCounter)
+	redirectService := services.NewRedirectServiceWithGeo(repo, clickCounter, loopbackResolver{})
 	redirectHandler := handlers.NewRedirectHandler(redirectService)
 	srv.SetRedirectHandler(redirectHandler)
 
 	// Set up analytics endpoint
-	analyticsService := services.NewAnalyticsServiceWithPendingStats(repo, clickCounter)
+	analyticsService := services.NewAnalyticsServiceWithGeo(repo, clickCounter, bucketRepo, sourceRepo, countryRepo)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 	srv.SetAnalyticsHandler(analyticsHandler)
 
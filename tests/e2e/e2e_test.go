@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -149,9 +151,13 @@ func TestE2E_ReadyEndpoint(t *testing.T) {
 	})
 
 	t.Run("ready endpoint reflects dependency health", func(t *testing.T) {
-		dbHealthy := true
-		srv.HealthHandler().AddCheck("database", func() bool {
-			return dbHealthy
+		var dbHealthy atomic.Bool
+		dbHealthy.Store(true)
+		srv.HealthHandler().AddCheck("database", func(context.Context) error {
+			if !dbHealthy.Load() {
+				return errors.New("database unreachable")
+			}
+			return nil
 		})
 
 		// Should be ready when dependency is healthy
@@ -160,7 +166,7 @@ func TestE2E_ReadyEndpoint(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
 		// Should be not ready when dependency fails
-		dbHealthy = false
+		dbHealthy.Store(false)
 		resp = httpGet(t, baseURL+"/ready")
 		defer resp.Body.Close()
 		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
@@ -170,7 +176,8 @@ func TestE2E_ReadyEndpoint(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "not ready", ready.Status)
-		assert.Equal(t, "fail", ready.Checks["database"])
+		assert.Equal(t, "fail", ready.Checks["database"].Status)
+		assert.Equal(t, "database unreachable", ready.Checks["database"].Error)
 	})
 }
 
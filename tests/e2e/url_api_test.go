@@ -4,9 +4,14 @@ package e2e
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -18,6 +23,7 @@ import (
 	"github.com/emadnahed/FastGoLink/internal/handlers"
 	"github.com/emadnahed/FastGoLink/internal/idgen"
 	"github.com/emadnahed/FastGoLink/internal/models"
+	"github.com/emadnahed/FastGoLink/internal/repository"
 	"github.com/emadnahed/FastGoLink/internal/server"
 	"github.com/emadnahed/FastGoLink/internal/services"
 	"github.com/emadnahed/FastGoLink/pkg/logger"
@@ -25,14 +31,16 @@ import (
 
 // InMemoryURLRepository implements repository.URLRepository for testing.
 type InMemoryURLRepository struct {
-	mu   sync.RWMutex
-	urls map[string]*models.URL
-	seq  int64
+	mu      sync.RWMutex
+	urls    map[string]*models.URL
+	deleted map[string]*models.URL // soft-deleted URLs, restorable
+	seq     int64
 }
 
 func NewInMemoryURLRepository() *InMemoryURLRepository {
 	return &InMemoryURLRepository{
-		urls: make(map[string]*models.URL),
+		urls:    make(map[string]*models.URL),
+		deleted: make(map[string]*models.URL),
 	}
 }
 
@@ -45,7 +53,7 @@ func (r *InMemoryURLRepository) Create(ctx context.Context, create *models.URLCr
 	defer r.mu.Unlock()
 
 	// Check for duplicate
-	if _, exists := r.urls[create.ShortCode]; exists {
+	if r.existsLocked(create.ShortCode) {
 		return nil, errors.New("duplicate short code")
 	}
 
@@ -57,11 +65,37 @@ func (r *InMemoryURLRepository) Create(ctx context.Context, create *models.URLCr
 		CreatedAt:   time.Now(),
 		ExpiresAt:   create.ExpiresAt,
 		ClickCount:  0,
+		Permanent:   create.Permanent,
+		MaxClicks:   create.MaxClicks,
+		ShowPreview: create.ShowPreview,
+
+		PasswordHash: create.PasswordHash,
+		AppendParams: create.AppendParams,
+
+		PlatformTargets: create.PlatformTargets,
+		Tags:            create.Tags,
 	}
 	r.urls[create.ShortCode] = url
 	return url, nil
 }
 
+func (r *InMemoryURLRepository) CreateBatch(ctx context.Context, creates []*models.URLCreate) ([]*models.URL, error) {
+	for _, create := range creates {
+		if err := create.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	urls := make([]*models.URL, len(creates))
+	for i, create := range creates {
+		url, err := r.Create(ctx, create)
+		if err == nil {
+			urls[i] = url
+		}
+	}
+	return urls, nil
+}
+
 func (r *InMemoryURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -70,7 +104,23 @@ func (r *InMemoryURLRepository) GetByShortCode(ctx context.Context, shortCode st
 	if !exists {
 		return nil, models.ErrURLNotFound
 	}
-	return url, nil
+	// Return a copy so callers never race with concurrent click updates
+	copied := *url
+	return &copied, nil
+}
+
+func (r *InMemoryURLRepository) GetByShortCodes(ctx context.Context, shortCodes []string) ([]*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var urls []*models.URL
+	for _, shortCode := range shortCodes {
+		if url, exists := r.urls[shortCode]; exists {
+			copied := *url
+			urls = append(urls, &copied)
+		}
+	}
+	return urls, nil
 }
 
 func (r *InMemoryURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
@@ -85,17 +135,118 @@ func (r *InMemoryURLRepository) GetByID(ctx context.Context, id int64) (*models.
 	return nil, models.ErrURLNotFound
 }
 
+func (r *InMemoryURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var oldest *models.URL
+	for _, url := range r.urls {
+		plain := url.ExpiresAt == nil && url.PasswordHash == "" && url.MaxClicks == nil &&
+			!url.Permanent && !url.ShowPreview && !url.Disabled &&
+			len(url.AppendParams) == 0 && len(url.PlatformTargets) == 0 && len(url.Tags) == 0
+		if url.OriginalURL == originalURL && plain && (oldest == nil || url.ID < oldest.ID) {
+			oldest = url
+		}
+	}
+	if oldest == nil {
+		return nil, models.ErrURLNotFound
+	}
+	return oldest, nil
+}
+
 func (r *InMemoryURLRepository) Delete(ctx context.Context, shortCode string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.urls[shortCode]; !exists {
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	delete(r.urls, shortCode)
+	r.deleted[shortCode] = url
+	return nil
+}
+
+func (r *InMemoryURLRepository) DeleteBatch(ctx context.Context, shortCodes []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted []string
+	for _, shortCode := range shortCodes {
+		url, exists := r.urls[shortCode]
+		if !exists {
+			continue
+		}
+		delete(r.urls, shortCode)
+		r.deleted[shortCode] = url
+		deleted = append(deleted, shortCode)
+	}
+	return deleted, nil
+}
+
+func (r *InMemoryURLRepository) Restore(ctx context.Context, shortCode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.deleted[shortCode]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	delete(r.deleted, shortCode)
+	r.urls[shortCode] = url
+	return nil
+}
+
+func (r *InMemoryURLRepository) DeletePermanent(ctx context.Context, shortCode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.existsLocked(shortCode) {
 		return models.ErrURLNotFound
 	}
 	delete(r.urls, shortCode)
+	delete(r.deleted, shortCode)
+	return nil
+}
+
+func (r *InMemoryURLRepository) UpdateOriginalURL(ctx context.Context, shortCode, newURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	url.OriginalURL = newURL
 	return nil
 }
 
+func (r *InMemoryURLRepository) UpdateExpiry(ctx context.Context, shortCode string, expiresAt time.Time) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return nil, models.ErrURLNotFound
+	}
+	url.ExpiresAt = &expiresAt
+	copied := *url
+	return &copied, nil
+}
+
+func (r *InMemoryURLRepository) SetActive(ctx context.Context, shortCode string, active bool) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return nil, models.ErrURLNotFound
+	}
+	url.Disabled = !active
+	copied := *url
+	return &copied, nil
+}
+
 func (r *InMemoryURLRepository) IncrementClickCount(ctx context.Context, shortCode string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -108,39 +259,140 @@ func (r *InMemoryURLRepository) IncrementClickCount(ctx context.Context, shortCo
 	return nil
 }
 
-func (r *InMemoryURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) error {
+func (r *InMemoryURLRepository) ClaimClick(ctx context.Context, shortCode string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, exists := r.urls[shortCode]
+	if !exists {
+		return 0, models.ErrURLNotFound
+	}
+	if url.MaxClicks != nil && url.ClickCount >= *url.MaxClicks {
+		return 0, models.ErrURLExhausted
+	}
+	url.ClickCount++
+	return url.ClickCount, nil
+}
+
+func (r *InMemoryURLRepository) BatchIncrementClickCounts(ctx context.Context, counts map[string]int64) (map[string]int64, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	totals := make(map[string]int64, len(counts))
 	for shortCode, count := range counts {
 		if url, exists := r.urls[shortCode]; exists {
 			url.ClickCount += count
+			totals[shortCode] = url.ClickCount
 		}
 	}
-	return nil
+	return totals, nil
 }
 
-func (r *InMemoryURLRepository) DeleteExpired(ctx context.Context) (int64, error) {
+func (r *InMemoryURLRepository) DeleteExpired(ctx context.Context) ([]string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	var count int64
+	var deleted []string
 	now := time.Now()
-	for code, url := range r.urls {
-		if url.ExpiresAt != nil && url.ExpiresAt.Before(now) {
-			delete(r.urls, code)
-			count++
+	for _, urls := range []map[string]*models.URL{r.urls, r.deleted} {
+		for code, url := range urls {
+			if url.ExpiresAt != nil && url.ExpiresAt.Before(now) {
+				delete(urls, code)
+				deleted = append(deleted, code)
+			}
 		}
 	}
-	return count, nil
+	return deleted, nil
+}
+
+func (r *InMemoryURLRepository) List(ctx context.Context, limit, offset int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	matches := make([]*models.URL, 0, len(r.urls))
+	for _, url := range r.urls {
+		if filter.Matches(url, now) {
+			copied := *url
+			matches = append(matches, &copied)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID > matches[j].ID
+	})
+
+	total := int64(len(matches))
+	if offset >= len(matches) {
+		return []*models.URL{}, total, nil
+	}
+	end := offset + limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[offset:end], total, nil
+}
+
+func (r *InMemoryURLRepository) ListAfter(ctx context.Context, afterID int64, limit int, filter repository.ListFilter) ([]*models.URL, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	urls := make([]*models.URL, 0, len(r.urls))
+	for _, url := range r.urls {
+		if url.ID > afterID && filter.Matches(url, now) {
+			copied := *url
+			urls = append(urls, &copied)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].ID < urls[j].ID
+	})
+
+	if len(urls) <= limit {
+		return urls, 0, nil
+	}
+	urls = urls[:limit]
+	return urls, urls[limit-1].ID, nil
+}
+
+func (r *InMemoryURLRepository) TopByClicks(ctx context.Context, limit int) ([]*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	urls := make([]*models.URL, 0, len(r.urls))
+	for _, url := range r.urls {
+		copied := *url
+		urls = append(urls, &copied)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if urls[i].ClickCount != urls[j].ClickCount {
+			return urls[i].ClickCount > urls[j].ClickCount
+		}
+		return urls[i].ID < urls[j].ID
+	})
+
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
 }
 
 func (r *InMemoryURLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	_, exists := r.urls[shortCode]
-	return exists, nil
+	return r.existsLocked(shortCode), nil
+}
+
+// existsLocked reports whether shortCode is taken, including by a
+// soft-deleted URL. The caller must hold r.mu.
+func (r *InMemoryURLRepository) existsLocked(shortCode string) bool {
+	_, live := r.urls[shortCode]
+	_, deleted := r.deleted[shortCode]
+	return live || deleted
 }
 
 func (r *InMemoryURLRepository) HealthCheck(ctx context.Context) error {
@@ -150,6 +402,12 @@ func (r *InMemoryURLRepository) HealthCheck(ctx context.Context) error {
 // testServerWithURLAPI creates a test server with URL API configured.
 func testServerWithURLAPI(t *testing.T) (*server.Server, string, func()) {
 	t.Helper()
+	return testServerWithURLAPIAt(t, "")
+}
+
+// testServerWithURLAPIAt starts a URL API server mounted under basePath.
+func testServerWithURLAPIAt(t *testing.T, basePath string) (*server.Server, string, func()) {
+	t.Helper()
 
 	cfg := &config.Config{
 		App: config.AppConfig{
@@ -162,6 +420,7 @@ func testServerWithURLAPI(t *testing.T) (*server.Server, string, func()) {
 			ReadTimeout:     5 * time.Second,
 			WriteTimeout:    10 * time.Second,
 			ShutdownTimeout: 5 * time.Second,
+			BasePath:        basePath,
 		},
 		URL: config.URLConfig{
 			BaseURL:      "http://localhost:8080",
@@ -182,9 +441,10 @@ func testServerWithURLAPI(t *testing.T) (*server.Server, string, func()) {
 	collisionGen := idgen.NewCollisionAwareGenerator(baseGen, repo, 3)
 
 	// Create URL service and handler
-	urlService := services.NewURLService(repo, collisionGen, cfg.URL.BaseURL)
+	urlService := services.NewURLService(repo, collisionGen, cfg.PublicBaseURL())
 	urlHandler := handlers.NewURLHandler(urlService)
 	srv.SetURLHandler(urlHandler)
+	srv.SetQRHandler(handlers.NewQRHandler(urlService, cfg.PublicBaseURL()))
 
 	// Create redirect service and handler
 	redirectService := services.NewRedirectService(repo)
@@ -387,6 +647,37 @@ func TestE2E_GetURL(t *testing.T) {
 	})
 }
 
+func TestE2E_QRCode(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	t.Run("GET /api/v1/urls/:code/qr returns a PNG for created URL", func(t *testing.T) {
+		reqBody := handlers.ShortenRequest{
+			URL: "https://example.com/qrtest",
+		}
+		createResp := httpPost(t, baseURL+"/api/v1/shorten", reqBody)
+		require.Equal(t, http.StatusCreated, createResp.StatusCode)
+
+		var shortenResp handlers.ShortenResponse
+		err := json.NewDecoder(createResp.Body).Decode(&shortenResp)
+		createResp.Body.Close()
+		require.NoError(t, err)
+
+		resp := httpGet(t, baseURL+"/api/v1/urls/"+shortenResp.ShortCode+"/qr")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("GET /api/v1/urls/:code/qr returns 404 for non-existent URL", func(t *testing.T) {
+		resp := httpGet(t, baseURL+"/api/v1/urls/notfound123/qr")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
 func TestE2E_DeleteURL(t *testing.T) {
 	_, baseURL, cleanup := testServerWithURLAPI(t)
 	defer cleanup()
@@ -428,6 +719,291 @@ func TestE2E_DeleteURL(t *testing.T) {
 	})
 }
 
+func TestE2E_DeleteBatch(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	var codes []string
+	for _, target := range []string{"https://example.com/campaign-a", "https://example.com/campaign-b"} {
+		createResp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{URL: target})
+		require.Equal(t, http.StatusCreated, createResp.StatusCode)
+		var shortenResp handlers.ShortenResponse
+		err := json.NewDecoder(createResp.Body).Decode(&shortenResp)
+		createResp.Body.Close()
+		require.NoError(t, err)
+		codes = append(codes, shortenResp.ShortCode)
+	}
+
+	resp := httpPost(t, baseURL+"/api/v1/urls/batch-delete", []string{codes[0], "notfound789", codes[1]})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+
+	var batchResp handlers.BatchDeleteResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&batchResp))
+	assert.Equal(t, 2, batchResp.Succeeded)
+	assert.Equal(t, 1, batchResp.Failed)
+	require.Len(t, batchResp.Results, 3)
+	assert.Equal(t, http.StatusOK, batchResp.Results[0].Status)
+	assert.Equal(t, http.StatusNotFound, batchResp.Results[1].Status)
+	assert.Equal(t, http.StatusOK, batchResp.Results[2].Status)
+
+	for _, code := range codes {
+		redirectResp := httpGetNoRedirect(t, baseURL+"/"+code)
+		redirectResp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, redirectResp.StatusCode)
+	}
+
+	// Batch-deleted URLs can be restored like single deletes
+	restoreResp := httpPost(t, baseURL+"/api/v1/urls/"+codes[0]+"/restore", nil)
+	restoreResp.Body.Close()
+	assert.Equal(t, http.StatusOK, restoreResp.StatusCode)
+}
+
+func TestE2E_DeleteRestoreRedirect(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	createResp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+		URL: "https://example.com/restore",
+	})
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var shortenResp handlers.ShortenResponse
+	err := json.NewDecoder(createResp.Body).Decode(&shortenResp)
+	createResp.Body.Close()
+	require.NoError(t, err)
+	code := shortenResp.ShortCode
+
+	// Soft delete stops the redirect
+	resp := httpDelete(t, baseURL+"/api/v1/urls/"+code)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = httpGetNoRedirect(t, baseURL+"/"+code)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The short code stays reserved while deleted
+	resp = httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+		URL:         "https://example.com/other",
+		CustomAlias: code,
+	})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Restore brings it back
+	resp = httpPost(t, baseURL+"/api/v1/urls/"+code+"/restore", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var info handlers.URLInfoResponse
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/restore", info.OriginalURL)
+
+	resp = httpGetNoRedirect(t, baseURL+"/"+code)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://example.com/restore", resp.Header.Get("Location"))
+
+	// Restoring a live URL is a 404
+	resp = httpPost(t, baseURL+"/api/v1/urls/"+code+"/restore", nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Permanent deletion cannot be undone
+	resp = httpDelete(t, baseURL+"/api/v1/urls/"+code+"?permanent=true")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = httpPost(t, baseURL+"/api/v1/urls/"+code+"/restore", nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestE2E_DisableEnableRedirect(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	createResp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+		URL: "https://example.com/paused",
+	})
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var shortenResp handlers.ShortenResponse
+	err := json.NewDecoder(createResp.Body).Decode(&shortenResp)
+	createResp.Body.Close()
+	require.NoError(t, err)
+	code := shortenResp.ShortCode
+
+	// Disabling stops the redirect but keeps the URL visible
+	resp := httpPost(t, baseURL+"/api/v1/urls/"+code+"/disable", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var info handlers.URLInfoResponse
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+
+	resp = httpGetNoRedirect(t, baseURL+"/"+code)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = httpGet(t, baseURL+"/api/v1/urls/"+code)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+
+	// Enabling brings the redirect back
+	resp = httpPost(t, baseURL+"/api/v1/urls/"+code+"/enable", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.True(t, info.Active)
+
+	resp = httpGetNoRedirect(t, baseURL+"/"+code)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://example.com/paused", resp.Header.Get("Location"))
+
+	resp = httpPost(t, baseURL+"/api/v1/urls/missing1/disable", nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestE2E_ListURLs(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	for _, target := range []string{
+		"https://example.com/list-alpha",
+		"https://example.com/list-beta",
+		"https://example.com/list-gamma",
+	} {
+		resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{URL: target})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	list := func(t *testing.T, query string) handlers.ListURLsResponse {
+		t.Helper()
+		resp, err := http.Get(baseURL + "/api/v1/urls" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var listResp handlers.ListURLsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listResp))
+		return listResp
+	}
+
+	t.Run("paginates", func(t *testing.T) {
+		first := list(t, "?limit=2")
+		assert.Equal(t, int64(3), first.Total)
+		assert.Len(t, first.Items, 2)
+
+		second := list(t, "?limit=2&offset=2")
+		assert.Equal(t, int64(3), second.Total)
+		require.Len(t, second.Items, 1)
+		assert.NotContains(t, []string{first.Items[0].ShortCode, first.Items[1].ShortCode}, second.Items[0].ShortCode)
+	})
+
+	t.Run("searches", func(t *testing.T) {
+		resp := list(t, "?search=GAMMA")
+		assert.Equal(t, int64(1), resp.Total)
+		require.Len(t, resp.Items, 1)
+		assert.Equal(t, "https://example.com/list-gamma", resp.Items[0].OriginalURL)
+	})
+
+	t.Run("filters by status", func(t *testing.T) {
+		assert.Equal(t, int64(3), list(t, "?status=active").Total)
+		assert.Equal(t, int64(0), list(t, "?status=expired").Total)
+	})
+}
+
+func TestE2E_ListURLsCursor(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	var created []string
+	for i := 0; i < 5; i++ {
+		resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{URL: "https://example.com/cursor-" + strconv.Itoa(i)})
+		var shortenResp handlers.ShortenResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&shortenResp))
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		created = append(created, shortenResp.ShortCode)
+	}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination did not terminate")
+
+		resp, err := http.Get(baseURL + "/api/v1/urls?limit=2&cursor=" + cursor)
+		require.NoError(t, err)
+		var listResp handlers.ListURLsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listResp))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		for _, item := range listResp.Items {
+			seen = append(seen, item.ShortCode)
+		}
+		if listResp.NextCursor == 0 {
+			break
+		}
+		cursor = strconv.FormatInt(listResp.NextCursor, 10)
+	}
+
+	// Every URL appears exactly once
+	assert.ElementsMatch(t, created, seen)
+}
+
+func TestE2E_ExportURLs(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	for _, target := range []string{"https://example.com/export-a", "https://example.com/export-b"} {
+		resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{URL: target})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/api/v1/urls/export?format=csv")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, "short_code", records[0][0])
+		assert.ElementsMatch(t,
+			[]string{"https://example.com/export-a", "https://example.com/export-b"},
+			[]string{records[1][1], records[2][1]})
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/api/v1/urls/export?format=json")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		decoder := json.NewDecoder(resp.Body)
+		count := 0
+		for decoder.More() {
+			var record handlers.ExportRecord
+			require.NoError(t, decoder.Decode(&record))
+			assert.NotEmpty(t, record.ShortCode)
+			count++
+		}
+		assert.Equal(t, 2, count)
+	})
+}
+
 func TestE2E_URLFlow_CreateRetrieveDelete(t *testing.T) {
 	_, baseURL, cleanup := testServerWithURLAPI(t)
 	defer cleanup()
@@ -608,6 +1184,351 @@ func TestE2E_Redirect(t *testing.T) {
 	})
 }
 
+func TestE2E_BasePath(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPIAt(t, "/shortener")
+	defer cleanup()
+
+	createResp := httpPost(t, baseURL+"/shortener/api/v1/shorten", handlers.ShortenRequest{
+		URL: "https://example.com/base-path",
+	})
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+
+	var shortenResp handlers.ShortenResponse
+	err := json.NewDecoder(createResp.Body).Decode(&shortenResp)
+	createResp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/shortener/"+shortenResp.ShortCode, shortenResp.ShortURL)
+
+	t.Run("short URL path redirects", func(t *testing.T) {
+		shortURL, err := url.Parse(shortenResp.ShortURL)
+		require.NoError(t, err)
+
+		resp := httpGetNoRedirect(t, baseURL+shortURL.Path)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://example.com/base-path", resp.Header.Get("Location"))
+	})
+
+	t.Run("unprefixed paths are not served", func(t *testing.T) {
+		resp := httpGetNoRedirect(t, baseURL+"/"+shortenResp.ShortCode)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{URL: "https://example.com"})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("info lookup under prefix", func(t *testing.T) {
+		resp := httpGetNoRedirect(t, baseURL+"/shortener/api/v1/urls/"+shortenResp.ShortCode)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestE2E_RedirectPreview(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	shorten := func(t *testing.T, req handlers.ShortenRequest) string {
+		t.Helper()
+		resp := httpPost(t, baseURL+"/api/v1/shorten", req)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		defer resp.Body.Close()
+
+		var shortenResp handlers.ShortenResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&shortenResp))
+		return shortenResp.ShortCode
+	}
+
+	t.Run("returns metadata without counting a click", func(t *testing.T) {
+		code := shorten(t, handlers.ShortenRequest{URL: "https://example.com/preview-test"})
+
+		for i := 0; i < 3; i++ {
+			resp := httpGetNoRedirect(t, baseURL+"/"+code+"?preview=true")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("Location"))
+
+			var preview handlers.PreviewResponse
+			err := json.NewDecoder(resp.Body).Decode(&preview)
+			resp.Body.Close()
+			require.NoError(t, err)
+			assert.Equal(t, code, preview.ShortCode)
+			assert.Equal(t, "https://example.com/preview-test", preview.OriginalURL)
+		}
+
+		infoResp := httpGet(t, baseURL+"/api/v1/urls/"+code)
+		defer infoResp.Body.Close()
+		var urlInfo handlers.URLInfoResponse
+		require.NoError(t, json.NewDecoder(infoResp.Body).Decode(&urlInfo))
+		assert.Equal(t, int64(0), urlInfo.ClickCount)
+	})
+
+	t.Run("requires the password of protected links", func(t *testing.T) {
+		code := shorten(t, handlers.ShortenRequest{URL: "https://example.com/secret", Password: "s3cret"})
+
+		resp := httpGetNoRedirect(t, baseURL+"/"+code+"?preview=true")
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NotContains(t, string(body), "example.com/secret")
+
+		resp = httpGetNoRedirect(t, baseURL+"/"+code+"?preview=true&password=s3cret")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("returns 410 for expired URL", func(t *testing.T) {
+		code := shorten(t, handlers.ShortenRequest{URL: "https://example.com/expired-preview", ExpiresIn: "1ms"})
+		time.Sleep(10 * time.Millisecond)
+
+		resp := httpGetNoRedirect(t, baseURL+"/"+code+"?preview=true")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusGone, resp.StatusCode)
+	})
+
+	t.Run("show_preview links count the click on continue", func(t *testing.T) {
+		code := shorten(t, handlers.ShortenRequest{URL: "https://example.com/interstitial", ShowPreview: true})
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, baseURL+"/"+code, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "text/html")
+		resp, err := noRedirectClient().Do(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "https://example.com/interstitial")
+		assert.Contains(t, string(body), `"`+code+"?preview=false")
+
+		resp = httpGetNoRedirect(t, baseURL+"/"+code+"?preview=false")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://example.com/interstitial", resp.Header.Get("Location"))
+
+		infoResp := httpGet(t, baseURL+"/api/v1/urls/"+code)
+		defer infoResp.Body.Close()
+		var urlInfo handlers.URLInfoResponse
+		require.NoError(t, json.NewDecoder(infoResp.Body).Decode(&urlInfo))
+		assert.True(t, urlInfo.ShowPreview)
+		assert.Equal(t, int64(1), urlInfo.ClickCount)
+	})
+}
+
+func TestE2E_RedirectAppendParams(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+		URL:          "https://example.com/landing?ref=home#signup",
+		AppendParams: map[string]string{"utm_source": "newsletter", "ref": "campaign"},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var shortenResp handlers.ShortenResponse
+	err := json.NewDecoder(resp.Body).Decode(&shortenResp)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "newsletter", shortenResp.AppendParams["utm_source"])
+
+	redirectResp := httpGetNoRedirect(t, baseURL+"/"+shortenResp.ShortCode)
+	defer redirectResp.Body.Close()
+
+	assert.Equal(t, http.StatusFound, redirectResp.StatusCode)
+	assert.Equal(t, "https://example.com/landing?ref=home&utm_source=newsletter#signup", redirectResp.Header.Get("Location"))
+}
+
+func TestE2E_ShortenWithExpiresAt(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	t.Run("uses the absolute expiry", func(t *testing.T) {
+		expiresAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+		resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+			URL:       "https://example.com/launch",
+			ExpiresIn: "1h",
+			ExpiresAt: expiresAt.Format(time.RFC3339),
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var shortenResp handlers.ShortenResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&shortenResp))
+		require.NotNil(t, shortenResp.ExpiresAt)
+		assert.Equal(t, expiresAt.Format(time.RFC3339), *shortenResp.ExpiresAt)
+	})
+
+	t.Run("rejects a past expiry", func(t *testing.T) {
+		resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+			URL:       "https://example.com/too-late",
+			ExpiresAt: time.Now().Add(-time.Hour).Format(time.RFC3339),
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, "INVALID_EXPIRES_AT", errResp.Code)
+	})
+}
+
+func TestE2E_Tags(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+		URL:  "https://example.com/summer-sale",
+		Tags: []string{"Promo", "summer-2024", "promo"},
+	})
+	var shortenResp handlers.ShortenResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&shortenResp))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{"promo", "summer-2024"}, shortenResp.Tags)
+
+	resp = httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{URL: "https://example.com/untagged"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("filters list by tag", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/api/v1/urls?tag=PROMO")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var listResp handlers.ListURLsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listResp))
+		assert.Equal(t, int64(1), listResp.Total)
+		require.Len(t, listResp.Items, 1)
+		assert.Equal(t, shortenResp.ShortCode, listResp.Items[0].ShortCode)
+		assert.Equal(t, []string{"promo", "summer-2024"}, listResp.Items[0].Tags)
+	})
+
+	t.Run("rejects invalid tags", func(t *testing.T) {
+		resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+			URL:  "https://example.com/bad",
+			Tags: []string{"no spaces"},
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, "INVALID_TAG", errResp.Code)
+	})
+}
+
+func TestE2E_RedirectPlatformTargets(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	resp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+		URL: "https://example.com/app",
+		PlatformTargets: map[string]string{
+			"ios":     "https://apps.apple.com/app/id123",
+			"android": "https://play.google.com/store/apps/details?id=com.example",
+		},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var shortenResp handlers.ShortenResponse
+	err := json.NewDecoder(resp.Body).Decode(&shortenResp)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Len(t, shortenResp.PlatformTargets, 2)
+
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) Mobile/15E148 Safari/604.1", "https://apps.apple.com/app/id123"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) Chrome/120.0.0.0 Mobile Safari/537.36", "https://play.google.com/store/apps/details?id=com.example"},
+		{"Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0", "https://example.com/app"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, baseURL+"/"+shortenResp.ShortCode, nil)
+		require.NoError(t, err)
+		req.Header.Set("User-Agent", tt.userAgent)
+		redirectResp, err := noRedirectClient().Do(req)
+		require.NoError(t, err)
+		redirectResp.Body.Close()
+
+		assert.Equal(t, http.StatusFound, redirectResp.StatusCode)
+		assert.Equal(t, tt.want, redirectResp.Header.Get("Location"))
+	}
+
+	infoResp := httpGet(t, baseURL+"/api/v1/urls/"+shortenResp.ShortCode)
+	defer infoResp.Body.Close()
+	var urlInfo handlers.URLInfoResponse
+	require.NoError(t, json.NewDecoder(infoResp.Body).Decode(&urlInfo))
+	assert.Equal(t, int64(len(tests)), urlInfo.ClickCount)
+}
+
+func TestE2E_SingleUseRedirect(t *testing.T) {
+	_, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	createSingleUse := func(t *testing.T, target string) string {
+		t.Helper()
+		maxClicks := int64(1)
+		createResp := httpPost(t, baseURL+"/api/v1/shorten", handlers.ShortenRequest{
+			URL:       target,
+			MaxClicks: &maxClicks,
+		})
+		require.Equal(t, http.StatusCreated, createResp.StatusCode)
+
+		var shortenResp handlers.ShortenResponse
+		err := json.NewDecoder(createResp.Body).Decode(&shortenResp)
+		createResp.Body.Close()
+		require.NoError(t, err)
+		require.NotNil(t, shortenResp.MaxClicks)
+		return shortenResp.ShortCode
+	}
+
+	t.Run("second redirect returns 410", func(t *testing.T) {
+		code := createSingleUse(t, "https://example.com/once")
+
+		resp := httpGetNoRedirect(t, baseURL+"/"+code)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://example.com/once", resp.Header.Get("Location"))
+
+		resp = httpGetNoRedirect(t, baseURL+"/"+code)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusGone, resp.StatusCode)
+	})
+
+	t.Run("concurrent redirects race for the last click", func(t *testing.T) {
+		code := createSingleUse(t, "https://example.com/race")
+
+		const numRequests = 20
+		var wg sync.WaitGroup
+		statuses := make(chan int, numRequests)
+
+		for i := 0; i < numRequests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := noRedirectClient().Get(baseURL + "/" + code)
+				if err != nil {
+					return
+				}
+				resp.Body.Close()
+				statuses <- resp.StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		counts := make(map[int]int)
+		for status := range statuses {
+			counts[status]++
+		}
+		assert.Equal(t, 1, counts[http.StatusFound], "exactly one redirect should succeed")
+		assert.Equal(t, numRequests-1, counts[http.StatusGone])
+	})
+}
+
 func TestE2E_RedirectLatency(t *testing.T) {
 	_, baseURL, cleanup := testServerWithURLAPI(t)
 	defer cleanup()
@@ -699,3 +1620,49 @@ func TestE2E_RedirectFullFlow(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, finalResp.StatusCode)
 	})
 }
+
+func TestE2E_PurgeExpired(t *testing.T) {
+	srv, baseURL, cleanup := testServerWithURLAPI(t)
+	defer cleanup()
+
+	// The API refuses past expiries, so expired links go straight to the repository
+	ctx := context.Background()
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	for _, create := range []*models.URLCreate{
+		{ShortCode: "expired1", OriginalURL: "https://example.com/1", ExpiresAt: &past},
+		{ShortCode: "expired2", OriginalURL: "https://example.com/2", ExpiresAt: &past},
+		{ShortCode: "active1", OriginalURL: "https://example.com/3", ExpiresAt: &future},
+		{ShortCode: "active2", OriginalURL: "https://example.com/4"},
+	} {
+		_, err := srv.URLRepository().Create(ctx, create)
+		require.NoError(t, err)
+	}
+
+	resp := httpPost(t, baseURL+"/api/v1/admin/purge-expired", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var purgeResp handlers.PurgeExpiredResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&purgeResp))
+	resp.Body.Close()
+	assert.Equal(t, int64(2), purgeResp.Deleted)
+
+	for code, want := range map[string]int{
+		"expired1": http.StatusNotFound,
+		"expired2": http.StatusNotFound,
+		"active1":  http.StatusOK,
+		"active2":  http.StatusOK,
+	} {
+		resp, err := http.Get(baseURL + "/api/v1/urls/" + code)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, want, resp.StatusCode, code)
+	}
+
+	t.Run("second purge finds nothing", func(t *testing.T) {
+		resp := httpPost(t, baseURL+"/api/v1/admin/purge-expired", nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var purgeResp handlers.PurgeExpiredResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&purgeResp))
+		assert.Zero(t, purgeResp.Deleted)
+	})
+}